@@ -0,0 +1,292 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// This file writes out/soong/affected_tests.json when SOONG_CHANGED_FILES_LIST names a file that
+// lists the files changed by a change, one per line, relative to the top of the tree, e.g.
+// `SOONG_CHANGED_FILES_LIST=changed.txt m nothing`. It lists, for each test group of the
+// TEST_MAPPING files of the tree, the tests affected by the change, and the targets to build to
+// run them, so that CI doesn't have to discover the tests outside of the build.
+//
+// A test that is defined by a Soong module is affected if the module or any of the modules it
+// transitively depends on is defined in the package of a changed file. Other tests are affected if
+// a changed file is in the directory of the TEST_MAPPING file that lists them, or in a directory
+// the TEST_MAPPING file imports, as with the TEST_MAPPING discovery outside of the build.
+
+// AffectedTestsFileName is the name of the file in the soong output directory that lists the tests
+// affected by the changed files.
+const AffectedTestsFileName = "affected_tests.json"
+
+func init() {
+	RegisterAffectedTestsBuildComponents(InitRegistrationContext)
+}
+
+func RegisterAffectedTestsBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("affected_tests", affectedTestsSingletonFactory)
+}
+
+var PrepareForTestWithAffectedTests = FixtureRegisterWithContext(RegisterAffectedTestsBuildComponents)
+
+// testMapping is the subset of the TEST_MAPPING format that is used: the tests of each group, e.g.
+// presubmit, and the directories whose TEST_MAPPING files are imported.
+type testMapping struct {
+	dir     string
+	groups  map[string][]string
+	imports []string
+}
+
+// parseTestMapping parses a TEST_MAPPING file, which is JSON with // comments on their own lines.
+func parseTestMapping(dir string, data []byte) (*testMapping, error) {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			lines = append(lines, line)
+		}
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &raw); err != nil {
+		return nil, err
+	}
+
+	m := &testMapping{dir: dir, groups: make(map[string][]string)}
+	for group, value := range raw {
+		if group == "imports" {
+			var imports []struct {
+				Path string `json:"path"`
+			}
+			if err := json.Unmarshal(value, &imports); err != nil {
+				return nil, fmt.Errorf("invalid imports: %w", err)
+			}
+			for _, i := range imports {
+				m.imports = append(m.imports, filepath.Clean(i.Path))
+			}
+			continue
+		}
+		var tests []struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(value, &tests); err != nil {
+			return nil, fmt.Errorf("invalid test group %q: %w", group, err)
+		}
+		for _, t := range tests {
+			if t.Name != "" {
+				m.groups[group] = append(m.groups[group], t.Name)
+			}
+		}
+	}
+	return m, nil
+}
+
+type affectedTestsReport struct {
+	// The affected tests of each test group.
+	Tests map[string][]string `json:"tests"`
+	// The targets to build to run all the affected tests.
+	BuildTargets []string `json:"build_targets"`
+}
+
+func affectedTestsSingletonFactory() Singleton {
+	return &affectedTestsSingleton{}
+}
+
+type affectedTestsSingleton struct{}
+
+func (s *affectedTestsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	changedFilesList := ctx.Config().Getenv("SOONG_CHANGED_FILES_LIST")
+	if changedFilesList == "" {
+		return
+	}
+	readFile := func(file string) ([]byte, error) {
+		r, err := ctx.Config().fs.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		ctx.AddNinjaFileDeps(file)
+		return io.ReadAll(r)
+	}
+
+	data, err := readFile(changedFilesList)
+	if err != nil {
+		ctx.Errorf("failed to read the changed files: %s", err)
+		return
+	}
+	changedFiles := strings.Fields(string(data))
+
+	// The TEST_MAPPING files are found by soong_ui next to the list of Android.bp files.
+	data, err = readFile(filepath.Join(filepath.Dir(ctx.Config().moduleListFile), "TEST_MAPPING.list"))
+	if err != nil {
+		ctx.Errorf("failed to read the list of TEST_MAPPING files: %s", err)
+		return
+	}
+	var mappings []*testMapping
+	for _, file := range strings.Fields(string(data)) {
+		content, err := readFile(file)
+		if err != nil {
+			ctx.Errorf("failed to read %s: %s", file, err)
+			continue
+		}
+		m, err := parseTestMapping(filepath.Dir(file), content)
+		if err != nil {
+			ctx.Errorf("failed to parse %s: %s", file, err)
+			continue
+		}
+		mappings = append(mappings, m)
+	}
+
+	// The modules defined in the packages of the changed files, and the modules that transitively
+	// depend on them.
+	var modules []Module
+	byName := make(map[string][]Module)
+	packages := make(map[string]bool)
+	ctx.VisitAllModules(func(module Module) {
+		modules = append(modules, module)
+		byName[ctx.ModuleName(module)] = append(byName[ctx.ModuleName(module)], module)
+		packages[ctx.ModuleDir(module)] = true
+	})
+	changedPackages := make(map[string]bool)
+	for _, file := range changedFiles {
+		for dir := filepath.Dir(filepath.Clean(file)); ; dir = filepath.Dir(dir) {
+			if packages[dir] {
+				changedPackages[dir] = true
+				break
+			}
+			if dir == "." || dir == "/" {
+				break
+			}
+		}
+	}
+	reverseDeps := make(map[Module][]Module)
+	affected := make(map[Module]bool)
+	var queue []Module
+	for _, module := range modules {
+		ctx.VisitDirectDeps(module, func(dep Module) {
+			reverseDeps[dep] = append(reverseDeps[dep], module)
+		})
+		if changedPackages[ctx.ModuleDir(module)] {
+			affected[module] = true
+			queue = append(queue, module)
+		}
+	}
+	for len(queue) > 0 {
+		module := queue[0]
+		queue = queue[1:]
+		for _, rdep := range reverseDeps[module] {
+			if !affected[rdep] {
+				affected[rdep] = true
+				queue = append(queue, rdep)
+			}
+		}
+	}
+
+	// Whether the files changed in the directory of each TEST_MAPPING file or the directories it
+	// imports.
+	underDir := func(dir string) bool {
+		for _, file := range changedFiles {
+			if dir == "." || strings.HasPrefix(filepath.Clean(file), dir+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	byDir := make(map[string]*testMapping)
+	for _, m := range mappings {
+		byDir[m.dir] = m
+	}
+	mappingAffected := func(m *testMapping) bool {
+		seen := make(map[string]bool)
+		var visit func(dir string) bool
+		visit = func(dir string) bool {
+			if seen[dir] {
+				return false
+			}
+			seen[dir] = true
+			if underDir(dir) {
+				return true
+			}
+			if imported := byDir[dir]; imported != nil {
+				for _, i := range imported.imports {
+					if visit(i) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+		return visit(m.dir)
+	}
+
+	tests := make(map[string]map[string]bool)
+	buildTargets := make(map[string]bool)
+	for _, m := range mappings {
+		dirAffected := mappingAffected(m)
+		for group, names := range m.groups {
+			for _, name := range names {
+				testAffected := dirAffected
+				if variants := byName[name]; len(variants) > 0 {
+					testAffected = false
+					for _, v := range variants {
+						testAffected = testAffected || affected[v]
+					}
+				}
+				if !testAffected {
+					continue
+				}
+				if tests[group] == nil {
+					tests[group] = make(map[string]bool)
+				}
+				tests[group][name] = true
+				buildTargets[name] = true
+			}
+		}
+	}
+
+	report := affectedTestsReport{
+		Tests:        make(map[string][]string),
+		BuildTargets: SortedKeys(buildTargets),
+	}
+	for group, names := range tests {
+		report.Tests[group] = SortedKeys(names)
+	}
+	if report.BuildTargets == nil {
+		report.BuildTargets = []string{}
+	}
+
+	data, err = json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the affected tests: %s", err)
+		return
+	}
+
+	// The report is written during analysis so that `m nothing` writes it.
+	path := PathForOutput(ctx, AffectedTestsFileName)
+	if err := WriteFileToOutputDir(path, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", path, err)
+		return
+	}
+
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: path,
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAffectedTests(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithAffectedTests,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("component", componentTestModuleFactory)
+		}),
+		FixtureMergeEnv(map[string]string{
+			"SOONG_CHANGED_FILES_LIST": "changed_files.txt",
+		}),
+		MockFS{
+			"Android.bp": nil,
+			"foo/Android.bp": []byte(`
+				component {
+					name: "libfoo",
+				}
+			`),
+			"bar/Android.bp": []byte(`
+				component {
+					name: "libbar",
+				}
+			`),
+			"tests/footest/Android.bp": []byte(`
+				component {
+					name: "footest",
+					deps: ["libfoo"],
+				}
+			`),
+			"tests/bartest/Android.bp": []byte(`
+				component {
+					name: "bartest",
+					deps: ["libbar"],
+				}
+			`),
+			"tests/TEST_MAPPING": []byte(`{
+				// The tests of libfoo and libbar.
+				"presubmit": [
+					{"name": "footest"},
+					{"name": "bartest", "options": [{"include-filter": "BarTest"}]}
+				],
+				"postsubmit": [
+					{"name": "MakeTest"}
+				]
+			}`),
+			"bar/TEST_MAPPING": []byte(`{
+				"presubmit": [
+					{"name": "BarMakeTest"}
+				],
+				"imports": [
+					{"path": "tests"}
+				]
+			}`),
+			"TEST_MAPPING.list": []byte("tests/TEST_MAPPING\nbar/TEST_MAPPING\n"),
+			"changed_files.txt": []byte("foo/foo.c\ntests/README.md\n"),
+		}.AddToFixture(),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), AffectedTestsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report affectedTestsReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	AssertDeepEquals(t, "tests", map[string][]string{
+		"presubmit":  {"BarMakeTest", "footest"},
+		"postsubmit": {"MakeTest"},
+	}, report.Tests)
+	AssertArrayString(t, "build targets", []string{"BarMakeTest", "MakeTest", "footest"}, report.BuildTargets)
+}
+
+func TestParseTestMappingErrors(t *testing.T) {
+	_, err := parseTestMapping("foo", []byte(`{"presubmit": {"name": "footest"}}`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	AssertStringDoesContain(t, "error", err.Error(), `invalid test group "presubmit"`)
+}
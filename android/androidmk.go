@@ -392,8 +392,8 @@ func (a *AndroidMkEntries) getDistContributions(mod blueprint.Module) *distContr
 			continue
 		}
 
-		if len(tagPaths) > 1 && (dist.Dest != nil || dist.Suffix != nil) {
-			errorMessage := "%s: Cannot apply dest/suffix for more than one dist " +
+		if len(tagPaths) > 1 && dist.Dest != nil {
+			errorMessage := "%s: Cannot apply dest for more than one dist " +
 				"file for %q goals tag %q in module %s. The list of dist files, " +
 				"which should have a single element, is:\n%s"
 			panic(fmt.Errorf(errorMessage, mod, goals, tag, name, tagPaths))
@@ -412,11 +412,20 @@ func (a *AndroidMkEntries) getDistContributions(mod blueprint.Module) *distContr
 				panic(fmt.Errorf("Dist file should not be nil for the %s tag in %s", tagName, name))
 			}
 
+			// The variables were checked in ModuleBase.GenerateBuildActions.
+			expand := func(s string) string {
+				expanded, err := expandDistVariables(a.entryContext.Config(), name, s)
+				if err != nil {
+					panic(err)
+				}
+				return expanded
+			}
+
 			dest := filepath.Base(path.String())
 
 			if dist.Dest != nil {
 				var err error
-				if dest, err = validateSafePath(*dist.Dest); err != nil {
+				if dest, err = validateSafePath(expand(*dist.Dest)); err != nil {
 					// This was checked in ModuleBase.GenerateBuildActions
 					panic(err)
 				}
@@ -425,7 +434,7 @@ func (a *AndroidMkEntries) getDistContributions(mod blueprint.Module) *distContr
 			ext := filepath.Ext(dest)
 			suffix := ""
 			if dist.Suffix != nil {
-				suffix = *dist.Suffix
+				suffix = expand(*dist.Suffix)
 			}
 
 			productString := ""
@@ -439,7 +448,7 @@ func (a *AndroidMkEntries) getDistContributions(mod blueprint.Module) *distContr
 
 			if dist.Dir != nil {
 				var err error
-				if dest, err = validateSafePath(*dist.Dir, dest); err != nil {
+				if dest, err = validateSafePath(expand(*dist.Dir), dest); err != nil {
 					// This was checked in ModuleBase.GenerateBuildActions
 					panic(err)
 				}
@@ -789,4 +789,38 @@ func TestGetDistContributions(t *testing.T) {
 			},
 		},
 	})
+
+	testHelper(t, "dists-with-variables", `
+			custom {
+				name: "foo",
+				dists: [
+					{
+						targets: ["my_goal"],
+						dest: "$(module)-$(product).out",
+					},
+					{
+						targets: ["my_goal"],
+						tag: ".multiple",
+						dir: "$(module)",
+						suffix: "-$(product)",
+					},
+				],
+			}
+`, &distContributions{
+		copiesForGoals: []*copiesForGoals{
+			{
+				goals: "my_goal",
+				copies: []distCopy{
+					distCopyForTest("one.out", "foo-bar.out"),
+				},
+			},
+			{
+				goals: "my_goal",
+				copies: []distCopy{
+					distCopyForTest("two.out", "foo/two-bar.out"),
+					distCopyForTest("three/four.out", "foo/four-bar.out"),
+				},
+			},
+		},
+	})
 }
@@ -861,20 +861,20 @@ type ModuleWithMinSdkVersionCheck interface {
 }
 
 // CheckMinSdkVersion checks if every dependency of an updatable module sets min_sdk_version
-// accordingly
+// accordingly, except for the dependencies that are waived in the MinSdkVersionWaivers file.
 func CheckMinSdkVersion(ctx ModuleContext, minSdkVersion ApiLevel, walk WalkPayloadDepsFunc) {
-	// do not enforce min_sdk_version for host
-	if ctx.Host() {
+	if skipMinSdkVersionCheck(ctx) {
 		return
 	}
 
-	// do not enforce for coverage build
-	if ctx.Config().IsEnvTrue("EMMA_INSTRUMENT") || ctx.DeviceConfig().NativeCoverageEnabled() || ctx.DeviceConfig().ClangCoverageEnabled() {
+	// do not enforce deps.min_sdk_version if APEX/APK doesn't set min_sdk_version
+	if minSdkVersion.IsNone() {
 		return
 	}
 
-	// do not enforce deps.min_sdk_version if APEX/APK doesn't set min_sdk_version
-	if minSdkVersion.IsNone() {
+	waivers := getMinSdkVersionWaivers(ctx)
+	if waivers.err != nil {
+		ctx.ModuleErrorf("%s", waivers.err)
 		return
 	}
 
@@ -888,6 +888,9 @@ func CheckMinSdkVersion(ctx ModuleContext, minSdkVersion ApiLevel, walk WalkPayl
 		if am, ok := from.(DepIsInSameApex); ok && !am.DepIsInSameApex(ctx, to) {
 			return false
 		}
+		if waivers.waived(ctx.ModuleName(), ctx.OtherModuleName(to)) {
+			return false
+		}
 		if m, ok := to.(ModuleWithMinSdkVersionCheck); ok {
 			// This dependency performs its own min_sdk_version check, just make sure it sets min_sdk_version
 			// to trigger the check.
@@ -393,7 +393,9 @@ func ApiLevelsSingleton() Singleton {
 	return &apiLevelsSingleton{}
 }
 
-type apiLevelsSingleton struct{}
+type apiLevelsSingleton struct {
+	apiLevelsInfoJson WritablePath
+}
 
 func createApiLevelsJson(ctx SingletonContext, file WritablePath,
 	apiLevelsMap map[string]int) {
@@ -478,10 +480,56 @@ func GetApiLevelsMap(config Config) map[string]int {
 	}).(map[string]int)
 }
 
+// apiLevelsInfo describes the API levels of the platform, with the SDK versions of the finalized
+// codenames. It is written to out/soong/api_levels_info.json for the tools outside of the build.
+type apiLevelsInfo struct {
+	PlatformSdkVersion  int            `json:"platform_sdk_version"`
+	PlatformSdkCodename string         `json:"platform_sdk_codename"`
+	PlatformSdkFinal    bool           `json:"platform_sdk_final"`
+	ActiveCodenames     []string       `json:"active_codenames"`
+	PreviewCodenames    []string       `json:"preview_codenames"`
+	Codenames           map[string]int `json:"codenames"`
+}
+
+func GetApiLevelsInfoJson(ctx PathContext) WritablePath {
+	return PathForOutput(ctx, "api_levels_info.json")
+}
+
+func createApiLevelsInfoJson(ctx SingletonContext, file WritablePath) {
+	config := ctx.Config()
+	info := apiLevelsInfo{
+		PlatformSdkCodename: config.PlatformSdkCodename(),
+		PlatformSdkFinal:    config.PlatformSdkFinal(),
+		ActiveCodenames:     append([]string{}, config.PlatformVersionActiveCodenames()...),
+		PreviewCodenames:    append([]string{}, config.PlatformVersionAllPreviewCodenames()...),
+		Codenames:           getFinalCodenamesMap(config),
+	}
+	if config.RawPlatformSdkVersion() != nil {
+		info.PlatformSdkVersion = config.PlatformSdkVersion().FinalInt()
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write %s: %s", file, err)
+		return
+	}
+	WriteFileRuleVerbatim(ctx, file, string(data)+"\n")
+}
+
 func (a *apiLevelsSingleton) GenerateBuildActions(ctx SingletonContext) {
 	apiLevelsMap := GetApiLevelsMap(ctx.Config())
 	apiLevelsJson := GetApiLevelsJson(ctx)
 	createApiLevelsJson(ctx, apiLevelsJson, apiLevelsMap)
+
+	a.apiLevelsInfoJson = GetApiLevelsInfoJson(ctx)
+	createApiLevelsInfoJson(ctx, a.apiLevelsInfoJson)
+	ctx.Phony("api_levels_info", a.apiLevelsInfoJson)
+}
+
+func (a *apiLevelsSingleton) MakeVars(ctx MakeVarsContext) {
+	if a.apiLevelsInfoJson != nil {
+		ctx.DistForGoal("droidcore", a.apiLevelsInfoJson)
+	}
 }
 
 func StarlarkApiLevelConfigs(config Config) string {
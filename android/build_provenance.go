@@ -0,0 +1,267 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/blueprint"
+)
+
+// This file implements the build provenance of the dist artifacts, which is enabled by the
+// BuildProvenance product variable. For each artifact that Soong builds and that is disted through
+// the MakeVars of the singletons and the modules, gen_build_provenance writes an in-toto statement
+// with a SLSA v1 provenance predicate, listing the digests of the inputs of the artifact, the rule
+// and the arguments that built it, the versions of the toolchains and the environment of the build,
+// and signs it with the BuildProvenanceSigningKey into a DSSE envelope. The envelope is disted next
+// to the artifact as <name>.intoto.jsonl.
+//
+// The inputs are the direct inputs of the rule that built the artifact, which have their own
+// provenance when they are disted too. The artifacts disted through the dist properties of the
+// modules are not attested.
+
+var (
+	_ = pctx.HostBinToolVariable("genBuildProvenanceCmd", "gen_build_provenance")
+
+	genBuildProvenanceRule = pctx.AndroidStaticRule("genBuildProvenance", blueprint.RuleParams{
+		Command: "${genBuildProvenanceCmd} -key ${key} -build_number_file '${buildNumberFile}' " +
+			"-o $out $in",
+		CommandDeps: []string{"${genBuildProvenanceCmd}"},
+	}, "key", "buildNumberFile")
+)
+
+// The environment variables of the build recorded in the provenance of the dist artifacts.
+var buildProvenanceEnvVars = []string{
+	"TARGET_BUILD_VARIANT",
+	"TARGET_PRODUCT",
+	"TARGET_RELEASE",
+}
+
+type buildProvenanceToolchain struct {
+	name    string
+	version func(ctx PathContext) string
+}
+
+// Collection of the toolchains recorded in the provenance of the dist artifacts, that are
+// registered in init() methods.
+var buildProvenanceToolchains []buildProvenanceToolchain
+
+// RegisterBuildProvenanceToolchain registers a toolchain whose version is recorded in the provenance
+// of the dist artifacts. It must be called from an init() method.
+func RegisterBuildProvenanceToolchain(name string, version func(ctx PathContext) string) {
+	buildProvenanceToolchains = append(buildProvenanceToolchains, buildProvenanceToolchain{name, version})
+}
+
+// The rule and the direct inputs of an output of a build statement.
+type buildProvenanceRecord struct {
+	output WritablePath
+	rule   string
+	args   map[string]string
+	inputs Paths
+}
+
+type buildProvenanceRecords struct {
+	sync.Mutex
+	records map[string]buildProvenanceRecord
+}
+
+var buildProvenanceRecordsKey = NewOnceKey("buildProvenanceRecords")
+
+func getBuildProvenanceRecords(config Config) *buildProvenanceRecords {
+	return config.Once(buildProvenanceRecordsKey, func() interface{} {
+		return &buildProvenanceRecords{records: make(map[string]buildProvenanceRecord)}
+	}).(*buildProvenanceRecords)
+}
+
+// recordBuildProvenance records the rule and the inputs of the outputs of a build statement when
+// the build provenance is enabled, so that they can be attested if the outputs are disted.
+func recordBuildProvenance(config Config, params BuildParams) {
+	if !config.BuildProvenanceEnabled() {
+		return
+	}
+
+	var inputs Paths
+	if params.Input != nil {
+		inputs = append(inputs, params.Input)
+	}
+	inputs = append(inputs, params.Inputs...)
+	if params.Implicit != nil {
+		inputs = append(inputs, params.Implicit)
+	}
+	inputs = append(inputs, params.Implicits...)
+
+	var outputs WritablePaths
+	if params.Output != nil {
+		outputs = append(outputs, params.Output)
+	}
+	outputs = append(outputs, params.Outputs...)
+	if params.ImplicitOutput != nil {
+		outputs = append(outputs, params.ImplicitOutput)
+	}
+	outputs = append(outputs, params.ImplicitOutputs...)
+
+	record := buildProvenanceRecord{
+		rule:   params.Rule.String(),
+		args:   params.Args,
+		inputs: inputs,
+	}
+
+	records := getBuildProvenanceRecords(config)
+	records.Lock()
+	defer records.Unlock()
+	for _, output := range outputs {
+		record.output = output
+		records.records[output.String()] = record
+	}
+}
+
+// The parameters of gen_build_provenance for a dist artifact.
+type buildProvenanceParams struct {
+	Name        string            `json:"name"`
+	Subject     string            `json:"subject"`
+	Goals       []string          `json:"goals"`
+	Product     map[string]string `json:"product"`
+	Environment map[string]string `json:"environment"`
+	Rule        string            `json:"rule"`
+	Args        map[string]string `json:"args,omitempty"`
+	Inputs      []string          `json:"inputs"`
+	Toolchains  map[string]string `json:"toolchains,omitempty"`
+}
+
+// buildDistProvenance generates the rules that write the provenance of the artifacts built by Soong
+// among the dists, and returns the dists of the provenance.
+func buildDistProvenance(ctx SingletonContext, dists []dist) []dist {
+	config := ctx.Config()
+	if !config.BuildProvenanceEnabled() {
+		return nil
+	}
+	key := config.BuildProvenanceSigningKey(ctx)
+	if !key.Valid() {
+		ctx.Errorf("BuildProvenance is enabled without a BuildProvenanceSigningKey")
+		return nil
+	}
+
+	type artifact struct {
+		path  string
+		name  string
+		goals []string
+	}
+
+	records := getBuildProvenanceRecords(config)
+	artifacts := make(map[string]*artifact)
+	for _, d := range dists {
+		for _, p := range d.paths {
+			path, name := p, filepath.Base(p)
+			if i := strings.LastIndex(p, ":"); i >= 0 {
+				path, name = p[:i], p[i+1:]
+			}
+			if _, ok := records.records[path]; !ok {
+				// Not built by Soong, e.g. a source file.
+				continue
+			}
+			a := artifacts[path+":"+name]
+			if a == nil {
+				a = &artifact{path: path, name: name}
+				artifacts[path+":"+name] = a
+			}
+			a.goals = append(a.goals, d.goals...)
+		}
+	}
+
+	product := map[string]string{
+		"build_id":         config.BuildId(),
+		"platform_version": config.PlatformVersionName(),
+	}
+	if config.HasDeviceProduct() {
+		product["product"] = config.DeviceProduct()
+		product["device"] = config.DeviceName()
+	}
+
+	environment := make(map[string]string)
+	for _, env := range buildProvenanceEnvVars {
+		environment[env] = config.Getenv(env)
+	}
+
+	var toolchains map[string]string
+	for _, t := range buildProvenanceToolchains {
+		if toolchains == nil {
+			toolchains = make(map[string]string)
+		}
+		toolchains[t.name] = t.version(ctx)
+	}
+
+	var buildNumberFile string
+	if config.productVariables.BuildNumberFile != nil {
+		buildNumberFile = config.BuildNumberFile(ctx).String()
+	}
+
+	outDir := filepath.Dir(config.soongOutDir)
+
+	var ret []dist
+	for _, k := range SortedKeys(artifacts) {
+		a := artifacts[k]
+		record := records.records[a.path]
+
+		rel, err := filepath.Rel(outDir, a.path)
+		if err != nil || strings.HasPrefix(rel, "../") {
+			ctx.Errorf("dist artifact %q is outside of the out directory %q", a.path, outDir)
+			continue
+		}
+
+		params := buildProvenanceParams{
+			Name:        a.name,
+			Subject:     a.path,
+			Goals:       SortedUniqueStrings(a.goals),
+			Product:     product,
+			Environment: environment,
+			Rule:        record.rule,
+			Args:        record.args,
+			Inputs:      record.inputs.Strings(),
+			Toolchains:  toolchains,
+		}
+		data, err := json.MarshalIndent(params, "", "  ")
+		if err != nil {
+			ctx.Errorf("failed to write the build provenance parameters of %q: %s", a.path, err)
+			continue
+		}
+
+		dir := filepath.Join("build_provenance", filepath.Dir(rel))
+		paramsFile := PathForOutput(ctx, dir, a.name+".provenance.json")
+		WriteFileRuleVerbatim(ctx, paramsFile, string(data)+"\n")
+
+		provenance := PathForOutput(ctx, dir, a.name+".intoto.jsonl")
+		ctx.Build(pctx, BuildParams{
+			Rule:        genBuildProvenanceRule,
+			Description: "build provenance " + a.name,
+			Input:       paramsFile,
+			Implicits:   append(Paths{record.output, key.Path()}, record.inputs...),
+			Output:      provenance,
+			Args: map[string]string{
+				"key":             key.String(),
+				"buildNumberFile": buildNumberFile,
+			},
+		})
+
+		ret = append(ret, dist{
+			goals: params.Goals,
+			paths: []string{provenance.String() + ":" + a.name + ".intoto.jsonl"},
+		})
+	}
+
+	return ret
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+type buildProvenanceTestSingleton struct {
+	out OutputPath
+}
+
+func (s *buildProvenanceTestSingleton) GenerateBuildActions(ctx SingletonContext) {
+	s.out = PathForOutput(ctx, "dist", "foo.zip")
+	ctx.Build(pctx, BuildParams{
+		Rule:     Cp,
+		Input:    PathForSource(ctx, "foo.txt"),
+		Implicit: PathForSource(ctx, "bar.txt"),
+		Output:   s.out,
+	})
+}
+
+func (s *buildProvenanceTestSingleton) MakeVars(ctx MakeVarsContext) {
+	ctx.DistForGoals([]string{"sdk", "droidcore"}, s.out)
+	ctx.DistForGoalWithFilename("sdk", s.out, "bar.zip")
+	ctx.DistForGoal("sdk", PathForSource(ctx, "foo.txt"))
+}
+
+var prepareForBuildProvenanceTest = GroupFixturePreparers(
+	FixtureModifyConfig(SetKatiEnabledForTests),
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterSingletonType("build_provenance_test", func() Singleton {
+			return &buildProvenanceTestSingleton{}
+		})
+	}),
+	PrepareForTestWithMakevars,
+	MockFS{
+		"foo.txt":             nil,
+		"bar.txt":             nil,
+		"keys/provenance.pk8": nil,
+	}.AddToFixture(),
+)
+
+func TestBuildProvenance(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForBuildProvenanceTest,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.BuildProvenance = boolPtr(true)
+			variables.BuildProvenanceSigningKey = proptools.StringPtr("keys/provenance.pk8")
+		}),
+	).RunTest(t)
+
+	makevars := result.SingletonForTests("makevars")
+	params := makevars.Output("build_provenance/soong/dist/foo.zip.provenance.json")
+	AssertStringEquals(t, "params", `{
+  "name": "foo.zip",
+  "subject": "out/soong/dist/foo.zip",
+  "goals": [
+    "droidcore",
+    "sdk"
+  ],
+  "product": {
+    "build_id": "",
+    "device": "test_device",
+    "platform_version": "",
+    "product": "test_product"
+  },
+  "environment": {
+    "TARGET_BUILD_VARIANT": "",
+    "TARGET_PRODUCT": "",
+    "TARGET_RELEASE": ""
+  },
+  "rule": "android/soong/android.Cp",
+  "inputs": [
+    "foo.txt",
+    "bar.txt"
+  ]
+}
+`, StringRelativeToTop(result.Config, ContentFromFileRuleForTests(t, params)))
+
+	provenance := makevars.Output("build_provenance/soong/dist/foo.zip.intoto.jsonl")
+	AssertPathRelativeToTopEquals(t, "params", "out/soong/build_provenance/soong/dist/foo.zip.provenance.json", provenance.Input)
+	AssertPathsRelativeToTopEquals(t, "deps", []string{
+		"out/soong/dist/foo.zip",
+		"keys/provenance.pk8",
+		"foo.txt",
+		"bar.txt",
+	}, provenance.Implicits)
+	AssertStringEquals(t, "key", "keys/provenance.pk8", provenance.Args["key"])
+
+	// The provenance of the artifact disted with another name has that name.
+	makevars.Output("build_provenance/soong/dist/bar.zip.intoto.jsonl")
+
+	var dists []string
+	for _, d := range makevars.Singleton().(*makeVarsSingleton).distsForTesting {
+		if len(d.paths) == 1 && strings.HasSuffix(d.paths[0], ".intoto.jsonl") {
+			dists = append(dists, strings.Join(d.goals, " ")+": "+StringRelativeToTop(result.Config, d.paths[0]))
+		}
+	}
+	AssertArrayString(t, "dists", []string{
+		"sdk: out/soong/build_provenance/soong/dist/bar.zip.intoto.jsonl:bar.zip.intoto.jsonl",
+		"droidcore sdk: out/soong/build_provenance/soong/dist/foo.zip.intoto.jsonl:foo.zip.intoto.jsonl",
+	}, dists)
+
+	// The source files that are disted have no provenance.
+	if strings.Contains(strings.Join(makevars.AllOutputs(), " "), "foo.txt.intoto.jsonl") {
+		t.Errorf("unexpected provenance of the disted source file foo.txt")
+	}
+}
+
+func TestBuildProvenanceWithoutSigningKey(t *testing.T) {
+	GroupFixturePreparers(
+		prepareForBuildProvenanceTest,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.BuildProvenance = boolPtr(true)
+		}),
+	).ExtendWithErrorHandler(FixtureExpectsAtLeastOneErrorMatchingPattern(
+		"BuildProvenance is enabled without a BuildProvenanceSigningKey")).
+		RunTest(t)
+}
+
+func TestBuildProvenanceDisabled(t *testing.T) {
+	result := prepareForBuildProvenanceTest.RunTest(t)
+
+	makevars := result.SingletonForTests("makevars")
+	for _, output := range makevars.AllOutputs() {
+		if strings.HasSuffix(output, ".intoto.jsonl") {
+			t.Errorf("unexpected build provenance %q", output)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -91,6 +92,7 @@ type CmdArgs struct {
 	ModuleGraphFile     string
 	ModuleActionsFile   string
 	DocFile             string
+	ProductValidateFile string
 
 	MultitreeBuild bool
 
@@ -102,6 +104,10 @@ type CmdArgs struct {
 	UseBazelProxy bool
 
 	BuildFromTextStub bool
+
+	ReportUnusedModules bool
+
+	SoongIdeModules string
 }
 
 // Build modes that soong_build can run as.
@@ -142,6 +148,10 @@ const (
 	// Use bazel during analysis of build modules from an allowlist carefully
 	// curated by the build team to be proven stable.
 	BazelProdMode
+
+	// Validate the product config against the parsed blueprint files, without
+	// analyzing the modules, and exit.
+	ValidateProductConfigMode
 )
 
 // SoongOutDir returns the build output directory for the configuration.
@@ -189,6 +199,13 @@ func (c Config) DisableHiddenApiChecks() bool {
 			!c.ReleaseDefaultModuleBuildFromSource())
 }
 
+// ShardedHiddenApiFlags returns true if the monolithic hidden API flags are merged from the flags
+// of each bootclasspath_fragment and of the platform libraries, instead of being generated from the
+// whole bootclasspath.
+func (c Config) ShardedHiddenApiFlags() bool {
+	return Bool(c.productVariables.ShardedHiddenApiFlags)
+}
+
 // MaxPageSizeSupported returns the max page size supported by the device. This
 // value will define the ELF segment alignment for binaries (executables and
 // shared libraries).
@@ -203,6 +220,13 @@ func (c Config) ReleaseDefaultModuleBuildFromSource() bool {
 		Bool(c.config.productVariables.ReleaseDefaultModuleBuildFromSource)
 }
 
+// GetBuildFlag returns the value of the build flag of the release configuration, and whether it
+// is defined.
+func (c Config) GetBuildFlag(name string) (string, bool) {
+	value, ok := c.config.productVariables.BuildFlags[name]
+	return value, ok
+}
+
 // A DeviceConfig object represents the configuration for a particular device
 // being built. For now there will only be one of these, but in the future there
 // may be multiple devices being built.
@@ -307,6 +331,13 @@ type config struct {
 	// If buildFromTextStub is true then the Java API stubs are
 	// built from the signature text files, not the source Java files.
 	buildFromTextStub bool
+
+	// If reportUnusedModules is true then the modules that are not reachable from the product,
+	// the tests or the dist targets are written to unused_modules-<product>.json.
+	reportUnusedModules bool
+
+	// The modules of the IDE project generated by soong_ide, from --modules.
+	soongIdeModules []string
 }
 
 type deviceConfig struct {
@@ -512,6 +543,12 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 		UseBazelProxy:  cmdArgs.UseBazelProxy,
 
 		buildFromTextStub: cmdArgs.BuildFromTextStub,
+
+		reportUnusedModules: cmdArgs.ReportUnusedModules,
+
+		soongIdeModules: FirstUniqueStrings(strings.FieldsFunc(cmdArgs.SoongIdeModules, func(r rune) bool {
+			return r == ','
+		})),
 	}
 
 	config.deviceConfig = &deviceConfig{
@@ -617,6 +654,7 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 	setBuildMode(cmdArgs.BazelApiBp2buildDir, ApiBp2build)
 	setBuildMode(cmdArgs.ModuleGraphFile, GenerateModuleGraph)
 	setBuildMode(cmdArgs.DocFile, GenerateDocFile)
+	setBuildMode(cmdArgs.ProductValidateFile, ValidateProductConfigMode)
 	setBazelMode(cmdArgs.BazelModeDev, "--bazel-mode-dev", BazelDevMode)
 	setBazelMode(cmdArgs.BazelMode, "--bazel-mode", BazelProdMode)
 	setBazelMode(cmdArgs.BazelModeStaging, "--bazel-mode-staging", BazelStagingMode)
@@ -1001,6 +1039,12 @@ func (c *config) ProductAAPTPrebuiltDPI() []string {
 	return c.productVariables.AAPTPrebuiltDPI
 }
 
+// ProductAppImportPreferredAbis returns the ABIs whose arch variants of the android_app_import
+// modules the product prefers, in priority order.
+func (c *config) ProductAppImportPreferredAbis() []string {
+	return c.productVariables.AppImportPreferredAbis
+}
+
 func (c *config) DefaultAppCertificateDir(ctx PathContext) SourcePath {
 	defaultCert := String(c.productVariables.DefaultAppCertificate)
 	if defaultCert != "" {
@@ -1018,6 +1062,132 @@ func (c *config) DefaultAppCertificate(ctx PathContext) (pem, key SourcePath) {
 	return defaultDir.Join(ctx, "testkey.x509.pem"), defaultDir.Join(ctx, "testkey.pk8")
 }
 
+// BuildProvenanceEnabled returns true if the provenance of the dist artifacts is generated.
+func (c *config) BuildProvenanceEnabled() bool {
+	return Bool(c.productVariables.BuildProvenance)
+}
+
+// BuildProvenanceSigningKey returns the PKCS#8 private key that signs the provenance of the dist
+// artifacts, if any.
+func (c *config) BuildProvenanceSigningKey(ctx PathContext) OptionalPath {
+	if key := String(c.productVariables.BuildProvenanceSigningKey); key != "" {
+		return OptionalPathForPath(PathForSource(ctx, key))
+	}
+	return OptionalPath{}
+}
+
+// ReproducibleBuild returns true if the rules that embed timestamps in their outputs receive a
+// SOURCE_DATE_EPOCH, and the rules of the modules that embed the wall-clock time are reported.
+func (c *config) ReproducibleBuild() bool {
+	return Bool(c.productVariables.ReproducibleBuild)
+}
+
+// SourceDateEpoch returns the SOURCE_DATE_EPOCH of the rules that embed timestamps in reproducible
+// builds as a shell word, or an empty string otherwise. It is the SourceDateEpoch product variable
+// if set, otherwise the build date of soong_ui, that release builds set for their build number with
+// BUILD_DATETIME. The build date is read when the rules run without depending on it, like the build
+// number.
+func (c *config) SourceDateEpoch() string {
+	if !c.ReproducibleBuild() {
+		return ""
+	}
+	if epoch := String(c.productVariables.SourceDateEpoch); epoch != "" {
+		return epoch
+	}
+	if buildDateFile := c.Getenv("BUILD_DATETIME_FILE"); buildDateFile != "" {
+		return "$(cat " + buildDateFile + ")"
+	}
+	return ""
+}
+
+// SourceDateEpochEnv returns the shell assignment of SourceDateEpoch that prefixes the commands of
+// the tools that read SOURCE_DATE_EPOCH from their environment, or an empty string.
+func (c *config) SourceDateEpochEnv() string {
+	if epoch := c.SourceDateEpoch(); epoch != "" {
+		return "SOURCE_DATE_EPOCH=" + epoch + " "
+	}
+	return ""
+}
+
+// SourceDateEpochFlag returns the flag that passes SourceDateEpoch to soong_zip and merge_zips, or
+// an empty string.
+func (c *config) SourceDateEpochFlag() string {
+	if epoch := c.SourceDateEpoch(); epoch != "" {
+		return "-source_date_epoch " + epoch
+	}
+	return ""
+}
+
+// ElfCheckBtiPac returns true if the linked arm64 device binaries must have the BTI and PAC
+// properties.
+func (c *config) ElfCheckBtiPac() bool {
+	return Bool(c.productVariables.ElfCheckBtiPac)
+}
+
+// ElfCheckExecuteOnly returns true if the executable segments of the linked arm64 device binaries
+// must not be readable.
+func (c *config) ElfCheckExecuteOnly() bool {
+	return Bool(c.productVariables.ElfCheckExecuteOnly)
+}
+
+// ElfCheckTextRelocations returns true if the linked device binaries must not have text
+// relocations.
+func (c *config) ElfCheckTextRelocations() bool {
+	return Bool(c.productVariables.ElfCheckTextRelocations)
+}
+
+// ElfCheckMaxPageSize returns the page size that the LOAD segments of the linked device binaries
+// must be aligned to, or an empty string if their alignment isn't checked.
+func (c *config) ElfCheckMaxPageSize() string {
+	return String(c.productVariables.ElfCheckMaxPageSize)
+}
+
+// ElfCheckBaseline returns the contents of the baseline file of the modules that are known to fail
+// the checks of the linked binaries, or nil if the product doesn't have one. The file is read by
+// soong_build, so build.ninja depends on it.
+func (c *config) ElfCheckBaseline(ctx PathContext) ([]byte, error) {
+	baseline := String(c.productVariables.ElfCheckBaseline)
+	if baseline == "" {
+		return nil, nil
+	}
+	ctx.AddNinjaFileDeps(baseline)
+	r, err := c.fs.Open(baseline)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// MinSdkVersionWaivers returns the contents of the file of the dependencies that are known not to
+// support the min_sdk_version of the modules that enforce it, or nil if the product doesn't have
+// one. The file is read by soong_build, so build.ninja depends on it.
+func (c *config) MinSdkVersionWaivers(ctx PathContext) ([]byte, error) {
+	waivers := String(c.productVariables.MinSdkVersionWaivers)
+	if waivers == "" {
+		return nil, nil
+	}
+	ctx.AddNinjaFileDeps(waivers)
+	r, err := c.fs.Open(waivers)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// ErrorproneBaseline returns the contents of the errorprone baseline file of a module. The file is
+// read by soong_build, so build.ninja depends on it.
+func (c *config) ErrorproneBaseline(ctx PathContext, baseline Path) ([]byte, error) {
+	ctx.AddNinjaFileDeps(baseline.String())
+	r, err := c.fs.Open(baseline.String())
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
 func (c *config) ApexKeyDir(ctx ModuleContext) SourcePath {
 	// TODO(b/121224311): define another variable such as TARGET_APEX_KEY_OVERRIDE
 	defaultCert := String(c.productVariables.DefaultAppCertificate)
@@ -1150,10 +1320,53 @@ func (c *config) UseRemoteBuild() bool {
 	return c.UseGoma() || c.UseRBE()
 }
 
+// CheckbuildPaths returns the directories that the checkbuild target is restricted to, from the
+// comma separated list in SOONG_CHECKBUILD_PATHS, each with a trailing slash. It returns nil if
+// checkbuild builds all the modules.
+func (c *config) CheckbuildPaths() []string {
+	var paths []string
+	for _, path := range strings.Split(c.Getenv("SOONG_CHECKBUILD_PATHS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, filepath.Clean(path)+"/")
+		}
+	}
+	return paths
+}
+
+const checkbuildReverseDepsDepthDefault = 1
+
+// CheckbuildReverseDepsDepth returns the number of hops of reverse dependencies of the modules
+// in CheckbuildPaths that checkbuild also builds, from SOONG_CHECKBUILD_RDEPS_DEPTH.
+func (c *config) CheckbuildReverseDepsDepth() int {
+	v := c.Getenv("SOONG_CHECKBUILD_RDEPS_DEPTH")
+	if v == "" {
+		return checkbuildReverseDepsDepthDefault
+	}
+	depth, err := strconv.Atoi(v)
+	if err != nil || depth < 0 {
+		fmt.Fprintf(os.Stderr, "bad SOONG_CHECKBUILD_RDEPS_DEPTH value: %q, will use %d\n",
+			v, checkbuildReverseDepsDepthDefault)
+		return checkbuildReverseDepsDepthDefault
+	}
+	return depth
+}
+
+// ActionCacheDir returns the directory of the local cache of the outputs of the sandboxed rules,
+// or an empty string if the cache is disabled.
+func (c *config) ActionCacheDir() string {
+	return c.Getenv("SOONG_ACTION_CACHE_DIR")
+}
+
 func (c *config) RunErrorProne() bool {
 	return c.IsEnvTrue("RUN_ERROR_PRONE")
 }
 
+// UpdateErrorproneBaseline returns true if the errorprone baselines of the modules with
+// errorprone.fatal_checks must be regenerated instead of enforcing their fatal checks.
+func (c *config) UpdateErrorproneBaseline() bool {
+	return c.IsEnvTrue("UPDATE_ERRORPRONE_BASELINE")
+}
+
 // XrefCorpusName returns the Kythe cross-reference corpus name.
 func (c *config) XrefCorpusName() string {
 	return c.Getenv("XREF_CORPUS")
@@ -1237,14 +1450,55 @@ func (c *config) EnforceRROExcludedOverlay(path string) bool {
 	return false
 }
 
+// AutoGeneratedRROs returns true if Soong generates the runtime resource overlays of the modules
+// with enforced RROs from the device and the product resource overlays, instead of Make.
+func (c *config) AutoGeneratedRROs() bool {
+	return Bool(c.productVariables.AutoGeneratedRROs)
+}
+
+// DeviceRRO returns the partition and the priority of the runtime resource overlays that are
+// generated from the device resource overlays. Defaults to the vendor partition and priority 0.
+func (c *config) DeviceRRO() (partition string, priority int) {
+	partition = StringDefault(c.productVariables.DeviceRROPartition, "vendor")
+	if c.productVariables.DeviceRROPriority != nil {
+		priority = *c.productVariables.DeviceRROPriority
+	}
+	return partition, priority
+}
+
+// ProductRRO returns the partition and the priority of the runtime resource overlays that are
+// generated from the product resource overlays. Defaults to the product partition and priority 1,
+// so that they override the overlays generated from the device resource overlays.
+func (c *config) ProductRRO() (partition string, priority int) {
+	partition = StringDefault(c.productVariables.ProductRROPartition, "product")
+	priority = 1
+	if c.productVariables.ProductRROPriority != nil {
+		priority = *c.productVariables.ProductRROPriority
+	}
+	return partition, priority
+}
+
 func (c *config) ExportedNamespaces() []string {
 	return append([]string(nil), c.productVariables.NamespacesToExport...)
 }
 
+// StrictNamespaceResolution returns true if SOONG_STRICT_NAMESPACE_RESOLUTION is set, which makes
+// references to a module name defined in more than one visible namespace errors.
+func (c *config) StrictNamespaceResolution() bool {
+	return c.IsEnvTrue("SOONG_STRICT_NAMESPACE_RESOLUTION")
+}
+
 func (c *config) SourceRootDirs() []string {
 	return c.productVariables.SourceRootDirs
 }
 
+// SourceOverrideModules returns the modules whose preference between source and prebuilt is set by
+// the product (PRODUCT_SOURCE_OVERRIDE_MODULES). A module name selects the source module even if
+// its prebuilt has prefer: true, and a prebuilt_ name selects the prebuilt even if it does not.
+func (c *config) SourceOverrideModules() []string {
+	return c.productVariables.SourceOverrideModules
+}
+
 func (c *config) IncludeTags() []string {
 	return c.productVariables.IncludeTags
 }
@@ -1634,6 +1888,34 @@ func (c *config) FlattenApex() bool {
 	return Bool(c.productVariables.Flatten_apex)
 }
 
+// FlattenApexModule returns true if the product requests the named APEX to be installed
+// flattened even if the device does not use flattened APEXes.
+func (c *config) FlattenApexModule(name string) bool {
+	return InList(name, c.productVariables.FlattenApexModules)
+}
+
+// ExternalSigningCommand returns the command used to sign APKs and APEXes instead of signapk,
+// or an empty string if signing is not delegated to an external command.
+func (c *config) ExternalSigningCommand() string {
+	return String(c.productVariables.ExternalSigningCommand)
+}
+
+// ExternalSigningForModule returns true if the named module is signed with the external signing
+// command. All modules are signed with it when no modules are listed.
+func (c *config) ExternalSigningForModule(name string) bool {
+	if c.ExternalSigningCommand() == "" {
+		return false
+	}
+	modules := c.productVariables.ExternalSigningModules
+	return len(modules) == 0 || InList(name, modules)
+}
+
+// ExternalSigningDryRun returns true if the external signing command should only be validated
+// while signing with signapk.
+func (c *config) ExternalSigningDryRun() bool {
+	return Bool(c.productVariables.ExternalSigningDryRun)
+}
+
 func (c *config) ForceApexSymlinkOptimization() bool {
 	return Bool(c.productVariables.ForceApexSymlinkOptimization)
 }
@@ -1642,6 +1924,30 @@ func (c *config) ApexCompressionEnabled() bool {
 	return Bool(c.productVariables.CompressedApex) && !c.UnbundledBuildApps()
 }
 
+// ApexDecompressionSupported returns true unless the board declares that the device can't
+// decompress compressed APEXes at boot. Defaults to true.
+func (c *config) ApexDecompressionSupported() bool {
+	return proptools.BoolDefault(c.productVariables.BoardApexDecompressionSupported, true)
+}
+
+// ApexCompressibleOverride returns the product-level override of the compressible property of
+// the given APEX, if any.
+func (c *config) ApexCompressibleOverride(name string) (compressible bool, overridden bool) {
+	value, overridden := findOverrideValue(c.productVariables.CompressedApexOverrides, name,
+		"invalid override rule %q in PRODUCT_COMPRESSED_APEX_OVERRIDES should be <module_name>:<true|false>")
+	if !overridden {
+		return false, false
+	}
+	switch value {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	default:
+		panic(fmt.Errorf("invalid value %q for %q in PRODUCT_COMPRESSED_APEX_OVERRIDES should be true or false", value, name))
+	}
+}
+
 func (c *config) ApexTrimEnabled() bool {
 	return Bool(c.productVariables.TrimmedApex)
 }
@@ -1670,6 +1976,28 @@ func (c *config) InstallExtraFlattenedApexes() bool {
 	return Bool(c.productVariables.InstallExtraFlattenedApexes)
 }
 
+// ProductPackages returns the modules installed by the product, or nil if Make didn't provide
+// them.
+func (c *config) ProductPackages() []string {
+	return c.productVariables.ProductPackages
+}
+
+// ProductPackageOrigin returns the makefiles that added a module to the ProductPackages, from the
+// product makefile to the makefile that lists it, or nil if Make didn't provide them.
+func (c *config) ProductPackageOrigin(name string) []string {
+	return c.productVariables.ProductPackagesOrigins[name]
+}
+
+func (c *config) ProductPackagesStrictness() []string {
+	return c.productVariables.ProductPackagesStrictness
+}
+
+// MakeInstalledSharedLibs returns the file names of the shared libraries that Make installs to a
+// partition.
+func (c *config) MakeInstalledSharedLibs(partition string) []string {
+	return c.productVariables.MakeInstalledSharedLibs[partition]
+}
+
 func (c *config) ProductHiddenAPIStubs() []string {
 	return c.productVariables.ProductHiddenAPIStubs
 }
@@ -1821,6 +2149,12 @@ func (c *deviceConfig) RamdiskSnapshotModules() map[string]bool {
 	return c.config.productVariables.RamdiskSnapshotModules
 }
 
+// DedupSnapshotPrebuilts returns true if identical prebuilts of the cc snapshots should be stored
+// once by their content.
+func (c *deviceConfig) DedupSnapshotPrebuilts() bool {
+	return c.config.productVariables.DedupSnapshotPrebuilts
+}
+
 func createDirsMap(previous map[string]bool, dirs []string) (map[string]bool, error) {
 	var ret = make(map[string]bool)
 	for _, dir := range dirs {
@@ -2021,6 +2355,20 @@ func (c *config) BuildFromTextStub() bool {
 func (c *config) SetBuildFromTextStub(b bool) {
 	c.buildFromTextStub = b
 }
+
+func (c *config) ReportUnusedModules() bool {
+	return c.reportUnusedModules
+}
+
+// SoongIdeModules returns the modules of the IDE project generated by soong_ide.
+func (c *config) SoongIdeModules() []string {
+	return c.soongIdeModules
+}
+
+func (c *config) SetSoongIdeModules(modules []string) {
+	c.soongIdeModules = modules
+}
+
 func (c *config) AddForceEnabledModules(forceEnabled []string) {
 	for _, forceEnabledModule := range forceEnabled {
 		c.bazelForceEnabledModules[forceEnabledModule] = struct{}{}
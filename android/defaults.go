@@ -15,6 +15,7 @@
 package android
 
 import (
+	"fmt"
 	"reflect"
 
 	"github.com/google/blueprint"
@@ -29,6 +30,60 @@ var DefaultsDepTag defaultsDependencyTag
 
 type defaultsProperties struct {
 	Defaults []string
+
+	// Defaults modules that are applied after the ones in the defaults property when a soong
+	// config variable has a value, so that a single defaults module can express the differences
+	// between products, e.g.
+	//
+	//	conditional_defaults: [{
+	//	    soong_config_namespace: "acme",
+	//	    soong_config_variable: "board",
+	//	    value: "soc_a",
+	//	    defaults: ["acme_soc_a_defaults"],
+	//	}],
+	//
+	// The conditions are evaluated when the defaults are applied, including the conditional
+	// defaults of defaults modules.
+	Conditional_defaults []ConditionalDefaults
+}
+
+// ConditionalDefaults is a list of defaults modules to apply when a soong config variable has a
+// value.
+type ConditionalDefaults struct {
+	// The namespace of the soong config variable, as in soong_config_module_type.
+	Soong_config_namespace *string
+
+	// The name of the soong config variable.
+	Soong_config_variable *string
+
+	// The value the variable must have for the defaults to be applied. If unset, the variable is
+	// a bool variable and the defaults are applied if it is true.
+	Value *string
+
+	// The defaults modules to apply.
+	Defaults []string
+}
+
+// effectiveDefaults returns the defaults modules listed in the defaults property followed by the
+// conditional defaults whose condition is true.
+func (p *defaultsProperties) effectiveDefaults(ctx BaseModuleContext) []string {
+	if len(p.Conditional_defaults) == 0 {
+		return p.Defaults
+	}
+	ret := append([]string(nil), p.Defaults...)
+	for i, c := range p.Conditional_defaults {
+		namespace, variable := String(c.Soong_config_namespace), String(c.Soong_config_variable)
+		if namespace == "" || variable == "" {
+			ctx.PropertyErrorf(fmt.Sprintf("conditional_defaults[%d]", i),
+				"soong_config_namespace and soong_config_variable must be set")
+			continue
+		}
+		config := ctx.Config().VendorConfig(namespace)
+		if (c.Value != nil && config.String(variable) == *c.Value) || (c.Value == nil && config.Bool(variable)) {
+			ret = append(ret, c.Defaults...)
+		}
+	}
+	return ret
 }
 
 type DefaultableModuleBase struct {
@@ -340,13 +395,13 @@ func RegisterDefaultsPreArchMutators(ctx RegisterMutatorsContext) {
 
 func defaultsDepsMutator(ctx BottomUpMutatorContext) {
 	if defaultable, ok := ctx.Module().(Defaultable); ok {
-		ctx.AddDependency(ctx.Module(), DefaultsDepTag, defaultable.defaults().Defaults...)
+		ctx.AddDependency(ctx.Module(), DefaultsDepTag, defaultable.defaults().effectiveDefaults(ctx)...)
 	}
 }
 
 func defaultsMutator(ctx TopDownMutatorContext) {
 	if defaultable, ok := ctx.Module().(Defaultable); ok {
-		if len(defaultable.defaults().Defaults) > 0 {
+		if len(defaultable.defaults().Defaults) > 0 || len(defaultable.defaults().Conditional_defaults) > 0 {
 			var defaultsList []Defaults
 			seen := make(map[Defaults]bool)
 
@@ -356,7 +411,8 @@ func defaultsMutator(ctx TopDownMutatorContext) {
 						if !seen[defaults] {
 							seen[defaults] = true
 							defaultsList = append(defaultsList, defaults)
-							return len(defaults.defaults().Defaults) > 0
+							return len(defaults.defaults().Defaults) > 0 ||
+								len(defaults.defaults().Conditional_defaults) > 0
 						}
 					} else {
 						ctx.PropertyErrorf("defaults", "module %s is not an defaults module",
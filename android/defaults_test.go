@@ -93,6 +93,93 @@ func TestDefaults(t *testing.T) {
 	AssertDeepEquals(t, "foo", []string{"transitive", "defaults", "module"}, foo.properties.Foo)
 }
 
+func TestConditionalDefaults(t *testing.T) {
+	bp := `
+		defaults {
+			name: "soc_a_defaults",
+			foo: ["soc_a"],
+		}
+
+		defaults {
+			name: "soc_b_defaults",
+			foo: ["soc_b"],
+		}
+
+		defaults {
+			name: "feature_defaults",
+			foo: ["feature"],
+		}
+
+		defaults {
+			name: "board_defaults",
+			foo: ["board"],
+			conditional_defaults: [
+				{
+					soong_config_namespace: "acme",
+					soong_config_variable: "board",
+					value: "soc_a",
+					defaults: ["soc_a_defaults"],
+				},
+				{
+					soong_config_namespace: "acme",
+					soong_config_variable: "board",
+					value: "soc_b",
+					defaults: ["soc_b_defaults"],
+				},
+				{
+					soong_config_namespace: "acme",
+					soong_config_variable: "feature",
+					defaults: ["feature_defaults"],
+				},
+			],
+		}
+
+		test {
+			name: "foo",
+			defaults: ["board_defaults"],
+			foo: ["module"],
+		}
+	`
+
+	result := GroupFixturePreparers(
+		prepareForDefaultsTest,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.VendorVars = map[string]map[string]string{
+				"acme": {
+					"board":   "soc_a",
+					"feature": "true",
+				},
+			}
+		}),
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	foo := result.Module("foo", "").(*defaultsTestModule)
+
+	AssertDeepEquals(t, "foo", []string{"feature", "soc_a", "board", "module"}, foo.properties.Foo)
+}
+
+func TestConditionalDefaultsErrors(t *testing.T) {
+	bp := `
+		test {
+			name: "foo",
+			conditional_defaults: [
+				{
+					soong_config_namespace: "acme",
+					defaults: ["missing"],
+				},
+			],
+		}
+	`
+
+	GroupFixturePreparers(
+		prepareForDefaultsTest,
+		FixtureWithRootAndroidBp(bp),
+	).ExtendWithErrorHandler(FixtureExpectsOneErrorPattern(
+		`module "foo": conditional_defaults\[0\]: soong_config_namespace and soong_config_variable must be set`,
+	)).RunTest(t)
+}
+
 func TestDefaultsAllowMissingDependencies(t *testing.T) {
 	bp := `
 		defaults {
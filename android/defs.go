@@ -138,6 +138,14 @@ func init() {
 		return ctx.Config().RBEWrapper()
 	})
 
+	pctx.VariableFunc("SourceDateEpochEnv", func(ctx PackageVarContext) string {
+		return proptools.NinjaEscape(ctx.Config().SourceDateEpochEnv())
+	})
+
+	pctx.VariableFunc("SourceDateEpochFlag", func(ctx PackageVarContext) string {
+		return proptools.NinjaEscape(ctx.Config().SourceDateEpochFlag())
+	})
+
 	exportedVars.ExportStringList("NeverAllowNotInIncludeDir", neverallowNotInIncludeDir)
 	exportedVars.ExportStringList("NeverAllowNoUseIncludeDir", neverallowNoUseIncludeDir)
 }
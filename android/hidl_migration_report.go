@@ -0,0 +1,207 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// This file implements the HIDL to AIDL migration report, which lists the hidl_interface modules
+// that remain in the tree, whether they are frozen, and the modules that serve and use them on each
+// partition. `m hidl-migration-report` writes it to out/soong/hidl_migration_report.json, and dists
+// it. soong_ui sets SOONG_HIDL_MIGRATION_REPORT when the goal is built, as the report visits all
+// the modules.
+//
+// The modules of a partition that depend on the libraries generated for an interface, e.g.
+// android.hardware.foo@1.0 or android.hardware.foo-V1.0-java, are its clients. The clients
+// that require a vintf_fragment declaring a HAL instance of the interface are its servers.
+
+func init() {
+	RegisterHidlMigrationReportBuildComponents(InitRegistrationContext)
+}
+
+func RegisterHidlMigrationReportBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("hidl_migration_report", hidlMigrationReportSingletonFactory)
+}
+
+var PrepareForTestWithHidlMigrationReport = FixtureRegisterWithContext(RegisterHidlMigrationReportBuildComponents)
+
+// hidlInterfaceModuleSuffix is appended by hidl_interface to the name of its module, to leave the
+// name of the interface to the C++ library generated for it.
+const hidlInterfaceModuleSuffix = "_interface"
+
+var (
+	// e.g. android.hardware.foo@1.0, android.hardware.foo@1.0-adapter-helper.
+	hidlLibraryRegexp = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)@([0-9]+\.[0-9]+)(?:-.*)?$`)
+	// e.g. android.hardware.foo-V1.0-java, android.hardware.foo-V1.0-java-shallow.
+	hidlJavaLibraryRegexp = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)-V([0-9]+\.[0-9]+)-java(?:-.*)?$`)
+)
+
+// hidlInterfaceForLibrary returns the interface that a library is generated for, e.g.
+// android.hardware.foo@1.0, or an empty string if the library isn't generated for an interface.
+func hidlInterfaceForLibrary(name string) string {
+	for _, re := range []*regexp.Regexp{hidlLibraryRegexp, hidlJavaLibraryRegexp} {
+		if match := re.FindStringSubmatch(name); match != nil {
+			return match[1] + "@" + match[2]
+		}
+	}
+	return ""
+}
+
+type hidlMigrationReportPartition struct {
+	Servers []string `json:"servers"`
+	Clients []string `json:"clients"`
+}
+
+type hidlMigrationReportEntry struct {
+	Interface string `json:"interface"`
+	Owner     string `json:"owner,omitempty"`
+	// "frozen", "unfrozen", or "unknown" if the module doesn't tell.
+	Frozen     string                                   `json:"frozen"`
+	Partitions map[string]*hidlMigrationReportPartition `json:"partitions"`
+}
+
+func hidlMigrationReportSingletonFactory() Singleton {
+	return &hidlMigrationReportSingleton{}
+}
+
+type hidlMigrationReportSingleton struct {
+	report WritablePath
+}
+
+func (s *hidlMigrationReportSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !ctx.Config().IsEnvTrue("SOONG_HIDL_MIGRATION_REPORT") {
+		return
+	}
+
+	entries := make(map[string]*hidlMigrationReportEntry)
+	// The interfaces used by each module, and the HAL instances declared by the vintf_fragment
+	// modules, which are only known to be interfaces once all the modules are visited.
+	type client struct {
+		name, partition string
+		interfaces      []string
+		required        []string
+	}
+	var clients []client
+	declaredHals := make(map[string][]string)
+
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() {
+			return
+		}
+		m := module.base()
+		if ctx.ModuleType(module) == "hidl_interface" {
+			name := strings.TrimSuffix(ctx.ModuleName(module), hidlInterfaceModuleSuffix)
+			entry := &hidlMigrationReportEntry{
+				Interface:  name,
+				Owner:      m.Owner(),
+				Frozen:     "unknown",
+				Partitions: make(map[string]*hidlMigrationReportPartition),
+			}
+			for _, prop := range m.propertiesWithValues() {
+				if prop.Name == "Frozen" {
+					if prop.Value == "true" {
+						entry.Frozen = "frozen"
+					} else {
+						entry.Frozen = "unfrozen"
+					}
+				}
+			}
+			entries[name] = entry
+			return
+		}
+		if f, ok := module.(*vintfFragmentModule); ok {
+			declaredHals[ctx.ModuleName(f)] = f.properties.Hals
+			return
+		}
+		if !m.Device() || m.IsSkipInstall() {
+			return
+		}
+
+		var interfaces []string
+		ctx.VisitDirectDeps(module, func(dep Module) {
+			if i := hidlInterfaceForLibrary(ctx.ModuleName(dep)); i != "" {
+				interfaces = append(interfaces, i)
+			}
+		})
+		if len(interfaces) > 0 {
+			clients = append(clients, client{
+				name:       ctx.ModuleName(module),
+				partition:  m.PartitionTag(ctx.DeviceConfig()),
+				interfaces: interfaces,
+				required:   m.RequiredModuleNames(),
+			})
+		}
+	})
+	if len(entries) == 0 {
+		return
+	}
+
+	for _, c := range clients {
+		// e.g. android.hardware.foo@1.0::IFoo/default serves android.hardware.foo@1.0.
+		served := make(map[string]bool)
+		for _, required := range c.required {
+			for _, hal := range declaredHals[required] {
+				served[strings.SplitN(hal, "::", 2)[0]] = true
+			}
+		}
+		for _, i := range FirstUniqueStrings(c.interfaces) {
+			entry, ok := entries[i]
+			if !ok {
+				continue
+			}
+			partition := entry.Partitions[c.partition]
+			if partition == nil {
+				partition = &hidlMigrationReportPartition{Servers: []string{}, Clients: []string{}}
+				entry.Partitions[c.partition] = partition
+			}
+			if served[i] {
+				partition.Servers = append(partition.Servers, c.name)
+			} else {
+				partition.Clients = append(partition.Clients, c.name)
+			}
+		}
+	}
+
+	var report []*hidlMigrationReportEntry
+	for _, name := range SortedKeys(entries) {
+		entry := entries[name]
+		for _, partition := range entry.Partitions {
+			partition.Servers = SortedUniqueStrings(partition.Servers)
+			partition.Clients = SortedUniqueStrings(partition.Clients)
+			// A module that serves the interface in one variant serves it in all of them.
+			partition.Clients = RemoveListFromList(partition.Clients, partition.Servers)
+		}
+		report = append(report, entry)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the HIDL migration report: %s", err)
+		return
+	}
+	s.report = PathForOutput(ctx, "hidl_migration_report.json")
+	WriteFileRuleVerbatim(ctx, s.report, string(data)+"\n")
+
+	ctx.Phony("hidl-migration-report", s.report)
+}
+
+func (s *hidlMigrationReportSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.report != nil {
+		ctx.DistForGoal("hidl-migration-report", s.report)
+	}
+}
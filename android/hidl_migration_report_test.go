@@ -0,0 +1,166 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+// A module that stands for the hidl_interface modules of system/tools/hidl.
+type hidlInterfaceTestModule struct {
+	ModuleBase
+	props struct {
+		Frozen *bool
+	}
+}
+
+func hidlInterfaceTestModuleFactory() Module {
+	m := &hidlInterfaceTestModule{}
+	m.AddProperties(&m.props)
+	InitAndroidModule(m)
+	return m
+}
+
+func (m *hidlInterfaceTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {}
+
+func TestHidlInterfaceForLibrary(t *testing.T) {
+	for name, want := range map[string]string{
+		"android.hardware.foo@1.0":                "android.hardware.foo@1.0",
+		"android.hardware.foo@1.0-adapter-helper": "android.hardware.foo@1.0",
+		"android.hardware.foo-V1.1-java":          "android.hardware.foo@1.1",
+		"android.hardware.foo-V1.1-java-shallow":  "android.hardware.foo@1.1",
+		"android.hardware.foo-V1-java":            "",
+		"libfoo":                                  "",
+	} {
+		AssertStringEquals(t, name, want, hidlInterfaceForLibrary(name))
+	}
+}
+
+func TestHidlMigrationReport(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithHidlMigrationReport,
+		PrepareForTestWithVintfFragment,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("component", componentTestModuleFactory)
+			ctx.RegisterModuleType("hidl_interface", hidlInterfaceTestModuleFactory)
+		}),
+		FixtureMergeEnv(map[string]string{
+			"SOONG_HIDL_MIGRATION_REPORT": "true",
+		}),
+		MockFS{
+			"foo.xml": nil,
+		}.AddToFixture(),
+		FixtureWithRootAndroidBp(`
+			hidl_interface {
+				name: "android.hardware.foo@1.0_interface",
+				owner: "google",
+				frozen: true,
+			}
+
+			hidl_interface {
+				name: "android.hardware.bar@1.0_interface",
+			}
+
+			component {
+				name: "android.hardware.foo@1.0",
+			}
+
+			component {
+				name: "android.hardware.bar@1.0",
+			}
+
+			component {
+				name: "foo_service",
+				deps: ["android.hardware.foo@1.0"],
+				required: ["foo_manifest"],
+			}
+
+			vintf_fragment {
+				name: "foo_manifest",
+				src: "foo.xml",
+				hals: ["android.hardware.foo@1.0::IFoo/default"],
+			}
+
+			component {
+				name: "foo_client",
+				deps: ["android.hardware.foo@1.0", "android.hardware.bar@1.0"],
+			}
+
+			component {
+				name: "foo_vendor_client",
+				deps: ["android.hardware.foo@1.0"],
+				vendor: true,
+			}
+		`),
+	).RunTest(t)
+
+	report := result.SingletonForTests("hidl_migration_report").Output("hidl_migration_report.json")
+	AssertStringEquals(t, "report", `[
+  {
+    "interface": "android.hardware.bar@1.0",
+    "frozen": "unknown",
+    "partitions": {
+      "system": {
+        "servers": [],
+        "clients": [
+          "foo_client"
+        ]
+      }
+    }
+  },
+  {
+    "interface": "android.hardware.foo@1.0",
+    "owner": "google",
+    "frozen": "frozen",
+    "partitions": {
+      "system": {
+        "servers": [
+          "foo_service"
+        ],
+        "clients": [
+          "foo_client"
+        ]
+      },
+      "vendor": {
+        "servers": [],
+        "clients": [
+          "foo_vendor_client"
+        ]
+      }
+    }
+  }
+]
+`, ContentFromFileRuleForTests(t, report))
+}
+
+func TestHidlMigrationReportDisabled(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithHidlMigrationReport,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("hidl_interface", hidlInterfaceTestModuleFactory)
+		}),
+		FixtureWithRootAndroidBp(`
+			hidl_interface {
+				name: "android.hardware.foo@1.0_interface",
+			}
+		`),
+	).RunTest(t)
+
+	report := result.SingletonForTests("hidl_migration_report").MaybeOutput("hidl_migration_report.json")
+	if report.Rule != nil {
+		t.Errorf("expected no report when the hidl-migration-report goal isn't built")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"strings"
+)
+
+// This file checks the init_rc files of the device modules with init_rc_check when
+// `m init-rc-check` is run: the services must run files installed by the product, as their users
+// and groups must be AIDs of android_filesystem_config.h or the config.fs files of the product, and
+// their capabilities must be Linux capabilities. init would otherwise only fail to start the
+// services when the device boots. The check isn't part of droidcore as the files installed by Make
+// are only known by the names of their modules.
+
+func init() {
+	RegisterInitRcCheckBuildComponents(InitRegistrationContext)
+}
+
+func RegisterInitRcCheckBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("init_rc_check", initRcCheckSingletonFactory)
+}
+
+// The header of the AIDs of the platform.
+const aidHeaderPath = "system/core/libcutils/include/private/android_filesystem_config.h"
+
+// deviceInstallPath returns the path on the device of a file installed to the partition, which
+// may be under the root of a ramdisk or recovery image, e.g. /system/bin/foo for bin/foo in
+// recovery/root/system.
+func deviceInstallPath(partition, relPath string) string {
+	for _, root := range []string{"recovery/root", "ramdisk", "vendor_ramdisk", "debug_ramdisk"} {
+		if partition == root {
+			return "/" + relPath
+		}
+		if strings.HasPrefix(partition, root+"/") {
+			partition = strings.TrimPrefix(partition, root+"/")
+			break
+		}
+	}
+	return "/" + partition + "/" + relPath
+}
+
+func initRcCheckSingletonFactory() Singleton {
+	return &initRcCheckSingleton{}
+}
+
+type initRcCheckSingleton struct{}
+
+func (s *initRcCheckSingleton) GenerateBuildActions(ctx SingletonContext) {
+	var initRcPaths Paths
+	var installed []string
+	defined := make(map[string]bool)
+	ctx.VisitAllModules(func(module Module) {
+		defined[ctx.ModuleName(module)] = true
+		if !module.Enabled() || module.base().IsSkipInstall() || module.Target().Os.Class != Device {
+			return
+		}
+		specs := module.base().packagingSpecs
+		for _, spec := range specs {
+			installed = append(installed, deviceInstallPath(spec.Partition(), spec.RelPathInPackage()))
+		}
+		// The init_rc files of the modules that aren't installed aren't installed either.
+		if len(specs) > 0 {
+			initRcPaths = append(initRcPaths, module.base().initRcPaths...)
+		}
+	})
+	if len(initRcPaths) == 0 {
+		return
+	}
+
+	var makeModules []string
+	for _, name := range ctx.Config().ProductPackages() {
+		if !defined[name] {
+			makeModules = append(makeModules, name)
+		}
+	}
+
+	// The lists are written during analysis as they contain every file installed by the product.
+	installedList := PathForOutput(ctx, "init_rc_check", "installed.txt")
+	makeModulesList := PathForOutput(ctx, "init_rc_check", "make_modules.txt")
+	for _, list := range []struct {
+		path  WritablePath
+		lines []string
+	}{
+		{installedList, SortedUniqueStrings(installed)},
+		{makeModulesList, SortedUniqueStrings(makeModules)},
+	} {
+		path := list.path
+		if err := WriteFileToOutputDir(path, []byte(strings.Join(list.lines, "\n")+"\n"), 0666); err != nil {
+			ctx.Errorf("failed to write %s: %s", path, err)
+			return
+		}
+		// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+		ctx.Build(pctx, BuildParams{
+			Rule:   Touch,
+			Output: path,
+		})
+	}
+
+	stamp := PathForOutput(ctx, "init_rc_check", "init_rc_check.timestamp")
+	builder := NewRuleBuilder(pctx, ctx)
+	cmd := builder.Command().BuiltTool("init_rc_check").
+		FlagWithOutput("-o ", stamp).
+		FlagWithInput("-installed_list ", installedList).
+		FlagWithInput("-make_modules_list ", makeModulesList)
+	// The AIDs are only checked in trees that define them.
+	if header := ExistentPathForSource(ctx, aidHeaderPath); header.Valid() {
+		cmd.FlagWithInput("-aids ", header.Path())
+		for _, configFs := range ctx.DeviceConfig().TargetFSConfigGen() {
+			cmd.FlagWithInput("-config_fs ", PathForSource(ctx, configFs))
+		}
+	}
+	cmd.Inputs(SortedUniquePaths(initRcPaths))
+	builder.Build("init_rc_check", "Checking the init_rc files")
+
+	ctx.Phony("init-rc-check", stamp)
+}
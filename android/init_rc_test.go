@@ -0,0 +1,74 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitRcCheck(t *testing.T) {
+	bp := `
+		component {
+			name: "foo",
+			vendor: true,
+			init_rc: ["foo.rc"],
+		}
+	`
+
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("component", componentTestModuleFactory)
+			RegisterInitRcCheckBuildComponents(ctx)
+		}),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.TargetFSConfigGen = []string{"device/test/config.fs"}
+			variables.ProductPackages = []string{"foo", "sh"}
+		}),
+		FixtureWithRootAndroidBp(bp),
+		MockFS{
+			"foo.rc":                nil,
+			"device/test/config.fs": nil,
+			aidHeaderPath:           nil,
+		}.AddToFixture(),
+	).RunTest(t)
+
+	check := result.SingletonForTests("init_rc_check").Rule("init_rc_check")
+	AssertStringDoesContain(t, "check", check.RuleParams.Command,
+		"init_rc_check -o out/soong/init_rc_check/init_rc_check.timestamp"+
+			" -installed_list out/soong/init_rc_check/installed.txt"+
+			" -make_modules_list out/soong/init_rc_check/make_modules.txt"+
+			" -aids "+aidHeaderPath+" -config_fs device/test/config.fs foo.rc")
+
+	installed, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), "init_rc_check", "installed.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertStringEquals(t, "installed", "/vendor/etc/init/foo.rc\n/vendor/lib/foo\n/vendor/lib64/foo\n", string(installed))
+
+	makeModules, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), "init_rc_check", "make_modules.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertStringEquals(t, "make modules", "sh\n", string(makeModules))
+}
+
+func TestDeviceInstallPath(t *testing.T) {
+	AssertStringEquals(t, "system", "/system/bin/foo", deviceInstallPath("system", "bin/foo"))
+	AssertStringEquals(t, "recovery", "/system/bin/foo", deviceInstallPath("recovery/root/system", "bin/foo"))
+	AssertStringEquals(t, "ramdisk root", "/init.rc", deviceInstallPath("ramdisk", "init.rc"))
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// This file implements the installed_file_attributes property of all modules, which declares the
+// mode, owner, capabilities and SELinux label of the files the module installs. The attributes are
+// recorded in the PackagingSpecs of the files, from which filesystem modules generate the fs_config
+// and file_contexts entries of the image, instead of the products listing them in config.fs and
+// file_contexts files separately from the modules.
+
+// InstalledFileAttributes are the attributes of some of the installed files of a module.
+type InstalledFileAttributes struct {
+	// The installed files the attributes apply to, relative to the partition they are installed to,
+	// e.g. "bin/foo". If empty, the attributes apply to all the files installed by the module.
+	Files []string
+
+	// The mode of the files in octal, e.g. "0750".
+	Mode *string
+
+	// The owner of the files, as an AID, e.g. "AID_SYSTEM".
+	User *string
+
+	// The group of the files, as an AID, e.g. "AID_SHELL".
+	Group *string
+
+	// The Linux capabilities of the files, e.g. ["NET_RAW", "SYS_NICE"].
+	Capabilities []string
+
+	// The SELinux label of the files, e.g. "u:object_r:foo_exec:s0".
+	Selinux_label *string
+}
+
+// InstalledFileMetadata is the metadata of an installed file, as declared by the
+// installed_file_attributes property of the module that installs it.
+type InstalledFileMetadata struct {
+	// The mode of the file in octal, or empty for the default mode.
+	Mode string
+
+	// The owner and group of the file as AIDs, or empty for the default owner and group.
+	User  string
+	Group string
+
+	// The Linux capabilities of the file, without the CAP_ prefix.
+	Capabilities []string
+
+	// The SELinux label of the file, or empty for the label from the file_contexts of the image.
+	SelinuxLabel string
+}
+
+// Empty returns true if no metadata is declared for the file.
+func (m InstalledFileMetadata) Empty() bool {
+	return m.Mode == "" && m.User == "" && m.Group == "" && len(m.Capabilities) == 0 && m.SelinuxLabel == ""
+}
+
+// HasFsConfig returns true if the mode, owner or capabilities of the file are declared.
+func (m InstalledFileMetadata) HasFsConfig() bool {
+	return m.Mode != "" || m.User != "" || m.Group != "" || len(m.Capabilities) > 0
+}
+
+var (
+	installedFileModeRegexp       = regexp.MustCompile(`^0?[0-7]{3,4}$`)
+	installedFileAidRegexp        = regexp.MustCompile(`^AID_[A-Z0-9_]+$`)
+	installedFileCapabilityRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	installedFileSelinuxRegexp    = regexp.MustCompile(`^u:object_r:[a-z0-9_]+:s0(:[a-z0-9,.]+)?$`)
+)
+
+// installedFileAttributesChecker reports the errors in the installed_file_attributes property.
+func installedFileAttributesChecker(ctx ModuleContext) {
+	for i, attrs := range ctx.Module().base().commonProperties.Installed_file_attributes {
+		property := fmt.Sprintf("installed_file_attributes[%d]", i)
+		for _, file := range attrs.Files {
+			if file == "" || filepath.IsAbs(file) || filepath.Clean(file) != file || strings.HasPrefix(file, "../") {
+				ctx.PropertyErrorf(property+".files", "%q must be a clean path relative to the partition", file)
+			}
+		}
+		if mode := String(attrs.Mode); mode != "" && !installedFileModeRegexp.MatchString(mode) {
+			ctx.PropertyErrorf(property+".mode", "%q is not an octal mode", mode)
+		}
+		for _, aid := range []struct {
+			name  string
+			value string
+		}{{"user", String(attrs.User)}, {"group", String(attrs.Group)}} {
+			if aid.value != "" && !installedFileAidRegexp.MatchString(aid.value) {
+				ctx.PropertyErrorf(property+"."+aid.name, "%q is not an AID, e.g. AID_SYSTEM", aid.value)
+			}
+		}
+		for _, c := range attrs.Capabilities {
+			if !installedFileCapabilityRegexp.MatchString(strings.TrimPrefix(c, "CAP_")) {
+				ctx.PropertyErrorf(property+".capabilities", "%q is not a capability, e.g. NET_RAW", c)
+			}
+		}
+		if label := String(attrs.Selinux_label); label != "" && !installedFileSelinuxRegexp.MatchString(label) {
+			ctx.PropertyErrorf(property+".selinux_label", "%q is not a file label, e.g. u:object_r:foo_exec:s0", label)
+		}
+	}
+}
+
+// installedFileMetadata returns the metadata declared by the installed_file_attributes property of
+// the module for the installed file at relPath in the partition. Later entries of the property
+// override the attributes set by earlier ones.
+func (m *ModuleBase) installedFileMetadata(relPath string) InstalledFileMetadata {
+	var metadata InstalledFileMetadata
+	for _, attrs := range m.commonProperties.Installed_file_attributes {
+		if len(attrs.Files) > 0 && !InList(relPath, attrs.Files) {
+			continue
+		}
+		if mode := String(attrs.Mode); mode != "" {
+			// config.fs and fs_config files use 4 digit modes.
+			metadata.Mode = fmt.Sprintf("%04s", strings.TrimPrefix(mode, "0"))
+		}
+		if user := String(attrs.User); user != "" {
+			metadata.User = user
+		}
+		if group := String(attrs.Group); group != "" {
+			metadata.Group = group
+		}
+		if len(attrs.Capabilities) > 0 {
+			metadata.Capabilities = nil
+			for _, c := range attrs.Capabilities {
+				metadata.Capabilities = append(metadata.Capabilities, strings.TrimPrefix(c, "CAP_"))
+			}
+		}
+		if label := String(attrs.Selinux_label); label != "" {
+			metadata.SelinuxLabel = label
+		}
+	}
+	return metadata
+}
+
+// checkInstalledFileAttributesFiles reports the files of the installed_file_attributes property
+// that the module doesn't install.
+func checkInstalledFileAttributesFiles(ctx ModuleContext, specs []PackagingSpec) {
+	installed := make(map[string]bool)
+	for _, spec := range specs {
+		installed[spec.relPathInPackage] = true
+	}
+	for i, attrs := range ctx.Module().base().commonProperties.Installed_file_attributes {
+		for _, file := range attrs.Files {
+			if !installed[file] {
+				ctx.PropertyErrorf(fmt.Sprintf("installed_file_attributes[%d].files", i),
+					"%q is not installed by this module", file)
+			}
+		}
+	}
+}
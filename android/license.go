@@ -27,8 +27,13 @@ type licenseKindDependencyTag struct {
 	blueprint.BaseDependencyTag
 }
 
+type licenseExceptionDependencyTag struct {
+	blueprint.BaseDependencyTag
+}
+
 var (
-	licenseKindTag = licenseKindDependencyTag{}
+	licenseKindTag      = licenseKindDependencyTag{}
+	licenseExceptionTag = licenseExceptionDependencyTag{}
 )
 
 func init() {
@@ -43,6 +48,9 @@ func RegisterLicenseBuildComponents(ctx RegistrationContext) {
 type licenseProperties struct {
 	// Specifies the kinds of license that apply.
 	License_kinds []string
+	// Specifies the exceptions to the conditions of the license kinds, e.g. the classpath
+	// exception. Must reference license_exception modules.
+	License_exceptions []string
 	// Specifies a short copyright notice to use for the license.
 	Copyright_notice *string
 	// Specifies the path or label for the text of the license.
@@ -61,6 +69,9 @@ type licenseModule struct {
 	BazelModuleBase
 
 	properties licenseProperties
+
+	// Why the conditions of the license differ from those of its license kinds.
+	conditionReasons []string
 }
 
 type bazelLicenseAttributes struct {
@@ -111,6 +122,7 @@ func (m *licenseModule) DepsMutator(ctx BottomUpMutatorContext) {
 		}
 	}
 	ctx.AddVariationDependencies(nil, licenseKindTag, m.properties.License_kinds...)
+	ctx.AddVariationDependencies(nil, licenseExceptionTag, m.properties.License_exceptions...)
 }
 
 func (m *licenseModule) GenerateAndroidBuildActions(ctx ModuleContext) {
@@ -119,12 +131,39 @@ func (m *licenseModule) GenerateAndroidBuildActions(ctx ModuleContext) {
 	namePathProps(&m.base().commonProperties.Effective_license_text, m.properties.Package_name, PathsForModuleSrc(ctx, m.properties.License_text)...)
 	for _, module := range ctx.GetDirectDepsWithTag(licenseKindTag) {
 		if lk, ok := module.(*licenseKindModule); ok {
-			mergeStringProps(&m.base().commonProperties.Effective_license_conditions, lk.properties.Conditions...)
+			mergeStringProps(&m.base().commonProperties.Declared_license_conditions, lk.properties.Conditions...)
 			mergeStringProps(&m.base().commonProperties.Effective_license_kinds, ctx.OtherModuleName(module))
 		} else {
 			ctx.ModuleErrorf("license_kinds property %q is not a license_kind module", ctx.OtherModuleName(module))
 		}
 	}
+
+	// The exceptions replace the conditions that they waive.
+	declared := m.base().commonProperties.Declared_license_conditions
+	var waived, added []string
+	for _, module := range ctx.GetDirectDepsWithTag(licenseExceptionTag) {
+		le, ok := module.(*licenseExceptionModule)
+		if !ok {
+			ctx.ModuleErrorf("license_exceptions property %q is not a license_exception module", ctx.OtherModuleName(module))
+			continue
+		}
+		name := ctx.OtherModuleName(module)
+		mergeStringProps(&m.base().commonProperties.Effective_license_exceptions, name)
+		for _, c := range le.properties.Waived_conditions {
+			if InList(c, declared) {
+				waived = append(waived, c)
+				m.conditionReasons = append(m.conditionReasons, fmt.Sprintf("%s waived by %s", c, name))
+			}
+		}
+		for _, c := range le.properties.Conditions {
+			if !InList(c, declared) {
+				added = append(added, c)
+				m.conditionReasons = append(m.conditionReasons, fmt.Sprintf("%s added by %s", c, name))
+			}
+		}
+	}
+	mergeStringProps(&m.base().commonProperties.Effective_license_conditions, RemoveListFromList(declared, waived)...)
+	mergeStringProps(&m.base().commonProperties.Effective_license_conditions, added...)
 }
 
 func LicenseFactory() Module {
@@ -0,0 +1,116 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+)
+
+// This file implements the license conditions audit, which lists for legal review the modules
+// whose license conditions differ from the conditions of the license kinds that they declare,
+// either because license exceptions apply to them, or because they inherit restricted conditions
+// from their static dependencies, and why. It is written to out/soong/license_conditions_audit.json
+// during analysis, and `m license-conditions-audit` dists it.
+
+func init() {
+	RegisterLicenseConditionsAuditBuildComponents(InitRegistrationContext)
+}
+
+func RegisterLicenseConditionsAuditBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("license_conditions_audit", licenseConditionsAuditSingletonFactory)
+}
+
+var PrepareForTestWithLicenseConditionsAudit = FixtureRegisterWithContext(RegisterLicenseConditionsAuditBuildComponents)
+
+type licenseConditionsAuditEntry struct {
+	Module             string   `json:"module"`
+	Dir                string   `json:"dir"`
+	Licenses           []string `json:"licenses"`
+	Exceptions         []string `json:"exceptions,omitempty"`
+	DeclaredConditions []string `json:"declared_conditions"`
+	Conditions         []string `json:"conditions"`
+	Reasons            []string `json:"reasons"`
+}
+
+func licenseConditionsAuditSingletonFactory() Singleton {
+	return &licenseConditionsAuditSingleton{}
+}
+
+type licenseConditionsAuditSingleton struct {
+	audit WritablePath
+}
+
+func (s *licenseConditionsAuditSingleton) GenerateBuildActions(ctx SingletonContext) {
+	// The entries of the modules by name, merging the variants of each module.
+	entries := make(map[string]*licenseConditionsAuditEntry)
+
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() || !ctx.ModuleHasProvider(module, LicenseConditionsInfoProvider) {
+			return
+		}
+		info := ctx.ModuleProvider(module, LicenseConditionsInfoProvider).(LicenseConditionsInfo)
+		if len(info.Reasons) == 0 {
+			return
+		}
+		m := module.base()
+		name := ctx.ModuleName(module)
+		entry := entries[name]
+		if entry == nil {
+			entry = &licenseConditionsAuditEntry{
+				Module:             name,
+				Dir:                ctx.ModuleDir(module),
+				Licenses:           []string{},
+				DeclaredConditions: []string{},
+			}
+			entries[name] = entry
+		}
+		mergeStringProps(&entry.Licenses, m.commonProperties.Effective_licenses...)
+		mergeStringProps(&entry.Exceptions, m.commonProperties.Effective_license_exceptions...)
+		mergeStringProps(&entry.DeclaredConditions, info.Declared...)
+		mergeStringProps(&entry.Conditions, info.Conditions...)
+		mergeStringProps(&entry.Reasons, info.Reasons...)
+	})
+
+	var audit []*licenseConditionsAuditEntry
+	for _, name := range SortedKeys(entries) {
+		audit = append(audit, entries[name])
+	}
+
+	data, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the license conditions audit: %s", err)
+		return
+	}
+	// The audit is written during analysis rather than by a rule so that its contents aren't part of
+	// the ninja file.
+	s.audit = PathForOutput(ctx, "license_conditions_audit.json")
+	if err := WriteFileToOutputDir(s.audit, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", s.audit, err)
+		return
+	}
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: s.audit,
+	})
+
+	ctx.Phony("license-conditions-audit", s.audit)
+}
+
+func (s *licenseConditionsAuditSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.audit != nil {
+		ctx.DistForGoal("license-conditions-audit", s.audit)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLicenseConditionsAudit(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForLicenseTest,
+		PrepareForTestWithLicenseConditionsAudit,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("mock_library", newMockLicensesLibraryModule)
+		}),
+		FixtureAddTextFile("top/Android.bp", `
+			license_kind {
+				name: "gpl",
+				conditions: ["restricted", "notice"],
+			}
+
+			license_kind {
+				name: "apache",
+				conditions: ["notice"],
+			}
+
+			license_exception {
+				name: "classpath_exception",
+				waived_conditions: ["restricted"],
+				conditions: ["restricted_with_classpath_exception"],
+			}
+
+			license {
+				name: "top_gpl",
+				license_kinds: ["gpl"],
+			}
+
+			license {
+				name: "top_gpl_with_classpath_exception",
+				license_kinds: ["gpl"],
+				license_exceptions: ["classpath_exception"],
+			}
+
+			license {
+				name: "top_apache",
+				license_kinds: ["apache"],
+			}
+
+			mock_library {
+				name: "libgpl",
+				licenses: ["top_gpl"],
+			}
+
+			mock_library {
+				name: "libexception",
+				licenses: ["top_gpl_with_classpath_exception"],
+			}
+
+			mock_library {
+				name: "libfoo",
+				licenses: ["top_apache"],
+				deps: ["libgpl"],
+			}
+
+			mock_library {
+				name: "libbar",
+				licenses: ["top_apache"],
+				deps: ["libexception"],
+			}
+
+			mock_library {
+				name: "libbaz",
+				licenses: ["top_apache"],
+				deps: ["libfoo"],
+			}
+
+			mock_library {
+				name: "libqux",
+				licenses: ["top_apache"],
+				shared_deps: ["libgpl"],
+			}
+		`),
+	).RunTest(t)
+
+	// libqux links libgpl dynamically, so it doesn't inherit its conditions.
+	audit, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), "license_conditions_audit.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertStringEquals(t, "audit", `[
+  {
+    "module": "libbaz",
+    "dir": "top",
+    "licenses": [
+      "top_apache"
+    ],
+    "declared_conditions": [
+      "notice"
+    ],
+    "conditions": [
+      "notice",
+      "restricted"
+    ],
+    "reasons": [
+      "restricted inherited from the static dependencies libfoo"
+    ]
+  },
+  {
+    "module": "libexception",
+    "dir": "top",
+    "licenses": [
+      "top_gpl_with_classpath_exception"
+    ],
+    "exceptions": [
+      "classpath_exception"
+    ],
+    "declared_conditions": [
+      "notice",
+      "restricted"
+    ],
+    "conditions": [
+      "notice",
+      "restricted_with_classpath_exception"
+    ],
+    "reasons": [
+      "restricted waived by classpath_exception",
+      "restricted_with_classpath_exception added by classpath_exception"
+    ]
+  },
+  {
+    "module": "libfoo",
+    "dir": "top",
+    "licenses": [
+      "top_apache"
+    ],
+    "declared_conditions": [
+      "notice"
+    ],
+    "conditions": [
+      "notice",
+      "restricted"
+    ],
+    "reasons": [
+      "restricted inherited from the static dependencies libgpl"
+    ]
+  }
+]
+`, string(audit))
+}
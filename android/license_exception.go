@@ -0,0 +1,71 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+func init() {
+	RegisterLicenseExceptionBuildComponents(InitRegistrationContext)
+}
+
+// Register the license_exception module type.
+func RegisterLicenseExceptionBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterModuleType("license_exception", LicenseExceptionFactory)
+}
+
+type licenseExceptionProperties struct {
+	// Specifies the conditions of the license kinds that the exception waives, e.g. "restricted"
+	// for the classpath exception of the GPL.
+	Waived_conditions []string
+	// Specifies the conditions that apply instead of the waived ones, e.g.
+	// "restricted_with_classpath_exception".
+	Conditions []string
+	// Specifies the url to the canonical exception definition.
+	Url string
+	// Specifies where this exception can be used
+	Visibility []string
+}
+
+type licenseExceptionModule struct {
+	ModuleBase
+	DefaultableModuleBase
+
+	properties licenseExceptionProperties
+}
+
+func (m *licenseExceptionModule) DepsMutator(ctx BottomUpMutatorContext) {
+	// Nothing to do.
+}
+
+func (m *licenseExceptionModule) GenerateAndroidBuildActions(ModuleContext) {
+	// Nothing to do.
+}
+
+// license_exception is an exception to the conditions of the license kinds of a license, e.g. the
+// classpath exception of the GPL, referenced by the license_exceptions property of license modules.
+// The waived conditions don't apply to the modules using the license, and so don't propagate to the
+// modules that statically link them.
+func LicenseExceptionFactory() Module {
+	module := &licenseExceptionModule{}
+
+	base := module.base()
+	module.AddProperties(&base.nameProperties, &module.properties)
+
+	// The visibility property needs to be checked and parsed by the visibility module.
+	setPrimaryVisibilityProperty(module, "visibility", &module.properties.Visibility)
+
+	initAndroidModuleBase(module)
+	InitDefaultableModule(module)
+
+	return module
+}
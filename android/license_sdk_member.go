@@ -82,6 +82,9 @@ type licenseSdkMemberProperties struct {
 	// The kinds of licenses provided by the module.
 	License_kinds []string
 
+	// The exceptions to the conditions of the kinds of licenses.
+	License_exceptions []string
+
 	// The source paths to the files containing license text.
 	License_text Paths
 }
@@ -90,6 +93,7 @@ func (p *licenseSdkMemberProperties) PopulateFromVariant(_ SdkMemberContext, var
 	// Populate the properties from the variant.
 	l := variant.(*licenseModule)
 	p.License_kinds = l.properties.License_kinds
+	p.License_exceptions = l.properties.License_exceptions
 	p.License_text = make(Paths, 0, len(l.base().commonProperties.Effective_license_text))
 	for _, np := range l.base().commonProperties.Effective_license_text {
 		p.License_text = append(p.License_text, np.Path)
@@ -101,6 +105,9 @@ func (p *licenseSdkMemberProperties) AddToPropertySet(ctx SdkMemberContext, prop
 	if len(p.License_kinds) > 0 {
 		propertySet.AddProperty("license_kinds", p.License_kinds)
 	}
+	if len(p.License_exceptions) > 0 {
+		propertySet.AddProperty("license_exceptions", p.License_exceptions)
+	}
 
 	// Copy any license test files to the snapshot into a module specific location.
 	if len(p.License_text) > 0 {
@@ -15,7 +15,9 @@
 package android
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/google/blueprint"
@@ -26,7 +28,8 @@ import (
 // Stage 1 - bottom-up records package-level default_applicable_licenses property mapped by package name.
 // Stage 2 - bottom-up converts licenses property or package default_applicable_licenses to dependencies.
 // Stage 3 - bottom-up type-checks every added applicable license dependency and license_kind dependency.
-// Stage 4 - GenerateBuildActions calculates properties for the union of license kinds, conditions and texts.
+// Stage 4 - GenerateBuildActions calculates properties for the union of license kinds, conditions and texts,
+//           and the conditions inherited from static dependencies.
 
 type licensesDependencyTag struct {
 	blueprint.BaseDependencyTag
@@ -51,6 +54,12 @@ var (
 	_ SdkMemberDependencyTag = licensesTag
 )
 
+// LicenseStaticLinkDependencyTag is implemented by the dependency tags of the dependencies that may
+// be linked statically into the module, whose restricted license conditions apply to the module.
+type LicenseStaticLinkDependencyTag interface {
+	LicenseStaticLink() bool
+}
+
 // Describes the property provided by a module to reference applicable licenses.
 type applicableLicensesProperty interface {
 	// The name of the property. e.g. default_applicable_licenses or licenses
@@ -166,12 +175,18 @@ func licensesDependencyChecker(ctx BottomUpMutatorContext) {
 	}
 
 	// license modules have no licenses, but license_kinds must refer to license_kind modules
+	// and license_exceptions to license_exception modules
 	if _, ok := m.(*licenseModule); ok {
 		for _, module := range ctx.GetDirectDepsWithTag(licenseKindTag) {
 			if _, ok := module.(*licenseKindModule); !ok {
 				ctx.ModuleErrorf("license_kinds property %q is not a license_kind module", ctx.OtherModuleName(module))
 			}
 		}
+		for _, module := range ctx.GetDirectDepsWithTag(licenseExceptionTag) {
+			if _, ok := module.(*licenseExceptionModule); !ok {
+				ctx.ModuleErrorf("license_exceptions property %q is not a license_exception module", ctx.OtherModuleName(module))
+			}
+		}
 		return
 	}
 
@@ -206,9 +221,11 @@ func licensesPropertyFlattener(ctx ModuleContext) {
 	}
 
 	var licenses []string
+	var reasons []string
 	for _, module := range ctx.GetDirectDepsWithTag(licensesTag) {
 		if l, ok := module.(*licenseModule); ok {
 			licenses = append(licenses, ctx.OtherModuleName(module))
+			reasons = append(reasons, l.conditionReasons...)
 			if m.base().commonProperties.Effective_package_name == nil && l.properties.Package_name != nil {
 				m.base().commonProperties.Effective_package_name = l.properties.Package_name
 			}
@@ -216,6 +233,8 @@ func licensesPropertyFlattener(ctx ModuleContext) {
 			mergeNamedPathProps(&m.base().commonProperties.Effective_license_text, module.base().commonProperties.Effective_license_text...)
 			mergeStringProps(&m.base().commonProperties.Effective_license_kinds, module.base().commonProperties.Effective_license_kinds...)
 			mergeStringProps(&m.base().commonProperties.Effective_license_conditions, module.base().commonProperties.Effective_license_conditions...)
+			mergeStringProps(&m.base().commonProperties.Declared_license_conditions, module.base().commonProperties.Declared_license_conditions...)
+			mergeStringProps(&m.base().commonProperties.Effective_license_exceptions, module.base().commonProperties.Effective_license_exceptions...)
 		} else {
 			propertyName := "licenses"
 			primaryProperty := m.base().primaryLicensesProperty
@@ -231,6 +250,42 @@ func licensesPropertyFlattener(ctx ModuleContext) {
 		Licenses: licenses,
 	}
 	ctx.SetProvider(LicenseInfoProvider, licenseInfo)
+
+	// The restricted conditions of the static dependencies, whose dependency tags implement
+	// LicenseStaticLinkDependencyTag, apply to the modules linking them, unless the license
+	// exceptions of the dependencies waive them. The license metadata records the
+	// dependencies rather than these conditions, so that the compliance tools propagate them too.
+	conditions := CopyOf(m.base().commonProperties.Effective_license_conditions)
+	inheritedFrom := make(map[string][]string)
+	ctx.VisitDirectDepsBlueprint(func(bpdep blueprint.Module) {
+		dep, _ := bpdep.(Module)
+		if dep == nil || !dep.Enabled() {
+			return
+		}
+		tag, ok := ctx.OtherModuleDependencyTag(dep).(LicenseStaticLinkDependencyTag)
+		if !ok || !tag.LicenseStaticLink() {
+			return
+		}
+		if !ctx.OtherModuleHasProvider(dep, LicenseConditionsInfoProvider) {
+			return
+		}
+		info := ctx.OtherModuleProvider(dep, LicenseConditionsInfoProvider).(LicenseConditionsInfo)
+		for _, c := range info.Conditions {
+			if InList(c, staticallyLinkedLicenseConditions) && !InList(c, conditions) {
+				inheritedFrom[c] = append(inheritedFrom[c], ctx.OtherModuleName(dep))
+			}
+		}
+	})
+	for _, c := range SortedKeys(inheritedFrom) {
+		conditions = append(conditions, c)
+		reasons = append(reasons, fmt.Sprintf("%s inherited from the static dependencies %s",
+			c, strings.Join(FirstUniqueStrings(inheritedFrom[c]), ", ")))
+	}
+	ctx.SetProvider(LicenseConditionsInfoProvider, LicenseConditionsInfo{
+		Declared:   m.base().commonProperties.Declared_license_conditions,
+		Conditions: SortedUniqueStrings(conditions),
+		Reasons:    SortedUniqueStrings(reasons),
+	})
 }
 
 // Update a property string array with a distinct union of its values and a list of new values.
@@ -303,6 +358,7 @@ func exemptFromRequiredApplicableLicensesProperty(module Module) bool {
 	switch reflect.TypeOf(module).String() {
 	case "*android.licenseModule": // is a license, doesn't need one
 	case "*android.licenseKindModule": // is a license, doesn't need one
+	case "*android.licenseExceptionModule": // is a license, doesn't need one
 	case "*android.genNoticeModule": // contains license texts as data
 	case "*android.NamespaceModule": // just partitions things, doesn't add anything
 	case "*android.soongConfigModuleTypeModule": // creates aliases for modules with licenses
@@ -324,6 +380,26 @@ type LicenseInfo struct {
 
 var LicenseInfoProvider = blueprint.NewProvider(LicenseInfo{})
 
+// The license conditions that apply to the modules that statically link a module with them.
+var staticallyLinkedLicenseConditions = []string{
+	"restricted",
+	"restricted_allows_dynamic_linking",
+	"restricted_if_statically_linked",
+}
+
+// LicenseConditionsInfo contains information about the license conditions of a specific module.
+type LicenseConditionsInfo struct {
+	// The conditions of the license kinds of the licenses of the module.
+	Declared []string
+	// The conditions that apply to the module: the declared ones with the license exceptions
+	// applied, and the restricted conditions inherited from its static dependencies.
+	Conditions []string
+	// Why the conditions differ from the declared ones.
+	Reasons []string
+}
+
+var LicenseConditionsInfoProvider = blueprint.NewProvider(LicenseConditionsInfo{})
+
 func init() {
 	RegisterMakeVarsProvider(pctx, licensesMakeVarsProvider)
 }
@@ -452,6 +452,70 @@ var licensesTests = []struct {
 			"other":  []string{"prebuilt", "top_sources"},
 		},
 	},
+	{
+		name: "license exceptions replace the waived conditions",
+		fs: map[string][]byte{
+			"top/Android.bp": []byte(`
+				license_kind {
+					name: "gpl",
+					conditions: ["restricted", "notice"],
+				}
+
+				license_exception {
+					name: "classpath_exception",
+					waived_conditions: ["restricted"],
+					conditions: ["restricted_with_classpath_exception"],
+				}
+
+				license {
+					name: "top_gpl_with_classpath_exception",
+					license_kinds: ["gpl"],
+					license_exceptions: ["classpath_exception"],
+				}
+
+				license {
+					name: "top_gpl",
+					license_kinds: ["gpl"],
+				}
+
+				mock_library {
+					name: "libexception",
+					licenses: ["top_gpl_with_classpath_exception"],
+				}
+
+				mock_library {
+					name: "libgpl",
+					licenses: ["top_gpl"],
+				}`),
+		},
+		effectiveKinds: map[string][]string{
+			"libexception": []string{"gpl"},
+			"libgpl":       []string{"gpl"},
+		},
+		effectiveConditions: map[string][]string{
+			"libexception": []string{"notice", "restricted_with_classpath_exception"},
+			"libgpl":       []string{"notice", "restricted"},
+		},
+	},
+	{
+		name: "license exceptions must be license_exception modules",
+		fs: map[string][]byte{
+			"top/Android.bp": []byte(`
+				license_kind {
+					name: "gpl",
+					conditions: ["restricted"],
+				}
+
+				license {
+					name: "top_gpl",
+					license_kinds: ["gpl"],
+					license_exceptions: ["gpl"],
+				}`),
+		},
+		expectedErrors: []string{
+			`license_exceptions property "gpl" is not a license_exception module`,
+		},
+	},
 }
 
 func TestLicenses(t *testing.T) {
@@ -793,7 +857,8 @@ func (m *mockLicensesBadModule) GenerateAndroidBuildActions(ModuleContext) {
 }
 
 type mockLicensesLibraryProperties struct {
-	Deps []string
+	Deps        []string
+	Shared_deps []string
 }
 
 type mockLicensesLibraryModule struct {
@@ -815,8 +880,14 @@ type dependencyLicensesTag struct {
 	name string
 }
 
+// The deps are linked statically, the shared_deps aren't.
+func (d dependencyLicensesTag) LicenseStaticLink() bool {
+	return d.name == "mockdeps"
+}
+
 func (j *mockLicensesLibraryModule) DepsMutator(ctx BottomUpMutatorContext) {
 	ctx.AddVariationDependencies(nil, dependencyLicensesTag{name: "mockdeps"}, j.properties.Deps...)
+	ctx.AddVariationDependencies(nil, dependencyLicensesTag{name: "mockshareddeps"}, j.properties.Shared_deps...)
 }
 
 func (p *mockLicensesLibraryModule) GenerateAndroidBuildActions(ModuleContext) {
@@ -182,6 +182,7 @@ func makeVarsSingletonFunc() Singleton {
 type makeVarsSingleton struct {
 	varsForTesting     []makeVarsVariable
 	installsForTesting []byte
+	distsForTesting    []dist
 }
 
 type makeVarsProvider struct {
@@ -279,6 +280,8 @@ func (s *makeVarsSingleton) GenerateBuildActions(ctx SingletonContext) {
 		}
 	})
 
+	dists = append(dists, buildDistProvenance(ctx, dists)...)
+
 	if ctx.Failed() {
 		return
 	}
@@ -325,6 +328,7 @@ func (s *makeVarsSingleton) GenerateBuildActions(ctx SingletonContext) {
 	if ctx.Config().RunningInsideUnitTest() {
 		s.varsForTesting = vars
 		s.installsForTesting = installsBytes
+		s.distsForTesting = dists
 	}
 }
 
@@ -0,0 +1,190 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// This file implements the min_sdk_version check of the modules that set
+// enforce_min_sdk_version, outside of the apexes: every dependency that is built into the module,
+// transitively, must support the min_sdk_version of the module.
+//
+// The dependencies that are known not to support it are waived in the MinSdkVersionWaivers file
+// of the product, which also applies to the min_sdk_version check of the updatable apexes. It is a
+// JSON list of the waived dependencies of each module, with the reason of the waiver:
+//
+//	[
+//	  {
+//	    "module": "com.android.foo",
+//	    "dependency": "libbar",
+//	    "reason": "libbar is only used on the devices that support it, b/123456"
+//	  }
+//	]
+//
+// A waived dependency isn't checked, nor are its own dependencies.
+
+// minSdkVersionWaiver is an entry of the MinSdkVersionWaivers file.
+type minSdkVersionWaiver struct {
+	Module     string `json:"module"`
+	Dependency string `json:"dependency"`
+	Reason     string `json:"reason"`
+}
+
+var minSdkVersionWaiversKey = NewOnceKey("MinSdkVersionWaivers")
+
+type minSdkVersionWaivers struct {
+	// The reasons of the waived dependencies, by module and dependency names.
+	reasons map[string]map[string]string
+	err     error
+}
+
+// parseMinSdkVersionWaivers returns the reasons of the waived dependencies of a waiver file, by
+// module and dependency names.
+func parseMinSdkVersionWaivers(data []byte) (map[string]map[string]string, error) {
+	var waivers []minSdkVersionWaiver
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&waivers); err != nil {
+		return nil, err
+	}
+
+	reasons := make(map[string]map[string]string)
+	for i, w := range waivers {
+		if w.Module == "" || w.Dependency == "" {
+			return nil, fmt.Errorf("waiver %d: expected a module and a dependency", i)
+		}
+		if w.Reason == "" {
+			return nil, fmt.Errorf("waiver %d: expected the reason of the waiver of %q for %q", i, w.Dependency, w.Module)
+		}
+		if _, exists := reasons[w.Module][w.Dependency]; exists {
+			return nil, fmt.Errorf("waiver %d: %q is already waived for %q", i, w.Dependency, w.Module)
+		}
+		if reasons[w.Module] == nil {
+			reasons[w.Module] = make(map[string]string)
+		}
+		reasons[w.Module][w.Dependency] = w.Reason
+	}
+	return reasons, nil
+}
+
+func getMinSdkVersionWaivers(ctx BaseModuleContext) minSdkVersionWaivers {
+	return ctx.Config().Once(minSdkVersionWaiversKey, func() interface{} {
+		data, err := ctx.Config().MinSdkVersionWaivers(ctx)
+		if err != nil {
+			return minSdkVersionWaivers{err: fmt.Errorf("failed to read the min_sdk_version waivers: %s", err)}
+		}
+		if data == nil {
+			return minSdkVersionWaivers{}
+		}
+		reasons, err := parseMinSdkVersionWaivers(data)
+		if err != nil {
+			return minSdkVersionWaivers{err: fmt.Errorf("invalid min_sdk_version waivers: %s", err)}
+		}
+		return minSdkVersionWaivers{reasons: reasons}
+	}).(minSdkVersionWaivers)
+}
+
+// waived returns true if the min_sdk_version check of a module is waived for a dependency.
+func (w minSdkVersionWaivers) waived(module, dep string) bool {
+	_, waived := w.reasons[module][dep]
+	return waived
+}
+
+// skipMinSdkVersionCheck returns true if the min_sdk_version of the dependencies isn't checked for
+// the current module variant.
+func skipMinSdkVersionCheck(ctx ModuleContext) bool {
+	// do not enforce min_sdk_version for host
+	if ctx.Host() {
+		return true
+	}
+
+	// do not enforce for coverage build
+	return ctx.Config().IsEnvTrue("EMMA_INSTRUMENT") || ctx.DeviceConfig().NativeCoverageEnabled() ||
+		ctx.DeviceConfig().ClangCoverageEnabled()
+}
+
+// moduleMinSdkVersion returns the min_sdk_version of a module, which is either an SdkContext or a
+// native module that returns its raw min_sdk_version.
+func moduleMinSdkVersion(ctx ModuleContext, module Module) (ApiLevel, error) {
+	switch m := module.(type) {
+	case interface {
+		MinSdkVersion(ctx EarlyModuleContext) ApiLevel
+	}:
+		return m.MinSdkVersion(ctx), nil
+	case interface{ MinSdkVersion() string }:
+		if m.MinSdkVersion() == "" {
+			return NoneApiLevel, nil
+		}
+		return ApiLevelFromUser(ctx, m.MinSdkVersion())
+	}
+	return NoneApiLevel, nil
+}
+
+// checkEnforcedMinSdkVersion checks that the dependencies that are built into a module that sets
+// enforce_min_sdk_version support its min_sdk_version.
+func checkEnforcedMinSdkVersion(ctx ModuleContext) {
+	if skipMinSdkVersionCheck(ctx) {
+		return
+	}
+
+	minSdkVersion, err := moduleMinSdkVersion(ctx, ctx.Module())
+	if err != nil {
+		ctx.PropertyErrorf("min_sdk_version", "%s", err)
+		return
+	}
+	if !minSdkVersion.Specified() {
+		ctx.PropertyErrorf("enforce_min_sdk_version", "requires min_sdk_version to be set")
+		return
+	}
+
+	waivers := getMinSdkVersionWaivers(ctx)
+	if waivers.err != nil {
+		ctx.ModuleErrorf("%s", waivers.err)
+		return
+	}
+
+	reported := make(map[string]bool)
+	ctx.WalkDeps(func(child, parent Module) bool {
+		// Only the dependencies that are built into their parent are checked, the others are
+		// behind a stable interface.
+		if _, ok := parent.(DepIsInSameApex); !ok || !IsDepInSameApex(ctx, parent, child) {
+			return false
+		}
+		to, ok := child.(ApexModule)
+		if !ok {
+			return false
+		}
+		toName := ctx.OtherModuleName(to)
+		if waivers.waived(ctx.ModuleName(), toName) {
+			return false
+		}
+		if err := to.ShouldSupportSdkVersion(ctx, minSdkVersion); err != nil {
+			if !reported[toName] {
+				reported[toName] = true
+				ctx.PropertyErrorf("enforce_min_sdk_version", "%q should support min_sdk_version(%v): %v."+
+					"\n\nDependency path: %s\n\n"+
+					"Consider adding 'min_sdk_version: %q' to %q, or waiving it in the MinSdkVersionWaivers file",
+					toName, minSdkVersion, err.Error(),
+					ctx.GetPathString(false),
+					minSdkVersion, toName)
+			}
+			return false
+		}
+		return true
+	})
+}
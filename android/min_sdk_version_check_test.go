@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+func TestParseMinSdkVersionWaivers(t *testing.T) {
+	reasons, err := parseMinSdkVersionWaivers([]byte(`[
+		{"module": "libfoo", "dependency": "libbar", "reason": "b/1"},
+		{"module": "libfoo", "dependency": "libbaz", "reason": "b/2"},
+		{"module": "com.android.foo", "dependency": "libbar", "reason": "b/3"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, "reasons", map[string]map[string]string{
+		"libfoo":          {"libbar": "b/1", "libbaz": "b/2"},
+		"com.android.foo": {"libbar": "b/3"},
+	}, reasons)
+
+	testCases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{
+			name: "no dependency",
+			data: `[{"module": "libfoo", "reason": "b/1"}]`,
+			err:  `waiver 0: expected a module and a dependency`,
+		},
+		{
+			name: "no reason",
+			data: `[{"module": "libfoo", "dependency": "libbar"}]`,
+			err:  `waiver 0: expected the reason of the waiver of "libbar" for "libfoo"`,
+		},
+		{
+			name: "duplicate",
+			data: `[
+				{"module": "libfoo", "dependency": "libbar", "reason": "b/1"},
+				{"module": "libfoo", "dependency": "libbar", "reason": "b/2"}
+			]`,
+			err: `waiver 1: "libbar" is already waived for "libfoo"`,
+		},
+		{
+			name: "unknown field",
+			data: `[{"module": "libfoo", "dependency": "libbar", "reason": "b/1", "bug": 1}]`,
+			err:  `json: unknown field "bug"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseMinSdkVersionWaivers([]byte(tc.data))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			AssertStringEquals(t, "error", tc.err, err.Error())
+		})
+	}
+}
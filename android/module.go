@@ -638,14 +638,21 @@ type Dist struct {
 	Targets []string `android:"arch_variant"`
 
 	// The name of the output artifact. This defaults to the basename of the output of
-	// the module.
+	// the module. It can only be set if the tag selects a single output file.
+	//
+	// The dest, dir and suffix properties may contain the following variables:
+	//  $(module): the name of the module.
+	//  $(product): the name of the product being built, e.g. "aosp_arm64".
+	//  $(build_id): the BUILD_ID of the build.
+	//  $(version): the platform version name, e.g. "14".
 	Dest *string `android:"arch_variant"`
 
 	// The directory within the dist directory to store the artifact. Defaults to the
 	// top level directory ("").
 	Dir *string `android:"arch_variant"`
 
-	// A suffix to add to the artifact file name (before any extension).
+	// A suffix to add to the artifact file name (before any extension). It is added to
+	// each file if the tag selects more than one output file.
 	Suffix *string `android:"arch_variant"`
 
 	// If true, then the artifact file will be appended with _<product name>. For
@@ -725,6 +732,34 @@ type commonProperties struct {
 	// and so prevent early detection of changes that have broken those modules.
 	Enabled *bool `android:"arch_variant"`
 
+	// Conditions on the build flags of the release configuration that must all hold for the
+	// module to be enabled, e.g.
+	//
+	//	enabled_by_release_flags: [{
+	//	    flag: "RELEASE_FOO",
+	//	}],
+	//
+	// so that trunk stable development can gate whole modules on the releases that launch them.
+	Enabled_by_release_flags []ReleaseFlagCondition
+
+	// The mode, owner, capabilities and SELinux label of the files installed by the module, which
+	// filesystem modules write to the fs_config and file_contexts of the image, e.g.
+	//
+	//	installed_file_attributes: [{
+	//	    files: ["bin/foo"],
+	//	    mode: "0750",
+	//	    user: "AID_SYSTEM",
+	//	    group: "AID_SHELL",
+	//	    capabilities: ["NET_RAW"],
+	//	    selinux_label: "u:object_r:foo_exec:s0",
+	//	}],
+	Installed_file_attributes []InstalledFileAttributes
+
+	// The selinux_policy modules that define the SELinux domains of the module, e.g.
+	// [":foo_policy"]. They are installed along with the module, and must declare the domains of
+	// the executables labeled by installed_file_attributes.
+	Selinux_policy []string
+
 	// Controls the visibility of this module to other modules. Allowable values are one or more of
 	// these formats:
 	//
@@ -783,6 +818,10 @@ type commonProperties struct {
 	Effective_license_kinds []string `blueprint:"mutated"`
 	// License conditions
 	Effective_license_conditions []string `blueprint:"mutated"`
+	// License conditions of the license kinds, before the license exceptions are applied
+	Declared_license_conditions []string `blueprint:"mutated"`
+	// License exceptions
+	Effective_license_exceptions []string `blueprint:"mutated"`
 
 	// control whether this module compiles for 32-bit, 64-bit, or both.  Possible values
 	// are "32" (compile for 32-bit only), "64" (compile for 64-bit only), "both" (compile for both
@@ -812,6 +851,9 @@ type commonProperties struct {
 	// vendor who owns this module
 	Owner *string
 
+	// the name of the team module of the team that owns this module.
+	Team *string
+
 	// whether this module is specific to an SoC (System-On-a-Chip). When set to true,
 	// it is installed into /vendor (or /system/vendor if vendor partition does not exist).
 	// Use `soc_specific` instead for better meaning.
@@ -866,6 +908,11 @@ type commonProperties struct {
 	// names of other modules to install on target if this module is installed
 	Target_required []string `android:"arch_variant"`
 
+	// Whether the device variants of this module check that all their dependencies support its
+	// min_sdk_version, like the contents of an updatable apex. The dependencies that are known not
+	// to support it are listed in the MinSdkVersionWaivers file of the product.
+	Enforce_min_sdk_version *bool
+
 	// The OsType of artifacts that this module variant is responsible for creating.
 	//
 	// Set by osMutator
@@ -1489,6 +1536,10 @@ type ModuleBase struct {
 	katiInstalls katiInstalls
 	katiSymlinks katiInstalls
 
+	// The direct dependencies whose installed files are installed along with the files of this
+	// module, used to explain why files are installed.
+	installDeps []Module
+
 	// The files to copy to the dist as explicitly specified in the .bp file.
 	distFiles TaggedDistFiles
 
@@ -1516,6 +1567,9 @@ type ModuleBase struct {
 
 	// The path to the generated license metadata file for the module.
 	licenseMetadataFile WritablePath
+
+	// The outputs and digests recorded for artifact promotion, see promotion.go.
+	promotedArtifacts []promotedArtifact
 }
 
 // A struct containing all relevant information about a Bazel target converted via bp2build.
@@ -2055,6 +2109,7 @@ func (m *ModuleBase) computeInstallDeps(ctx ModuleContext) ([]*installPathsDepSe
 			// installable.
 			if !dep.IsHideFromMake() && !dep.IsSkipInstall() {
 				installDeps = append(installDeps, dep.base().installFilesDepSet)
+				m.installDeps = append(m.installDeps, dep)
 			}
 			// Add packaging deps even when the dependency is not installed so that uninstallable
 			// modules can still be packaged.  Often the package will be installed instead.
@@ -2136,6 +2191,10 @@ func (m *ModuleBase) Owner() string {
 	return String(m.commonProperties.Owner)
 }
 
+func (m *ModuleBase) Team() string {
+	return String(m.commonProperties.Team)
+}
+
 func (m *ModuleBase) setImageVariation(variant string) {
 	m.commonProperties.ImageVariation = variant
 }
@@ -2174,6 +2233,9 @@ func (m *ModuleBase) InRecovery() bool {
 }
 
 func (m *ModuleBase) RequiredModuleNames() []string {
+	if policies := m.base().selinuxPolicyModuleNames(); len(policies) > 0 {
+		return append(CopyOf(m.base().commonProperties.Required), policies...)
+	}
 	return m.base().commonProperties.Required
 }
 
@@ -2336,6 +2398,7 @@ func (m *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 		baseModuleContext: m.baseModuleContextFactory(blueprintCtx),
 		variables:         make(map[string]string),
 	}
+	ctx.promoted = promotionEnabledForModule(ctx.Config(), ctx.ModuleName())
 
 	m.licenseMetadataFile = PathForModuleOut(ctx, "meta_lic")
 
@@ -2396,6 +2459,8 @@ func (m *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 		})
 
 		licensesPropertyFlattener(ctx)
+		teamPropertyChecker(ctx)
+		installedFileAttributesChecker(ctx)
 		if ctx.Failed() {
 			return
 		}
@@ -2415,6 +2480,10 @@ func (m *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 			ctx.PackageFile(rcDir, filepath.Base(src.String()), src)
 		}
 
+		if Bool(m.commonProperties.Enforce_min_sdk_version) {
+			checkEnforcedMinSdkVersion(ctx)
+		}
+
 		m.vintfFragmentsPaths = PathsForModuleSrc(ctx, m.commonProperties.Vintf_fragments)
 		vintfDir := PathForModuleInstall(ctx, "etc", "vintf", "manifest")
 		for _, src := range m.vintfFragmentsPaths {
@@ -2430,11 +2499,18 @@ func (m *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 			return
 		}
 
+		// The files of the other architectures are installed to other paths, e.g. lib instead of lib64.
+		if ctx.Device() && (ctx.PrimaryArch() || ctx.Arch().ArchType == Common) {
+			checkInstalledFileAttributesFiles(ctx, ctx.packagingSpecs)
+		}
+		selinuxPolicyChecker(ctx, ctx.packagingSpecs)
+
 		m.installFiles = append(m.installFiles, ctx.installFiles...)
 		m.checkbuildFiles = append(m.checkbuildFiles, ctx.checkbuildFiles...)
 		m.packagingSpecs = append(m.packagingSpecs, ctx.packagingSpecs...)
 		m.katiInstalls = append(m.katiInstalls, ctx.katiInstalls...)
 		m.katiSymlinks = append(m.katiSymlinks, ctx.katiSymlinks...)
+		m.promotedArtifacts = append(m.promotedArtifacts, ctx.promotedArtifacts...)
 	} else if ctx.Config().AllowMissingDependencies() {
 		// If the module is not enabled it will not create any build rules, nothing will call
 		// ctx.GetMissingDependencies(), and blueprint will consider the missing dependencies to be unhandled
@@ -2475,26 +2551,59 @@ func (m *ModuleBase) isHandledByBazel(ctx ModuleContext) (MixedBuildBuildable, b
 // name of the nested property to produce the full property, e.g. dist.dest or
 // dists[1].dir.
 func checkDistProperties(ctx *moduleContext, property string, dist *Dist) {
+	name := ctx.Module().base().BaseModuleName()
 	if dist.Dest != nil {
-		_, err := validateSafePath(*dist.Dest)
+		dest, err := expandDistVariables(ctx.Config(), name, *dist.Dest)
+		if err == nil {
+			_, err = validateSafePath(dest)
+		}
 		if err != nil {
 			ctx.PropertyErrorf(property+".dest", "%s", err.Error())
 		}
 	}
 	if dist.Dir != nil {
-		_, err := validateSafePath(*dist.Dir)
+		dir, err := expandDistVariables(ctx.Config(), name, *dist.Dir)
+		if err == nil {
+			_, err = validateSafePath(dir)
+		}
 		if err != nil {
 			ctx.PropertyErrorf(property+".dir", "%s", err.Error())
 		}
 	}
 	if dist.Suffix != nil {
-		if strings.Contains(*dist.Suffix, "/") {
+		suffix, err := expandDistVariables(ctx.Config(), name, *dist.Suffix)
+		if err != nil {
+			ctx.PropertyErrorf(property+".suffix", "%s", err.Error())
+		} else if strings.Contains(suffix, "/") {
 			ctx.PropertyErrorf(property+".suffix", "Suffix may not contain a '/' character.")
 		}
 	}
 
 }
 
+// expandDistVariables expands the variables that the dest, dir and suffix properties of a Dist
+// may contain.
+func expandDistVariables(config Config, module string, s string) (string, error) {
+	return Expand(s, func(name string) (string, error) {
+		switch name {
+		case "module":
+			return module, nil
+		case "product":
+			if !config.HasDeviceProduct() {
+				return "", fmt.Errorf("$(product) is not available without a product")
+			}
+			return config.DeviceProduct(), nil
+		case "build_id":
+			return config.BuildId(), nil
+		case "version":
+			return config.PlatformVersionName(), nil
+		default:
+			return "", fmt.Errorf("unknown variable $(%s), expected one of $(module), $(product), "+
+				"$(build_id) or $(version)", name)
+		}
+	})
+}
+
 type earlyModuleContext struct {
 	blueprint.EarlyModuleContext
 
@@ -2646,6 +2755,11 @@ type moduleContext struct {
 	katiInstalls []katiInstall
 	katiSymlinks []katiInstall
 
+	// For artifact promotion, see promotion.go
+	promoted            bool
+	promotionRuleParams map[blueprint.Rule]blueprint.RuleParams
+	promotedArtifacts   []promotedArtifact
+
 	// For tests
 	buildParams []BuildParams
 	ruleParams  map[blueprint.Rule]blueprint.RuleParams
@@ -2808,12 +2922,21 @@ func (m *moduleContext) Rule(pctx PackageContext, name string, params blueprint.
 		}
 	}
 
+	checkWallClockRule(m, name, params.Command)
+
 	rule := m.bp.Rule(pctx.PackageContext, name, params, argNames...)
 
 	if m.config.captureBuild {
 		m.ruleParams[rule] = params
 	}
 
+	if m.promoted {
+		if m.promotionRuleParams == nil {
+			m.promotionRuleParams = make(map[blueprint.Rule]blueprint.RuleParams)
+		}
+		m.promotionRuleParams[rule] = params
+	}
+
 	return rule
 }
 
@@ -2827,10 +2950,16 @@ func (m *moduleContext) Build(pctx PackageContext, params BuildParams) {
 			m.ModuleName(), strings.Join(missingDeps, ", ")))
 	}
 
+	if m.promoted {
+		params = m.promoteBuildParams(pctx, params)
+	}
+
 	if m.config.captureBuild {
 		m.buildParams = append(m.buildParams, params)
 	}
 
+	recordBuildProvenance(m.config, params)
+
 	bparams := convertBuildParams(params)
 	err := validateBuildParams(bparams)
 	if err != nil {
@@ -3303,6 +3432,7 @@ func (m *moduleContext) packageFile(fullInstallPath InstallPath, srcPath Path, e
 		effectiveLicenseFiles: &licenseFiles,
 		partition:             fullInstallPath.partition,
 	}
+	spec.metadata = m.module.base().installedFileMetadata(spec.relPathInPackage)
 	m.packagingSpecs = append(m.packagingSpecs, spec)
 	return spec
 }
@@ -3775,13 +3905,34 @@ func (c *buildTargetSingleton) GenerateBuildActions(ctx SingletonContext) {
 
 	modulesInDir := make(map[string]Paths)
 
+	// If checkbuild is restricted to some directories, it only builds the modules in them and
+	// the modules that depend on them.
+	var checkbuildModules map[Module]bool
+	if paths := ctx.Config().CheckbuildPaths(); len(paths) > 0 {
+		var modules []Module
+		reverseDeps := make(map[Module][]Module)
+		ctx.VisitAllModules(func(module Module) {
+			modules = append(modules, module)
+			ctx.VisitDirectDeps(module, func(dep Module) {
+				reverseDeps[dep] = append(reverseDeps[dep], module)
+			})
+		})
+		inPaths := func(module Module) bool {
+			return HasAnyPrefix(module.base().blueprintDir+"/", paths)
+		}
+		checkbuildModules = selectCheckbuildModules(modules, inPaths, reverseDeps,
+			ctx.Config().CheckbuildReverseDepsDepth())
+	}
+
 	ctx.VisitAllModules(func(module Module) {
 		blueprintDir := module.base().blueprintDir
 		installTarget := module.base().installTarget
 		checkbuildTarget := module.base().checkbuildTarget
 
 		if checkbuildTarget != nil {
-			checkbuildDeps = append(checkbuildDeps, checkbuildTarget)
+			if checkbuildModules == nil || checkbuildModules[module] {
+				checkbuildDeps = append(checkbuildDeps, checkbuildTarget)
+			}
 			modulesInDir[blueprintDir] = append(modulesInDir[blueprintDir], checkbuildTarget)
 		}
 
@@ -3854,6 +4005,32 @@ func (c *buildTargetSingleton) GenerateBuildActions(ctx SingletonContext) {
 	}
 }
 
+// selectCheckbuildModules returns the modules for which inPaths returns true, and the modules that
+// depend on them through up to depth hops of reverseDeps.
+func selectCheckbuildModules[T comparable](modules []T, inPaths func(T) bool, reverseDeps map[T][]T, depth int) map[T]bool {
+	selected := make(map[T]bool)
+	var frontier []T
+	for _, module := range modules {
+		if inPaths(module) {
+			selected[module] = true
+			frontier = append(frontier, module)
+		}
+	}
+	for i := 0; i < depth && len(frontier) > 0; i++ {
+		var next []T
+		for _, module := range frontier {
+			for _, rdep := range reverseDeps[module] {
+				if !selected[rdep] {
+					selected[rdep] = true
+					next = append(next, rdep)
+				}
+			}
+		}
+		frontier = next
+	}
+	return selected
+}
+
 // Collect information for opening IDE project files in java/jdeps.go.
 type IDEInfo interface {
 	IDEInfo(ideInfo *IdeInfo)
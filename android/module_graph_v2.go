@@ -0,0 +1,144 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// This file writes out/soong/module-graph-v2.json with `m json-module-graph`, next to
+// module-graph.json. Unlike module-graph.json, which is the internal format of Blueprint, it is a
+// versioned format for external tools, which lists every variant of every module with its
+// dependencies on other variants and the data of its providers: its output files, its installed
+// files and the apexes it is in. The format is documented in docs/module_graph.md.
+
+// ModuleGraphV2FileName is the name of the file in the soong output directory that contains
+// the module graph in the version 2 format.
+const ModuleGraphV2FileName = "module-graph-v2.json"
+
+// ModuleGraphV2Version is the version of the format, which is only incremented when fields are
+// removed or their meaning changes. New fields may be added without changing it.
+const ModuleGraphV2Version = 2
+
+func init() {
+	RegisterModuleGraphV2BuildComponents(InitRegistrationContext)
+}
+
+func RegisterModuleGraphV2BuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("module_graph_v2", moduleGraphV2SingletonFactory)
+}
+
+type moduleGraphV2 struct {
+	Version int                   `json:"version"`
+	Modules []moduleGraphV2Module `json:"modules"`
+}
+
+// moduleGraphV2Variant identifies a variant of a module.
+type moduleGraphV2Variant struct {
+	Name    string `json:"name"`
+	Variant string `json:"variant"`
+}
+
+type moduleGraphV2Module struct {
+	moduleGraphV2Variant
+	Type      string `json:"type"`
+	Blueprint string `json:"blueprint"`
+	Enabled   bool   `json:"enabled"`
+	// The variants the variant depends on directly.
+	Deps []moduleGraphV2Variant `json:"deps"`
+	// The default output files of the variant, relative to the top of the tree.
+	Outputs []string `json:"outputs"`
+	// The files installed by the variant, relative to the top of the tree.
+	InstalledFiles []string `json:"installed_files"`
+	// The apex variation of the variant, unset for the platform variants.
+	Apex *moduleGraphV2Apex `json:"apex,omitempty"`
+}
+
+type moduleGraphV2Apex struct {
+	Variation string `json:"variation"`
+	// The apex variations and the apex modules the variant is in.
+	InApexVariants []string `json:"in_apex_variants"`
+	InApexModules  []string `json:"in_apex_modules"`
+}
+
+func moduleGraphV2SingletonFactory() Singleton {
+	return &moduleGraphV2Singleton{}
+}
+
+type moduleGraphV2Singleton struct{}
+
+func (s *moduleGraphV2Singleton) GenerateBuildActions(ctx SingletonContext) {
+	if ctx.Config().BuildMode != GenerateModuleGraph {
+		return
+	}
+
+	variant := func(module Module) moduleGraphV2Variant {
+		return moduleGraphV2Variant{ctx.ModuleName(module), ctx.ModuleSubDir(module)}
+	}
+
+	graph := moduleGraphV2{
+		Version: ModuleGraphV2Version,
+		Modules: []moduleGraphV2Module{},
+	}
+	ctx.VisitAllModules(func(module Module) {
+		m := moduleGraphV2Module{
+			moduleGraphV2Variant: variant(module),
+			Type:                 ctx.ModuleType(module),
+			Blueprint:            ctx.BlueprintFile(module),
+			Enabled:              module.Enabled(),
+			Deps:                 []moduleGraphV2Variant{},
+			Outputs:              []string{},
+			InstalledFiles:       []string{},
+		}
+		ctx.VisitDirectDeps(module, func(dep Module) {
+			m.Deps = append(m.Deps, variant(dep))
+		})
+		if producer, ok := module.(OutputFileProducer); ok && m.Enabled {
+			if outputs, err := producer.OutputFiles(""); err == nil {
+				m.Outputs = append(m.Outputs, outputs.Strings()...)
+			}
+		}
+		for _, file := range module.FilesToInstall() {
+			m.InstalledFiles = append(m.InstalledFiles, file.ToMakePath().String())
+		}
+		if apexInfo := ctx.ModuleProvider(module, ApexInfoProvider).(ApexInfo); !apexInfo.IsForPlatform() {
+			m.Apex = &moduleGraphV2Apex{
+				Variation:      apexInfo.ApexVariationName,
+				InApexVariants: append([]string{}, apexInfo.InApexVariants...),
+				InApexModules:  append([]string{}, apexInfo.InApexModules...),
+			}
+		}
+		graph.Modules = append(graph.Modules, m)
+	})
+	sort.SliceStable(graph.Modules, func(i, j int) bool {
+		if graph.Modules[i].Name != graph.Modules[j].Name {
+			return graph.Modules[i].Name < graph.Modules[j].Name
+		}
+		return graph.Modules[i].Variant < graph.Modules[j].Variant
+	})
+
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the module graph: %s", err)
+		return
+	}
+
+	// No ninja file is written with `m json-module-graph`, so the file is written directly.
+	path := PathForOutput(ctx, ModuleGraphV2FileName)
+	if err := WriteFileToOutputDir(path, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", path, err)
+	}
+}
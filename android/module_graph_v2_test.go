@@ -0,0 +1,91 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModuleGraphV2(t *testing.T) {
+	bp := `
+		component {
+			name: "foo",
+			deps: ["libbar"],
+		}
+
+		component {
+			name: "libbar",
+		}
+	`
+
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		FixtureRegisterWithContext(RegisterModuleGraphV2BuildComponents),
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("component", componentTestModuleFactory)
+		}),
+		FixtureModifyConfig(func(config Config) {
+			config.BuildMode = GenerateModuleGraph
+		}),
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), ModuleGraphV2FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var graph moduleGraphV2
+	if err := json.Unmarshal(data, &graph); err != nil {
+		t.Fatal(err)
+	}
+
+	AssertIntEquals(t, "version", ModuleGraphV2Version, graph.Version)
+
+	var foo *moduleGraphV2Module
+	var variants []string
+	for i, m := range graph.Modules {
+		if !strings.HasPrefix(m.Variant, "android_") {
+			continue
+		}
+		variants = append(variants, m.Name+" "+m.Variant)
+		if m.Name == "foo" && m.Variant == "android_arm64_armv8-a" {
+			foo = &graph.Modules[i]
+		}
+	}
+	AssertArrayString(t, "variants", []string{
+		"foo android_arm64_armv8-a",
+		"foo android_arm_armv7-a-neon",
+		"libbar android_arm64_armv8-a",
+		"libbar android_arm_armv7-a-neon",
+	}, variants)
+	if foo == nil {
+		t.Fatal("missing variant android_arm64_armv8-a of foo")
+	}
+
+	AssertStringEquals(t, "type", "component", foo.Type)
+	AssertStringEquals(t, "blueprint", "Android.bp", foo.Blueprint)
+	AssertBoolEquals(t, "enabled", true, foo.Enabled)
+	AssertDeepEquals(t, "deps", []moduleGraphV2Variant{{"libbar", "android_arm64_armv8-a"}}, foo.Deps)
+	if len(foo.InstalledFiles) != 1 || !strings.HasSuffix(foo.InstalledFiles[0], "target/product/test_device/system/lib64/foo") {
+		t.Errorf("expected foo to install system/lib64/foo, got %q", foo.InstalledFiles)
+	}
+	if foo.Apex != nil {
+		t.Errorf("expected the platform variant not to have apex data, got %+v", foo.Apex)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/google/blueprint"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"testing"
 )
 
@@ -310,6 +311,11 @@ func TestDistErrorChecking(t *testing.T) {
           dir: "../invalid-dir1",
           suffix: "invalid/suffix1",
         },
+        {
+          dest: "$(unknown)",
+          dir: "$(module",
+          suffix: "-$version",
+        },
       ],
  		}
 	`
@@ -324,6 +330,9 @@ func TestDistErrorChecking(t *testing.T) {
 		"\\QAndroid.bp:16:15: module \"foo\": dists[1].dest: Path is outside directory: ../invalid-dest1\\E",
 		"\\QAndroid.bp:17:14: module \"foo\": dists[1].dir: Path is outside directory: ../invalid-dir1\\E",
 		"\\QAndroid.bp:18:17: module \"foo\": dists[1].suffix: Suffix may not contain a '/' character.\\E",
+		"\\QAndroid.bp:21:15: module \"foo\": dists[2].dest: unknown variable $(unknown), expected one of $(module), $(product), $(build_id) or $(version)\\E",
+		"\\QAndroid.bp:22:14: module \"foo\": dists[2].dir: missing )\\E",
+		"\\QAndroid.bp:23:17: module \"foo\": dists[2].suffix: expected '(' after '$', did you mean $(version)?\\E",
 	}
 
 	prepareForModuleTests.
@@ -1064,3 +1073,24 @@ func TestOutputFileForModule(t *testing.T) {
 		AssertArrayString(t, "expected missing deps", tt.missingDeps, ctx.missingDeps)
 	}
 }
+
+func TestSelectCheckbuildModules(t *testing.T) {
+	// libc <- libbase <- libutils <- app
+	reverseDeps := map[string][]string{
+		"libc":     {"libbase"},
+		"libbase":  {"libutils"},
+		"libutils": {"app"},
+	}
+	modules := []string{"libc", "libbase", "libutils", "app", "other"}
+	inPaths := func(module string) bool { return module == "libbase" }
+
+	for depth, expected := range [][]string{
+		{"libbase"},
+		{"libbase", "libutils"},
+		{"app", "libbase", "libutils"},
+		{"app", "libbase", "libutils"},
+	} {
+		selected := selectCheckbuildModules(modules, inPaths, reverseDeps, depth)
+		AssertArrayString(t, "selected modules at depth "+strconv.Itoa(depth), expected, SortedKeys(selected))
+	}
+}
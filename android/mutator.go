@@ -162,6 +162,11 @@ var preArch = []RegisterMutatorFunc{
 	// the prebuilt module and not the source module.
 	RegisterComponentsMutator,
 
+	// Disable the modules whose release flag conditions don't hold.
+	//
+	// Must be run after defaults so that the conditions can be set in defaults modules.
+	RegisterReleaseFlagsMutator,
+
 	// Create an association between prebuilt modules and their corresponding source
 	// modules (if any).
 	//
@@ -681,6 +686,8 @@ func componentDepsMutator(ctx BottomUpMutatorContext) {
 
 func depsMutator(ctx BottomUpMutatorContext) {
 	if m := ctx.Module(); m.Enabled() {
+		addTeamDependency(ctx)
+		addSelinuxPolicyDependencies(ctx)
 		m.DepsMutator(ctx)
 	}
 }
@@ -89,6 +89,17 @@ type NameResolver struct {
 
 	// func telling whether to export a namespace to Kati
 	namespaceExportFilter func(*Namespace) bool
+
+	// The names of the modules of the namespaces exported to Kati.
+	exportedModuleNames sync.Map // map[string]bool
+
+	// If true, references to a module name that is defined in more than one of the namespaces
+	// imported by the referencing namespace are errors.
+	strictResolution bool
+
+	// The references to a module name that is defined in more than one of the namespaces visible
+	// to the referencing namespace, found by ModuleFromName.
+	ambiguousResolutions sync.Map // map[namespaceReference]*namespaceResolution
 }
 
 // NameResolverConfig provides the subset of the Config interface needed by the
@@ -97,6 +108,10 @@ type NameResolverConfig interface {
 	// ExportedNamespaces is the list of namespaces that Soong must export to
 	// make.
 	ExportedNamespaces() []string
+
+	// StrictNamespaceResolution returns true if references to a module name that
+	// is defined in more than one visible namespace are errors.
+	StrictNamespaceResolution() bool
 }
 
 func NewNameResolver(config NameResolverConfig) *NameResolver {
@@ -115,6 +130,7 @@ func NewNameResolver(config NameResolverConfig) *NameResolver {
 	r := &NameResolver{
 		namespacesByDir:       sync.Map{},
 		namespaceExportFilter: namespaceExportFilter,
+		strictResolution:      config.StrictNamespaceResolution(),
 	}
 	r.rootNamespace = r.newNamespace(".")
 	r.rootNamespace.visibleNamespaces = []*Namespace{r.rootNamespace}
@@ -224,6 +240,9 @@ func (r *NameResolver) NewModule(ctx blueprint.NamespaceContext, moduleGroup blu
 		amod.base().commonProperties.NamespaceExportedToMake = ns.exportToKati
 		amod.base().commonProperties.DebugName = module.Name()
 	}
+	if ns.exportToKati {
+		r.exportedModuleNames.Store(module.Name(), true)
+	}
 
 	return ns, nil
 }
@@ -248,6 +267,23 @@ func (r *NameResolver) AllModules() []blueprint.ModuleGroup {
 	return allModules
 }
 
+// ModuleExportedToMake returns true if a module of the name is in a namespace exported to Make.
+func (r *NameResolver) ModuleExportedToMake(name string) bool {
+	_, found := r.exportedModuleNames.Load(name)
+	return found
+}
+
+// ModulesExportedToMake returns the sorted names of the modules of the namespaces exported to Make.
+func (r *NameResolver) ModulesExportedToMake() []string {
+	var names []string
+	r.exportedModuleNames.Range(func(name, _ interface{}) bool {
+		names = append(names, name.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
 func (r *NameResolver) SkippedModuleFromName(moduleName string, namespace blueprint.Namespace) (skipInfos []blueprint.SkippedModuleInfo, skipped bool) {
 	return r.rootNamespace.moduleContainer.SkippedModuleFromName(moduleName, namespace)
 }
@@ -288,9 +324,15 @@ func (r *NameResolver) ModuleFromName(name string, namespace blueprint.Namespace
 		container := namespace.moduleContainer
 		return container.ModuleFromName(moduleName, nil)
 	}
-	for _, candidate := range r.getNamespacesToSearchForModule(namespace) {
+	searchOrder := r.getNamespacesToSearchForModule(namespace)
+	for i, candidate := range searchOrder {
 		group, found = candidate.moduleContainer.ModuleFromName(name, nil)
 		if found {
+			if resolution := r.recordAmbiguousResolution(namespace, name, candidate, searchOrder[i+1:]); resolution != nil &&
+				resolution.ambiguousImports && r.strictResolution {
+				// MissingDependencyError reports the ambiguity.
+				return blueprint.ModuleGroup{}, false
+			}
 			return group, true
 		}
 	}
@@ -338,6 +380,15 @@ func (r *NameResolver) MissingDependencyError(depender string, dependerNamespace
 		return fmt.Errorf(text)
 	}
 
+	if r.strictResolution {
+		if resolution, ok := r.ambiguousResolution(dependerNamespace, depName); ok && resolution.ambiguousImports {
+			return fmt.Errorf("%q depends on module %q, which is defined in namespace %q and also in "+
+				"namespaces %q visible to namespace %q. Use a fully qualified reference like %q.",
+				depender, depName, resolution.ResolvedNamespace, resolution.ShadowedNamespaces,
+				resolution.Namespace, "//"+resolution.ResolvedNamespace+":"+depName)
+		}
+	}
+
 	// determine which namespaces the module can be found in
 	foundInNamespaces := []string{}
 	skippedDepErrors := []error{}
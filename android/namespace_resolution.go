@@ -0,0 +1,196 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/blueprint"
+)
+
+// This file records the references to a module name that is defined in more than one of the
+// namespaces visible to the referencing namespace, and writes out/soong/namespace_resolutions.json,
+// which shows for each of them which namespace was chosen, why, and which modules made the
+// reference. With SOONG_STRICT_NAMESPACE_RESOLUTION=true the references to a name that more than
+// one of the imported namespaces define are errors. A definition in the referencing namespace or
+// one that shadows a module of the root namespace is an intended override, and not an error.
+
+// NamespaceResolutionsFileName is the name of the file in the soong output directory that lists
+// the ambiguous references to modules in namespaces.
+const NamespaceResolutionsFileName = "namespace_resolutions.json"
+
+func init() {
+	RegisterNamespaceResolutionsBuildComponents(InitRegistrationContext)
+}
+
+func RegisterNamespaceResolutionsBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("namespace_resolutions", namespaceResolutionsSingletonFactory)
+}
+
+type namespaceReference struct {
+	namespace string
+	name      string
+}
+
+// namespaceResolution is an ambiguous reference to a module name from a namespace.
+type namespaceResolution struct {
+	// The path of the namespace of the referencing modules.
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// The path of the namespace the reference resolved to.
+	ResolvedNamespace string `json:"resolved_namespace"`
+	// The paths of the other visible namespaces that define the name, in search order.
+	ShadowedNamespaces []string `json:"shadowed_namespaces"`
+	Reason             string   `json:"reason"`
+	// The modules that depend on the resolved module through this reference.
+	ReferencedBy []string `json:"referenced_by"`
+
+	// True if the reference resolved to an imported namespace and another imported namespace
+	// also defines the name, which strict resolution rejects.
+	ambiguousImports bool
+}
+
+// recordAmbiguousResolution records the resolution of name from namespace to the module in
+// resolved if any of the namespaces searched after it also define name, and returns it, or nil
+// if the reference isn't ambiguous.
+func (r *NameResolver) recordAmbiguousResolution(namespace blueprint.Namespace, name string, resolved *Namespace, rest []*Namespace) *namespaceResolution {
+	ns, ok := namespace.(*Namespace)
+	if !ok || ns.visibleNamespaces == nil {
+		// Modules handled before namespaceMutator search all the namespaces.
+		return nil
+	}
+	key := namespaceReference{ns.Path, name}
+	if resolution, ok := r.ambiguousResolutions.Load(key); ok {
+		return resolution.(*namespaceResolution)
+	}
+
+	var shadowed []string
+	ambiguousImports := false
+	for _, candidate := range rest {
+		if _, found := candidate.moduleContainer.ModuleFromName(name, nil); found {
+			shadowed = append(shadowed, candidate.Path)
+			if resolved != ns && candidate != r.rootNamespace {
+				ambiguousImports = true
+			}
+		}
+	}
+	if len(shadowed) == 0 {
+		return nil
+	}
+
+	reason := fmt.Sprintf("namespace %q imports %q before %q", ns.Path, resolved.Path, shadowed)
+	if resolved == ns {
+		reason = fmt.Sprintf("namespace %q defines the module itself", ns.Path)
+	}
+	resolution, _ := r.ambiguousResolutions.LoadOrStore(key, &namespaceResolution{
+		Namespace:          ns.Path,
+		Name:               name,
+		ResolvedNamespace:  resolved.Path,
+		ShadowedNamespaces: shadowed,
+		Reason:             reason,
+		ambiguousImports:   ambiguousImports,
+	})
+	return resolution.(*namespaceResolution)
+}
+
+func (r *NameResolver) ambiguousResolution(namespace blueprint.Namespace, name string) (*namespaceResolution, bool) {
+	ns, ok := namespace.(*Namespace)
+	if !ok {
+		return nil, false
+	}
+	resolution, ok := r.ambiguousResolutions.Load(namespaceReference{ns.Path, name})
+	if !ok {
+		return nil, false
+	}
+	return resolution.(*namespaceResolution), true
+}
+
+func namespaceResolutionsSingletonFactory() Singleton {
+	return &namespaceResolutionsSingleton{}
+}
+
+type namespaceResolutionsSingleton struct{}
+
+func (s *namespaceResolutionsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	// The resolver is only reachable from the namespace modules, and there can't be ambiguous
+	// references without them.
+	var resolver *NameResolver
+	ctx.VisitAllModules(func(module Module) {
+		if ns, ok := module.(*NamespaceModule); ok {
+			resolver = ns.resolver
+		}
+	})
+
+	resolutions := make(map[namespaceReference]*namespaceResolution)
+	if resolver != nil {
+		resolver.ambiguousResolutions.Range(func(key, value interface{}) bool {
+			resolutions[key.(namespaceReference)] = value.(*namespaceResolution)
+			return true
+		})
+
+		referencedBy := make(map[*namespaceResolution]map[string]bool)
+		ctx.VisitAllModules(func(module Module) {
+			ns := resolver.findNamespace(ctx.ModuleDir(module))
+			ctx.VisitDirectDeps(module, func(dep Module) {
+				resolution := resolutions[namespaceReference{ns.Path, ctx.ModuleName(dep)}]
+				if resolution == nil || resolver.findNamespace(ctx.ModuleDir(dep)).Path != resolution.ResolvedNamespace {
+					return
+				}
+				if referencedBy[resolution] == nil {
+					referencedBy[resolution] = make(map[string]bool)
+				}
+				referencedBy[resolution]["//"+ctx.ModuleDir(module)+":"+ctx.ModuleName(module)] = true
+			})
+		})
+		for resolution, modules := range referencedBy {
+			resolution.ReferencedBy = SortedKeys(modules)
+		}
+	}
+
+	report := []*namespaceResolution{}
+	for _, resolution := range resolutions {
+		if resolution.ReferencedBy == nil {
+			resolution.ReferencedBy = []string{}
+		}
+		report = append(report, resolution)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Namespace != report[j].Namespace {
+			return report[i].Namespace < report[j].Namespace
+		}
+		return report[i].Name < report[j].Name
+	})
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the namespace resolutions: %s", err)
+		return
+	}
+
+	// The report is written during analysis so that `m nothing` writes it.
+	path := PathForOutput(ctx, NamespaceResolutionsFileName)
+	if err := WriteFileToOutputDir(path, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", path, err)
+		return
+	}
+
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: path,
+	})
+}
@@ -15,6 +15,8 @@
 package android
 
 import (
+	"encoding/json"
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
@@ -490,6 +492,130 @@ Or did you mean ["b"]?`)).
 		RunTest(t)
 }
 
+// ambiguousNamespacesBp defines module "a" in two namespaces imported by dir3.
+var ambiguousNamespacesBp = map[string]string{
+	"dir1": `
+		soong_namespace {
+		}
+		test_module {
+			name: "a",
+		}
+	`,
+	"dir2": `
+		soong_namespace {
+		}
+		test_module {
+			name: "a",
+		}
+	`,
+	"dir3": `
+		soong_namespace {
+			imports: ["dir1", "dir2"],
+		}
+		test_module {
+			name: "b",
+			deps: ["a"],
+		}
+		test_module {
+			name: "c",
+			deps: ["//dir2:a"],
+		}
+	`,
+}
+
+func TestNamespaceResolutions(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForTestWithNamespace,
+		FixtureRegisterWithContext(RegisterNamespaceResolutionsBuildComponents),
+		dirBpToPreparer(ambiguousNamespacesBp),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), NamespaceResolutionsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resolutions []namespaceResolution
+	if err := json.Unmarshal(data, &resolutions); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []namespaceResolution{{
+		Namespace:          "dir3",
+		Name:               "a",
+		ResolvedNamespace:  "dir1",
+		ShadowedNamespaces: []string{"dir2"},
+		Reason:             `namespace "dir3" imports "dir1" before ["dir2"]`,
+		ReferencedBy:       []string{"//dir3:b"},
+	}}
+	if !reflect.DeepEqual(expected, resolutions) {
+		t.Errorf("expected resolutions %#v, got %#v", expected, resolutions)
+	}
+}
+
+func TestStrictNamespaceResolution(t *testing.T) {
+	GroupFixturePreparers(
+		prepareForTestWithNamespace,
+		FixtureMergeEnv(map[string]string{"SOONG_STRICT_NAMESPACE_RESOLUTION": "true"}),
+		dirBpToPreparer(ambiguousNamespacesBp),
+	).
+		ExtendWithErrorHandler(FixtureExpectsOneErrorPattern(`\Qdir3/Android.bp:5:3: "b" depends on module "a", ` +
+			`which is defined in namespace "dir1" and also in namespaces ["dir2"] visible to namespace "dir3". ` +
+			`Use a fully qualified reference like "//dir1:a".\E`)).
+		RunTest(t)
+}
+
+func TestStrictNamespaceResolutionOverrides(t *testing.T) {
+	// A namespace that defines a module of an imported namespace, or an imported namespace that
+	// defines a module of the root namespace, overrides the module.
+	result := GroupFixturePreparers(
+		prepareForTestWithNamespace,
+		FixtureRegisterWithContext(RegisterNamespaceResolutionsBuildComponents),
+		FixtureMergeEnv(map[string]string{"SOONG_STRICT_NAMESPACE_RESOLUTION": "true"}),
+		dirBpToPreparer(map[string]string{
+			".": `
+				test_module {
+					name: "r",
+					id: "0",
+				}
+			`,
+			"dir1": `
+				soong_namespace {
+				}
+				test_module {
+					name: "a",
+					id: "1",
+				}
+				test_module {
+					name: "r",
+					id: "2",
+				}
+			`,
+			"dir2": `
+				soong_namespace {
+					imports: ["dir1"],
+				}
+				test_module {
+					name: "a",
+					id: "3",
+				}
+				test_module {
+					name: "b",
+					id: "4",
+					deps: ["a", "r"],
+				}
+			`,
+		}),
+	).RunTest(t)
+
+	b := findModuleById(result, "4")
+	if !dependsOn(result, b, findModuleById(result, "3")) {
+		t.Errorf("b doesn't depend on the a of its namespace")
+	}
+	if !dependsOn(result, b, findModuleById(result, "2")) {
+		t.Errorf("b doesn't depend on the r of the imported namespace")
+	}
+}
+
 func TestTwoNamepacesInSameDir(t *testing.T) {
 	GroupFixturePreparers(
 		prepareForTestWithNamespace,
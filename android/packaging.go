@@ -42,6 +42,10 @@ type PackagingSpec struct {
 	effectiveLicenseFiles *Paths
 
 	partition string
+
+	// The metadata declared by the installed_file_attributes property of the module that installs
+	// the file.
+	metadata InstalledFileMetadata
 }
 
 // Get file name of installed package
@@ -58,6 +62,11 @@ func (p *PackagingSpec) RelPathInPackage() string {
 	return p.relPathInPackage
 }
 
+// Path of the file that is installed, or nil if the entry is a symlink
+func (p *PackagingSpec) SrcPath() Path {
+	return p.srcPath
+}
+
 func (p *PackagingSpec) SetRelPathInPackage(relPathInPackage string) {
 	p.relPathInPackage = relPathInPackage
 }
@@ -73,6 +82,11 @@ func (p *PackagingSpec) Partition() string {
 	return p.partition
 }
 
+// Metadata returns the mode, owner, capabilities and SELinux label declared for the file.
+func (p *PackagingSpec) Metadata() InstalledFileMetadata {
+	return p.metadata
+}
+
 type PackageModule interface {
 	Module
 	packagingBase() *PackagingBase
@@ -220,6 +234,20 @@ func (p *PackagingBase) AddDeps(ctx BottomUpMutatorContext, depTag blueprint.Dep
 	}
 }
 
+// AddDepsWithImageVariation is like AddDeps, but adds the dependencies on the given image
+// variation of the modules, e.g. RamdiskVariation.
+func (p *PackagingBase) AddDepsWithImageVariation(ctx BottomUpMutatorContext, depTag blueprint.DependencyTag, image string) {
+	for _, t := range p.getSupportedTargets(ctx) {
+		variations := append(t.Variations(), blueprint.Variation{Mutator: "image", Variation: image})
+		for _, dep := range p.getDepsForArch(ctx, t.Arch.ArchType) {
+			if p.IgnoreMissingDependencies && !ctx.OtherModuleExists(dep) {
+				continue
+			}
+			ctx.AddFarVariationDependencies(variations, depTag, dep)
+		}
+	}
+}
+
 // See PackageModule.GatherPackagingSpecs
 func (p *PackagingBase) GatherPackagingSpecs(ctx ModuleContext) map[string]PackagingSpec {
 	m := make(map[string]PackagingSpec)
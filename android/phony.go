@@ -53,6 +53,8 @@ func (p *phonySingleton) GenerateBuildActions(ctx SingletonContext) {
 		p.phonyMap[phony] = SortedUniquePaths(p.phonyMap[phony])
 	}
 
+	writeSoongTargetsList(ctx, p.phonyList)
+
 	if !ctx.Config().KatiEnabled() {
 		for _, phony := range p.phonyList {
 			ctx.Build(pctx, BuildParams{
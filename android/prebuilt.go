@@ -465,7 +465,8 @@ func PrebuiltPostDepsMutator(ctx BottomUpMutatorContext) {
 }
 
 // usePrebuilt returns true if a prebuilt should be used instead of the source module.  The prebuilt
-// will be used if it is marked "prefer" or if the source module is disabled.
+// will be used if it is marked "prefer" or if the source module is disabled, unless the product
+// overrides the preference with PRODUCT_SOURCE_OVERRIDE_MODULES.
 func (p *Prebuilt) usePrebuilt(ctx TopDownMutatorContext, source Module, prebuilt Module) bool {
 	if p.srcsSupplier != nil && len(p.srcsSupplier(ctx, prebuilt)) == 0 {
 		return false
@@ -483,6 +484,15 @@ func (p *Prebuilt) usePrebuilt(ctx TopDownMutatorContext, source Module, prebuil
 		return true
 	}
 
+	// The product can override the preference of specific modules.
+	name := ctx.OtherModuleName(prebuilt)
+	if InList(name, ctx.Config().SourceOverrideModules()) {
+		return true
+	}
+	if InList(RemoveOptionalPrebuiltPrefix(name), ctx.Config().SourceOverrideModules()) {
+		return false
+	}
+
 	// If the use_source_config_var property is set then it overrides the prefer property setting.
 	if configVar := p.properties.Use_source_config_var; configVar != nil {
 		return !ctx.Config().VendorConfig(proptools.String(configVar.Config_namespace)).Bool(proptools.String(configVar.Var_name))
@@ -0,0 +1,138 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/blueprint"
+)
+
+// This file verifies the sha256 property of prebuilt modules and generates a manifest of the
+// hashes of all the prebuilts used in the build.
+
+func init() {
+	RegisterPrebuiltSha256BuildComponents(InitRegistrationContext)
+}
+
+func RegisterPrebuiltSha256BuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("prebuilt_sha256_manifest", prebuiltSha256ManifestSingletonFactory)
+}
+
+var (
+	checkPrebuiltSha256 = pctx.AndroidStaticRule("checkPrebuiltSha256",
+		blueprint.RuleParams{
+			Command: `actual=$$(sha256sum ${in} | cut -d' ' -f1) && ` +
+				`if [ "$${actual}" != "${sha256}" ]; then ` +
+				`echo "${in}: sha256 mismatch, expected ${sha256} but was $${actual}" >&2; exit 1; fi && ` +
+				`cp -f ${in} ${out}`,
+			Description: "check sha256 $in",
+		},
+		"sha256")
+
+	prebuiltSha256Manifest = pctx.AndroidStaticRule("prebuiltSha256Manifest",
+		blueprint.RuleParams{
+			Command:        `xargs sha256sum < ${out}.rsp > ${out}`,
+			Rspfile:        "${out}.rsp",
+			RspfileContent: "${in}",
+			Description:    "prebuilt sha256 manifest",
+		})
+)
+
+var sha256Regexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// PrebuiltSha256Info is provided by prebuilt modules for the prebuilt files they use.
+type PrebuiltSha256Info struct {
+	// The prebuilt files that are used by the module.
+	Srcs Paths
+}
+
+var PrebuiltSha256InfoProvider = blueprint.NewProvider(PrebuiltSha256Info{})
+
+// CheckPrebuiltSha256 records that the module uses the prebuilt files srcs. If sha256, the value of
+// the sha256 property of the module, is set, srcs must contain a single file and a copy of it is
+// returned that can only be built if its sha256 matches, otherwise srcs is returned.
+func CheckPrebuiltSha256(ctx ModuleContext, srcs Paths, sha256 *string) Paths {
+	ctx.SetProvider(PrebuiltSha256InfoProvider, PrebuiltSha256Info{
+		Srcs: srcs,
+	})
+
+	if sha256 == nil {
+		return srcs
+	}
+	if len(srcs) != 1 {
+		ctx.PropertyErrorf("sha256", "can only be set for a single prebuilt file, found %d", len(srcs))
+		return srcs
+	}
+	expected := strings.ToLower(*sha256)
+	if !sha256Regexp.MatchString(expected) {
+		ctx.PropertyErrorf("sha256", "must be 64 hexadecimal digits, was %q", *sha256)
+		return srcs
+	}
+
+	checked := PathForModuleOut(ctx, "sha256", srcs[0].Base())
+	ctx.Build(pctx, BuildParams{
+		Rule:   checkPrebuiltSha256,
+		Input:  srcs[0],
+		Output: checked,
+		Args: map[string]string{
+			"sha256": expected,
+		},
+	})
+	return Paths{checked}
+}
+
+func prebuiltSha256ManifestSingletonFactory() Singleton {
+	return &prebuiltSha256ManifestSingleton{}
+}
+
+type prebuiltSha256ManifestSingleton struct {
+	manifest WritablePath
+}
+
+func (s *prebuiltSha256ManifestSingleton) GenerateBuildActions(ctx SingletonContext) {
+	var srcs Paths
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() || !IsModulePreferred(module) {
+			return
+		}
+		if ctx.ModuleHasProvider(module, PrebuiltSha256InfoProvider) {
+			info := ctx.ModuleProvider(module, PrebuiltSha256InfoProvider).(PrebuiltSha256Info)
+			srcs = append(srcs, info.Srcs...)
+		}
+	})
+	if len(srcs) == 0 {
+		return
+	}
+	srcs = SortedUniquePaths(srcs)
+
+	s.manifest = PathForOutput(ctx, "prebuilt_sha256s.txt")
+	ctx.Build(pctx, BuildParams{
+		Rule:   prebuiltSha256Manifest,
+		Inputs: srcs,
+		Output: s.manifest,
+	})
+
+	// `m prebuilt-sha256-manifest` generates the list of the sha256 of the prebuilts used in the
+	// build for auditing.
+	ctx.Phony("prebuilt-sha256-manifest", s.manifest)
+}
+
+func (s *prebuiltSha256ManifestSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.manifest != nil {
+		ctx.DistForGoal("prebuilt-sha256-manifest", s.manifest)
+	}
+}
@@ -357,6 +357,56 @@ func TestPrebuilts(t *testing.T) {
 			// used.
 			prebuilt: nil,
 		},
+		{
+			name: "prebuilt preferred - source override",
+			modules: `
+				source {
+					name: "bar",
+				}
+
+				prebuilt {
+					name: "bar",
+					prefer: true,
+					srcs: ["prebuilt_file"],
+				}`,
+			preparer: FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+				variables.SourceOverrideModules = []string{"bar"}
+			}),
+			// The product selects the source module although the prebuilt is preferred.
+			prebuilt: nil,
+		},
+		{
+			name: "prebuilt not preferred - prebuilt override",
+			modules: `
+				source {
+					name: "bar",
+				}
+
+				prebuilt {
+					name: "bar",
+					prefer: false,
+					srcs: ["prebuilt_file"],
+				}`,
+			preparer: FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+				variables.SourceOverrideModules = []string{"prebuilt_bar"}
+			}),
+			// The product selects the prebuilt although it is not preferred.
+			prebuilt: []OsType{Android, buildOS},
+		},
+		{
+			name: "prebuilt preferred - source override, no source",
+			modules: `
+				prebuilt {
+					name: "bar",
+					prefer: true,
+					srcs: ["prebuilt_file"],
+				}`,
+			preparer: FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+				variables.SourceOverrideModules = []string{"bar"}
+			}),
+			// Although the product selects the source module there is no source available.
+			prebuilt: []OsType{Android, buildOS},
+		},
 		{
 			name: "prebuilt use_source_config_var={acme, use_source} - acme_use_source=true, no source",
 			modules: `
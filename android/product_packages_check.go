@@ -0,0 +1,198 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// This file implements the check that the PRODUCT_PACKAGES exist, i.e. that a Soong module that is
+// exported to Make or an Android.mk module has their name, which `m product-validate` runs (see
+// product_validate.go). A missing module is reported with the makefiles that added it to the
+// product and with the existing modules of similar names. The check isn't run by soong_build,
+// which doesn't know the modules of the Android.mk files.
+//
+// The strictness of the check depends on the path of the makefile that lists the missing module,
+// with the longest matching "<path>:<level>" entry of ProductPackagesStrictness, where the level
+// is error, warning or ignore. A module without a matching entry is a warning.
+
+const (
+	productPackagesLevelError   = "error"
+	productPackagesLevelWarning = "warning"
+	productPackagesLevelIgnore  = "ignore"
+
+	// The maximum number of suggestions of a missing module.
+	maxProductPackageSuggestions = 3
+)
+
+// The suffixes of the image variants that PRODUCT_PACKAGES can install, e.g. libfoo.vendor.
+var productPackageImageSuffixes = []string{".vendor", ".product", ".recovery", ".ramdisk", ".vendor_ramdisk"}
+
+// MissingProductPackage is a module of the PRODUCT_PACKAGES that doesn't exist.
+type MissingProductPackage struct {
+	Name        string   `json:"name"`
+	Level       string   `json:"level"`
+	Origin      []string `json:"origin,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+func (p MissingProductPackage) String() string {
+	s := fmt.Sprintf("PRODUCT_PACKAGES module %q doesn't exist", p.Name)
+	if len(p.Origin) > 0 {
+		s += fmt.Sprintf(", it is added by %s", strings.Join(p.Origin, " -> "))
+	}
+	if len(p.Suggestions) > 0 {
+		s += fmt.Sprintf("\nOr did you mean %q?", p.Suggestions)
+	}
+	return s
+}
+
+type productPackagesStrictness struct {
+	path  string
+	level string
+}
+
+func parseProductPackagesStrictness(entries []string) ([]productPackagesStrictness, error) {
+	var ret []productPackagesStrictness
+	for _, entry := range entries {
+		path, level, ok := strings.Cut(entry, ":")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid ProductPackagesStrictness %q, expected <path>:<level>", entry)
+		}
+		switch level {
+		case productPackagesLevelError, productPackagesLevelWarning, productPackagesLevelIgnore:
+		default:
+			return nil, fmt.Errorf("invalid ProductPackagesStrictness %q, the level must be %s, %s or %s",
+				entry, productPackagesLevelError, productPackagesLevelWarning, productPackagesLevelIgnore)
+		}
+		ret = append(ret, productPackagesStrictness{filepath.Clean(path), level})
+	}
+	// The longest paths first, so that the first match is the most specific.
+	sort.SliceStable(ret, func(i, j int) bool {
+		return len(ret[i].path) > len(ret[j].path)
+	})
+	return ret, nil
+}
+
+// productPackagesLevel returns the level of a missing module that is listed by a makefile.
+func productPackagesLevel(strictness []productPackagesStrictness, makefile string) string {
+	makefile = filepath.Clean(makefile)
+	for _, s := range strictness {
+		if s.path == "." || isAncestor(s.path, makefile) {
+			return s.level
+		}
+	}
+	return productPackagesLevelWarning
+}
+
+// missingProductPackages returns the PRODUCT_PACKAGES that defined doesn't know, with the known
+// names that are similar as suggestions. The ignored modules are not returned.
+func missingProductPackages(config Config, defined func(string) bool, known []string) ([]MissingProductPackage, error) {
+	strictness, err := parseProductPackagesStrictness(config.ProductPackagesStrictness())
+	if err != nil {
+		return nil, err
+	}
+
+	missing := []MissingProductPackage{}
+	for _, name := range SortedUniqueStrings(config.ProductPackages()) {
+		if productPackageDefined(name, defined) {
+			continue
+		}
+		origin := config.ProductPackageOrigin(name)
+		level := productPackagesLevelWarning
+		if len(origin) > 0 {
+			level = productPackagesLevel(strictness, origin[len(origin)-1])
+		}
+		if level == productPackagesLevelIgnore {
+			continue
+		}
+		missing = append(missing, MissingProductPackage{
+			Name:        name,
+			Level:       level,
+			Origin:      origin,
+			Suggestions: similarNames(name, known, maxProductPackageSuggestions),
+		})
+	}
+	return missing, nil
+}
+
+func productPackageDefined(name string, defined func(string) bool) bool {
+	if defined(name) {
+		return true
+	}
+	for _, suffix := range productPackageImageSuffixes {
+		if strings.HasSuffix(name, suffix) && defined(strings.TrimSuffix(name, suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// similarNames returns up to max names that are at most a third of the length of name away from
+// it in edit distance, the closest first.
+func similarNames(name string, names []string, max int) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	for _, n := range names {
+		if d := editDistance(name, n); d*3 <= len(name) {
+			candidates = append(candidates, candidate{n, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var ret []string
+	for i := 0; i < len(candidates) && i < max; i++ {
+		ret = append(ret, candidates[i].name)
+	}
+	return ret
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
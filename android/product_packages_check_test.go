@@ -0,0 +1,84 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+func TestMissingProductPackages(t *testing.T) {
+	config := TestConfig(t.TempDir(), nil, "", nil)
+	config.productVariables.ProductPackages = []string{"foo", "foo.vendor", "libfoo_baz", "make_module", "make_modul", "ignored", "fooo"}
+	config.productVariables.ProductPackagesOrigins = map[string][]string{
+		"libfoo_baz": {"device/x/product.mk", "device/x/packages.mk"},
+		"ignored":    {"device/x/product.mk", "vendor/y/packages.mk"},
+		"fooo":       {"device/x/product.mk", "vendor/x/packages.mk"},
+	}
+	config.productVariables.ProductPackagesStrictness = []string{"device:warning", "vendor/y:ignore", "vendor:error"}
+
+	known := []string{"foo", "libfoo_bar", "make_module"}
+	defined := func(name string) bool { return InList(name, known) }
+	missing, err := missingProductPackages(config, defined, known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, "missing product packages", []MissingProductPackage{
+		{
+			Name:        "fooo",
+			Level:       "error",
+			Origin:      []string{"device/x/product.mk", "vendor/x/packages.mk"},
+			Suggestions: []string{"foo"},
+		},
+		{
+			Name:        "libfoo_baz",
+			Level:       "warning",
+			Origin:      []string{"device/x/product.mk", "device/x/packages.mk"},
+			Suggestions: []string{"libfoo_bar"},
+		},
+		{
+			Name:        "make_modul",
+			Level:       "warning",
+			Suggestions: []string{"make_module"},
+		},
+	}, missing)
+	AssertStringEquals(t, "message",
+		`PRODUCT_PACKAGES module "fooo" doesn't exist, it is added by device/x/product.mk -> vendor/x/packages.mk`+"\n"+
+			`Or did you mean ["foo"]?`, missing[0].String())
+}
+
+func TestInvalidProductPackagesStrictness(t *testing.T) {
+	config := TestConfig(t.TempDir(), nil, "", nil)
+	config.productVariables.ProductPackages = []string{"foo"}
+	config.productVariables.ProductPackagesStrictness = []string{"vendor:fatal"}
+	_, err := missingProductPackages(config, func(string) bool { return false }, nil)
+	AssertStringEquals(t, "error",
+		`invalid ProductPackagesStrictness "vendor:fatal", the level must be error, warning or ignore`, err.Error())
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"foo", "", 3},
+		{"foo", "foo", 0},
+		{"foo", "fooo", 1},
+		{"libfoo", "libbar", 3},
+		{"kitten", "sitting", 3},
+	} {
+		AssertIntEquals(t, tc.a+" "+tc.b, tc.expected, editDistance(tc.a, tc.b))
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// This file implements the validation of the product config run by `m product-validate`, which
+// only runs the product config and parses the Android.bp files, without analyzing the modules. It
+// reports the variables of soong.variables that Soong doesn't know, the board settings that
+// conflict with each other, and the PRODUCT_PACKAGES that no Android.bp or Android.mk file defines,
+// as errors or warnings depending on the ProductPackagesStrictness (see product_packages_check.go).
+//
+// The modules of the Android.mk files are found by their LOCAL_MODULE or LOCAL_PACKAGE_NAME
+// assignments. The references to the variables assigned earlier in the same Android.mk file are
+// expanded, and the names that are still computed, e.g. with a Make function, match any value of
+// the computed parts, so that their modules are never reported as missing.
+
+// A pair of product variables that can't be set together. The conflicts that Soong can't load a
+// config with, e.g. GcovCoverage and ClangCoverage, are reported when loading soong.variables.
+type productConfigConflict struct {
+	variables []string
+	conflicts func(v *productVariables) bool
+	reason    string
+}
+
+var productConfigConflicts = []productConfigConflict{
+	{
+		variables: []string{"BoardUsesRecoveryAsBoot", "BoardMoveRecoveryResourcesToVendorBoot"},
+		conflicts: func(v *productVariables) bool {
+			return Bool(v.BoardUsesRecoveryAsBoot) && Bool(v.BoardMoveRecoveryResourcesToVendorBoot)
+		},
+		reason: "the recovery resources can't be in both the boot and the vendor_boot images",
+	},
+	{
+		variables: []string{"Flatten_apex", "CompressedApex"},
+		conflicts: func(v *productVariables) bool {
+			return Bool(v.Flatten_apex) && Bool(v.CompressedApex)
+		},
+		reason: "flattened APEXes can't be compressed",
+	},
+	{
+		variables: []string{"ProductVndkVersion", "DeviceVndkVersion"},
+		conflicts: func(v *productVariables) bool {
+			return String(v.ProductVndkVersion) != "" && String(v.DeviceVndkVersion) == ""
+		},
+		reason: "the product VNDK version requires a device VNDK version",
+	},
+}
+
+var (
+	// The assignments of the names of the modules in the Android.mk files.
+	androidMkModuleNamePattern = regexp.MustCompile(`^\s*LOCAL_(MODULE|PACKAGE_NAME)\s*:?=\s*(\S+)\s*$`)
+	// The assignments of single words to the other variables, e.g. `my_name := foo`.
+	androidMkAssignmentPattern = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+)\s*:?=\s*(\S+)\s*$`)
+	// The references to variables, e.g. `$(my_name)`.
+	androidMkReferencePattern = regexp.MustCompile(`\$[({]([A-Za-z0-9_.-]+)[)}]`)
+)
+
+type ProductConfigConflict struct {
+	Variables []string `json:"variables"`
+	Reason    string   `json:"reason"`
+}
+
+// ProductConfigReport is the result of the validation of the product config.
+type ProductConfigReport struct {
+	UnknownVariables       []string                `json:"unknown_variables"`
+	Conflicts              []ProductConfigConflict `json:"conflicts"`
+	MissingProductPackages []MissingProductPackage `json:"missing_product_packages"`
+}
+
+// Errors returns the messages of the errors of the report.
+func (r ProductConfigReport) Errors() []string {
+	var errs []string
+	for _, v := range r.UnknownVariables {
+		errs = append(errs, fmt.Sprintf("unknown product variable %q", v))
+	}
+	for _, c := range r.Conflicts {
+		errs = append(errs, fmt.Sprintf("conflicting product variables %s: %s",
+			strings.Join(c.Variables, " and "), c.Reason))
+	}
+	for _, p := range r.MissingProductPackages {
+		if p.Level == productPackagesLevelError {
+			errs = append(errs, p.String())
+		}
+	}
+	return errs
+}
+
+// Warnings returns the messages of the warnings of the report, i.e. the missing PRODUCT_PACKAGES
+// that are not errors in the ProductPackagesStrictness.
+func (r ProductConfigReport) Warnings() []string {
+	var warnings []string
+	for _, p := range r.MissingProductPackages {
+		if p.Level == productPackagesLevelWarning {
+			warnings = append(warnings, p.String())
+		}
+	}
+	return warnings
+}
+
+// ValidateProductConfig validates the product config against the modules of the Android.bp files
+// parsed into resolver and of the androidMks files. It returns the Android.mk files that it read,
+// which the validation depends on.
+func ValidateProductConfig(config Config, resolver *NameResolver, androidMks []string) (ProductConfigReport, []string, error) {
+	report := ProductConfigReport{
+		UnknownVariables:       []string{},
+		Conflicts:              []ProductConfigConflict{},
+		MissingProductPackages: []MissingProductPackage{},
+	}
+
+	data, err := os.ReadFile(absolutePath(config.ProductVariablesFileName))
+	if err != nil {
+		return report, nil, fmt.Errorf("failed to read the product variables: %s", err)
+	}
+	unknown, err := unknownProductVariables(data)
+	if err != nil {
+		return report, nil, fmt.Errorf("failed to parse %s: %s", config.ProductVariablesFileName, err)
+	}
+	report.UnknownVariables = unknown
+
+	for _, c := range productConfigConflicts {
+		if c.conflicts(&config.productVariables) {
+			report.Conflicts = append(report.Conflicts, ProductConfigConflict{c.variables, c.reason})
+		}
+	}
+
+	productPackages := config.ProductPackages()
+	if len(productPackages) == 0 {
+		return report, nil, nil
+	}
+	makeModules, err := androidMkModuleNames(config, androidMks)
+	if err != nil {
+		return report, nil, err
+	}
+	defined := func(name string) bool {
+		return makeModules.defined(name) || resolver.ModuleExportedToMake(name)
+	}
+	known := SortedUniqueStrings(append(SortedKeys(makeModules.names), resolver.ModulesExportedToMake()...))
+	report.MissingProductPackages, err = missingProductPackages(config, defined, known)
+	if err != nil {
+		return report, nil, err
+	}
+	return report, androidMks, nil
+}
+
+// unknownProductVariables returns the variables of a soong.variables file that don't match a
+// field of productVariables, case insensitively like encoding/json.
+func unknownProductVariables(data []byte) ([]string, error) {
+	var variables map[string]json.RawMessage
+	if err := json.Unmarshal(data, &variables); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	t := reflect.TypeOf(productVariables{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		known[strings.ToLower(name)] = true
+	}
+
+	unknown := []string{}
+	for name := range variables {
+		if !known[strings.ToLower(name)] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// androidMkModules are the names of the modules of the Android.mk files.
+type androidMkModules struct {
+	names map[string]bool
+	// The names that are computed, which match any value of their computed parts.
+	patterns []*regexp.Regexp
+}
+
+func (m androidMkModules) defined(name string) bool {
+	if m.names[name] {
+		return true
+	}
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// androidMkNamePattern returns the pattern that matches the values of a name computed by Make,
+// where each variable reference or function call matches any string.
+func androidMkNamePattern(name string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i := 0; i < len(name); i++ {
+		if name[i] != '$' || i+1 == len(name) {
+			pattern.WriteString(regexp.QuoteMeta(name[i : i+1]))
+			continue
+		}
+		// Skip the reference, up to its matching parenthesis or brace, or the single character of
+		// a reference like $x.
+		i++
+		if left := name[i]; left == '(' || left == '{' {
+			right := byte(')')
+			if left == '{' {
+				right = '}'
+			}
+			depth := 1
+			for i+1 < len(name) && depth > 0 {
+				i++
+				if name[i] == left {
+					depth++
+				} else if name[i] == right {
+					depth--
+				}
+			}
+		}
+		pattern.WriteString(".*")
+	}
+	pattern.WriteString("$")
+	return regexp.MustCompile(pattern.String())
+}
+
+// androidMkModuleNames returns the names of the modules assigned in the Android.mk files.
+func androidMkModuleNames(config Config, androidMks []string) (androidMkModules, error) {
+	modules := androidMkModules{names: make(map[string]bool)}
+	for _, androidMk := range androidMks {
+		file, err := config.fs.Open(androidMk)
+		if err != nil {
+			return modules, fmt.Errorf("failed to read %s: %s", androidMk, err)
+		}
+		variables := make(map[string]string)
+		expand := func(value string) string {
+			return androidMkReferencePattern.ReplaceAllStringFunc(value, func(ref string) string {
+				if v, ok := variables[androidMkReferencePattern.FindStringSubmatch(ref)[1]]; ok {
+					return v
+				}
+				return ref
+			})
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if match := androidMkModuleNamePattern.FindStringSubmatch(line); match != nil {
+				if name := expand(match[2]); strings.Contains(name, "$") {
+					modules.patterns = append(modules.patterns, androidMkNamePattern(name))
+				} else {
+					modules.names[name] = true
+				}
+			} else if match := androidMkAssignmentPattern.FindStringSubmatch(line); match != nil {
+				variables[match[1]] = expand(match[2])
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return modules, fmt.Errorf("failed to read %s: %s", androidMk, err)
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+func TestValidateProductConfig(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("component", componentTestModuleFactory)
+		}),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.ProductPackages = []string{"foo", "libbar.vendor", "make_app", "make_module", "make_computed", "make_prefixed_foo", "missing"}
+			variables.ProductPackagesOrigins = map[string][]string{
+				"missing": {"device/x/product.mk", "vendor/x/packages.mk"},
+			}
+			variables.ProductPackagesStrictness = []string{"vendor:error"}
+			variables.BoardUsesRecoveryAsBoot = proptools.BoolPtr(true)
+			variables.BoardMoveRecoveryResourcesToVendorBoot = proptools.BoolPtr(true)
+		}),
+		FixtureWithRootAndroidBp(`
+			component {
+				name: "foo",
+			}
+
+			component {
+				name: "libbar",
+			}
+		`),
+		FixtureAddTextFile("vendor/x/Android.mk", `
+LOCAL_PATH := $(call my-dir)
+include $(CLEAR_VARS)
+LOCAL_MODULE := make_module
+include $(BUILD_PREBUILT)
+
+include $(CLEAR_VARS)
+LOCAL_PACKAGE_NAME := make_app
+include $(BUILD_PACKAGE)
+
+my_name := make_computed
+include $(CLEAR_VARS)
+LOCAL_MODULE := $(my_name)
+include $(BUILD_PREBUILT)
+
+include $(CLEAR_VARS)
+LOCAL_MODULE := make_prefixed_$(call get-name,$(TARGET_ARCH))
+include $(BUILD_PREBUILT)
+`),
+	).RunTest(t)
+
+	variablesFile := filepath.Join(t.TempDir(), "soong.variables")
+	if err := os.WriteFile(variablesFile, []byte(`{"DeviceName": "test", "platform_sdk_version": 33, "Unknown_variable": true}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	result.Config.ProductVariablesFileName = variablesFile
+
+	report, _, err := ValidateProductConfig(result.Config, result.NameResolver, []string{"vendor/x/Android.mk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	AssertArrayString(t, "unknown variables", []string{"Unknown_variable"}, report.UnknownVariables)
+	AssertDeepEquals(t, "conflicts", []ProductConfigConflict{{
+		Variables: []string{"BoardUsesRecoveryAsBoot", "BoardMoveRecoveryResourcesToVendorBoot"},
+		Reason:    "the recovery resources can't be in both the boot and the vendor_boot images",
+	}}, report.Conflicts)
+	AssertDeepEquals(t, "missing product packages", []MissingProductPackage{{
+		Name:   "missing",
+		Level:  "error",
+		Origin: []string{"device/x/product.mk", "vendor/x/packages.mk"},
+	}}, report.MissingProductPackages)
+	AssertIntEquals(t, "errors", 3, len(report.Errors()))
+}
+
+func TestAndroidMkNamePattern(t *testing.T) {
+	for _, tc := range []struct {
+		name, value string
+		expected    bool
+	}{
+		{"foo_$(arch)", "foo_arm64", true},
+		{"foo_$(arch)", "bar_arm64", false},
+		{"foo_$(call name,$(arch))_bar", "foo_x_bar", true},
+		{"foo_${arch}.so", "foo_x.so", true},
+		{"foo_${arch}.so", "foo_xxso", false},
+		{"$(name)", "anything", true},
+	} {
+		AssertBoolEquals(t, tc.name+" "+tc.value, tc.expected, androidMkNamePattern(tc.name).MatchString(tc.value))
+	}
+}
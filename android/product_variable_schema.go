@@ -0,0 +1,260 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+)
+
+// This file implements product_variable_schema, which declares the type and the allowed values of
+// product variables so that a misspelled or out of range PRODUCT_ or BOARD_ value fails the build
+// instead of being silently ignored by the code that reads it.
+
+func init() {
+	RegisterProductVariableSchemaBuildComponents(InitRegistrationContext)
+}
+
+func RegisterProductVariableSchemaBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterModuleType("product_variable_schema", ProductVariableSchemaFactory)
+	ctx.RegisterPreSingletonType("product_variable_schema_defaults", productVariableSchemaDefaultsSingletonFactory)
+}
+
+var PrepareForTestWithProductVariableSchema = FixtureRegisterWithContext(RegisterProductVariableSchemaBuildComponents)
+
+// The types of product_variable_schema variables, and the kinds of the productVariables fields
+// they describe.
+var productVariableSchemaTypes = map[string]reflect.Type{
+	"bool":        reflect.TypeOf((*bool)(nil)),
+	"int":         reflect.TypeOf((*int)(nil)),
+	"string":      reflect.TypeOf((*string)(nil)),
+	"string_list": reflect.TypeOf([]string(nil)),
+}
+
+type productVariableSchemaVariable struct {
+	// Name of the product variable as it appears in soong.variables, e.g. "DeviceMaxPageSizeSupported".
+	Name *string
+
+	// Type of the product variable: "bool", "int", "string" or "string_list".
+	Type *string
+
+	// Values that a string variable, or each element of a string_list variable, may have. Any
+	// value is allowed if empty.
+	Values []string
+
+	// Bounds of the value of an int variable.
+	Min *int64
+	Max *int64
+
+	// The value of the variable when the product doesn't set it. The elements of a string_list
+	// default are separated by spaces. It is applied before the mutators run, so the load hooks
+	// still see the variable unset.
+	Default *string
+
+	// When set to true, the variable must be set by the product. Can't be set with default.
+	Required *bool
+}
+
+type productVariableSchemaProperties struct {
+	Variables []productVariableSchemaVariable
+}
+
+type productVariableSchema struct {
+	ModuleBase
+
+	properties productVariableSchemaProperties
+}
+
+// product_variable_schema declares the types and the allowed values of product variables. The
+// values of the product being built are checked against it.
+func ProductVariableSchemaFactory() Module {
+	module := &productVariableSchema{}
+	module.AddProperties(&module.properties)
+	InitAndroidModule(module)
+	return module
+}
+
+func (s *productVariableSchema) GenerateAndroidBuildActions(ctx ModuleContext) {
+	seen := make(map[string]bool)
+	for _, v := range s.properties.Variables {
+		name := String(v.Name)
+		if name == "" {
+			ctx.PropertyErrorf("variables", "name must be set")
+			continue
+		}
+		if seen[name] {
+			ctx.PropertyErrorf("variables", "%s: declared more than once", name)
+			continue
+		}
+		seen[name] = true
+		for _, err := range checkProductVariableSchema(ctx.Config(), v) {
+			ctx.PropertyErrorf("variables", "%s: %s", name, err)
+		}
+	}
+}
+
+// checkProductVariableSchema returns the problems with the declaration of the variable and with
+// the value the product sets it to.
+func checkProductVariableSchema(config Config, v productVariableSchemaVariable) []error {
+	typ := String(v.Type)
+	fieldType, ok := productVariableSchemaTypes[typ]
+	if !ok {
+		return []error{fmt.Errorf("type must be one of %q, was %q", SortedKeys(productVariableSchemaTypes), typ)}
+	}
+	value, ok := config.productVariables.variableByName(String(v.Name))
+	if !ok {
+		return []error{fmt.Errorf("is not a product variable")}
+	}
+	if value.Type() != fieldType {
+		return []error{fmt.Errorf("is a %s, not a %s", value.Type(), typ)}
+	}
+	if len(v.Values) > 0 && typ != "string" && typ != "string_list" {
+		return []error{fmt.Errorf("values can only be set for string and string_list variables")}
+	}
+	if (v.Min != nil || v.Max != nil) && typ != "int" {
+		return []error{fmt.Errorf("min and max can only be set for int variables")}
+	}
+
+	var errs []error
+	if v.Default != nil {
+		if proptools.Bool(v.Required) {
+			return []error{fmt.Errorf("default can't be set for a required variable")}
+		}
+		for _, s := range productVariableSchemaDefaultValues(v) {
+			if err := checkProductVariableSchemaValue(v, s); err != nil {
+				errs = append(errs, fmt.Errorf("default: %s", err))
+			}
+		}
+	}
+
+	if value.IsNil() {
+		if proptools.Bool(v.Required) {
+			errs = append(errs, fmt.Errorf("must be set by the product"))
+		}
+		return errs
+	}
+	var values []string
+	switch typ {
+	case "bool":
+		values = []string{strconv.FormatBool(value.Elem().Bool())}
+	case "int":
+		values = []string{strconv.FormatInt(value.Elem().Int(), 10)}
+	case "string":
+		values = []string{value.Elem().String()}
+	case "string_list":
+		values = value.Interface().([]string)
+	}
+	for _, s := range values {
+		if err := checkProductVariableSchemaValue(v, s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// checkProductVariableSchemaValue returns an error if s is not an allowed value of the variable,
+// or of an element of it for string_list variables.
+func checkProductVariableSchemaValue(v productVariableSchemaVariable, s string) error {
+	switch String(v.Type) {
+	case "bool":
+		if _, err := strconv.ParseBool(s); err != nil {
+			return fmt.Errorf("%q is not a bool", s)
+		}
+	case "int":
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an int", s)
+		}
+		if v.Min != nil && i < *v.Min {
+			return fmt.Errorf("%d is less than the minimum %d", i, *v.Min)
+		}
+		if v.Max != nil && i > *v.Max {
+			return fmt.Errorf("%d is more than the maximum %d", i, *v.Max)
+		}
+	case "string", "string_list":
+		if len(v.Values) > 0 && !InList(s, v.Values) {
+			return fmt.Errorf("%q is not one of %q", s, v.Values)
+		}
+	}
+	return nil
+}
+
+// productVariableSchemaDefaultValues returns the default of the variable, split into its elements
+// for string_list variables.
+func productVariableSchemaDefaultValues(v productVariableSchemaVariable) []string {
+	if String(v.Type) == "string_list" {
+		return strings.Fields(String(v.Default))
+	}
+	return []string{String(v.Default)}
+}
+
+func productVariableSchemaDefaultsSingletonFactory() Singleton {
+	return &productVariableSchemaDefaultsSingleton{}
+}
+
+// productVariableSchemaDefaultsSingleton sets the product variables that the product doesn't set
+// to the defaults of the schema. It is a pre-singleton so that all the mutators and modules see
+// the defaults.
+type productVariableSchemaDefaultsSingleton struct{}
+
+func (s *productVariableSchemaDefaultsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	ctx.VisitAllModules(func(module Module) {
+		schema, ok := module.(*productVariableSchema)
+		if !ok {
+			return
+		}
+		for _, v := range schema.properties.Variables {
+			applyProductVariableSchemaDefault(ctx.Config(), v)
+		}
+	})
+}
+
+// applyProductVariableSchemaDefault sets the variable to its default if it isn't set. An invalid
+// declaration or default is left to be reported by the product_variable_schema module.
+func applyProductVariableSchemaDefault(config Config, v productVariableSchemaVariable) {
+	if v.Default == nil || proptools.Bool(v.Required) {
+		return
+	}
+	fieldType, ok := productVariableSchemaTypes[String(v.Type)]
+	if !ok {
+		return
+	}
+	value, ok := config.productVariables.variableByName(String(v.Name))
+	if !ok || value.Type() != fieldType || !value.IsNil() {
+		return
+	}
+	values := productVariableSchemaDefaultValues(v)
+	for _, s := range values {
+		if checkProductVariableSchemaValue(v, s) != nil {
+			return
+		}
+	}
+	switch String(v.Type) {
+	case "bool":
+		b, _ := strconv.ParseBool(values[0])
+		value.Set(reflect.ValueOf(boolPtr(b)))
+	case "int":
+		i, _ := strconv.ParseInt(values[0], 10, 64)
+		value.Set(reflect.ValueOf(intPtr(int(i))))
+	case "string":
+		value.Set(reflect.ValueOf(stringPtr(values[0])))
+	case "string_list":
+		value.Set(reflect.ValueOf(values))
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+func TestProductVariableSchema(t *testing.T) {
+	bp := `
+		product_variable_schema {
+			name: "schema",
+			variables: [
+				{
+					name: "DeviceMaxPageSizeSupported",
+					type: "string",
+					values: ["4096", "16384", "65536"],
+				},
+				{
+					name: "Platform_sdk_version",
+					type: "int",
+					min: 30,
+				},
+				{
+					name: "Eng",
+					type: "bool",
+					default: "false",
+				},
+			],
+		}
+	`
+
+	t.Run("valid", func(t *testing.T) {
+		GroupFixturePreparers(
+			PrepareForTestWithProductVariableSchema,
+			FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+				variables.DeviceMaxPageSizeSupported = stringPtr("16384")
+				variables.Platform_sdk_version = intPtr(34)
+			}),
+		).RunTestWithBp(t, bp)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		GroupFixturePreparers(
+			PrepareForTestWithProductVariableSchema,
+			FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+				variables.DeviceMaxPageSizeSupported = stringPtr("16k")
+				variables.Platform_sdk_version = intPtr(29)
+			}),
+		).ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+			`variables: DeviceMaxPageSizeSupported: "16k" is not one of \["4096" "16384" "65536"\]`,
+			`variables: Platform_sdk_version: 29 is less than the minimum 30`,
+		})).RunTestWithBp(t, bp)
+	})
+}
+
+func TestProductVariableSchemaDefault(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithProductVariableSchema,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.DeviceMaxPageSizeSupported = nil
+			variables.Eng = nil
+			variables.BoardVendorSepolicyDirs = nil
+		}),
+	).RunTestWithBp(t, `
+		product_variable_schema {
+			name: "schema",
+			variables: [
+				{
+					name: "DeviceMaxPageSizeSupported",
+					type: "string",
+					default: "16384",
+				},
+				{
+					name: "Eng",
+					type: "bool",
+					default: "true",
+				},
+				{
+					name: "BoardVendorSepolicyDirs",
+					type: "string_list",
+					default: "device/a device/b",
+				},
+				{
+					name: "Platform_sdk_version",
+					type: "int",
+					default: "29",
+				},
+			],
+		}
+	`)
+
+	variables := result.Config.productVariables
+	AssertStringEquals(t, "DeviceMaxPageSizeSupported", "16384", String(variables.DeviceMaxPageSizeSupported))
+	AssertBoolEquals(t, "Eng", true, Bool(variables.Eng))
+	AssertDeepEquals(t, "BoardVendorSepolicyDirs", []string{"device/a", "device/b"}, variables.BoardVendorSepolicyDirs)
+	// The value set by the product is kept.
+	AssertIntEquals(t, "Platform_sdk_version", 30, *variables.Platform_sdk_version)
+}
+
+func TestProductVariableSchemaInvalidDeclaration(t *testing.T) {
+	GroupFixturePreparers(
+		PrepareForTestWithProductVariableSchema,
+	).ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`variables: DeviceNmae: is not a product variable`,
+		`variables: DeviceName: is a \*string, not a bool`,
+		`variables: Debuggable: must be set by the product`,
+		`variables: Eng: default: "maybe" is not a bool`,
+		`variables: Unbundled_build: default can't be set for a required variable`,
+	})).RunTestWithBp(t, `
+		product_variable_schema {
+			name: "schema",
+			variables: [
+				{
+					name: "DeviceNmae",
+					type: "string",
+				},
+				{
+					name: "DeviceName",
+					type: "bool",
+				},
+				{
+					name: "Debuggable",
+					type: "bool",
+					required: true,
+				},
+				{
+					name: "Eng",
+					type: "bool",
+					default: "maybe",
+				},
+				{
+					name: "Unbundled_build",
+					type: "bool",
+					default: "false",
+					required: true,
+				},
+			],
+		}
+	`)
+}
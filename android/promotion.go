@@ -0,0 +1,239 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint"
+)
+
+// Artifact promotion allows a build to reuse the outputs of selected modules from a previous
+// build instead of rebuilding them.
+//
+// A build run with SOONG_RECORD_PROMOTION_DIGESTS=true records, for every rule of the modules
+// listed in SOONG_PROMOTE_MODULES, a digest of the rule and the contents of its inputs next to
+// its outputs, and packages both into promotion_archive.zip (the promotion_archive goal).
+//
+// A build run with SOONG_PROMOTION_ARCHIVE pointing at the extracted archive replaces the rules
+// of the modules listed in SOONG_PROMOTE_MODULES with rules that copy the archived outputs after
+// verifying that the digest of the inputs is unchanged. If the inputs changed the build fails
+// rather than silently using stale outputs.
+//
+// Inputs discovered through depfiles are not part of the digest, and the commands of static
+// rules are only identified by their name, so archives must be regenerated when Soong itself
+// changes.
+
+func init() {
+	RegisterSingletonType("promotion_archive", promotionArchiveSingletonFactory)
+}
+
+const (
+	promotionModulesEnv = "SOONG_PROMOTE_MODULES"
+	promotionArchiveEnv = "SOONG_PROMOTION_ARCHIVE"
+	promotionRecordEnv  = "SOONG_RECORD_PROMOTION_DIGESTS"
+
+	// promotionDigestDir is the directory, relative to the soong output directory, in which the
+	// digests of promoted outputs are written.
+	promotionDigestDir = ".promotion"
+)
+
+var (
+	promoteArtifactCmd = pctx.SourcePathVariable("promoteArtifactCmd", "build/soong/scripts/promote_artifact.sh")
+
+	promotionDigest = pctx.AndroidStaticRule("promotionDigest",
+		blueprint.RuleParams{
+			Command:        "${promoteArtifactCmd} digest $key ${out}.rsp $out",
+			CommandDeps:    []string{"${promoteArtifactCmd}"},
+			Rspfile:        "${out}.rsp",
+			RspfileContent: "$in",
+			Description:    "promotion digest $out",
+		},
+		"key")
+
+	promoteArtifact = pctx.AndroidStaticRule("promoteArtifact",
+		blueprint.RuleParams{
+			Command:        "${promoteArtifactCmd} restore $key ${out}.rsp $archive $soongOutDir $outputs",
+			CommandDeps:    []string{"${promoteArtifactCmd}"},
+			Rspfile:        "${out}.rsp",
+			RspfileContent: "$in",
+			Description:    "promote $out",
+		},
+		"key", "archive", "soongOutDir", "outputs")
+)
+
+// promotedArtifact is an output of a promoted module along with the digest recorded for it.
+type promotedArtifact struct {
+	output WritablePath
+	digest WritablePath
+}
+
+// promotionEnabledForModule returns true if the rules of the named module should either record
+// promotion digests or be substituted by archived outputs.
+func promotionEnabledForModule(config Config, name string) bool {
+	modules := config.Getenv(promotionModulesEnv)
+	if modules == "" {
+		return false
+	}
+	if !config.IsEnvTrue(promotionRecordEnv) && config.Getenv(promotionArchiveEnv) == "" {
+		return false
+	}
+	return InList(name, strings.Split(modules, ","))
+}
+
+// promotionKey returns a hash identifying the rule and the arguments used to build params,
+// excluding its inputs and outputs.
+func promotionKey(params BuildParams, ruleParams map[blueprint.Rule]blueprint.RuleParams) string {
+	h := sha256.New()
+	fmt.Fprintln(h, params.Rule.String())
+	if rp, ok := ruleParams[params.Rule]; ok {
+		fmt.Fprintln(h, rp.Command)
+	}
+	for _, k := range SortedKeys(params.Args) {
+		fmt.Fprintf(h, "%s=%s\n", k, params.Args[k])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// promotionOutputs returns the outputs of params if they can all be promoted, i.e. they are all
+// regular files under the soong output directory.
+func promotionOutputs(config Config, params BuildParams) (WritablePaths, bool) {
+	if params.SymlinkOutput != nil || len(params.SymlinkOutputs) > 0 {
+		return nil, false
+	}
+	var outputs WritablePaths
+	all := append(WritablePaths{params.Output, params.ImplicitOutput}, params.Outputs...)
+	for _, p := range append(all, params.ImplicitOutputs...) {
+		if p == nil {
+			continue
+		}
+		if !strings.HasPrefix(p.String(), config.soongOutDir+"/") {
+			return nil, false
+		}
+		outputs = append(outputs, p)
+	}
+	return outputs, len(outputs) > 0
+}
+
+func promotionInputs(params BuildParams) Paths {
+	var inputs Paths
+	if params.Input != nil {
+		inputs = append(inputs, params.Input)
+	}
+	inputs = append(inputs, params.Inputs...)
+	if params.Implicit != nil {
+		inputs = append(inputs, params.Implicit)
+	}
+	inputs = append(inputs, params.Implicits...)
+	return SortedUniquePaths(inputs)
+}
+
+// promoteBuildParams returns the build params to use for params when the module is promoted.
+// When recording digests it also creates the digest rules for the outputs.
+func (m *moduleContext) promoteBuildParams(pctx PackageContext, params BuildParams) BuildParams {
+	outputs, ok := promotionOutputs(m.config, params)
+	if !ok {
+		return params
+	}
+	inputs := promotionInputs(params)
+	key := promotionKey(params, m.promotionRuleParams)
+
+	if archive := m.config.Getenv(promotionArchiveEnv); archive != "" {
+		// $outputs passes all the outputs to promote_artifact.sh, including the implicit ones.
+		return BuildParams{
+			Rule:            promoteArtifact,
+			Description:     params.Description,
+			Outputs:         outputs[:1],
+			ImplicitOutputs: outputs[1:],
+			Inputs:          inputs,
+			OrderOnly:       params.OrderOnly,
+			Validations:     params.Validations,
+			Args: map[string]string{
+				"key":         key,
+				"archive":     archive,
+				"outputs":     strings.Join(outputs.Strings(), " "),
+				"soongOutDir": m.config.soongOutDir,
+			},
+		}
+	}
+
+	for _, output := range outputs {
+		rel, err := filepath.Rel(m.config.soongOutDir, output.String())
+		if err != nil {
+			continue
+		}
+		digest := PathForOutput(m, promotionDigestDir, rel+".digest")
+		digestParams := BuildParams{
+			Rule:        promotionDigest,
+			Output:      digest,
+			Inputs:      inputs,
+			Description: "promotion digest " + m.ModuleName(),
+			Args: map[string]string{
+				"key": key,
+			},
+		}
+		if m.config.captureBuild {
+			m.buildParams = append(m.buildParams, digestParams)
+		}
+		m.bp.Build(pctx.PackageContext, convertBuildParams(digestParams))
+		m.promotedArtifacts = append(m.promotedArtifacts, promotedArtifact{output, digest})
+	}
+	return params
+}
+
+func promotionArchiveSingletonFactory() Singleton {
+	return &promotionArchiveSingleton{}
+}
+
+type promotionArchiveSingleton struct {
+	archive WritablePath
+}
+
+func (p *promotionArchiveSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !ctx.Config().IsEnvTrue(promotionRecordEnv) {
+		return
+	}
+
+	var files Paths
+	ctx.VisitAllModules(func(module Module) {
+		for _, artifact := range module.base().promotedArtifacts {
+			files = append(files, artifact.output, artifact.digest)
+		}
+	})
+	if len(files) == 0 {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].String() < files[j].String() })
+
+	p.archive = PathForOutput(ctx, "promotion_archive.zip")
+	rule := NewRuleBuilder(pctx, ctx)
+	rule.Command().BuiltTool("soong_zip").
+		FlagWithOutput("-o ", p.archive).
+		FlagWithArg("-C ", PathForOutput(ctx).String()).
+		FlagWithRspFileInputList("-r ", PathForOutput(ctx, "promotion_archive.zip.rsp"), files)
+	rule.Build("promotion_archive", "promotion archive")
+
+	ctx.Phony("promotion_archive", p.archive)
+}
+
+func (p *promotionArchiveSingleton) MakeVars(ctx MakeVarsContext) {
+	if p.archive != nil {
+		ctx.DistForGoal("promotion_archive", p.archive)
+	}
+}
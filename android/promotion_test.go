@@ -0,0 +1,112 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+var promotionTestBp = `
+	deps {
+		name: "foo",
+	}
+
+	deps {
+		name: "bar",
+	}
+`
+
+// promotionMultiOutputModule builds a rule with an explicit and an implicit output.
+type promotionMultiOutputModule struct {
+	ModuleBase
+}
+
+func (m *promotionMultiOutputModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	ctx.Build(pctx, BuildParams{
+		Rule:           Touch,
+		Output:         PathForModuleOut(ctx, ctx.ModuleName()),
+		ImplicitOutput: PathForModuleOut(ctx, ctx.ModuleName()+".extra"),
+	})
+}
+
+func promotionMultiOutputModuleFactory() Module {
+	m := &promotionMultiOutputModule{}
+	InitAndroidArchModule(m, HostAndDeviceDefault, MultilibCommon)
+	return m
+}
+
+func TestPromotionRecordsDigests(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForModuleTests,
+		PrepareForTestWithArchMutator,
+		FixtureMergeEnv(map[string]string{
+			"SOONG_PROMOTE_MODULES":          "foo",
+			"SOONG_RECORD_PROMOTION_DIGESTS": "true",
+		}),
+	).RunTestWithBp(t, promotionTestBp)
+
+	foo := result.ModuleForTests("foo", "android_common")
+	digest := foo.Output(".promotion/.intermediates/foo/android_common/foo.digest")
+	AssertStringEquals(t, "digest rule", promotionDigest.String(), digest.Rule.String())
+	AssertBoolEquals(t, "foo output is still built", true, foo.Output("foo").Rule == Touch)
+
+	bar := result.ModuleForTests("bar", "android_common")
+	AssertBoolEquals(t, "bar has no digest", true,
+		bar.MaybeOutput(".promotion/.intermediates/bar/android_common/bar.digest").Rule == nil)
+}
+
+func TestPromotionRestoresFromArchive(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForModuleTests,
+		PrepareForTestWithArchMutator,
+		FixtureMergeEnv(map[string]string{
+			"SOONG_PROMOTE_MODULES":   "foo",
+			"SOONG_PROMOTION_ARCHIVE": "/tmp/archive",
+		}),
+	).RunTestWithBp(t, promotionTestBp)
+
+	foo := result.ModuleForTests("foo", "android_common").Output("foo")
+	AssertStringEquals(t, "foo rule", promoteArtifact.String(), foo.Rule.String())
+	AssertStringEquals(t, "archive", "/tmp/archive", foo.Args["archive"])
+
+	bar := result.ModuleForTests("bar", "android_common").Output("bar")
+	AssertBoolEquals(t, "bar is built from source", true, bar.Rule == Touch)
+}
+
+func TestPromotionRestoresAllOutputsFromArchive(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("promotion_multi_output", promotionMultiOutputModuleFactory)
+		}),
+		FixtureMergeEnv(map[string]string{
+			"SOONG_PROMOTE_MODULES":   "foo",
+			"SOONG_PROMOTION_ARCHIVE": "/tmp/archive",
+		}),
+	).RunTestWithBp(t, `
+		promotion_multi_output {
+			name: "foo",
+		}
+	`)
+
+	foo := result.ModuleForTests("foo", "android_common").Output("foo")
+	AssertStringEquals(t, "foo rule", promoteArtifact.String(), foo.Rule.String())
+	AssertPathsRelativeToTopEquals(t, "implicit outputs",
+		[]string{"out/soong/.intermediates/foo/android_common/foo.extra"},
+		foo.ImplicitOutputs.Paths())
+	AssertStringEquals(t, "restored outputs",
+		"out/soong/.intermediates/foo/android_common/foo out/soong/.intermediates/foo/android_common/foo.extra",
+		StringRelativeToTop(result.Config, foo.Args["outputs"]))
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+)
+
+// This file writes out/soong/release_config-<product>.json, which records the product variables,
+// including the build flags, and the enabled modules that Soong computed for the release
+// configuration of the build. `m nothing` writes it, and release_config_diff compares the files
+// of two release configurations.
+//
+// The build flags are the RELEASE_* values of the release configuration. Soong doesn't define the
+// aconfig module types in this tree, so the aconfig flag values aren't computed here and aren't
+// part of the report.
+
+func init() {
+	RegisterReleaseConfigBuildComponents(InitRegistrationContext)
+}
+
+func RegisterReleaseConfigBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("release_config", releaseConfigSingletonFactory)
+}
+
+// releaseConfigReport is the format of release_config-<product>.json, which release_config_diff
+// reads.
+type releaseConfigReport struct {
+	Product          string           `json:"product"`
+	ProductVariables productVariables `json:"product_variables"`
+	// The modules with at least one enabled variant, as "//<dir>:<name>".
+	EnabledModules []string `json:"enabled_modules"`
+}
+
+func releaseConfigSingletonFactory() Singleton {
+	return &releaseConfigSingleton{}
+}
+
+type releaseConfigSingleton struct{}
+
+func (s *releaseConfigSingleton) GenerateBuildActions(ctx SingletonContext) {
+	enabled := make(map[string]bool)
+	ctx.VisitAllModules(func(module Module) {
+		if module.Enabled() {
+			enabled["//"+ctx.ModuleDir(module)+":"+ctx.ModuleName(module)] = true
+		}
+	})
+
+	report := releaseConfigReport{
+		Product:          "unknown",
+		ProductVariables: ctx.Config().productVariables,
+		EnabledModules:   SortedKeys(enabled),
+	}
+	if ctx.Config().HasDeviceProduct() {
+		report.Product = ctx.Config().DeviceProduct()
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the release config: %s", err)
+		return
+	}
+
+	// The report is written during analysis so that `m nothing` writes it.
+	path := PathForOutput(ctx, "release_config-"+report.Product+".json")
+	if err := WriteFileToOutputDir(path, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", path, err)
+		return
+	}
+
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: path,
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+)
+
+// This file implements the enabled_by_release_flags property of all modules, which disables the
+// module unless the build flags of the release configuration have the given values.
+
+var PrepareForTestWithReleaseFlags = FixtureRegisterWithContext(func(ctx RegistrationContext) {
+	ctx.PreArchMutators(RegisterReleaseFlagsMutator)
+})
+
+// ReleaseFlagCondition is a condition on the value of a build flag of the release configuration.
+type ReleaseFlagCondition struct {
+	// The name of the build flag, e.g. RELEASE_FOO.
+	Flag *string
+
+	// The values of the flag for which the condition holds. If empty, the condition holds if the
+	// flag is true. Flags that are not defined in the release configuration have the empty value,
+	// so [""] holds if the flag is false or not defined.
+	Values []string
+}
+
+// holds returns true if the flag has one of the values of the condition in the configuration.
+func (c ReleaseFlagCondition) holds(config Config) bool {
+	value, _ := config.GetBuildFlag(String(c.Flag))
+	if len(c.Values) == 0 {
+		return value == "true"
+	}
+	return InList(value, c.Values)
+}
+
+func RegisterReleaseFlagsMutator(ctx RegisterMutatorsContext) {
+	ctx.BottomUp("release_flags", releaseFlagsMutator).Parallel()
+}
+
+// releaseFlagsMutator disables the modules whose enabled_by_release_flags conditions don't hold.
+func releaseFlagsMutator(ctx BottomUpMutatorContext) {
+	m := ctx.Module().base()
+	for i, c := range m.commonProperties.Enabled_by_release_flags {
+		if String(c.Flag) == "" {
+			ctx.PropertyErrorf(fmt.Sprintf("enabled_by_release_flags[%d]", i), "flag must be set")
+			continue
+		}
+		if !c.holds(ctx.Config()) {
+			m.Disable()
+		}
+	}
+}
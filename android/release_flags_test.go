@@ -0,0 +1,104 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+var prepareForReleaseFlagsTest = GroupFixturePreparers(
+	PrepareForTestWithArchMutator,
+	PrepareForTestWithReleaseFlags,
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("component", componentTestModuleFactory)
+	}),
+	FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+		variables.BuildFlags = map[string]string{
+			"RELEASE_FOO":     "true",
+			"RELEASE_BAR":     "",
+			"RELEASE_VERSION": "2",
+		}
+	}),
+)
+
+func TestEnabledByReleaseFlags(t *testing.T) {
+	bp := `
+		component {
+			name: "foo",
+			enabled_by_release_flags: [{ flag: "RELEASE_FOO" }],
+		}
+
+		component {
+			name: "bar",
+			enabled_by_release_flags: [{ flag: "RELEASE_BAR" }],
+		}
+
+		component {
+			name: "not_bar",
+			enabled_by_release_flags: [{ flag: "RELEASE_BAR", values: [""] }],
+		}
+
+		component {
+			name: "undefined",
+			enabled_by_release_flags: [{ flag: "RELEASE_UNDEFINED" }],
+		}
+
+		component {
+			name: "version",
+			enabled_by_release_flags: [{ flag: "RELEASE_VERSION", values: ["2", "3"] }],
+		}
+
+		component {
+			name: "foo_and_bar",
+			enabled_by_release_flags: [
+				{ flag: "RELEASE_FOO" },
+				{ flag: "RELEASE_BAR" },
+			],
+		}
+	`
+
+	result := GroupFixturePreparers(
+		prepareForReleaseFlagsTest,
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	for name, expected := range map[string]bool{
+		"foo":         true,
+		"bar":         false,
+		"not_bar":     true,
+		"undefined":   false,
+		"version":     true,
+		"foo_and_bar": false,
+	} {
+		module := result.ModuleForTests(name, "android_arm64_armv8-a").Module()
+		AssertBoolEquals(t, name+" enabled", expected, module.Enabled())
+	}
+}
+
+func TestEnabledByReleaseFlagsErrors(t *testing.T) {
+	bp := `
+		component {
+			name: "foo",
+			enabled_by_release_flags: [{ values: ["true"] }],
+		}
+	`
+
+	GroupFixturePreparers(
+		prepareForReleaseFlagsTest,
+		FixtureWithRootAndroidBp(bp),
+	).ExtendWithErrorHandler(FixtureExpectsOneErrorPattern(
+		`module "foo": enabled_by_release_flags\[0\]: flag must be set`,
+	)).RunTest(t)
+}
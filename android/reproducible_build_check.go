@@ -0,0 +1,157 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// This file implements the check of the reproducible builds, which are enabled by the
+// ReproducibleBuild product variable. The rules that embed timestamps in their outputs, e.g. the
+// jars, the APEXes, the boot images and the signed APKs, receive a SOURCE_DATE_EPOCH from
+// Config.SourceDateEpochEnv or Config.SourceDateEpochFlag, and the check reports the rules defined
+// by the modules, e.g. with genrules, whose commands embed the wall-clock time instead. `m reproducible-build-check` writes
+// the report to out/soong/reproducible_build_check.json, dists it, and fails if it isn't empty.
+
+func init() {
+	RegisterReproducibleBuildCheckBuildComponents(InitRegistrationContext)
+}
+
+func RegisterReproducibleBuildCheckBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("reproducible_build_check", reproducibleBuildCheckSingletonFactory)
+}
+
+var PrepareForTestWithReproducibleBuildCheck = FixtureRegisterWithContext(RegisterReproducibleBuildCheckBuildComponents)
+
+// The patterns of the commands that embed the wall-clock time in their outputs.
+var wallClockCommandPatterns = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{
+		// date, optionally with a format, but without a fixed date, e.g. -d @${SOURCE_DATE_EPOCH}.
+		regexp.MustCompile("(^|[\\s;&|(`])date(\\s+(-u|--utc|-R|--rfc-email|-I\\S*|--iso-8601(=\\S+)?|\\+\\S+|'\\+[^']*'|\"\\+[^\"]*\"))*\\s*($|[;&|)`>])"),
+		"runs date without a fixed date",
+	},
+	{
+		regexp.MustCompile(`\bdatetime\.(date|datetime)?\.?(now|today|utcnow)\(`),
+		"reads the current time in python",
+	},
+	{
+		regexp.MustCompile(`\btime\.time\(\)`),
+		"reads the current time in python",
+	},
+}
+
+type wallClockRule struct {
+	Module string `json:"module"`
+	Dir    string `json:"dir"`
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+type wallClockRules struct {
+	sync.Mutex
+	rules map[wallClockRule]bool
+}
+
+var wallClockRulesKey = NewOnceKey("wallClockRules")
+
+func getWallClockRules(config Config) *wallClockRules {
+	return config.Once(wallClockRulesKey, func() interface{} {
+		return &wallClockRules{rules: make(map[wallClockRule]bool)}
+	}).(*wallClockRules)
+}
+
+// checkWallClockRule records the rule of a module if its command embeds the wall-clock time in a
+// reproducible build.
+func checkWallClockRule(ctx BaseModuleContext, name, command string) {
+	if !ctx.Config().ReproducibleBuild() {
+		return
+	}
+	for _, p := range wallClockCommandPatterns {
+		if p.pattern.MatchString(command) {
+			rules := getWallClockRules(ctx.Config())
+			rules.Lock()
+			rules.rules[wallClockRule{ctx.ModuleName(), ctx.ModuleDir(), name, p.reason}] = true
+			rules.Unlock()
+			return
+		}
+	}
+}
+
+func reproducibleBuildCheckSingletonFactory() Singleton {
+	return &reproducibleBuildCheckSingleton{}
+}
+
+type reproducibleBuildCheckSingleton struct {
+	report WritablePath
+}
+
+func (s *reproducibleBuildCheckSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !ctx.Config().ReproducibleBuild() {
+		return
+	}
+
+	rules := []wallClockRule{}
+	for rule := range getWallClockRules(ctx.Config()).rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Module != rules[j].Module {
+			return rules[i].Module < rules[j].Module
+		}
+		return rules[i].Rule < rules[j].Rule
+	})
+
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the reproducible build check: %s", err)
+		return
+	}
+	s.report = PathForOutput(ctx, "reproducible_build_check.json")
+	WriteFileRuleVerbatim(ctx, s.report, string(data)+"\n")
+
+	stamp := PathForOutput(ctx, "reproducible_build_check.stamp")
+	if len(rules) > 0 {
+		ctx.Build(pctx, BuildParams{
+			Rule:     ErrorRule,
+			Output:   stamp,
+			Implicit: s.report,
+			Args: map[string]string{
+				"error": fmt.Sprintf("%d rules of the modules embed the wall-clock time, see %s",
+					len(rules), s.report),
+			},
+		})
+	} else {
+		ctx.Build(pctx, BuildParams{
+			Rule:     Touch,
+			Output:   stamp,
+			Implicit: s.report,
+		})
+	}
+
+	ctx.Phony("reproducible-build-check", stamp)
+}
+
+func (s *reproducibleBuildCheckSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.report != nil {
+		ctx.DistForGoal("reproducible-build-check", s.report)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+type reproducibleBuildTestModule struct {
+	ModuleBase
+	props struct {
+		Cmd  string
+		Sbox bool
+	}
+}
+
+func reproducibleBuildTestModuleFactory() Module {
+	m := &reproducibleBuildTestModule{}
+	m.AddProperties(&m.props)
+	InitAndroidModule(m)
+	return m
+}
+
+func (m *reproducibleBuildTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	out := PathForModuleOut(ctx, "out")
+	builder := NewRuleBuilder(pctx, ctx)
+	if m.props.Sbox {
+		out = PathForModuleOut(ctx, "gen", "out")
+		builder.Sbox(PathForModuleOut(ctx, "gen"), PathForModuleOut(ctx, "cmd.sbox.textproto"))
+	}
+	builder.Command().Text(m.props.Cmd).Text(">").Output(out)
+	builder.Build("cmd", "cmd")
+}
+
+var prepareForReproducibleBuildTest = GroupFixturePreparers(
+	PrepareForTestWithReproducibleBuildCheck,
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("test_module", reproducibleBuildTestModuleFactory)
+	}),
+	FixtureWithRootAndroidBp(`
+		test_module {
+			name: "wall_clock",
+			cmd: "date +%s",
+		}
+
+		test_module {
+			name: "python_wall_clock",
+			cmd: "python3 -c 'import time; print(time.time())'",
+		}
+
+		test_module {
+			name: "sbox_wall_clock",
+			cmd: "date",
+			sbox: true,
+		}
+
+		test_module {
+			name: "fixed_date",
+			cmd: "date -d @0 +%s",
+		}
+
+		test_module {
+			name: "no_date",
+			cmd: "cat date.txt",
+		}
+	`),
+)
+
+func TestReproducibleBuildCheck(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForReproducibleBuildTest,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.ReproducibleBuild = boolPtr(true)
+		}),
+	).RunTest(t)
+
+	check := result.SingletonForTests("reproducible_build_check")
+	AssertStringEquals(t, "report", `[
+  {
+    "module": "python_wall_clock",
+    "dir": ".",
+    "rule": "cmd",
+    "reason": "reads the current time in python"
+  },
+  {
+    "module": "sbox_wall_clock",
+    "dir": ".",
+    "rule": "cmd",
+    "reason": "runs date without a fixed date"
+  },
+  {
+    "module": "wall_clock",
+    "dir": ".",
+    "rule": "cmd",
+    "reason": "runs date without a fixed date"
+  }
+]
+`, ContentFromFileRuleForTests(t, check.Output("reproducible_build_check.json")))
+
+	stamp := check.Output("reproducible_build_check.stamp")
+	AssertStringEquals(t, "rule", ErrorRule.String(), stamp.Rule.String())
+}
+
+func TestReproducibleBuildCheckDisabled(t *testing.T) {
+	result := prepareForReproducibleBuildTest.RunTest(t)
+
+	check := result.SingletonForTests("reproducible_build_check")
+	AssertIntEquals(t, "outputs", 0, len(check.AllOutputs()))
+}
+
+func TestSourceDateEpochEnv(t *testing.T) {
+	testCases := []struct {
+		name              string
+		reproducible      bool
+		sourceDateEpoch   string
+		buildDateTimeFile string
+		want              string
+		wantFlag          string
+	}{
+		{
+			name: "disabled",
+			want: "",
+		},
+		{
+			name:            "fixed",
+			reproducible:    true,
+			sourceDateEpoch: "1672531200",
+			want:            "SOURCE_DATE_EPOCH=1672531200 ",
+			wantFlag:        "-source_date_epoch 1672531200",
+		},
+		{
+			name:              "build date",
+			reproducible:      true,
+			buildDateTimeFile: "out/build_date.txt",
+			want:              "SOURCE_DATE_EPOCH=$(cat out/build_date.txt) ",
+			wantFlag:          "-source_date_epoch $(cat out/build_date.txt)",
+		},
+		{
+			name:         "no build date",
+			reproducible: true,
+			want:         "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := GroupFixturePreparers(
+				FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+					variables.ReproducibleBuild = proptools.BoolPtr(tc.reproducible)
+					if tc.sourceDateEpoch != "" {
+						variables.SourceDateEpoch = proptools.StringPtr(tc.sourceDateEpoch)
+					}
+				}),
+				FixtureMergeEnv(map[string]string{
+					"BUILD_DATETIME_FILE": tc.buildDateTimeFile,
+				}),
+			).RunTest(t)
+
+			AssertStringEquals(t, "SOURCE_DATE_EPOCH", tc.want, result.Config.SourceDateEpochEnv())
+			AssertStringEquals(t, "flag", tc.wantFlag, result.Config.SourceDateEpochFlag())
+		})
+	}
+}
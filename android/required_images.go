@@ -0,0 +1,164 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// This file checks the required property of device modules against the images the required
+// modules are available for. A module in the vendor or product image that requires a module that
+// has no variant in its image is an error, as the required module would be installed in another
+// partition. The other required modules that are only available for other images are written to
+// out/soong/required_image_crossings.json, which `m nothing` writes.
+//
+// The required modules that are not defined in Soong are not checked.
+
+// RequiredImageCrossingsFileName is the name of the file in the soong output directory that lists
+// the required modules that are not available for the image of the modules that require them.
+const RequiredImageCrossingsFileName = "required_image_crossings.json"
+
+func init() {
+	RegisterRequiredImagesBuildComponents(InitRegistrationContext)
+}
+
+func RegisterRequiredImagesBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("required_images", requiredImagesSingletonFactory)
+}
+
+var PrepareForTestWithRequiredImages = FixtureRegisterWithContext(RegisterRequiredImagesBuildComponents)
+
+// The images a device module can be installed in, for the purpose of the required property.
+const (
+	requiredImageSystem  = "system"
+	requiredImageVendor  = "vendor"
+	requiredImageProduct = "product"
+)
+
+// requiredImage returns the image the variant of the module is installed in: the image variation
+// for the vendor and product variants of modules that have them, else the partition the module is
+// specific to.
+func requiredImage(module Module) string {
+	m := module.base()
+	variation := m.commonProperties.ImageVariation
+	switch {
+	case variation == RecoveryVariation, variation == RamdiskVariation,
+		variation == VendorRamdiskVariation, variation == DebugRamdiskVariation:
+		return variation
+	// The prefixes of the vendor and product variations of cc and rust modules.
+	case strings.HasPrefix(variation, "vendor."):
+		return requiredImageVendor
+	case strings.HasPrefix(variation, "product."):
+		return requiredImageProduct
+	case m.SocSpecific() || m.DeviceSpecific():
+		return requiredImageVendor
+	case m.ProductSpecific():
+		return requiredImageProduct
+	}
+	return requiredImageSystem
+}
+
+// requiredImageCrossing is a required module that has no variant in the image of the module that
+// requires it.
+type requiredImageCrossing struct {
+	Module  string `json:"module"`
+	Variant string `json:"variant"`
+	Image   string `json:"image"`
+	// The name of the required module, and the images it is available for.
+	Required       string   `json:"required"`
+	RequiredImages []string `json:"required_images"`
+	// Whether the crossing is reported as an error, see the comment at the top of this file.
+	Error bool `json:"error"`
+}
+
+func requiredImagesSingletonFactory() Singleton {
+	return &requiredImagesSingleton{}
+}
+
+type requiredImagesSingleton struct{}
+
+func (s *requiredImagesSingleton) GenerateBuildActions(ctx SingletonContext) {
+	var modules []Module
+	byName := make(map[string][]Module)
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() || module.Target().Os.Class != Device {
+			return
+		}
+		modules = append(modules, module)
+		byName[ctx.ModuleName(module)] = append(byName[ctx.ModuleName(module)], module)
+	})
+
+	crossings := []requiredImageCrossing{}
+	for _, module := range modules {
+		if !ctx.ModuleProvider(module, ApexInfoProvider).(ApexInfo).IsForPlatform() {
+			continue
+		}
+		image := requiredImage(module)
+		for _, name := range FirstUniqueStrings(module.RequiredModuleNames()) {
+			variants := byName[name]
+			if len(variants) == 0 {
+				continue
+			}
+			images := make(map[string]bool)
+			for _, v := range variants {
+				images[requiredImage(v)] = true
+			}
+			if images[image] {
+				continue
+			}
+			crossing := requiredImageCrossing{
+				Module:         ctx.ModuleName(module),
+				Variant:        ctx.ModuleSubDir(module),
+				Image:          image,
+				Required:       name,
+				RequiredImages: SortedKeys(images),
+				Error:          image == requiredImageVendor || image == requiredImageProduct,
+			}
+			if crossing.Error {
+				ctx.ModuleErrorf(module, "required module %q has no %s variant, it is only available for %s. "+
+					"Make it available for the %s image, e.g. with %s_available: true, or remove it from required.",
+					name, image, strings.Join(crossing.RequiredImages, ", "), image, image)
+			}
+			crossings = append(crossings, crossing)
+		}
+	}
+	sort.SliceStable(crossings, func(i, j int) bool {
+		if crossings[i].Module != crossings[j].Module {
+			return crossings[i].Module < crossings[j].Module
+		}
+		return crossings[i].Variant < crossings[j].Variant
+	})
+
+	data, err := json.MarshalIndent(crossings, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the required image crossings: %s", err)
+		return
+	}
+
+	// The report is written during analysis so that `m nothing` writes it.
+	path := PathForOutput(ctx, RequiredImageCrossingsFileName)
+	if err := WriteFileToOutputDir(path, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", path, err)
+		return
+	}
+
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: path,
+	})
+}
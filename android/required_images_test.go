@@ -0,0 +1,129 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var prepareForRequiredImagesTest = GroupFixturePreparers(
+	PrepareForTestWithArchMutator,
+	PrepareForTestWithRequiredImages,
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("component", componentTestModuleFactory)
+	}),
+)
+
+func TestRequiredImageCrossings(t *testing.T) {
+	bp := `
+		component {
+			name: "system_bin",
+			required: ["vendor_lib", "system_lib", "make_module"],
+		}
+
+		component {
+			name: "vendor_bin",
+			vendor: true,
+			required: ["vendor_lib"],
+		}
+
+		component {
+			name: "system_lib",
+		}
+
+		component {
+			name: "vendor_lib",
+			vendor: true,
+		}
+	`
+
+	result := GroupFixturePreparers(
+		prepareForRequiredImagesTest,
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), RequiredImageCrossingsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var crossings []requiredImageCrossing
+	if err := json.Unmarshal(data, &crossings); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range crossings {
+		got = append(got, fmt.Sprintf("%s (%s) -> %s (%s) error=%t",
+			c.Module, c.Image, c.Required, strings.Join(c.RequiredImages, ","), c.Error))
+	}
+	AssertArrayString(t, "crossings", []string{
+		"system_bin (system) -> vendor_lib (vendor) error=false",
+	}, FirstUniqueStrings(got))
+}
+
+func TestRequiredImageCrossingErrors(t *testing.T) {
+	bp := `
+		component {
+			name: "vendor_bin",
+			vendor: true,
+			required: ["system_lib"],
+		}
+
+		component {
+			name: "system_lib",
+		}
+	`
+
+	GroupFixturePreparers(
+		prepareForRequiredImagesTest,
+		FixtureWithRootAndroidBp(bp),
+	).ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`module "vendor_bin".*: required module "system_lib" has no vendor variant, it is only available for system`,
+	})).RunTest(t)
+}
+
+func TestRequiredVariants(t *testing.T) {
+	bp := `
+		component {
+			name: "vendor_bin",
+			vendor: true,
+		}
+
+		component {
+			name: "system_lib",
+		}
+
+		component {
+			name: "vendor_lib",
+			vendor: true,
+		}
+	`
+
+	result := GroupFixturePreparers(
+		prepareForRequiredImagesTest,
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	variant := func(name string) Module {
+		return result.ModuleForTests(name, "android_arm64_armv8-a").Module()
+	}
+	lib := []Module{variant("system_lib"), variant("vendor_lib")}
+	AssertDeepEquals(t, "vendor variants", []Module{variant("vendor_lib")}, requiredVariants(variant("vendor_bin"), lib))
+}
@@ -56,6 +56,8 @@ type RuleBuilder struct {
 	sboxTools        bool
 	sboxInputs       bool
 	sboxManifestPath WritablePath
+	sboxFsProfile    []string
+	sboxWritableDirs []string
 	missingDeps      []string
 }
 
@@ -181,6 +183,29 @@ func (r *RuleBuilder) SandboxInputs() *RuleBuilder {
 	return r
 }
 
+// SandboxFsProfile restricts the files that the commands of the rule may access. sbox traces the
+// file accesses of the commands and fails the rule if they read anything outside of the sandbox,
+// the inputs and tools of the rule, the system directories and readOnlyDirs, or write anything
+// outside of the sandbox. readOnlyDirs are relative to the top of the source tree.
+func (r *RuleBuilder) SandboxFsProfile(readOnlyDirs []string) *RuleBuilder {
+	if !r.sbox {
+		panic("SandboxFsProfile() must be called after Sbox()")
+	}
+	r.sboxFsProfile = append([]string{}, readOnlyDirs...)
+	return r
+}
+
+// SandboxFsProfileWritableDirs allows the commands of a rule with a SandboxFsProfile to also read
+// and write writableDirs, e.g. a cache that persists across builds. writableDirs are relative to
+// the top of the source tree.
+func (r *RuleBuilder) SandboxFsProfileWritableDirs(writableDirs []string) *RuleBuilder {
+	if r.sboxFsProfile == nil {
+		panic("SandboxFsProfileWritableDirs() must be called after SandboxFsProfile()")
+	}
+	r.sboxWritableDirs = append([]string{}, writableDirs...)
+	return r
+}
+
 // Install associates an output of the rule with an install location, which can be retrieved later using
 // RuleBuilder.Installs.
 func (r *RuleBuilder) Install(from Path, to string) {
@@ -516,6 +541,12 @@ func (r *RuleBuilder) Build(name string, desc string) {
 	commandString := strings.Join(commands, " && ")
 
 	if r.sbox {
+		// The commands are hidden from ModuleContext.Rule in the sbox manifest, so they are checked
+		// here.
+		if ctx, ok := r.ctx.(BaseModuleContext); ok {
+			checkWallClockRule(ctx, name, commandString)
+		}
+
 		// If running the command inside sbox, write the rule data out to an sbox
 		// manifest.textproto.
 		manifest := sbox_proto.Manifest{}
@@ -635,6 +666,25 @@ func (r *RuleBuilder) Build(name string, desc string) {
 			sboxCmd.Flag("--write-if-changed")
 		}
 
+		// Only rules whose inputs are all copied into the sandbox are hermetic enough to cache
+		// their outputs by the contents of their inputs.
+		if cacheDir := r.ctx.Config().ActionCacheDir(); cacheDir != "" && r.sboxInputs && r.rbeParams == nil {
+			sboxCmd.FlagWithArg("--action-cache ", cacheDir)
+		}
+
+		if r.sboxFsProfile != nil {
+			// Write the paths that the commands may read to a file next to the manifest.
+			fsProfile := r.sboxManifestPath.ReplaceExtension(r.ctx, "fs_profile")
+			allowedReads := append(inputs.Strings(), tools.Strings()...)
+			allowedReads = append(allowedReads, r.sboxFsProfile...)
+			for _, dir := range r.sboxWritableDirs {
+				allowedReads = append(allowedReads, "w:"+dir)
+			}
+			WriteFileRule(r.ctx, fsProfile, strings.Join(allowedReads, "\n"))
+			sboxCmd.FlagWithInput("--fsatrace ", r.ctx.Config().PrebuiltBuildTool(r.ctx, "fsatrace")).
+				FlagWithInput("--fs-profile ", fsProfile)
+		}
+
 		// Replace the command string, and add the sbox tool and manifest textproto to the
 		// dependencies of the final sbox rule.
 		commandString = sboxCmd.buf.String()
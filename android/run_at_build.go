@@ -0,0 +1,91 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+
+	"github.com/google/blueprint/proptools"
+)
+
+// RunAtBuildProperties are the properties of the host tests that can run during the build, so that
+// the tests of the tools that the build relies on gate the build rather than a separate test run.
+type RunAtBuildProperties struct {
+	// Run the test during the build whenever it is built, and fail the build if the test fails. The
+	// test runs without a device, in a sandbox that only allows it to read its inputs and to write
+	// to a temporary directory. Only supported by host tests.
+	Run_at_build *bool
+
+	// The time in seconds after which a test run during the build is killed and fails. Defaults
+	// to 600.
+	Run_at_build_timeout *int64
+}
+
+const defaultRunAtBuildTimeout = 600
+
+// RunTestAtBuild adds a rule that runs a host test during the build if run_at_build is set, and
+// returns a copy of the test that validates the run, so that building the copy, e.g. to install it,
+// runs the test. cmd adds the command line of the test to the rule, deps are the other files that
+// the test reads, like its data and shared libraries, and readOnlyDirs are the directories of the
+// source tree that it may read, like the toolchain that runs it. The test is returned as-is if
+// run_at_build isn't set, or if the test can't run on the build machine.
+func RunTestAtBuild(ctx ModuleContext, props RunAtBuildProperties, test Path,
+	cmd func(*RuleBuilderCommand), deps Paths, readOnlyDirs []string) Path {
+
+	if !proptools.Bool(props.Run_at_build) {
+		return test
+	}
+	if !ctx.Host() {
+		ctx.PropertyErrorf("run_at_build", "only host tests can run during the build")
+		return test
+	}
+	if ctx.Os() != ctx.Config().BuildOS {
+		return test
+	}
+	timeout := proptools.IntDefault(props.Run_at_build_timeout, defaultRunAtBuildTimeout)
+	if timeout <= 0 {
+		ctx.PropertyErrorf("run_at_build_timeout", "must be positive, got %d", timeout)
+		return test
+	}
+
+	sandboxDir := PathForModuleOut(ctx, "run_at_build")
+	timestamp := sandboxDir.Join(ctx, "run_at_build.timestamp")
+	rule := NewRuleBuilder(pctx, ctx).Sbox(sandboxDir, PathForModuleOut(ctx, "run_at_build.sbox.textproto"))
+	if ctx.Config().BuildOS == Linux {
+		rule.SandboxFsProfile(readOnlyDirs)
+	}
+	// The test may only write to a temporary directory in the sandbox, which sbox discards.
+	tmpDir := sandboxDir.Join(ctx, "tmp")
+	mkdir := rule.Command().Text("mkdir -p")
+	mkdir.Text(mkdir.PathForOutput(tmpDir))
+	command := rule.Command()
+	command.Textf("TMPDIR=%s", command.PathForOutput(tmpDir)).
+		Text("timeout").Flag(fmt.Sprintf("%ds", timeout)).
+		Implicits(deps)
+	cmd(command)
+	rule.Command().Text("touch").Output(timestamp)
+	rule.Build("run_at_build", "run "+ctx.ModuleName()+" at build")
+
+	// Like the package check of java libraries, the copy of the test is validated by the run
+	// rather than depending on it, so that modules depending on the test don't wait for it to pass.
+	validated := PathForModuleOut(ctx, "run_at_build_validated", test.Base())
+	ctx.Build(pctx, BuildParams{
+		Rule:       Cp,
+		Input:      test,
+		Output:     validated,
+		Validation: timestamp,
+	})
+	return validated
+}
@@ -0,0 +1,257 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/blueprint"
+)
+
+// This file implements the SBOMs of the partitions and the APEXes, which are SPDX 2.3 documents
+// listing the files installed in each partition, or packaged in each APEX, with their checksums,
+// and the packages, i.e. the modules, that build them, with their licenses and the version and
+// provenance of their projects from the METADATA files. `m sbom` writes them to out/soong/sbom,
+// and dists them.
+//
+// The singleton writes the spec of each SBOM, from which gen_sbom reads the METADATA files and
+// checksums the files when they are built.
+
+func init() {
+	RegisterSbomBuildComponents(InitRegistrationContext)
+}
+
+func RegisterSbomBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("sbom", sbomSingletonFactory)
+}
+
+var PrepareForTestWithSbom = FixtureRegisterWithContext(RegisterSbomBuildComponents)
+
+var (
+	_ = pctx.HostBinToolVariable("genSbomCmd", "gen_sbom")
+
+	genSbomRule = pctx.AndroidStaticRule("genSbom", blueprint.RuleParams{
+		Command:     "${genSbomCmd} -build_date_file '${buildDateFile}' -o $out $in",
+		CommandDeps: []string{"${genSbomCmd}"},
+	}, "buildDateFile")
+)
+
+// SbomContainerInfo is provided by the modules that package the files of other modules into a
+// container that has its own SBOM, e.g. APEXes.
+type SbomContainerInfo struct {
+	Files []SbomContainerFile
+}
+
+// SbomContainerFile is a file of a container, by its path in the container and the name of the
+// module that it comes from, or an empty name if it is generated by the container.
+type SbomContainerFile struct {
+	Path   string
+	Src    Path
+	Module string
+}
+
+var SbomContainerInfoProvider = blueprint.NewProvider(SbomContainerInfo{})
+
+// The spec of an SBOM, read by gen_sbom.
+type sbomSpec struct {
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace"`
+	Packages  []*sbomPackage `json:"packages"`
+}
+
+type sbomPackage struct {
+	Name         string     `json:"name"`
+	PackageName  string     `json:"package_name,omitempty"`
+	Dir          string     `json:"dir"`
+	Metadata     string     `json:"metadata,omitempty"`
+	LicenseKinds []string   `json:"license_kinds,omitempty"`
+	Prebuilt     bool       `json:"prebuilt,omitempty"`
+	Files        []sbomFile `json:"files"`
+}
+
+type sbomFile struct {
+	Path string `json:"path"`
+	Src  string `json:"src"`
+
+	src Path
+}
+
+func sbomSingletonFactory() Singleton {
+	return &sbomSingleton{}
+}
+
+type sbomSingleton struct {
+	sboms Paths
+}
+
+func (s *sbomSingleton) GenerateBuildActions(ctx SingletonContext) {
+	// The packages of the modules without their files, by module name.
+	modules := make(map[string]*sbomPackage)
+	// The packages of each partition, by module name.
+	partitions := make(map[string]map[string]*sbomPackage)
+	containers := make(map[string]SbomContainerInfo)
+	metadataFiles := make(map[string]OptionalPath)
+
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() {
+			return
+		}
+		m := module.base()
+		name := ctx.ModuleName(module)
+		if _, ok := modules[name]; !ok {
+			modules[name] = &sbomPackage{
+				Name:         name,
+				PackageName:  String(m.commonProperties.Effective_package_name),
+				Dir:          ctx.ModuleDir(module),
+				Metadata:     findSbomMetadata(ctx, metadataFiles, ctx.ModuleDir(module)),
+				LicenseKinds: m.EffectiveLicenseKinds(),
+				Prebuilt:     IsModulePrebuilt(module),
+			}
+		}
+		if ctx.ModuleHasProvider(module, SbomContainerInfoProvider) {
+			containers[name] = ctx.ModuleProvider(module, SbomContainerInfoProvider).(SbomContainerInfo)
+		}
+		if !m.Device() || m.IsSkipInstall() {
+			return
+		}
+		for _, spec := range m.PackagingSpecs() {
+			if spec.SrcPath() == nil || spec.Partition() == "" {
+				continue
+			}
+			pkgs := partitions[spec.Partition()]
+			if pkgs == nil {
+				pkgs = make(map[string]*sbomPackage)
+				partitions[spec.Partition()] = pkgs
+			}
+			pkg := pkgs[name]
+			if pkg == nil {
+				p := *modules[name]
+				pkg = &p
+				pkgs[name] = pkg
+			}
+			pkg.Files = append(pkg.Files, sbomFile{Path: spec.RelPathInPackage(), Src: spec.SrcPath().String(), src: spec.SrcPath()})
+		}
+	})
+
+	for _, partition := range SortedKeys(partitions) {
+		s.buildSbom(ctx, partition, "sbom", partitions[partition])
+	}
+	for _, container := range SortedKeys(containers) {
+		pkgs := make(map[string]*sbomPackage)
+		for _, f := range containers[container].Files {
+			name := f.Module
+			if name == "" {
+				name = container
+			}
+			pkg := pkgs[name]
+			if pkg == nil {
+				if m, ok := modules[name]; ok {
+					p := *m
+					pkg = &p
+				} else {
+					pkg = &sbomPackage{Name: name}
+				}
+				pkgs[name] = pkg
+			}
+			pkg.Files = append(pkg.Files, sbomFile{Path: f.Path, Src: f.Src.String(), src: f.Src})
+		}
+		s.buildSbom(ctx, container, filepath.Join("sbom", "apex"), pkgs)
+	}
+
+	if len(s.sboms) > 0 {
+		ctx.Phony("sbom", s.sboms...)
+	}
+}
+
+// findSbomMetadata returns the METADATA file of the project of a module, i.e. the first METADATA
+// file in the directory of the module or in its parents, or an empty string if there is none.
+func findSbomMetadata(ctx SingletonContext, cache map[string]OptionalPath, dir string) string {
+	path, ok := cache[dir]
+	if !ok {
+		path = ExistentPathForSource(ctx, dir, "METADATA")
+		if !path.Valid() && dir != "." && dir != "" {
+			if parent := findSbomMetadata(ctx, cache, filepath.Dir(dir)); parent != "" {
+				path = OptionalPathForPath(PathForSource(ctx, parent))
+			}
+		}
+		cache[dir] = path
+	}
+	if !path.Valid() {
+		return ""
+	}
+	return path.String()
+}
+
+// buildSbom writes the spec of the SBOM of a partition or a container, with its packages sorted by
+// name and their files sorted by path, and adds the rule that generates it.
+func (s *sbomSingleton) buildSbom(ctx SingletonContext, name, dir string, pkgs map[string]*sbomPackage) {
+	spec := sbomSpec{
+		Name: name,
+		Namespace: fmt.Sprintf("https://www.google.com/sbom/spdx/android/%s/%s/%s",
+			String(ctx.Config().productVariables.DeviceProduct), ctx.Config().BuildId(), name),
+	}
+	var deps Paths
+	for _, pkgName := range SortedKeys(pkgs) {
+		pkg := pkgs[pkgName]
+		sort.SliceStable(pkg.Files, func(i, j int) bool { return pkg.Files[i].Path < pkg.Files[j].Path })
+		for _, f := range pkg.Files {
+			deps = append(deps, f.src)
+		}
+		if pkg.Metadata != "" {
+			deps = append(deps, PathForSource(ctx, pkg.Metadata))
+		}
+		spec.Packages = append(spec.Packages, pkg)
+	}
+
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the SBOM spec of %s: %s", name, err)
+		return
+	}
+	// The spec is written during analysis rather than by a rule so that its contents, which list
+	// every installed file, aren't part of the ninja file.
+	specFile := PathForOutput(ctx, dir, name+".spec.json")
+	if err := WriteFileToOutputDir(specFile, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", specFile, err)
+		return
+	}
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: specFile,
+	})
+
+	sbom := PathForOutput(ctx, dir, name+".spdx.json")
+	ctx.Build(pctx, BuildParams{
+		Rule:        genSbomRule,
+		Description: "SBOM " + name,
+		Input:       specFile,
+		Implicits:   deps,
+		Output:      sbom,
+		Args: map[string]string{
+			"buildDateFile": ctx.Config().Getenv("BUILD_DATETIME_FILE"),
+		},
+	})
+	s.sboms = append(s.sboms, sbom)
+}
+
+func (s *sbomSingleton) MakeVars(ctx MakeVarsContext) {
+	if len(s.sboms) > 0 {
+		ctx.DistForGoal("sbom", s.sboms...)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A module that stands for the APEXes, which package the files of other modules.
+type sbomContainerTestModule struct {
+	ModuleBase
+	props struct {
+		Contents []string
+	}
+}
+
+func sbomContainerTestModuleFactory() Module {
+	m := &sbomContainerTestModule{}
+	m.AddProperties(&m.props)
+	InitAndroidModule(m)
+	return m
+}
+
+func (m *sbomContainerTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	manifest := PathForModuleOut(ctx, "manifest.json")
+	WriteFileRule(ctx, manifest, "")
+	files := []SbomContainerFile{{Path: "manifest.json", Src: manifest}}
+	for _, name := range m.props.Contents {
+		files = append(files, SbomContainerFile{
+			Path:   "lib64/" + name,
+			Src:    PathForModuleOut(ctx, name),
+			Module: name,
+		})
+	}
+	ctx.SetProvider(SbomContainerInfoProvider, SbomContainerInfo{Files: files})
+}
+
+func TestSbom(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithSbom,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("component", componentTestModuleFactory)
+			ctx.RegisterModuleType("container", sbomContainerTestModuleFactory)
+		}),
+		MockFS{
+			"foo/METADATA": nil,
+		}.AddToFixture(),
+		FixtureAddTextFile("foo/Android.bp", `
+			component {
+				name: "foo",
+			}
+		`),
+		FixtureWithRootAndroidBp(`
+			component {
+				name: "bar",
+				vendor: true,
+			}
+
+			component {
+				name: "baz",
+				skip_install: true,
+			}
+
+			container {
+				name: "com.android.foo",
+				contents: ["foo"],
+			}
+		`),
+	).RunTest(t)
+
+	readSpec := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	sbom := result.SingletonForTests("sbom")
+	AssertStringEquals(t, "system", `{
+  "name": "system",
+  "namespace": "https://www.google.com/sbom/spdx/android/test_product//system",
+  "packages": [
+    {
+      "name": "foo",
+      "dir": "foo",
+      "metadata": "foo/METADATA",
+      "files": [
+        {
+          "path": "lib/foo",
+          "src": "out/soong/.intermediates/foo/android_arm_armv7-a-neon/foo"
+        },
+        {
+          "path": "lib64/foo",
+          "src": "out/soong/.intermediates/foo/android_arm64_armv8-a/foo"
+        }
+      ]
+    }
+  ]
+}
+`, StringRelativeToTop(result.Config, readSpec("sbom/system.spec.json")))
+
+	AssertStringEquals(t, "vendor", `{
+  "name": "vendor",
+  "namespace": "https://www.google.com/sbom/spdx/android/test_product//vendor",
+  "packages": [
+    {
+      "name": "bar",
+      "dir": ".",
+      "files": [
+        {
+          "path": "lib/bar",
+          "src": "out/soong/.intermediates/bar/android_arm_armv7-a-neon/bar"
+        },
+        {
+          "path": "lib64/bar",
+          "src": "out/soong/.intermediates/bar/android_arm64_armv8-a/bar"
+        }
+      ]
+    }
+  ]
+}
+`, StringRelativeToTop(result.Config, readSpec("sbom/vendor.spec.json")))
+
+	AssertStringEquals(t, "apex", `{
+  "name": "com.android.foo",
+  "namespace": "https://www.google.com/sbom/spdx/android/test_product//com.android.foo",
+  "packages": [
+    {
+      "name": "com.android.foo",
+      "dir": ".",
+      "files": [
+        {
+          "path": "manifest.json",
+          "src": "out/soong/.intermediates/com.android.foo/manifest.json"
+        }
+      ]
+    },
+    {
+      "name": "foo",
+      "dir": "foo",
+      "metadata": "foo/METADATA",
+      "files": [
+        {
+          "path": "lib64/foo",
+          "src": "out/soong/.intermediates/com.android.foo/foo"
+        }
+      ]
+    }
+  ]
+}
+`, StringRelativeToTop(result.Config, readSpec("sbom/apex/com.android.foo.spec.json")))
+
+	gen := sbom.Output("sbom/system.spdx.json")
+	AssertPathRelativeToTopEquals(t, "spec", "out/soong/sbom/system.spec.json", gen.Input)
+	AssertPathsRelativeToTopEquals(t, "deps", []string{
+		"out/soong/.intermediates/foo/android_arm_armv7-a-neon/foo",
+		"out/soong/.intermediates/foo/android_arm64_armv8-a/foo",
+		"foo/METADATA",
+	}, gen.Implicits)
+}
@@ -0,0 +1,227 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+)
+
+// This file implements the check of the min_sdk_version and sdk_version properties of all the
+// modules, whatever their module type. A version must be parseable, and a finalized API level must
+// not be beyond the SDK version of the platform: a module that targets an unreleased API level
+// uses its codename, or "current". `m check-sdk-versions` writes the problems of all the modules
+// to out/soong/sdk_version_check.json, and fails with the list of them. soong_ui sets
+// SOONG_CHECK_SDK_VERSIONS when the goal is built, as the check visits all the modules.
+
+func init() {
+	RegisterSdkVersionCheckBuildComponents(InitRegistrationContext)
+}
+
+func RegisterSdkVersionCheckBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("sdk_version_check", sdkVersionCheckSingletonFactory)
+}
+
+var PrepareForTestWithSdkVersionCheck = FixtureRegisterWithContext(RegisterSdkVersionCheckBuildComponents)
+
+// The properties that are checked, by their field names.
+var sdkVersionCheckedProperties = map[string]bool{
+	"Min_sdk_version": true,
+	"Sdk_version":     true,
+}
+
+// The special values of the checked properties that are not API levels.
+var sdkVersionSpecialValues = []string{"apex_inherit", "minimum"}
+
+// sdkVersionProblem is a min_sdk_version or sdk_version property of a module with an invalid value.
+type sdkVersionProblem struct {
+	Module   string `json:"module"`
+	Dir      string `json:"dir"`
+	Property string `json:"property"`
+	Value    string `json:"value"`
+	Error    string `json:"error"`
+}
+
+// checkSdkVersion returns the problem of the value of a min_sdk_version (if minSdk) or
+// sdk_version property, or an empty string if it is valid.
+func checkSdkVersion(config Config, value string, minSdk bool) string {
+	if InList(value, sdkVersionSpecialValues) {
+		return ""
+	}
+
+	var level ApiLevel
+	if minSdk {
+		var err error
+		if level, err = ApiLevelFromUserWithConfig(config, value); err != nil {
+			return err.Error()
+		}
+	} else {
+		spec := SdkSpecFromWithConfig(config, value)
+		if spec.Kind == SdkInvalid {
+			return fmt.Sprintf("%q is not a valid sdk_version", value)
+		}
+		level = spec.ApiLevel
+	}
+
+	if level.IsPreview() || level.IsNone() || config.RawPlatformSdkVersion() == nil {
+		return ""
+	}
+	// The raw future API level is the same as "current".
+	if level.FinalInt() == FutureApiLevelInt {
+		return ""
+	}
+	if platform := config.PlatformSdkVersion(); level.GreaterThan(platform) {
+		return fmt.Sprintf("API level %s is beyond the platform SDK version %s, use the codename of the preview or \"current\"",
+			level, platform)
+	}
+	return ""
+}
+
+// sdkVersionProperties returns the values of the checked properties in the property structs of a
+// module, by their property names.
+func sdkVersionProperties(props []interface{}) map[string]string {
+	values := make(map[string]string)
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := prefix + proptools.PropertyNameForField(field.Name)
+			fv := v.Field(i)
+			if sdkVersionCheckedProperties[field.Name] && fv.Type() == reflect.TypeOf((*string)(nil)) {
+				if !fv.IsNil() && fv.Elem().String() != "" {
+					values[name] = fv.Elem().String()
+				}
+				continue
+			}
+			if field.Anonymous {
+				walk(fv, prefix)
+			} else {
+				walk(fv, name+".")
+			}
+		}
+	}
+	for _, p := range props {
+		walk(reflect.ValueOf(p), "")
+	}
+	return values
+}
+
+func sdkVersionCheckSingletonFactory() Singleton {
+	return &sdkVersionCheckSingleton{}
+}
+
+type sdkVersionCheckSingleton struct {
+	report WritablePath
+}
+
+func (s *sdkVersionCheckSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !ctx.Config().IsEnvTrue("SOONG_CHECK_SDK_VERSIONS") {
+		return
+	}
+
+	seen := make(map[sdkVersionProblem]bool)
+	problems := []sdkVersionProblem{}
+
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() {
+			return
+		}
+		for property, value := range sdkVersionProperties(module.GetProperties()) {
+			minSdk := strings.HasSuffix(property, "min_sdk_version")
+			if err := checkSdkVersion(ctx.Config(), value, minSdk); err != "" {
+				// The variants of a module report the same problems.
+				problem := sdkVersionProblem{
+					Module:   ctx.ModuleName(module),
+					Dir:      ctx.ModuleDir(module),
+					Property: property,
+					Value:    value,
+					Error:    err,
+				}
+				if !seen[problem] {
+					seen[problem] = true
+					problems = append(problems, problem)
+				}
+			}
+		}
+	})
+
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Dir != problems[j].Dir {
+			return problems[i].Dir < problems[j].Dir
+		}
+		if problems[i].Module != problems[j].Module {
+			return problems[i].Module < problems[j].Module
+		}
+		return problems[i].Property < problems[j].Property
+	})
+
+	data, err := json.MarshalIndent(problems, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the sdk version check: %s", err)
+		return
+	}
+	s.report = PathForOutput(ctx, "sdk_version_check.json")
+	WriteFileRuleVerbatim(ctx, s.report, string(data)+"\n")
+
+	stamp := PathForOutput(ctx, "sdk_version_check.stamp")
+	if len(problems) > 0 {
+		// The errors quote the values, list the properties and leave the details to the report.
+		var properties []string
+		for _, p := range problems {
+			properties = append(properties, p.Module+"."+p.Property)
+		}
+		ctx.Build(pctx, BuildParams{
+			Rule:     ErrorRule,
+			Output:   stamp,
+			Implicit: s.report,
+			Args: map[string]string{
+				"error": fmt.Sprintf("%d invalid sdk versions, see %s: %s", len(problems), s.report,
+					strings.Join(properties, ", ")),
+			},
+		})
+	} else {
+		ctx.Build(pctx, BuildParams{
+			Rule:     Touch,
+			Output:   stamp,
+			Implicit: s.report,
+		})
+	}
+
+	ctx.Phony("check-sdk-versions", stamp)
+}
+
+func (s *sdkVersionCheckSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.report != nil {
+		ctx.DistForGoal("check-sdk-versions", s.report)
+	}
+}
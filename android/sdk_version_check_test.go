@@ -0,0 +1,163 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+type sdkVersionCheckTestModule struct {
+	ModuleBase
+	props struct {
+		Min_sdk_version *string
+		Sdk_version     *string
+		Nested          struct {
+			Min_sdk_version *string
+		}
+	}
+}
+
+func sdkVersionCheckTestModuleFactory() Module {
+	m := &sdkVersionCheckTestModule{}
+	m.AddProperties(&m.props)
+	InitAndroidModule(m)
+	return m
+}
+
+func (m *sdkVersionCheckTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+}
+
+var prepareForSdkVersionCheckTest = GroupFixturePreparers(
+	PrepareForTestWithSdkVersionCheck,
+	FixtureMergeEnv(map[string]string{
+		"SOONG_CHECK_SDK_VERSIONS": "true",
+	}),
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("test_module", sdkVersionCheckTestModuleFactory)
+	}),
+)
+
+func TestSdkVersionCheck(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForSdkVersionCheckTest,
+		FixtureWithRootAndroidBp(`
+			test_module {
+				name: "valid",
+				min_sdk_version: "29",
+				sdk_version: "system_current",
+			}
+
+			test_module {
+				name: "preview",
+				min_sdk_version: "S",
+				sdk_version: "current",
+			}
+
+			test_module {
+				name: "special",
+				min_sdk_version: "apex_inherit",
+				sdk_version: "minimum",
+			}
+
+			test_module {
+				name: "unparseable",
+				min_sdk_version: "foo",
+				sdk_version: "bar_30",
+			}
+
+			test_module {
+				name: "too_new",
+				sdk_version: "system_31",
+				nested: {
+					min_sdk_version: "31",
+				},
+			}
+		`),
+	).RunTest(t)
+
+	check := result.SingletonForTests("sdk_version_check")
+	AssertStringEquals(t, "report", `[
+  {
+    "module": "too_new",
+    "dir": ".",
+    "property": "nested.min_sdk_version",
+    "value": "31",
+    "error": "API level 31 is beyond the platform SDK version 30, use the codename of the preview or \"current\""
+  },
+  {
+    "module": "too_new",
+    "dir": ".",
+    "property": "sdk_version",
+    "value": "system_31",
+    "error": "API level 31 is beyond the platform SDK version 30, use the codename of the preview or \"current\""
+  },
+  {
+    "module": "unparseable",
+    "dir": ".",
+    "property": "min_sdk_version",
+    "value": "foo",
+    "error": "\"foo\" could not be parsed as an integer and is not a recognized codename"
+  },
+  {
+    "module": "unparseable",
+    "dir": ".",
+    "property": "sdk_version",
+    "value": "bar_30",
+    "error": "\"bar_30\" is not a valid sdk_version"
+  }
+]
+`, ContentFromFileRuleForTests(t, check.Output("sdk_version_check.json")))
+
+	stamp := check.Output("sdk_version_check.stamp")
+	AssertStringEquals(t, "rule", ErrorRule.String(), stamp.Rule.String())
+	AssertStringDoesContain(t, "error", stamp.Args["error"],
+		"too_new.nested.min_sdk_version, too_new.sdk_version, unparseable.min_sdk_version, unparseable.sdk_version")
+}
+
+func TestSdkVersionCheckValid(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForSdkVersionCheckTest,
+		FixtureWithRootAndroidBp(`
+			test_module {
+				name: "valid",
+				min_sdk_version: "30",
+				sdk_version: "Tiramisu",
+			}
+		`),
+	).RunTest(t)
+
+	check := result.SingletonForTests("sdk_version_check")
+	AssertStringEquals(t, "report", "[]\n", ContentFromFileRuleForTests(t, check.Output("sdk_version_check.json")))
+	AssertStringEquals(t, "rule", Touch.String(), check.Output("sdk_version_check.stamp").Rule.String())
+}
+
+func TestSdkVersionCheckDisabled(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithSdkVersionCheck,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("test_module", sdkVersionCheckTestModuleFactory)
+		}),
+		FixtureWithRootAndroidBp(`
+			test_module {
+				name: "unparseable",
+				min_sdk_version: "foo",
+			}
+		`),
+	).RunTest(t)
+
+	if stamp := result.SingletonForTests("sdk_version_check").MaybeOutput("sdk_version_check.stamp"); stamp.Rule != nil {
+		t.Errorf("expected no check when the check-sdk-versions goal isn't built")
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/blueprint"
+)
+
+// This file implements the selinux_policy module type, which groups the SELinux policy sources of
+// a binary or an apex, and the selinux_policy property of all modules, which references the
+// selinux_policy modules that define the domains of the module. The policy modules are required by
+// the module, so that the policy is installed whenever the module is, and the build checks that
+// the policy defines the domains it declares, and that the domains of the files the module labels
+// with installed_file_attributes are declared by its policy modules.
+
+func init() {
+	RegisterSelinuxPolicyBuildComponents(InitRegistrationContext)
+}
+
+func RegisterSelinuxPolicyBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterModuleType("selinux_policy", SelinuxPolicyFactory)
+}
+
+var PrepareForTestWithSelinuxPolicy = FixtureRegisterWithContext(RegisterSelinuxPolicyBuildComponents)
+
+type selinuxPolicyDependencyTag struct {
+	blueprint.BaseDependencyTag
+}
+
+var selinuxPolicyDepTag = selinuxPolicyDependencyTag{}
+
+// SelinuxPolicyInfo is provided by selinux_policy modules, for the policy build to include them.
+type SelinuxPolicyInfo struct {
+	// The policy sources, e.g. .te and file_contexts files.
+	Srcs Paths
+
+	// The domains that the policy defines.
+	Domains []string
+
+	// A file that can only be built if the policy sources define the domains.
+	Check Path
+}
+
+var SelinuxPolicyInfoProvider = blueprint.NewProvider(SelinuxPolicyInfo{})
+
+type selinuxPolicyProperties struct {
+	// The policy sources, e.g. ["foo.te", "file_contexts"].
+	Srcs []string `android:"path"`
+
+	// The domains that the .te sources define, e.g. ["foo"] for the domain of the files labeled
+	// u:object_r:foo_exec:s0.
+	Domains []string
+}
+
+type selinuxPolicyModule struct {
+	ModuleBase
+
+	properties selinuxPolicyProperties
+
+	srcs  Paths
+	check WritablePath
+}
+
+var _ OutputFileProducer = (*selinuxPolicyModule)(nil)
+
+var selinuxDomainRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// selinux_policy groups the SELinux policy sources that define the domains of binaries and apexes,
+// which reference it from their selinux_policy property. The sources are the default output of the
+// module, e.g. for ":foo_policy" in the srcs of the modules that build the policy of a partition.
+func SelinuxPolicyFactory() Module {
+	module := &selinuxPolicyModule{}
+	module.AddProperties(&module.properties)
+	InitAndroidModule(module)
+	return module
+}
+
+func (p *selinuxPolicyModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	p.srcs = PathsForModuleSrc(ctx, p.properties.Srcs)
+	var teSrcs Paths
+	for _, src := range p.srcs {
+		if src.Ext() == ".te" {
+			teSrcs = append(teSrcs, src)
+		}
+	}
+	if len(p.properties.Domains) > 0 && len(teSrcs) == 0 {
+		ctx.PropertyErrorf("domains", "the domains must be defined by .te files in srcs")
+		return
+	}
+
+	// Check that the .te sources declare the domains, e.g. `type foo, domain;`.
+	p.check = PathForModuleOut(ctx, "check.timestamp")
+	builder := NewRuleBuilder(pctx, ctx)
+	for _, domain := range p.properties.Domains {
+		if !selinuxDomainRegexp.MatchString(domain) {
+			ctx.PropertyErrorf("domains", "%q is not a domain", domain)
+			continue
+		}
+		builder.Command().
+			Text("grep -qE").Flag(fmt.Sprintf(`'^\s*type\s+%s\s*[,;]'`, domain)).Inputs(teSrcs).
+			Text("||").
+			Text(fmt.Sprintf(`(echo "%s: domain %s is not defined by %s" && exit 1)`,
+				ctx.ModuleName(), domain, strings.Join(teSrcs.Strings(), " ")))
+	}
+	builder.Command().Text("touch").Output(p.check)
+	builder.Build("selinux_policy_check", fmt.Sprintf("Checking the SELinux domains of %s", ctx.ModuleName()))
+
+	ctx.SetProvider(SelinuxPolicyInfoProvider, SelinuxPolicyInfo{
+		Srcs:    p.srcs,
+		Domains: p.properties.Domains,
+		Check:   p.check,
+	})
+}
+
+// OutputFileProducer
+func (p *selinuxPolicyModule) OutputFiles(tag string) (Paths, error) {
+	if tag != "" {
+		return nil, fmt.Errorf("unsupported tag %q", tag)
+	}
+	return p.srcs, nil
+}
+
+// The policy module is a module of Make so that it can be required by the modules that reference
+// it. Installing it builds the check of its domains.
+func (p *selinuxPolicyModule) AndroidMkEntries() []AndroidMkEntries {
+	return []AndroidMkEntries{{
+		Class:      "FAKE",
+		OutputFile: OptionalPathForPath(p.check),
+	}}
+}
+
+// selinuxPolicyModuleNames returns the names of the modules referenced by the selinux_policy
+// property. The modules can be referenced by name or by ":name".
+func (m *ModuleBase) selinuxPolicyModuleNames() []string {
+	var names []string
+	for _, name := range m.commonProperties.Selinux_policy {
+		names = append(names, strings.TrimPrefix(name, ":"))
+	}
+	return names
+}
+
+// addSelinuxPolicyDependencies adds dependencies on the modules referenced by the selinux_policy
+// property of the module.
+func addSelinuxPolicyDependencies(ctx BottomUpMutatorContext) {
+	if names := ctx.Module().base().selinuxPolicyModuleNames(); len(names) > 0 {
+		ctx.AddVariationDependencies(nil, selinuxPolicyDepTag, names...)
+	}
+}
+
+// selinuxPolicyChecker verifies that the selinux_policy property references selinux_policy modules
+// that declare the domains of the executables labeled by the installed_file_attributes property.
+func selinuxPolicyChecker(ctx ModuleContext, specs []PackagingSpec) {
+	deps := ctx.GetDirectDepsWithTag(selinuxPolicyDepTag)
+	if len(deps) == 0 {
+		return
+	}
+	domains := make(map[string]bool)
+	for _, module := range deps {
+		if !ctx.OtherModuleHasProvider(module, SelinuxPolicyInfoProvider) {
+			ctx.PropertyErrorf("selinux_policy", "%q is not a selinux_policy module", ctx.OtherModuleName(module))
+			continue
+		}
+		info := ctx.OtherModuleProvider(module, SelinuxPolicyInfoProvider).(SelinuxPolicyInfo)
+		for _, domain := range info.Domains {
+			domains[domain] = true
+		}
+	}
+	if ctx.Failed() {
+		return
+	}
+	for _, spec := range specs {
+		// e.g. u:object_r:foo_exec:s0 for the executables of the foo domain.
+		fields := strings.Split(spec.Metadata().SelinuxLabel, ":")
+		if len(fields) < 3 || !strings.HasSuffix(fields[2], "_exec") {
+			continue
+		}
+		if domain := strings.TrimSuffix(fields[2], "_exec"); !domains[domain] {
+			ctx.PropertyErrorf("selinux_policy", "%s is labeled %s, but the domain %s is not declared by %s",
+				spec.RelPathInPackage(), spec.Metadata().SelinuxLabel, domain,
+				strings.Join(ctx.Module().base().selinuxPolicyModuleNames(), ", "))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+var prepareForSelinuxPolicyTest = GroupFixturePreparers(
+	PrepareForTestWithArchMutator,
+	PrepareForTestWithSelinuxPolicy,
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("component", componentTestModuleFactory)
+	}),
+	MockFS{
+		"foo.te":        nil,
+		"file_contexts": nil,
+	}.AddToFixture(),
+)
+
+func TestSelinuxPolicy(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForSelinuxPolicyTest,
+		FixtureWithRootAndroidBp(`
+			component {
+				name: "foo",
+				required: ["bar"],
+				selinux_policy: [":foo_policy"],
+				installed_file_attributes: [{
+					selinux_label: "u:object_r:foo_exec:s0",
+				}],
+			}
+
+			component {
+				name: "bar",
+			}
+
+			selinux_policy {
+				name: "foo_policy",
+				srcs: ["foo.te", "file_contexts"],
+				domains: ["foo"],
+			}
+		`),
+	).RunTest(t)
+
+	foo := result.ModuleForTests("foo", "android_arm64_armv8-a").Module()
+	AssertArrayString(t, "required", []string{"bar", "foo_policy"}, foo.RequiredModuleNames())
+
+	policy := result.ModuleForTests("foo_policy", "")
+	AssertStringDoesContain(t, "check", policy.Rule("selinux_policy_check").RuleParams.Command,
+		`grep -qE '^\s*type\s+foo\s*[,;]' foo.te || (echo "foo_policy: domain foo is not defined by foo.te" && exit 1)`)
+	outputs, err := policy.Module().(*selinuxPolicyModule).OutputFiles("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertPathsRelativeToTopEquals(t, "outputs", []string{"foo.te", "file_contexts"}, outputs)
+}
+
+func TestSelinuxPolicyErrors(t *testing.T) {
+	GroupFixturePreparers(
+		prepareForSelinuxPolicyTest,
+	).ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`module "foo".*: selinux_policy: lib(32|64)/foo is labeled u:object_r:foo_exec:s0, but the domain foo is not declared by bar_policy`,
+		`module "baz".*: selinux_policy: "bar" is not a selinux_policy module`,
+		`module "bad_policy".*: domains: the domains must be defined by .te files in srcs`,
+	})).RunTestWithBp(t, `
+		component {
+			name: "foo",
+			selinux_policy: ["bar_policy"],
+			installed_file_attributes: [{
+				selinux_label: "u:object_r:foo_exec:s0",
+			}],
+		}
+
+		component {
+			name: "bar",
+		}
+
+		component {
+			name: "baz",
+			selinux_policy: ["bar"],
+		}
+
+		selinux_policy {
+			name: "bar_policy",
+			srcs: ["foo.te"],
+			domains: ["bar"],
+		}
+
+		selinux_policy {
+			name: "bad_policy",
+			srcs: ["file_contexts"],
+			domains: ["bad"],
+		}
+	`)
+}
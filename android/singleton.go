@@ -171,6 +171,7 @@ func (s *singletonContextAdaptor) Build(pctx PackageContext, params BuildParams)
 	if s.Config().captureBuild {
 		s.buildParams = append(s.buildParams, params)
 	}
+	recordBuildProvenance(s.Config(), params)
 	bparams := convertBuildParams(params)
 	err := validateBuildParams(bparams)
 	if err != nil {
@@ -41,6 +41,7 @@ func RegisterSoongConfigModuleBuildComponents(ctx RegistrationContext) {
 	ctx.RegisterModuleType("soong_config_module_type", SoongConfigModuleTypeFactory)
 	ctx.RegisterModuleType("soong_config_string_variable", SoongConfigStringVariableDummyFactory)
 	ctx.RegisterModuleType("soong_config_bool_variable", SoongConfigBoolVariableDummyFactory)
+	ctx.RegisterSingletonType("soong_config_trace", soongConfigTraceSingletonFactory)
 }
 
 var PrepareForTestWithSoongConfigModuleBuildComponents = FixtureRegisterWithContext(RegisterSoongConfigModuleBuildComponents)
@@ -494,6 +495,7 @@ func configModuleFactory(factory blueprint.ModuleFactory, moduleType *soongconfi
 				for _, ps := range newProps {
 					ctx.AppendProperties(ps)
 				}
+				traceSoongConfigConditions(ctx, moduleType, conditionalProps, config)
 			})
 		}
 		return module, props
@@ -15,8 +15,11 @@
 package android
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
+
+	"android/soong/android/soongconfig"
 )
 
 type soongConfigTestDefaultsModule struct {
@@ -377,6 +380,96 @@ func TestNonExistentPropertyInSoongConfigModule(t *testing.T) {
 	})).RunTest(t)
 }
 
+func TestSoongConfigTrace(t *testing.T) {
+	bp := `
+		soong_config_module_type {
+			name: "acme_test",
+			module_type: "test",
+			config_namespace: "acme",
+			variables: ["board"],
+			bool_variables: ["feature1", "feature2"],
+			value_variables: ["size"],
+			properties: ["cflags"],
+		}
+
+		soong_config_string_variable {
+			name: "board",
+			values: ["soc_a", "soc_b"],
+		}
+
+		soong_config_module_type {
+			name: "other_test",
+			module_type: "test",
+			config_namespace: "other",
+			bool_variables: ["feature1"],
+			properties: ["cflags"],
+		}
+
+		acme_test {
+			name: "foo",
+			soong_config_variables: {
+				board: {
+					soc_a: {
+						cflags: ["-DSOC_A"],
+					},
+					conditions_default: {
+						cflags: ["-DSOC_DEFAULT"],
+					},
+				},
+				feature1: {
+					cflags: ["-DFEATURE1"],
+					conditions_default: {
+						cflags: ["-DNO_FEATURE1"],
+					},
+				},
+				size: {
+					cflags: ["-DSIZE=%s"],
+				},
+			},
+		}
+
+		other_test {
+			name: "bar",
+			soong_config_variables: {
+				feature1: {
+					cflags: ["-DFEATURE1"],
+				},
+			},
+		}
+	`
+
+	result := GroupFixturePreparers(
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.VendorVars = map[string]map[string]string{
+				"acme":  {"board": "soc_b", "size": "42"},
+				"other": {"feature1": "true"},
+			}
+		}),
+		FixtureMergeEnv(map[string]string{"SOONG_CONFIG_TRACE": "acme"}),
+		PrepareForTestWithDefaults,
+		PrepareForTestWithSoongConfigModuleBuildComponents,
+		prepareForSoongConfigTestModule,
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	content := ContentFromFileRuleForTests(t, result.SingletonForTests("soong_config_trace").Output("soong_config_trace.json"))
+	var entries []soongConfigTraceEntry
+	if err := json.Unmarshal([]byte(content), &entries); err != nil {
+		t.Fatalf("failed to parse the trace: %s\n%s", err, content)
+	}
+	AssertDeepEquals(t, "trace", []soongConfigTraceEntry{{
+		Module:     "foo",
+		Dir:        ".",
+		ModuleType: "acme_test",
+		Namespace:  "acme",
+		Variables: []soongconfig.ConditionTrace{
+			{Variable: "feature1", Condition: "conditions_default"},
+			{Variable: "size", IsSet: true, Value: "42", Condition: "size"},
+			{Variable: "board", IsSet: true, Value: "soc_b", Condition: "conditions_default"},
+		},
+	}}, entries)
+}
+
 func TestDuplicateStringValueInSoongConfigStringVariable(t *testing.T) {
 	bp := `
 		soong_config_string_variable {
@@ -0,0 +1,117 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"android/soong/android/soongconfig"
+)
+
+// This file records which conditionals of soong_config_module_type modules were applied, for the
+// Soong config namespaces listed in SOONG_CONFIG_TRACE. For example,
+// `SOONG_CONFIG_TRACE=acme,acme_camera m soong-config-trace` writes
+// out/soong/soong_config_trace.json with, for every module of a module type in the acme or
+// acme_camera namespaces, the values of the variables it uses and the conditions that applied.
+
+const soongConfigTraceEnv = "SOONG_CONFIG_TRACE"
+
+type soongConfigTraceEntry struct {
+	Module     string                       `json:"module"`
+	Dir        string                       `json:"dir"`
+	ModuleType string                       `json:"module_type"`
+	Namespace  string                       `json:"namespace"`
+	Variables  []soongconfig.ConditionTrace `json:"variables"`
+}
+
+type soongConfigTrace struct {
+	namespaces map[string]bool
+
+	lock    sync.Mutex
+	entries []soongConfigTraceEntry
+}
+
+var soongConfigTraceKey = NewOnceKey("SoongConfigTrace")
+
+func getSoongConfigTrace(config Config) *soongConfigTrace {
+	return config.Once(soongConfigTraceKey, func() interface{} {
+		trace := &soongConfigTrace{namespaces: make(map[string]bool)}
+		for _, namespace := range strings.Split(config.Getenv(soongConfigTraceEnv), ",") {
+			if namespace = strings.TrimSpace(namespace); namespace != "" {
+				trace.namespaces[namespace] = true
+			}
+		}
+		return trace
+	}).(*soongConfigTrace)
+}
+
+// traceSoongConfigConditions records the conditions that were applied to the module if the
+// namespace of its module type is traced.
+func traceSoongConfigConditions(ctx LoadHookContext, moduleType *soongconfig.ModuleType, props reflect.Value, config soongconfig.SoongConfig) {
+	trace := getSoongConfigTrace(ctx.Config())
+	if !trace.namespaces[moduleType.ConfigNamespace] {
+		return
+	}
+	entry := soongConfigTraceEntry{
+		Module:     ctx.ModuleName(),
+		Dir:        ctx.ModuleDir(),
+		ModuleType: ctx.ModuleType(),
+		Namespace:  moduleType.ConfigNamespace,
+		Variables:  soongconfig.TracePropertiesToApply(moduleType, props, config),
+	}
+	trace.lock.Lock()
+	defer trace.lock.Unlock()
+	trace.entries = append(trace.entries, entry)
+}
+
+func soongConfigTraceSingletonFactory() Singleton {
+	return &soongConfigTraceSingleton{}
+}
+
+type soongConfigTraceSingleton struct{}
+
+func (s *soongConfigTraceSingleton) GenerateBuildActions(ctx SingletonContext) {
+	trace := getSoongConfigTrace(ctx.Config())
+	if len(trace.namespaces) == 0 {
+		return
+	}
+
+	entries := append([]soongConfigTraceEntry(nil), trace.entries...)
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Dir != entries[j].Dir {
+			return entries[i].Dir < entries[j].Dir
+		}
+		return entries[i].Module < entries[j].Module
+	})
+	// Always write a list, so that an empty trace is distinguishable from a broken one.
+	if entries == nil {
+		entries = []soongConfigTraceEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the Soong config trace: %s", err)
+		return
+	}
+
+	out := PathForOutput(ctx, "soong_config_trace.json")
+	WriteFileRuleVerbatim(ctx, out, string(data)+"\n")
+
+	// `m soong-config-trace` writes the trace.
+	ctx.Phony("soong-config-trace", out)
+}
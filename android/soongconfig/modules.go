@@ -538,6 +538,41 @@ func PropertiesToApply(moduleType *ModuleType, props reflect.Value, config Soong
 	return ret, nil
 }
 
+// ConditionTrace describes how a Soong config variable affected the properties of a module.
+type ConditionTrace struct {
+	// Name of the variable.
+	Variable string `json:"variable"`
+
+	// Whether the variable was set by Make, and its value.
+	IsSet bool   `json:"is_set"`
+	Value string `json:"value"`
+
+	// The condition of soong_config_variables whose properties were applied to the module: a
+	// value of a string variable, the name of a bool or value variable, or "conditions_default".
+	// Empty if the module has no properties for the applied condition.
+	Condition string `json:"condition"`
+}
+
+// TracePropertiesToApply returns how each variable of the module type that the module sets
+// properties for affected the properties returned by PropertiesToApply.
+func TracePropertiesToApply(moduleType *ModuleType, props reflect.Value, config SoongConfig) []ConditionTrace {
+	var ret []ConditionTrace
+	props = props.Elem().FieldByName(SoongConfigProperty)
+	for i, c := range moduleType.Variables {
+		values := props.Field(i)
+		if !c.isReferenced(values) {
+			continue
+		}
+		ret = append(ret, ConditionTrace{
+			Variable:  c.variableName(),
+			IsSet:     config.IsSet(c.variableName()),
+			Value:     config.String(c.variableName()),
+			Condition: c.appliedCondition(config, values),
+		})
+	}
+	return ret
+}
+
 type ModuleType struct {
 	BaseModuleType  string
 	ConfigNamespace string
@@ -602,12 +637,26 @@ type soongConfigVariable interface {
 	// PropertiesToApply should return one of the interface{} values set by initializeProperties to be applied
 	// to the module.
 	PropertiesToApply(config SoongConfig, values reflect.Value) (interface{}, error)
+
+	// variableName returns the name of the variable.
+	variableName() string
+
+	// isReferenced returns true if the module sets properties for any condition of the variable.
+	isReferenced(values reflect.Value) bool
+
+	// appliedCondition returns the condition whose properties PropertiesToApply applies, or the
+	// empty string if there are none.
+	appliedCondition(config SoongConfig, values reflect.Value) string
 }
 
 type baseVariable struct {
 	variable string
 }
 
+func (c *baseVariable) variableName() string {
+	return c.variable
+}
+
 func (c *baseVariable) variableProperty() string {
 	return CanonicalizeToProperty(c.variable)
 }
@@ -661,6 +710,28 @@ func (s *stringVariable) PropertiesToApply(config SoongConfig, values reflect.Va
 	return values.Field(len(s.values)).Interface(), nil
 }
 
+func (s *stringVariable) isReferenced(values reflect.Value) bool {
+	for i := 0; i < values.NumField(); i++ {
+		if !values.Field(i).Elem().IsNil() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *stringVariable) appliedCondition(config SoongConfig, values reflect.Value) string {
+	configValue := config.String(s.variable)
+	for j, v := range s.values {
+		if configValue == v && !values.Field(j).Elem().IsNil() {
+			return v
+		}
+	}
+	if values.Field(len(s.values)).Elem().IsNil() {
+		return ""
+	}
+	return conditionsDefault
+}
+
 // Struct to allow conditions set based on a boolean variable
 type boolVariable struct {
 	baseVariable
@@ -754,6 +825,26 @@ func (b boolVariable) PropertiesToApply(config SoongConfig, values reflect.Value
 	return nil, nil
 }
 
+func (b boolVariable) isReferenced(values reflect.Value) bool {
+	return !values.Elem().IsZero()
+}
+
+func (b boolVariable) appliedCondition(config SoongConfig, values reflect.Value) string {
+	if config.Bool(b.variable) {
+		return b.variable
+	}
+	return appliedConditionsDefault(values)
+}
+
+// appliedConditionsDefault returns conditionsDefault if the conditions_default field of values,
+// initialized with initializePropertiesWithDefault, is set.
+func appliedConditionsDefault(values reflect.Value) string {
+	if f := conditionsDefaultField(values.Elem().Elem()); f.IsValid() && !f.IsNil() {
+		return conditionsDefault
+	}
+	return ""
+}
+
 // Struct to allow conditions set based on a value variable, supporting string substitution.
 type valueVariable struct {
 	baseVariable
@@ -820,6 +911,17 @@ func (s *valueVariable) PropertiesToApply(config SoongConfig, values reflect.Val
 	return values.Interface(), nil
 }
 
+func (s *valueVariable) isReferenced(values reflect.Value) bool {
+	return values.IsValid() && !values.Elem().IsZero()
+}
+
+func (s *valueVariable) appliedCondition(config SoongConfig, values reflect.Value) string {
+	if config.IsSet(s.variable) {
+		return s.variable
+	}
+	return appliedConditionsDefault(values)
+}
+
 func printfIntoProperty(propertyValue reflect.Value, configValue string) error {
 	s := propertyValue.String()
 
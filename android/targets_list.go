@@ -0,0 +1,66 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SoongTargetsListFileName is the name of the file in the soong output directory that lists the
+// targets that can be built, for use by `soong_ui --list-targets` and shell completion.
+const SoongTargetsListFileName = "soong_targets.txt"
+
+// Kinds of targets in the soong_targets.txt file.
+const (
+	TargetKindModule = "module"
+	TargetKindTest   = "test"
+	TargetKindPhony  = "phony"
+)
+
+// writeSoongTargetsList writes the list of modules, tests and phony goals known to Soong. Each
+// line contains the kind of the target and its name separated by a tab, sorted by name. The file
+// is written during analysis so that it is available without running ninja.
+func writeSoongTargetsList(ctx SingletonContext, phonies []string) {
+	kinds := make(map[string]string)
+	for _, phony := range phonies {
+		kinds[phony] = TargetKindPhony
+	}
+	ctx.VisitAllModules(func(module Module) {
+		name := ctx.ModuleName(module)
+		if tsm, ok := module.(TestSuiteModule); ok && len(tsm.TestSuites()) > 0 {
+			kinds[name] = TargetKindTest
+		} else if kinds[name] != TargetKindTest {
+			kinds[name] = TargetKindModule
+		}
+	})
+
+	var buf strings.Builder
+	for _, name := range SortedKeys(kinds) {
+		fmt.Fprintf(&buf, "%s\t%s\n", kinds[name], name)
+	}
+
+	path := PathForOutput(ctx, SoongTargetsListFileName)
+	if err := WriteFileToOutputDir(path, []byte(buf.String()), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", path, err)
+		return
+	}
+
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: path,
+	})
+}
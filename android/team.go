@@ -0,0 +1,173 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/google/blueprint"
+)
+
+// This file implements the team module type, which registers a team that owns modules, and the
+// team property of all modules, which must reference a team module. The ownership singleton
+// writes out/soong/ownership-<product>.json, which maps every file installed by a Soong module to
+// the module and its team, so that build breakages and size regressions can be routed to the
+// owners automatically. It is written during analysis, so `m nothing` or `m ownership-map` writes
+// it.
+
+func init() {
+	RegisterTeamBuildComponents(InitRegistrationContext)
+}
+
+var PrepareForTestWithTeamBuildComponents = FixtureRegisterWithContext(RegisterTeamBuildComponents)
+
+// Register the team module type and the ownership singleton.
+func RegisterTeamBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterModuleType("team", TeamFactory)
+	ctx.RegisterSingletonType("ownership", ownershipSingletonFactory)
+}
+
+type teamDependencyTag struct {
+	blueprint.BaseDependencyTag
+}
+
+var teamDepTag = teamDependencyTag{}
+
+type teamProperties struct {
+	// The component of the bug tracker in which the bugs of the modules of the team are filed.
+	Bug_component *string
+	// The email addresses or aliases to contact about the modules of the team.
+	Contacts []string
+}
+
+type teamModule struct {
+	ModuleBase
+
+	properties teamProperties
+}
+
+func (t *teamModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	if t.properties.Bug_component != nil {
+		if _, err := strconv.ParseUint(*t.properties.Bug_component, 10, 64); err != nil {
+			ctx.PropertyErrorf("bug_component", "must be the numeric id of a component, got %q",
+				*t.properties.Bug_component)
+		}
+	}
+}
+
+// team defines a team that owns modules. Modules reference it from their team property.
+func TeamFactory() Module {
+	module := &teamModule{}
+	module.AddProperties(&module.properties)
+	InitAndroidModule(module)
+	return module
+}
+
+// addTeamDependency adds a dependency on the team module referenced by the team property of the
+// module.
+func addTeamDependency(ctx BottomUpMutatorContext) {
+	if team := ctx.Module().base().Team(); team != "" {
+		ctx.AddVariationDependencies(nil, teamDepTag, team)
+	}
+}
+
+// teamPropertyChecker verifies that the team property references a team module.
+func teamPropertyChecker(ctx ModuleContext) {
+	for _, module := range ctx.GetDirectDepsWithTag(teamDepTag) {
+		if _, ok := module.(*teamModule); !ok {
+			ctx.PropertyErrorf("team", "%q is not a team module", ctx.OtherModuleName(module))
+		}
+	}
+}
+
+type ownershipEntry struct {
+	// The path of the installed file, e.g. out/target/product/<device>/system/bin/foo.
+	File   string `json:"file"`
+	Module string `json:"module"`
+	// The team of the module, unset for modules without a team.
+	Team         string   `json:"team,omitempty"`
+	BugComponent string   `json:"bug_component,omitempty"`
+	Contacts     []string `json:"contacts,omitempty"`
+	// The vendor that owns the module, from the owner property.
+	Owner string `json:"owner,omitempty"`
+}
+
+func ownershipSingletonFactory() Singleton {
+	return &ownershipSingleton{}
+}
+
+type ownershipSingleton struct{}
+
+func (s *ownershipSingleton) GenerateBuildActions(ctx SingletonContext) {
+	teams := make(map[string]*teamModule)
+	ctx.VisitAllModules(func(module Module) {
+		if team, ok := module.(*teamModule); ok {
+			teams[ctx.ModuleName(module)] = team
+		}
+	})
+
+	var entries []ownershipEntry
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() || module.IsSkipInstall() {
+			return
+		}
+		for _, file := range module.FilesToInstall() {
+			entry := ownershipEntry{
+				File:   file.ToMakePath().String(),
+				Module: ctx.ModuleName(module),
+				Team:   module.base().Team(),
+				Owner:  module.Owner(),
+			}
+			if team := teams[entry.Team]; team != nil {
+				entry.BugComponent = String(team.properties.Bug_component)
+				entry.Contacts = team.properties.Contacts
+			}
+			entries = append(entries, entry)
+		}
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].File < entries[j].File
+	})
+	if entries == nil {
+		entries = []ownershipEntry{}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the ownership map: %s", err)
+		return
+	}
+
+	product := "unknown"
+	if ctx.Config().HasDeviceProduct() {
+		product = ctx.Config().DeviceProduct()
+	}
+	out := PathForOutput(ctx, "ownership-"+product+".json")
+	// The file is written during analysis rather than by a rule so that its contents aren't part of
+	// the ninja file.
+	if err := WriteFileToOutputDir(out, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", out, err)
+		return
+	}
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: out,
+	})
+
+	ctx.Phony("ownership-map", out)
+}
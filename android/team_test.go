@@ -0,0 +1,111 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+var prepareForTeamTest = GroupFixturePreparers(
+	PrepareForTestWithArchMutator,
+	PrepareForTestWithTeamBuildComponents,
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("component", componentTestModuleFactory)
+	}),
+	FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+		variables.DeviceProduct = proptools.StringPtr("test_product")
+	}),
+)
+
+func TestOwnershipMap(t *testing.T) {
+	bp := `
+		team {
+			name: "media_team",
+			bug_component: "1234",
+			contacts: ["media-team@example.com"],
+		}
+
+		component {
+			name: "libmedia",
+			team: "media_team",
+		}
+
+		component {
+			name: "libunowned",
+			owner: "vendor_x",
+		}
+	`
+
+	result := GroupFixturePreparers(
+		prepareForTeamTest,
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), "ownership-test_product.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []ownershipEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range entries {
+		_, file, found := strings.Cut(e.File, "target/product/test_device/")
+		if !found || !strings.HasPrefix(file, "system/lib64/") {
+			continue
+		}
+		got = append(got, fmt.Sprintf("%s %s team=%q bug_component=%q contacts=%q owner=%q",
+			file, e.Module, e.Team, e.BugComponent, e.Contacts, e.Owner))
+	}
+	AssertArrayString(t, "ownership map", []string{
+		`system/lib64/libmedia libmedia team="media_team" bug_component="1234" contacts=["media-team@example.com"] owner=""`,
+		`system/lib64/libunowned libunowned team="" bug_component="" contacts=[] owner="vendor_x"`,
+	}, got)
+}
+
+func TestTeamPropertyErrors(t *testing.T) {
+	bp := `
+		team {
+			name: "bad_team",
+			bug_component: "media",
+		}
+
+		component {
+			name: "not_a_team",
+		}
+
+		component {
+			name: "libfoo",
+			team: "not_a_team",
+		}
+	`
+
+	GroupFixturePreparers(
+		prepareForTeamTest,
+		FixtureWithRootAndroidBp(bp),
+	).ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`module "bad_team": bug_component: must be the numeric id of a component, got "media"`,
+		`module "libfoo" variant "[^"]+": team: "not_a_team" is not a team module`,
+	})).RunTest(t)
+}
@@ -0,0 +1,252 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/blueprint"
+)
+
+func init() {
+	RegisterTestSuiteBuildComponents(InitRegistrationContext)
+}
+
+func RegisterTestSuiteBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterModuleType("test_suite", TestSuiteFactory)
+}
+
+var PrepareForTestWithTestSuite = FixtureRegisterWithContext(RegisterTestSuiteBuildComponents)
+
+// TestSuiteInfo is provided by the test modules that can be packaged by a test_suite.
+type TestSuiteInfo struct {
+	// The files of the test, e.g. its executable and its data.
+	Files []TestSuiteFile
+	// The Tradefed config of the test, if it has one.
+	Config Path
+}
+
+// TestSuiteFile is a file of a test, and its path relative to the directory of the test in the
+// testcases directory of a suite.
+type TestSuiteFile struct {
+	Src Path
+	Rel string
+}
+
+var TestSuiteInfoProvider = blueprint.NewProvider(TestSuiteInfo{})
+
+// TestSuiteFilesForData returns the files of a test for its data, at the same paths as the
+// LOCAL_TEST_DATA of Make.
+func TestSuiteFilesForData(data []DataPath) []TestSuiteFile {
+	var files []TestSuiteFile
+	for _, d := range data {
+		rel := filepath.Join(d.RelativeInstallPath, d.SrcPath.Rel())
+		files = append(files, TestSuiteFile{Src: d.SrcPath, Rel: rel})
+	}
+	return files
+}
+
+type testSuiteProperties struct {
+	// The test modules of the suite. The device and host variants of a test are packaged in
+	// target/testcases/<test> and host/testcases/<test>, along with its Tradefed config as
+	// <test>.config.
+	Tests []string
+
+	// The host tools that run the suite, packaged in host/tools.
+	Host_tools []string
+
+	// The Tradefed filters of the tests or test cases that the suite runs, e.g. "FooTests" or
+	// "FooTests com.android.foo.FooTest#testBar", written in the config of the suite. If empty,
+	// the suite runs all of its tests.
+	Include_filters []string
+
+	// The Tradefed filters of the tests or test cases that the suite doesn't run.
+	Exclude_filters []string
+}
+
+type testSuiteModule struct {
+	ModuleBase
+
+	properties testSuiteProperties
+
+	outputFile OutputPath
+}
+
+var _ OutputFileProducer = (*testSuiteModule)(nil)
+
+type testSuiteDependencyTag struct {
+	blueprint.BaseDependencyTag
+	name string
+}
+
+var (
+	testSuiteTestTag     = testSuiteDependencyTag{name: "test"}
+	testSuiteHostToolTag = testSuiteDependencyTag{name: "host_tool"}
+)
+
+// test_suite packages its tests, their data and Tradefed configs, and the host tools that run them
+// into <name>.zip with the layout of the suites of Make, e.g. general-tests:
+//
+//	<name>.xml
+//	host/testcases/<test>/...
+//	host/tools/...
+//	target/testcases/<test>/...
+//
+// <name>.xml is the Tradefed config of the suite, with its include and exclude filters.
+func TestSuiteFactory() Module {
+	module := &testSuiteModule{}
+	module.AddProperties(&module.properties)
+	InitAndroidModule(module)
+	return module
+}
+
+func (m *testSuiteModule) DepsMutator(ctx BottomUpMutatorContext) {
+	config := ctx.Config()
+	for _, test := range m.properties.Tests {
+		// A test has either an arch or a common variant for Java on the device and on the host.
+		for _, targets := range [][]Target{
+			{config.AndroidFirstDeviceTarget, config.AndroidCommonTarget},
+			{config.BuildOSTarget, config.BuildOSCommonTarget},
+		} {
+			for _, target := range targets {
+				if ctx.OtherModuleFarDependencyVariantExists(target.Variations(), test) {
+					ctx.AddFarVariationDependencies(target.Variations(), testSuiteTestTag, test)
+					break
+				}
+			}
+		}
+	}
+	ctx.AddFarVariationDependencies(config.BuildOSTarget.Variations(), testSuiteHostToolTag,
+		m.properties.Host_tools...)
+}
+
+// testSuiteConfig is the Tradefed config of a suite.
+type testSuiteConfig struct {
+	XMLName     xml.Name                `xml:"configuration"`
+	Description string                  `xml:"description,attr"`
+	Options     []testSuiteConfigOption `xml:"option"`
+}
+
+type testSuiteConfigOption struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func (m *testSuiteModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	stagingDir := PathForModuleOut(ctx, "suite")
+	// The files of the suite, by their path in the suite.
+	files := make(map[string]Path)
+	addFile := func(rel string, src Path) {
+		if other, ok := files[rel]; ok {
+			if other.String() != src.String() {
+				ctx.ModuleErrorf("%q and %q are both packaged as %q", other, src, rel)
+			}
+			return
+		}
+		files[rel] = src
+	}
+
+	var tests []string
+	ctx.VisitDirectDepsWithTag(testSuiteTestTag, func(dep Module) {
+		name := ctx.OtherModuleName(dep)
+		if !ctx.OtherModuleHasProvider(dep, TestSuiteInfoProvider) {
+			ctx.PropertyErrorf("tests", "%q is not a test module that can be packaged in a suite",
+				name)
+			return
+		}
+		info := ctx.OtherModuleProvider(dep, TestSuiteInfoProvider).(TestSuiteInfo)
+		dir := filepath.Join("target", "testcases", name)
+		if dep.Target().Os.Class == Host {
+			dir = filepath.Join("host", "testcases", name)
+		}
+		for _, f := range info.Files {
+			addFile(filepath.Join(dir, f.Rel), f.Src)
+		}
+		if info.Config != nil {
+			addFile(filepath.Join(dir, name+".config"), info.Config)
+		}
+		tests = append(tests, name)
+	})
+
+	ctx.VisitDirectDepsWithTag(testSuiteHostToolTag, func(dep Module) {
+		tool, ok := dep.(HostToolProvider)
+		if !ok || !tool.HostToolPath().Valid() {
+			ctx.PropertyErrorf("host_tools", "%q is not a host tool", ctx.OtherModuleName(dep))
+			return
+		}
+		path := tool.HostToolPath().Path()
+		addFile(filepath.Join("host", "tools", path.Base()), path)
+	})
+
+	config := testSuiteConfig{Description: fmt.Sprintf("Runs the tests of %s.", ctx.ModuleName())}
+	for _, filter := range m.properties.Include_filters {
+		config.Options = append(config.Options,
+			testSuiteConfigOption{"compatibility:include-filter", filter})
+	}
+	for _, filter := range m.properties.Exclude_filters {
+		config.Options = append(config.Options,
+			testSuiteConfigOption{"compatibility:exclude-filter", filter})
+	}
+	data, err := xml.MarshalIndent(config, "", "    ")
+	if err != nil {
+		ctx.ModuleErrorf("failed to write the config of the suite: %s", err)
+		return
+	}
+	configFile := PathForModuleOut(ctx, ctx.ModuleName()+".xml")
+	WriteFileRuleVerbatim(ctx, configFile, xml.Header+string(data)+"\n")
+	addFile(configFile.Base(), configFile)
+
+	var staged Paths
+	for _, rel := range SortedKeys(files) {
+		out := stagingDir.Join(ctx, rel)
+		ctx.Build(pctx, BuildParams{
+			Rule:   Cp,
+			Input:  files[rel],
+			Output: out,
+		})
+		staged = append(staged, out)
+	}
+
+	m.outputFile = PathForModuleOut(ctx, ctx.ModuleName()+".zip").OutputPath
+	rule := NewRuleBuilder(pctx, ctx)
+	rule.Command().BuiltTool("soong_zip").
+		FlagWithOutput("-o ", m.outputFile).
+		FlagWithArg("-C ", stagingDir.String()).
+		FlagWithRspFileInputList("-r ", m.outputFile.ReplaceExtension(ctx, "rsp"), staged)
+	rule.Build("test_suite_zip",
+		fmt.Sprintf("Packaging the %d tests of %s", len(tests), ctx.ModuleName()))
+}
+
+// OutputFileProducer
+func (m *testSuiteModule) OutputFiles(tag string) (Paths, error) {
+	if tag != "" {
+		return nil, fmt.Errorf("unsupported tag %q", tag)
+	}
+	return Paths{m.outputFile}, nil
+}
+
+func (m *testSuiteModule) AndroidMkEntries() []AndroidMkEntries {
+	return []AndroidMkEntries{{
+		Class:      "ETC",
+		OutputFile: OptionalPathForPath(m.outputFile),
+		ExtraEntries: []AndroidMkExtraEntriesFunc{
+			func(ctx AndroidMkExtraEntriesContext, entries *AndroidMkEntries) {
+				entries.SetBool("LOCAL_UNINSTALLABLE_MODULE", true)
+			},
+		},
+	}}
+}
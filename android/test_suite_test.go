@@ -0,0 +1,151 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+// A test module that provides its executable and data, like cc_test.
+type testSuiteTestModule struct {
+	ModuleBase
+	props struct {
+		Data []string `android:"path"`
+	}
+}
+
+func testSuiteTestModuleFactory() Module {
+	m := &testSuiteTestModule{}
+	m.AddProperties(&m.props)
+	InitAndroidArchModule(m, HostAndDeviceSupported, MultilibFirst)
+	return m
+}
+
+func (m *testSuiteTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	out := PathForModuleOut(ctx, ctx.ModuleName())
+	WriteFileRule(ctx, out, "")
+	config := PathForModuleOut(ctx, ctx.ModuleName()+".config")
+	WriteFileRule(ctx, config, "")
+
+	var data []DataPath
+	for _, d := range PathsForModuleSrc(ctx, m.props.Data) {
+		data = append(data, DataPath{SrcPath: d})
+	}
+	ctx.SetProvider(TestSuiteInfoProvider, TestSuiteInfo{
+		Files:  append([]TestSuiteFile{{Src: out, Rel: out.Base()}}, TestSuiteFilesForData(data)...),
+		Config: config,
+	})
+}
+
+type testSuiteHostToolModule struct {
+	ModuleBase
+	out OutputPath
+}
+
+func testSuiteHostToolModuleFactory() Module {
+	m := &testSuiteHostToolModule{}
+	InitAndroidArchModule(m, HostSupported, MultilibFirst)
+	return m
+}
+
+func (m *testSuiteHostToolModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	m.out = PathForModuleOut(ctx, ctx.ModuleName()).OutputPath
+	WriteFileRule(ctx, m.out, "")
+}
+
+func (m *testSuiteHostToolModule) HostToolPath() OptionalPath {
+	return OptionalPathForPath(m.out)
+}
+
+var prepareForTestSuiteTest = GroupFixturePreparers(
+	PrepareForTestWithArchMutator,
+	PrepareForTestWithTestSuite,
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("suite_test", testSuiteTestModuleFactory)
+		ctx.RegisterModuleType("suite_host_tool", testSuiteHostToolModuleFactory)
+		ctx.RegisterModuleType("component", componentTestModuleFactory)
+	}),
+	MockFS{
+		"testdata/foo.txt": nil,
+	}.AddToFixture(),
+)
+
+func TestTestSuite(t *testing.T) {
+	result := prepareForTestSuiteTest.RunTestWithBp(t, `
+		suite_test {
+			name: "foo_test",
+			host_supported: true,
+			data: ["testdata/foo.txt"],
+		}
+
+		suite_test {
+			name: "bar_test",
+		}
+
+		suite_host_tool {
+			name: "suite-tradefed",
+		}
+
+		test_suite {
+			name: "foo-tests",
+			tests: ["foo_test", "bar_test"],
+			host_tools: ["suite-tradefed"],
+			include_filters: ["foo_test"],
+			exclude_filters: ["foo_test FooTest#testBar"],
+		}
+	`)
+
+	suite := result.ModuleForTests("foo-tests", "")
+	zip := suite.Output("foo-tests.zip")
+	AssertPathsRelativeToTopEquals(t, "packaged files", []string{
+		"out/soong/.intermediates/foo-tests/suite/foo-tests.xml",
+		"out/soong/.intermediates/foo-tests/suite/host/testcases/foo_test/foo_test",
+		"out/soong/.intermediates/foo-tests/suite/host/testcases/foo_test/foo_test.config",
+		"out/soong/.intermediates/foo-tests/suite/host/testcases/foo_test/testdata/foo.txt",
+		"out/soong/.intermediates/foo-tests/suite/host/tools/suite-tradefed",
+		"out/soong/.intermediates/foo-tests/suite/target/testcases/bar_test/bar_test",
+		"out/soong/.intermediates/foo-tests/suite/target/testcases/bar_test/bar_test.config",
+		"out/soong/.intermediates/foo-tests/suite/target/testcases/foo_test/foo_test",
+		"out/soong/.intermediates/foo-tests/suite/target/testcases/foo_test/foo_test.config",
+		"out/soong/.intermediates/foo-tests/suite/target/testcases/foo_test/testdata/foo.txt",
+	}, zip.Inputs)
+
+	data := suite.Output("out/soong/.intermediates/foo-tests/suite/target/testcases/foo_test/testdata/foo.txt")
+	AssertPathRelativeToTopEquals(t, "data", "testdata/foo.txt", data.Input)
+
+	config := suite.Output("out/soong/.intermediates/foo-tests/foo-tests.xml")
+	AssertStringEquals(t, "config", `<?xml version="1.0" encoding="UTF-8"?>
+<configuration description="Runs the tests of foo-tests.">
+    <option name="compatibility:include-filter" value="foo_test"></option>
+    <option name="compatibility:exclude-filter" value="foo_test FooTest#testBar"></option>
+</configuration>
+`, ContentFromFileRuleForTests(t, config))
+}
+
+func TestTestSuiteErrors(t *testing.T) {
+	prepareForTestSuiteTest.
+		ExtendWithErrorHandler(FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`"foo" is not a test module that can be packaged in a suite`)).
+		RunTestWithBp(t, `
+			component {
+				name: "foo",
+			}
+
+			test_suite {
+				name: "foo-tests",
+				tests: ["foo"],
+			}
+		`)
+}
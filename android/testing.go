@@ -82,6 +82,7 @@ var PrepareForTestWithOverrides = FixtureRegisterWithContext(func(ctx Registrati
 var PrepareForTestWithLicenses = GroupFixturePreparers(
 	FixtureRegisterWithContext(RegisterLicenseKindBuildComponents),
 	FixtureRegisterWithContext(RegisterLicenseBuildComponents),
+	FixtureRegisterWithContext(RegisterLicenseExceptionBuildComponents),
 	FixtureRegisterWithContext(registerLicenseMutators),
 )
 
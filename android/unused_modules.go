@@ -0,0 +1,255 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+)
+
+// This file writes out/soong/unused_modules-<product>.json when soong_build runs with
+// --report-unused-modules (`m nothing --report-unused-modules`). It lists the modules that are
+// defined but not reachable through dependencies or required modules from the modules installed
+// by the product, the tests or the modules with dist targets, so that dead Android.bp entries can
+// be removed.
+//
+// The modules installed by the product are the PRODUCT_PACKAGES provided by Make. If Make didn't
+// provide them, every installed module is used instead, and only the modules that aren't installed
+// at all can be reported. Modules only used by Make modules are reported too; they can be ignored
+// by listing them in the file named by SOONG_UNUSED_MODULES_ALLOWLIST, one per line, as either a
+// module name, "//<dir>:<name>" or "//<dir>/..." for all the modules under a directory.
+
+func init() {
+	RegisterUnusedModulesBuildComponents(InitRegistrationContext)
+}
+
+func RegisterUnusedModulesBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("unused_modules", unusedModulesSingletonFactory)
+}
+
+var PrepareForTestWithUnusedModules = FixtureRegisterWithContext(RegisterUnusedModulesBuildComponents)
+
+// unusedModulesIgnoredTypes are the module types that don't build anything, and so are never
+// reached from installed modules.
+var unusedModulesIgnoredTypes = map[string]bool{
+	"package":         true,
+	"soong_namespace": true,
+	"license_kind":    true,
+}
+
+type unusedModule struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Dir  string `json:"dir"`
+}
+
+type unusedModulesReport struct {
+	Product string `json:"product"`
+	// Whether the PRODUCT_PACKAGES were known, see the comment at the top of this file.
+	ProductPackagesKnown bool           `json:"product_packages_known"`
+	UnusedModules        []unusedModule `json:"unused_modules"`
+}
+
+func unusedModulesSingletonFactory() Singleton {
+	return &unusedModulesSingleton{}
+}
+
+type unusedModulesSingleton struct{}
+
+func (s *unusedModulesSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !ctx.Config().ReportUnusedModules() {
+		return
+	}
+
+	allowlist, err := readUnusedModulesAllowlist(ctx)
+	if err != nil {
+		ctx.Errorf("failed to read the unused modules allowlist: %s", err)
+		return
+	}
+
+	productPackages := make(map[string]bool)
+	for _, name := range ctx.Config().ProductPackages() {
+		productPackages[name] = true
+	}
+
+	// The enabled modules, and the modules of each name. Source and prebuilt modules share their
+	// name so that using either of them uses both.
+	var modules []Module
+	byName := make(map[string][]Module)
+	name := func(module Module) string {
+		return RemoveOptionalPrebuiltPrefix(ctx.ModuleName(module))
+	}
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() {
+			return
+		}
+		modules = append(modules, module)
+		byName[name(module)] = append(byName[name(module)], module)
+	})
+
+	var roots []Module
+	for _, module := range modules {
+		if isUnusedModulesRoot(module, productPackages, len(productPackages) > 0) {
+			roots = append(roots, module)
+		}
+	}
+
+	used := make(map[string]bool)
+	visited := make(map[Module]bool)
+	queue := roots
+	for _, root := range roots {
+		visited[root] = true
+	}
+	enqueue := func(module Module) {
+		if !visited[module] {
+			visited[module] = true
+			queue = append(queue, module)
+		}
+	}
+	for len(queue) > 0 {
+		module := queue[0]
+		queue = queue[1:]
+		if used[name(module)] {
+			continue
+		}
+		used[name(module)] = true
+		for _, variant := range byName[name(module)] {
+			ctx.VisitDirectDeps(variant, enqueue)
+			for _, required := range variant.RequiredModuleNames() {
+				for _, m := range byName[required] {
+					enqueue(m)
+				}
+			}
+			for _, required := range append(variant.HostRequiredModuleNames(), variant.TargetRequiredModuleNames()...) {
+				for _, m := range byName[required] {
+					enqueue(m)
+				}
+			}
+		}
+	}
+
+	report := unusedModulesReport{
+		Product:              "unknown",
+		ProductPackagesKnown: len(productPackages) > 0,
+		UnusedModules:        []unusedModule{},
+	}
+	if ctx.Config().HasDeviceProduct() {
+		report.Product = ctx.Config().DeviceProduct()
+	}
+	for _, n := range SortedKeys(byName) {
+		module := byName[n][0]
+		if used[n] || unusedModulesIgnoredTypes[ctx.ModuleType(module)] {
+			continue
+		}
+		if _, ok := module.(Defaults); ok {
+			continue
+		}
+		if allowlist.contains(ctx.ModuleDir(module), n) {
+			continue
+		}
+		report.UnusedModules = append(report.UnusedModules, unusedModule{
+			Name: n,
+			Type: ctx.ModuleType(module),
+			Dir:  ctx.ModuleDir(module),
+		})
+	}
+	sort.SliceStable(report.UnusedModules, func(i, j int) bool {
+		return report.UnusedModules[i].Dir < report.UnusedModules[j].Dir
+	})
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the unused modules: %s", err)
+		return
+	}
+
+	// The report is written during analysis so that `m nothing` writes it.
+	path := PathForOutput(ctx, "unused_modules-"+report.Product+".json")
+	if err := WriteFileToOutputDir(path, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", path, err)
+		return
+	}
+
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: path,
+	})
+}
+
+// isUnusedModulesRoot returns true if the module is used regardless of the other modules: it is
+// installed by the product, it is a test or it has dist targets.
+func isUnusedModulesRoot(module Module, productPackages map[string]bool, productPackagesKnown bool) bool {
+	if productPackagesKnown {
+		if productPackages[RemoveOptionalPrebuiltPrefix(module.Name())] {
+			return true
+		}
+	} else if len(module.FilesToInstall()) > 0 && !module.IsSkipInstall() {
+		return true
+	}
+	if tsm, ok := module.(TestSuiteModule); ok && len(tsm.TestSuites()) > 0 {
+		return true
+	}
+	return len(module.base().Dists()) > 0
+}
+
+// unusedModulesAllowlist is the set of modules that are never reported as unused.
+type unusedModulesAllowlist struct {
+	names  map[string]bool
+	labels map[string]bool
+	dirs   []string
+}
+
+func (a unusedModulesAllowlist) contains(dir, name string) bool {
+	return a.names[name] || a.labels["//"+dir+":"+name] || HasAnyPrefix(dir+"/", a.dirs)
+}
+
+// readUnusedModulesAllowlist reads the file named by SOONG_UNUSED_MODULES_ALLOWLIST, if it is set.
+func readUnusedModulesAllowlist(ctx SingletonContext) (unusedModulesAllowlist, error) {
+	file := ctx.Config().Getenv("SOONG_UNUSED_MODULES_ALLOWLIST")
+	if file == "" {
+		return parseUnusedModulesAllowlist(""), nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return unusedModulesAllowlist{}, err
+	}
+	ctx.AddNinjaFileDeps(file)
+	return parseUnusedModulesAllowlist(string(data)), nil
+}
+
+// parseUnusedModulesAllowlist parses an allowlist with one entry per line. Empty lines and lines
+// starting with # are ignored.
+func parseUnusedModulesAllowlist(data string) unusedModulesAllowlist {
+	a := unusedModulesAllowlist{
+		names:  make(map[string]bool),
+		labels: make(map[string]bool),
+	}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasSuffix(line, "/..."):
+			a.dirs = append(a.dirs, strings.TrimPrefix(strings.TrimSuffix(line, "..."), "//"))
+		case strings.HasPrefix(line, "//"):
+			a.labels[line] = true
+		default:
+			a.names[line] = true
+		}
+	}
+	return a
+}
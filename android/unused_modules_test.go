@@ -0,0 +1,117 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+func TestUnusedModules(t *testing.T) {
+	bp := `
+		component {
+			name: "foo",
+			deps: ["libbar"],
+			required: ["baz"],
+		}
+
+		component {
+			name: "libbar",
+		}
+
+		component {
+			name: "baz",
+		}
+
+		component {
+			name: "dist_only",
+			dist: {
+				targets: ["droidcore"],
+			},
+		}
+
+		component {
+			name: "libunused",
+		}
+
+		component {
+			name: "libunused_dep",
+		}
+
+		component {
+			name: "unused",
+			deps: ["libunused_dep"],
+		}
+	`
+
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithUnusedModules,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("component", componentTestModuleFactory)
+		}),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.DeviceProduct = proptools.StringPtr("test_product")
+			variables.ProductPackages = []string{"foo"}
+		}),
+		FixtureModifyConfig(func(config Config) {
+			config.reportUnusedModules = true
+		}),
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), "unused_modules-test_product.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report unusedModulesReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range report.UnusedModules {
+		got = append(got, m.Name)
+	}
+	AssertBoolEquals(t, "product packages known", true, report.ProductPackagesKnown)
+	AssertArrayString(t, "unused modules", []string{"libunused", "libunused_dep", "unused"}, got)
+}
+
+func TestUnusedModulesAllowlist(t *testing.T) {
+	a := parseUnusedModulesAllowlist(`
+		# Used by Make modules.
+		libfoo
+		//vendor/bar:libbar
+		//device/...
+	`)
+
+	for _, tc := range []struct {
+		dir, name string
+		expected  bool
+	}{
+		{"anywhere", "libfoo", true},
+		{"vendor/bar", "libbar", true},
+		{"vendor/baz", "libbar", false},
+		{"device", "libbaz", true},
+		{"device/google/x", "libbaz", true},
+		{"devices", "libbaz", false},
+	} {
+		AssertBoolEquals(t, "//"+tc.dir+":"+tc.name, tc.expected, a.contains(tc.dir, tc.name))
+	}
+}
@@ -289,14 +289,37 @@ type productVariables struct {
 	EnforceRROTargets          []string `json:",omitempty"`
 	EnforceRROExcludedOverlays []string `json:",omitempty"`
 
+	AutoGeneratedRROs   *bool   `json:",omitempty"`
+	DeviceRROPartition  *string `json:",omitempty"`
+	DeviceRROPriority   *int    `json:",omitempty"`
+	ProductRROPartition *string `json:",omitempty"`
+	ProductRROPriority  *int    `json:",omitempty"`
+
 	AAPTCharacteristics *string  `json:",omitempty"`
 	AAPTConfig          []string `json:",omitempty"`
 	AAPTPreferredConfig *string  `json:",omitempty"`
 	AAPTPrebuiltDPI     []string `json:",omitempty"`
 
+	AppImportPreferredAbis []string `json:",omitempty"`
+
 	DefaultAppCertificate           *string `json:",omitempty"`
 	MainlineSepolicyDevCertificates *string `json:",omitempty"`
 
+	BuildProvenance           *bool   `json:",omitempty"`
+	BuildProvenanceSigningKey *string `json:",omitempty"`
+
+	ReproducibleBuild *bool   `json:",omitempty"`
+	SourceDateEpoch   *string `json:",omitempty"`
+
+	// The checks of the linked device binaries, with the baseline file of the known offenders.
+	ElfCheckBtiPac          *bool   `json:",omitempty"`
+	ElfCheckExecuteOnly     *bool   `json:",omitempty"`
+	ElfCheckTextRelocations *bool   `json:",omitempty"`
+	ElfCheckMaxPageSize     *string `json:",omitempty"`
+	ElfCheckBaseline        *string `json:",omitempty"`
+
+	MinSdkVersionWaivers *string `json:",omitempty"`
+
 	AppsDefaultVersionName *string `json:",omitempty"`
 
 	Real_hal                   *bool `json:",omitempty"`
@@ -406,6 +429,8 @@ type productVariables struct {
 	DirectedRamdiskSnapshot bool            `json:",omitempty"`
 	RamdiskSnapshotModules  map[string]bool `json:",omitempty"`
 
+	DedupSnapshotPrebuilts bool `json:",omitempty"`
+
 	VendorSnapshotDirsIncluded   []string `json:",omitempty"`
 	VendorSnapshotDirsExcluded   []string `json:",omitempty"`
 	RecoverySnapshotDirsExcluded []string `json:",omitempty"`
@@ -447,6 +472,9 @@ type productVariables struct {
 	CompressedApex               *bool `json:",omitempty"`
 	Aml_abis                     *bool `json:",omitempty"`
 
+	CompressedApexOverrides         []string `json:",omitempty"`
+	BoardApexDecompressionSupported *bool    `json:",omitempty"`
+
 	DexpreoptGlobalConfig *string `json:",omitempty"`
 
 	WithDexpreopt bool `json:",omitempty"`
@@ -478,7 +506,12 @@ type productVariables struct {
 	EnforceInterPartitionJavaSdkLibrary *bool    `json:",omitempty"`
 	InterPartitionJavaLibraryAllowList  []string `json:",omitempty"`
 
-	InstallExtraFlattenedApexes *bool `json:",omitempty"`
+	InstallExtraFlattenedApexes *bool    `json:",omitempty"`
+	FlattenApexModules          []string `json:",omitempty"`
+
+	ExternalSigningCommand *string  `json:",omitempty"`
+	ExternalSigningModules []string `json:",omitempty"`
+	ExternalSigningDryRun  *bool    `json:",omitempty"`
 
 	BoardUsesRecoveryAsBoot *bool `json:",omitempty"`
 	BoardUsesRamdiskAsBoot *bool `json:",omitempty"`
@@ -491,6 +524,8 @@ type productVariables struct {
 
 	PrebuiltHiddenApiDir *string `json:",omitempty"`
 
+	ShardedHiddenApiFlags *bool `json:",omitempty"`
+
 	ShippingApiLevel *string `json:",omitempty"`
 
 	BuildBrokenClangAsFlags            bool     `json:",omitempty"`
@@ -520,6 +555,8 @@ type productVariables struct {
 	IncludeTags    []string `json:",omitempty"`
 	SourceRootDirs []string `json:",omitempty"`
 
+	SourceOverrideModules []string `json:",omitempty"`
+
 	AfdoProfiles []string `json:",omitempty"`
 
 	ProductManufacturer string   `json:",omitempty"`
@@ -528,6 +565,31 @@ type productVariables struct {
 
 	ReleaseDefaultModuleBuildFromSource *bool `json:",omitempty"`
 
+	// The build flags of the release configuration, e.g. RELEASE_FOO, and their values.
+	BuildFlags map[string]string `json:",omitempty"`
+
+	// The PRODUCT_PACKAGES and PRODUCT_HOST_PACKAGES of the product, used by
+	// --report-unused-modules.
+	ProductPackages []string `json:",omitempty"`
+
+	// The makefiles that added each of the ProductPackages, from the product makefile to the
+	// makefile that lists the module, and the "<path>:<error|warning|ignore>" strictness of the
+	// check that the ProductPackages exist, by the path of the makefile that lists them.
+	ProductPackagesOrigins    map[string][]string `json:",omitempty"`
+	ProductPackagesStrictness []string            `json:",omitempty"`
+
+	// The file names of the shared libraries that Make installs, by partition, which the linker
+	// configuration generated by Soong can't see.
+	MakeInstalledSharedLibs map[string][]string `json:",omitempty"`
+}
+
+// variableByName returns the field of the product variable with the given name, as it appears in
+// soong.variables, or false if there is no such variable.
+func (v *productVariables) variableByName(name string) (reflect.Value, bool) {
+	if field, ok := reflect.TypeOf(v).Elem().FieldByName(name); !ok || !field.IsExported() {
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(v).Elem().FieldByName(name), true
 }
 
 func boolPtr(v bool) *bool {
@@ -0,0 +1,161 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	RegisterVintfFragmentBuildComponents(InitRegistrationContext)
+}
+
+func RegisterVintfFragmentBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterModuleType("vintf_fragment", VintfFragmentFactory)
+	ctx.RegisterSingletonType("vintf_fragment_duplicates", vintfFragmentDuplicatesSingletonFactory)
+}
+
+var PrepareForTestWithVintfFragment = FixtureRegisterWithContext(RegisterVintfFragmentBuildComponents)
+
+type vintfFragmentProperties struct {
+	// The VINTF manifest fragment.
+	Src *string `android:"path"`
+
+	// The HAL instances that the fragment must declare, e.g.
+	// ["android.hardware.foo::IFoo/default", "android.hardware.bar@1.0::IBar/default"]. If empty,
+	// the HALs of the fragment are not checked against the module.
+	Hals []string
+}
+
+type vintfFragmentModule struct {
+	ModuleBase
+
+	properties vintfFragmentProperties
+
+	// The HAL instances that the fragment declares, one per line, written by vintf_fragment_check.
+	halsFile OutputPath
+
+	outputFilePath OutputPath
+	installDirPath InstallPath
+}
+
+var _ OutputFileProducer = (*vintfFragmentModule)(nil)
+
+// vintf_fragment installs a VINTF manifest fragment to <partition>/etc/vintf/manifest, from which
+// assemble_vintf assembles the VINTF manifest of the partition. The fragment is checked against the
+// manifest format and the HALs declared by the module when it is built, and the HAL instances must
+// not be declared by another vintf_fragment installed to the same partition.
+func VintfFragmentFactory() Module {
+	module := &vintfFragmentModule{}
+	module.AddProperties(&module.properties)
+	InitAndroidArchModule(module, DeviceSupported, MultilibFirst)
+	return module
+}
+
+func (m *vintfFragmentModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	if String(m.properties.Src) == "" {
+		ctx.PropertyErrorf("src", "missing VINTF manifest fragment")
+		return
+	}
+	for _, hal := range m.properties.Hals {
+		if strings.ContainsAny(hal, ", \t\n") {
+			ctx.PropertyErrorf("hals", "%q is not a HAL instance, e.g. android.hardware.foo::IFoo/default", hal)
+		}
+	}
+	src := PathForModuleSrc(ctx, String(m.properties.Src))
+
+	builder := NewRuleBuilder(pctx, ctx)
+	m.halsFile = PathForModuleOut(ctx, m.Name()+".hals").OutputPath
+	check := builder.Command().BuiltTool("vintf_fragment_check").
+		FlagWithOutput("-o ", m.halsFile)
+	if len(m.properties.Hals) > 0 {
+		check.FlagWithArg("-hals ", strings.Join(m.properties.Hals, ","))
+	}
+	check.Input(src)
+
+	// assemble_vintf checks the fragment further against libvintf, and writes it as it is included
+	// in the VINTF manifest of the partition.
+	m.outputFilePath = PathForModuleOut(ctx, src.Base()).OutputPath
+	builder.Command().
+		Flag("VINTF_IGNORE_TARGET_FCM_VERSION=true").
+		BuiltTool("assemble_vintf").
+		FlagWithInput("-i ", src).
+		FlagWithOutput("-o ", m.outputFilePath)
+	builder.Build("vintf_fragment", fmt.Sprintf("Processing VINTF fragment %s", src))
+
+	m.installDirPath = PathForModuleInstall(ctx, "etc", "vintf", "manifest")
+	ctx.InstallFile(m.installDirPath, m.outputFilePath.Base(), m.outputFilePath)
+}
+
+// OutputFileProducer
+func (m *vintfFragmentModule) OutputFiles(tag string) (Paths, error) {
+	if tag != "" {
+		return nil, fmt.Errorf("unsupported tag %q", tag)
+	}
+	return Paths{m.outputFilePath}, nil
+}
+
+func (m *vintfFragmentModule) AndroidMkEntries() []AndroidMkEntries {
+	return []AndroidMkEntries{{
+		Class:      "ETC",
+		OutputFile: OptionalPathForPath(m.outputFilePath),
+		ExtraEntries: []AndroidMkExtraEntriesFunc{
+			func(ctx AndroidMkExtraEntriesContext, entries *AndroidMkEntries) {
+				entries.SetString("LOCAL_MODULE_PATH", m.installDirPath.String())
+				entries.SetString("LOCAL_INSTALLED_MODULE_STEM", m.outputFilePath.Base())
+			},
+		},
+	}}
+}
+
+func vintfFragmentDuplicatesSingletonFactory() Singleton {
+	return &vintfFragmentDuplicatesSingleton{}
+}
+
+// vintfFragmentDuplicatesSingleton checks that the HAL instances of the vintf_fragment modules
+// installed to a partition are not declared more than once, as part of droidcore.
+type vintfFragmentDuplicatesSingleton struct{}
+
+func (s *vintfFragmentDuplicatesSingleton) GenerateBuildActions(ctx SingletonContext) {
+	halsFiles := make(map[string]Paths)
+	ctx.VisitAllModules(func(module Module) {
+		m, ok := module.(*vintfFragmentModule)
+		if !ok || !m.Enabled() || m.IsSkipInstall() || m.halsFile.String() == "" {
+			return
+		}
+		partition := m.installDirPath.partition
+		halsFiles[partition] = append(halsFiles[partition], m.halsFile)
+	})
+
+	var stamps Paths
+	for _, partition := range SortedKeys(halsFiles) {
+		stamp := PathForOutput(ctx, "vintf_fragment", partition+".duplicates.stamp")
+		builder := NewRuleBuilder(pctx, ctx)
+		builder.Command().BuiltTool("vintf_fragment_check").
+			Flag("-check_duplicates").
+			FlagWithOutput("-o ", stamp).
+			Inputs(halsFiles[partition])
+		builder.Build("vintf_fragment_duplicates_"+strings.ReplaceAll(partition, "/", "_"),
+			fmt.Sprintf("Checking the VINTF fragments of %s for duplicate HALs", partition))
+		stamps = append(stamps, stamp)
+	}
+	if len(stamps) == 0 {
+		return
+	}
+
+	ctx.Phony("check-vintf-fragments", stamps...)
+	ctx.Phony("droidcore", PathForPhony(ctx, "check-vintf-fragments"))
+}
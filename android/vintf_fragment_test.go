@@ -0,0 +1,82 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+func TestVintfFragment(t *testing.T) {
+	bp := `
+		vintf_fragment {
+			name: "foo_manifest",
+			src: "foo.xml",
+			hals: ["android.hardware.foo::IFoo/default"],
+		}
+
+		vintf_fragment {
+			name: "bar_manifest",
+			src: "bar.xml",
+		}
+
+		vintf_fragment {
+			name: "baz_manifest",
+			src: "baz.xml",
+			vendor: true,
+		}
+	`
+
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithVintfFragment,
+		FixtureWithRootAndroidBp(bp),
+		MockFS{
+			"foo.xml": nil,
+			"bar.xml": nil,
+			"baz.xml": nil,
+		}.AddToFixture(),
+	).RunTest(t)
+
+	foo := result.ModuleForTests("foo_manifest", "android_arm64_armv8-a")
+	cmd := foo.Rule("vintf_fragment").RuleParams.Command
+	AssertStringDoesContain(t, "check", cmd,
+		"vintf_fragment_check -o out/soong/.intermediates/foo_manifest/android_arm64_armv8-a/foo_manifest.hals -hals android.hardware.foo::IFoo/default foo.xml")
+	AssertStringDoesContain(t, "assemble", cmd,
+		"VINTF_IGNORE_TARGET_FCM_VERSION=true out/soong/host/linux-x86/bin/assemble_vintf -i foo.xml -o out/soong/.intermediates/foo_manifest/android_arm64_armv8-a/foo.xml")
+	AssertPathRelativeToTopEquals(t, "install", "out/soong/target/product/test_device/system/etc/vintf/manifest/foo.xml",
+		foo.Module().(*vintfFragmentModule).installDirPath.Join(result.TestContext, "foo.xml"))
+
+	singleton := result.SingletonForTests("vintf_fragment_duplicates")
+	AssertPathsRelativeToTopEquals(t, "system fragments", []string{
+		"out/soong/.intermediates/bar_manifest/android_arm64_armv8-a/bar_manifest.hals",
+		"out/soong/.intermediates/foo_manifest/android_arm64_armv8-a/foo_manifest.hals",
+	}, SortedUniquePaths(singleton.Rule("vintf_fragment_duplicates_system").Implicits))
+	AssertPathsRelativeToTopEquals(t, "vendor fragments", []string{
+		"out/soong/.intermediates/baz_manifest/android_arm64_armv8-a/baz_manifest.hals",
+	}, singleton.Rule("vintf_fragment_duplicates_vendor").Implicits)
+}
+
+func TestVintfFragmentErrors(t *testing.T) {
+	GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithVintfFragment,
+	).ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`module "foo_manifest".*: src: missing VINTF manifest fragment`,
+	})).RunTestWithBp(t, `
+		vintf_fragment {
+			name: "foo_manifest",
+		}
+	`)
+}
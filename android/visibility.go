@@ -316,6 +316,17 @@ func visibilityRuleGatherer(ctx BottomUpMutatorContext) {
 	// Parse the visibility rules that control access to the module and store them by id
 	// for use when enforcing the rules.
 	primaryProperty := m.base().primaryVisibilityProperty
+	if _, ok := m.(*visibilityGroupModule); ok {
+		// The members of a visibility group are not the visibility of the group itself.
+		rule := parseRules(ctx, currentPkg, primaryProperty.getName(), primaryProperty.getStrings())
+		for _, r := range rule {
+			if _, ok := r.(visibilityGroupRule); ok {
+				ctx.PropertyErrorf(primaryProperty.getName(), "cannot reference the visibility group %q", r)
+			}
+		}
+		visibilityGroupRuleMap(ctx.Config()).Store(qualifiedModuleId, rule)
+		return
+	}
 	if primaryProperty != nil {
 		if visibility := primaryProperty.getStrings(); visibility != nil {
 			rule := parseRules(ctx, currentPkg, primaryProperty.getName(), visibility)
@@ -363,10 +374,9 @@ func parseRules(ctx BaseModuleContext, currentPkg, property string, visibility [
 			case "__subpackages__":
 				r = subpackagesRule{pkg}
 			default:
-				ctx.PropertyErrorf(property, "invalid visibility pattern %q. Must match "+
-					" //<package>:<scope>, //<package> or :<scope> "+
-					"where <scope> is one of \"__pkg__\", \"__subpackages__\"",
-					v)
+				// Any other name refers to a visibility_group, which is checked by the enforcer once
+				// all the groups have been gathered.
+				r = visibilityGroupRule{ctx.Config(), qualifiedModuleName{pkg, name}}
 			}
 		}
 
@@ -440,6 +450,9 @@ func visibilityRuleEnforcer(ctx TopDownMutatorContext) {
 
 	qualified := createQualifiedModuleName(ctx.ModuleName(), ctx.ModuleDir())
 
+	// Check that the visibility groups used by the module exist.
+	checkVisibilityGroups(ctx)
+
 	// Visit all the dependencies making sure that this module has access to them all.
 	ctx.VisitDirectDeps(func(dep Module) {
 		// Ignore dependencies that have an ExcludeFromVisibilityEnforcementTag
@@ -464,6 +477,25 @@ func visibilityRuleEnforcer(ctx TopDownMutatorContext) {
 	})
 }
 
+func checkVisibilityGroups(ctx TopDownMutatorContext) {
+	m := ctx.Module().(Module)
+	primaryProperty := m.base().primaryVisibilityProperty
+	if primaryProperty == nil {
+		return
+	}
+	value, ok := moduleToVisibilityRuleMap(ctx.Config()).Load(m.qualifiedModuleId(ctx))
+	if !ok {
+		return
+	}
+	for _, r := range value.(compositeRule) {
+		if group, ok := r.(visibilityGroupRule); ok {
+			if _, ok := visibilityGroupRuleMap(ctx.Config()).Load(group.group); !ok {
+				ctx.PropertyErrorf(primaryProperty.getName(), "%q is not a visibility_group", group.String())
+			}
+		}
+	}
+}
+
 // Default visibility is public.
 var defaultVisibility = compositeRule{publicRule{}}
 
@@ -0,0 +1,93 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"sync"
+
+	"github.com/google/blueprint"
+)
+
+func init() {
+	RegisterVisibilityGroupBuildComponents(InitRegistrationContext)
+}
+
+var PrepareForTestWithVisibilityGroup = FixtureRegisterWithContext(RegisterVisibilityGroupBuildComponents)
+
+// Register the visibility_group module type.
+func RegisterVisibilityGroupBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterModuleType("visibility_group", VisibilityGroupFactory)
+}
+
+type visibilityGroupProperties struct {
+	// The visibility rules of the group, in the same form as the visibility property, e.g.
+	// ["//frameworks/base:__subpackages__", "//packages/apps/Settings"]. A module whose visibility
+	// lists the group is visible to the modules matched by any of these rules.
+	Members []string
+}
+
+type visibilityGroupModule struct {
+	ModuleBase
+
+	properties visibilityGroupProperties
+}
+
+func (g *visibilityGroupModule) GenerateAndroidBuildActions(ModuleContext) {
+	// Nothing to do.
+}
+
+func (g *visibilityGroupModule) GenerateBuildActions(ctx blueprint.ModuleContext) {
+	// Nothing to do.
+}
+
+// visibility_group defines a reusable set of visibility rules. Modules reference the group from
+// their visibility property as ":<name>" in the same package, or as "//<package>:<name>", instead
+// of repeating the rules of the group.
+func VisibilityGroupFactory() Module {
+	module := &visibilityGroupModule{}
+	module.AddProperties(&module.properties)
+
+	// The members property needs to be checked and parsed by the visibility module during its
+	// checking and parsing phases so make it the primary visibility property.
+	setPrimaryVisibilityProperty(module, "members", &module.properties.Members)
+
+	InitAndroidModule(module)
+	return module
+}
+
+var visibilityGroupRuleMapKey = NewOnceKey("visibilityGroupRuleMap")
+
+// The map from the qualifiedModuleName of each visibility_group to the rules of its members.
+func visibilityGroupRuleMap(config Config) *sync.Map {
+	return config.Once(visibilityGroupRuleMapKey, func() interface{} {
+		return &sync.Map{}
+	}).(*sync.Map)
+}
+
+// A visibilityGroupRule is a visibility rule that matches the modules matched by the members of a
+// visibility_group module.
+type visibilityGroupRule struct {
+	config Config
+	group  qualifiedModuleName
+}
+
+func (r visibilityGroupRule) matches(m qualifiedModuleName) bool {
+	value, ok := visibilityGroupRuleMap(r.config).Load(r.group)
+	return ok && value.(compositeRule).matches(m)
+}
+
+func (r visibilityGroupRule) String() string {
+	return r.group.String()
+}
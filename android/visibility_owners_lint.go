@@ -0,0 +1,328 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"bufio"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// This file implements the visibility owners lint, which cross-references the visibility rules
+// declared by the modules, or by the default_visibility of their packages, with the OWNERS files of
+// the directories, and reports the modules that are visible to directories whose owners are disjoint
+// from the owners of the module. The report helps to tighten the visibility of the modules
+// gradually, so it doesn't fail the build. `m visibility-owners-lint` writes it to
+// out/soong/visibility_owners_lint.json, and dists it. soong_ui sets SOONG_VISIBILITY_OWNERS_LINT
+// when the goal is built, as the lint visits all the modules.
+//
+// The owners of a directory are the owners listed in the OWNERS files of the directory and of its
+// parents, up to an OWNERS file with "set noparent", including the files referenced by "include"
+// and "file:". A path that starts with "/" is relative to the root of the git project of the
+// OWNERS file, and a path in another project, as in "include platform/build/soong:/OWNERS", is
+// resolved if the project is checked out at its name, with or without the "platform/" prefix.
+// Directories whose owners are unknown, or that anyone owns with "*", are not reported, nor is
+// //visibility:public.
+
+func init() {
+	RegisterVisibilityOwnersLintBuildComponents(InitRegistrationContext)
+}
+
+func RegisterVisibilityOwnersLintBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("visibility_owners_lint", visibilityOwnersLintSingletonFactory)
+}
+
+var PrepareForTestWithVisibilityOwnersLint = FixtureRegisterWithContext(RegisterVisibilityOwnersLintBuildComponents)
+
+type visibilityOwnersLintEntry struct {
+	Module          string   `json:"module"`
+	Dir             string   `json:"dir"`
+	Owners          []string `json:"owners"`
+	Visibility      string   `json:"visibility"`
+	PermittedDir    string   `json:"permitted_dir"`
+	PermittedOwners []string `json:"permitted_owners"`
+}
+
+// The owners of a directory, or of an OWNERS file.
+type directoryOwners struct {
+	owners   []string
+	anyone   bool
+	noparent bool
+}
+
+func (o directoryOwners) disjoint(other directoryOwners) bool {
+	if o.anyone || other.anyone || len(o.owners) == 0 || len(other.owners) == 0 {
+		return false
+	}
+	for _, owner := range o.owners {
+		if InList(owner, other.owners) {
+			return false
+		}
+	}
+	return true
+}
+
+func visibilityOwnersLintSingletonFactory() Singleton {
+	return &visibilityOwnersLintSingleton{}
+}
+
+type visibilityOwnersLintSingleton struct {
+	report WritablePath
+
+	// The owners of the OWNERS files and of the directories, by path.
+	ownersFiles map[string]directoryOwners
+	dirs        map[string]directoryOwners
+}
+
+func (s *visibilityOwnersLintSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !ctx.Config().IsEnvTrue("SOONG_VISIBILITY_OWNERS_LINT") {
+		return
+	}
+
+	s.ownersFiles = make(map[string]directoryOwners)
+	s.dirs = make(map[string]directoryOwners)
+
+	visited := make(map[qualifiedModuleName]bool)
+	report := []visibilityOwnersLintEntry{}
+
+	ctx.VisitAllModules(func(module Module) {
+		switch module.(type) {
+		case *packageModule, *visibilityGroupModule:
+			// The default_visibility of a package is checked for its modules, and the members of a
+			// visibility_group are checked for the modules that reference it.
+			return
+		}
+		qualified := createQualifiedModuleName(ctx.ModuleName(module), ctx.ModuleDir(module))
+		if visited[qualified] {
+			return
+		}
+		visited[qualified] = true
+
+		rule, ok := declaredVisibilityRules(ctx.Config(), qualified)
+		if !ok {
+			return
+		}
+		owners := s.directoryOwners(ctx, qualified.pkg)
+		for _, r := range expandVisibilityGroupRules(ctx.Config(), rule) {
+			var dir string
+			switch r := r.(type) {
+			case packageRule:
+				dir = r.pkg
+			case subpackagesRule:
+				dir = r.pkgPrefix
+			default:
+				continue
+			}
+			if dir == qualified.pkg {
+				continue
+			}
+			permittedOwners := s.directoryOwners(ctx, dir)
+			if owners.disjoint(permittedOwners) {
+				report = append(report, visibilityOwnersLintEntry{
+					Module:          qualified.name,
+					Dir:             qualified.pkg,
+					Owners:          owners.owners,
+					Visibility:      r.String(),
+					PermittedDir:    dir,
+					PermittedOwners: permittedOwners.owners,
+				})
+			}
+		}
+	})
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Dir != report[j].Dir {
+			return report[i].Dir < report[j].Dir
+		}
+		if report[i].Module != report[j].Module {
+			return report[i].Module < report[j].Module
+		}
+		return report[i].PermittedDir < report[j].PermittedDir
+	})
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the visibility owners lint: %s", err)
+		return
+	}
+	s.report = PathForOutput(ctx, "visibility_owners_lint.json")
+	WriteFileRuleVerbatim(ctx, s.report, string(data)+"\n")
+
+	ctx.Phony("visibility-owners-lint", s.report)
+}
+
+func (s *visibilityOwnersLintSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.report != nil {
+		ctx.DistForGoal("visibility-owners-lint", s.report)
+	}
+}
+
+// declaredVisibilityRules returns the visibility rules of a module if they are declared by the
+// module or by the default_visibility of its package, rather than the default public visibility.
+func declaredVisibilityRules(config Config, qualified qualifiedModuleName) (compositeRule, bool) {
+	if value, ok := moduleToVisibilityRuleMap(config).Load(qualified); ok {
+		return value.(compositeRule), true
+	}
+	if rule := packageDefaultVisibility(config, qualified); rule != nil {
+		return rule, true
+	}
+	return nil, false
+}
+
+// expandVisibilityGroupRules replaces the visibility_group rules with the rules of their members.
+func expandVisibilityGroupRules(config Config, rule compositeRule) compositeRule {
+	var ret compositeRule
+	for _, r := range rule {
+		if group, ok := r.(visibilityGroupRule); ok {
+			if value, ok := visibilityGroupRuleMap(config).Load(group.group); ok {
+				ret = append(ret, value.(compositeRule)...)
+			}
+			continue
+		}
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// directoryOwners returns the owners of a directory, inherited from its parents unless an OWNERS
+// file sets noparent.
+func (s *visibilityOwnersLintSingleton) directoryOwners(ctx SingletonContext, dir string) directoryOwners {
+	if dir == "" {
+		dir = "."
+	}
+	if owners, ok := s.dirs[dir]; ok {
+		return owners
+	}
+
+	owners := s.ownersFile(ctx, filepath.Join(dir, "OWNERS"), nil)
+	if !owners.noparent && !owners.anyone && dir != "." {
+		parent := s.directoryOwners(ctx, filepath.Dir(dir))
+		owners.anyone = parent.anyone
+		owners.owners = SortedUniqueStrings(append(CopyOf(owners.owners), parent.owners...))
+	}
+
+	s.dirs[dir] = owners
+	return owners
+}
+
+// ownersFile returns the owners listed in an OWNERS file, including the files that it includes.
+func (s *visibilityOwnersLintSingleton) ownersFile(ctx SingletonContext, path string, including []string) directoryOwners {
+	if owners, ok := s.ownersFiles[path]; ok {
+		return owners
+	}
+	if InList(path, including) {
+		// An include cycle, the owners are those of the files in the cycle.
+		return directoryOwners{}
+	}
+
+	// The OWNERS file is globbed so that adding it reruns the lint.
+	var owners directoryOwners
+	if matches, err := ctx.GlobWithDeps(path, nil); err != nil {
+		ctx.Errorf("failed to glob %s: %s", path, err)
+		return owners
+	} else if len(matches) == 0 {
+		s.ownersFiles[path] = owners
+		return owners
+	}
+	file, err := ctx.Config().fs.Open(path)
+	if err != nil {
+		ctx.Errorf("failed to read %s: %s", path, err)
+		return owners
+	}
+	defer file.Close()
+	ctx.AddNinjaFileDeps(path)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "*":
+			owners.anyone = true
+		case line == "set noparent":
+			owners.noparent = true
+		case strings.HasPrefix(line, "include "), strings.HasPrefix(line, "file:"):
+			include, ok := s.includedOwnersFile(ctx, path,
+				strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "include "), "file:")))
+			if !ok {
+				continue
+			}
+			included := s.ownersFile(ctx, include, append(including, path))
+			owners.anyone = owners.anyone || included.anyone
+			owners.owners = append(owners.owners, included.owners...)
+		case strings.Contains(line, "@") && !strings.Contains(line, " "):
+			owners.owners = append(owners.owners, line)
+		}
+	}
+	owners.owners = SortedUniqueStrings(owners.owners)
+
+	s.ownersFiles[path] = owners
+	return owners
+}
+
+// includedOwnersFile returns the path of the OWNERS file referenced by an "include" or "file:"
+// directive of an OWNERS file, "[<project>:][<branch>:]<path>", or false if the project isn't
+// checked out. The branch is ignored, the file of the checked out branch is used.
+func (s *visibilityOwnersLintSingleton) includedOwnersFile(ctx SingletonContext, ownersFile, include string) (string, bool) {
+	parts := strings.Split(include, ":")
+	project, path := "", parts[len(parts)-1]
+	if len(parts) > 1 {
+		project = parts[0]
+	}
+
+	if project != "" {
+		root, ok := projectDir(ctx, project)
+		if !ok {
+			return "", false
+		}
+		return filepath.Join(root, strings.TrimPrefix(path, "/")), true
+	}
+	if strings.HasPrefix(path, "/") {
+		return filepath.Join(projectRoot(ctx, filepath.Dir(ownersFile)), strings.TrimPrefix(path, "/")), true
+	}
+	return filepath.Join(filepath.Dir(ownersFile), path), true
+}
+
+// isProjectRoot returns true if a directory is the root of a git project.
+func isProjectRoot(ctx SingletonContext, dir string) bool {
+	exists, _, _ := ctx.Config().fs.Exists(filepath.Join(dir, ".git"))
+	return exists
+}
+
+// projectRoot returns the root of the git project that contains a directory, or the root of the
+// tree if the directory isn't in a project.
+func projectRoot(ctx SingletonContext, dir string) string {
+	for ; dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+		if isProjectRoot(ctx, dir) {
+			return dir
+		}
+	}
+	return "."
+}
+
+// projectDir returns the directory a git project is checked out to, or false if it isn't checked
+// out at its name, with or without the "platform/" prefix.
+func projectDir(ctx SingletonContext, project string) (string, bool) {
+	for _, dir := range FirstUniqueStrings([]string{project, strings.TrimPrefix(project, "platform/")}) {
+		if isProjectRoot(ctx, dir) {
+			return dir, true
+		}
+	}
+	return "", false
+}
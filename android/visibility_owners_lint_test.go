@@ -0,0 +1,145 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+func TestVisibilityOwnersLint(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithDefaults,
+		PrepareForTestWithVisibility,
+		PrepareForTestWithVisibilityGroup,
+		PrepareForTestWithVisibilityOwnersLint,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("mock_library", newMockLibraryModule)
+		}),
+		FixtureMergeEnv(map[string]string{
+			"SOONG_VISIBILITY_OWNERS_LINT": "true",
+		}),
+		MockFS{
+			"OWNERS": []byte("root@example.com\n"),
+			"top/OWNERS": []byte(`
+				set noparent
+				# The owners of top.
+				top@example.com
+				per-file Android.bp = build@example.com
+			`),
+			"top/Android.bp": []byte(`
+				visibility_group {
+					name: "friends",
+					members: ["//friend:__subpackages__"],
+				}
+				mock_library {
+					name: "libgroup",
+					visibility: [":friends"],
+				}
+				mock_library {
+					name: "libshared",
+					visibility: ["//shared", "//anyone", "//unowned"],
+				}
+				mock_library {
+					name: "libpublic",
+					visibility: ["//visibility:public"],
+				}`),
+			"friend/OWNERS":      []byte("set noparent\nfriend@example.com\n"),
+			"friend/Android.bp":  []byte(``),
+			"shared/OWNERS":      []byte("set noparent\ninclude /top/OWNERS\nshared@example.com\n"),
+			"shared/Android.bp":  []byte(``),
+			"anyone/OWNERS":      []byte("*\n"),
+			"anyone/Android.bp":  []byte(``),
+			"unowned/OWNERS":     []byte("set noparent\n"),
+			"unowned/Android.bp": []byte(``),
+			"other/OWNERS":       []byte("other@example.com\n"),
+			"other/Android.bp": []byte(`
+				package {
+					default_visibility: ["//top"],
+				}
+				mock_library {
+					name: "libother",
+				}`),
+			"external/proj/.git":          nil,
+			"external/proj/OWNERS.common": []byte("proj@example.com\n"),
+			"external/proj/lib/OWNERS":    []byte("set noparent\ninclude /OWNERS.common\n"),
+			"external/proj/lib/Android.bp": []byte(`
+				mock_library {
+					name: "libproj",
+					visibility: ["//friend", "//cross"],
+				}`),
+			"cross/OWNERS": []byte(`
+				set noparent
+				file:platform/external/proj:main:/OWNERS.common
+				include platform/missing:/OWNERS
+				cross@example.com
+			`),
+			"cross/Android.bp": []byte(``),
+		}.AddToFixture(),
+	).RunTest(t)
+
+	lint := result.SingletonForTests("visibility_owners_lint")
+	AssertStringEquals(t, "report", `[
+  {
+    "module": "libproj",
+    "dir": "external/proj/lib",
+    "owners": [
+      "proj@example.com"
+    ],
+    "visibility": "//friend",
+    "permitted_dir": "friend",
+    "permitted_owners": [
+      "friend@example.com"
+    ]
+  },
+  {
+    "module": "libother",
+    "dir": "other",
+    "owners": [
+      "other@example.com",
+      "root@example.com"
+    ],
+    "visibility": "//top",
+    "permitted_dir": "top",
+    "permitted_owners": [
+      "top@example.com"
+    ]
+  },
+  {
+    "module": "libgroup",
+    "dir": "top",
+    "owners": [
+      "top@example.com"
+    ],
+    "visibility": "//friend:__subpackages__",
+    "permitted_dir": "friend",
+    "permitted_owners": [
+      "friend@example.com"
+    ]
+  }
+]
+`, ContentFromFileRuleForTests(t, lint.Output("visibility_owners_lint.json")))
+}
+
+func TestVisibilityOwnersLintDisabled(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithVisibilityOwnersLint,
+		FixtureWithRootAndroidBp(``),
+	).RunTest(t)
+
+	if report := result.SingletonForTests("visibility_owners_lint").MaybeOutput("visibility_owners_lint.json"); report.Rule != nil {
+		t.Errorf("expected no report when the visibility-owners-lint goal isn't built")
+	}
+}
@@ -1904,6 +1904,97 @@ var visibilityTests = []struct {
 				}`),
 		},
 	},
+	{
+		name: "visibility_group",
+		fs: MockFS{
+			"top/Android.bp": []byte(`
+				visibility_group {
+					name: "friends",
+					members: ["//friend:__subpackages__", "//other:__pkg__"],
+				}
+				mock_library {
+					name: "libexample",
+					visibility: [":friends"],
+				}`),
+			"friend/nested/Android.bp": []byte(`
+				mock_library {
+					name: "libfriend",
+					deps: ["libexample"],
+				}`),
+			"other/Android.bp": []byte(`
+				mock_library {
+					name: "libother",
+					deps: ["libexample"],
+				}`),
+			"stranger/Android.bp": []byte(`
+				mock_library {
+					name: "libstranger",
+					deps: ["libexample"],
+				}`),
+		},
+		expectedErrors: []string{
+			`module "libstranger" variant "android_common": depends on //top:libexample which is not visible to this module`,
+		},
+		effectiveVisibility: map[qualifiedModuleName][]string{
+			qualifiedModuleName{pkg: "top", name: "libexample"}: {"//top:friends"},
+		},
+	},
+	{
+		name: "visibility_group in another package",
+		fs: MockFS{
+			"groups/Android.bp": []byte(`
+				visibility_group {
+					name: "friends",
+					members: ["//friend"],
+				}`),
+			"top/Android.bp": []byte(`
+				package {
+					default_visibility: ["//groups:friends"],
+				}
+				mock_library {
+					name: "libexample",
+				}`),
+			"friend/Android.bp": []byte(`
+				mock_library {
+					name: "libfriend",
+					deps: ["libexample"],
+				}`),
+			"stranger/Android.bp": []byte(`
+				mock_library {
+					name: "libstranger",
+					deps: ["libexample"],
+				}`),
+		},
+		expectedErrors: []string{
+			`module "libstranger" variant "android_common": depends on //top:libexample which is not visible to this module`,
+		},
+	},
+	{
+		name: "visibility_group: unknown group",
+		fs: MockFS{
+			"top/Android.bp": []byte(`
+				mock_library {
+					name: "libexample",
+					visibility: [":friends"],
+				}`),
+		},
+		expectedErrors: []string{`visibility: "//top:friends" is not a visibility_group`},
+	},
+	{
+		name: "visibility_group: nested group",
+		fs: MockFS{
+			"top/Android.bp": []byte(`
+				visibility_group {
+					name: "friends",
+					members: ["//friend"],
+				}
+				visibility_group {
+					name: "more_friends",
+					members: [":friends"],
+				}`),
+		},
+		expectedErrors: []string{`members: cannot reference the visibility group "//top:friends"`},
+	},
 }
 
 func TestVisibility(t *testing.T) {
@@ -1919,6 +2010,7 @@ func TestVisibility(t *testing.T) {
 				PrepareForTestWithPackageModule,
 				PrepareForTestWithPrebuilts,
 				PrepareForTestWithVisibility,
+				PrepareForTestWithVisibilityGroup,
 
 				// Additional test specific preparers.
 				FixtureRegisterWithContext(func(ctx RegistrationContext) {
@@ -0,0 +1,287 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+)
+
+// This file writes out/soong/why_installed-<product>.json, which explains for every file that
+// Soong modules install on the device which chain of modules caused it to be installed, for image
+// size and provenance audits. It is written during analysis, so `m nothing` or `m why-installed`
+// writes it.
+//
+// Soong doesn't know the PRODUCT_PACKAGES of the product, so a chain ends at a module that no other
+// Soong module installs, which is either listed in PRODUCT_PACKAGES or required by a Make module.
+
+func init() {
+	RegisterWhyInstalledBuildComponents(InitRegistrationContext)
+}
+
+func RegisterWhyInstalledBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("why_installed", whyInstalledSingletonFactory)
+}
+
+var PrepareForTestWithWhyInstalled = FixtureRegisterWithContext(RegisterWhyInstalledBuildComponents)
+
+// The reasons a module installs a file.
+const (
+	whyInstalledReasonInstalled     = "installed"
+	whyInstalledReasonInitRc        = "init_rc"
+	whyInstalledReasonVintfFragment = "vintf_fragment"
+	whyInstalledReasonApexPayload   = "apex_payload"
+)
+
+// The causes of the installation of a module.
+const (
+	// The module is not installed by another Soong module. It is listed in PRODUCT_PACKAGES, or
+	// required by a Make module.
+	whyInstalledCauseRoot = "product_packages_or_make"
+	// The module is a dependency whose files are installed with the files of another module,
+	// e.g. a shared library of a binary.
+	whyInstalledCauseInstalledWith = "installed_with"
+	// The module is listed in the required property of another module.
+	whyInstalledCauseRequiredBy = "required_by"
+	// The module is in the payload of an apex.
+	whyInstalledCauseApexPayloadOf = "apex_payload_of"
+)
+
+type whyInstalledLink struct {
+	Module  string `json:"module"`
+	Variant string `json:"variant,omitempty"`
+	Cause   string `json:"cause"`
+	// The module that caused the installation, unset for whyInstalledCauseRoot.
+	By string `json:"by,omitempty"`
+}
+
+type whyInstalledEntry struct {
+	// The path of the file relative to the partition, or to the apex for apex payloads.
+	File      string `json:"file"`
+	Partition string `json:"partition"`
+	Reason    string `json:"reason"`
+	// The chain of modules that caused the installation of the file, from the module that installs
+	// the file to the module that no other Soong module installs.
+	Chain []whyInstalledLink `json:"chain"`
+}
+
+type whyInstalledCause struct {
+	cause string
+	by    Module
+}
+
+func whyInstalledSingletonFactory() Singleton {
+	return &whyInstalledSingleton{}
+}
+
+type whyInstalledSingleton struct{}
+
+func (s *whyInstalledSingleton) GenerateBuildActions(ctx SingletonContext) {
+	// The installed device modules, and the modules of each name.
+	var modules []Module
+	byName := make(map[string][]Module)
+	apexInfos := make(map[Module]ApexInfo)
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() || module.Target().Os.Class != Device {
+			return
+		}
+		apexInfo := ctx.ModuleProvider(module, ApexInfoProvider).(ApexInfo)
+		if apexInfo.IsForPlatform() && (module.IsHideFromMake() || module.IsSkipInstall()) {
+			return
+		}
+		modules = append(modules, module)
+		byName[ctx.ModuleName(module)] = append(byName[ctx.ModuleName(module)], module)
+		apexInfos[module] = apexInfo
+	})
+
+	// The modules that caused the installation of each module.
+	causes := make(map[Module][]whyInstalledCause)
+	for _, module := range modules {
+		if apexInfo := apexInfos[module]; !apexInfo.IsForPlatform() {
+			for _, apex := range apexInfo.InApexModules {
+				for _, apexModule := range byName[apex] {
+					if _, ok := apexInfos[apexModule]; ok {
+						causes[module] = append(causes[module], whyInstalledCause{whyInstalledCauseApexPayloadOf, apexModule})
+					}
+				}
+			}
+			continue
+		}
+		for _, dep := range module.base().installDeps {
+			if _, ok := apexInfos[dep]; ok {
+				causes[dep] = append(causes[dep], whyInstalledCause{whyInstalledCauseInstalledWith, module})
+			}
+		}
+		for _, name := range module.RequiredModuleNames() {
+			for _, required := range requiredVariants(module, byName[name]) {
+				causes[required] = append(causes[required], whyInstalledCause{whyInstalledCauseRequiredBy, module})
+			}
+		}
+	}
+
+	chains := whyInstalledChains(ctx, modules, causes)
+
+	var entries []whyInstalledEntry
+	for _, module := range modules {
+		apexInfo := apexInfos[module]
+		initRc := make(map[string]bool)
+		for _, p := range module.base().initRcPaths {
+			initRc[filepath.Join("etc", "init", p.Base())] = true
+		}
+		vintfFragments := make(map[string]bool)
+		for _, p := range module.base().vintfFragmentsPaths {
+			vintfFragments[filepath.Join("etc", "vintf", "manifest", p.Base())] = true
+		}
+		for _, spec := range module.PackagingSpecs() {
+			entry := whyInstalledEntry{
+				File:      spec.RelPathInPackage(),
+				Partition: spec.Partition(),
+				Reason:    whyInstalledReasonInstalled,
+				Chain:     chains[module],
+			}
+			switch {
+			case !apexInfo.IsForPlatform():
+				entry.Partition = apexInfo.ApexVariationName
+				entry.Reason = whyInstalledReasonApexPayload
+			case initRc[entry.File]:
+				entry.Reason = whyInstalledReasonInitRc
+			case vintfFragments[entry.File]:
+				entry.Reason = whyInstalledReasonVintfFragment
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Partition != entries[j].Partition {
+			return entries[i].Partition < entries[j].Partition
+		}
+		return entries[i].File < entries[j].File
+	})
+	if entries == nil {
+		entries = []whyInstalledEntry{}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the installed file explanations: %s", err)
+		return
+	}
+
+	product := "unknown"
+	if ctx.Config().HasDeviceProduct() {
+		product = ctx.Config().DeviceProduct()
+	}
+	out := PathForOutput(ctx, "why_installed-"+product+".json")
+	// The file is written during analysis rather than by a rule so that its contents aren't part of
+	// the ninja file.
+	if err := WriteFileToOutputDir(out, append(data, '\n'), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", out, err)
+		return
+	}
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: out,
+	})
+
+	ctx.Phony("why-installed", out)
+}
+
+// requiredVariants returns the variants of a required module that are installed for the module
+// that requires it: the variants for the same image if there are any, and among them the variants
+// for the same arch if there are any.
+func requiredVariants(module Module, variants []Module) []Module {
+	var sameImage []Module
+	for _, v := range variants {
+		if requiredImage(v) == requiredImage(module) {
+			sameImage = append(sameImage, v)
+		}
+	}
+	if len(sameImage) > 0 {
+		variants = sameImage
+	}
+
+	var sameArch []Module
+	for _, v := range variants {
+		if v.Target().Arch.ArchType == module.Target().Arch.ArchType {
+			sameArch = append(sameArch, v)
+		}
+	}
+	if len(sameArch) > 0 {
+		return sameArch
+	}
+	return variants
+}
+
+// whyInstalledChains returns a shortest chain of causes of the installation of every module, from
+// the module to a module without causes. Modules that are only caused by cycles of modules are
+// treated like modules without causes.
+func whyInstalledChains(ctx SingletonContext, modules []Module, causes map[Module][]whyInstalledCause) map[Module][]whyInstalledLink {
+	// Search from the modules without causes to the modules they caused, so that every module is
+	// reached by a shortest chain.
+	caused := make(map[Module][]whyInstalledCause)
+	for _, module := range modules {
+		for _, c := range causes[module] {
+			caused[c.by] = append(caused[c.by], whyInstalledCause{c.cause, module})
+		}
+	}
+
+	link := func(module Module, c whyInstalledCause) whyInstalledLink {
+		l := whyInstalledLink{
+			Module:  ctx.ModuleName(module),
+			Variant: ctx.ModuleSubDir(module),
+			Cause:   c.cause,
+		}
+		if c.by != nil {
+			l.By = ctx.ModuleName(c.by)
+		}
+		return l
+	}
+
+	chains := make(map[Module][]whyInstalledLink)
+	search := func(roots []Module) {
+		queue := roots
+		for _, root := range roots {
+			chains[root] = []whyInstalledLink{link(root, whyInstalledCause{cause: whyInstalledCauseRoot})}
+		}
+		for len(queue) > 0 {
+			module := queue[0]
+			queue = queue[1:]
+			for _, c := range caused[module] {
+				if _, ok := chains[c.by]; ok {
+					continue
+				}
+				chain := []whyInstalledLink{link(c.by, whyInstalledCause{c.cause, module})}
+				chains[c.by] = append(chain, chains[module]...)
+				queue = append(queue, c.by)
+			}
+		}
+	}
+
+	var roots []Module
+	for _, module := range modules {
+		if len(causes[module]) == 0 {
+			roots = append(roots, module)
+		}
+	}
+	search(roots)
+	for _, module := range modules {
+		if _, ok := chains[module]; !ok {
+			search([]Module{module})
+		}
+	}
+	return chains
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+func TestWhyInstalled(t *testing.T) {
+	bp := `
+		component {
+			name: "foo",
+			deps: ["libbar"],
+			required: ["baz"],
+		}
+
+		component {
+			name: "libbar",
+		}
+
+		component {
+			name: "baz",
+			deps: ["libbar"],
+		}
+
+		component {
+			name: "not_installed",
+			skip_install: true,
+		}
+	`
+
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithWhyInstalled,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("component", componentTestModuleFactory)
+		}),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.DeviceProduct = proptools.StringPtr("test_product")
+		}),
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), "why_installed-test_product.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []whyInstalledEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.File, "lib64/") {
+			continue
+		}
+		var chain []string
+		for _, l := range e.Chain {
+			if l.By != "" {
+				chain = append(chain, fmt.Sprintf("%s %s %s", l.Module, l.Cause, l.By))
+			} else {
+				chain = append(chain, fmt.Sprintf("%s %s", l.Module, l.Cause))
+			}
+		}
+		got = append(got, fmt.Sprintf("%s/%s (%s): %s", e.Partition, e.File, e.Reason, strings.Join(chain, ", ")))
+	}
+
+	expected := []string{
+		"system/lib64/baz (installed): baz required_by foo, foo product_packages_or_make",
+		"system/lib64/foo (installed): foo product_packages_or_make",
+		"system/lib64/libbar (installed): libbar installed_with foo, foo product_packages_or_make",
+	}
+	AssertArrayString(t, "installed files", expected, got)
+}
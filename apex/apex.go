@@ -17,6 +17,7 @@
 package apex
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"regexp"
@@ -173,6 +174,12 @@ type apexBundleProperties struct {
 	// is 'image'.
 	Payload_type *string
 
+	// Whether to install the flattened variant of this APEX to the device instead of the image
+	// APEX, regardless of whether the device uses flattened APEXes (TARGET_FLATTEN_APEX). The
+	// image APEX is still built as <name>.apex so that it can be dist'ed. Only applies when
+	// payload_type is 'image' or 'both'. Default: false.
+	Install_flattened *bool
+
 	// The type of filesystem to use when the payload_type is 'image'. Either 'ext4', 'f2fs'
 	// or 'erofs'. Default 'ext4'.
 	Payload_fs_type *string
@@ -217,7 +224,7 @@ type apexBundleProperties struct {
 	HideFromMake bool `blueprint:"mutated"`
 
 	// Internal package method for this APEX. When payload_type is image, this can be either
-	// imageApex or flattenedApex depending on Config.FlattenApex(). When payload_type is zip,
+	// imageApex or flattenedApex depending on installFlattened(). When payload_type is zip,
 	// this becomes zipApex.
 	ApexType apexPackaging `blueprint:"mutated"`
 }
@@ -492,6 +499,13 @@ type apexBundle struct {
 
 	// Collect the module directory for IDE info in java/jdeps.go.
 	modulePaths []string
+
+	// Native libraries provided by and required from outside of this APEX, as written to
+	// apex_manifest.pb, and the stub libraries the required ones come from. Used by the
+	// apex_deps_graph singleton.
+	provideNativeLibs []string
+	requireNativeLibs []string
+	stubLibs          []string
 }
 
 // apexFileClass represents a type of file that can be included in APEX.
@@ -1442,7 +1456,7 @@ func apexFlattenedMutator(mctx android.BottomUpMutatorContext) {
 			case flattenedApexType:
 				modules[i].(*apexBundle).properties.ApexType = flattenedApex
 				// See the comment above for why system_ext.
-				if !mctx.Config().FlattenApex() && ab.Platform() {
+				if !ab.installFlattened(mctx.Config()) && ab.Platform() {
 					modules[i].(*apexBundle).MakeAsSystemExt()
 				}
 			}
@@ -2074,7 +2088,16 @@ func (a *apexBundle) setCompression(ctx android.ModuleContext) {
 	} else if a.testOnlyShouldForceCompression() {
 		a.isCompressed = true
 	} else {
-		a.isCompressed = ctx.Config().ApexCompressionEnabled() && a.isCompressable()
+		a.isCompressed = ctx.Config().ApexCompressionEnabled() && a.isCompressable(ctx.Config())
+	}
+
+	// Updatable APEXes are activated from /data after decompression by apexd. Compressing them
+	// for a device that can't decompress them would leave the device without the module.
+	if a.isCompressed && a.Updatable() && !ctx.Config().ApexDecompressionSupported() {
+		ctx.PropertyErrorf("compressible", "updatable APEX %q can't be compressed because the "+
+			"device doesn't support APEX decompression (BOARD_APEX_DECOMPRESSION_SUPPORTED is false). "+
+			"Set compressible: false or add %q to PRODUCT_COMPRESSED_APEX_OVERRIDES.",
+			a.Name(), a.Name()+":false")
 	}
 }
 
@@ -2120,9 +2143,18 @@ func (a *apexBundle) setPayloadFsType(ctx android.ModuleContext) {
 	}
 }
 
+// installFlattened returns true if the flattened variant of this APEX, rather than the image
+// variant, is installed to the device. This is the case for all APEXes on devices that use
+// flattened APEXes, and for the APEXes listed in FlattenApexModules or that set
+// install_flattened.
+func (a *apexBundle) installFlattened(config android.Config) bool {
+	return config.FlattenApex() || config.FlattenApexModule(a.Name()) ||
+		proptools.Bool(a.properties.Install_flattened)
+}
+
 func (a *apexBundle) setApexTypeAndSuffix(ctx android.ModuleContext) {
 	// Set suffix and primaryApexType depending on the ApexType
-	buildFlattenedAsDefault := ctx.Config().FlattenApex()
+	buildFlattenedAsDefault := a.installFlattened(ctx.Config())
 	switch a.properties.ApexType {
 	case imageApex:
 		if buildFlattenedAsDefault {
@@ -2152,8 +2184,14 @@ func (a *apexBundle) setApexTypeAndSuffix(ctx android.ModuleContext) {
 	}
 }
 
-func (a apexBundle) isCompressable() bool {
-	return proptools.BoolDefault(a.overridableProperties.Compressible, false) && !a.testApex
+// isCompressable returns true if this APEX may be compressed. The compressible property can be
+// overridden per module by the product via PRODUCT_COMPRESSED_APEX_OVERRIDES.
+func (a apexBundle) isCompressable(config android.Config) bool {
+	compressible := proptools.BoolDefault(a.overridableProperties.Compressible, false)
+	if override, overridden := config.ApexCompressibleOverride(a.Name()); overridden {
+		compressible = override
+	}
+	return compressible && !a.testApex
 }
 
 func (a *apexBundle) commonBuildActions(ctx android.ModuleContext) bool {
@@ -2177,6 +2215,9 @@ type visitorContext struct {
 	provideNativeLibs []string
 	requireNativeLibs []string
 
+	// names of the stub libraries that requireNativeLibs come from
+	stubLibs []string
+
 	handleSpecialLibs bool
 
 	// if true, raise error on duplicate apexFile
@@ -2310,7 +2351,15 @@ func (a *apexBundle) depVisitor(vctx *visitorContext, ctx android.ModuleContext,
 		case androidAppTag:
 			switch ap := child.(type) {
 			case *java.AndroidApp:
-				vctx.filesInfo = append(vctx.filesInfo, apexFileForAndroidApp(ctx, ap))
+				af := apexFileForAndroidApp(ctx, ap)
+				vctx.filesInfo = append(vctx.filesInfo, af)
+				// The feature splits are installed alongside the APK of the app.
+				for _, split := range ap.FeatureSplitOutputFiles() {
+					splitFile := newApexFile(ctx, split, strings.TrimSuffix(split.Base(), ".apk"), af.installDir, app, ap)
+					splitFile.certificate = af.certificate
+					splitFile.overriddenPackageName = af.overriddenPackageName
+					vctx.filesInfo = append(vctx.filesInfo, splitFile)
+				}
 				return true // track transitive dependencies
 			case *java.AndroidAppImport:
 				vctx.filesInfo = append(vctx.filesInfo, apexFileForAndroidApp(ctx, ap))
@@ -2449,6 +2498,7 @@ func (a *apexBundle) depVisitor(vctx *visitorContext, ctx android.ModuleContext,
 					}
 				}
 				vctx.requireNativeLibs = append(vctx.requireNativeLibs, af.stem())
+				vctx.stubLibs = append(vctx.stubLibs, depName)
 				// Don't track further
 				return false
 			}
@@ -2614,6 +2664,7 @@ func (a *apexBundle) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	////////////////////////////////////////////////////////////////////////////////////////////
 	// 4) generate the build rules to create the APEX. This is done in builder.go.
 	a.buildManifest(ctx, vctx.provideNativeLibs, vctx.requireNativeLibs)
+	a.stubLibs = android.SortedUniqueStrings(vctx.stubLibs)
 	if a.properties.ApexType == flattenedApex {
 		a.buildFlattenedApex(ctx)
 	} else {
@@ -2640,6 +2691,29 @@ func (a *apexBundle) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		})
 		a.filesInfo = append(a.filesInfo, newApexFile(ctx, copiedPubkey, "apex_pubkey", ".", etc, nil))
 	}
+
+	a.setSbomContainerInfo(ctx)
+}
+
+// setSbomContainerInfo provides the files of the APEX for its SBOM, along with the modules that
+// they come from.
+func (a *apexBundle) setSbomContainerInfo(ctx android.ModuleContext) {
+	var files []android.SbomContainerFile
+	for _, fi := range a.filesInfo {
+		if !fi.ok() {
+			continue
+		}
+		var module string
+		if fi.module != nil {
+			module = ctx.OtherModuleName(fi.module)
+		}
+		files = append(files, android.SbomContainerFile{
+			Path:   fi.path(),
+			Src:    fi.builtFile,
+			Module: module,
+		})
+	}
+	ctx.SetProvider(android.SbomContainerInfoProvider, android.SbomContainerInfo{Files: files})
 }
 
 // apexBootclasspathFragmentFiles returns the list of apexFile structures defining the files that
@@ -3076,6 +3150,10 @@ func (a *apexBundle) checkApexAvailability(ctx android.ModuleContext) {
 		return
 	}
 
+	suggest := ctx.Config().IsEnvTrue("APEX_AVAILABLE_SUGGEST")
+	var suggestions []apexAvailableSuggestion
+	seenSuggestions := make(map[string]bool)
+
 	a.WalkPayloadDeps(ctx, func(ctx android.ModuleContext, from blueprint.Module, to android.ApexModule, externalDep bool) bool {
 		// As soon as the dependency graph crosses the APEX boundary, don't go further.
 		if externalDep {
@@ -3103,9 +3181,52 @@ func (a *apexBundle) checkApexAvailability(ctx android.ModuleContext) {
 			"\n\nDependency path:%s\n\n"+
 			"Consider adding %q to 'apex_available' property of %q",
 			fromName, toName, ctx.GetPathString(true), apexName, toName)
+		if suggest && !seenSuggestions[toName] {
+			seenSuggestions[toName] = true
+			var path []string
+			for _, m := range ctx.GetWalkPath() {
+				path = append(path, ctx.OtherModuleName(m))
+			}
+			suggestions = append(suggestions, apexAvailableSuggestion{
+				Module:         toName,
+				Apex:           apexName,
+				DependencyPath: path,
+			})
+		}
 		// Visit this module's dependencies to check and report any issues with their availability.
 		return true
 	})
+
+	if len(suggestions) > 0 {
+		writeApexAvailableSuggestions(ctx, suggestions)
+	}
+}
+
+// apexAvailableSuggestion is an entry of the file written when APEX_AVAILABLE_SUGGEST=true for
+// a module that needs to list the APEX in its apex_available property.
+type apexAvailableSuggestion struct {
+	Module string `json:"module"`
+	Apex   string `json:"apex"`
+	// The chain of modules from the APEX to the module that caused the module to be included.
+	DependencyPath []string `json:"dependency_path"`
+}
+
+// writeApexAvailableSuggestions writes the suggested apex_available additions directly during
+// analysis, as no build rules are run when apex_available violations are reported.
+func writeApexAvailableSuggestions(ctx android.ModuleContext, suggestions []apexAvailableSuggestion) {
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Module < suggestions[j].Module })
+	content, err := json.MarshalIndent(suggestions, "", "  ")
+	if err != nil {
+		ctx.ModuleErrorf("failed to marshal apex_available suggestions: %s", err)
+		return
+	}
+	path := android.PathForModuleOut(ctx, "apex_available_suggestions.json")
+	if err := android.WriteFileToOutputDir(path, content, 0666); err != nil {
+		ctx.ModuleErrorf("failed to write apex_available suggestions: %s", err)
+		return
+	}
+	ctx.ModuleErrorf("suggested apex_available additions for %d module(s) were written to %s",
+		len(suggestions), path)
 }
 
 // checkStaticExecutable ensures that executables in an APEX are not static.
@@ -17,6 +17,11 @@
 package apex
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/google/blueprint"
 
 	"android/soong/android"
@@ -28,6 +33,10 @@ func init() {
 
 type apexDepsInfoSingleton struct {
 	allowedApexDepsInfoCheckResult android.OutputPath
+
+	// Graph of the native library dependencies between APEXes and the platform.
+	apexDepsGraphJson android.OutputPath
+	apexDepsGraphDot  android.OutputPath
 }
 
 func apexDepsInfoSingletonFactory() android.Singleton {
@@ -119,9 +128,99 @@ func (s *apexDepsInfoSingleton) GenerateBuildActions(ctx android.SingletonContex
 	}
 
 	ctx.Phony("apex-allowed-deps-check", s.allowedApexDepsInfoCheckResult)
+
+	s.buildApexDepsGraph(ctx)
+}
+
+// apexDepsGraphNode describes the native library dependencies of a single APEX in the
+// apex-deps-graph.json file.
+type apexDepsGraphNode struct {
+	Name string `json:"name"`
+	// Native libraries provided by this APEX to the outside (provideNativeLibs).
+	ProvideNativeLibs []string `json:"provide_native_libs"`
+	// Native libraries this APEX requires from the outside (requireNativeLibs).
+	RequireNativeLibs []string `json:"require_native_libs"`
+	// Stub libraries that the required native libraries are linked against.
+	StubLibs []string `json:"stub_libs"`
+	// Other APEXes providing some of the required native libraries, keyed by APEX name.
+	ApexDeps map[string][]string `json:"apex_deps"`
+	// Required native libraries that are not provided by any APEX, i.e. come from the platform.
+	PlatformLibs []string `json:"platform_libs"`
+}
+
+// buildApexDepsGraph writes a JSON and a Graphviz description of which APEXes depend on which
+// other APEXes and platform libraries through the native libraries listed in their
+// apex_manifest.pb, so that cross-APEX coupling can be audited.
+func (s *apexDepsInfoSingleton) buildApexDepsGraph(ctx android.SingletonContext) {
+	var apexes []*apexBundle
+	seen := make(map[string]bool)
+	ctx.VisitAllModules(func(module android.Module) {
+		if a, ok := module.(*apexBundle); ok && a.primaryApexType && !a.testApex && !seen[a.Name()] {
+			seen[a.Name()] = true
+			apexes = append(apexes, a)
+		}
+	})
+	sort.Slice(apexes, func(i, j int) bool { return apexes[i].Name() < apexes[j].Name() })
+
+	providers := make(map[string][]string)
+	for _, a := range apexes {
+		for _, lib := range a.provideNativeLibs {
+			providers[lib] = append(providers[lib], a.Name())
+		}
+	}
+
+	nodes := []apexDepsGraphNode{}
+	var dot strings.Builder
+	dot.WriteString("digraph apex_deps {\n")
+	dot.WriteString("  \"platform\" [shape=box];\n")
+	for _, a := range apexes {
+		node := apexDepsGraphNode{
+			Name:              a.Name(),
+			ProvideNativeLibs: append([]string{}, a.provideNativeLibs...),
+			RequireNativeLibs: append([]string{}, a.requireNativeLibs...),
+			StubLibs:          append([]string{}, a.stubLibs...),
+			ApexDeps:          make(map[string][]string),
+			PlatformLibs:      []string{},
+		}
+		for _, lib := range a.requireNativeLibs {
+			if _, from := android.RemoveFromList(a.Name(), providers[lib]); len(from) > 0 {
+				for _, other := range from {
+					node.ApexDeps[other] = append(node.ApexDeps[other], lib)
+				}
+			} else {
+				node.PlatformLibs = append(node.PlatformLibs, lib)
+			}
+		}
+		nodes = append(nodes, node)
+
+		fmt.Fprintf(&dot, "  %q;\n", a.Name())
+		for _, other := range android.SortedKeys(node.ApexDeps) {
+			fmt.Fprintf(&dot, "  %q -> %q [label=%q];\n", a.Name(), other, strings.Join(node.ApexDeps[other], "\\n"))
+		}
+		if len(node.PlatformLibs) > 0 {
+			fmt.Fprintf(&dot, "  %q -> \"platform\" [label=%q];\n", a.Name(), strings.Join(node.PlatformLibs, "\\n"))
+		}
+	}
+	dot.WriteString("}\n")
+
+	content, err := json.MarshalIndent(struct {
+		Apexes []apexDepsGraphNode `json:"apexes"`
+	}{nodes}, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal apex deps graph: %s", err)
+		return
+	}
+
+	s.apexDepsGraphJson = android.PathForOutput(ctx, "apex", "depsinfo", "apex-deps-graph.json")
+	s.apexDepsGraphDot = android.PathForOutput(ctx, "apex", "depsinfo", "apex-deps-graph.dot")
+	android.WriteFileRuleVerbatim(ctx, s.apexDepsGraphJson, string(content))
+	android.WriteFileRuleVerbatim(ctx, s.apexDepsGraphDot, dot.String())
+	ctx.Phony("apex-deps-graph", s.apexDepsGraphJson, s.apexDepsGraphDot)
 }
 
 func (s *apexDepsInfoSingleton) MakeVars(ctx android.MakeVarsContext) {
 	// Export check result to Make. The path is added to droidcore.
 	ctx.Strict("APEX_ALLOWED_DEPS_CHECK", s.allowedApexDepsInfoCheckResult.String())
+
+	ctx.DistForGoal("apex-deps-graph", s.apexDepsGraphJson, s.apexDepsGraphDot)
 }
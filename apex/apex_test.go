@@ -15,7 +15,9 @@
 package apex
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"reflect"
@@ -35,6 +37,7 @@ import (
 	prebuilt_etc "android/soong/etc"
 	"android/soong/filesystem"
 	"android/soong/java"
+	"android/soong/linkerconfig"
 	"android/soong/rust"
 	"android/soong/sh"
 )
@@ -534,6 +537,10 @@ func TestBasicApex(t *testing.T) {
 	ensureContains(t, optFlags, "--pubkey vendor/foo/devkeys/testkey.avbpubkey")
 	// Ensure that the NOTICE output is being packaged as an asset.
 	ensureContains(t, optFlags, "--assets_dir out/soong/.intermediates/myapex/android_common_myapex_image/NOTICE")
+	// Ensure that the NOTICE is generated from the license metadata of the payload.
+	noticeInputs := ctx.ModuleForTests("myapex", "android_common_myapex_image").Output("NOTICE.html.gz").Implicits.Strings()
+	ensureListContains(t, noticeInputs, "out/soong/.intermediates/mylib/android_arm64_armv8-a_shared_apex10000/meta_lic")
+	ensureListNotContains(t, noticeInputs, "out/soong/.intermediates/myapex/android_common_myapex_image/meta_lic")
 
 	copyCmds := apexRule.Args["copy_commands"]
 
@@ -986,9 +993,9 @@ func TestApexWithStubs(t *testing.T) {
 	// ensureNotContains(t, mylib2Cflags, "-include ")
 
 	// Ensure that genstub for platform-provided lib is invoked with --systemapi
-	ensureContains(t, ctx.ModuleForTests("mylib2", "android_arm64_armv8-a_shared_3").Rule("genStubSrc").Args["flags"], "--systemapi")
+	ensureContains(t, ctx.ModuleForTests("mylib2", "android_arm64_armv8-a_shared_current").Rule("genStubSrcs").Args["flags"], "--systemapi")
 	// Ensure that genstub for apex-provided lib is invoked with --apex
-	ensureContains(t, ctx.ModuleForTests("mylib3", "android_arm64_armv8-a_shared_12").Rule("genStubSrc").Args["flags"], "--apex")
+	ensureContains(t, ctx.ModuleForTests("mylib3", "android_arm64_armv8-a_shared_current").Rule("genStubSrcs").Args["flags"], "--apex")
 
 	ensureExactContents(t, ctx, "myapex", "android_common_myapex_image", []string{
 		"lib64/mylib.so",
@@ -1173,7 +1180,7 @@ func TestApexWithStubsWithMinSdkVersion(t *testing.T) {
 	ensureNotContains(t, mylib2Cflags, "-include ")
 
 	// Ensure that genstub is invoked with --systemapi
-	ensureContains(t, ctx.ModuleForTests("mylib2", "android_arm64_armv8-a_shared_29").Rule("genStubSrc").Args["flags"], "--systemapi")
+	ensureContains(t, ctx.ModuleForTests("mylib2", "android_arm64_armv8-a_shared_current").Rule("genStubSrcs").Args["flags"], "--systemapi")
 
 	ensureExactContents(t, ctx, "myapex", "android_common_myapex_image", []string{
 		"lib64/mylib.so",
@@ -1412,6 +1419,271 @@ func TestApexWithRuntimeLibsDependency(t *testing.T) {
 
 }
 
+func TestApexDepsGraph(t *testing.T) {
+	ctx := testApex(t, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			native_shared_libs: ["mylib"],
+			updatable: false,
+		}
+
+		apex {
+			name: "otherapex",
+			key: "myapex.key",
+			native_shared_libs: ["libfoo"],
+			updatable: false,
+		}
+
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+
+		cc_library {
+			name: "mylib",
+			srcs: ["mylib.cpp"],
+			shared_libs: ["libfoo", "libbar"],
+			system_shared_libs: [],
+			stl: "none",
+			apex_available: [ "myapex" ],
+		}
+
+		cc_library {
+			name: "libfoo",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			stubs: {
+				versions: ["10", "20", "30"],
+			},
+			apex_available: [ "otherapex" ],
+		}
+
+		cc_library {
+			name: "libbar",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			stubs: {
+				versions: ["10", "20", "30"],
+			},
+		}
+	`, android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+		ctx.RegisterSingletonType("apex_depsinfo_singleton", apexDepsInfoSingletonFactory)
+	}))
+
+	singleton := ctx.SingletonForTests("apex_depsinfo_singleton")
+	graphJson := android.ContentFromFileRuleForTests(t, singleton.Output("apex/depsinfo/apex-deps-graph.json"))
+	ensureContains(t, graphJson, `"name": "myapex"`)
+	ensureMatches(t, graphJson, `"apex_deps": \{\s*"otherapex": \[\s*"libfoo.so"\s*\]\s*\}`)
+	ensureMatches(t, graphJson, `"platform_libs": \[\s*"libbar.so"\s*\]`)
+
+	graphDot := android.ContentFromFileRuleForTests(t, singleton.Output("apex/depsinfo/apex-deps-graph.dot"))
+	ensureContains(t, graphDot, `"myapex" -> "otherapex" [label="libfoo.so"];`)
+	ensureContains(t, graphDot, `"myapex" -> "platform" [label="libbar.so"];`)
+}
+
+func TestApexerRBE(t *testing.T) {
+	ctx := testApex(t, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			updatable: false,
+		}
+
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+	`,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.UseRBE = proptools.BoolPtr(true)
+		}),
+		android.FixtureMergeEnv(map[string]string{
+			"RBE_APEXER": "true",
+		}))
+
+	apexRule := ctx.ModuleForTests("myapex", "android_common_myapex_image").Rule("apexRuleRE")
+	ensureContains(t, apexRule.RuleParams.Command, "${android.RBEWrapper}")
+	ensureContains(t, apexRule.RuleParams.Command, "--toolchain_inputs=${apexer},")
+	ensureContains(t, apexRule.RuleParams.Command, ",${hostLib64Dir} ")
+	ensureContains(t, apexRule.Args["implicits"], "out/soong/.intermediates/myapex/android_common_myapex_image/apex_manifest.pb")
+}
+
+func TestLinkerConfigInputs(t *testing.T) {
+	ctx := testApex(t, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			native_shared_libs: ["mylib"],
+			updatable: false,
+		}
+
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+
+		cc_library {
+			name: "mylib",
+			srcs: ["mylib.cpp"],
+			shared_libs: ["libbar"],
+			system_shared_libs: [],
+			stl: "none",
+			apex_available: [ "myapex" ],
+		}
+
+		cc_library {
+			name: "libbar",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			stubs: {
+				versions: ["10", "20", "30"],
+			},
+		}
+
+		linker_config {
+			name: "system_linker_config",
+			src: "linker.config.json",
+			include_generated_libs: true,
+		}
+	`,
+		withFiles(android.MockFS{
+			"linker.config.json": nil,
+		}),
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterModuleType("linker_config", linkerconfig.LinkerConfigFactory)
+			ctx.RegisterSingletonType("linker_config_inputs", linkerConfigInputsSingletonFactory)
+		}),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.MakeInstalledSharedLibs = map[string][]string{"system": {"libmake.so"}}
+		}))
+
+	singleton := ctx.SingletonForTests("linker_config_inputs")
+	systemJson := android.ContentFromFileRuleForTests(t, singleton.Output("linker_config/system.json"))
+	ensureMatches(t, systemJson, `"provideLibs": \[\s*"libbar.so"\s*\]`)
+	apexJson := android.ContentFromFileRuleForTests(t, singleton.Output("linker_config/apex/myapex.json"))
+	ensureMatches(t, apexJson, `"requireLibs": \[\s*"libbar.so"\s*\]`)
+
+	check := singleton.Output("linker_config/check/system_linker_config.stamp")
+	ensureContains(t, check.RuleParams.Command, "conv_linker_config check -s linker.config.json --generated out/soong/linker_config/system.json")
+	ensureContains(t, check.RuleParams.Command, "--make-installed libmake.so")
+
+	// The generated inputs of the partition are added to the linker.config.pb of the configuration.
+	conv := ctx.ModuleForTests("system_linker_config", "android_arm64_armv8-a").Rule("conv_linker_config")
+	ensureContains(t, conv.RuleParams.Command, "conv_linker_config proto -s linker.config.json:out/soong/linker_config/system.json")
+}
+
+func TestSharedLibClosure(t *testing.T) {
+	ctx := testApex(t, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			native_shared_libs: ["libstubs"],
+			updatable: false,
+		}
+
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+
+		cc_binary {
+			name: "mybin",
+			srcs: ["mylib.cpp"],
+			shared_libs: ["libstubs", "libsystem", "libsystem_ext", "libuninstallable"],
+			system_shared_libs: [],
+			stl: "none",
+		}
+
+		cc_binary {
+			name: "mybin_system_ext",
+			srcs: ["mylib.cpp"],
+			shared_libs: ["libsystem", "libsystem_ext"],
+			system_shared_libs: [],
+			stl: "none",
+			system_ext_specific: true,
+		}
+
+		cc_library {
+			name: "libstubs",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			stubs: {
+				versions: ["10"],
+			},
+			apex_available: ["myapex"],
+		}
+
+		cc_library {
+			name: "libsystem",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+		}
+
+		cc_library {
+			name: "libsystem_ext",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			system_ext_specific: true,
+		}
+
+		cc_library {
+			name: "libuninstallable",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			installable: false,
+		}
+
+		cc_binary {
+			name: "mybin_vendor",
+			srcs: ["mylib.cpp"],
+			shared_libs: ["libodm"],
+			system_shared_libs: [],
+			stl: "none",
+			vendor: true,
+		}
+
+		cc_library {
+			name: "libodm",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			device_specific: true,
+		}
+	`,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterSingletonType("shared_lib_closure", sharedLibClosureSingletonFactory)
+		}))
+
+	singleton := ctx.SingletonForTests("shared_lib_closure")
+	android.AssertStringEquals(t, "shared_lib_closure.json", `[
+  {
+    "module": "mybin",
+    "partition": "system",
+    "dependency": "libsystem_ext",
+    "library": "libsystem_ext.so",
+    "dependency_partition": "system_ext",
+    "problem": "mispartitioned"
+  }
+]
+`, android.ContentFromFileRuleForTests(t, singleton.Output("shared_lib_closure.json")))
+
+	check := singleton.Output("shared_lib_closure.stamp")
+	android.AssertStringDoesContain(t, "error", check.Args["error"],
+		"mybin (system) -> libsystem_ext: libsystem_ext.so is installed to system_ext")
+}
+
 var prepareForTestOfRuntimeApexWithHwasan = android.GroupFixturePreparers(
 	cc.PrepareForTestWithCcBuildComponents,
 	PrepareForTestWithApexBuildComponents,
@@ -5083,6 +5355,53 @@ func TestPrebuiltOverrides(t *testing.T) {
 	android.AssertStringEquals(t, "Invalid args", "/system/apex/myapex.prebuilt.apex", rule.Args["install_path"])
 }
 
+func TestPrebuiltAbiCheckedNativeLibs(t *testing.T) {
+	ctx := testApex(t, `
+		prebuilt_apex {
+			name: "myapex",
+			arch: {
+				arm64: {
+					src: "myapex-arm64.apex",
+				},
+				arm: {
+					src: "myapex-arm.apex",
+				},
+			},
+			abi_checked_native_libs: ["libfoo", "libbar"],
+			abi_check_baseline_dir: "abi",
+		}
+
+		cc_library {
+			name: "libfoo",
+			srcs: ["mylib.cpp"],
+			stubs: {
+				versions: ["1"],
+			},
+			apex_available: ["myapex"],
+		}
+	`, android.FixtureAddFile("abi/arm64/libfoo.so.abidiff", nil))
+
+	deapexer := ctx.ModuleForTests("myapex.deapexer", "android_common")
+	android.AssertStringListContains(t, "extracted files", deapexer.Rule("deapexer").AllOutputs(),
+		"out/soong/.intermediates/myapex.deapexer/android_common/deapexer/lib64/libfoo.so")
+
+	abiDiff := deapexer.Output("abi_check/arm64/libfoo.so.abidiff")
+	android.AssertStringEquals(t, "baseline", "abi/arm64/libfoo.so.abidiff", abiDiff.Args["baseline"])
+	android.AssertStringEquals(t, "reference dump",
+		"out/soong/.intermediates/libfoo/android_arm64_armv8-a_shared/libfoo.so.lsdump", abiDiff.Args["referenceDump"])
+	deapexer.Output("abi_check/arm64/libfoo.so.lsdump")
+
+	// libbar does not exist so it is not checked.
+	if deapexer.MaybeOutput("abi_check/arm64/libbar.so.abidiff").Rule != nil {
+		t.Errorf("unexpected ABI check for libbar")
+	}
+
+	rule := ctx.ModuleForTests("myapex", "android_common_myapex").Rule("android/soong/android.Cp")
+	android.AssertPathsRelativeToTopEquals(t, "validations",
+		[]string{"out/soong/.intermediates/myapex.deapexer/android_common/abi_check/arm64/libfoo.so.abidiff"},
+		rule.Validations)
+}
+
 func TestPrebuiltApexName(t *testing.T) {
 	testApex(t, `
 		prebuilt_apex {
@@ -6057,6 +6376,52 @@ func TestInstallExtraFlattenedApexes(t *testing.T) {
 	ensureContains(t, androidMk, "LOCAL_REQUIRED_MODULES := apex_manifest.pb.myapex apex_pubkey.myapex myapex.flattened\n")
 }
 
+func TestInstallFlattenedApex(t *testing.T) {
+	bp := `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			updatable: false,
+			install_flattened: true,
+		}
+		apex {
+			name: "otherapex",
+			key: "myapex.key",
+			updatable: false,
+		}
+		apex {
+			name: "productflattenedapex",
+			key: "myapex.key",
+			updatable: false,
+		}
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+	`
+	ctx := testApex(t, bp,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.FlattenApexModules = []string{"productflattenedapex"}
+		}),
+	)
+
+	for _, name := range []string{"myapex", "productflattenedapex"} {
+		image := ctx.ModuleForTests(name, "android_common_"+name+"_image").Module().(*apexBundle)
+		flattened := ctx.ModuleForTests(name, "android_common_"+name+"_flattened").Module().(*apexBundle)
+		android.AssertBoolEquals(t, name+" flattened is primary", true, flattened.primaryApexType)
+		android.AssertBoolEquals(t, name+" image is not primary", false, image.primaryApexType)
+		android.AssertStringEquals(t, name+" image suffix", imageApexSuffix, image.suffix)
+		// The image APEX is still built.
+		ensureContains(t, image.outputFile.String(), name+".apex")
+		// The flattened APEX is installed to system rather than system_ext.
+		android.AssertBoolEquals(t, name+" flattened is system_ext specific", false, flattened.SystemExtSpecific())
+	}
+
+	other := ctx.ModuleForTests("otherapex", "android_common_otherapex_image").Module().(*apexBundle)
+	android.AssertBoolEquals(t, "otherapex image is primary", true, other.primaryApexType)
+}
+
 func TestErrorsIfDepsAreNotEnabled(t *testing.T) {
 	testApexError(t, `module "myapex" .* depends on disabled module "libfoo"`, `
 		apex {
@@ -6241,6 +6606,43 @@ func TestApexWithAppImportBuildId(t *testing.T) {
 	}
 }
 
+func TestApexWithAppFeatures(t *testing.T) {
+	ctx := testApex(t, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			apps: ["AppFoo"],
+			updatable: false,
+		}
+
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+
+		android_app {
+			name: "AppFoo",
+			srcs: ["foo/bar/MyClass.java"],
+			sdk_version: "current",
+			system_modules: "none",
+			features: [":AppFooCamera"],
+			apex_available: [ "myapex" ],
+		}
+
+		android_app_feature {
+			name: "AppFooCamera",
+			split_name: "camera",
+		}
+	`)
+
+	module := ctx.ModuleForTests("myapex", "android_common_myapex_image")
+	copyCmds := module.Rule("apexRule").Args["copy_commands"]
+
+	ensureContains(t, copyCmds, "image.apex/app/AppFoo@TEST.BUILD_ID/AppFoo.apk")
+	ensureContains(t, copyCmds, "image.apex/app/AppFoo@TEST.BUILD_ID/AppFoo_camera.apk")
+}
+
 func TestApexWithAppImports(t *testing.T) {
 	ctx := testApex(t, `
 		apex {
@@ -6482,6 +6884,79 @@ func TestApexAvailable_IndirectDep(t *testing.T) {
 	}`)
 }
 
+func TestApexAvailable_Suggest(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForApexTest,
+		android.FixtureMergeEnv(map[string]string{
+			"APEX_AVAILABLE_SUGGEST": "true",
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`suggested apex_available additions for 2 module\(s\) were written to .*apex_available_suggestions.json`)).
+		RunTestWithBp(t, `
+	apex {
+		name: "myapex",
+		key: "myapex.key",
+		native_shared_libs: ["libfoo"],
+		updatable: false,
+	}
+
+	apex_key {
+		name: "myapex.key",
+		public_key: "testkey.avbpubkey",
+		private_key: "testkey.pem",
+	}
+
+	cc_library {
+		name: "libfoo",
+		stl: "none",
+		shared_libs: ["libbar"],
+		system_shared_libs: [],
+		apex_available: ["myapex"],
+	}
+
+	cc_library {
+		name: "libbar",
+		stl: "none",
+		shared_libs: ["libbaz"],
+		system_shared_libs: [],
+	}
+
+	cc_library {
+		name: "libbaz",
+		stl: "none",
+		system_shared_libs: [],
+	}`)
+
+	var suggestionsPath string
+	written := regexp.MustCompile(`were written to (\S+apex_available_suggestions.json)`)
+	for _, err := range result.Errs {
+		if m := written.FindStringSubmatch(err.Error()); m != nil {
+			suggestionsPath = m[1]
+			break
+		}
+	}
+	content, err := os.ReadFile(suggestionsPath)
+	if err != nil {
+		t.Fatalf("failed to read the apex_available suggestions: %s", err)
+	}
+	var suggestions []apexAvailableSuggestion
+	if err := json.Unmarshal(content, &suggestions); err != nil {
+		t.Fatalf("failed to parse the apex_available suggestions: %s", err)
+	}
+	android.AssertDeepEquals(t, "apex_available suggestions", []apexAvailableSuggestion{
+		{
+			Module:         "libbar",
+			Apex:           "myapex",
+			DependencyPath: []string{"myapex", "libfoo", "libbar"},
+		},
+		{
+			Module:         "libbaz",
+			Apex:           "myapex",
+			DependencyPath: []string{"myapex", "libfoo", "libbar", "libbaz"},
+		},
+	}, suggestions)
+}
+
 func TestApexAvailable_InvalidApexName(t *testing.T) {
 	testApexError(t, "\"otherapex\" is not a valid module name", `
 	apex {
@@ -9032,6 +9507,7 @@ func TestCompressedApex(t *testing.T) {
 		apex {
 			name: "myapex",
 			key: "myapex.key",
+			certificate: ":myapex.certificate",
 			compressible: true,
 			updatable: false,
 		}
@@ -9040,6 +9516,10 @@ func TestCompressedApex(t *testing.T) {
 			public_key: "testkey.avbpubkey",
 			private_key: "testkey.pem",
 		}
+		android_app_certificate {
+			name: "myapex.certificate",
+			certificate: "testkey",
+		}
 	`,
 		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
 			variables.CompressedApex = proptools.BoolPtr(true)
@@ -9051,6 +9531,8 @@ func TestCompressedApex(t *testing.T) {
 
 	signApkRule := ctx.ModuleForTests("myapex", "android_common_myapex_image").Description("sign compressedApex")
 	ensureEquals(t, signApkRule.Input.String(), compressRule.Output.String())
+	// The compressed APEX is signed with the certificate of the module, like the APEX.
+	ensureEquals(t, signApkRule.Args["certificates"], "testkey.x509.pem testkey.pk8")
 
 	// Make sure output of bundle is .capex
 	ab := ctx.ModuleForTests("myapex", "android_common_myapex_image").Module().(*apexBundle)
@@ -9064,6 +9546,99 @@ func TestCompressedApex(t *testing.T) {
 	ensureContains(t, androidMk, "LOCAL_MODULE_STEM := myapex.capex\n")
 }
 
+func TestCompressedApexExternalSigning(t *testing.T) {
+	ctx := testApex(t, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			certificate: ":myapex.certificate",
+			compressible: true,
+			updatable: false,
+		}
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+		android_app_certificate {
+			name: "myapex.certificate",
+			certificate: "testkey",
+		}
+	`,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.CompressedApex = proptools.BoolPtr(true)
+			variables.ExternalSigningCommand = proptools.StringPtr("vendor/hsm/sign.sh")
+		}),
+		android.FixtureAddFile("vendor/hsm/sign.sh", nil),
+	)
+
+	module := ctx.ModuleForTests("myapex", "android_common_myapex_image")
+	compressRule := module.Rule("compressRule")
+	signRule := module.Description("external sign compressedApex")
+	ensureEquals(t, signRule.Input.String(), compressRule.Output.String())
+	ensureContains(t, signRule.Output.String(), "myapex.capex")
+	ensureContains(t, signRule.Args["extraArgs"], "--certificate testkey.x509.pem")
+	ensureNotContains(t, signRule.Args["extraArgs"], ".pk8")
+
+	apexSignRule := module.Description("external signapk")
+	ensureContains(t, apexSignRule.Args["extraArgs"], "--certificate testkey.x509.pem")
+}
+
+func TestCompressedApexOverrides(t *testing.T) {
+	bp := `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			compressible: true,
+			updatable: false,
+		}
+		apex {
+			name: "otherapex",
+			key: "myapex.key",
+			updatable: false,
+		}
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+	`
+	ctx := testApex(t, bp,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.CompressedApex = proptools.BoolPtr(true)
+			variables.CompressedApexOverrides = []string{"myapex:false", "otherapex:true"}
+		}),
+	)
+
+	myapex := ctx.ModuleForTests("myapex", "android_common_myapex_image").Module().(*apexBundle)
+	ensureContains(t, myapex.outputFile.String(), "myapex.apex")
+	ensureNotContains(t, myapex.outputFile.String(), "myapex.capex")
+
+	otherapex := ctx.ModuleForTests("otherapex", "android_common_otherapex_image").Module().(*apexBundle)
+	ensureContains(t, otherapex.outputFile.String(), "otherapex.capex")
+}
+
+func TestCompressedUpdatableApexWithoutDecompressionSupport(t *testing.T) {
+	testApexError(t, `updatable APEX "myapex" can't be compressed`, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			compressible: true,
+			min_sdk_version: "29",
+		}
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+	`,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.CompressedApex = proptools.BoolPtr(true)
+			variables.BoardApexDecompressionSupported = proptools.BoolPtr(false)
+		}),
+	)
+}
+
 func TestPreferredPrebuiltSharedLibDep(t *testing.T) {
 	ctx := testApex(t, `
 		apex {
@@ -888,8 +888,10 @@ func TestBootclasspathFragment_HiddenAPIList(t *testing.T) {
 	java.CheckModuleDependencies(t, result.TestContext, "mybootclasspathfragment", "android_common_apex10000", []string{
 		"art-bootclasspath-fragment",
 		"bar",
+		"baz",
 		"dex2oatd",
 		"foo",
+		"quuz",
 	})
 
 	fooStubs := getDexJarPath(result, "foo.stubs")
@@ -1059,8 +1061,10 @@ func TestBootclasspathFragment_AndroidNonUpdatable(t *testing.T) {
 		"android-non-updatable.stubs.test",
 		"art-bootclasspath-fragment",
 		"bar",
+		"baz",
 		"dex2oatd",
 		"foo",
+		"quuz",
 	})
 
 	nonUpdatablePublicStubs := getDexJarPath(result, "android-non-updatable.stubs")
@@ -1216,12 +1220,14 @@ func TestBootclasspathFragment_AndroidNonUpdatable_AlwaysUsePrebuiltSdks(t *test
 	java.CheckModuleDependencies(t, result.TestContext, "mybootclasspathfragment", "android_common_apex10000", []string{
 		"art-bootclasspath-fragment",
 		"bar",
+		"baz",
 		"dex2oatd",
 		"foo",
 		"prebuilt_sdk_module-lib_current_android-non-updatable",
 		"prebuilt_sdk_public_current_android-non-updatable",
 		"prebuilt_sdk_system_current_android-non-updatable",
 		"prebuilt_sdk_test_current_android-non-updatable",
+		"quuz",
 	})
 
 	nonUpdatablePublicStubs := getDexJarPath(result, "sdk_public_current_android-non-updatable")
@@ -1248,4 +1254,118 @@ func TestBootclasspathFragment_AndroidNonUpdatable_AlwaysUsePrebuiltSdks(t *test
 	android.AssertStringDoesContain(t, "test", command, "--test-stub-classpath="+nonUpdatableTestStubs)
 }
 
+// TestBootclasspathFragment_ClassCollisions checks that the classes of a bootclasspath_fragment
+// are checked against the classes of the rest of the bootclasspath when building the fragment.
+func TestBootclasspathFragment_ClassCollisions(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForTestWithBootclasspathFragment,
+		prepareForTestWithArtApex,
+		prepareForTestWithMyapex,
+		java.FixtureConfigureBootJars("com.android.art:baz", "platform:quux"),
+		java.FixtureConfigureApexBootJars("myapex:bar"),
+		android.FixtureAddTextFile("class-collisions-allowlist.txt", ""),
+	).RunTestWithBp(t, `
+		apex {
+			name: "com.android.art",
+			key: "com.android.art.key",
+			bootclasspath_fragments: ["art-bootclasspath-fragment"],
+			updatable: false,
+		}
+
+		apex_key {
+			name: "com.android.art.key",
+			public_key: "com.android.art.avbpubkey",
+			private_key: "com.android.art.pem",
+		}
+
+		java_library {
+			name: "baz",
+			apex_available: [
+				"com.android.art",
+			],
+			srcs: ["b.java"],
+			compile_dex: true,
+		}
+
+		bootclasspath_fragment {
+			name: "art-bootclasspath-fragment",
+			image_name: "art",
+			contents: ["baz"],
+			apex_available: [
+				"com.android.art",
+			],
+			hidden_api: {
+				split_packages: ["*"],
+			},
+		}
+
+		java_library {
+			name: "quux",
+			srcs: ["b.java"],
+			installable: true,
+		}
+
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			bootclasspath_fragments: [
+				"mybootclasspathfragment",
+			],
+			updatable: false,
+		}
+
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+
+		java_library {
+			name: "bar",
+			srcs: ["b.java"],
+			installable: true,
+			apex_available: [
+				"myapex",
+			],
+		}
+
+		bootclasspath_fragment {
+			name: "mybootclasspathfragment",
+			contents: ["bar"],
+			apex_available: [
+				"myapex",
+			],
+			hidden_api: {
+				split_packages: ["*"],
+			},
+			class_collisions_allowlist: "class-collisions-allowlist.txt",
+		}
+	`)
+
+	fragment := result.ModuleForTests("mybootclasspathfragment", "android_common_apex10000")
+
+	check := fragment.Rule("check_class_collisions")
+	command := check.RuleParams.Command
+	android.AssertStringDoesContain(t, "fragment", command, "--fragment mybootclasspathfragment")
+	android.AssertStringDoesContain(t, "contents", command,
+		"--jar bar=out/soong/.intermediates/bar/android_common_apex10000/")
+	android.AssertStringDoesContain(t, "art jars", command,
+		"--other-jar com.android.art:baz=out/soong/.intermediates/baz/android_common_apex10000/")
+	android.AssertStringDoesContain(t, "platform jars", command,
+		"--other-jar platform:quux=out/soong/.intermediates/quux/android_common/")
+	android.AssertStringDoesContain(t, "allowlist", command,
+		"--allowlist class-collisions-allowlist.txt")
+
+	// The check must be run whenever the classpaths.proto config of the fragment is built.
+	classpathProto := fragment.Rule("classpath_fragment")
+	android.AssertPathsRelativeToTopEquals(t, "validations", []string{
+		"out/soong/.intermediates/mybootclasspathfragment/android_common_apex10000/class_collisions/check.stamp",
+	}, classpathProto.Validations)
+
+	// The platform variant of the fragment is not checked.
+	platformFragment := result.ModuleForTests("mybootclasspathfragment", "android_common")
+	android.AssertBoolEquals(t, "platform variant is checked", false,
+		platformFragment.MaybeRule("check_class_collisions").Rule != nil)
+}
+
 // TODO(b/177892522) - add test for host apex.
@@ -25,6 +25,7 @@ import (
 
 	"android/soong/android"
 	"android/soong/java"
+	"android/soong/remoteexec"
 
 	"github.com/google/blueprint"
 	"github.com/google/blueprint/proptools"
@@ -75,6 +76,13 @@ func init() {
 	pctx.HostBinToolVariable("deapexer", "deapexer")
 	pctx.HostBinToolVariable("debugfs_static", "debugfs_static")
 	pctx.SourcePathVariable("genNdkUsedbyApexPath", "build/soong/scripts/gen_ndk_usedby_apex.sh")
+
+	pctx.StaticVariableWithEnvOverride("REApexerExecStrategy", "RBE_APEXER_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
+	// The host tools that apexer runs are linked against the shared libraries of the host.
+	pctx.VariableFunc("hostLib64Dir", func(ctx android.PackageVarContext) string {
+		return filepath.Join(filepath.Dir(ctx.Config().HostToolDir()), "lib64")
+	})
+	pctx.StaticVariableWithEnvOverride("REApexerPool", "RBE_APEXER_POOL", remoteexec.DefaultPool)
 }
 
 var (
@@ -105,11 +113,12 @@ var (
 	// against the binary policy using sefcontext_compiler -p <policy>.
 
 	// TODO(b/114327326): automate the generation of file_contexts
-	apexRule = pctx.StaticRule("apexRule", blueprint.RuleParams{
+	// The image directory is populated locally, apexRuleRE then runs apexer remotely.
+	apexRule, apexRuleRE = pctx.RemoteStaticRules("apexRule", blueprint.RuleParams{
 		Command: `rm -rf ${image_dir} && mkdir -p ${image_dir} && ` +
 			`(. ${out}.copy_commands) && ` +
-			`APEXER_TOOL_PATH=${tool_path} ` +
-			`${apexer} --force --manifest ${manifest} ` +
+			`APEXER_TOOL_PATH=${tool_path} ${android.SourceDateEpochEnv}` +
+			`$reTemplate${apexer} --force --manifest ${manifest} ` +
 			`--file_contexts ${file_contexts} ` +
 			`--canned_fs_config ${canned_fs_config} ` +
 			`--include_build_info ` +
@@ -121,13 +130,24 @@ var (
 		Rspfile:        "${out}.copy_commands",
 		RspfileContent: "${copy_commands}",
 		Description:    "APEX ${image_dir} => ${out}",
-	}, "tool_path", "image_dir", "copy_commands", "file_contexts", "canned_fs_config", "key",
-		"opt_flags", "manifest")
+	}, &remoteexec.REParams{
+		Labels: map[string]string{"type": "tool", "name": "apexer"},
+		Inputs: []string{"${image_dir}", "${manifest}", "${file_contexts}", "${canned_fs_config}", "${key}",
+			"prebuilts/sdk/current/public/android.jar", "$implicits"},
+		OutputFiles:  []string{"${out}"},
+		ExecStrategy: "${REApexerExecStrategy}",
+		ToolchainInputs: []string{"${apexer}", "${avbtool}", "${e2fsdroid}", "${mke2fs}", "${resize2fs}",
+			"${sefcontext_compile}", "${make_f2fs}", "${sload_f2fs}", "${make_erofs}", "${soong_zip}",
+			"${zipalign}", "${aapt2}", "${hostLib64Dir}"},
+		EnvironmentVariables: []string{"APEXER_TOOL_PATH", "SOURCE_DATE_EPOCH"},
+		Platform:             map[string]string{remoteexec.PoolKey: "${REApexerPool}"},
+	}, []string{"tool_path", "image_dir", "copy_commands", "file_contexts", "canned_fs_config", "key",
+		"opt_flags", "manifest"}, []string{"implicits"})
 
 	DCLAApexRule = pctx.StaticRule("DCLAApexRule", blueprint.RuleParams{
 		Command: `rm -rf ${image_dir} && mkdir -p ${image_dir} && ` +
 			`(. ${out}.copy_commands) && ` +
-			`APEXER_TOOL_PATH=${tool_path} ` +
+			`APEXER_TOOL_PATH=${tool_path} ${android.SourceDateEpochEnv}` +
 			`${apexer_with_DCLA_preprocessing} ` +
 			`--apexer ${apexer} ` +
 			`--canned_fs_config ${canned_fs_config} ` +
@@ -154,7 +174,7 @@ var (
 	TrimmedApexRule = pctx.StaticRule("TrimmedApexRule", blueprint.RuleParams{
 		Command: `rm -rf ${image_dir} && mkdir -p ${image_dir} && ` +
 			`(. ${out}.copy_commands) && ` +
-			`APEXER_TOOL_PATH=${tool_path} ` +
+			`APEXER_TOOL_PATH=${tool_path} ${android.SourceDateEpochEnv}` +
 			`${apexer_with_trim_preprocessing} ` +
 			`--apexer ${apexer} ` +
 			`--canned_fs_config ${canned_fs_config} ` +
@@ -182,7 +202,7 @@ var (
 	zipApexRule = pctx.StaticRule("zipApexRule", blueprint.RuleParams{
 		Command: `rm -rf ${image_dir} && mkdir -p ${image_dir} && ` +
 			`(. ${out}.copy_commands) && ` +
-			`APEXER_TOOL_PATH=${tool_path} ` +
+			`APEXER_TOOL_PATH=${tool_path} ${android.SourceDateEpochEnv}` +
 			`${apexer} --force --manifest ${manifest} ` +
 			`--payload_type zip ` +
 			`${image_dir} ${out} `,
@@ -248,6 +268,8 @@ func (a *apexBundle) buildManifest(ctx android.ModuleContext, provideNativeLibs,
 	// Put dependency({provide|require}NativeLibs) in apex_manifest.json
 	provideNativeLibs = android.SortedUniqueStrings(provideNativeLibs)
 	requireNativeLibs = android.SortedUniqueStrings(android.RemoveListFromList(requireNativeLibs, provideNativeLibs))
+	a.provideNativeLibs = provideNativeLibs
+	a.requireNativeLibs = requireNativeLibs
 
 	// VNDK APEX name is determined at runtime, so update "name" in apex_manifest
 	optCommands := []string{}
@@ -669,14 +691,14 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 			optFlags = append(optFlags, "--logging_parent ", a.overridableProperties.Logging_parent)
 		}
 
-		// Create a NOTICE file, and embed it as an asset file in the APEX.
+		// Create a NOTICE file of the payload, and embed it as an asset file in the APEX.
 		htmlGzNotice := android.PathForModuleOut(ctx, "NOTICE.html.gz")
 		android.BuildNoticeHtmlOutputFromLicenseMetadata(
-			ctx, htmlGzNotice, "", "",
+			ctx, htmlGzNotice, "", a.Name(),
 			[]string{
 				android.PathForModuleInstall(ctx).String() + "/",
 				android.PathForModuleInPartitionInstall(ctx, "apex").String() + "/",
-			})
+			}, a.payloadModules()...)
 		noticeAssetPath := android.PathForModuleOut(ctx, "NOTICE", "NOTICE.html.gz")
 		builder := android.NewRuleBuilder(pctx, ctx)
 		builder.Command().Text("cp").
@@ -691,7 +713,7 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 		// apex bundle (filesystem image in it, to be specific), we can save storage.
 		needHashTree := moduleMinSdkVersion.LessThanOrEqualTo(android.SdkVersion_Android10) ||
 			a.shouldGenerateHashtree()
-		if ctx.Config().ApexCompressionEnabled() && a.isCompressable() {
+		if ctx.Config().ApexCompressionEnabled() && a.isCompressable(ctx.Config()) {
 			needHashTree = true
 		}
 		if !needHashTree {
@@ -745,21 +767,27 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 				},
 			})
 		} else {
+			rule := apexRule
+			args := map[string]string{
+				"tool_path":        outHostBinDir + ":" + prebuiltSdkToolsBinDir,
+				"image_dir":        imageDir.String(),
+				"copy_commands":    strings.Join(copyCommands, " && "),
+				"manifest":         a.manifestPbOut.String(),
+				"file_contexts":    fileContexts.String(),
+				"canned_fs_config": cannedFsConfig.String(),
+				"key":              a.privateKeyFile.String(),
+				"opt_flags":        strings.Join(optFlags, " "),
+			}
+			if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_APEXER") {
+				rule = apexRuleRE
+				args["implicits"] = strings.Join(android.Paths(implicitInputs).Strings(), ",")
+			}
 			ctx.Build(pctx, android.BuildParams{
-				Rule:        apexRule,
+				Rule:        rule,
 				Implicits:   implicitInputs,
 				Output:      unsignedOutputFile,
 				Description: "apex (" + apexType.name() + ")",
-				Args: map[string]string{
-					"tool_path":        outHostBinDir + ":" + prebuiltSdkToolsBinDir,
-					"image_dir":        imageDir.String(),
-					"copy_commands":    strings.Join(copyCommands, " && "),
-					"manifest":         a.manifestPbOut.String(),
-					"file_contexts":    fileContexts.String(),
-					"canned_fs_config": cannedFsConfig.String(),
-					"key":              a.privateKeyFile.String(),
-					"opt_flags":        strings.Join(optFlags, " "),
-				},
+				Args:        args,
 			})
 		}
 
@@ -863,31 +891,24 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 	// Step 4: Sign the APEX using signapk
 	signedOutputFile := android.PathForModuleOut(ctx, a.Name()+suffix)
 
+	// The APEX and the compressed APEX are both signed with the container certificate of the
+	// module.
 	pem, key := a.getCertificateAndPrivateKey(ctx)
-	rule := java.Signapk
-	args := map[string]string{
-		"certificates": pem.String() + " " + key.String(),
-		"flags":        "-a 4096 --align-file-size", //alignment
-	}
-	implicits := android.Paths{pem, key}
-	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_SIGNAPK") {
-		rule = java.SignapkRE
-		args["implicits"] = strings.Join(implicits.Strings(), ",")
-		args["outCommaList"] = signedOutputFile.String()
+	signapkParams := func(input android.Path, output android.WritablePath) java.SignapkParams {
+		return java.SignapkParams{
+			Input:           input,
+			Outputs:         android.WritablePaths{output},
+			CertificateArgs: []string{pem.String(), key.String()},
+			Certificates:    []java.Certificate{{Pem: pem, Key: key}},
+			Implicits:       android.Paths{pem, key},
+			Flags:           []string{"-a", "4096", "--align-file-size"}, //alignment
+		}
 	}
-	var validations android.Paths
+	apexSignapkParams := signapkParams(unsignedOutputFile, signedOutputFile)
 	if suffix == imageApexSuffix {
-		validations = append(validations, runApexSepolicyTests(ctx, unsignedOutputFile.OutputPath))
+		apexSignapkParams.Validations = android.Paths{runApexSepolicyTests(ctx, unsignedOutputFile.OutputPath)}
 	}
-	ctx.Build(pctx, android.BuildParams{
-		Rule:        rule,
-		Description: "signapk",
-		Output:      signedOutputFile,
-		Input:       unsignedOutputFile,
-		Implicits:   implicits,
-		Args:        args,
-		Validations: validations,
-	})
+	java.BuildSignapk(ctx, apexSignapkParams)
 	if suffix == imageApexSuffix {
 		a.outputApexFile = signedOutputFile
 	}
@@ -916,17 +937,9 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 		compressRule.Build("compressRule", "Generate unsigned compressed APEX file")
 
 		signedCompressedOutputFile := android.PathForModuleOut(ctx, a.Name()+imageCapexSuffix)
-		if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_SIGNAPK") {
-			args["outCommaList"] = signedCompressedOutputFile.String()
-		}
-		ctx.Build(pctx, android.BuildParams{
-			Rule:        rule,
-			Description: "sign compressedApex",
-			Output:      signedCompressedOutputFile,
-			Input:       unsignedCompressedOutputFile,
-			Implicits:   implicits,
-			Args:        args,
-		})
+		capexSignapkParams := signapkParams(unsignedCompressedOutputFile, signedCompressedOutputFile)
+		capexSignapkParams.Description = "sign compressedApex"
+		java.BuildSignapk(ctx, capexSignapkParams)
 		a.outputFile = signedCompressedOutputFile
 		installSuffix = imageCapexSuffix
 	}
@@ -1026,6 +1039,22 @@ func (a *apexBundle) getOverrideManifestPackageName(ctx android.ModuleContext) s
 	return ""
 }
 
+// payloadModules returns the modules of the files in the payload of the APEX, whose license
+// metadata the NOTICE of the APEX is generated from, so that it only lists what the APEX ships,
+// rather than the key, certificate and other build-time dependencies of the APEX.
+func (a *apexBundle) payloadModules() []android.Module {
+	var modules []android.Module
+	seen := make(map[android.Module]bool)
+	for _, fi := range a.filesInfo {
+		if fi.module == nil || seen[fi.module] {
+			continue
+		}
+		seen[fi.module] = true
+		modules = append(modules, fi.module)
+	}
+	return modules
+}
+
 func (a *apexBundle) buildApexDependencyInfo(ctx android.ModuleContext) {
 	if !a.primaryApexType {
 		return
@@ -15,9 +15,15 @@
 package apex
 
 import (
+	"fmt"
+	"path/filepath"
 	"strings"
 
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
 	"android/soong/android"
+	"android/soong/cc"
 )
 
 // Contains 'deapexer' a private module type used by 'prebuilt_apex' to make dex files contained
@@ -57,6 +63,13 @@ type DeapexerProperties struct {
 	//
 	// Each entry is a path from the apex root, e.g. javalib/core-libart.jar.
 	ExportedFiles []string
+
+	// List of native libraries from the .apex file whose ABI is checked against the libraries built
+	// from source.
+	AbiCheckedNativeLibs []string
+
+	// Directory containing the accepted ABI differences of the checked libraries.
+	AbiCheckBaselineDir *string
 }
 
 type SelectedApexProperties struct {
@@ -102,6 +115,20 @@ func (p *Deapexer) DepsMutator(ctx android.BottomUpMutatorContext) {
 		dep := prebuiltApexExportedModuleName(ctx, lib)
 		ctx.AddReverseDependency(ctx.Module(), android.DeapexerTag, dep)
 	}
+
+	// Add dependencies onto the source libraries that the libraries in the `.apex` file are checked
+	// against, and from the prebuilt_apex onto this module so that it can run the checks.
+	if len(p.properties.AbiCheckedNativeLibs) > 0 {
+		target, _ := abiCheckTarget(ctx.Config())
+		variations := append(target.Variations(), blueprint.Variation{Mutator: "link", Variation: "shared"})
+		for _, lib := range p.properties.AbiCheckedNativeLibs {
+			if ctx.OtherModuleFarDependencyVariantExists(variations, lib) {
+				ctx.AddFarVariationDependencies(variations, abiCheckedNativeLibTag, lib)
+			}
+		}
+		prebuiltApex := prebuiltApexExportedModuleName(ctx, apexModuleName(ctx.ModuleName()))
+		ctx.AddReverseDependency(ctx.Module(), abiCheckDeapexerTag, prebuiltApex)
+	}
 }
 
 func (p *Deapexer) GenerateAndroidBuildActions(ctx android.ModuleContext) {
@@ -148,5 +175,92 @@ func (p *Deapexer) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 			command.Output(p.(android.WritablePath))
 		}
 		builder.Build("deapexer", "deapex "+apexModuleName(ctx.ModuleName()))
+
+		p.checkNativeLibAbis(ctx, exports)
+	}
+}
+
+// checkNativeLibAbis creates the rules that check the ABI of the native libraries extracted from
+// the .apex file against the libraries built from source.
+func (p *Deapexer) checkNativeLibAbis(ctx android.ModuleContext, exports map[string]android.WritablePath) {
+	target, ok := abiCheckTarget(ctx.Config())
+	if !ok {
+		return
 	}
+	var abiDiffs android.Paths
+	ctx.VisitDirectDepsWithTag(abiCheckedNativeLibTag, func(module android.Module) {
+		if !ctx.OtherModuleHasProvider(module, cc.SourceAbiDumpInfoProvider) {
+			return
+		}
+		info := ctx.OtherModuleProvider(module, cc.SourceAbiDumpInfoProvider).(cc.SourceAbiDumpInfo)
+		name := ctx.OtherModuleName(module)
+		prebuilt := exports[abiCheckedNativeLibPath(target, name)]
+
+		var baseline android.OptionalPath
+		if dir := proptools.String(p.properties.AbiCheckBaselineDir); dir != "" {
+			baseline = android.ExistentPathForSource(ctx, ctx.ModuleDir(), dir,
+				target.Arch.ArchType.Name, prebuilt.Base()+".abidiff")
+		}
+		errorMessage := fmt.Sprintf("error: The ABI of %s in %s differs from the library built from source. "+
+			"If the difference is expected, accept it by copying the report to %s",
+			name, apexModuleName(ctx.ModuleName()),
+			filepath.Join(ctx.ModuleDir(), proptools.StringDefault(p.properties.AbiCheckBaselineDir, "<abi_check_baseline_dir>"),
+				target.Arch.ArchType.Name, prebuilt.Base()+".abidiff"))
+		abiDiffs = append(abiDiffs, cc.TransformPrebuiltSharedLibraryToAbiDiff(ctx, info, prebuilt,
+			target.Arch.ArchType, "abi_check", baseline, errorMessage))
+	})
+	ctx.SetProvider(prebuiltAbiCheckProvider, prebuiltAbiCheckInfo{abiDiffs})
+}
+
+// prebuiltAbiCheckInfo provides the ABI checks of the native libraries of a prebuilt_apex to the
+// prebuilt_apex, which runs them as validations.
+type prebuiltAbiCheckInfo struct {
+	abiDiffs android.Paths
+}
+
+var prebuiltAbiCheckProvider = blueprint.NewProvider(prebuiltAbiCheckInfo{})
+
+type abiCheckDependencyTag struct {
+	blueprint.BaseDependencyTag
+	name string
+}
+
+// The dependencies of the ABI checks are never part of an APEX.
+func (t abiCheckDependencyTag) ExcludeFromApexContents() {}
+
+var (
+	abiCheckedNativeLibTag = abiCheckDependencyTag{name: "abi_checked_native_libs"}
+	abiCheckDeapexerTag    = abiCheckDependencyTag{name: "abi_check_deapexer"}
+)
+
+// abiCheckTarget returns the target whose native libraries are checked against the libraries
+// built from source.
+func abiCheckTarget(config android.Config) (android.Target, bool) {
+	for _, target := range config.Targets[android.Android] {
+		if target.NativeBridge == android.NativeBridgeDisabled {
+			return target, true
+		}
+	}
+	return android.Target{}, false
+}
+
+// abiCheckedNativeLibPath returns the path of a native library of the target in an .apex file.
+func abiCheckedNativeLibPath(target android.Target, name string) string {
+	libDir := "lib"
+	if target.Arch.ArchType.Multilib == "lib64" {
+		libDir = "lib64"
+	}
+	return filepath.Join(libDir, name+".so")
+}
+
+// abiChecksForPrebuiltApex returns the ABI checks of the native libraries of a prebuilt_apex.
+func abiChecksForPrebuiltApex(ctx android.ModuleContext) android.Paths {
+	var abiDiffs android.Paths
+	ctx.VisitDirectDepsWithTag(abiCheckDeapexerTag, func(module android.Module) {
+		if ctx.OtherModuleHasProvider(module, prebuiltAbiCheckProvider) {
+			info := ctx.OtherModuleProvider(module, prebuiltAbiCheckProvider).(prebuiltAbiCheckInfo)
+			abiDiffs = append(abiDiffs, info.abiDiffs...)
+		}
+	})
+	return abiDiffs
 }
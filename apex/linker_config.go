@@ -0,0 +1,195 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/cc"
+	"android/soong/linkerconfig"
+)
+
+// This file generates the provideLibs and requireLibs inputs of linkerconfig for each partition
+// and each APEX from the native libraries that the modules provide and require, in the
+// linker.config.json format, to out/soong/linker_config/<partition>.json and
+// out/soong/linker_config/apex/<apex>.json. `m linker_config_inputs` builds them.
+//
+// A partition provides the libraries with stubs that are installed to it, and requires the
+// libraries with stubs that its modules link against and that are not installed to it, by Soong or
+// by Make. An APEX provides and requires the libraries in the provideNativeLibs and
+// requireNativeLibs of its apex_manifest.pb.
+//
+// The installable linker_config modules with include_generated_libs add the inputs of their
+// partitions to their linker.config.pb. Their hand-maintained entries are checked against the
+// inputs as part of droidcore, so that they don't list libraries that are no longer provided or
+// required. The libraries that Make installs, which Soong doesn't see, are accepted.
+
+func init() {
+	android.RegisterSingletonType("linker_config_inputs", linkerConfigInputsSingletonFactory)
+}
+
+// linkerConfigInput is the subset of the linker.config.json format that is generated.
+type linkerConfigInput struct {
+	ProvideLibs []string `json:"provideLibs"`
+	RequireLibs []string `json:"requireLibs"`
+}
+
+func linkerConfigInputsSingletonFactory() android.Singleton {
+	return &linkerConfigInputsSingleton{}
+}
+
+type linkerConfigInputsSingleton struct{}
+
+func (s *linkerConfigInputsSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	provideLibs := make(map[string]map[string]bool)
+	requireLibs := make(map[string]map[string]bool)
+	add := func(libs map[string]map[string]bool, partition, lib string) {
+		if libs[partition] == nil {
+			libs[partition] = make(map[string]bool)
+		}
+		libs[partition][lib] = true
+	}
+
+	type manualConfig struct {
+		module string
+		info   linkerconfig.LinkerConfigInfo
+	}
+	var manualConfigs []manualConfig
+	var apexes []*apexBundle
+	seenApexes := make(map[string]bool)
+
+	ctx.VisitAllModules(func(module android.Module) {
+		if !module.Enabled() {
+			return
+		}
+		if ctx.ModuleHasProvider(module, linkerconfig.LinkerConfigInfoProvider) {
+			info := ctx.ModuleProvider(module, linkerconfig.LinkerConfigInfoProvider).(linkerconfig.LinkerConfigInfo)
+			if info.Partition != "" {
+				manualConfigs = append(manualConfigs, manualConfig{ctx.ModuleName(module), info})
+			}
+			return
+		}
+		if a, ok := module.(*apexBundle); ok {
+			if a.primaryApexType && !a.testApex && !seenApexes[a.Name()] {
+				seenApexes[a.Name()] = true
+				apexes = append(apexes, a)
+			}
+			return
+		}
+
+		m, ok := module.(*cc.Module)
+		if !ok || m.Target().Os.Class != android.Device || m.IsSkipInstall() {
+			return
+		}
+		if apexInfo := ctx.ModuleProvider(m, android.ApexInfoProvider).(android.ApexInfo); !apexInfo.IsForPlatform() {
+			return
+		}
+		specs := m.PackagingSpecs()
+		if len(specs) == 0 {
+			return
+		}
+		partition := specs[0].Partition()
+		if cc.IsStubTarget(m) {
+			for _, ps := range specs {
+				if strings.HasSuffix(ps.FileName(), ".so") {
+					add(provideLibs, partition, ps.FileName())
+				}
+			}
+		}
+		ctx.VisitDirectDeps(m, func(dep android.Module) {
+			if c, ok := dep.(*cc.Module); ok && c.IsStubs() && c.OutputFile().Valid() {
+				add(requireLibs, partition, c.OutputFile().Path().Base())
+			}
+		})
+	})
+
+	var outputs android.Paths
+	write := func(path android.WritablePath, input linkerConfigInput) {
+		data, err := json.MarshalIndent(input, "", "  ")
+		if err != nil {
+			ctx.Errorf("failed to write %s: %s", path, err)
+			return
+		}
+		android.WriteFileRuleVerbatim(ctx, path, string(data)+"\n")
+		outputs = append(outputs, path)
+	}
+
+	inputs := make(map[string]android.Path)
+	partitions := android.SortedUniqueStrings(append(android.SortedKeys(provideLibs), android.SortedKeys(requireLibs)...))
+	for _, partition := range partitions {
+		input := linkerConfigInput{ProvideLibs: []string{}, RequireLibs: []string{}}
+		input.ProvideLibs = append(input.ProvideLibs, android.SortedKeys(provideLibs[partition])...)
+		makeInstalled := ctx.Config().MakeInstalledSharedLibs(partition)
+		for _, lib := range android.SortedKeys(requireLibs[partition]) {
+			if !provideLibs[partition][lib] && !android.InList(lib, makeInstalled) {
+				input.RequireLibs = append(input.RequireLibs, lib)
+			}
+		}
+		path := linkerconfig.GeneratedLinkerConfigPath(ctx, partition)
+		write(path, input)
+		inputs[partition] = path
+	}
+
+	for _, a := range apexes {
+		input := linkerConfigInput{
+			ProvideLibs: android.SortedUniqueStrings(append([]string{}, a.provideNativeLibs...)),
+			RequireLibs: []string{},
+		}
+		for _, lib := range android.SortedUniqueStrings(a.requireNativeLibs) {
+			// :vndk stands for the VNDK libraries, which the APEX doesn't list.
+			if lib != ":vndk" {
+				input.RequireLibs = append(input.RequireLibs, lib)
+			}
+		}
+		write(android.PathForOutput(ctx, "linker_config", "apex", a.Name()+".json"), input)
+	}
+
+	var checks android.Paths
+	for _, c := range manualConfigs {
+		input, ok := inputs[c.info.Partition]
+		if !ok {
+			// The partition neither provides nor requires libraries.
+			path := linkerconfig.GeneratedLinkerConfigPath(ctx, c.info.Partition)
+			write(path, linkerConfigInput{ProvideLibs: []string{}, RequireLibs: []string{}})
+			input = path
+			inputs[c.info.Partition] = input
+		}
+		stamp := android.PathForOutput(ctx, "linker_config", "check", c.module+".stamp")
+		builder := android.NewRuleBuilder(pctx, ctx)
+		cmd := builder.Command().BuiltTool("conv_linker_config").
+			Flag("check").
+			FlagWithInput("-s ", c.info.Src).
+			FlagWithInput("--generated ", input).
+			FlagWithOutput("-o ", stamp)
+		if makeInstalled := ctx.Config().MakeInstalledSharedLibs(c.info.Partition); len(makeInstalled) > 0 {
+			cmd.FlagWithArg("--make-installed ", proptools.ShellEscapeIncludingSpaces(strings.Join(makeInstalled, " ")))
+		}
+		builder.Build("linker_config_check_"+c.module, fmt.Sprintf("Checking the linker config of %s", c.module))
+		checks = append(checks, stamp)
+	}
+
+	if len(outputs) > 0 {
+		ctx.Phony("linker_config_inputs", outputs...)
+	}
+	if len(checks) > 0 {
+		ctx.Phony("check-linker-config", checks...)
+		ctx.Phony("droidcore", android.PathForPhony(ctx, "check-linker-config"))
+	}
+}
@@ -170,6 +170,115 @@ func TestPlatformBootclasspath_Fragments(t *testing.T) {
 	android.AssertArrayString(t, "all flags", []string{"out/soong/.intermediates/bar-fragment/android_common_apex10000/modular-hiddenapi/filtered-flags.csv:out/soong/.intermediates/bar-fragment/android_common_apex10000/modular-hiddenapi/signature-patterns.csv"}, info.FlagSubsets.RelativeToTop())
 }
 
+func TestPlatformBootclasspath_ShardedHiddenAPIFlags(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForTestWithPlatformBootclasspath,
+		prepareForTestWithMyapex,
+		java.PrepareForTestWithJavaSdkLibraryFiles,
+		java.FixtureWithLastReleaseApis("foo"),
+		java.FixtureConfigureBootJars("platform:quuz"),
+		java.FixtureConfigureApexBootJars("myapex:bar"),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.ShardedHiddenApiFlags = proptools.BoolPtr(true)
+		}),
+		android.FixtureWithRootAndroidBp(`
+			platform_bootclasspath {
+				name: "platform-bootclasspath",
+				fragments: [
+					{
+						apex: "myapex",
+						module:"bar-fragment",
+					},
+				],
+				hidden_api: {
+					unsupported: [
+							"unsupported.txt",
+					],
+				},
+			}
+
+			apex {
+				name: "myapex",
+				key: "myapex.key",
+				bootclasspath_fragments: [
+					"bar-fragment",
+				],
+				updatable: false,
+			}
+
+			apex_key {
+				name: "myapex.key",
+				public_key: "testkey.avbpubkey",
+				private_key: "testkey.pem",
+			}
+
+			bootclasspath_fragment {
+				name: "bar-fragment",
+				contents: ["bar"],
+				apex_available: ["myapex"],
+				api: {
+					stub_libs: ["foo"],
+				},
+				hidden_api: {
+					unsupported: [
+							"bar-unsupported.txt",
+					],
+					split_packages: ["*"],
+				},
+			}
+
+			java_library {
+				name: "bar",
+				apex_available: ["myapex"],
+				srcs: ["a.java"],
+				system_modules: "none",
+				sdk_version: "none",
+				compile_dex: true,
+				permitted_packages: ["bar"],
+			}
+
+			java_library {
+				name: "quuz",
+				srcs: ["a.java"],
+				system_modules: "none",
+				sdk_version: "none",
+				compile_dex: true,
+			}
+
+			java_sdk_library {
+				name: "foo",
+				srcs: ["a.java"],
+				public: {
+					enabled: true,
+				},
+				compile_dex: true,
+			}
+		`),
+	).RunTest(t)
+
+	pbcp := result.ModuleForTests("platform-bootclasspath", "android_common")
+
+	// The libraries that are not in a fragment are processed like a fragment.
+	stubFlags := pbcp.Output("hiddenapi-platform/stub-flags.csv")
+	android.AssertStringDoesContain(t, "platform stub flags", stubFlags.RuleParams.Command, "--fragment")
+	android.AssertStringDoesContain(t, "platform stub flags", stubFlags.RuleParams.Command, "--boot-dex=out/soong/.intermediates/quuz/")
+	android.AssertStringDoesNotContain(t, "platform stub flags", stubFlags.RuleParams.Command, "--boot-dex=out/soong/.intermediates/bar/")
+
+	allFlags := pbcp.Output("hiddenapi-platform/all-flags.csv")
+	android.AssertStringDoesContain(t, "platform flags", allFlags.RuleParams.Command, "--unsupported unsupported.txt")
+	android.AssertStringDoesNotContain(t, "platform flags", allFlags.RuleParams.Command, "bar-unsupported.txt")
+
+	// The monolithic files merge the flags of the platform and of the fragments.
+	java.CheckHiddenAPIRuleInputs(t, "merged stub flags", `
+		out/soong/.intermediates/bar-fragment/android_common_apex10000/modular-hiddenapi/stub-flags.csv
+		out/soong/.intermediates/platform-bootclasspath/android_common/hiddenapi-platform/stub-flags.csv
+	`, pbcp.Output("out/soong/hiddenapi/hiddenapi-stub-flags.txt"))
+	java.CheckHiddenAPIRuleInputs(t, "merged flags", `
+		out/soong/.intermediates/bar-fragment/android_common_apex10000/modular-hiddenapi/all-flags.csv
+		out/soong/.intermediates/platform-bootclasspath/android_common/hiddenapi-platform/all-flags.csv
+	`, pbcp.Output("out/soong/hiddenapi/hiddenapi-flags.csv"))
+}
+
 // TestPlatformBootclasspath_LegacyPrebuiltFragment verifies that the
 // prebuilt_bootclasspath_fragment falls back to using the complete stub-flags/all-flags if the
 // filtered files are not provided.
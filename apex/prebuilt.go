@@ -110,6 +110,17 @@ type PrebuiltCommonProperties struct {
 	// List of systemserverclasspath fragments inside this prebuilt APEX bundle and for which this
 	// APEX bundle will create an APEX variant.
 	Exported_systemserverclasspath_fragments []string
+
+	// List of native shared libraries inside this prebuilt APEX bundle whose ABI is checked against
+	// the libraries of the same name built from source. Only the libraries of the primary device
+	// architecture are checked, and a library is skipped if there is no source module creating sAbi
+	// dumps for it.
+	Abi_checked_native_libs []string
+
+	// Directory containing the accepted ABI differences of the libraries listed in
+	// abi_checked_native_libs, as <arch>/<library>.so.abidiff files. A library whose differences
+	// match the accepted report does not fail the build.
+	Abi_check_baseline_dir *string
 }
 
 // initPrebuiltCommon initializes the prebuiltCommon structure and performs initialization of the
@@ -133,9 +144,9 @@ func (p *prebuiltCommon) isForceDisabled() bool {
 }
 
 func (p *prebuiltCommon) checkForceDisable(ctx android.ModuleContext) bool {
-	// If the device is configured to use flattened APEX, force disable the prebuilt because
-	// the prebuilt is a non-flattened one.
-	forceDisable := ctx.Config().FlattenApex()
+	// If the device is configured to use flattened APEX, or to install this APEX flattened,
+	// force disable the prebuilt because the prebuilt is a non-flattened one.
+	forceDisable := ctx.Config().FlattenApex() || ctx.Config().FlattenApexModule(p.BaseModuleName())
 
 	// Force disable the prebuilts when we are doing unbundled build. We do unbundled build
 	// to build the prebuilts themselves.
@@ -603,7 +614,8 @@ func createApexSelectorModule(ctx android.TopDownMutatorContext, name string, ap
 // the listed modules need access to files from within the prebuilt .apex file.
 func (p *prebuiltCommon) createDeapexerModuleIfNeeded(ctx android.TopDownMutatorContext, deapexerName string, apexFileSource string) {
 	// Only create the deapexer module if it is needed.
-	if !p.hasExportedDeps() {
+	abiCheckedLibs := p.prebuiltCommonProperties.Abi_checked_native_libs
+	if !p.hasExportedDeps() && len(abiCheckedLibs) == 0 {
 		return
 	}
 
@@ -640,6 +652,15 @@ func (p *prebuiltCommon) createDeapexerModuleIfNeeded(ctx android.TopDownMutator
 		CommonModules: android.SortedUniqueStrings(commonModules),
 	}
 
+	// The native libraries whose ABI is checked must also be extracted from the .apex file.
+	if target, ok := abiCheckTarget(ctx.Config()); ok {
+		for _, lib := range abiCheckedLibs {
+			exportedFiles = append(exportedFiles, abiCheckedNativeLibPath(target, lib))
+		}
+		deapexerProperties.AbiCheckedNativeLibs = abiCheckedLibs
+		deapexerProperties.AbiCheckBaselineDir = p.prebuiltCommonProperties.Abi_check_baseline_dir
+	}
+
 	// Populate the exported files property in a fixed order.
 	deapexerProperties.ExportedFiles = android.SortedUniqueStrings(exportedFiles)
 
@@ -769,9 +790,10 @@ func (p *Prebuilt) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	}
 	p.outputApex = android.PathForModuleOut(ctx, p.installFilename)
 	ctx.Build(pctx, android.BuildParams{
-		Rule:   android.Cp,
-		Input:  p.inputApex,
-		Output: p.outputApex,
+		Rule:        android.Cp,
+		Input:       p.inputApex,
+		Output:      p.outputApex,
+		Validations: abiChecksForPrebuiltApex(ctx),
 	})
 
 	if p.prebuiltCommon.checkForceDisable(ctx) {
@@ -989,9 +1011,10 @@ func (a *ApexSet) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	inputApex := android.OptionalPathForModuleSrc(ctx, a.prebuiltCommonProperties.Selected_apex).Path()
 	a.outputApex = android.PathForModuleOut(ctx, a.installFilename)
 	ctx.Build(pctx, android.BuildParams{
-		Rule:   android.Cp,
-		Input:  inputApex,
-		Output: a.outputApex,
+		Rule:        android.Cp,
+		Input:       inputApex,
+		Output:      a.outputApex,
+		Validations: abiChecksForPrebuiltApex(ctx),
 	})
 
 	if a.prebuiltCommon.checkForceDisable(ctx) {
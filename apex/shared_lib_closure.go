@@ -0,0 +1,206 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apex
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"android/soong/android"
+	"android/soong/cc"
+)
+
+// This file verifies that the shared libraries that the installed native modules of the platform
+// link against can be loaded at run time, i.e. that each of them is installed to the partition of
+// the module or to a partition whose libraries the linker namespace of the module can load. As
+// every installed library is itself checked, this covers the whole DT_NEEDED closure of the
+// installed binaries. `m check-shared-lib-closure` runs the check, which is part of droidcore, and
+// the problems are written to out/soong/shared_lib_closure.json with the offending dependency edge.
+//
+// The stubs of the libraries of the APEXes and of the other partitions, the LLNDK and VNDK
+// libraries and the vendor public libraries are resolved by linkerconfig, so a dependency on them
+// is always satisfied.
+
+func init() {
+	android.RegisterSingletonType("shared_lib_closure", sharedLibClosureSingletonFactory)
+}
+
+const (
+	// The library is only installed in APEXes.
+	sharedLibClosureMissing = "missing"
+	// The library is installed to a partition that the module can't load libraries from.
+	sharedLibClosureMispartitioned = "mispartitioned"
+)
+
+// The partitions whose libraries the modules of a partition can load without stubs. The vendor
+// variants are installed to vendor or odm, which share their linker namespace, and the product
+// variants can only load the libraries of product.
+var sharedLibClosurePermittedPartitions = map[string][]string{
+	"system":     {"system"},
+	"system_ext": {"system_ext", "system"},
+	"product":    {"product", "system_ext", "system"},
+	"vendor":     {"vendor", "odm"},
+	"odm":        {"odm", "vendor"},
+}
+
+// sharedLibClosureProblem is a shared library dependency of an installed module that can't be
+// loaded at run time.
+type sharedLibClosureProblem struct {
+	Module              string `json:"module"`
+	Partition           string `json:"partition"`
+	Dependency          string `json:"dependency"`
+	Library             string `json:"library"`
+	DependencyPartition string `json:"dependency_partition,omitempty"`
+	Problem             string `json:"problem"`
+}
+
+func (p sharedLibClosureProblem) String() string {
+	if p.Problem == sharedLibClosureMissing {
+		return fmt.Sprintf("%s (%s) -> %s: %s is not installed", p.Module, p.Partition, p.Dependency, p.Library)
+	}
+	return fmt.Sprintf("%s (%s) -> %s: %s is installed to %s", p.Module, p.Partition, p.Dependency,
+		p.Library, p.DependencyPartition)
+}
+
+func sharedLibClosureSingletonFactory() android.Singleton {
+	return &sharedLibClosureSingleton{}
+}
+
+type sharedLibClosureSingleton struct{}
+
+// installedPartition returns the partition that a platform variant of a device module is installed
+// to, or false if it isn't installed.
+func installedPartition(ctx android.SingletonContext, m *cc.Module) (string, bool) {
+	if m.Target().Os.Class != android.Device || m.IsSkipInstall() {
+		return "", false
+	}
+	if apexInfo := ctx.ModuleProvider(m, android.ApexInfoProvider).(android.ApexInfo); !apexInfo.IsForPlatform() {
+		return "", false
+	}
+	specs := m.PackagingSpecs()
+	if len(specs) == 0 {
+		return "", false
+	}
+	return specs[0].Partition(), true
+}
+
+func (s *sharedLibClosureSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	seen := make(map[sharedLibClosureProblem]bool)
+	problems := []sharedLibClosureProblem{}
+
+	ctx.VisitAllModules(func(module android.Module) {
+		m, ok := module.(*cc.Module)
+		if !ok || !m.Enabled() || m.UseSdk() {
+			return
+		}
+		partition, installed := installedPartition(ctx, m)
+		if !installed {
+			return
+		}
+		permitted, ok := sharedLibClosurePermittedPartitions[partition]
+		if !ok {
+			return
+		}
+		if m.InProduct() {
+			permitted = []string{partition}
+		}
+
+		if !ctx.ModuleHasProvider(m, cc.SharedLibraryDepsInfoProvider) {
+			return
+		}
+		deps := ctx.ModuleProvider(m, cc.SharedLibraryDepsInfoProvider).(cc.SharedLibraryDepsInfo)
+		for _, dep := range deps.SharedLibraryDeps {
+			c, ok := dep.(*cc.Module)
+			if !ok || !c.OutputFile().Valid() {
+				continue
+			}
+			if c.IsStubs() || c.IsLlndk() || c.IsVndk() || c.IsVendorPublicLibrary() {
+				continue
+			}
+
+			problem := sharedLibClosureProblem{
+				Module:     ctx.ModuleName(m),
+				Partition:  partition,
+				Dependency: ctx.ModuleName(c),
+				Library:    c.OutputFile().Path().Base(),
+			}
+			if depPartition, installed := installedPartition(ctx, c); !installed {
+				// A library that isn't installed for another reason, e.g. one that is installed by
+				// Make or not part of the product, can't be checked.
+				if !c.NotAvailableForPlatform() {
+					continue
+				}
+				problem.Problem = sharedLibClosureMissing
+			} else if !android.InList(depPartition, permitted) {
+				problem.Problem = sharedLibClosureMispartitioned
+				problem.DependencyPartition = depPartition
+			} else {
+				continue
+			}
+			// The variants of the other architectures report the same problem.
+			if !seen[problem] {
+				seen[problem] = true
+				problems = append(problems, problem)
+			}
+		}
+	})
+
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Module != problems[j].Module {
+			return problems[i].Module < problems[j].Module
+		}
+		if problems[i].Partition != problems[j].Partition {
+			return problems[i].Partition < problems[j].Partition
+		}
+		return problems[i].Dependency < problems[j].Dependency
+	})
+
+	data, err := json.MarshalIndent(problems, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the shared library closure: %s", err)
+		return
+	}
+	report := android.PathForOutput(ctx, "shared_lib_closure.json")
+	android.WriteFileRuleVerbatim(ctx, report, string(data)+"\n")
+
+	stamp := android.PathForOutput(ctx, "shared_lib_closure.stamp")
+	if len(problems) > 0 {
+		var edges []string
+		for _, p := range problems {
+			edges = append(edges, p.String())
+		}
+		msg := fmt.Sprintf("%d shared library dependencies of the installed modules can't be loaded, see %s: %s",
+			len(problems), report, strings.Join(edges, ", "))
+		ctx.Build(pctx, android.BuildParams{
+			Rule:     android.ErrorRule,
+			Output:   stamp,
+			Implicit: report,
+			Args: map[string]string{
+				"error": msg,
+			},
+		})
+	} else {
+		ctx.Build(pctx, android.BuildParams{
+			Rule:     android.Touch,
+			Output:   stamp,
+			Implicit: report,
+		})
+	}
+
+	ctx.Phony("check-shared-lib-closure", stamp)
+	ctx.Phony("droidcore", android.PathForPhony(ctx, "check-shared-lib-closure"))
+}
@@ -115,6 +115,86 @@ func TestSystemserverclasspathFragmentContents(t *testing.T) {
 	assertProfileGuided(t, ctx, "baz", "android_common_apex10000", false)
 }
 
+func TestSystemserverclasspathFragmentProfile(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForTestWithSystemserverclasspathFragment,
+		prepareForTestWithMyapex,
+		dexpreopt.FixtureSetApexSystemServerJars("myapex:foo", "myapex:bar"),
+		android.FixtureMergeMockFs(android.MockFS{
+			"art-profile": nil,
+		}),
+	).RunTestWithBp(t, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			systemserverclasspath_fragments: [
+				"mysystemserverclasspathfragment",
+			],
+			updatable: false,
+		}
+
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+
+		java_library {
+			name: "foo",
+			srcs: ["b.java"],
+			installable: true,
+			apex_available: [
+				"myapex",
+			],
+		}
+
+		java_library {
+			name: "bar",
+			srcs: ["c.java"],
+			installable: true,
+			dex_preopt: {
+				profile: "bar-art-profile",
+			},
+			apex_available: [
+				"myapex",
+			],
+		}
+
+		systemserverclasspath_fragment {
+			name: "mysystemserverclasspathfragment",
+			contents: [
+				"foo",
+				"bar",
+			],
+			dex_preopt: {
+				profile: "art-profile",
+			},
+			apex_available: [
+				"myapex",
+			],
+		}
+	`)
+
+	ctx := result.TestContext
+
+	ensureExactContents(t, ctx, "myapex", "android_common_myapex_image", []string{
+		"etc/classpaths/systemserverclasspath.pb",
+		"javalib/foo.jar",
+		"javalib/foo.jar.prof",
+		"javalib/bar.jar",
+		"javalib/bar.jar.prof",
+	})
+
+	// The contents use the profile of the fragment, unless they have their own.
+	foo := ctx.ModuleForTests("foo", "android_common_apex10000").Rule("dexpreopt").RuleParams.Command
+	android.AssertStringDoesContain(t, "foo profile", foo, "--create-profile-from=art-profile")
+	android.AssertStringDoesContain(t, "foo compiler filter", foo, "--compiler-filter=speed-profile")
+
+	bar := ctx.ModuleForTests("bar", "android_common_apex10000").Rule("dexpreopt").RuleParams.Command
+	android.AssertStringDoesContain(t, "bar profile", bar, "--create-profile-from=bar-art-profile")
+	android.AssertStringDoesContain(t, "bar compiler filter", bar, "--compiler-filter=speed-profile")
+}
+
 func TestSystemserverclasspathFragmentNoGeneratedProto(t *testing.T) {
 	result := android.GroupFixturePreparers(
 		prepareForTestWithSystemserverclasspathFragment,
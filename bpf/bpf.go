@@ -34,6 +34,7 @@ func init() {
 	registerBpfBuildComponents(android.InitRegistrationContext)
 	pctx.Import("android/soong/cc/config")
 	pctx.StaticVariable("relPwd", cc.PwdPrefix())
+	pctx.HostBinToolVariable("bpfObjCheckCmd", "bpf_obj_check")
 }
 
 var (
@@ -55,6 +56,14 @@ var (
 			CommandDeps: []string{"$stripCmd"},
 		},
 		"stripCmd")
+
+	// Checks the license and BTF debug info of a bpf object, and writes the metadata it found.
+	objCheckRule = pctx.AndroidStaticRule("objCheckRule",
+		blueprint.RuleParams{
+			Command:     `$bpfObjCheckCmd $flags -o $out $in`,
+			CommandDeps: []string{"$bpfObjCheckCmd"},
+		},
+		"flags")
 )
 
 func registerBpfBuildComponents(ctx android.RegistrationContext) {
@@ -172,6 +181,11 @@ func (bpf *bpf) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		}
 	}
 
+	var checkFlags []string
+	if proptools.Bool(bpf.properties.Btf) {
+		checkFlags = append(checkFlags, "-btf")
+	}
+
 	srcs := android.PathsForModuleSrc(ctx, bpf.properties.Srcs)
 
 	for _, src := range srcs {
@@ -179,32 +193,48 @@ func (bpf *bpf) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 			ctx.ModuleErrorf("invalid character '_' in source name")
 		}
 		obj := android.ObjPathWithExt(ctx, "unstripped", src, "o")
+		btf := proptools.Bool(bpf.properties.Btf)
+		// The installed object is checked whenever it is built.
+		metadata := android.ObjPathWithExt(ctx, "", src, "json")
+		var ccValidation android.Path
+		if !btf {
+			ccValidation = metadata
+		}
 
 		ctx.Build(pctx, android.BuildParams{
-			Rule:   ccRule,
-			Input:  src,
-			Output: obj,
+			Rule:       ccRule,
+			Input:      src,
+			Output:     obj,
+			Validation: ccValidation,
 			Args: map[string]string{
 				"cFlags": strings.Join(cflags, " "),
 				"ccCmd":  "${config.ClangBin}/clang",
 			},
 		})
 
-		if proptools.Bool(bpf.properties.Btf) {
+		if btf {
 			objStripped := android.ObjPathWithExt(ctx, "", src, "o")
 			ctx.Build(pctx, android.BuildParams{
-				Rule:   stripRule,
-				Input:  obj,
-				Output: objStripped,
+				Rule:       stripRule,
+				Input:      obj,
+				Output:     objStripped,
+				Validation: metadata,
 				Args: map[string]string{
 					"stripCmd": "${config.ClangBin}/llvm-strip",
 				},
 			})
-			bpf.objs = append(bpf.objs, objStripped.WithoutRel())
-		} else {
-			bpf.objs = append(bpf.objs, obj.WithoutRel())
+			obj = objStripped
 		}
+		bpf.objs = append(bpf.objs, obj.WithoutRel())
 
+		ctx.Build(pctx, android.BuildParams{
+			Rule:   objCheckRule,
+			Input:  obj,
+			Output: metadata,
+			Args: map[string]string{
+				"flags": strings.Join(checkFlags, " "),
+			},
+		})
 	}
 }
 
@@ -94,3 +94,26 @@ func TestBpfWithBazel(t *testing.T) {
 	expectedOutputFiles := []string{"outputbase/execroot/__main__/bpf.o"}
 	android.AssertDeepEquals(t, "output files", expectedOutputFiles, output.objs.Strings())
 }
+
+func TestBpfObjCheck(t *testing.T) {
+	bp := `
+		bpf {
+			name: "bpf.o",
+			srcs: ["bpf.c"],
+			btf: true,
+		}
+	`
+
+	result := prepareForBpfTest.RunTestWithBp(t, bp)
+
+	module := result.ModuleForTests("bpf.o", "android_common")
+	check := module.Rule("objCheckRule")
+	android.AssertPathRelativeToTopEquals(t, "input", "out/soong/.intermediates/bpf.o/android_common/bpf.o", check.Input)
+	android.AssertPathRelativeToTopEquals(t, "output", "out/soong/.intermediates/bpf.o/android_common/bpf.json", check.Output)
+	android.AssertStringEquals(t, "flags", "-btf", check.Args["flags"])
+
+	// The check runs whenever the installed object is built.
+	strip := module.Rule("stripRule")
+	android.AssertPathRelativeToTopEquals(t, "validation",
+		"out/soong/.intermediates/bpf.o/android_common/bpf.json", strip.Validation)
+}
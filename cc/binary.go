@@ -430,6 +430,7 @@ func (binary *binaryDecorator) link(ctx ModuleContext,
 	}
 
 	validations = append(validations, objs.tidyDepFiles...)
+	validations = append(validations, checkElfFile(ctx, outputFile)...)
 	linkerDeps = append(linkerDeps, flags.LdFlagsDeps...)
 
 	// Register link action.
@@ -292,6 +292,17 @@ var (
 		},
 		"extraFlags", "referenceDump", "libName", "arch", "errorMessage")
 
+	// Rule to compare the linked sAbi dump of a prebuilt library with the one of the source library,
+	// accepting differences that match a baseline report.
+	sAbiPrebuiltDiff = pctx.AndroidStaticRule("sAbiPrebuiltDiff",
+		blueprint.RuleParams{
+			Command: "($sAbiDiffer -allow-extensions -allow-unreferenced-changes -lib ${libName} -arch ${arch} " +
+				"-o ${out} -new ${in} -old ${referenceDump}) || cmp -s ${out} ${baseline} || " +
+				"(echo '${errorMessage}' && exit 1)",
+			CommandDeps: []string{"$sAbiDiffer"},
+		},
+		"referenceDump", "libName", "arch", "baseline", "errorMessage")
+
 	// Rule to zip files.
 	zip = pctx.AndroidStaticRule("zip",
 		blueprint.RuleParams{
@@ -887,6 +898,14 @@ func transformDumpToLinkedDump(ctx android.ModuleContext, sAbiDumps android.Path
 	excludedSymbolVersions, excludedSymbolTags []string) android.OptionalPath {
 
 	outputFile := android.PathForModuleOut(ctx, baseName+".lsdump")
+	linkAbiDump(ctx, outputFile, ctx.Arch().ArchType, sAbiDumps, soFile, exportedHeaderFlags, symbolFile,
+		excludedSymbolVersions, excludedSymbolTags)
+	return android.OptionalPathForPath(outputFile)
+}
+
+func linkAbiDump(ctx android.ModuleContext, outputFile android.WritablePath, arch android.ArchType,
+	sAbiDumps android.Paths, soFile android.Path, exportedHeaderFlags string, symbolFile android.OptionalPath,
+	excludedSymbolVersions, excludedSymbolTags []string) {
 
 	implicits := android.Paths{soFile}
 	symbolFilterStr := "-so " + soFile.String()
@@ -904,7 +923,7 @@ func transformDumpToLinkedDump(ctx android.ModuleContext, sAbiDumps android.Path
 	rule := sAbiLink
 	args := map[string]string{
 		"symbolFilter":        symbolFilterStr,
-		"arch":                ctx.Arch().ArchType.Name,
+		"arch":                arch.Name,
 		"exportedHeaderFlags": exportedHeaderFlags,
 	}
 	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_ABI_LINKER") {
@@ -926,7 +945,47 @@ func transformDumpToLinkedDump(ctx android.ModuleContext, sAbiDumps android.Path
 		Implicits:   implicits,
 		Args:        args,
 	})
-	return android.OptionalPathForPath(outputFile)
+}
+
+// TransformPrebuiltSharedLibraryToAbiDiff registers the build statements that check the ABI of a
+// prebuilt copy of a shared library against the library built from source. The sAbi dumps of the
+// source library are linked against the symbols exported by the prebuilt and the result is
+// compared with the linked dump of the source library. The check fails unless there are no
+// differences other than extensions, or the differences match the report in baseline.
+//
+// As the type information comes from the source headers, this detects removed and added symbols
+// but not changes to the layout of types that were only made in the prebuilt.
+func TransformPrebuiltSharedLibraryToAbiDiff(ctx android.ModuleContext, info SourceAbiDumpInfo,
+	prebuilt android.Path, arch android.ArchType, outDir string, baseline android.OptionalPath,
+	errorMessage string) android.Path {
+
+	baseName := prebuilt.Base()
+	prebuiltDump := android.PathForModuleOut(ctx, outDir, arch.Name, baseName+".lsdump")
+	linkAbiDump(ctx, prebuiltDump, arch, info.Dumps, prebuilt, info.ExportedHeaderFlags, info.SymbolFile,
+		info.ExcludedSymbolVersions, info.ExcludedSymbolTags)
+
+	outputFile := android.PathForModuleOut(ctx, outDir, arch.Name, baseName+".abidiff")
+	baselineFile := "/dev/null"
+	implicits := android.Paths{info.LinkedDump}
+	if baseline.Valid() {
+		baselineFile = baseline.String()
+		implicits = append(implicits, baseline.Path())
+	}
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        sAbiPrebuiltDiff,
+		Description: "header-abi-diff " + outputFile.Base(),
+		Output:      outputFile,
+		Input:       prebuiltDump,
+		Implicits:   implicits,
+		Args: map[string]string{
+			"referenceDump": info.LinkedDump.String(),
+			"libName":       strings.TrimSuffix(baseName, filepath.Ext(baseName)),
+			"arch":          arch.Name,
+			"baseline":      baselineFile,
+			"errorMessage":  errorMessage,
+		},
+	})
+	return outputFile
 }
 
 func transformAbiDumpToAbiDiff(ctx android.ModuleContext, inputDump, referenceDump android.Path,
@@ -720,6 +720,13 @@ func (d libraryDependencyTag) static() bool {
 	return d.Kind == staticLibraryDependency
 }
 
+// LicenseStaticLink returns true for the static libraries, which are linked into the module.
+func (d libraryDependencyTag) LicenseStaticLink() bool {
+	return d.static()
+}
+
+var _ android.LicenseStaticLinkDependencyTag = libraryDependencyTag{}
+
 func (d libraryDependencyTag) LicenseAnnotations() []android.LicenseAnnotation {
 	if d.shared() {
 		return []android.LicenseAnnotation{android.LicenseAnnotationSharedDependency}
@@ -770,6 +777,7 @@ var (
 	runtimeDepTag         = installDependencyTag{name: "runtime lib"}
 	testPerSrcDepTag      = dependencyTag{name: "test_per_src"}
 	stubImplDepTag        = dependencyTag{name: "stub_impl"}
+	stubSrcsDepTag        = dependencyTag{name: "stub_srcs"}
 	JniFuzzLibTag         = dependencyTag{name: "jni_fuzz_lib_tag"}
 	FdoProfileTag         = dependencyTag{name: "fdo_profile"}
 )
@@ -2011,6 +2019,14 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		return
 	}
 
+	var sharedLibraryDeps []android.Module
+	ctx.VisitDirectDeps(func(dep android.Module) {
+		if IsSharedDepTag(ctx.OtherModuleDependencyTag(dep)) {
+			sharedLibraryDeps = append(sharedLibraryDeps, dep)
+		}
+	})
+	ctx.SetProvider(SharedLibraryDepsInfoProvider, SharedLibraryDepsInfo{SharedLibraryDeps: sharedLibraryDeps})
+
 	if c.Properties.Clang != nil && *c.Properties.Clang == false {
 		ctx.PropertyErrorf("clang", "false (GCC) is no longer supported")
 	}
@@ -2875,7 +2891,7 @@ func checkDoubleLoadableLibraries(ctx android.TopDownMutatorContext) {
 		if depTag == staticVariantTag {
 			return false
 		}
-		if depTag == stubImplDepTag {
+		if depTag == stubImplDepTag || depTag == stubSrcsDepTag {
 			return false
 		}
 
@@ -3841,6 +3857,10 @@ func (c *Module) DepIsInSameApex(ctx android.BaseModuleContext, dep android.Modu
 		// We don't track from an implementation library to its stubs.
 		return false
 	}
+	if depTag == stubSrcsDepTag {
+		// The stubs variants only share the generated stub sources.
+		return false
+	}
 	if depTag == staticVariantTag {
 		// This dependency is for optimization (reuse *.o from the static lib). It doesn't
 		// actually mean that the static lib (and its dependencies) are copied into the
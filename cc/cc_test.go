@@ -856,6 +856,56 @@ func TestTestBinaryTestSuites(t *testing.T) {
 	}
 }
 
+func TestTestBinaryRunAtBuild(t *testing.T) {
+	t.Parallel()
+	bp := `
+		cc_test_host {
+			name: "main_test",
+			srcs: ["main_test.cpp"],
+			shared_libs: ["libfoo"],
+			gtest: false,
+			run_at_build: true,
+			run_at_build_timeout: 60,
+		}
+
+		cc_library_shared {
+			name: "libfoo",
+			host_supported: true,
+		}
+	`
+
+	ctx := prepareForCcTest.RunTestWithBp(t, bp).TestContext
+	module := ctx.ModuleForTests("main_test", "linux_glibc_x86_64")
+
+	manifest := android.RuleBuilderSboxProtoForTests(t, module.Output("run_at_build.sbox.textproto"))
+	cmd := manifest.Commands[0].GetCommand()
+	android.AssertStringDoesContain(t, "timeout", cmd, "timeout 60s ")
+	android.AssertStringDoesContain(t, "shared libraries", cmd,
+		"LD_LIBRARY_PATH=out/soong/.intermediates/libfoo/linux_glibc_x86_64_shared ")
+
+	validated := module.Output("out/soong/.intermediates/main_test/linux_glibc_x86_64/run_at_build_validated/main_test")
+	android.AssertPathRelativeToTopEquals(t, "validation",
+		"out/soong/.intermediates/main_test/linux_glibc_x86_64/run_at_build/run_at_build.timestamp",
+		validated.Validation)
+
+	install := module.Output("out/soong/host/linux-x86/nativetest64/main_test/main_test")
+	android.AssertPathRelativeToTopEquals(t, "installed test",
+		"out/soong/.intermediates/main_test/linux_glibc_x86_64/run_at_build_validated/main_test",
+		install.Input)
+}
+
+func TestTestBinaryRunAtBuildDevice(t *testing.T) {
+	t.Parallel()
+	testCcError(t, `only host tests can run during the build`, `
+		cc_test {
+			name: "main_test",
+			srcs: ["main_test.cpp"],
+			gtest: false,
+			run_at_build: true,
+		}
+	`)
+}
+
 func TestTestLibraryTestSuites(t *testing.T) {
 	t.Parallel()
 	bp := `
@@ -2850,6 +2900,30 @@ func TestLlndkLibrary(t *testing.T) {
 	checkExportedIncludeDirs("libllndk_with_override_headers", "android_vendor.29_arm64_armv8-a_shared", "include_llndk")
 }
 
+func TestLlndkSymbolFileLint(t *testing.T) {
+	t.Parallel()
+	bp := `
+	cc_library {
+		name: "libllndk",
+		llndk: {
+			symbol_file: "libllndk.map.txt",
+		},
+	}
+	`
+	result := prepareForCcTest.RunTestWithBp(t, bp)
+	module := result.ModuleForTests("libllndk", "android_vendor.29_arm64_armv8-a_shared")
+	lint := module.Output("symbol_file_lint.timestamp")
+	android.AssertPathRelativeToTopEquals(t, "linted symbol file", "libllndk.map.txt", lint.Input)
+	android.AssertStringPathRelativeToTopEquals(t, "fixed symbol file", result.Config,
+		"out/soong/.intermediates/libllndk/android_vendor.29_arm64_armv8-a_shared/symbol_file_lint/libllndk.map.txt",
+		lint.Args["fixed"])
+
+	genStub := module.Description("generate stub")
+	android.AssertPathsRelativeToTopEquals(t, "generate stub implicits",
+		[]string{"out/soong/api_levels.json", "out/soong/.intermediates/libllndk/android_vendor.29_arm64_armv8-a_shared/symbol_file_lint.timestamp"},
+		genStub.Implicits)
+}
+
 func TestLlndkHeaders(t *testing.T) {
 	t.Parallel()
 	ctx := testCc(t, `
@@ -4177,6 +4251,98 @@ func TestFuzzTarget(t *testing.T) {
 	ctx.ModuleForTests("fuzz_smoke_test", variant).Rule("cc")
 }
 
+func TestFuzzCorpusProviders(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		PrepareForTestWithFuzzPackaging,
+		android.FixtureAddTextFile("seeds/Android.bp", `
+			filegroup {
+				name: "libfoo_seeds",
+				srcs: ["a.bin", "b.bin"],
+			}
+		`),
+		android.FixtureAddFile("seeds/a.bin", nil),
+		android.FixtureAddFile("seeds/b.bin", nil),
+		android.FixtureAddFile("c.bin", nil),
+		android.FixtureAddFile("d.bin", nil),
+		android.FixtureAddFile("foo.dict", nil),
+	).RunTestWithBp(t, `
+		cc_fuzz {
+			name: "foo_fuzzer",
+			srcs: ["foo.c"],
+			corpus: ["c.bin"],
+			dictionary: "foo.dict",
+		}
+
+		cc_fuzz {
+			name: "bar_fuzzer",
+			srcs: ["foo.c"],
+			corpus: ["d.bin", "c.bin"],
+			fuzz_corpus_providers: [":libfoo_seeds", ":foo_fuzzer"],
+			corpus_max_size: 1024,
+		}
+	`)
+
+	variant := "android_arm64_armv8-a_fuzzer"
+	copyCorpus := result.ModuleForTests("bar_fuzzer", variant).Rule("copy_corpus")
+	android.AssertPathsRelativeToTopEquals(t, "corpus", []string{
+		"c.bin",
+		"d.bin",
+		"seeds/a.bin",
+		"seeds/b.bin",
+	}, copyCorpus.Implicits)
+
+	packaging := result.SingletonForTests("cc_fuzz_packaging")
+	corpusZip := packaging.Output("out/soong/.intermediates/fuzz/target/arm64/bar_fuzzer_seed_corpus.zip")
+	android.AssertStringDoesContain(t, "corpus size check", corpusZip.RuleParams.Command,
+		"-gt 1024 ]")
+
+	metadata := packaging.Output("out/soong/.intermediates/fuzz/target/arm64/bar_fuzzer_metadata.json")
+	android.AssertStringEquals(t, "metadata", `{
+  "name": "bar_fuzzer",
+  "host_or_target": "target",
+  "arch": "arm64",
+  "framework": "libfuzzer",
+  "corpus": {
+    "zip": "bar_fuzzer_seed_corpus.zip",
+    "entries": [
+      "d.bin",
+      "c.bin",
+      "a.bin",
+      "b.bin"
+    ],
+    "providers": [
+      "libfoo_seeds",
+      "foo_fuzzer"
+    ],
+    "max_size": 1024
+  }
+}
+`, android.ContentFromFileRuleForTests(t, metadata))
+
+	metadata = packaging.Output("out/soong/.intermediates/fuzz/target/arm64/foo_fuzzer_metadata.json")
+	android.AssertStringDoesContain(t, "metadata", android.ContentFromFileRuleForTests(t, metadata),
+		`"dictionary": "foo.dict"`)
+}
+
+func TestFuzzCorpusConflict(t *testing.T) {
+	t.Parallel()
+	android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureAddFile("a/seed", nil),
+		android.FixtureAddFile("b/seed", nil),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`corpus seeds "a/seed" and "b/seed" have the same name "seed"`)).
+		RunTestWithBp(t, `
+		cc_fuzz {
+			name: "foo_fuzzer",
+			srcs: ["foo.c"],
+			corpus: ["a/seed", "b/seed"],
+		}
+	`)
+}
+
 func assertString(t *testing.T, got, expected string) {
 	t.Helper()
 	if got != expected {
@@ -4540,6 +4706,65 @@ func TestMinSdkVersionInClangTriple(t *testing.T) {
 	android.AssertStringDoesContain(t, "min sdk version", cFlags, "-target aarch64-linux-android29")
 }
 
+func TestEnforceMinSdkVersion(t *testing.T) {
+	t.Parallel()
+	bp := `
+		cc_library_shared {
+			name: "libfoo",
+			srcs: ["foo.c"],
+			static_libs: ["libbar", "libbaz"],
+			min_sdk_version: "29",
+			enforce_min_sdk_version: true,
+			compile_multilib: "first",
+			system_shared_libs: [],
+			stl: "none",
+		}
+
+		cc_library_static {
+			name: "libbar",
+			srcs: ["bar.c"],
+			static_libs: ["libqux"],
+			min_sdk_version: "29",
+			system_shared_libs: [],
+			stl: "none",
+		}
+
+		cc_library_static {
+			name: "libbaz",
+			srcs: ["baz.c"],
+			min_sdk_version: "31",
+			system_shared_libs: [],
+			stl: "none",
+		}
+
+		cc_library_static {
+			name: "libqux",
+			srcs: ["qux.c"],
+			min_sdk_version: "30",
+			system_shared_libs: [],
+			stl: "none",
+		}
+	`
+
+	android.GroupFixturePreparers(
+		prepareForCcTest,
+	).ExtendWithErrorHandler(android.FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`"libbaz" should support min_sdk_version\(29\)`,
+		`"libqux" should support min_sdk_version\(29\)(?s).*Dependency path: libfoo.*-> libbar.*-> libqux`,
+	})).RunTestWithBp(t, bp)
+
+	android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.MinSdkVersionWaivers = StringPtr("min_sdk_version_waivers.json")
+		}),
+		android.FixtureAddTextFile("min_sdk_version_waivers.json", `[
+			{"module": "libfoo", "dependency": "libbaz", "reason": "b/1"},
+			{"module": "libfoo", "dependency": "libqux", "reason": "b/2"}
+		]`),
+	).RunTestWithBp(t, bp)
+}
+
 func TestNonDigitMinSdkVersionInClangTriple(t *testing.T) {
 	t.Parallel()
 	bp := `
@@ -5215,3 +5440,32 @@ func TestDclaLibraryInApex(t *testing.T) {
 	expectedOutputFiles := []string{"outputbase/execroot/__main__/foo.so"}
 	android.AssertDeepEquals(t, "output files", expectedOutputFiles, outputFiles.Strings())
 }
+
+func TestGtestHostMusl(t *testing.T) {
+	t.Parallel()
+	bp := `
+		cc_library_static {
+			name: "libgtest_main",
+			host_supported: true,
+		}
+
+		cc_library_static {
+			name: "libgtest",
+			host_supported: true,
+		}
+
+		cc_test {
+			name: "host_test",
+			srcs: ["foo.cpp"],
+			host_supported: true,
+		}
+	`
+
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		PrepareForTestWithHostMusl,
+	).RunTestWithBp(t, bp)
+
+	cFlags := result.ModuleForTests("host_test", "linux_musl_x86_64").Rule("cc").Args["cFlags"]
+	android.AssertStringDoesContain(t, "musl host test cflags", cFlags, "-DGTEST_OS_LINUX")
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"fmt"
+	"strings"
+
+	"android/soong/android"
+
+	"github.com/google/blueprint"
+)
+
+// This file implements the checks of the security features and of the alignment of the binaries
+// and shared libraries that are linked for the device, as validations of their link rules. The
+// product enables each check with a product variable:
+//   - ElfCheckBtiPac: the arm64 binaries must have the BTI and PAC properties.
+//   - ElfCheckExecuteOnly: the executable segments of the arm64 binaries must not be readable.
+//   - ElfCheckTextRelocations: the binaries must not have text relocations.
+//   - ElfCheckMaxPageSize: the LOAD segments must be aligned to the given page size.
+//
+// The modules that are known to fail a check are listed in the ElfCheckBaseline file, with a line
+// of the module name followed by the names of the checks it is exempt from, e.g.
+// "libfoo bti_pac max_page_size". Lines starting with # are comments.
+
+const (
+	elfCheckBtiPac          = "bti_pac"
+	elfCheckExecuteOnly     = "execute_only"
+	elfCheckTextRelocations = "text_relocations"
+	elfCheckMaxPageSize     = "max_page_size"
+)
+
+var (
+	_ = pctx.SourcePathVariable("checkElfCmd", "build/soong/scripts/check_elf.sh")
+
+	checkElf = pctx.AndroidStaticRule("checkElf",
+		blueprint.RuleParams{
+			Command:     "CLANG_BIN=${config.ClangBin} $checkElfCmd $flags -m $module -i $in -o $out",
+			CommandDeps: []string{"$checkElfCmd", "${config.ClangBin}/llvm-readelf"},
+		},
+		"flags", "module")
+)
+
+var elfCheckBaselineKey = android.NewOnceKey("ElfCheckBaseline")
+
+type elfCheckBaseline struct {
+	exemptions map[string]map[string]bool
+	err        error
+}
+
+// parseElfCheckBaseline returns the checks that each module of a baseline file is exempt from.
+func parseElfCheckBaseline(data []byte) (map[string]map[string]bool, error) {
+	exemptions := make(map[string]map[string]bool)
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) == 1 {
+			return nil, fmt.Errorf("line %d: expected the checks that %q is exempt from", i+1, fields[0])
+		}
+		for _, check := range fields[1:] {
+			switch check {
+			case elfCheckBtiPac, elfCheckExecuteOnly, elfCheckTextRelocations, elfCheckMaxPageSize:
+			default:
+				return nil, fmt.Errorf("line %d: unknown check %q, expected %s, %s, %s or %s", i+1, check,
+					elfCheckBtiPac, elfCheckExecuteOnly, elfCheckTextRelocations, elfCheckMaxPageSize)
+			}
+			if exemptions[fields[0]] == nil {
+				exemptions[fields[0]] = make(map[string]bool)
+			}
+			exemptions[fields[0]][check] = true
+		}
+	}
+	return exemptions, nil
+}
+
+func getElfCheckBaseline(ctx android.ModuleContext) elfCheckBaseline {
+	return ctx.Config().Once(elfCheckBaselineKey, func() interface{} {
+		data, err := ctx.Config().ElfCheckBaseline(ctx)
+		if err != nil {
+			return elfCheckBaseline{err: fmt.Errorf("failed to read the ELF check baseline: %s", err)}
+		}
+		exemptions, err := parseElfCheckBaseline(data)
+		if err != nil {
+			return elfCheckBaseline{err: fmt.Errorf("invalid ELF check baseline: %s", err)}
+		}
+		return elfCheckBaseline{exemptions: exemptions}
+	}).(elfCheckBaseline)
+}
+
+// elfCheckFlags returns the flags of check_elf.sh for the checks that the product enables for a
+// module and that it isn't exempt from.
+func elfCheckFlags(ctx ModuleContext) []string {
+	config := ctx.Config()
+	if !ctx.Device() {
+		return nil
+	}
+	if !config.ElfCheckBtiPac() && !config.ElfCheckExecuteOnly() && !config.ElfCheckTextRelocations() &&
+		config.ElfCheckMaxPageSize() == "" {
+		return nil
+	}
+
+	baseline := getElfCheckBaseline(ctx)
+	if baseline.err != nil {
+		ctx.ModuleErrorf("%s", baseline.err)
+		return nil
+	}
+	exempt := baseline.exemptions[ctx.ModuleName()]
+
+	var flags []string
+	if config.ElfCheckBtiPac() && ctx.Arch().ArchType == android.Arm64 && !exempt[elfCheckBtiPac] {
+		flags = append(flags, "--bti-pac")
+	}
+	if config.ElfCheckExecuteOnly() && ctx.Arch().ArchType == android.Arm64 && !exempt[elfCheckExecuteOnly] {
+		flags = append(flags, "--execute-only")
+	}
+	if config.ElfCheckTextRelocations() && !exempt[elfCheckTextRelocations] {
+		flags = append(flags, "--no-text-relocations")
+	}
+	if pageSize := config.ElfCheckMaxPageSize(); pageSize != "" && !exempt[elfCheckMaxPageSize] {
+		flags = append(flags, "--max-page-size="+pageSize)
+	}
+	return flags
+}
+
+// checkElfFile returns the stamp of the checks of a linked binary or shared library, to add to the
+// validations of its link rule, or nil if none of the checks apply to the module.
+func checkElfFile(ctx ModuleContext, in android.Path) android.Paths {
+	flags := elfCheckFlags(ctx)
+	if len(flags) == 0 {
+		return nil
+	}
+	stamp := android.PathForModuleOut(ctx, "elf_check", in.Base()+".stamp")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        checkElf,
+		Description: "check elf " + in.Base(),
+		Input:       in,
+		Output:      stamp,
+		Args: map[string]string{
+			"flags":  strings.Join(flags, " "),
+			"module": ctx.ModuleName(),
+		},
+	})
+	return android.Paths{stamp}
+}
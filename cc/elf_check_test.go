@@ -0,0 +1,92 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestElfCheck(t *testing.T) {
+	t.Parallel()
+	bp := `
+	cc_binary {
+		name: "mybin",
+		srcs: ["src.c"],
+	}
+	cc_library_shared {
+		name: "libexempt",
+		srcs: ["src.c"],
+	}
+	cc_binary_host {
+		name: "myhostbin",
+		srcs: ["src.c"],
+	}
+`
+
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.ElfCheckBtiPac = BoolPtr(true)
+			variables.ElfCheckExecuteOnly = BoolPtr(true)
+			variables.ElfCheckMaxPageSize = StringPtr("16384")
+			variables.ElfCheckBaseline = StringPtr("elf_check_baseline.txt")
+		}),
+		android.FixtureAddTextFile("elf_check_baseline.txt", `
+# The known offenders.
+libexempt bti_pac execute_only
+`),
+	).RunTestWithBp(t, bp)
+
+	mybin := result.ModuleForTests("mybin", "android_arm64_armv8-a")
+	check := mybin.Rule("checkElf")
+	android.AssertStringEquals(t, "mybin flags", "--bti-pac --execute-only --max-page-size=16384", check.Args["flags"])
+	android.AssertStringEquals(t, "mybin module", "mybin", check.Args["module"])
+	android.AssertPathsRelativeToTopEquals(t, "mybin link validations", []string{check.Output.String()},
+		mybin.Rule("ld").Validations)
+
+	libexempt := result.ModuleForTests("libexempt", "android_arm64_armv8-a_shared")
+	android.AssertStringEquals(t, "libexempt flags", "--max-page-size=16384",
+		libexempt.Rule("checkElf").Args["flags"])
+
+	libexempt32 := result.ModuleForTests("libexempt", "android_arm_armv7-a-neon_shared")
+	android.AssertStringEquals(t, "libexempt arm flags", "--max-page-size=16384",
+		libexempt32.Rule("checkElf").Args["flags"])
+
+	myhostbin := result.ModuleForTests("myhostbin", result.Config.BuildOSTarget.String())
+	if myhostbin.MaybeRule("checkElf").Rule != nil {
+		t.Errorf("expected no ELF check of a host binary")
+	}
+}
+
+func TestParseElfCheckBaseline(t *testing.T) {
+	t.Parallel()
+	exemptions, err := parseElfCheckBaseline([]byte("# comment\n\nlibfoo bti_pac max_page_size\nlibbar text_relocations\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	android.AssertDeepEquals(t, "exemptions", map[string]map[string]bool{
+		"libfoo": {"bti_pac": true, "max_page_size": true},
+		"libbar": {"text_relocations": true},
+	}, exemptions)
+
+	_, err = parseElfCheckBaseline([]byte("libfoo\n"))
+	android.AssertStringEquals(t, "missing checks", `line 1: expected the checks that "libfoo" is exempt from`, err.Error())
+
+	_, err = parseElfCheckBaseline([]byte("libfoo bti\n"))
+	android.AssertStringEquals(t, "unknown check",
+		`line 1: unknown check "bti", expected bti_pac, execute_only, text_relocations or max_page_size`, err.Error())
+}
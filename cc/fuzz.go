@@ -238,6 +238,9 @@ func (fuzzBin *fuzzBinary) install(ctx ModuleContext, file android.Path) {
 	fuzzBin.binaryDecorator.baseInstaller.install(ctx, file)
 
 	fuzzBin.fuzzPackagedModule = PackageFuzzModule(ctx, fuzzBin.fuzzPackagedModule, pctx)
+	module := ctx.Module().(*Module)
+	fuzzBin.fuzzPackagedModule.Framework = module.fuzzer.Properties.FuzzFramework
+	fuzzBin.fuzzPackagedModule.Coverage = module.coverage != nil && module.coverage.Properties.CoverageEnabled
 
 	// Grab the list of required shared libraries.
 	fuzzBin.sharedLibraries, _ = CollectAllSharedDependencies(ctx)
@@ -257,6 +260,27 @@ func (fuzzBin *fuzzBinary) install(ctx ModuleContext, file android.Path) {
 
 func PackageFuzzModule(ctx android.ModuleContext, fuzzPackagedModule fuzz.FuzzPackagedModule, pctx android.PackageContext) fuzz.FuzzPackagedModule {
 	fuzzPackagedModule.Corpus = android.PathsForModuleSrc(ctx, fuzzPackagedModule.FuzzProperties.Corpus)
+	fuzzPackagedModule.CorpusProviders = nil
+	for _, provider := range fuzzPackagedModule.FuzzProperties.Fuzz_corpus_providers {
+		m, t := android.SrcIsModuleWithTag(provider)
+		if m == "" {
+			ctx.PropertyErrorf("fuzz_corpus_providers",
+				"%q is not a module reference, e.g. \":module\"", provider)
+			continue
+		}
+		fuzzPackagedModule.CorpusProviders = append(fuzzPackagedModule.CorpusProviders, m)
+		// Reuse the corpus of another fuzz target rather than its binary.
+		dep, ok := android.GetModuleFromPathDep(ctx, m, t).(LinkableInterface)
+		if ok && t == "" && dep.IsFuzzModule() {
+			fuzzPackagedModule.Corpus = append(fuzzPackagedModule.Corpus,
+				dep.FuzzPackagedModule().Corpus...)
+			continue
+		}
+		fuzzPackagedModule.Corpus = append(fuzzPackagedModule.Corpus,
+			android.PathsForModuleSrc(ctx, []string{provider})...)
+	}
+	fuzzPackagedModule.Corpus = dedupCorpus(ctx, fuzzPackagedModule.Corpus)
+
 	builder := android.NewRuleBuilder(pctx, ctx)
 	intermediateDir := android.PathForModuleOut(ctx, "corpus")
 	for _, entry := range fuzzPackagedModule.Corpus {
@@ -295,6 +319,21 @@ func PackageFuzzModule(ctx android.ModuleContext, fuzzPackagedModule fuzz.FuzzPa
 	return fuzzPackagedModule
 }
 
+// dedupCorpus removes the seeds that are listed more than once from the corpus. The corpus is
+// packaged flat, so distinct seeds with the same name are an error.
+func dedupCorpus(ctx android.ModuleContext, corpus android.Paths) android.Paths {
+	corpus = android.FirstUniquePaths(corpus)
+	seen := make(map[string]android.Path)
+	for _, entry := range corpus {
+		if other, ok := seen[entry.Base()]; ok {
+			ctx.ModuleErrorf("corpus seeds %q and %q have the same name %q", other, entry, entry.Base())
+			continue
+		}
+		seen[entry.Base()] = entry
+	}
+	return corpus
+}
+
 func NewFuzzer(hod android.HostOrDeviceSupported) *Module {
 	module, binary := newBinary(hod, false)
 	baseInstallerPath := "fuzz"
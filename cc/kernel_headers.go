@@ -15,27 +15,152 @@
 package cc
 
 import (
+	"path/filepath"
+	"strings"
+
+	"github.com/google/blueprint"
+
 	"android/soong/android"
 )
 
+var (
+	sanitizeKernelHeader = pctx.AndroidStaticRule("sanitizeKernelHeader",
+		blueprint.RuleParams{
+			Command: "$headersInstall $in $out",
+		},
+		"headersInstall")
+)
+
+type kernelHeadersProperties struct {
+	// Path, relative to the root of the source tree, of a kernel source tree whose UAPI headers
+	// are sanitized with its scripts/headers_install.sh and exported, instead of the directories of
+	// TARGET_BOARD_KERNEL_HEADERS and TARGET_PRODUCT_KERNEL_HEADERS. The headers generated by
+	// the kernel build, e.g. asm/unistd_64.h, are not exported, use kernel_prebuilt_headers for
+	// them.
+	Kernel_src *string
+
+	// Path, relative to the root of the source tree, of the headers installed by the
+	// `make headers_install` of a kernel, with one directory per kernel architecture, e.g.
+	// arm64/include or x86/include, exported instead of the directories of
+	// TARGET_BOARD_KERNEL_HEADERS and TARGET_PRODUCT_KERNEL_HEADERS.
+	Kernel_prebuilt_headers *string
+}
+
 type kernelHeadersDecorator struct {
 	*libraryDecorator
+
+	kernelHeadersProperties kernelHeadersProperties
+}
+
+func (stub *kernelHeadersDecorator) linkerProps() []interface{} {
+	return append(stub.libraryDecorator.linkerProps(), &stub.kernelHeadersProperties)
+}
+
+// kernelArch returns the name of the kernel architecture, e.g. arm64 or x86, for the target.
+func kernelArch(arch android.ArchType) string {
+	switch arch {
+	case android.X86, android.X86_64:
+		return "x86"
+	case android.Riscv64:
+		return "riscv"
+	default:
+		return arch.Name
+	}
 }
 
 func (stub *kernelHeadersDecorator) link(ctx ModuleContext, flags Flags, deps PathDeps, objs Objects) android.Path {
 	if ctx.Device() {
 		f := &stub.libraryDecorator.flagExporter
-		f.reexportSystemDirs(android.PathsForSource(ctx, ctx.DeviceConfig().DeviceKernelHeaderDirs())...)
+		props := stub.kernelHeadersProperties
+		switch {
+		case props.Kernel_src != nil && props.Kernel_prebuilt_headers != nil:
+			ctx.PropertyErrorf("kernel_prebuilt_headers", "cannot be set with kernel_src")
+		case props.Kernel_src != nil:
+			include, headers := stub.sanitizeKernelHeaders(ctx, String(props.Kernel_src))
+			f.reexportSystemDirs(include)
+			f.reexportDeps(headers...)
+			f.addExportedGeneratedHeaders(headers...)
+		case props.Kernel_prebuilt_headers != nil:
+			dir := android.PathForSource(ctx, String(props.Kernel_prebuilt_headers),
+				kernelArch(ctx.Arch().ArchType), "include")
+			f.reexportSystemDirs(dir)
+		default:
+			f.reexportSystemDirs(android.PathsForSource(ctx, ctx.DeviceConfig().DeviceKernelHeaderDirs())...)
+		}
 		f.setProvider(ctx)
 	}
 	return stub.libraryDecorator.linkStatic(ctx, flags, deps, objs)
 }
 
+// sanitizeKernelHeaders sanitizes the generic and architecture specific UAPI headers of a kernel
+// source tree into an include directory, like `make headers_install` does, and returns it with the
+// headers.
+func (stub *kernelHeadersDecorator) sanitizeKernelHeaders(ctx ModuleContext,
+	kernelSrc string) (android.Path, android.Paths) {
+	include := android.PathForModuleGen(ctx, "include")
+	headersInstall := android.PathForSource(ctx, kernelSrc, "scripts", "headers_install.sh")
+	var headers android.Paths
+
+	sanitize := func(dir android.Path) map[string]bool {
+		installed := make(map[string]bool)
+		for _, header := range ctx.GlobFiles(headerGlobPattern(dir.String()), nil) {
+			rel, err := filepath.Rel(dir.String(), header.String())
+			if err != nil {
+				ctx.ModuleErrorf("%s is not under %s: %s", header, dir, err)
+				continue
+			}
+			out := include.Join(ctx, rel)
+			ctx.Build(pctx, android.BuildParams{
+				Rule:        sanitizeKernelHeader,
+				Description: "sanitize kernel header " + rel,
+				Input:       header,
+				Output:      out,
+				Implicit:    headersInstall,
+				Args: map[string]string{
+					"headersInstall": headersInstall.String(),
+				},
+			})
+			headers = append(headers, out)
+			installed[rel] = true
+		}
+		return installed
+	}
+
+	generic := sanitize(android.MaybeExistentPathForSource(ctx, kernelSrc, "include", "uapi"))
+	if len(generic) == 0 {
+		ctx.PropertyErrorf("kernel_src", "%q has no UAPI headers in include/uapi", kernelSrc)
+		return include, nil
+	}
+	arch := sanitize(android.MaybeExistentPathForSource(ctx, kernelSrc, "arch",
+		kernelArch(ctx.Arch().ArchType), "include", "uapi"))
+
+	// Like the mandatory-y headers of include/uapi/asm-generic/Kbuild, the asm headers that the
+	// architecture doesn't provide include the generic ones.
+	for _, rel := range android.SortedKeys(generic) {
+		if !strings.HasPrefix(rel, "asm-generic/") {
+			continue
+		}
+		name := strings.TrimPrefix(rel, "asm-generic/")
+		asm := filepath.Join("asm", name)
+		if arch[asm] {
+			continue
+		}
+		out := include.Join(ctx, asm)
+		android.WriteFileRule(ctx, out, "#include <asm-generic/"+name+">")
+		headers = append(headers, out)
+	}
+
+	return include, headers
+}
+
 // kernel_headers retrieves the list of kernel headers directories from
 // TARGET_BOARD_KERNEL_HEADERS and TARGET_PRODUCT_KERNEL_HEADERS variables in
 // a makefile for compilation. See
 // https://android.googlesource.com/platform/build/+/master/core/config.mk
 // for more details on them.
+//
+// With kernel_src or kernel_prebuilt_headers, it exports the UAPI headers of
+// a kernel for the architecture of each variant instead.
 func kernelHeadersFactory() android.Module {
 	module, library := NewLibrary(android.HostAndDeviceSupported)
 	library.HeaderOnly()
@@ -50,5 +175,9 @@ func kernelHeadersFactory() android.Module {
 }
 
 func init() {
-	android.RegisterModuleType("kernel_headers", kernelHeadersFactory)
+	RegisterKernelHeadersBuildComponents(android.InitRegistrationContext)
+}
+
+func RegisterKernelHeadersBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterModuleType("kernel_headers", kernelHeadersFactory)
 }
@@ -0,0 +1,109 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestKernelHeadersKernelSrc(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureMergeMockFs(android.MockFS{
+			"kernel/scripts/headers_install.sh":                nil,
+			"kernel/include/uapi/linux/types.h":                nil,
+			"kernel/include/uapi/asm-generic/errno.h":          nil,
+			"kernel/include/uapi/asm-generic/types.h":          nil,
+			"kernel/arch/arm64/include/uapi/asm/types.h":       nil,
+			"kernel/arch/arm/include/uapi/asm/types.h":         nil,
+			"kernel/arch/arm/include/uapi/asm/errno.h":         nil,
+			"kernel/arch/x86/include/uapi/asm/bootparam.h":     nil,
+			"kernel/Documentation/not_a_uapi_header/example.h": nil,
+		}),
+	).RunTestWithBp(t, `
+		kernel_headers {
+			name: "device_kernel_headers",
+			vendor: true,
+			kernel_src: "kernel",
+		}
+
+		cc_library_static {
+			name: "libvendor",
+			srcs: ["foo.c"],
+			header_libs: ["device_kernel_headers"],
+			vendor: true,
+		}
+	`)
+
+	headers := result.ModuleForTests("device_kernel_headers", "android_vendor.29_arm64_armv8-a")
+	include := "out/soong/.intermediates/device_kernel_headers/android_vendor.29_arm64_armv8-a/gen/include"
+
+	sanitize := headers.Output("gen/include/linux/types.h")
+	android.AssertStringEquals(t, "rule", "sanitizeKernelHeader", sanitize.Rule.String())
+	android.AssertPathRelativeToTopEquals(t, "input", "kernel/include/uapi/linux/types.h", sanitize.Input)
+	android.AssertStringEquals(t, "headers_install", "kernel/scripts/headers_install.sh", sanitize.Args["headersInstall"])
+
+	arch := headers.Output("gen/include/asm/types.h")
+	android.AssertPathRelativeToTopEquals(t, "arch input", "kernel/arch/arm64/include/uapi/asm/types.h", arch.Input)
+
+	wrapper := headers.Output("gen/include/asm/errno.h")
+	android.AssertStringEquals(t, "wrapper", "#include <asm-generic/errno.h>", android.ContentFromFileRuleForTests(t, wrapper))
+
+	cc := result.ModuleForTests("libvendor", "android_vendor.29_arm64_armv8-a_static").Rule("cc")
+	android.AssertStringDoesContain(t, "cFlags", cc.Args["cFlags"], "-isystem "+include)
+
+	// The arm kernel headers define their own asm/errno.h.
+	armHeaders := result.ModuleForTests("device_kernel_headers", "android_vendor.29_arm_armv7-a-neon")
+	armErrno := armHeaders.Output("gen/include/asm/errno.h")
+	android.AssertPathRelativeToTopEquals(t, "arm input", "kernel/arch/arm/include/uapi/asm/errno.h", armErrno.Input)
+}
+
+func TestKernelHeadersPrebuilt(t *testing.T) {
+	t.Parallel()
+	result := prepareForCcTest.RunTestWithBp(t, `
+		kernel_headers {
+			name: "device_kernel_headers",
+			vendor: true,
+			kernel_prebuilt_headers: "prebuilts/kernel-headers",
+		}
+
+		cc_library_static {
+			name: "libvendor",
+			srcs: ["foo.c"],
+			header_libs: ["device_kernel_headers"],
+			vendor: true,
+		}
+	`)
+
+	cc := result.ModuleForTests("libvendor", "android_vendor.29_arm_armv7-a-neon_static").Rule("cc")
+	android.AssertStringDoesContain(t, "cFlags", cc.Args["cFlags"], "-isystem prebuilts/kernel-headers/arm/include")
+}
+
+func TestKernelHeadersSrcAndPrebuilt(t *testing.T) {
+	t.Parallel()
+	prepareForCcTest.ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`kernel_prebuilt_headers: cannot be set with kernel_src`)).
+		RunTestWithBp(t, `
+			kernel_headers {
+				name: "device_kernel_headers",
+				vendor: true,
+				kernel_src: "kernel",
+				kernel_prebuilt_headers: "prebuilts/kernel-headers",
+			}
+		`)
+}
@@ -1260,9 +1260,9 @@ func (library *libraryDecorator) compile(ctx ModuleContext, flags Flags, deps Pa
 		if library.stubsVersion() != "" {
 			vndkVer = library.stubsVersion()
 		}
-		nativeAbiResult := parseNativeAbiDefinition(ctx,
-			String(library.Properties.Llndk.Symbol_file),
-			android.ApiLevelOrPanic(ctx, vndkVer), "--llndk")
+		symbolFile := String(library.Properties.Llndk.Symbol_file)
+		nativeAbiResult := parseNativeAbiDefinition(ctx, symbolFile,
+			android.ApiLevelOrPanic(ctx, vndkVer), "--llndk", lintSymbolFile(ctx, symbolFile))
 		objs := compileStubLibrary(ctx, flags, nativeAbiResult.stubSrc)
 		if !Bool(library.Properties.Llndk.Unversioned) {
 			library.versionScriptPath = android.OptionalPathForPath(
@@ -1305,8 +1305,20 @@ func (library *libraryDecorator) compile(ctx ModuleContext, flags Flags, deps Pa
 		if !ctx.Module().(*Module).IsNdk(ctx.Config()) {
 			flag = flag + " --no-ndk"
 		}
-		nativeAbiResult := parseNativeAbiDefinition(ctx, symbolFile,
-			android.ApiLevelOrPanic(ctx, library.MutatedProperties.StubsVersion), flag)
+		// The latest stubs variant generates the stub sources of all the versions with a single
+		// rule, and the other stubs variants depend on it for theirs.
+		var stubSrcs map[string]ndkApiOutputs
+		if library.isLatestStubVersion() {
+			stubSrcs = parseNativeAbiDefinitions(ctx, symbolFile, library.allStubsVersions(), flag)
+			ctx.SetProvider(stubSrcsProvider, stubSrcsInfo{stubSrcs})
+		} else if dep := ctx.GetDirectDepWithTag(ctx.ModuleName(), stubSrcsDepTag); dep != nil {
+			stubSrcs = ctx.OtherModuleProvider(dep, stubSrcsProvider).(stubSrcsInfo).outputs
+		}
+		nativeAbiResult, ok := stubSrcs[library.stubsVersion()]
+		if !ok {
+			ctx.ModuleErrorf("missing the stub sources of version %q", library.stubsVersion())
+			return Objects{}
+		}
 		objs := compileStubLibrary(ctx, flags, nativeAbiResult.stubSrc)
 		library.versionScriptPath = android.OptionalPathForPath(
 			nativeAbiResult.versionScript)
@@ -1788,9 +1800,15 @@ func (library *libraryDecorator) linkShared(ctx ModuleContext,
 	linkerDeps = append(linkerDeps, deps.EarlySharedLibsDeps...)
 	linkerDeps = append(linkerDeps, deps.SharedLibsDeps...)
 	linkerDeps = append(linkerDeps, deps.LateSharedLibsDeps...)
+
+	var validations android.Paths
+	validations = append(validations, objs.tidyDepFiles...)
+	if !library.buildStubs() {
+		validations = append(validations, checkElfFile(ctx, outputFile)...)
+	}
 	transformObjToDynamicBinary(ctx, objs.objFiles, sharedLibs,
 		deps.StaticLibs, deps.LateStaticLibs, deps.WholeStaticLibs,
-		linkerDeps, deps.CrtBegin, deps.CrtEnd, false, builderFlags, outputFile, implicitOutputs, objs.tidyDepFiles)
+		linkerDeps, deps.CrtBegin, deps.CrtEnd, false, builderFlags, outputFile, implicitOutputs, validations)
 
 	objs.coverageFiles = append(objs.coverageFiles, deps.StaticLibObjs.coverageFiles...)
 	objs.coverageFiles = append(objs.coverageFiles, deps.WholeStaticLibObjs.coverageFiles...)
@@ -1986,11 +2004,21 @@ func (library *libraryDecorator) linkSAbiDumpFiles(ctx ModuleContext, objs Objec
 		}
 		exportedHeaderFlags := strings.Join(SourceAbiFlags, " ")
 		headerAbiChecker := library.getHeaderAbiCheckerProperties(ctx)
+		symbolFile := android.OptionalPathForModuleSrc(ctx, library.symbolFileForAbiCheck(ctx))
 		library.sAbiOutputFile = transformDumpToLinkedDump(ctx, objs.sAbiDumpFiles, soFile, fileName, exportedHeaderFlags,
-			android.OptionalPathForModuleSrc(ctx, library.symbolFileForAbiCheck(ctx)),
+			symbolFile,
 			headerAbiChecker.Exclude_symbol_versions,
 			headerAbiChecker.Exclude_symbol_tags)
 
+		ctx.SetProvider(SourceAbiDumpInfoProvider, SourceAbiDumpInfo{
+			LinkedDump:             library.sAbiOutputFile.Path(),
+			Dumps:                  objs.sAbiDumpFiles,
+			ExportedHeaderFlags:    exportedHeaderFlags,
+			SymbolFile:             symbolFile,
+			ExcludedSymbolVersions: headerAbiChecker.Exclude_symbol_versions,
+			ExcludedSymbolTags:     headerAbiChecker.Exclude_symbol_tags,
+		})
+
 		addLsdumpPath(classifySourceAbiDump(ctx) + ":" + library.sAbiOutputFile.String())
 
 		// The logic must be consistent with classifySourceAbiDump.
@@ -2742,6 +2770,10 @@ func createVersionVariations(mctx android.BottomUpMutatorContext, versions []str
 				c.Properties.HideFromMake = true
 				lib.setStubsVersion(variants[i])
 				mctx.AddInterVariantDependency(stubImplDepTag, modules[len(modules)-1], modules[i])
+				// The latest stubs module generates the stub sources of all the versions.
+				if !isLatest {
+					mctx.AddInterVariantDependency(stubSrcsDepTag, modules[i], modules[len(versions)-1])
+				}
 			}
 		}
 	}
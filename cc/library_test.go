@@ -246,6 +246,46 @@ func TestStubsVersions_ParseError(t *testing.T) {
 	testCcError(t, `"libfoo" .*: versions: "X" could not be parsed as an integer and is not a recognized codename`, bp)
 }
 
+func TestStubsVersionsStubSrcs(t *testing.T) {
+	t.Parallel()
+	bp := `
+		cc_library {
+			name: "libfoo",
+			srcs: ["foo.c"],
+			stubs: {
+				symbol_file: "foo.map.txt",
+				versions: ["29", "30"],
+			},
+		}
+	`
+	ctx := testCc(t, bp)
+
+	// The latest stubs variant generates the stub sources of all the versions.
+	latest := ctx.ModuleForTests("libfoo", "android_arm64_armv8-a_shared_current")
+	genStubSrcs := latest.Rule("genStubSrcs")
+	android.AssertStringEquals(t, "apiLevels", "29,30,current", genStubSrcs.Args["apiLevels"])
+	android.AssertStringEquals(t, "outDir", "out/soong/.intermediates/libfoo/android_arm64_armv8-a_shared_current/gen/stubs",
+		genStubSrcs.Args["outDir"])
+
+	stub29 := ctx.ModuleForTests("libfoo", "android_arm64_armv8-a_shared_29")
+	if stub29.MaybeRule("genStubSrcs").Rule != nil || stub29.MaybeRule("genStubSrc").Rule != nil {
+		t.Errorf("expected the stub sources of version 29 to be generated by the latest stubs variant")
+	}
+	android.AssertPathRelativeToTopEquals(t, "stub source of version 29",
+		"out/soong/.intermediates/libfoo/android_arm64_armv8-a_shared_current/gen/stubs/29/stub.c",
+		stub29.Rule("cc").Input)
+
+	impl := ctx.ModuleForTests("libfoo", "android_arm64_armv8-a_shared").Module()
+	stubsInfo := ctx.ModuleProvider(impl, SharedLibraryStubsProvider).(SharedLibraryStubsInfo)
+	android.AssertArrayString(t, "stubs versions", []string{"29", "30", "current"}, stubsInfo.Versions())
+	if _, ok := stubsInfo.StubForVersion("30"); !ok {
+		t.Errorf("expected the stubs of version 30")
+	}
+	if _, ok := stubsInfo.StubForVersion("31"); ok {
+		t.Errorf("expected no stubs of version 31")
+	}
+}
+
 func TestCcLibraryWithBazel(t *testing.T) {
 	t.Parallel()
 	bp := `
@@ -347,6 +347,30 @@ type SharedLibraryInfo struct {
 
 var SharedLibraryInfoProvider = blueprint.NewProvider(SharedLibraryInfo{})
 
+// SharedLibraryDepsInfo is a provider to propagate the direct shared library dependencies of a
+// module, for the singletons that check the shared library graph.
+type SharedLibraryDepsInfo struct {
+	SharedLibraryDeps []android.Module
+}
+
+var SharedLibraryDepsInfoProvider = blueprint.NewProvider(SharedLibraryDepsInfo{})
+
+// SourceAbiDumpInfo is a provider to propagate the sAbi dumps of a shared C++ library built from
+// source, so that copies of the library from prebuilts can be checked against it.
+type SourceAbiDumpInfo struct {
+	// The linked dump of the library.
+	LinkedDump android.Path
+
+	// The dumps of the individual source files and the arguments that were used to link them.
+	Dumps                  android.Paths
+	ExportedHeaderFlags    string
+	SymbolFile             android.OptionalPath
+	ExcludedSymbolVersions []string
+	ExcludedSymbolTags     []string
+}
+
+var SourceAbiDumpInfoProvider = blueprint.NewProvider(SourceAbiDumpInfo{})
+
 // SharedStubLibrary is a struct containing information about a stub shared library.
 // Stub libraries are used for cross-APEX dependencies; when a library is to depend on a shared
 // library in another APEX, it must depend on the stub version of that library.
@@ -368,6 +392,25 @@ type SharedLibraryStubsInfo struct {
 	IsLLNDK bool
 }
 
+// Versions returns the versions of the stubs, from the oldest to the latest.
+func (info SharedLibraryStubsInfo) Versions() []string {
+	versions := make([]string, 0, len(info.SharedStubLibraries))
+	for _, stub := range info.SharedStubLibraries {
+		versions = append(versions, stub.Version)
+	}
+	return versions
+}
+
+// StubForVersion returns the stubs of a version, or false if the library doesn't have stubs for it.
+func (info SharedLibraryStubsInfo) StubForVersion(version string) (SharedStubLibrary, bool) {
+	for _, stub := range info.SharedStubLibraries {
+		if stub.Version == version {
+			return stub, true
+		}
+	}
+	return SharedStubLibrary{}, false
+}
+
 var SharedLibraryStubsProvider = blueprint.NewProvider(SharedLibraryStubsInfo{})
 
 // StaticLibraryInfo is a provider to propagate information about a static C++ library.
@@ -0,0 +1,106 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"android/soong/android"
+)
+
+func init() {
+	android.RegisterSingletonType("ndk_api_coverage", NdkApiCoverageSingleton)
+}
+
+// The known problems of the NDK symbol files, which do not fail the coverage check.
+const ndkApiCoverageBaseline = "prebuilts/abi-dumps/ndk/api_coverage_baseline.txt"
+
+func getNdkApiCoverageReportFile(ctx android.PathContext) android.WritablePath {
+	return android.PathForOutput(ctx, "ndk_api_coverage.txt")
+}
+
+func NdkApiCoverageSingleton() android.Singleton {
+	return &ndkApiCoverageSingleton{}
+}
+
+type ndkApiCoverageSingleton struct{}
+
+// isNdkImplementationForCoverage returns true if the module is the variant of an implementation
+// library of the NDK whose exported symbols are compared with its symbol file.
+func isNdkImplementationForCoverage(ctx android.SingletonContext, m *Module) bool {
+	if m.library == nil || !m.library.shared() || m.IsStubs() || m.isCoverageVariant() || m.IsSdkVariant() {
+		return false
+	}
+	if m.InVendor() || m.InProduct() || m.InRamdisk() || m.InVendorRamdisk() || m.InRecovery() {
+		return false
+	}
+	target := m.Target()
+	if target.Os != android.Android || target.NativeBridge != android.NativeBridgeDisabled ||
+		target.Arch.ArchType != ctx.Config().DevicePrimaryArchType() {
+		return false
+	}
+	return ctx.ModuleProvider(m, android.ApexInfoProvider).(android.ApexInfo).IsForPlatform()
+}
+
+func (n *ndkApiCoverageSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	symbolFiles := make(map[string]android.Path)
+	tocs := make(map[string]android.Path)
+	ctx.VisitAllModules(func(module android.Module) {
+		if !module.Enabled() {
+			return
+		}
+
+		if m, ok := module.(*Module); ok {
+			if installer, ok := m.installer.(*stubDecorator); ok {
+				if installer.symbolFilePath != nil {
+					name := installer.implementationModuleName(ctx.ModuleName(m))
+					symbolFiles[name] = installer.symbolFilePath
+				}
+			} else if isNdkImplementationForCoverage(ctx, m) {
+				info := ctx.ModuleProvider(m, SharedLibraryInfoProvider).(SharedLibraryInfo)
+				if info.TableOfContents.Valid() {
+					tocs[ctx.ModuleName(m)] = info.TableOfContents.Path()
+				}
+			}
+		}
+	})
+
+	if len(symbolFiles) == 0 {
+		return
+	}
+
+	report := getNdkApiCoverageReportFile(ctx)
+	apiLevelsJson := android.GetApiLevelsJson(ctx)
+	rule := android.NewRuleBuilder(pctx, ctx)
+	cmd := rule.Command().
+		BuiltTool("ndk_api_coverage_report").
+		FlagWithInput("--api-map ", apiLevelsJson).
+		FlagWithArg("--arch ", ctx.Config().DevicePrimaryArchType().Name).
+		FlagWithOutput("--out ", report)
+	if baseline := android.ExistentPathForSource(ctx, ndkApiCoverageBaseline); baseline.Valid() {
+		cmd.FlagWithInput("--baseline ", baseline.Path())
+	}
+	for _, name := range android.SortedKeys(symbolFiles) {
+		toc, ok := tocs[name]
+		if !ok {
+			continue
+		}
+		cmd.Text(name + ":" + symbolFiles[name].String() + ":" + toc.String()).
+			Implicits(android.Paths{symbolFiles[name], toc})
+	}
+	rule.Build("ndk_api_coverage", "NDK API coverage")
+
+	// `m ndk-api-coverage` will report the coverage of the NDK symbol files and fail if there are
+	// problems that are not in the baseline.
+	ctx.Phony("ndk-api-coverage", report)
+}
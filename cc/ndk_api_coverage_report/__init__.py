@@ -0,0 +1,199 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Reports the coverage of NDK symbol files by their implementation libraries.
+
+For every NDK library the report lists the number of symbols per API level and
+the problems found:
+
+  missing      the symbol is in the symbol file but is not exported by the
+               implementation library.
+  unannotated  the symbol has no introduced tag in a symbol file that
+               otherwise uses them, so it claims to be available at every API
+               level.
+
+Problems that are not listed in the baseline file are regressions and fail the
+check.
+"""
+import argparse
+import collections
+import json
+import sys
+from typing import Dict, Iterable, List, Set, Tuple
+
+from symbolfile import (
+    Arch,
+    Filter,
+    FUTURE_API_LEVEL,
+    MultiplyDefinedSymbolError,
+    SymbolFileParser,
+    Tags,
+    Version,
+    get_tag_value,
+)
+
+Problem = Tuple[str, str, str]
+
+
+def introduced_level(tags: Tags, arch: Arch) -> int:
+    """Returns the API level in which a symbol was introduced, or 0 if there
+    is no introduced tag."""
+    level = 0
+    for tag in tags:
+        if tag == 'future':
+            return FUTURE_API_LEVEL
+        if tag.startswith('introduced-' + arch + '='):
+            return int(get_tag_value(tag))
+        if tag.startswith('introduced='):
+            level = int(get_tag_value(tag))
+    return level
+
+
+def declared_symbols(versions: Iterable[Version], arch: Arch) -> Dict[str, int]:
+    """Returns a map from the public symbols in the versions to the API level
+    in which they were introduced."""
+    filt = Filter(arch, FUTURE_API_LEVEL)
+    symbols = {}
+    for version in versions:
+        if filt.should_omit_version(version):
+            continue
+        version_level = introduced_level(version.tags, arch)
+        for symbol in version.symbols:
+            if filt.should_omit_symbol(symbol):
+                continue
+            level = introduced_level(symbol.tags, arch)
+            symbols[symbol.name] = level or version_level
+    return symbols
+
+
+def exported_symbols(toc_lines: Iterable[str]) -> Set[str]:
+    """Returns the symbols defined by a library from its table of contents.
+
+    The table of contents lists the dynamic symbols with the value and size
+    columns removed, e.g. "12:  FUNC GLOBAL DEFAULT 9 malloc@@LIBC".
+    """
+    symbols = set()
+    for line in toc_lines:
+        fields = line.split()
+        if len(fields) < 6 or not fields[0].rstrip(':').isdigit():
+            continue
+        if fields[-2] == 'UND':
+            continue
+        symbols.add(fields[-1].split('@')[0])
+    return symbols
+
+
+def check_library(name: str, declared: Dict[str, int],
+                  exported: Set[str]) -> Tuple[List[str], List[Problem]]:
+    """Returns the report lines and the problems for a library."""
+    lines = [name + ':']
+    by_level = collections.Counter(declared.values())
+    for level in sorted(by_level):
+        if level == 0:
+            label = 'unversioned'
+        elif level == FUTURE_API_LEVEL:
+            label = 'future'
+        else:
+            label = 'API %d' % level
+        lines.append('  %s: %d symbols' % (label, by_level[level]))
+
+    problems = []
+    for symbol in sorted(declared):
+        if declared[symbol] != FUTURE_API_LEVEL and symbol not in exported:
+            problems.append(('missing', name, symbol))
+    if any(level != 0 for level in declared.values()):
+        for symbol in sorted(declared):
+            if declared[symbol] == 0:
+                problems.append(('unannotated', name, symbol))
+    return lines, problems
+
+
+def read_baseline(path: str) -> Set[Problem]:
+    baseline = set()
+    if not path:
+        return baseline
+    with open(path) as f:
+        for line in f:
+            line = line.partition('#')[0].strip()
+            if line:
+                kind, lib, symbol = line.split()
+                baseline.add((kind, lib, symbol))
+    return baseline
+
+
+def parse_args():
+    """Parses and returns command line arguments."""
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument('--api-map', required=True,
+                        help='Path to the API level map JSON file.')
+    parser.add_argument('--arch', required=True,
+                        help='Architecture of the implementation libraries.')
+    parser.add_argument('--baseline', default='',
+                        help='Path to the file listing the known problems.')
+    parser.add_argument('--out', required=True,
+                        help='Path to the report to write.')
+    parser.add_argument(
+        'libraries', nargs='*', metavar='NAME:SYMBOL_FILE:TOC',
+        help='An NDK library, its symbol file and the table of contents of '
+        'its implementation.')
+    return parser.parse_args()
+
+
+def main():
+    """Program entry point."""
+    args = parse_args()
+
+    with open(args.api_map) as map_file:
+        api_map = json.load(map_file)
+
+    report = []
+    problems = []
+    for library in args.libraries:
+        name, symbol_file_path, toc_path = library.split(':')
+        with open(symbol_file_path) as symbol_file:
+            try:
+                filt = Filter(Arch(args.arch), FUTURE_API_LEVEL)
+                versions = SymbolFileParser(symbol_file, api_map, filt).parse()
+            except MultiplyDefinedSymbolError as ex:
+                sys.exit('{}: error: {}'.format(symbol_file_path, ex))
+        with open(toc_path) as toc:
+            exported = exported_symbols(toc)
+        lines, library_problems = check_library(
+            name, declared_symbols(versions, Arch(args.arch)), exported)
+        report.extend(lines)
+        problems.extend(library_problems)
+
+    baseline = read_baseline(args.baseline)
+    regressions = [p for p in problems if p not in baseline]
+    report.append('')
+    report.append('Problems:')
+    report.extend('  %s %s %s' % p for p in problems)
+    with open(args.out, 'w') as out:
+        out.write('\n'.join(report) + '\n')
+
+    if regressions:
+        print('error: NDK API coverage regressions found, see %s:' % args.out,
+              file=sys.stderr)
+        for problem in regressions:
+            print('  %s %s %s' % problem, file=sys.stderr)
+        if args.baseline:
+            print('If they are expected, add them to %s.' % args.baseline,
+                  file=sys.stderr)
+        sys.exit(1)
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,108 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Tests for ndk_api_coverage_report."""
+import io
+import textwrap
+import unittest
+
+from symbolfile import Arch, Filter, FUTURE_API_LEVEL, SymbolFileParser
+import ndk_api_coverage_report as report
+
+
+def parse(symbol_file):
+    filt = Filter(Arch('arm64'), FUTURE_API_LEVEL)
+    return SymbolFileParser(
+        io.StringIO(textwrap.dedent(symbol_file)), {}, filt).parse()
+
+
+class DeclaredSymbolsTest(unittest.TestCase):
+    def test_levels(self):
+        versions = parse("""\
+            LIBFOO {
+              global:
+                foo;
+                bar; # introduced=29
+                baz; # introduced=29 introduced-arm64=30
+                qux; # future
+                arm_only; # arm
+            };
+            LIBFOO_R { # introduced=30
+              global:
+                r;
+            } LIBFOO;
+            LIBFOO_PRIVATE {
+              global:
+                private;
+            };
+        """)
+        self.assertEqual(
+            report.declared_symbols(versions, Arch('arm64')), {
+                'foo': 0,
+                'bar': 29,
+                'baz': 30,
+                'qux': FUTURE_API_LEVEL,
+                'r': 30,
+            })
+
+
+class ExportedSymbolsTest(unittest.TestCase):
+    def test_toc(self):
+        toc = textwrap.dedent("""\
+            0x000000000000000e (SONAME) Library soname: [libfoo.so]
+
+            Symbol table '.dynsym' contains 4 entries:
+               Num:    Type    Bind   Vis       Ndx Name
+                 0:   NOTYPE  LOCAL  DEFAULT   UND
+                 1:   FUNC    GLOBAL DEFAULT   UND malloc@LIBC
+                 2:   FUNC    GLOBAL DEFAULT    12 foo@@LIBFOO
+                 3:   OBJECT  GLOBAL DEFAULT    20 bar
+        """).splitlines()
+        self.assertEqual(report.exported_symbols(toc), {'foo', 'bar'})
+
+
+class CheckLibraryTest(unittest.TestCase):
+    def test_problems(self):
+        lines, problems = report.check_library(
+            'libfoo', {
+                'foo': 0,
+                'bar': 29,
+                'baz': 29,
+                'qux': FUTURE_API_LEVEL,
+            }, {'foo', 'bar'})
+        self.assertEqual(lines, [
+            'libfoo:',
+            '  unversioned: 1 symbols',
+            '  API 29: 2 symbols',
+            '  future: 1 symbols',
+        ])
+        self.assertEqual(problems, [
+            ('missing', 'libfoo', 'baz'),
+            ('unannotated', 'libfoo', 'foo'),
+        ])
+
+    def test_unversioned_library(self):
+        _, problems = report.check_library('libfoo', {'foo': 0}, {'foo'})
+        self.assertEqual(problems, [])
+
+
+def main():
+    suite = unittest.TestLoader().loadTestsFromName(__name__)
+    unittest.TextTestRunner(verbosity=3).run(suite)
+
+
+if __name__ == '__main__':
+    main()
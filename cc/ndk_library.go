@@ -31,6 +31,7 @@ import (
 
 func init() {
 	pctx.HostBinToolVariable("ndkStubGenerator", "ndkstubgen")
+	pctx.HostBinToolVariable("symbolFileLint", "symbolfilelint")
 	pctx.HostBinToolVariable("abidiff", "abidiff")
 	pctx.HostBinToolVariable("abitidy", "abitidy")
 	pctx.HostBinToolVariable("abidw", "abidw")
@@ -44,6 +45,22 @@ var (
 			CommandDeps: []string{"$ndkStubGenerator"},
 		}, "arch", "apiLevel", "apiMap", "flags")
 
+	// Generates the stub sources of all the versions of a stubs library at once.
+	genStubSrcs = pctx.AndroidStaticRule("genStubSrcs",
+		blueprint.RuleParams{
+			Command: "rm -rf $outDir && $ndkStubGenerator --arch $arch --apis $apiLevels " +
+				"--out-dir $outDir --api-map $apiMap $flags $in",
+			CommandDeps: []string{"$ndkStubGenerator"},
+		}, "arch", "apiLevels", "outDir", "apiMap", "flags")
+
+	// Lints a symbol file before the stubs are generated from it. With --update, the errors that
+	// can be fixed are fixed in the symbol file itself.
+	lintSymbolFileRule = pctx.AndroidStaticRule("lintSymbolFile",
+		blueprint.RuleParams{
+			Command:     "$symbolFileLint --api-map $apiMap --fixed $fixed -o $out $in",
+			CommandDeps: []string{"$symbolFileLint"},
+		}, "apiMap", "fixed")
+
 	abidw = pctx.AndroidStaticRule("abidw",
 		blueprint.RuleParams{
 			Command: "$abidw --type-id-style hash --no-corpus-path " +
@@ -124,6 +141,7 @@ type stubDecorator struct {
 
 	versionScriptPath     android.ModuleGenPath
 	parsedCoverageXmlPath android.ModuleOutPath
+	symbolFilePath        android.Path
 	installPath           android.Path
 	abiDumpPath           android.OutputPath
 	abiDiffPaths          android.Paths
@@ -258,8 +276,41 @@ type ndkApiOutputs struct {
 	symbolList    android.ModuleGenPath
 }
 
+// stubSrcsInfo is provided by the latest stubs variant of a library to its other stubs variants,
+// with the generated stub sources of each of the versions.
+type stubSrcsInfo struct {
+	outputs map[string]ndkApiOutputs
+}
+
+var stubSrcsProvider = blueprint.NewProvider(stubSrcsInfo{})
+
+// lintSymbolFile warns about the symbols of an NDK or LLNDK symbol file that aren't sorted, are
+// duplicated or aren't tagged with the API levels that introduced them. The lint writes a copy of
+// the symbol file with the fixable problems fixed to the output directory of the module, and
+// prints the command that copies it over the symbol file. It returns the file to build before
+// generating the stubs.
+func lintSymbolFile(ctx ModuleContext, symbolFile string) android.Path {
+	symbolFilePath := android.PathForModuleSrc(ctx, symbolFile)
+	lintPath := android.PathForModuleOut(ctx, "symbol_file_lint.timestamp")
+	fixedPath := android.PathForModuleOut(ctx, "symbol_file_lint", symbolFilePath.Base())
+	apiLevelsJson := android.GetApiLevelsJson(ctx)
+	ctx.Build(pctx, android.BuildParams{
+		Rule:           lintSymbolFileRule,
+		Description:    "lint symbol file " + symbolFilePath.Rel(),
+		Output:         lintPath,
+		ImplicitOutput: fixedPath,
+		Input:          symbolFilePath,
+		Implicit:       apiLevelsJson,
+		Args: map[string]string{
+			"apiMap": apiLevelsJson.String(),
+			"fixed":  fixedPath.String(),
+		},
+	})
+	return lintPath
+}
+
 func parseNativeAbiDefinition(ctx ModuleContext, symbolFile string,
-	apiLevel android.ApiLevel, genstubFlags string) ndkApiOutputs {
+	apiLevel android.ApiLevel, genstubFlags string, deps ...android.Path) ndkApiOutputs {
 
 	stubSrcPath := android.PathForModuleGen(ctx, "stub.c")
 	versionScriptPath := android.PathForModuleGen(ctx, "stub.map")
@@ -272,7 +323,7 @@ func parseNativeAbiDefinition(ctx ModuleContext, symbolFile string,
 		Outputs: []android.WritablePath{stubSrcPath, versionScriptPath,
 			symbolListPath},
 		Input:     symbolFilePath,
-		Implicits: []android.Path{apiLevelsJson},
+		Implicits: append([]android.Path{apiLevelsJson}, deps...),
 		Args: map[string]string{
 			"arch":     ctx.Arch().ArchType.String(),
 			"apiLevel": apiLevel.String(),
@@ -288,6 +339,44 @@ func parseNativeAbiDefinition(ctx ModuleContext, symbolFile string,
 	}
 }
 
+// parseNativeAbiDefinitions generates the stub sources of all the versions of a stubs library with
+// a single rule, instead of a rule per version as parseNativeAbiDefinition.
+func parseNativeAbiDefinitions(ctx ModuleContext, symbolFile string, versions []string,
+	genstubFlags string) map[string]ndkApiOutputs {
+
+	symbolFilePath := android.PathForModuleSrc(ctx, symbolFile)
+	apiLevelsJson := android.GetApiLevelsJson(ctx)
+	outDir := android.PathForModuleGen(ctx, "stubs")
+
+	ret := make(map[string]ndkApiOutputs)
+	var outputs android.WritablePaths
+	for _, version := range versions {
+		apiOutputs := ndkApiOutputs{
+			stubSrc:       android.PathForModuleGen(ctx, "stubs", version, "stub.c"),
+			versionScript: android.PathForModuleGen(ctx, "stubs", version, "stub.map"),
+			symbolList:    android.PathForModuleGen(ctx, "stubs", version, "abi_symbol_list.txt"),
+		}
+		ret[version] = apiOutputs
+		outputs = append(outputs, apiOutputs.stubSrc, apiOutputs.versionScript, apiOutputs.symbolList)
+	}
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        genStubSrcs,
+		Description: "generate stubs " + symbolFilePath.Rel(),
+		Outputs:     outputs,
+		Input:       symbolFilePath,
+		Implicit:    apiLevelsJson,
+		Args: map[string]string{
+			"arch":      ctx.Arch().ArchType.String(),
+			"apiLevels": strings.Join(versions, ","),
+			"outDir":    outDir.String(),
+			"apiMap":    apiLevelsJson.String(),
+			"flags":     genstubFlags,
+		},
+	})
+	return ret
+}
+
 func compileStubLibrary(ctx ModuleContext, flags Flags, src android.Path) Objects {
 	// libc/libm stubs libraries end up mismatching with clang's internal definition of these
 	// functions (which have noreturn attributes and other things). Because we just want to create a
@@ -482,7 +571,8 @@ func (c *stubDecorator) compile(ctx ModuleContext, flags Flags, deps PathDeps) O
 	}
 
 	symbolFile := String(c.properties.Symbol_file)
-	nativeAbiResult := parseNativeAbiDefinition(ctx, symbolFile, c.apiLevel, "")
+	nativeAbiResult := parseNativeAbiDefinition(ctx, symbolFile, c.apiLevel, "",
+		lintSymbolFile(ctx, symbolFile))
 	objs := compileStubLibrary(ctx, flags, nativeAbiResult.stubSrc)
 	c.versionScriptPath = nativeAbiResult.versionScript
 	if canDumpAbi(ctx.Config()) {
@@ -493,6 +583,7 @@ func (c *stubDecorator) compile(ctx ModuleContext, flags Flags, deps PathDeps) O
 	}
 	if c.apiLevel.IsCurrent() && ctx.PrimaryArch() {
 		c.parsedCoverageXmlPath = parseSymbolFileForAPICoverage(ctx, symbolFile)
+		c.symbolFilePath = android.PathForModuleSrc(ctx, symbolFile)
 	}
 	return objs
 }
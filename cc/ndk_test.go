@@ -54,3 +54,32 @@ func TestNdkHeaderDependency(t *testing.T) {
 	libfoo_headers := ctx.ModuleForTests("libfoo_headers", "")
 	android.AssertBoolEquals(t, "Could not find headers of ndk_library", true, isDep(ctx, libfoo.Module(), libfoo_headers.Module()))
 }
+
+func TestNdkApiCoverage(t *testing.T) {
+	bp := `
+	ndk_library {
+		name: "libfoo",
+		first_version: "29",
+		symbol_file: "libfoo.map.txt",
+	}
+	cc_library {
+		name: "libfoo",
+	}
+	`
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterSingletonType("ndk_api_coverage", NdkApiCoverageSingleton)
+		}),
+	).RunTestWithBp(t, bp)
+
+	rule := result.SingletonForTests("ndk_api_coverage").Rule("ndk_api_coverage")
+	toc := "out/soong/.intermediates/libfoo/android_arm64_armv8-a_shared/libfoo.so.toc"
+	android.AssertStringDoesContain(t, "arch", rule.RuleParams.Command, "--arch arm64")
+	android.AssertStringDoesContain(t, "command", android.StringRelativeToTop(result.Config, rule.RuleParams.Command),
+		"libfoo:libfoo.map.txt:"+toc)
+	inputs := android.PathsRelativeToTop(rule.Implicits)
+	android.AssertStringListContains(t, "inputs", inputs, "out/soong/api_levels.json")
+	android.AssertStringListContains(t, "inputs", inputs, "libfoo.map.txt")
+	android.AssertStringListContains(t, "inputs", inputs, toc)
+}
@@ -16,6 +16,7 @@
 #
 """Generates source for stub shared libraries for the NDK."""
 import argparse
+import io
 import json
 import logging
 from pathlib import Path
@@ -95,8 +96,16 @@ def parse_args() -> argparse.Namespace:
 
     parser.add_argument('-v', '--verbose', action='count', default=0)
 
+    parser.add_argument('--api', help='API level being targeted.')
     parser.add_argument(
-        '--api', required=True, help='API level being targeted.')
+        '--apis',
+        help='Comma separated API levels being targeted, to generate the '
+        'stubs of all of them at once into --out-dir.')
+    parser.add_argument(
+        '--out-dir',
+        type=resolved_path,
+        help='Directory of the outputs of each of the --apis, in '
+        '<api>/stub.c, <api>/stub.map and <api>/abi_symbol_list.txt.')
     parser.add_argument(
         '--arch', choices=symbolfile.ALL_ARCHITECTURES, required=True,
         help='Architecture being targeted.')
@@ -127,15 +136,44 @@ def parse_args() -> argparse.Namespace:
                         help='Path to symbol file.')
     parser.add_argument('stub_src',
                         type=resolved_path,
+                        nargs='?',
                         help='Path to output stub source file.')
     parser.add_argument('version_script',
                         type=resolved_path,
+                        nargs='?',
                         help='Path to output version script.')
     parser.add_argument('symbol_list',
                         type=resolved_path,
+                        nargs='?',
                         help='Path to output abigail symbol list.')
 
-    return parser.parse_args()
+    args = parser.parse_args()
+    if args.apis is not None:
+        if args.api is not None or args.stub_src is not None:
+            parser.error('--apis can\'t be used with --api or the output paths')
+        if args.out_dir is None:
+            parser.error('--apis requires --out-dir')
+    elif args.api is None or args.symbol_list is None:
+        parser.error('--api and the output paths are required without --apis')
+    return args
+
+
+def generate(symbol_file_path: Path, symbol_file: str, api_map: symbolfile.ApiMap,
+             filt: symbolfile.Filter, stub_src: Path, version_script: Path,
+             symbol_list: Path) -> None:
+    """Writes the stub source files and version scripts of one API level."""
+    try:
+        versions = symbolfile.SymbolFileParser(io.StringIO(symbol_file),
+                                               api_map, filt).parse()
+    except symbolfile.MultiplyDefinedSymbolError as ex:
+        sys.exit(f'{symbol_file_path}: error: {ex}')
+
+    with stub_src.open('w') as src_file:
+        with version_script.open('w') as version_script_file:
+            with symbol_list.open('w') as symbol_list_file:
+                generator = Generator(src_file, version_script_file,
+                                      symbol_list_file, filt)
+                generator.write(versions)
 
 
 def main() -> None:
@@ -144,7 +182,6 @@ def main() -> None:
 
     with args.api_map.open() as map_file:
         api_map = json.load(map_file)
-    api = symbolfile.decode_api_level(args.api, api_map)
 
     verbose_map = (logging.WARNING, logging.INFO, logging.DEBUG)
     verbosity = args.verbose
@@ -152,19 +189,27 @@ def main() -> None:
         verbosity = 2
     logging.basicConfig(level=verbose_map[verbosity])
 
-    filt = symbolfile.Filter(args.arch, api, args.llndk, args.apex, args.systemapi, args.ndk)
-    with args.symbol_file.open() as symbol_file:
-        try:
-          versions = symbolfile.SymbolFileParser(symbol_file, api_map, filt).parse()
-        except symbolfile.MultiplyDefinedSymbolError as ex:
-            sys.exit(f'{args.symbol_file}: error: {ex}')
-
-    with args.stub_src.open('w') as src_file:
-        with args.version_script.open('w') as version_script:
-            with args.symbol_list.open('w') as symbol_list:
-                generator = Generator(src_file, version_script, symbol_list,
-                                      filt)
-                generator.write(versions)
+    symbol_file = args.symbol_file.read_text()
+
+    if args.apis is None:
+        api = symbolfile.decode_api_level(args.api, api_map)
+        filt = symbolfile.Filter(args.arch, api, args.llndk, args.apex,
+                                 args.systemapi, args.ndk)
+        generate(args.symbol_file, symbol_file, api_map, filt, args.stub_src,
+                 args.version_script, args.symbol_list)
+        return
+
+    # All the API levels are generated by a single process so that a library
+    # with many stub versions doesn't pay for the startup of one per version.
+    for api_str in args.apis.split(','):
+        api = symbolfile.decode_api_level(api_str, api_map)
+        filt = symbolfile.Filter(args.arch, api, args.llndk, args.apex,
+                                 args.systemapi, args.ndk)
+        out_dir = args.out_dir / api_str
+        out_dir.mkdir(parents=True, exist_ok=True)
+        generate(args.symbol_file, symbol_file, api_map, filt,
+                 out_dir / 'stub.c', out_dir / 'stub.map',
+                 out_dir / 'abi_symbol_list.txt')
 
 
 if __name__ == '__main__':
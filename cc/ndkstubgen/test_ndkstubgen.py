@@ -16,6 +16,8 @@
 #
 """Tests for ndkstubgen.py."""
 import io
+from pathlib import Path
+import tempfile
 import textwrap
 import unittest
 from copy import copy
@@ -496,6 +498,33 @@ class IntegrationTest(unittest.TestCase):
         self.assertEqual('', src_file.getvalue())
         self.assertEqual('', version_file.getvalue())
 
+    def test_generate_multiple_apis(self) -> None:
+        symbol_file = textwrap.dedent("""\
+            VERSION_1 {
+                global:
+                    foo;
+                    bar; # introduced=14
+                local:
+                    *;
+            };
+        """)
+        with tempfile.TemporaryDirectory() as tmp_dir:
+            out_dir = Path(tmp_dir)
+            for api in (9, 14):
+                api_dir = out_dir / str(api)
+                api_dir.mkdir()
+                f = copy(self.filter)
+                f.api = api
+                ndkstubgen.generate(Path('libfoo.map.txt'), symbol_file, {},
+                                    f, api_dir / 'stub.c',
+                                    api_dir / 'stub.map',
+                                    api_dir / 'abi_symbol_list.txt')
+
+            self.assertEqual('void foo() {}\n',
+                             (out_dir / '9' / 'stub.c').read_text())
+            self.assertEqual('void foo() {}\nvoid bar() {}\n',
+                             (out_dir / '14' / 'stub.c').read_text())
+
 
 def main() -> None:
     suite = unittest.TestLoader().loadTestsFromName(__name__)
@@ -44,6 +44,10 @@ type prebuiltLinkerProperties struct {
 	// a prebuilt library or binary. Can reference a genrule module that generates an executable file.
 	Srcs []string `android:"path,arch_variant"`
 
+	// The sha256 of the prebuilt file for the target. If set, the build fails if the prebuilt file
+	// does not match.
+	Sha256 *string `android:"arch_variant"`
+
 	Sanitized Sanitized `android:"arch_variant"`
 
 	// Check the prebuilt ELF files (e.g. DT_SONAME, DT_NEEDED, resolution of undefined
@@ -130,7 +134,8 @@ func (p *prebuiltLibraryLinker) link(ctx ModuleContext,
 
 		p.libraryDecorator.exportVersioningMacroIfNeeded(ctx)
 
-		in := android.PathForModuleSrc(ctx, srcs[0])
+		in := android.CheckPrebuiltSha256(ctx, android.PathsForModuleSrc(ctx, srcs[:1]),
+			p.prebuiltLinker.properties.Sha256)[0]
 
 		if String(p.prebuiltLinker.properties.Prefix_symbols) != "" {
 			prefixed := android.PathForModuleOut(ctx, "prefixed", srcs[0])
@@ -426,6 +431,10 @@ func prebuiltLibrarySharedBp2Build(ctx android.TopDownMutatorContext, module *Mo
 
 type prebuiltObjectProperties struct {
 	Srcs []string `android:"path,arch_variant"`
+
+	// The sha256 of the prebuilt object for the target. If set, the build fails if the prebuilt
+	// object does not match.
+	Sha256 *string `android:"arch_variant"`
 }
 
 type prebuiltObjectLinker struct {
@@ -574,7 +583,8 @@ func (p *prebuiltObjectLinker) link(ctx ModuleContext,
 	flags Flags, deps PathDeps, objs Objects) android.Path {
 	if len(p.properties.Srcs) > 0 {
 		// Copy objects to a name matching the final installed name
-		in := p.Prebuilt.SingleSourcePath(ctx)
+		in := android.CheckPrebuiltSha256(ctx, android.Paths{p.Prebuilt.SingleSourcePath(ctx)},
+			p.properties.Sha256)[0]
 		outputFile := android.PathForModuleOut(ctx, ctx.ModuleName()+".o")
 		ctx.Build(pctx, android.BuildParams{
 			Rule:        android.CpExecutable,
@@ -677,7 +687,8 @@ func (p *prebuiltBinaryLinker) link(ctx ModuleContext,
 	// TODO(ccross): verify shared library dependencies
 	if len(p.properties.Srcs) > 0 {
 		fileName := p.getStem(ctx) + flags.Toolchain.ExecutableSuffix()
-		in := p.Prebuilt.SingleSourcePath(ctx)
+		in := android.CheckPrebuiltSha256(ctx, android.Paths{p.Prebuilt.SingleSourcePath(ctx)},
+			p.prebuiltLinker.properties.Sha256)[0]
 		outputFile := android.PathForModuleOut(ctx, fileName)
 		p.unstrippedOutputFile = in
 
@@ -233,6 +233,46 @@ func TestPrebuiltLibrary(t *testing.T) {
 	assertString(t, static.OutputFile().Path().Base(), "libf.a")
 }
 
+func TestPrebuiltLibrarySha256(t *testing.T) {
+	sha256 := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	ctx := testPrebuilt(t, `
+	cc_prebuilt_library_shared {
+		name: "libtest",
+		srcs: ["libf.so"],
+		sha256: "`+sha256+`",
+		strip: {
+			none: true,
+		},
+	}
+	`, map[string][]byte{
+		"libf.so": nil,
+	})
+
+	shared := ctx.ModuleForTests("libtest", "android_arm64_armv8-a_shared")
+	check := shared.Output("sha256/libf.so")
+	android.AssertPathRelativeToTopEquals(t, "check input", "libf.so", check.Input)
+	android.AssertStringEquals(t, "check sha256", sha256, check.Args["sha256"])
+
+	// The checked copy of the prebuilt is used instead of the prebuilt itself.
+	android.AssertPathRelativeToTopEquals(t, "library input", check.Output.String(),
+		shared.Output("libtest.so").Input)
+}
+
+func TestPrebuiltLibraryInvalidSha256(t *testing.T) {
+	android.GroupFixturePreparers(
+		prepareForPrebuiltTest,
+		android.FixtureAddFile("libf.so", nil),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`sha256: must be 64 hexadecimal digits, was "1234"`)).
+		RunTestWithBp(t, `
+		cc_prebuilt_library_shared {
+			name: "libtest",
+			srcs: ["libf.so"],
+			sha256: "1234",
+		}
+		`)
+}
+
 func TestPrebuiltLibraryStem(t *testing.T) {
 	ctx := testPrebuilt(t, `
 	cc_prebuilt_library {
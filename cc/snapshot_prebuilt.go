@@ -399,6 +399,24 @@ func vendorSnapshotLoadHook(ctx android.LoadHookContext, p *BaseSnapshotDecorato
 	}
 }
 
+// snapshotSrcPath returns the prebuilt file of a snapshot module. If the snapshot stores its
+// prebuilts by their content, src refers to the stored object and srcName is the name of the
+// prebuilt, so the object is copied to a file with that name.
+func snapshotSrcPath(ctx ModuleContext, src string, srcName *string) android.Path {
+	in := android.PathForModuleSrc(ctx, src)
+	if String(srcName) == "" {
+		return in
+	}
+	out := android.PathForModuleOut(ctx, "prebuilt", *srcName)
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        android.Cp,
+		Description: "snapshot prebuilt " + *srcName,
+		Output:      out,
+		Input:       in,
+	})
+	return out
+}
+
 // Module definitions for snapshots of libraries (shared, static, header).
 //
 // Modules (vendor|recovery)_snapshot_(shared|static|header) are defined here. Shared libraries and
@@ -411,6 +429,9 @@ type SnapshotLibraryProperties struct {
 	// Prebuilt file for each arch.
 	Src *string `android:"arch_variant"`
 
+	// Name of the prebuilt file, if src refers to a prebuilt stored by its content.
+	Src_name *string `android:"arch_variant"`
+
 	// list of directories that will be added to the include path (using -I).
 	Export_include_dirs []string `android:"arch_variant"`
 
@@ -506,7 +527,7 @@ func (p *snapshotLibraryDecorator) link(ctx ModuleContext, flags Flags, deps Pat
 	p.libraryDecorator.reexportDeps(deps.ReexportedDeps...)
 	p.libraryDecorator.addExportedGeneratedHeaders(deps.ReexportedGeneratedHeaders...)
 
-	in := android.PathForModuleSrc(ctx, *p.properties.Src)
+	in := snapshotSrcPath(ctx, *p.properties.Src, p.properties.Src_name)
 	p.unstrippedOutputFile = in
 
 	if p.shared() {
@@ -713,6 +734,9 @@ func RamdiskSnapshotHeaderFactory() android.Module {
 type snapshotBinaryProperties struct {
 	// Prebuilt file for each arch.
 	Src *string `android:"arch_variant"`
+
+	// Name of the prebuilt file, if src refers to a prebuilt stored by its content.
+	Src_name *string `android:"arch_variant"`
 }
 
 type snapshotBinaryDecorator struct {
@@ -743,6 +767,9 @@ func (p *snapshotBinaryDecorator) link(ctx ModuleContext, flags Flags, deps Path
 	in := android.PathForModuleSrc(ctx, *p.properties.Src)
 	p.unstrippedOutputFile = in
 	binName := in.Base()
+	if p.properties.Src_name != nil {
+		binName = *p.properties.Src_name
+	}
 
 	// use cpExecutable to make it executable
 	outputFile := android.PathForModuleOut(ctx, binName)
@@ -817,6 +844,9 @@ func snapshotBinaryFactory(image SnapshotImage, moduleSuffix string) android.Mod
 type vendorSnapshotObjectProperties struct {
 	// Prebuilt file for each arch.
 	Src *string `android:"arch_variant"`
+
+	// Name of the prebuilt file, if src refers to a prebuilt stored by its content.
+	Src_name *string `android:"arch_variant"`
 }
 
 type snapshotObjectLinker struct {
@@ -844,7 +874,7 @@ func (p *snapshotObjectLinker) link(ctx ModuleContext, flags Flags, deps PathDep
 		return nil
 	}
 
-	return android.PathForModuleSrc(ctx, *p.properties.Src)
+	return snapshotSrcPath(ctx, *p.properties.Src, p.properties.Src_name)
 }
 
 func (p *snapshotObjectLinker) nativeCoverage() bool {
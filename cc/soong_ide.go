@@ -0,0 +1,168 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"android/soong/android"
+	"android/soong/genrule"
+)
+
+// This singleton generates an IDE project for the native modules listed with --modules, e.g.
+// `m soong_ide --modules=libfoo,libbar`, in
+// ${OUT_DIR}/soong/development/ide/soong_ide. The project contains:
+//   - compile_commands.json, restricted to the listed modules and their dependencies,
+//   - a .clangd configuration file that points clangd at it, to be linked from the root of the
+//     workspace opened in the IDE,
+//   - generated_headers, a symlink forest of the directories of the headers generated for these
+//     modules, which the soong_ide target builds.
+// See docs/soong_ide.md.
+
+func init() {
+	android.RegisterSingletonType("soong_ide", soongIdeSingletonFactory)
+}
+
+const (
+	soongIdeOutputDirectory         = "development/ide/soong_ide"
+	soongIdeClangdFilename          = ".clangd"
+	soongIdeGeneratedHeadersDirname = "generated_headers"
+)
+
+func soongIdeSingletonFactory() android.Singleton {
+	return &soongIdeSingleton{}
+}
+
+type soongIdeSingleton struct{}
+
+func (s *soongIdeSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	names := ctx.Config().SoongIdeModules()
+	if len(names) == 0 {
+		return
+	}
+
+	byName := make(map[string][]android.Module)
+	ctx.VisitAllModules(func(module android.Module) {
+		if module.Enabled() {
+			byName[ctx.ModuleName(module)] = append(byName[ctx.ModuleName(module)], module)
+		}
+	})
+	var roots []android.Module
+	for _, name := range names {
+		if len(byName[name]) == 0 {
+			ctx.Errorf("--modules: unknown module %q", name)
+			continue
+		}
+		roots = append(roots, byName[name]...)
+	}
+
+	// Visit the listed modules and their transitive dependencies, and gather the compile commands of
+	// the native modules and the headers generated for them.
+	entries := make(map[string]compDbEntry)
+	generatedHeaderDirs := make(map[string]android.Path)
+	var generatedDeps android.Paths
+	visited := make(map[android.Module]bool)
+	queue := roots
+	for _, root := range roots {
+		visited[root] = true
+	}
+	for len(queue) > 0 {
+		module := queue[0]
+		queue = queue[1:]
+		if ccModule, ok := module.(*Module); ok {
+			if compiledModule, ok := ccModule.compiler.(CompiledInterface); ok {
+				generateCompdbProject(compiledModule, ctx, ccModule, entries)
+			}
+		}
+		if gen, ok := module.(genrule.SourceFileGenerator); ok {
+			for _, dir := range gen.GeneratedHeaderDirs() {
+				generatedHeaderDirs[dir.String()] = dir
+			}
+			generatedDeps = append(generatedDeps, gen.GeneratedDeps()...)
+		}
+		ctx.VisitDirectDeps(module, func(dep android.Module) {
+			if !visited[dep] && dep.Enabled() {
+				visited[dep] = true
+				queue = append(queue, dep)
+			}
+		})
+	}
+
+	dir := android.PathForOutput(ctx, soongIdeOutputDirectory)
+	if err := android.RemoveAllOutputDir(dir); err != nil {
+		ctx.Errorf("failed to remove %s: %s", dir, err)
+		return
+	}
+
+	files := make([]string, 0, len(entries))
+	for file := range entries {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	compdb := make([]compDbEntry, 0, len(files))
+	for _, file := range files {
+		compdb = append(compdb, entries[file])
+	}
+	data, err := json.MarshalIndent(compdb, "", " ")
+	if err != nil {
+		ctx.Errorf("failed to marshal the compile commands: %s", err)
+		return
+	}
+	compdbFile := dir.Join(ctx, compdbFilename)
+	if err := android.WriteFileToOutputDir(compdbFile, data, 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", compdbFile, err)
+		return
+	}
+
+	clangd := fmt.Sprintf("CompileFlags:\n  CompilationDatabase: %s\n", soongIdeAbsPath(dir.String()))
+	clangdFile := dir.Join(ctx, soongIdeClangdFilename)
+	if err := android.WriteFileToOutputDir(clangdFile, []byte(clangd), 0666); err != nil {
+		ctx.Errorf("failed to write %s: %s", clangdFile, err)
+		return
+	}
+
+	// The generated header directories are linked at their path relative to the intermediates
+	// directory.
+	intermediates := android.PathForOutput(ctx, ".intermediates").String() + "/"
+	for _, key := range android.SortedKeys(generatedHeaderDirs) {
+		headerDir := generatedHeaderDirs[key]
+		link := filepath.Join(soongIdeAbsPath(dir.String()), soongIdeGeneratedHeadersDirname,
+			strings.TrimPrefix(headerDir.String(), intermediates))
+		if err := os.MkdirAll(filepath.Dir(link), 0777); err != nil {
+			ctx.Errorf("failed to create %s: %s", filepath.Dir(link), err)
+			return
+		}
+		if err := os.Symlink(soongIdeAbsPath(headerDir.String()), link); err != nil {
+			ctx.Errorf("failed to link %s: %s", link, err)
+			return
+		}
+	}
+
+	// Build the generated headers so that the links of the symlink forest are not dangling.
+	ctx.Phony("soong_ide", android.FirstUniquePaths(generatedDeps)...)
+}
+
+// soongIdeAbsPath returns the absolute path of a path relative to the top of the tree.
+func soongIdeAbsPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(android.AbsSrcDirForExistingUseCases(), path)
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestSoongIde(t *testing.T) {
+	bp := `
+		genrule {
+			name: "genrule_foo",
+			cmd: "generate-foo",
+			out: ["generated_headers/foo/generated_header.h"],
+			export_include_dirs: ["generated_headers"],
+		}
+
+		cc_library {
+			name: "libfoo",
+			srcs: ["foo.c"],
+			generated_headers: ["genrule_foo"],
+		}
+
+		cc_library {
+			name: "libbar",
+			srcs: ["bar.c"],
+			shared_libs: ["libfoo"],
+		}
+
+		cc_library {
+			name: "libunrelated",
+			srcs: ["unrelated.c"],
+		}
+	`
+
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterSingletonType("soong_ide", soongIdeSingletonFactory)
+		}),
+		android.FixtureModifyConfig(func(config android.Config) {
+			config.SetSoongIdeModules([]string{"libbar"})
+		}),
+		android.FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	dir := filepath.Join(result.Config.SoongOutDir(), soongIdeOutputDirectory)
+	data, err := os.ReadFile(filepath.Join(dir, compdbFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []compDbEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, e := range entries {
+		files = append(files, e.File)
+	}
+	android.AssertArrayString(t, "files", []string{"bar.c", "foo.c"}, files)
+
+	clangd, err := os.ReadFile(filepath.Join(dir, soongIdeClangdFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	android.AssertStringEquals(t, ".clangd", "CompileFlags:\n  CompilationDatabase: "+dir+"\n", string(clangd))
+
+	link, err := os.Readlink(filepath.Join(dir, soongIdeGeneratedHeadersDirname, "genrule_foo/gen/generated_headers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	android.AssertStringEquals(t, "generated headers",
+		filepath.Join(result.Config.SoongOutDir(), ".intermediates/genrule_foo/gen/generated_headers"), link)
+}
@@ -0,0 +1,276 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Lints the symbol files of NDK and LLNDK libraries.
+
+The symbol files must:
+  * list the symbols of each group of consecutive symbol lines sorted,
+  * not define a symbol more than once for an architecture,
+  * tag the symbols of all public versions but the first one with the API
+    level that introduced them, either on the symbol or on its version,
+  * only use API levels that are known to the build.
+
+The errors are reported as warnings. The symbol file is copied to --fixed with
+the unsorted and exactly repeated symbols fixed, and the command that replaces
+the symbol file with the fixed copy is printed.
+"""
+import argparse
+from dataclasses import dataclass
+import json
+from pathlib import Path
+import sys
+from typing import Dict, List, Optional, Set
+
+import symbolfile
+from symbolfile import ApiMap, Tags
+
+
+@dataclass
+class LintError:
+    """An error in a symbol file."""
+
+    line: int
+    message: str
+    fixable: bool = False
+
+
+@dataclass
+class SymbolLine:
+    """A symbol definition line of a symbol file."""
+
+    index: int
+    name: str
+    tags: Tags
+    arches: Set[str]
+
+
+def symbol_arches(tags: Tags) -> Set[str]:
+    """Returns the architectures that the tagged symbol is available for."""
+    arches = {tag for tag in tags if tag in symbolfile.ALL_ARCHITECTURES}
+    return arches or set(symbolfile.ALL_ARCHITECTURES)
+
+
+def has_introduced_tag(tags: Tags) -> bool:
+    """Returns True if the tags tell the API level introducing the symbol."""
+    return any(
+        tag.startswith('introduced=') or tag.startswith('introduced-')
+        or tag == 'future' for tag in tags)
+
+
+class Linter:
+    """Lints the lines of a symbol file and computes their fixed version."""
+    def __init__(self, lines: List[str], api_map: ApiMap) -> None:
+        self.lines = lines
+        self.api_map = api_map
+        self.errors: List[LintError] = []
+        # The indexes of the lines to remove, and the lines to replace.
+        self.removed: Set[int] = set()
+        self.replaced: Dict[int, str] = {}
+
+    def error(self, index: int, message: str, fixable: bool = False) -> None:
+        """Records an error on the line at the given index."""
+        self.errors.append(LintError(index + 1, message, fixable))
+
+    def get_tags(self, index: int) -> Optional[Tags]:
+        """Returns the decoded tags of a line, or None if they are invalid."""
+        try:
+            return symbolfile.get_tags(self.lines[index], self.api_map)
+        except symbolfile.ParseError as ex:
+            self.error(index, str(ex))
+            return None
+
+    def lint(self) -> None:
+        """Lints all the lines of the symbol file."""
+        defined: Dict[str, List[SymbolLine]] = {}
+        run: List[SymbolLine] = []
+        version_tags: Optional[Tags] = None
+        public_versions = 0
+        require_introduced = False
+        global_scope = True
+        cpp_symbols = False
+
+        for index, line in enumerate(self.lines):
+            content = line.partition('#')[0].strip()
+            if not content:
+                if line.strip():
+                    # A comment line separates groups of symbols.
+                    self.check_sorted(run)
+                    run = []
+                continue
+
+            if version_tags is None:
+                if '{' not in content:
+                    self.error(index, f'unexpected contents at top level: {content}')
+                    continue
+                version_tags = self.get_tags(index) or Tags()
+                name = content.split('{')[0].strip()
+                version = symbolfile.Version(name, None, version_tags, [])
+                public = (not version.is_private
+                          and not version_tags.has_platform_only_tags
+                          and not version_tags.has_mode_tags)
+                require_introduced = (public and public_versions > 0
+                                      and not has_introduced_tag(version_tags))
+                if public:
+                    public_versions += 1
+                global_scope = True
+                cpp_symbols = False
+                continue
+
+            if content.startswith('}'):
+                self.check_sorted(run)
+                run = []
+                if cpp_symbols:
+                    cpp_symbols = False
+                else:
+                    version_tags = None
+                continue
+            if 'extern "C++" {' in content:
+                self.check_sorted(run)
+                run = []
+                cpp_symbols = True
+                continue
+            if cpp_symbols:
+                continue
+            if content.endswith(':'):
+                self.check_sorted(run)
+                run = []
+                global_scope = content[:-1].strip() != 'local'
+                continue
+            if not global_scope:
+                continue
+
+            tags = self.get_tags(index)
+            if tags is None:
+                continue
+            if not content.endswith(';'):
+                self.error(index, f'expected ; to terminate symbol: {content}')
+                continue
+            symbol = SymbolLine(index, content.rstrip(';').strip(), tags,
+                                symbol_arches(tags))
+            if '*' in symbol.name:
+                self.error(index, 'wildcard global symbols are not permitted')
+                continue
+            if (require_introduced and not tags.has_mode_tags
+                    and not has_introduced_tag(tags)):
+                self.error(
+                    index, f'{symbol.name} must be tagged with the API level '
+                    'that introduced it, e.g. # introduced=30')
+
+            duplicate = self.check_duplicate(symbol, defined.get(symbol.name, []))
+            if duplicate:
+                continue
+            defined.setdefault(symbol.name, []).append(symbol)
+            run.append(symbol)
+
+        if version_tags is not None:
+            self.error(len(self.lines) - 1, 'unexpected EOF in version block')
+        self.check_sorted(run)
+
+    def check_duplicate(self, symbol: SymbolLine,
+                        previous: List[SymbolLine]) -> bool:
+        """Records an error if the symbol is already defined for one of its
+        architectures, and returns True if the line is removed by the fix."""
+        for other in previous:
+            if not symbol.arches & other.arches:
+                continue
+            if self.lines[symbol.index].strip() == self.lines[other.index].strip():
+                self.error(symbol.index,
+                           f'{symbol.name} is already defined on line '
+                           f'{other.index + 1}', fixable=True)
+                self.removed.add(symbol.index)
+                return True
+            self.error(symbol.index,
+                       f'{symbol.name} is already defined on line '
+                       f'{other.index + 1} with different tags')
+            return False
+        return False
+
+    def check_sorted(self, run: List[SymbolLine]) -> None:
+        """Records an error if a group of symbols is not sorted."""
+        names = [symbol.name for symbol in run]
+        if names == sorted(names):
+            return
+        for previous, symbol in zip(run, run[1:]):
+            if symbol.name < previous.name:
+                self.error(symbol.index,
+                           f'{symbol.name} must be sorted before '
+                           f'{previous.name}', fixable=True)
+                break
+        ordered = sorted(run, key=lambda symbol: symbol.name)
+        for symbol, replacement in zip(run, ordered):
+            self.replaced[symbol.index] = self.lines[replacement.index]
+
+    def fixed_lines(self) -> List[str]:
+        """Returns the lines of the symbol file with the fixable errors
+        fixed."""
+        return [
+            self.replaced.get(index, line)
+            for index, line in enumerate(self.lines)
+            if index not in self.removed
+        ]
+
+
+def parse_args() -> argparse.Namespace:
+    """Parses and returns command line arguments."""
+    parser = argparse.ArgumentParser()
+
+    parser.add_argument('--api-map',
+                        type=Path,
+                        required=True,
+                        help='Path to the API level map JSON file.')
+    parser.add_argument(
+        '--fixed',
+        type=Path,
+        required=True,
+        help='Path to the copy of the symbol file with the unsorted and '
+        'repeated symbols fixed.')
+    parser.add_argument('-o',
+                        '--output',
+                        type=Path,
+                        required=True,
+                        help='Path to the file to touch after the lint.')
+    parser.add_argument('symbol_file', type=Path, help='Path to symbol file.')
+
+    return parser.parse_args()
+
+
+def main() -> None:
+    """Program entry point."""
+    args = parse_args()
+
+    with args.api_map.open() as map_file:
+        api_map = json.load(map_file)
+    with args.symbol_file.open() as symbol_file:
+        lines = symbol_file.read().splitlines(keepends=True)
+
+    linter = Linter(lines, api_map)
+    linter.lint()
+
+    fixed = linter.fixed_lines()
+    args.fixed.write_text(''.join(fixed))
+
+    for error in sorted(linter.errors, key=lambda error: error.line):
+        print(f'{args.symbol_file}:{error.line}: warning: {error.message}',
+              file=sys.stderr)
+    if fixed != lines:
+        print('To sort the symbols and remove the repeated ones, run: '
+              f'cp {args.fixed} {args.symbol_file}', file=sys.stderr)
+
+    args.output.touch()
+
+
+if __name__ == '__main__':
+    main()
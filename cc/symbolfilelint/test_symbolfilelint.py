@@ -0,0 +1,164 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Tests for symbolfilelint."""
+import textwrap
+import unittest
+from typing import List, Tuple
+
+import symbolfilelint
+
+
+# pylint: disable=missing-docstring
+
+
+def lint(contents: str) -> Tuple[symbolfilelint.Linter, List[Tuple[int, str, bool]]]:
+    linter = symbolfilelint.Linter(
+        textwrap.dedent(contents).splitlines(keepends=True), {'Q': 9001})
+    linter.lint()
+    return linter, [(e.line, e.message, e.fixable) for e in linter.errors]
+
+
+class LinterTest(unittest.TestCase):
+    def test_valid(self) -> None:
+        _, errors = lint("""\
+            VERSION_1 {
+                global:
+                    bar;
+                    foo;
+                local:
+                    *;
+            };
+
+            VERSION_2 { # introduced=30
+                global:
+                    baz;
+                    qux; # introduced=Q
+            } VERSION_1;
+
+            VERSION_3 {
+                # Grouped symbols are sorted within their group.
+                    zzz; # introduced=31
+                # Another group.
+                    aaa; # llndk
+                extern "C++" {
+                    ccc*;
+                };
+            } VERSION_2;
+
+            VERSION_PRIVATE {
+                    private;
+            } VERSION_3;
+        """)
+        self.assertEqual([], errors)
+
+    def test_unsorted(self) -> None:
+        linter, errors = lint("""\
+            VERSION_1 {
+                global:
+                    foo;
+                    bar; # var
+                    baz;
+            };
+        """)
+        self.assertEqual([(4, 'bar must be sorted before foo', True)], errors)
+        self.assertEqual(textwrap.dedent("""\
+            VERSION_1 {
+                global:
+                    bar; # var
+                    baz;
+                    foo;
+            };
+        """), ''.join(linter.fixed_lines()))
+
+    def test_duplicate(self) -> None:
+        linter, errors = lint("""\
+            VERSION_1 {
+                    bar;
+                    foo;
+                    foo;
+                    qux; # arm
+                    qux; # x86
+            };
+        """)
+        self.assertEqual([(4, 'foo is already defined on line 3', True)],
+                         errors)
+        self.assertEqual(textwrap.dedent("""\
+            VERSION_1 {
+                    bar;
+                    foo;
+                    qux; # arm
+                    qux; # x86
+            };
+        """), ''.join(linter.fixed_lines()))
+
+    def test_conflicting_duplicate(self) -> None:
+        _, errors = lint("""\
+            VERSION_1 {
+                    foo;
+            };
+            VERSION_2 { # introduced=30
+                    foo; # arm
+            } VERSION_1;
+        """)
+        self.assertEqual(
+            [(5, 'foo is already defined on line 2 with different tags',
+              False)], errors)
+
+    def test_missing_introduced(self) -> None:
+        _, errors = lint("""\
+            VERSION_1 {
+                    foo;
+            };
+            VERSION_2 {
+                    bar; # introduced=30
+                    baz;
+                    qux; # apex
+            } VERSION_1;
+        """)
+        self.assertEqual([
+            (6, 'baz must be tagged with the API level that introduced it, '
+             'e.g. # introduced=30', False),
+        ], errors)
+
+    def test_unknown_api_level(self) -> None:
+        _, errors = lint("""\
+            VERSION_1 {
+                    foo; # introduced=R
+            };
+        """)
+        self.assertEqual(
+            [(2, 'Unknown version name in tag: introduced=R', False)], errors)
+
+    def test_unterminated(self) -> None:
+        _, errors = lint("""\
+            VERSION_1 {
+                    bar
+                    foo;
+        """)
+        self.assertEqual([
+            (2, 'expected ; to terminate symbol: bar', False),
+            (3, 'unexpected EOF in version block', False),
+        ], errors)
+
+
+def main() -> None:
+    suite = unittest.TestLoader().loadTestsFromName(__name__)
+    unittest.TextTestRunner(verbosity=3).run(suite)
+
+
+if __name__ == '__main__':
+    main()
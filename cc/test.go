@@ -121,6 +121,8 @@ type TestBinaryProperties struct {
 
 	// Install the test into a folder named for the module in all test suites.
 	Per_testcase_directory *bool
+
+	android.RunAtBuildProperties
 }
 
 func init() {
@@ -280,7 +282,7 @@ func (test *testDecorator) linkerFlags(ctx ModuleContext, flags Flags) Flags {
 		switch ctx.Os() {
 		case android.Windows:
 			flags.Local.CFlags = append(flags.Local.CFlags, "-DGTEST_OS_WINDOWS")
-		case android.Linux:
+		case android.Linux, android.LinuxMusl:
 			flags.Local.CFlags = append(flags.Local.CFlags, "-DGTEST_OS_LINUX")
 		case android.Darwin:
 			flags.Local.CFlags = append(flags.Local.CFlags, "-DGTEST_OS_MAC")
@@ -418,7 +420,50 @@ func (test *testBinary) install(ctx ModuleContext, file android.Path) {
 	if ctx.Host() && test.gtest() && test.Properties.Test_options.Unit_test == nil {
 		test.Properties.Test_options.Unit_test = proptools.BoolPtr(true)
 	}
+	file = test.runAtBuild(ctx, file)
 	test.binaryDecorator.baseInstaller.install(ctx, file)
+
+	ctx.SetProvider(android.TestSuiteInfoProvider, android.TestSuiteInfo{
+		Files: append([]android.TestSuiteFile{{Src: file, Rel: file.Base()}},
+			android.TestSuiteFilesForData(test.data)...),
+		Config: test.testConfig,
+	})
+}
+
+// runAtBuild runs the test during the build if run_at_build is set. The test is run before it is
+// installed next to the shared libraries that it depends on, so they are found through
+// LD_LIBRARY_PATH.
+func (test *testBinary) runAtBuild(ctx ModuleContext, file android.Path) android.Path {
+	if !Bool(test.Properties.Run_at_build) {
+		return file
+	}
+	var sharedLibs android.Paths
+	ctx.WalkDeps(func(child, parent android.Module) bool {
+		if !IsSharedDepTag(ctx.OtherModuleDependencyTag(child)) {
+			return false
+		}
+		if ctx.OtherModuleHasProvider(child, SharedLibraryInfoProvider) {
+			sharedLibs = append(sharedLibs, ctx.OtherModuleProvider(child, SharedLibraryInfoProvider).(SharedLibraryInfo).SharedLibrary)
+		}
+		return true
+	})
+	sharedLibs = android.FirstUniquePaths(sharedLibs)
+
+	var libDirs []string
+	for _, lib := range sharedLibs {
+		libDirs = append(libDirs, filepath.Dir(lib.String()))
+	}
+	deps := append(android.Paths{}, sharedLibs...)
+	for _, d := range test.data {
+		deps = append(deps, d.SrcPath)
+	}
+	return android.RunTestAtBuild(ctx, test.Properties.RunAtBuildProperties, file,
+		func(cmd *android.RuleBuilderCommand) {
+			if len(libDirs) > 0 {
+				cmd.Text("env").Textf("LD_LIBRARY_PATH=%s", strings.Join(android.FirstUniqueStrings(libDirs), ":"))
+			}
+			cmd.Input(file)
+		}, deps, nil)
 }
 
 func getTestInstallBase(useVendor bool) string {
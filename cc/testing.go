@@ -31,6 +31,7 @@ func RegisterRequiredBuildComponentsForTest(ctx android.RegistrationContext) {
 	RegisterBinaryBuildComponents(ctx)
 	RegisterLibraryBuildComponents(ctx)
 	RegisterLibraryHeadersBuildComponents(ctx)
+	RegisterKernelHeadersBuildComponents(ctx)
 	RegisterLibraryStubBuildComponents(ctx)
 
 	multitree.RegisterApiImportsModule(ctx)
@@ -690,6 +691,12 @@ var PrepareForTestWithFdoProfile = android.FixtureRegisterWithContext(func(ctx a
 	ctx.RegisterModuleType("fdo_profile", fdoProfileFactory)
 })
 
+// PrepareForTestWithFuzzPackaging registers the singleton that packages the cc and rust fuzz
+// targets.
+var PrepareForTestWithFuzzPackaging = android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+	ctx.RegisterSingletonType("cc_fuzz_packaging", fuzzPackagingFactory)
+})
+
 // TestConfig is the legacy way of creating a test Config for testing cc modules.
 //
 // See testCc for an explanation as to how to stop using this deprecated method.
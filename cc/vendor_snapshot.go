@@ -193,6 +193,8 @@ var ccSnapshotAction snapshot.GenerateSnapshotAction = func(s snapshot.SnapshotS
 					(executable binaries)
 				object/
 					(.o object files)
+			objects/
+				(prebuilts stored by their sha256, only if DedupSnapshotPrebuilts is set)
 			NOTICE_FILES/
 				(notice files, e.g. libbase.txt)
 			configs/
@@ -212,6 +214,13 @@ var ccSnapshotAction snapshot.GenerateSnapshotAction = func(s snapshot.SnapshotS
 
 	var headers android.Paths
 
+	// If the prebuilts are deduplicated, identical .so, .a, executable and .o files are stored
+	// once under objects/ and each arch directory gets a manifest.json referencing them.
+	dedup := ctx.DeviceConfig().DedupSnapshotPrebuilts() && !s.Fake
+	var storedPrebuilts []string
+	var storedPrebuiltPaths android.Paths
+	storedArchs := make(map[string]bool)
+
 	copyFile := func(ctx android.SingletonContext, path android.Path, out string, fake bool) android.OutputPath {
 		if fake {
 			// All prebuilt binaries and headers are installed by copyFile function. This makes a fake
@@ -222,6 +231,18 @@ var ccSnapshotAction snapshot.GenerateSnapshotAction = func(s snapshot.SnapshotS
 		}
 	}
 
+	// copyPrebuilt copies a prebuilt to {targetArch}/{rel}, or stores it by its content if the
+	// prebuilts are deduplicated.
+	copyPrebuilt := func(ctx android.SingletonContext, path android.Path, targetArch, rel string, fake bool) android.Paths {
+		if !dedup || fake {
+			return android.Paths{copyFile(ctx, path, filepath.Join(snapshotArchDir, targetArch, rel), fake)}
+		}
+		storedArchs[targetArch] = true
+		storedPrebuilts = append(storedPrebuilts, targetArch+":"+rel+":"+path.String())
+		storedPrebuiltPaths = append(storedPrebuiltPaths, path)
+		return nil
+	}
+
 	// installSnapshot function copies prebuilt file (.so, .a, or executable) and json flag file.
 	// For executables, init_rc and vintf_fragments files are also copied.
 	installSnapshot := func(m LinkableInterface, fake bool) android.Paths {
@@ -335,8 +356,8 @@ var ccSnapshotAction snapshot.GenerateSnapshotAction = func(s snapshot.SnapshotS
 						}
 					}
 				}
-				snapshotLibOut := filepath.Join(snapshotArchDir, targetArch, libType, m.RelativeInstallPath(), stem)
-				ret = append(ret, copyFile(ctx, libPath, snapshotLibOut, fake))
+				ret = append(ret, copyPrebuilt(ctx, libPath, targetArch,
+					filepath.Join(libType, m.RelativeInstallPath(), stem), fake)...)
 			} else {
 				stem = ctx.ModuleName(m)
 			}
@@ -352,17 +373,15 @@ var ccSnapshotAction snapshot.GenerateSnapshotAction = func(s snapshot.SnapshotS
 			prop.StaticLibs = m.SnapshotStaticLibs()
 			// install bin
 			binPath := m.OutputFile().Path()
-			snapshotBinOut := filepath.Join(snapshotArchDir, targetArch, "binary", binPath.Base())
-			ret = append(ret, copyFile(ctx, binPath, snapshotBinOut, fake))
-			propOut = snapshotBinOut + ".json"
+			ret = append(ret, copyPrebuilt(ctx, binPath, targetArch, filepath.Join("binary", binPath.Base()), fake)...)
+			propOut = filepath.Join(snapshotArchDir, targetArch, "binary", binPath.Base()+".json")
 		} else if m.Object() {
 			// object files aren't installed to the device, so their names can conflict.
 			// Use module name as stem.
 			objPath := m.OutputFile().Path()
-			snapshotObjOut := filepath.Join(snapshotArchDir, targetArch, "object",
-				ctx.ModuleName(m)+filepath.Ext(objPath.Base()))
-			ret = append(ret, copyFile(ctx, objPath, snapshotObjOut, fake))
-			propOut = snapshotObjOut + ".json"
+			snapshotObjOut := filepath.Join("object", ctx.ModuleName(m)+filepath.Ext(objPath.Base()))
+			ret = append(ret, copyPrebuilt(ctx, objPath, targetArch, snapshotObjOut, fake)...)
+			propOut = filepath.Join(snapshotArchDir, targetArch, snapshotObjOut+".json")
 		} else {
 			ctx.Errorf("unknown module %q in vendor snapshot", m.String())
 			return nil
@@ -430,7 +449,29 @@ var ccSnapshotAction snapshot.GenerateSnapshotAction = func(s snapshot.SnapshotS
 		snapshotOutputs = append(snapshotOutputs, copyFile(ctx, header, filepath.Join(includeDir, header.String()), s.Fake))
 	}
 
-	return snapshot.SnapshotPaths{OutputFiles: snapshotOutputs, NoticeFiles: snapshotNotices}
+	var snapshotListFiles android.Paths
+	if len(storedPrebuilts) > 0 {
+		objectsList := android.PathForOutput(ctx, snapshotArchDir, "objects.list")
+		// The prebuilts of a snapshot can be too many for a command line.
+		entries := android.PathForOutput(ctx, snapshotArchDir, "objects.rsp")
+		android.WriteFileRule(ctx, entries, strings.Join(storedPrebuilts, "\n"))
+		rule := android.NewRuleBuilder(pctx, ctx)
+		cmd := rule.Command().
+			BuiltTool("snapshot_content_store").
+			FlagWithArg("--snapshot-dir ", android.PathForOutput(ctx, snapshotArchDir).String()).
+			FlagWithOutput("--list ", objectsList).
+			FlagWithInput("@", entries).
+			Implicits(storedPrebuiltPaths)
+		for _, arch := range android.SortedKeys(storedArchs) {
+			manifest := android.PathForOutput(ctx, snapshotArchDir, arch, "manifest.json")
+			cmd.ImplicitOutput(manifest)
+			snapshotOutputs = append(snapshotOutputs, manifest)
+		}
+		rule.Build(objectsList.String(), "snapshot prebuilts "+objectsList.String())
+		snapshotListFiles = append(snapshotListFiles, objectsList)
+	}
+
+	return snapshot.SnapshotPaths{OutputFiles: snapshotOutputs, NoticeFiles: snapshotNotices, ListFiles: snapshotListFiles}
 }
 
 func init() {
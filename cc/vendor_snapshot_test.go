@@ -213,6 +213,52 @@ func TestVendorSnapshotCapture(t *testing.T) {
 	}
 }
 
+func TestVendorSnapshotDedup(t *testing.T) {
+	bp := `
+	cc_library {
+		name: "libvendor",
+		vendor: true,
+		nocrt: true,
+	}
+
+	cc_binary {
+		name: "vendor_bin",
+		vendor: true,
+		nocrt: true,
+	}
+`
+	config := TestConfig(t.TempDir(), android.Android, nil, bp, nil)
+	config.TestProductVariables.DeviceVndkVersion = StringPtr("current")
+	config.TestProductVariables.Platform_vndk_version = StringPtr("29")
+	config.TestProductVariables.DedupSnapshotPrebuilts = true
+	ctx := testCcWithConfig(t, config)
+
+	snapshotVariantPath := filepath.Join("out/soong", "vendor-snapshot", "arm64")
+	snapshotSingleton := ctx.SingletonForTests("vendor-snapshot")
+
+	// The prebuilts are stored by the snapshot_content_store tool instead of being copied.
+	store := snapshotSingleton.Output(filepath.Join(snapshotVariantPath, "objects.list"))
+	entries := snapshotSingleton.Output(filepath.Join(snapshotVariantPath, "objects.rsp"))
+	android.AssertStringDoesContain(t, "store command", store.RuleParams.Command, "@"+entries.Output.String())
+	entriesContent := android.ContentFromFileRuleForTests(t, entries)
+	for _, arch := range []string{"arch-arm64-armv8-a", "arch-arm-armv7-a-neon"} {
+		archDir := filepath.Join(snapshotVariantPath, arch)
+		if snapshotSingleton.MaybeOutput(filepath.Join(archDir, "shared", "libvendor.so")).Rule != nil {
+			t.Errorf("libvendor.so must not be copied to %q", archDir)
+		}
+		android.AssertStringListContains(t, "store outputs", store.AllOutputs(), filepath.Join(archDir, "manifest.json"))
+		android.AssertStringDoesContain(t, "store entries", entriesContent, arch+":shared/libvendor.so:")
+
+		// json flag files are still written for each prebuilt.
+		snapshotSingleton.Output(filepath.Join(archDir, "shared", "libvendor.so.json"))
+	}
+	android.AssertStringDoesContain(t, "store entries", entriesContent, "arch-arm64-armv8-a:binary/vendor_bin:")
+
+	// The stored objects are added to the snapshot zip.
+	zip := snapshotSingleton.Output(filepath.Join("out/soong", "vendor-snapshot", "vendor-test_device.zip"))
+	android.AssertStringDoesContain(t, "zip command", zip.RuleParams.Command, "-l "+store.Output.String())
+}
+
 func TestVendorSnapshotDirected(t *testing.T) {
 	bp := `
 	cc_library_shared {
@@ -546,6 +592,7 @@ func TestVendorSnapshotUse(t *testing.T) {
 				binaries: [
 					"bin",
 					"bin_override",
+					"bin_dedup",
 				],
 			},
 			arm: {
@@ -819,6 +866,20 @@ func TestVendorSnapshotUse(t *testing.T) {
 		symlinks: ["binfoo", "binbar"],
 	}
 
+	vendor_snapshot_binary {
+		name: "bin_dedup",
+		version: "31",
+		target_arch: "arm64",
+		compile_multilib: "64",
+		vendor: true,
+		arch: {
+			arm64: {
+				src: "objects/0123456789abcdef",
+				src_name: "bin_dedup",
+			},
+		},
+	}
+
 	vendor_snapshot_binary {
 		name: "bin32",
 		version: "31",
@@ -870,6 +931,7 @@ func TestVendorSnapshotUse(t *testing.T) {
 		"vendor/bin":                       nil,
 		"vendor/override/bin":              nil,
 		"vendor/bin32":                     nil,
+		"vendor/objects/0123456789abcdef":  nil,
 		"vendor/bin.cpp":                   nil,
 		"vendor/client.cpp":                nil,
 		"vendor/include/libvndk/a.h":       nil,
@@ -1017,6 +1079,9 @@ func TestVendorSnapshotUse(t *testing.T) {
 	// bin32 is installed by bin32.vendor_binary.31.arm64
 	ctx.ModuleForTests("bin32.vendor_binary.31.arm64", binary32Variant).Output("bin32")
 
+	// bin_dedup is installed with its src_name rather than the name of the stored object
+	ctx.ModuleForTests("bin_dedup.vendor_binary.31.arm64", binaryVariant).Output("bin_dedup")
+
 	// bin_without_snapshot is installed by bin_without_snapshot
 	ctx.ModuleForTests("bin_without_snapshot", binaryVariant).Output("bin_without_snapshot")
 
@@ -0,0 +1,195 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// This tool checks a bpf object when it is built, rather than when bpfloader loads it, and writes
+// the metadata that it checked:
+//   - the object must declare a license with the LICENSE() macro of bpf_helpers.h, which must be
+//     GPL-compatible if its programs call helpers that the kernel only provides to GPL programs,
+//   - the object must have BTF debug info if -btf is set.
+
+func main() {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+
+	// Hide the flag package to prevent accidental references to flag instead of flags.
+	flag := struct{}{}
+	_ = flag
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s [flags] -o <metadata.json> <obj.o>\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+
+		flags.PrintDefaults()
+	}
+
+	out := flags.String("o", "", "the metadata file to write")
+	btf := flags.Bool("btf", false, "check that the object has BTF debug info")
+
+	flags.Parse(os.Args[1:])
+
+	if *out == "" || flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	m, err := checkObjectFile(flags.Arg(0), *btf)
+	if err == nil {
+		var data []byte
+		data, err = json.MarshalIndent(m, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*out, append(data, '\n'), 0666)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// metadata is the checked metadata of the bpf object.
+type metadata struct {
+	License        string   `json:"license"`
+	Btf            bool     `json:"btf"`
+	GplOnlyHelpers []string `json:"gpl_only_helpers,omitempty"`
+}
+
+// The helpers that the kernel only provides to GPL-compatible programs, by helper id.
+var gplOnlyHelpers = map[int32]string{
+	4:   "bpf_probe_read",
+	6:   "bpf_trace_printk",
+	25:  "bpf_perf_event_output",
+	27:  "bpf_get_stackid",
+	35:  "bpf_get_current_task",
+	45:  "bpf_probe_read_str",
+	67:  "bpf_get_stack",
+	109: "bpf_send_signal",
+	112: "bpf_probe_read_user",
+	113: "bpf_probe_read_kernel",
+	114: "bpf_probe_read_user_str",
+	115: "bpf_probe_read_kernel_str",
+}
+
+// The licenses that the kernel considers GPL-compatible, see license_is_gpl_compatible().
+var gplCompatibleLicenses = map[string]bool{
+	"GPL":                       true,
+	"GPL v2":                    true,
+	"GPL and additional rights": true,
+	"Dual BSD/GPL":              true,
+	"Dual MIT/GPL":              true,
+	"Dual MPL/GPL":              true,
+}
+
+func checkObjectFile(file string, btf bool) (*metadata, error) {
+	f, err := elf.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := checkObject(f, btf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return m, nil
+}
+
+func checkObject(f *elf.File, btf bool) (*metadata, error) {
+	m := &metadata{}
+	license := f.Section("license")
+	if license == nil {
+		return nil, fmt.Errorf(`missing license, which must be declared with LICENSE("...")`)
+	}
+	data, err := license.Data()
+	if err != nil {
+		return nil, err
+	}
+	m.License = strings.TrimRight(string(data), "\x00")
+
+	m.Btf = f.Section(".BTF") != nil
+	if btf && !m.Btf {
+		return nil, fmt.Errorf("missing BTF debug info")
+	}
+
+	helpers := make(map[string]bool)
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_PROGBITS || s.Flags&elf.SHF_EXECINSTR == 0 {
+			continue
+		}
+		code, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		for _, helper := range calledGplOnlyHelpers(code, f.ByteOrder) {
+			helpers[helper] = true
+		}
+	}
+	for helper := range helpers {
+		m.GplOnlyHelpers = append(m.GplOnlyHelpers, helper)
+	}
+	sort.Strings(m.GplOnlyHelpers)
+
+	if err := checkLicense(m.License, m.GplOnlyHelpers); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// checkLicense checks that the license is GPL-compatible if the programs call GPL-only helpers.
+func checkLicense(license string, gplOnlyHelpers []string) error {
+	if len(gplOnlyHelpers) > 0 && !gplCompatibleLicenses[license] {
+		return fmt.Errorf("license %q is not GPL-compatible, but the programs call %s, which require a GPL-compatible license",
+			license, strings.Join(gplOnlyHelpers, ", "))
+	}
+	return nil
+}
+
+const (
+	// BPF_JMP | BPF_CALL.
+	bpfCallOpcode = 0x85
+	bpfInsnSize   = 8
+)
+
+// calledGplOnlyHelpers returns the GPL-only helpers that the instructions call.
+func calledGplOnlyHelpers(code []byte, order binary.ByteOrder) []string {
+	var helpers []string
+	for i := 0; i+bpfInsnSize <= len(code); i += bpfInsnSize {
+		insn := code[i : i+bpfInsnSize]
+		// The source register is 0 for calls of helpers, rather than of bpf functions or kfuncs.
+		var srcReg byte
+		if order == binary.BigEndian {
+			srcReg = insn[1] & 0x0f
+		} else {
+			srcReg = insn[1] >> 4
+		}
+		if insn[0] != bpfCallOpcode || srcReg != 0 {
+			continue
+		}
+		if helper, ok := gplOnlyHelpers[int32(order.Uint32(insn[4:8]))]; ok {
+			helpers = append(helpers, helper)
+		}
+	}
+	return helpers
+}
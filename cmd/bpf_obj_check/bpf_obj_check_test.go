@@ -0,0 +1,67 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestCalledGplOnlyHelpers(t *testing.T) {
+	code := []byte{
+		// r1 = 0
+		0xb7, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		// call bpf_map_lookup_elem#1
+		0x85, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+		// call bpf_trace_printk#6
+		0x85, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00,
+		// call of the bpf function at +4, which isn't a helper
+		0x85, 0x10, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00,
+		// call bpf_probe_read_kernel#113
+		0x85, 0x00, 0x00, 0x00, 0x71, 0x00, 0x00, 0x00,
+		// exit
+		0x95, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	got := calledGplOnlyHelpers(code, binary.LittleEndian)
+	want := []string{"bpf_trace_printk", "bpf_probe_read_kernel"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestCheckLicense(t *testing.T) {
+	for _, test := range []struct {
+		license string
+		helpers []string
+		err     string
+	}{
+		{license: "Apache 2.0"},
+		{license: "GPL", helpers: []string{"bpf_trace_printk"}},
+		{license: "Dual BSD/GPL", helpers: []string{"bpf_trace_printk"}},
+		{
+			license: "Apache 2.0",
+			helpers: []string{"bpf_probe_read", "bpf_trace_printk"},
+			err:     `license "Apache 2.0" is not GPL-compatible, but the programs call bpf_probe_read, bpf_trace_printk, which require a GPL-compatible license`,
+		},
+	} {
+		err := checkLicense(test.license, test.helpers)
+		if test.err == "" && err != nil {
+			t.Errorf("%q: unexpected error %q", test.license, err)
+		} else if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%q: expected error %q, got %v", test.license, test.err, err)
+		}
+	}
+}
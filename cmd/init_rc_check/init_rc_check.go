@@ -0,0 +1,317 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// This tool checks the init .rc files of a module when the module is built, rather than when the
+// device boots. For each service of the .rc files, it checks that:
+//   - the service runs one of the files installed by the product, if -installed_list is set. The
+//     binaries of the modules of -make_modules_list are installed by Make, which doesn't list their
+//     files, so any file with the name of one of these modules is accepted,
+//   - the user and groups of the service are AIDs defined by the -aids headers or the -config_fs
+//     files, if any of them is set,
+//   - the capabilities of the service are Linux capabilities.
+
+func main() {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+
+	// Hide the flag package to prevent accidental references to flag instead of flags.
+	flag := struct{}{}
+	_ = flag
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s [flags] -o <stamp> <rc>...\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+
+		flags.PrintDefaults()
+	}
+
+	out := flags.String("o", "", "the file to touch if the .rc files are valid")
+	installedList := flags.String("installed_list", "", "a file that lists the paths on the device of the files installed by the product")
+	makeModulesList := flags.String("make_modules_list", "", "a file that lists the modules installed by Make")
+	var aidHeaders, configFsFiles stringList
+	flags.Var(&aidHeaders, "aids", "a header that defines AIDs, e.g. android_filesystem_config.h")
+	flags.Var(&configFsFiles, "config_fs", "a config.fs file that defines AIDs")
+
+	flags.Parse(os.Args[1:])
+
+	if *out == "" || flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	c := &checker{}
+	if *installedList != "" {
+		c.installed = make(map[string]bool)
+		if err := readList(*installedList, c.installed); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		c.makeModules = make(map[string]bool)
+		if *makeModulesList != "" {
+			if err := readList(*makeModulesList, c.makeModules); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	}
+	if len(aidHeaders) > 0 || len(configFsFiles) > 0 {
+		c.aids = make(map[string]bool)
+		for _, file := range aidHeaders {
+			if err := readAids(file, aidHeaderRegexp, c.aids); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		for _, file := range configFsFiles {
+			if err := readAids(file, configFsAidRegexp, c.aids); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	var errs []string
+	for _, file := range flags.Args() {
+		r, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, err := range c.check(r) {
+			errs = append(errs, file+":"+err.Error())
+		}
+		r.Close()
+	}
+	if len(errs) > 0 {
+		fmt.Fprintln(os.Stderr, strings.Join(errs, "\n"))
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*out, nil, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// readList adds the lines of the file.
+func readList(file string, list map[string]bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			list[line] = true
+		}
+	}
+	return nil
+}
+
+var (
+	// e.g. `#define AID_SYSTEM 1000 /* system server */`.
+	aidHeaderRegexp = regexp.MustCompile(`^\s*#define\s+(AID_[A-Z0-9_]+)\s+[0-9]+`)
+	// e.g. `[AID_VENDOR_FOO]`.
+	configFsAidRegexp = regexp.MustCompile(`^\s*\[(AID_[A-Z0-9_]+)\]`)
+)
+
+// readAids adds the AIDs defined by the file, as their names in .rc files, e.g. system for
+// AID_SYSTEM.
+func readAids(file string, re *regexp.Regexp, aids map[string]bool) error {
+	r, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if match := re.FindStringSubmatch(scanner.Text()); match != nil {
+			aids[strings.ToLower(strings.TrimPrefix(match[1], "AID_"))] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// The Linux capabilities, as named in .rc files.
+var capabilities = map[string]bool{
+	"CHOWN": true, "DAC_OVERRIDE": true, "DAC_READ_SEARCH": true, "FOWNER": true, "FSETID": true,
+	"KILL": true, "SETGID": true, "SETUID": true, "SETPCAP": true, "LINUX_IMMUTABLE": true,
+	"NET_BIND_SERVICE": true, "NET_BROADCAST": true, "NET_ADMIN": true, "NET_RAW": true,
+	"IPC_LOCK": true, "IPC_OWNER": true, "SYS_MODULE": true, "SYS_RAWIO": true, "SYS_CHROOT": true,
+	"SYS_PTRACE": true, "SYS_PACCT": true, "SYS_ADMIN": true, "SYS_BOOT": true, "SYS_NICE": true,
+	"SYS_RESOURCE": true, "SYS_TIME": true, "SYS_TTY_CONFIG": true, "MKNOD": true, "LEASE": true,
+	"AUDIT_WRITE": true, "AUDIT_CONTROL": true, "SETFCAP": true, "MAC_OVERRIDE": true,
+	"MAC_ADMIN": true, "SYSLOG": true, "WAKE_ALARM": true, "BLOCK_SUSPEND": true,
+	"AUDIT_READ": true, "PERFMON": true, "BPF": true, "CHECKPOINT_RESTORE": true,
+}
+
+type checker struct {
+	// The paths on the device of the files installed by the product, or nil to not check the paths
+	// of the services.
+	installed map[string]bool
+
+	// The modules installed by Make, whose files aren't in installed.
+	makeModules map[string]bool
+
+	// The names of the AIDs, or nil to not check the users and groups of the services.
+	aids map[string]bool
+}
+
+// rcLines returns the lines of a .rc file split into words, with the line number of each line,
+// joining the lines continued with a trailing backslash and removing the comments.
+func rcLines(r io.Reader) ([][]string, []int, error) {
+	var lines [][]string
+	var numbers []int
+	scanner := bufio.NewScanner(r)
+	var continued string
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := continued + scanner.Text()
+		if strings.HasSuffix(line, "\\") {
+			continued = strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		continued = ""
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines = append(lines, fields)
+			numbers = append(numbers, number)
+		}
+	}
+	return lines, numbers, scanner.Err()
+}
+
+// check returns the errors in the services of a .rc file, prefixed with their line numbers.
+func (c *checker) check(r io.Reader) []error {
+	lines, numbers, err := rcLines(r)
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	errorf := func(i int, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%d: %s", numbers[i], fmt.Sprintf(format, args...)))
+	}
+	service := ""
+	for i, words := range lines {
+		switch words[0] {
+		case "service":
+			if len(words) < 3 {
+				errorf(i, "service must have a name and a path")
+				service = ""
+				continue
+			}
+			service = words[1]
+			c.checkPath(words[2], func(format string, args ...interface{}) {
+				errorf(i, "service %s: "+format, append([]interface{}{service}, args...)...)
+			})
+			continue
+		case "on", "import":
+			service = ""
+			continue
+		}
+		if service == "" {
+			continue
+		}
+
+		serviceErrorf := func(format string, args ...interface{}) {
+			errorf(i, "service %s: "+format, append([]interface{}{service}, args...)...)
+		}
+		switch words[0] {
+		case "user":
+			if len(words) != 2 {
+				serviceErrorf("user takes one argument")
+				continue
+			}
+			c.checkAid(words[1], "user", serviceErrorf)
+		case "group":
+			if len(words) < 2 {
+				serviceErrorf("group takes at least one argument")
+				continue
+			}
+			for _, group := range words[1:] {
+				c.checkAid(group, "group", serviceErrorf)
+			}
+		case "capabilities":
+			for _, capability := range words[1:] {
+				if !capabilities[strings.TrimPrefix(strings.ToUpper(capability), "CAP_")] {
+					serviceErrorf("unknown capability %q", capability)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func (c *checker) checkPath(path string, errorf func(format string, args ...interface{})) {
+	// The paths of the binaries of apexes are checked by the apexes.
+	if c.installed == nil || strings.HasPrefix(path, "/apex/") {
+		return
+	}
+	for _, stem := range multilibStems(path) {
+		if c.installed[stem] || c.makeModules[filepath.Base(stem)] {
+			return
+		}
+	}
+	errorf("%s is not installed by the product", path)
+}
+
+// multilibStems returns the path and the paths of the other variants of a binary that may be
+// installed for both architectures, e.g. /system/bin/foo, /system/bin/foo32 and
+// /system/bin/foo64 for /system/bin/foo.
+func multilibStems(path string) []string {
+	for _, suffix := range []string{"32", "64"} {
+		if strings.HasSuffix(path, suffix) {
+			path = strings.TrimSuffix(path, suffix)
+			break
+		}
+	}
+	return []string{path, path + "32", path + "64"}
+}
+
+var numericAidRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+func (c *checker) checkAid(name, kind string, errorf func(format string, args ...interface{})) {
+	if c.aids == nil || numericAidRegexp.MatchString(name) {
+		return
+	}
+	if !c.aids[name] {
+		errorf("unknown %s %q, which must be an AID of android_filesystem_config.h or config.fs", kind, name)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	c := &checker{
+		installed: map[string]bool{"/vendor/bin/foo": true},
+		aids:      map[string]bool{"system": true, "shell": true, "vendor_foo": true},
+	}
+
+	rc := `
+# The foo daemon.
+service foo /vendor/bin/foo --flag \
+        --other_flag
+    class main
+    user vendor_foo
+    group system shell 3003
+    capabilities NET_RAW sys_nice
+
+service bar /vendor/bin/bar
+    user bar
+    group system nobody
+    capabilities NET_FOO
+
+on boot
+    user nobody
+    start foo
+
+service baz /apex/com.android.baz/bin/baz
+    user system
+`
+	var errs []string
+	for _, err := range c.check(strings.NewReader(rc)) {
+		errs = append(errs, err.Error())
+	}
+	expected := []string{
+		"10: service bar: /vendor/bin/bar is not installed by the product",
+		`11: service bar: unknown user "bar", which must be an AID of android_filesystem_config.h or config.fs`,
+		`12: service bar: unknown group "nobody", which must be an AID of android_filesystem_config.h or config.fs`,
+		`13: service bar: unknown capability "NET_FOO"`,
+	}
+	if !reflect.DeepEqual(expected, errs) {
+		t.Errorf("expected errors:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(errs, "\n"))
+	}
+}
+
+func TestCheckInstalledPaths(t *testing.T) {
+	c := &checker{
+		installed:   map[string]bool{"/system/bin/foo64": true, "/system/bin/bar": true},
+		makeModules: map[string]bool{"sh": true},
+	}
+
+	rc := `
+service foo /system/bin/foo
+service foo32 /system/bin/foo32
+service bar32 /system/bin/bar32
+service sh /system/bin/sh
+service baz /system/bin/baz
+`
+	var errs []string
+	for _, err := range c.check(strings.NewReader(rc)) {
+		errs = append(errs, err.Error())
+	}
+	expected := []string{
+		"6: service baz: /system/bin/baz is not installed by the product",
+	}
+	if !reflect.DeepEqual(expected, errs) {
+		t.Errorf("expected errors:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(errs, "\n"))
+	}
+}
+
+func TestCheckWithoutInstalledFilesAndAids(t *testing.T) {
+	c := &checker{}
+	errs := c.check(strings.NewReader("service foo /system/bin/foo\n    user foo\n"))
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors: %q", errs)
+	}
+}
+
+func TestReadAids(t *testing.T) {
+	dir := t.TempDir()
+	header := filepath.Join(dir, "android_filesystem_config.h")
+	if err := os.WriteFile(header, []byte("#define AID_ROOT 0 /* traditional unix root */\n#define AID_SYSTEM 1000\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	configFs := filepath.Join(dir, "config.fs")
+	if err := os.WriteFile(configFs, []byte("[AID_VENDOR_FOO]\nvalue: 2900\n\n[vendor/bin/foo]\nmode: 0755\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	aids := make(map[string]bool)
+	if err := readAids(header, aidHeaderRegexp, aids); err != nil {
+		t.Fatal(err)
+	}
+	if err := readAids(configFs, configFsAidRegexp, aids); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"root": true, "system": true, "vendor_foo": true}
+	if !reflect.DeepEqual(expected, aids) {
+		t.Errorf("expected %v, got %v", expected, aids)
+	}
+}
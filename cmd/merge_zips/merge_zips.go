@@ -212,7 +212,7 @@ func (oz *OutputZip) addZipEntryFromFile(name string, path string) error {
 			UncompressedSize64: uint64(len(buf)),
 		}
 		fh.SetMode(0700)
-		fh.SetModTime(jar.DefaultTime)
+		fh.SetModTime(entryTime)
 		_, err = oz.addZipEntry(name, ZipEntryFromBuffer{fh, buf})
 	}
 	return err
@@ -226,7 +226,7 @@ func (oz *OutputZip) addEmptyEntry(entry string) error {
 		UncompressedSize64: uint64(len(emptyBuf)),
 	}
 	fh.SetMode(0700)
-	fh.SetModTime(jar.DefaultTime)
+	fh.SetModTime(entryTime)
 	_, err := oz.addZipEntry(entry, ZipEntryFromBuffer{fh, emptyBuf})
 	return err
 }
@@ -648,8 +648,12 @@ var (
 	pyMain           = flag.String("pm", "", "__main__.py file to insert in par")
 	prefix           = flag.String("prefix", "", "A file to prefix to the zip file")
 	ignoreDuplicates = flag.Bool("ignore-duplicates", false, "take each entry from the first zip it exists in and don't warn")
+	sourceDateEpoch  = flag.String("source_date_epoch", "", "the modification time of the added entries, in seconds since the epoch")
 )
 
+// The modification time of the entries that aren't copied from the input zips.
+var entryTime = jar.DefaultTime
+
 func init() {
 	flag.Var(&excludeDirs, "stripDir", "directories to be excluded from the output zip, accepts wildcards")
 	flag.Var(&excludeFiles, "stripFile", "files to be excluded from the output zip, accepts wildcards")
@@ -710,6 +714,7 @@ func main() {
 		os.Exit(1)
 	}
 	outputPath := args[0]
+	entryTime = jar.SourceDateTime(*sourceDateEpoch)
 	inputs := make([]string, 0)
 	for _, input := range args[1:] {
 		if input[0] == '@' {
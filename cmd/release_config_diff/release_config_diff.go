@@ -0,0 +1,245 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// This tool compares the release_config-<product>.json files that `m nothing` writes for two
+// release configurations, e.g. trunk and next, and prints the differences in the product
+// variables, the build flags and the enabled modules, so that flag flips can be validated before
+// cutting builds.
+//
+// The build flags are the RELEASE_* values of the release configuration. The aconfig flag values
+// aren't compared, as Soong doesn't compute them in this tree.
+
+func main() {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+
+	// Hide the flag package to prevent accidental references to flag instead of flags.
+	flag := struct{}{}
+	_ = flag
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s [flags] <release_config.json> <release_config.json>\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+		fmt.Fprintln(flags.Output(), "The files are written to out/soong/release_config-<product>.json by `m nothing`.")
+		fmt.Fprintln(flags.Output(), "The RELEASE_* build flags are compared, the aconfig flag values are not.")
+		fmt.Fprintln(flags.Output())
+
+		flags.PrintDefaults()
+	}
+
+	jsonOutput := flags.Bool("json", false, "print the differences as JSON")
+
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var configs [2]*releaseConfig
+	for i, file := range flags.Args() {
+		c, err := readReleaseConfig(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		configs[i] = c
+	}
+
+	d, err := diffReleaseConfigs(configs[0], configs[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(append(data, '\n'))
+	} else {
+		printDiff(os.Stdout, d)
+	}
+}
+
+// The subset of the release_config-<product>.json format that is compared.
+type releaseConfig struct {
+	Product          string                     `json:"product"`
+	ProductVariables map[string]json.RawMessage `json:"product_variables"`
+	EnabledModules   []string                   `json:"enabled_modules"`
+}
+
+func readReleaseConfig(file string) (*releaseConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var c releaseConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return &c, nil
+}
+
+// valueChange is a product variable or a build flag whose value differs. An unset value is
+// empty.
+type valueChange struct {
+	Name string `json:"name"`
+	A    string `json:"a"`
+	B    string `json:"b"`
+}
+
+type releaseConfigDiff struct {
+	ProductVariables []valueChange `json:"product_variables"`
+	BuildFlags       []valueChange `json:"build_flags"`
+	// The modules that are only enabled in the second config, and only in the first one.
+	EnabledModules  []string `json:"enabled_modules"`
+	DisabledModules []string `json:"disabled_modules"`
+}
+
+// The product variable with the build flags, which are compared separately.
+const buildFlagsVariable = "BuildFlags"
+
+// diffReleaseConfigs returns the differences from a to b.
+func diffReleaseConfigs(a, b *releaseConfig) (*releaseConfigDiff, error) {
+	d := &releaseConfigDiff{
+		ProductVariables: []valueChange{},
+		BuildFlags:       []valueChange{},
+		EnabledModules:   []string{},
+		DisabledModules:  []string{},
+	}
+
+	compact := func(value json.RawMessage) (string, error) {
+		if len(value) == 0 {
+			return "", nil
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, value); err != nil {
+			return "", err
+		}
+		if s := buf.String(); s != "null" {
+			return s, nil
+		}
+		return "", nil
+	}
+	for _, name := range unionOfKeys(a.ProductVariables, b.ProductVariables) {
+		if name == buildFlagsVariable {
+			continue
+		}
+		va, err := compact(a.ProductVariables[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", name, err)
+		}
+		vb, err := compact(b.ProductVariables[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", name, err)
+		}
+		if va != vb {
+			d.ProductVariables = append(d.ProductVariables, valueChange{name, va, vb})
+		}
+	}
+
+	buildFlags := func(c *releaseConfig) (map[string]string, error) {
+		flags := make(map[string]string)
+		if value := c.ProductVariables[buildFlagsVariable]; len(value) > 0 {
+			if err := json.Unmarshal(value, &flags); err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %w", buildFlagsVariable, err)
+			}
+		}
+		return flags, nil
+	}
+	fa, err := buildFlags(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := buildFlags(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range unionOfKeys(fa, fb) {
+		if fa[name] != fb[name] {
+			d.BuildFlags = append(d.BuildFlags, valueChange{name, fa[name], fb[name]})
+		}
+	}
+
+	ma := make(map[string]bool)
+	for _, m := range a.EnabledModules {
+		ma[m] = true
+	}
+	mb := make(map[string]bool)
+	for _, m := range b.EnabledModules {
+		mb[m] = true
+		if !ma[m] {
+			d.EnabledModules = append(d.EnabledModules, m)
+		}
+	}
+	for _, m := range a.EnabledModules {
+		if !mb[m] {
+			d.DisabledModules = append(d.DisabledModules, m)
+		}
+	}
+	sort.Strings(d.EnabledModules)
+	sort.Strings(d.DisabledModules)
+	return d, nil
+}
+
+func unionOfKeys[T any](a, b map[string]T) []string {
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	ret := make([]string, 0, len(keys))
+	for k := range keys {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// printDiff prints the human readable differences to w.
+func printDiff(w io.Writer, d *releaseConfigDiff) {
+	printChanges := func(title string, changes []valueChange) {
+		fmt.Fprintf(w, "%s (%d):\n", title, len(changes))
+		for _, c := range changes {
+			fmt.Fprintf(w, "  %s: %q -> %q\n", c.Name, c.A, c.B)
+		}
+	}
+	printModules := func(title, prefix string, modules []string) {
+		fmt.Fprintf(w, "%s (%d):\n", title, len(modules))
+		for _, m := range modules {
+			fmt.Fprintf(w, "  %s %s\n", prefix, m)
+		}
+	}
+	printChanges("Product variables", d.ProductVariables)
+	printChanges("Build flags", d.BuildFlags)
+	printModules("Enabled modules", "+", d.EnabledModules)
+	printModules("Disabled modules", "-", d.DisabledModules)
+}
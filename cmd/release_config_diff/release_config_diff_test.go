@@ -0,0 +1,94 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func parseReleaseConfig(t *testing.T, data string) *releaseConfig {
+	var c releaseConfig
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	return &c
+}
+
+func TestDiffReleaseConfigs(t *testing.T) {
+	trunk := parseReleaseConfig(t, `{
+		"product": "test_product",
+		"product_variables": {
+			"Platform_sdk_version": 34,
+			"Platform_version_name": "VanillaIceCream",
+			"DeviceArch": "arm64",
+			"BuildFlags": {
+				"RELEASE_FOO": "",
+				"RELEASE_BAR": "true",
+				"RELEASE_SAME": "1"
+			}
+		},
+		"enabled_modules": ["//bar:bar", "//same:same"]
+	}`)
+	next := parseReleaseConfig(t, `{
+		"product": "test_product",
+		"product_variables": {
+			"Platform_sdk_version": 35,
+			"DeviceArch": "arm64",
+			"Unbundled_build": true,
+			"BuildFlags": {
+				"RELEASE_FOO": "true",
+				"RELEASE_SAME": "1",
+				"RELEASE_NEW": "2"
+			}
+		},
+		"enabled_modules": ["//foo:foo", "//same:same"]
+	}`)
+
+	d, err := diffReleaseConfigs(trunk, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	printDiff(&out, d)
+	expected := strings.Join([]string{
+		`Product variables (3):`,
+		`  Platform_sdk_version: "34" -> "35"`,
+		`  Platform_version_name: "\"VanillaIceCream\"" -> ""`,
+		`  Unbundled_build: "" -> "true"`,
+		`Build flags (3):`,
+		`  RELEASE_BAR: "true" -> ""`,
+		`  RELEASE_FOO: "" -> "true"`,
+		`  RELEASE_NEW: "" -> "2"`,
+		`Enabled modules (1):`,
+		`  + //foo:foo`,
+		`Disabled modules (1):`,
+		`  - //bar:bar`,
+		``,
+	}, "\n")
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestDiffReleaseConfigsInvalidBuildFlags(t *testing.T) {
+	a := parseReleaseConfig(t, `{"product_variables": {"BuildFlags": ["RELEASE_FOO"]}}`)
+	b := parseReleaseConfig(t, `{}`)
+	if _, err := diffReleaseConfigs(a, b); err == nil || !strings.Contains(err.Error(), "invalid value of BuildFlags") {
+		t.Errorf("expected an error about the build flags, got %v", err)
+	}
+}
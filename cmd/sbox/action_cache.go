@@ -0,0 +1,202 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"android/soong/cmd/sbox/sbox_proto"
+	"android/soong/response"
+)
+
+// The action cache stores the outputs of manifests whose commands only see the files copied into
+// the sandbox, keyed by a digest of the commands and the contents of their inputs. Bump the
+// version when the layout of the sandbox or the digest changes.
+const actionCacheVersion = "1"
+
+// actionCacheStatsFile is appended with one line per lookup in the action cache, soong_ui reads
+// it to report the hit rate and truncates it when it collects the garbage in the cache.
+const actionCacheStatsFile = "stats.log"
+
+// actionCacheKey returns the digest of the commands of the manifest and of the contents of their
+// inputs, or an empty string if the manifest can't be cached because its commands may read files
+// that aren't copied into the sandbox or produce a depfile.
+func actionCacheKey(manifest *sbox_proto.Manifest) (string, error) {
+	if manifest.GetOutputDepfile() != "" {
+		return "", nil
+	}
+	for _, command := range manifest.Commands {
+		if !command.GetChdir() {
+			return "", nil
+		}
+	}
+
+	h := sha256.New()
+	writeString := func(s string) {
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+	writeFile := func(file string) error {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fileHash := sha256.New()
+		if _, err := io.Copy(fileHash, f); err != nil {
+			return err
+		}
+		writeString(hex.EncodeToString(fileHash.Sum(nil)))
+		return nil
+	}
+
+	writeString(actionCacheVersion)
+	for _, command := range manifest.Commands {
+		writeString(command.GetCommand())
+		for _, copyPair := range command.CopyBefore {
+			writeString(copyPair.GetTo())
+			writeString(strconv.FormatBool(copyPair.GetExecutable()))
+			if err := writeFile(copyPair.GetFrom()); err != nil {
+				return "", err
+			}
+		}
+		for _, rspFile := range command.RspFiles {
+			in, err := os.Open(rspFile.GetFile())
+			if err != nil {
+				return "", err
+			}
+			files, err := response.ReadRspFile(in)
+			in.Close()
+			if err != nil {
+				return "", err
+			}
+			writeString(applyPathMappings(rspFile.PathMappings, rspFile.GetFile()))
+			for _, file := range files {
+				writeString(applyPathMappings(rspFile.PathMappings, file))
+				if err := writeFile(file); err != nil {
+					return "", err
+				}
+			}
+		}
+		for _, copyPair := range command.CopyAfter {
+			writeString(copyPair.GetFrom())
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// actionCacheEntryDir returns the directory holding the outputs of the manifest with the given key.
+func actionCacheEntryDir(cacheDir, key string) string {
+	return filepath.Join(cacheDir, key[:2], key)
+}
+
+// actionCacheOutput returns the path of the output of the given command in a cache entry.
+func actionCacheOutput(entryDir string, commandIndex, outputIndex int) string {
+	return filepath.Join(entryDir, strconv.Itoa(commandIndex), strconv.Itoa(outputIndex))
+}
+
+// restoreFromActionCache copies the outputs of the manifest out of the action cache if they are
+// present. It returns false if the manifest wasn't in the cache.
+func restoreFromActionCache(manifest *sbox_proto.Manifest, cacheDir, key string) (bool, error) {
+	entryDir := actionCacheEntryDir(cacheDir, key)
+	if _, err := os.Stat(entryDir); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	var copyAfter []*sbox_proto.Copy
+	for _, command := range manifest.Commands {
+		copyAfter = append(copyAfter, command.CopyAfter...)
+	}
+	err := clearOutputDirectory(copyAfter, outputDir, writeType(writeIfChanged))
+	if err != nil {
+		return false, err
+	}
+
+	for i, command := range manifest.Commands {
+		for j, copyPair := range command.CopyAfter {
+			err := copyOneFile(actionCacheOutput(entryDir, i, j), copyPair.GetTo(), false,
+				requireFromExists, writeType(writeIfChanged))
+			if err != nil {
+				return false, fmt.Errorf("error restoring %q from the action cache: %w", copyPair.GetTo(), err)
+			}
+		}
+	}
+
+	// The modification time of the entry records when it was last used for the garbage collection.
+	now := time.Now()
+	return true, os.Chtimes(entryDir, now, now)
+}
+
+// storeInActionCache copies the outputs of the manifest into the action cache. The entry is
+// written to a temporary directory and renamed into place so that concurrent sbox invocations
+// never see partial entries.
+func storeInActionCache(manifest *sbox_proto.Manifest, cacheDir, key string) error {
+	entryDir := actionCacheEntryDir(cacheDir, key)
+	if err := os.MkdirAll(filepath.Dir(entryDir), 0777); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(entryDir), key+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i, command := range manifest.Commands {
+		for j, copyPair := range command.CopyAfter {
+			err := copyOneFile(copyPair.GetTo(), actionCacheOutput(tmpDir, i, j), false,
+				requireFromExists, alwaysWrite)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = os.Rename(tmpDir, entryDir)
+	if err != nil {
+		if _, statErr := os.Stat(entryDir); statErr == nil {
+			// Another sbox invocation stored the same outputs first.
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// recordActionCacheLookup appends the result of a lookup in the action cache to the stats file.
+func recordActionCacheLookup(cacheDir string, hit bool) error {
+	f, err := os.OpenFile(filepath.Join(cacheDir, actionCacheStatsFile),
+		os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	line := "miss\n"
+	if hit {
+		line = "hit\n"
+	}
+	_, err = f.WriteString(line)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
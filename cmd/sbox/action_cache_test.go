@@ -0,0 +1,102 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"android/soong/cmd/sbox/sbox_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestActionCache(t *testing.T) {
+	temp := t.TempDir()
+	write := func(file, content string) string {
+		path := filepath.Join(temp, file)
+		os.MkdirAll(filepath.Dir(path), 0777)
+		if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	input := write("in/a.txt", "a")
+	output := filepath.Join(temp, "gen", "b.txt")
+	manifest := &sbox_proto.Manifest{
+		Commands: []*sbox_proto.Command{{
+			Command: proto.String("cp in/a.txt out/b.txt"),
+			Chdir:   proto.Bool(true),
+			CopyBefore: []*sbox_proto.Copy{
+				{From: proto.String(input), To: proto.String("in/a.txt")},
+			},
+			CopyAfter: []*sbox_proto.Copy{
+				{From: proto.String("out/b.txt"), To: proto.String(output)},
+			},
+		}},
+	}
+
+	key, err := actionCacheKey(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key == "" {
+		t.Fatal("expected a sandboxed manifest to be cacheable")
+	}
+
+	// The key changes with the contents of the inputs.
+	write("in/a.txt", "changed")
+	if changedKey, err := actionCacheKey(manifest); err != nil {
+		t.Fatal(err)
+	} else if changedKey == key {
+		t.Errorf("expected the key to change with the contents of the inputs")
+	}
+	write("in/a.txt", "a")
+
+	cacheDir := filepath.Join(temp, "cache")
+	outputDir = filepath.Join(temp, "gen")
+	if hit, err := restoreFromActionCache(manifest, cacheDir, key); err != nil {
+		t.Fatal(err)
+	} else if hit {
+		t.Errorf("expected a miss in an empty cache")
+	}
+
+	write("gen/b.txt", "a")
+	if err := storeInActionCache(manifest, cacheDir, key); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(output)
+
+	if hit, err := restoreFromActionCache(manifest, cacheDir, key); err != nil {
+		t.Fatal(err)
+	} else if !hit {
+		t.Errorf("expected a hit after storing the outputs")
+	}
+	if data, err := os.ReadFile(output); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "a" {
+		t.Errorf("expected the restored output to contain %q, got %q", "a", string(data))
+	}
+
+	// Commands that may read files outside the sandbox are not cached.
+	manifest.Commands[0].Chdir = proto.Bool(false)
+	if key, err := actionCacheKey(manifest); err != nil {
+		t.Fatal(err)
+	} else if key != "" {
+		t.Errorf("expected a manifest that isn't sandboxed not to be cacheable")
+	}
+}
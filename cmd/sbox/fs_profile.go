@@ -0,0 +1,190 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The commands may always read from and write to these directories.
+var fsProfileSystemDirs = []string{
+	"/bin",
+	"/dev",
+	"/etc",
+	"/lib",
+	"/lib64",
+	"/proc",
+	"/sys",
+	"/tmp",
+	"/usr",
+}
+
+// The prefix of the lines of a profile that list paths that may be written, e.g. a cache that
+// persists across builds.
+const fsProfileWritePrefix = "w:"
+
+// fsProfile is the set of paths that the commands of an sbox manifest may read. The commands may
+// read and write anything inside the sandbox directory and the allowed writes.
+type fsProfile struct {
+	sandboxDir    string
+	allowedReads  []string
+	allowedWrites []string
+}
+
+// readFsProfile reads a file listing the paths that may be read, one per line, relative to the
+// current directory or absolute. The paths of the lines prefixed with "w:" may also be written.
+func readFsProfile(file string, sandboxDir string) (*fsProfile, error) {
+	absSandboxDir, err := filepath.Abs(sandboxDir)
+	if err != nil {
+		return nil, err
+	}
+	profile := &fsProfile{sandboxDir: absSandboxDir}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading filesystem profile %q: %w", file, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, fsProfileWritePrefix) {
+			path := strings.TrimPrefix(line, fsProfileWritePrefix)
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to make %q absolute: %w", path, err)
+			}
+			profile.allowedWrites = append(profile.allowedWrites, abs)
+		} else if err := profile.allowRead(line); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading filesystem profile %q: %w", file, err)
+	}
+
+	profile.allowedReads = append(profile.allowedReads, fsProfileSystemDirs...)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if err := profile.allowRead(dir); err != nil {
+			return nil, err
+		}
+	}
+	return profile, nil
+}
+
+func (p *fsProfile) allowRead(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to make %q absolute: %w", path, err)
+	}
+	p.allowedReads = append(p.allowedReads, abs)
+	return nil
+}
+
+// isUnder returns true if path is dir or is inside dir.
+func isUnder(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")
+}
+
+func (p *fsProfile) writeAllowed(path string) bool {
+	if isUnder(path, p.sandboxDir) {
+		return true
+	}
+	for _, dir := range p.allowedWrites {
+		if isUnder(path, dir) {
+			return true
+		}
+	}
+	for _, dir := range fsProfileSystemDirs {
+		if dir == "/dev" || dir == "/tmp" {
+			if isUnder(path, dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *fsProfile) readAllowed(path string) bool {
+	if p.writeAllowed(path) {
+		return true
+	}
+	for _, allowed := range p.allowedReads {
+		// Reading the parent directories of allowed paths is needed to reach them.
+		if isUnder(path, allowed) || isUnder(allowed, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFsTrace returns the paths in a trace written by fsatrace that the commands accessed in
+// violation of the profile. Each line of the trace is an operation followed by one or two paths,
+// e.g. "r|/path/to/file" or "m|/path/to/new|/path/to/old". Relative paths are relative to dir,
+// the directory the commands were run in.
+func (p *fsProfile) checkFsTrace(trace string, dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(trace)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file access trace %q: %w", trace, err)
+	}
+	defer f.Close()
+
+	violations := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 2 {
+			continue
+		}
+		op, paths := fields[0], fields[1:]
+		for _, path := range paths {
+			path = filepath.Clean(joinPath(dir, path))
+			switch op {
+			case "r":
+				if !p.readAllowed(path) {
+					violations["read "+path] = true
+				}
+			case "w", "d", "t", "m":
+				if !p.writeAllowed(path) {
+					violations["write "+path] = true
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file access trace %q: %w", trace, err)
+	}
+
+	ret := make([]string, 0, len(violations))
+	for violation := range violations {
+		ret = append(ret, violation)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
@@ -43,6 +43,9 @@ var (
 	manifestFile   string
 	keepOutDir     bool
 	writeIfChanged bool
+	fsatrace       string
+	fsProfileFile  string
+	actionCacheDir string
 )
 
 const (
@@ -61,6 +64,12 @@ func init() {
 		"whether to keep the sandbox directory when done")
 	flag.BoolVar(&writeIfChanged, "write-if-changed", false,
 		"only write the output files if they have changed")
+	flag.StringVar(&fsatrace, "fsatrace", "",
+		"path to fsatrace, used to trace the file accesses of the commands")
+	flag.StringVar(&fsProfileFile, "fs-profile", "",
+		"file listing the paths outside the sandbox that the commands may read, requires --fsatrace")
+	flag.StringVar(&actionCacheDir, "action-cache", "",
+		"directory of a cache of the outputs of sandboxed commands keyed by the contents of their inputs")
 }
 
 func usageViolation(violation string) {
@@ -118,12 +127,37 @@ func run() error {
 		usageViolation("--sandbox-path <sandboxPath> is required and must be non-empty")
 	}
 
+	if fsProfileFile != "" && fsatrace == "" {
+		usageViolation("--fs-profile requires --fsatrace")
+	}
+
 	manifest, err := readManifest(manifestFile)
 
 	if len(manifest.Commands) == 0 {
 		return fmt.Errorf("at least one commands entry is required in %q", manifestFile)
 	}
 
+	// Reuse the outputs of an identical manifest from the action cache when possible.
+	var cacheKey string
+	if actionCacheDir != "" {
+		cacheKey, err = actionCacheKey(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to compute the action cache key of %q: %w", manifestFile, err)
+		}
+		if cacheKey != "" {
+			hit, err := restoreFromActionCache(manifest, actionCacheDir, cacheKey)
+			if err != nil {
+				return err
+			}
+			if err := recordActionCacheLookup(actionCacheDir, hit); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to record the action cache lookup: %s\n", err)
+			}
+			if hit {
+				return nil
+			}
+		}
+	}
+
 	// setup sandbox directory
 	err = os.MkdirAll(sandboxesRoot, 0777)
 	if err != nil {
@@ -197,6 +231,13 @@ func run() error {
 		}
 	}
 
+	if cacheKey != "" {
+		// A failure to fill the cache only costs a later cache miss, don't fail the build for it.
+		if err := storeInActionCache(manifest, actionCacheDir, cacheKey); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to store the outputs in the action cache: %s\n", err)
+		}
+	}
+
 	return nil
 }
 
@@ -292,6 +333,23 @@ func runCommand(command *sbox_proto.Command, tempDir string, commandIndex int) (
 		return "", err
 	}
 
+	// If the commands are restricted to a filesystem profile, run them under fsatrace to record the
+	// files they access.
+	var profile *fsProfile
+	var traceFile string
+	if fsProfileFile != "" {
+		profile, err = readFsProfile(fsProfileFile, tempDir)
+		if err != nil {
+			return "", err
+		}
+		fsatracePath, err := filepath.Abs(fsatrace)
+		if err != nil {
+			return "", err
+		}
+		traceFile = filepath.Join(profile.sandboxDir, fmt.Sprintf("sbox_command.%d.fsatrace", commandIndex))
+		cmd = exec.Command(fsatracePath, append([]string{"rwmdt", traceFile, "--"}, cmd.Args...)...)
+	}
+
 	buf := &bytes.Buffer{}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = buf
@@ -337,6 +395,22 @@ func runCommand(command *sbox_proto.Command, tempDir string, commandIndex int) (
 		return "", err
 	}
 
+	if profile != nil {
+		commandDir := "."
+		if command.GetChdir() {
+			commandDir = tempDir
+		}
+		violations, err := profile.checkFsTrace(traceFile, commandDir)
+		if err != nil {
+			return "", err
+		}
+		if len(violations) > 0 {
+			return "", fmt.Errorf("sbox command (%s) accessed files outside of its filesystem profile:\n  %s\n"+
+				"Declare the directories it needs to read in allowed_read_dirs.",
+				rawCommand, strings.Join(violations, "\n  "))
+		}
+	}
+
 	err = validateOutputFiles(command.CopyAfter, tempDir, outputDir, rawCommand)
 	if err != nil {
 		return "", err
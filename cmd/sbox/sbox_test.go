@@ -18,6 +18,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -125,3 +126,61 @@ func Test_filesHaveSameContents(t *testing.T) {
 		})
 	}
 }
+
+func Test_fsProfile_checkFsTrace(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "testCheckFsTrace")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	profileFile := filepath.Join(tempDir, "profile")
+	err = ioutil.WriteFile(profileFile, []byte("/src/allowed\n/src/input.txt\nw:/out/cache\n"), 0666)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", profileFile, err)
+	}
+	t.Setenv("PATH", "/path/bin")
+
+	sandboxDir := filepath.Join(tempDir, "sandbox")
+	profile, err := readFsProfile(profileFile, sandboxDir)
+	if err != nil {
+		t.Fatalf("failed to read profile: %s", err)
+	}
+
+	trace := filepath.Join(tempDir, "trace")
+	err = ioutil.WriteFile(trace, []byte(strings.Join([]string{
+		"r|/src/allowed/a/b.txt",
+		"r|/src/input.txt",
+		"r|/src",
+		"r|/path/bin/bash",
+		"r|/usr/lib/libc.so",
+		"r|" + sandboxDir + "/tools/tool",
+		"w|" + sandboxDir + "/out/gen.txt",
+		"r|out/gen.txt",
+		"m|/tmp/a|/tmp/b",
+		"r|/src/other.txt",
+		"w|/src/allowed/a/b.txt",
+		"d|/src/input.txt",
+		"q|/home/user/.config",
+		"w|/out/cache/a/b",
+		"r|/out/cache/c",
+		"w|/out/cached",
+	}, "\n")), 0666)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", trace, err)
+	}
+
+	violations, err := profile.checkFsTrace(trace, sandboxDir)
+	if err != nil {
+		t.Fatalf("failed to check trace: %s", err)
+	}
+	want := []string{
+		"read /src/other.txt",
+		"write /out/cached",
+		"write /src/allowed/a/b.txt",
+		"write /src/input.txt",
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("checkFsTrace() = %q, want %q", violations, want)
+	}
+}
@@ -16,6 +16,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -72,6 +73,7 @@ func init() {
 	flag.StringVar(&cmdlineArgs.ModuleGraphFile, "module_graph_file", "", "JSON module graph file to output")
 	flag.StringVar(&cmdlineArgs.ModuleActionsFile, "module_actions_file", "", "JSON file to output inputs/outputs of actions of modules")
 	flag.StringVar(&cmdlineArgs.DocFile, "soong_docs", "", "build documentation file to output")
+	flag.StringVar(&cmdlineArgs.ProductValidateFile, "product_validate", "", "validate the product config and write the report to the file")
 	flag.StringVar(&cmdlineArgs.BazelQueryViewDir, "bazel_queryview_dir", "", "path to the bazel queryview directory relative to --top")
 	flag.StringVar(&cmdlineArgs.BazelApiBp2buildDir, "bazel_api_bp2build_dir", "", "path to the bazel api_bp2build directory relative to --top")
 	flag.StringVar(&cmdlineArgs.Bp2buildMarker, "bp2build_marker", "", "If set, run bp2build, touch the specified marker file then exit")
@@ -85,6 +87,8 @@ func init() {
 	flag.BoolVar(&cmdlineArgs.BazelModeDev, "bazel-mode-dev", false, "use bazel for analysis of a large number of modules (less stable)")
 	flag.BoolVar(&cmdlineArgs.UseBazelProxy, "use-bazel-proxy", false, "communicate with bazel using unix socket proxy instead of spawning subprocesses")
 	flag.BoolVar(&cmdlineArgs.BuildFromTextStub, "build-from-text-stub", false, "build Java stubs from API text files instead of source files")
+	flag.BoolVar(&cmdlineArgs.ReportUnusedModules, "report-unused-modules", false, "report the modules that are not reachable from the product, the tests or the dist targets")
+	flag.StringVar(&cmdlineArgs.SoongIdeModules, "soong-ide-modules", "", "modules of the IDE project generated by soong_ide. Comma-delimited")
 
 	// Flags that probably shouldn't be flags of soong_build, but we haven't found
 	// the time to remove them yet
@@ -368,6 +372,62 @@ func runSoongOnlyBuild(ctx *android.Context, extraNinjaDeps []string) string {
 	}
 }
 
+// runProductValidate validates the product config against the parsed Android.bp and Android.mk
+// files without analyzing the modules, and writes the report. It fails if the report has errors.
+func runProductValidate(ctx *android.Context, extraNinjaDeps []string) string {
+	ctx.EventHandler.Begin("product_validate")
+	defer ctx.EventHandler.End("product_validate")
+
+	// Keep the name resolver to look up the modules after parsing.
+	resolver := newNameResolver(ctx.Config())
+	ctx.SetNameInterface(resolver)
+	ctx.SetModuleListFile(cmdlineArgs.ModuleListFile)
+
+	blueprintFiles, err := ctx.ListModulePaths(".")
+	maybeQuit(err, "error listing the Android.bp files")
+	ninjaDeps, errs := ctx.ParseFileList(".", blueprintFiles, ctx.Config())
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+	ninjaDeps = append(ninjaDeps, cmdlineArgs.ModuleListFile)
+	ninjaDeps = append(ninjaDeps, extraNinjaDeps...)
+
+	// The Android.mk files are listed next to the Android.bp files by soong_ui.
+	androidMkList := filepath.Join(filepath.Dir(cmdlineArgs.ModuleListFile), "Android.mk.list")
+	data, err := os.ReadFile(shared.JoinPath(topDir, androidMkList))
+	maybeQuit(err, "error reading the Android.mk files")
+	androidMks := android.SortedUniqueStrings(strings.Fields(string(data)))
+	ninjaDeps = append(ninjaDeps, androidMkList)
+
+	report, deps, err := android.ValidateProductConfig(ctx.Config(), resolver, androidMks)
+	maybeQuit(err, "error validating the product config")
+	ninjaDeps = append(ninjaDeps, deps...)
+
+	globListFiles := writeBuildGlobsNinjaFile(ctx)
+	ninjaDeps = append(ninjaDeps, globListFiles...)
+
+	data, err = json.MarshalIndent(report, "", "  ")
+	maybeQuit(err, "error marshalling the product config report")
+	err = os.WriteFile(shared.JoinPath(topDir, cmdlineArgs.ProductValidateFile), append(data, '\n'), 0666)
+	maybeQuit(err, "error writing the product config report '%s'", cmdlineArgs.ProductValidateFile)
+	writeDepFile(cmdlineArgs.ProductValidateFile, ctx.EventHandler, ninjaDeps)
+
+	for _, warning := range report.Warnings() {
+		fmt.Fprintln(os.Stderr, "warning: "+warning)
+	}
+	if errs := report.Errors(); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, "error: "+err)
+		}
+		fmt.Fprintf(os.Stderr, "%d errors in the product config, see %s\n", len(errs), cmdlineArgs.ProductValidateFile)
+		os.Exit(1)
+	}
+	return cmdlineArgs.ProductValidateFile
+}
+
 // soong_ui dumps the available environment variables to
 // soong.environment.available . Then soong_build itself is run with an empty
 // environment so that the only way environment variables can be accessed is
@@ -431,6 +491,9 @@ func main() {
 	case android.ApiBp2build:
 		finalOutputFile = runApiBp2build(ctx, extraNinjaDeps)
 		writeMetrics(configuration, ctx.EventHandler, metricsDir)
+	case android.ValidateProductConfigMode:
+		ctx.Register()
+		finalOutputFile = runProductValidate(ctx, extraNinjaDeps)
 	default:
 		ctx.Register()
 		if configuration.IsMixedBuildsEnabled() {
@@ -0,0 +1,269 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The subset of the module-graph.json format written by `m json-module-graph` that is queried.
+type jsonVariation struct {
+	Mutator   string
+	Variation string
+}
+
+type jsonModuleName struct {
+	Name       string
+	Variant    string
+	Variations []jsonVariation
+}
+
+type jsonDep struct {
+	jsonModuleName
+	Tag string
+}
+
+type jsonModule struct {
+	jsonModuleName
+	Deps      []jsonDep
+	Type      string
+	Blueprint string
+}
+
+// moduleKey identifies a variant of a module.
+type moduleKey struct {
+	name    string
+	variant string
+}
+
+func (k moduleKey) String() string {
+	if k.variant == "" {
+		return k.name
+	}
+	return k.name + " (" + k.variant + ")"
+}
+
+type edge struct {
+	to  moduleKey
+	tag string
+}
+
+type module struct {
+	key        moduleKey
+	variations map[string]string
+	typ        string
+	blueprint  string
+	deps       []edge
+	rdeps      []edge
+}
+
+type graph struct {
+	modules map[moduleKey]*module
+	// The variants of each module name.
+	byName map[string][]*module
+}
+
+func keyOf(n jsonModuleName) moduleKey {
+	return moduleKey{name: n.Name, variant: n.Variant}
+}
+
+// readGraph reads a module graph in the module-graph.json format.
+func readGraph(r io.Reader) (*graph, error) {
+	var modules []jsonModule
+	if err := json.NewDecoder(r).Decode(&modules); err != nil {
+		return nil, fmt.Errorf("failed to parse the module graph: %w", err)
+	}
+
+	g := &graph{
+		modules: make(map[moduleKey]*module),
+		byName:  make(map[string][]*module),
+	}
+	for _, jm := range modules {
+		m := &module{
+			key:        keyOf(jm.jsonModuleName),
+			variations: make(map[string]string),
+			typ:        jm.Type,
+			blueprint:  jm.Blueprint,
+		}
+		for _, v := range jm.Variations {
+			m.variations[v.Mutator] = v.Variation
+		}
+		for _, d := range jm.Deps {
+			m.deps = append(m.deps, edge{to: keyOf(d.jsonModuleName), tag: d.Tag})
+		}
+		g.modules[m.key] = m
+		g.byName[m.key.name] = append(g.byName[m.key.name], m)
+	}
+	for _, m := range g.modules {
+		for _, d := range m.deps {
+			if dep, ok := g.modules[d.to]; ok {
+				dep.rdeps = append(dep.rdeps, edge{to: m.key, tag: d.tag})
+			}
+		}
+	}
+	return g, nil
+}
+
+// variantFilter selects the variants of a module by the variations of some of their mutators.
+type variantFilter struct {
+	arch  string
+	image string
+	apex  string
+}
+
+func (f variantFilter) matches(m *module) bool {
+	// The arch variations are <arch>_<arch variant>, e.g. arm64_armv8-a.
+	if f.arch != "" {
+		arch := m.variations["arch"]
+		if arch != f.arch && !strings.HasPrefix(arch, f.arch+"_") {
+			return false
+		}
+	}
+	// The core image variation is the empty string.
+	if f.image != "" {
+		image := m.variations["image"]
+		if image != f.image && !(f.image == "core" && image == "") {
+			return false
+		}
+	}
+	// The platform apex variation is the empty string.
+	if f.apex != "" {
+		apex := m.variations["apex"]
+		if apex != f.apex && !(f.apex == "platform" && apex == "") {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns the variants of the module that match the filter.
+func (g *graph) find(name string, filter variantFilter) ([]*module, error) {
+	variants, ok := g.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("module %q is not in the module graph", name)
+	}
+	var ret []*module
+	for _, m := range variants {
+		if filter.matches(m) {
+			ret = append(ret, m)
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no variant of module %q matches the filters", name)
+	}
+	sortModules(ret)
+	return ret, nil
+}
+
+func sortModules(modules []*module) {
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].key.name != modules[j].key.name {
+			return modules[i].key.name < modules[j].key.name
+		}
+		return modules[i].key.variant < modules[j].key.variant
+	})
+}
+
+// reachable returns the modules reachable from the start modules by following the edges, not
+// including the start modules. maxDepth limits the number of edges followed if it is positive.
+func (g *graph) reachable(start []*module, edges func(*module) []edge, maxDepth int) []*module {
+	seen := make(map[moduleKey]bool)
+	for _, m := range start {
+		seen[m.key] = true
+	}
+	var ret []*module
+	queue := start
+	for depth := 0; len(queue) > 0 && (maxDepth <= 0 || depth < maxDepth); depth++ {
+		var next []*module
+		for _, m := range queue {
+			for _, e := range edges(m) {
+				if seen[e.to] {
+					continue
+				}
+				seen[e.to] = true
+				if to, ok := g.modules[e.to]; ok {
+					next = append(next, to)
+					ret = append(ret, to)
+				}
+			}
+		}
+		queue = next
+	}
+	sortModules(ret)
+	return ret
+}
+
+func (g *graph) deps(start []*module, maxDepth int) []*module {
+	return g.reachable(start, func(m *module) []edge { return m.deps }, maxDepth)
+}
+
+func (g *graph) rdeps(start []*module, maxDepth int) []*module {
+	return g.reachable(start, func(m *module) []edge { return m.rdeps }, maxDepth)
+}
+
+// step is an edge of a path between modules.
+type step struct {
+	from *module
+	tag  string
+}
+
+// path returns a shortest path of dependencies from one of the from modules to one of the to
+// modules, as the modules on the path and the tags of the dependencies between them, or nil if
+// there is none.
+func (g *graph) path(from, to []*module) ([]*module, []string) {
+	targets := make(map[moduleKey]bool)
+	for _, m := range to {
+		targets[m.key] = true
+	}
+	prev := make(map[moduleKey]step)
+	seen := make(map[moduleKey]bool)
+	queue := append([]*module(nil), from...)
+	for _, m := range from {
+		seen[m.key] = true
+	}
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		if targets[m.key] {
+			var modules []*module
+			var tags []string
+			for cur := m; ; {
+				modules = append([]*module{cur}, modules...)
+				s, ok := prev[cur.key]
+				if !ok {
+					break
+				}
+				tags = append([]string{s.tag}, tags...)
+				cur = s.from
+			}
+			return modules, tags
+		}
+		for _, e := range m.deps {
+			if seen[e.to] {
+				continue
+			}
+			if dep, ok := g.modules[e.to]; ok {
+				seen[e.to] = true
+				prev[e.to] = step{from: m, tag: e.tag}
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return nil, nil
+}
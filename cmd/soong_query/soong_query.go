@@ -0,0 +1,141 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// This tool answers questions about the module graph written by `m json-module-graph`, such as
+// which modules depend on a module, and through which chain of dependencies.
+
+func main() {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+
+	// Hide the flag package to prevent accidental references to flag instead of flags.
+	flag := struct{}{}
+	_ = flag
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s [flags] deps <module>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s [flags] rdeps <module>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s [flags] path <from module> <to module>\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+		fmt.Fprintln(flags.Output(), "The variant filters apply to the modules named on the command line.")
+		fmt.Fprintln(flags.Output())
+
+		flags.PrintDefaults()
+	}
+
+	graphFile := flags.String("graph", "out/soong/module-graph.json",
+		"module graph written by `m json-module-graph`")
+	depth := flags.Int("depth", 0, "maximum number of dependencies to follow for deps and rdeps, 0 means no limit")
+	long := flags.Bool("long", false, "print the module type and the Android.bp file of the modules")
+	var filter variantFilter
+	flags.StringVar(&filter.arch, "arch", "", "only query the variants for this arch, e.g. arm64, x86_64 or common")
+	flags.StringVar(&filter.image, "image", "", "only query the variants for this image, e.g. core, vendor.VER or recovery")
+	flags.StringVar(&filter.apex, "apex", "", "only query the variants for this apex variation, e.g. platform or apex10000")
+
+	flags.Parse(os.Args[1:])
+
+	f, err := os.Open(*graphFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\nRun `m json-module-graph` to write the module graph.\n", err)
+		os.Exit(1)
+	}
+	g, err := readGraph(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := query(os.Stdout, g, flags.Args(), filter, *depth, *long); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if _, ok := err.(usageError); ok {
+			flags.Usage()
+		}
+		os.Exit(1)
+	}
+}
+
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// query runs the query in args and prints the answer to w.
+func query(w io.Writer, g *graph, args []string, filter variantFilter, depth int, long bool) error {
+	if len(args) == 0 {
+		return usageError("missing query")
+	}
+	printModule := func(m *module, indent string) {
+		if long {
+			fmt.Fprintf(w, "%s%s\t%s\t%s\n", indent, m.key, m.typ, m.blueprint)
+		} else {
+			fmt.Fprintf(w, "%s%s\n", indent, m.key)
+		}
+	}
+
+	switch args[0] {
+	case "deps", "rdeps":
+		if len(args) != 2 {
+			return usageError(args[0] + " takes one module")
+		}
+		start, err := g.find(args[1], filter)
+		if err != nil {
+			return err
+		}
+		var modules []*module
+		if args[0] == "deps" {
+			modules = g.deps(start, depth)
+		} else {
+			modules = g.rdeps(start, depth)
+		}
+		for _, m := range modules {
+			printModule(m, "")
+		}
+	case "path":
+		if len(args) != 3 {
+			return usageError("path takes two modules")
+		}
+		from, err := g.find(args[1], filter)
+		if err != nil {
+			return err
+		}
+		to, err := g.find(args[2], filter)
+		if err != nil {
+			return err
+		}
+		modules, tags := g.path(from, to)
+		if modules == nil {
+			return fmt.Errorf("%s doesn't depend on %s", args[1], args[2])
+		}
+		printModule(modules[0], "")
+		for i, m := range modules[1:] {
+			fmt.Fprintf(w, "  -> %s\n", strings.TrimSpace(tags[i]))
+			printModule(m, "")
+		}
+	default:
+		return usageError(fmt.Sprintf("unknown query %q", args[0]))
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testGraph = `[
+	{
+		"Name": "system_image",
+		"Variant": "android_common",
+		"Variations": [{"Mutator": "os", "Variation": "android"}, {"Mutator": "arch", "Variation": "common"}],
+		"Deps": [
+			{"Name": "libfoo", "Variant": "android_arm64_armv8-a_shared", "Tag": "packaging"}
+		],
+		"Type": "android_system_image",
+		"Blueprint": "build/Android.bp"
+	},
+	{
+		"Name": "libfoo",
+		"Variant": "android_arm64_armv8-a_shared",
+		"Variations": [{"Mutator": "os", "Variation": "android"}, {"Mutator": "arch", "Variation": "arm64_armv8-a"}],
+		"Deps": [
+			{"Name": "libbar", "Variant": "android_arm64_armv8-a_shared", "Tag": "shared"}
+		],
+		"Type": "cc_library",
+		"Blueprint": "foo/Android.bp"
+	},
+	{
+		"Name": "libfoo",
+		"Variant": "android_x86_64_shared",
+		"Variations": [{"Mutator": "os", "Variation": "android"}, {"Mutator": "arch", "Variation": "x86_64"}],
+		"Deps": [
+			{"Name": "libbar", "Variant": "android_x86_64_shared", "Tag": "shared"}
+		],
+		"Type": "cc_library",
+		"Blueprint": "foo/Android.bp"
+	},
+	{
+		"Name": "libbar",
+		"Variant": "android_arm64_armv8-a_shared",
+		"Variations": [{"Mutator": "os", "Variation": "android"}, {"Mutator": "arch", "Variation": "arm64_armv8-a"}],
+		"Type": "cc_library",
+		"Blueprint": "bar/Android.bp"
+	},
+	{
+		"Name": "libbar",
+		"Variant": "android_x86_64_shared",
+		"Variations": [{"Mutator": "os", "Variation": "android"}, {"Mutator": "arch", "Variation": "x86_64"}],
+		"Type": "cc_library",
+		"Blueprint": "bar/Android.bp"
+	}
+]`
+
+func TestQuery(t *testing.T) {
+	g, err := readGraph(strings.NewReader(testGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		filter variantFilter
+		depth  int
+		want   string
+	}{
+		{
+			name: "deps",
+			args: []string{"deps", "system_image"},
+			want: "libbar (android_arm64_armv8-a_shared)\n" +
+				"libfoo (android_arm64_armv8-a_shared)\n",
+		},
+		{
+			name:  "direct deps",
+			args:  []string{"deps", "system_image"},
+			depth: 1,
+			want:  "libfoo (android_arm64_armv8-a_shared)\n",
+		},
+		{
+			name:   "rdeps for arch",
+			args:   []string{"rdeps", "libbar"},
+			filter: variantFilter{arch: "x86_64"},
+			want:   "libfoo (android_x86_64_shared)\n",
+		},
+		{
+			name: "path",
+			args: []string{"path", "system_image", "libbar"},
+			want: "system_image (android_common)\n" +
+				"  -> packaging\n" +
+				"libfoo (android_arm64_armv8-a_shared)\n" +
+				"  -> shared\n" +
+				"libbar (android_arm64_armv8-a_shared)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := query(&out, g, tt.args, tt.filter, tt.depth, false); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("want:\n%s\ngot:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	g, err := readGraph(strings.NewReader(testGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		filter variantFilter
+		want   string
+	}{
+		{
+			name: "unknown module",
+			args: []string{"deps", "libbaz"},
+			want: `module "libbaz" is not in the module graph`,
+		},
+		{
+			name:   "no matching variant",
+			args:   []string{"deps", "libfoo"},
+			filter: variantFilter{arch: "riscv64"},
+			want:   `no variant of module "libfoo" matches the filters`,
+		},
+		{
+			name: "no path",
+			args: []string{"path", "libbar", "libfoo"},
+			want: "libbar doesn't depend on libfoo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := query(&out, g, tt.args, tt.filter, 0, false)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("want error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -85,6 +85,14 @@ var commands = []command{
 		config:       dumpVarConfig,
 		stdio:        customStdio,
 		run:          dumpVars,
+	}, {
+		flag:         "--list-targets",
+		description:  "list the modules, tests and phony goals from the last analysis, optionally filtered by prefix",
+		simpleOutput: true,
+		logsPrefix:   "listtargets-",
+		config:       dumpVarConfig,
+		stdio:        customStdio,
+		run:          listTargets,
 	}, {
 		flag:        "--build-mode",
 		description: "build modules based on the specified build action",
@@ -436,6 +444,47 @@ func dumpVars(ctx build.Context, config build.Config, args []string) {
 	}
 }
 
+// listTargets prints the targets recorded by the last Soong analysis whose name starts with the
+// given prefix. It does not run any part of the build so that it is fast enough for shell
+// completion.
+func listTargets(ctx build.Context, config build.Config, args []string) {
+	flags := flag.NewFlagSet("list-targets", flag.ExitOnError)
+	flags.SetOutput(ctx.Writer)
+
+	flags.Usage = func() {
+		fmt.Fprintf(ctx.Writer, "usage: %s --list-targets [--kind=module,test,phony] [prefix]\n\n", os.Args[0])
+		fmt.Fprintln(ctx.Writer, "In list-targets mode, print the targets known to the last Soong analysis whose")
+		fmt.Fprintln(ctx.Writer, "name starts with prefix, one per line.")
+		fmt.Fprintln(ctx.Writer, "")
+		flags.PrintDefaults()
+	}
+	kinds := flags.String("kind", "", "Comma-separated list of kinds of targets to list (module, test, phony)")
+	showKind := flags.Bool("show-kind", false, "Print the kind of each target before its name")
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		ctx.Fatalf("Invalid usage")
+	}
+
+	targets, err := build.ReadTargetsList(config)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+
+	var kindList []string
+	if *kinds != "" {
+		kindList = strings.Split(*kinds, ",")
+	}
+	for _, t := range build.FilterTargets(targets, flags.Arg(0), kindList) {
+		if *showKind {
+			fmt.Printf("%s\t%s\n", t.Kind, t.Name)
+		} else {
+			fmt.Println(t.Name)
+		}
+	}
+}
+
 func stdio() terminal.StdioInterface {
 	return terminal.StdioImpl{}
 }
@@ -578,6 +627,11 @@ func runMake(ctx build.Context, config build.Config, _ []string) {
 		ctx.Fatal("Invalid environment")
 	}
 
+	if config.VerifyDeterminism() {
+		build.VerifyDeterminism(ctx, config)
+		return
+	}
+
 	build.Build(ctx, config)
 }
 
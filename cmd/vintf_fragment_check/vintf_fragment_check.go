@@ -0,0 +1,277 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// This tool validates the VINTF manifest fragments of vintf_fragment modules at build time, rather
+// than when the device boots:
+//   - `vintf_fragment_check -o <hals> [-hals <hal>,...] <fragment.xml>` checks the structure of the
+//     fragment, that it declares exactly the given HAL instances if -hals is set, and writes the HAL
+//     instances it declares to <hals>, one per line.
+//   - `vintf_fragment_check -check_duplicates -o <stamp> <hals>...` checks that no HAL instance is
+//     declared by several of the fragments installed on a partition, given the files written for
+//     them by the first form, and touches <stamp>.
+
+func main() {
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+
+	// Hide the flag package to prevent accidental references to flag instead of flags.
+	flag := struct{}{}
+	_ = flag
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s [-hals <hal>,...] -o <hals> <fragment.xml>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s -check_duplicates -o <stamp> <hals>...\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+
+		flags.PrintDefaults()
+	}
+
+	out := flags.String("o", "", "the file to write")
+	hals := flags.String("hals", "", "comma-separated HAL instances that the fragment must declare")
+	checkDuplicates := flags.Bool("check_duplicates", false, "check the HAL instances of the fragments of a partition")
+
+	flags.Parse(os.Args[1:])
+
+	if *out == "" || flags.NArg() == 0 || (!*checkDuplicates && flags.NArg() != 1) {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var err error
+	if *checkDuplicates {
+		err = checkDuplicateHals(flags.Args())
+		if err == nil {
+			err = os.WriteFile(*out, nil, 0666)
+		}
+	} else {
+		var declared []string
+		if *hals != "" {
+			declared = strings.Split(*hals, ",")
+		}
+		var instances []string
+		instances, err = checkFragmentFile(flags.Arg(0), declared)
+		if err == nil {
+			err = os.WriteFile(*out, []byte(strings.Join(append(instances, ""), "\n")), 0666)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// manifest is the subset of the VINTF manifest format that is checked.
+type manifest struct {
+	XMLName xml.Name
+	Version string `xml:"version,attr"`
+	Type    string `xml:"type,attr"`
+	Hals    []hal  `xml:"hal"`
+}
+
+type hal struct {
+	Format     string         `xml:"format,attr"`
+	Name       string         `xml:"name"`
+	Transport  string         `xml:"transport"`
+	Versions   []string       `xml:"version"`
+	Interfaces []halInterface `xml:"interface"`
+	Fqnames    []string       `xml:"fqname"`
+}
+
+type halInterface struct {
+	Name      string   `xml:"name"`
+	Instances []string `xml:"instance"`
+}
+
+var (
+	manifestVersionRegexp = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+	halNameRegexp         = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)*$`)
+	hidlVersionRegexp     = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+	aidlVersionRegexp     = regexp.MustCompile(`^[0-9]+$`)
+	interfaceNameRegexp   = regexp.MustCompile(`^I[a-zA-Z0-9_]*$`)
+	// e.g. @1.0::IFoo/default for HIDL and IFoo/default for AIDL.
+	fqnameRegexp = regexp.MustCompile(`^(@([0-9]+\.[0-9]+)::)?(I[a-zA-Z0-9_]*)/([^/\s]+)$`)
+)
+
+func checkFragmentFile(file string, declared []string) ([]string, error) {
+	r, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	instances, err := checkFragment(r, declared)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return instances, nil
+}
+
+// checkFragment checks the structure of a manifest fragment and returns the HAL instances it
+// declares, sorted, as name@version::IFoo/instance for HIDL and name::IFoo/instance for AIDL.
+// If declared is not empty, the fragment must declare exactly these instances.
+func checkFragment(r io.Reader, declared []string) ([]string, error) {
+	var m manifest
+	if err := xml.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid XML: %w", err)
+	}
+	if m.XMLName.Local != "manifest" {
+		return nil, fmt.Errorf("the root element must be <manifest>, not <%s>", m.XMLName.Local)
+	}
+	if !manifestVersionRegexp.MatchString(m.Version) {
+		return nil, fmt.Errorf("invalid manifest version %q", m.Version)
+	}
+	if m.Type != "device" && m.Type != "framework" {
+		return nil, fmt.Errorf("the manifest type must be device or framework, not %q", m.Type)
+	}
+
+	var instances []string
+	for _, h := range m.Hals {
+		halInstances, err := checkHal(h)
+		if err != nil {
+			return nil, fmt.Errorf("<hal> %q: %w", h.Name, err)
+		}
+		instances = append(instances, halInstances...)
+	}
+	sort.Strings(instances)
+	for i := 1; i < len(instances); i++ {
+		if instances[i] == instances[i-1] {
+			return nil, fmt.Errorf("%s is declared more than once", instances[i])
+		}
+	}
+
+	if len(declared) > 0 {
+		want := append([]string(nil), declared...)
+		sort.Strings(want)
+		if strings.Join(want, ",") != strings.Join(instances, ",") {
+			return nil, fmt.Errorf("the fragment declares [%s], but the module declares [%s]",
+				strings.Join(instances, ", "), strings.Join(want, ", "))
+		}
+	}
+	return instances, nil
+}
+
+func checkHal(h hal) ([]string, error) {
+	format := h.Format
+	if format == "" {
+		format = "hidl"
+	}
+	if !halNameRegexp.MatchString(h.Name) {
+		return nil, fmt.Errorf("invalid name")
+	}
+
+	var versions []string
+	switch format {
+	case "hidl":
+		if h.Transport != "hwbinder" && h.Transport != "passthrough" {
+			return nil, fmt.Errorf("the transport of HIDL HALs must be hwbinder or passthrough, not %q", h.Transport)
+		}
+		// The <fqname> of the instances include their version.
+		if len(h.Versions) == 0 && len(h.Interfaces) > 0 {
+			return nil, fmt.Errorf("HIDL HALs with an <interface> must have a <version>")
+		}
+		for _, v := range h.Versions {
+			if !hidlVersionRegexp.MatchString(v) {
+				return nil, fmt.Errorf("invalid HIDL version %q", v)
+			}
+		}
+		versions = h.Versions
+	case "aidl":
+		if h.Transport != "" && h.Transport != "inet" {
+			return nil, fmt.Errorf("AIDL HALs have no transport other than inet, not %q", h.Transport)
+		}
+		for _, v := range h.Versions {
+			if !aidlVersionRegexp.MatchString(v) {
+				return nil, fmt.Errorf("invalid AIDL version %q", v)
+			}
+		}
+	case "native":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("the format must be hidl, aidl or native, not %q", format)
+	}
+
+	instance := func(version, iface, name string) string {
+		if format == "hidl" {
+			return h.Name + "@" + version + "::" + iface + "/" + name
+		}
+		return h.Name + "::" + iface + "/" + name
+	}
+	var instances []string
+	for _, i := range h.Interfaces {
+		if !interfaceNameRegexp.MatchString(i.Name) {
+			return nil, fmt.Errorf("invalid interface name %q", i.Name)
+		}
+		if len(i.Instances) == 0 {
+			return nil, fmt.Errorf("<interface> %q has no <instance>", i.Name)
+		}
+		for _, name := range i.Instances {
+			if format == "hidl" {
+				for _, v := range versions {
+					instances = append(instances, instance(v, i.Name, name))
+				}
+			} else {
+				instances = append(instances, instance("", i.Name, name))
+			}
+		}
+	}
+	for _, fqname := range h.Fqnames {
+		match := fqnameRegexp.FindStringSubmatch(fqname)
+		if match == nil {
+			return nil, fmt.Errorf("invalid fqname %q", fqname)
+		}
+		if hasVersion := match[2] != ""; hasVersion != (format == "hidl") {
+			return nil, fmt.Errorf("fqname %q must have a version only for HIDL HALs", fqname)
+		}
+		instances = append(instances, instance(match[2], match[3], match[4]))
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no instance is declared")
+	}
+	return instances, nil
+}
+
+// checkDuplicateHals checks that no HAL instance is listed in several of the files.
+func checkDuplicateHals(files []string) error {
+	declaredBy := make(map[string]string)
+	var errs []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		for _, instance := range strings.Fields(string(data)) {
+			if other, ok := declaredBy[instance]; ok {
+				errs = append(errs, fmt.Sprintf("%s is declared by the fragments of %s and %s", instance, other, file))
+				continue
+			}
+			declaredBy[instance] = file
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckFragment(t *testing.T) {
+	fragment := `
+		<manifest version="1.0" type="device">
+			<hal format="aidl">
+				<name>android.hardware.foo</name>
+				<version>2</version>
+				<interface>
+					<name>IFoo</name>
+					<instance>default</instance>
+					<instance>secondary</instance>
+				</interface>
+			</hal>
+			<hal format="hidl">
+				<name>android.hardware.bar</name>
+				<transport>hwbinder</transport>
+				<fqname>@1.0::IBar/default</fqname>
+			</hal>
+			<hal format="native">
+				<name>mapper</name>
+			</hal>
+		</manifest>`
+
+	instances, err := checkFragment(strings.NewReader(fragment), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"android.hardware.bar@1.0::IBar/default",
+		"android.hardware.foo::IFoo/default",
+		"android.hardware.foo::IFoo/secondary",
+	}
+	if !reflect.DeepEqual(expected, instances) {
+		t.Errorf("expected %q, got %q", expected, instances)
+	}
+
+	if _, err := checkFragment(strings.NewReader(fragment), expected[:2]); err == nil ||
+		!strings.Contains(err.Error(), "but the module declares") {
+		t.Errorf("expected an error for the declared HALs, got %v", err)
+	}
+}
+
+func TestCheckFragmentErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fragment string
+		err      string
+	}{
+		{
+			name:     "not xml",
+			fragment: `<manifest`,
+			err:      "invalid XML",
+		},
+		{
+			name:     "matrix",
+			fragment: `<compatibility_matrix version="1.0" type="device"/>`,
+			err:      "the root element must be <manifest>",
+		},
+		{
+			name:     "type",
+			fragment: `<manifest version="1.0" type="vendor"/>`,
+			err:      `the manifest type must be device or framework, not "vendor"`,
+		},
+		{
+			name: "transport",
+			fragment: `<manifest version="1.0" type="device">
+				<hal><name>android.hardware.bar</name><version>1.0</version><fqname>@1.0::IBar/default</fqname></hal>
+			</manifest>`,
+			err: `<hal> "android.hardware.bar": the transport of HIDL HALs must be hwbinder or passthrough`,
+		},
+		{
+			name: "hidl interface version",
+			fragment: `<manifest version="1.0" type="device">
+				<hal><name>android.hardware.bar</name><transport>hwbinder</transport><interface><name>IBar</name><instance>default</instance></interface></hal>
+			</manifest>`,
+			err: `<hal> "android.hardware.bar": HIDL HALs with an <interface> must have a <version>`,
+		},
+		{
+			name: "no instance",
+			fragment: `<manifest version="1.0" type="device">
+				<hal format="aidl"><name>android.hardware.foo</name><interface><name>IFoo</name></interface></hal>
+			</manifest>`,
+			err: `<interface> "IFoo" has no <instance>`,
+		},
+		{
+			name: "fqname version",
+			fragment: `<manifest version="1.0" type="device">
+				<hal format="aidl"><name>android.hardware.foo</name><fqname>@1.0::IFoo/default</fqname></hal>
+			</manifest>`,
+			err: `fqname "@1.0::IFoo/default" must have a version only for HIDL HALs`,
+		},
+		{
+			name: "duplicate",
+			fragment: `<manifest version="1.0" type="device">
+				<hal format="aidl"><name>android.hardware.foo</name><fqname>IFoo/default</fqname></hal>
+				<hal format="aidl"><name>android.hardware.foo</name><fqname>IFoo/default</fqname></hal>
+			</manifest>`,
+			err: "android.hardware.foo::IFoo/default is declared more than once",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := checkFragment(strings.NewReader(tc.fragment), nil)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicateHals(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	foo := write("foo.hals", "android.hardware.foo::IFoo/default\n")
+	bar := write("bar.hals", "android.hardware.bar@1.0::IBar/default\n")
+	baz := write("baz.hals", "android.hardware.foo::IFoo/default\n")
+
+	if err := checkDuplicateHals([]string{foo, bar}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	err := checkDuplicateHals([]string{foo, bar, baz})
+	expected := "android.hardware.foo::IFoo/default is declared by the fragments of " + foo + " and " + baz
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// gen_sbom generates the SPDX 2.3 SBOM of a partition or an APEX from the spec written by the sbom
+// singleton of Soong: it checksums the files, and reads the version and the provenance of the
+// packages from their METADATA files.
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"android/soong/compliance/project_metadata_proto"
+)
+
+// The spec of an SBOM, as written by the sbom singleton.
+type spec struct {
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace"`
+	Packages  []specPackage `json:"packages"`
+}
+
+type specPackage struct {
+	Name         string     `json:"name"`
+	PackageName  string     `json:"package_name"`
+	Dir          string     `json:"dir"`
+	Metadata     string     `json:"metadata"`
+	LicenseKinds []string   `json:"license_kinds"`
+	Prebuilt     bool       `json:"prebuilt"`
+	Files        []specFile `json:"files"`
+}
+
+type specFile struct {
+	Path string `json:"path"`
+	Src  string `json:"src"`
+}
+
+// The subset of SPDX 2.3 written by gen_sbom.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Files             []spdxFile         `json:"files"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Creators []string `json:"creators"`
+	Created  string   `json:"created"`
+}
+
+type spdxPackage struct {
+	Name             string `json:"name"`
+	SPDXID           string `json:"SPDXID"`
+	VersionInfo      string `json:"versionInfo,omitempty"`
+	DownloadLocation string `json:"downloadLocation"`
+	FilesAnalyzed    bool   `json:"filesAnalyzed"`
+	Homepage         string `json:"homepage,omitempty"`
+	LicenseConcluded string `json:"licenseConcluded"`
+	LicenseDeclared  string `json:"licenseDeclared"`
+	CopyrightText    string `json:"copyrightText"`
+	Comment          string `json:"comment,omitempty"`
+}
+
+type spdxFile struct {
+	FileName         string         `json:"fileName"`
+	SPDXID           string         `json:"SPDXID"`
+	Checksums        []spdxChecksum `json:"checksums"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	CopyrightText    string         `json:"copyrightText"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+const noAssertion = "NOASSERTION"
+
+var (
+	outFile       = flag.String("o", "", "output file")
+	buildDateFile = flag.String("build_date_file", "", "file containing the date of the build in seconds since the epoch")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: gen_sbom [-build_date_file <file>] -o <output> <spec>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || *outFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := genSbom(flag.Arg(0), *outFile, *buildDateFile); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		os.Exit(2)
+	}
+}
+
+func genSbom(specFile, outFile, buildDateFile string) error {
+	buf, err := ioutil.ReadFile(specFile)
+	if err != nil {
+		return fmt.Errorf("error reading spec %q: %w", specFile, err)
+	}
+	var s spec
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return fmt.Errorf("error parsing spec %q: %w", specFile, err)
+	}
+
+	created, err := buildDate(buildDateFile)
+	if err != nil {
+		return err
+	}
+
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              s.Name,
+		DocumentNamespace: s.Namespace,
+		CreationInfo: spdxCreationInfo{
+			Creators: []string{"Tool: gen_sbom"},
+			Created:  created.UTC().Format(time.RFC3339),
+		},
+	}
+
+	// The root package is the partition or the APEX itself, which contains all the files.
+	rootID := "SPDXRef-" + spdxID(s.Name)
+	doc.Packages = append(doc.Packages, spdxPackage{
+		Name:             s.Name,
+		SPDXID:           rootID,
+		DownloadLocation: noAssertion,
+		LicenseConcluded: noAssertion,
+		LicenseDeclared:  noAssertion,
+		CopyrightText:    noAssertion,
+	})
+	doc.Relationships = append(doc.Relationships, spdxRelationship{"SPDXRef-DOCUMENT", "DESCRIBES", rootID})
+
+	for _, p := range s.Packages {
+		pkg, err := packageFor(p)
+		if err != nil {
+			return err
+		}
+		if pkg.SPDXID == rootID {
+			pkg.SPDXID += "-Package"
+		}
+		doc.Packages = append(doc.Packages, pkg)
+
+		for _, f := range p.Files {
+			file, err := fileFor(f, len(doc.Files))
+			if err != nil {
+				return err
+			}
+			doc.Files = append(doc.Files, file)
+			doc.Relationships = append(doc.Relationships,
+				spdxRelationship{rootID, "CONTAINS", file.SPDXID},
+				spdxRelationship{file.SPDXID, "GENERATED_FROM", pkg.SPDXID})
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling SBOM: %w", err)
+	}
+	if err := ioutil.WriteFile(outFile, append(out, '\n'), 0666); err != nil {
+		return fmt.Errorf("error writing SBOM %q: %w", outFile, err)
+	}
+	return nil
+}
+
+// buildDate returns the date of the build read from the given file, or the epoch if there is none
+// so that the SBOMs of the builds without a date are reproducible.
+func buildDate(file string) (time.Time, error) {
+	if file == "" {
+		return time.Unix(0, 0), nil
+	}
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading build date %q: %w", file, err)
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(buf)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing build date %q: %w", file, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+func packageFor(p specPackage) (spdxPackage, error) {
+	pkg := spdxPackage{
+		Name:             p.Name,
+		SPDXID:           "SPDXRef-" + spdxID(p.Name),
+		DownloadLocation: noAssertion,
+		LicenseConcluded: noAssertion,
+		LicenseDeclared:  licenseExpression(p.LicenseKinds),
+		CopyrightText:    noAssertion,
+	}
+	if p.PackageName != "" {
+		pkg.Comment = "Package " + p.PackageName + " in " + p.Dir
+	} else {
+		pkg.Comment = "Built in " + p.Dir
+	}
+	if p.Prebuilt {
+		pkg.Comment += ", from a prebuilt"
+	}
+
+	if p.Metadata == "" {
+		return pkg, nil
+	}
+	buf, err := ioutil.ReadFile(p.Metadata)
+	if err != nil {
+		return pkg, fmt.Errorf("error reading METADATA %q: %w", p.Metadata, err)
+	}
+	var metadata project_metadata_proto.Metadata
+	if err := (prototext.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(buf, &metadata); err != nil {
+		return pkg, fmt.Errorf("error parsing METADATA %q: %w", p.Metadata, err)
+	}
+	thirdParty := metadata.GetThirdParty()
+	pkg.VersionInfo = thirdParty.GetVersion()
+	pkg.Homepage = thirdParty.GetHomepage()
+	for _, url := range thirdParty.GetUrl() {
+		switch url.GetType() {
+		case project_metadata_proto.URL_HOMEPAGE:
+			if pkg.Homepage == "" {
+				pkg.Homepage = url.GetValue()
+			}
+		case project_metadata_proto.URL_ARCHIVE, project_metadata_proto.URL_GIT:
+			if pkg.DownloadLocation == noAssertion {
+				pkg.DownloadLocation = url.GetValue()
+			}
+		}
+	}
+	return pkg, nil
+}
+
+func fileFor(f specFile, index int) (spdxFile, error) {
+	file, err := os.Open(f.Src)
+	if err != nil {
+		return spdxFile{}, fmt.Errorf("error reading %q: %w", f.Src, err)
+	}
+	defer file.Close()
+
+	sha1sum := sha1.New()
+	sha256sum := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(sha1sum, sha256sum), file); err != nil {
+		return spdxFile{}, fmt.Errorf("error reading %q: %w", f.Src, err)
+	}
+
+	return spdxFile{
+		FileName: "./" + strings.TrimPrefix(f.Path, "/"),
+		SPDXID:   fmt.Sprintf("SPDXRef-File-%d", index),
+		Checksums: []spdxChecksum{
+			{"SHA1", hex.EncodeToString(sha1sum.Sum(nil))},
+			{"SHA256", hex.EncodeToString(sha256sum.Sum(nil))},
+		},
+		LicenseConcluded: noAssertion,
+		CopyrightText:    noAssertion,
+	}, nil
+}
+
+var escapedSpdxIDChars = regexp.MustCompile(`[^A-Za-z0-9.]`)
+
+// spdxID returns a name with the characters that aren't allowed in an SPDX identifier, and the
+// dashes, replaced by a dash and their hexadecimal code, e.g. legacy_notice becomes
+// legacy-5Fnotice, so that different names have different identifiers.
+func spdxID(name string) string {
+	return escapedSpdxIDChars.ReplaceAllStringFunc(name, func(c string) string {
+		var escaped strings.Builder
+		for _, b := range []byte(c) {
+			fmt.Fprintf(&escaped, "-%02X", b)
+		}
+		return escaped.String()
+	})
+}
+
+// licenseExpression returns the SPDX license expression of the license kinds of a module, where the
+// kinds named after an SPDX license identifier, e.g. SPDX-license-identifier-Apache-2.0, are the
+// identifier, and the others, e.g. legacy_notice, are a LicenseRef.
+func licenseExpression(kinds []string) string {
+	if len(kinds) == 0 {
+		return noAssertion
+	}
+	var licenses []string
+	for _, kind := range kinds {
+		if id := strings.TrimPrefix(kind, "SPDX-license-identifier-"); id != kind {
+			licenses = append(licenses, id)
+		} else {
+			licenses = append(licenses, "LicenseRef-"+spdxID(kind))
+		}
+	}
+	return strings.Join(licenses, " AND ")
+}
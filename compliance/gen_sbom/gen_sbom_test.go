@@ -0,0 +1,206 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGenSbom(t *testing.T) {
+	dir := t.TempDir()
+
+	metadata := writeFile(t, dir, "METADATA", `
+		name: "libfoo"
+		third_party {
+			url {
+				type: HOMEPAGE
+				value: "https://example.com/libfoo"
+			}
+			url {
+				type: GIT
+				value: "https://example.com/libfoo.git"
+			}
+			version: "1.2.3"
+		}
+	`)
+	libfoo := writeFile(t, dir, "libfoo.so", "foo")
+	bar := writeFile(t, dir, "bar", "bar")
+
+	s := spec{
+		Name:      "system",
+		Namespace: "https://example.com/sbom/system",
+		Packages: []specPackage{
+			{
+				Name:         "libfoo",
+				PackageName:  "external/libfoo",
+				Dir:          "external/libfoo",
+				Metadata:     metadata,
+				LicenseKinds: []string{"SPDX-license-identifier-Apache-2.0", "legacy_notice"},
+				Files:        []specFile{{Path: "/system/lib64/libfoo.so", Src: libfoo}},
+			},
+			{
+				Name:     "bar",
+				Dir:      "vendor/bar",
+				Prebuilt: true,
+				Files:    []specFile{{Path: "system/bin/bar", Src: bar}},
+			},
+		},
+	}
+	buf, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	specFile := writeFile(t, dir, "spec.json", string(buf))
+	buildDateFile := writeFile(t, dir, "build_date.txt", "1700000000\n")
+	outFile := filepath.Join(dir, "sbom.spdx.json")
+
+	if err := genSbom(specFile, outFile, buildDateFile); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc spdxDocument
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "system",
+		DocumentNamespace: "https://example.com/sbom/system",
+		CreationInfo: spdxCreationInfo{
+			Creators: []string{"Tool: gen_sbom"},
+			Created:  "2023-11-14T22:13:20Z",
+		},
+		Packages: []spdxPackage{
+			{
+				Name:             "system",
+				SPDXID:           "SPDXRef-system",
+				DownloadLocation: noAssertion,
+				LicenseConcluded: noAssertion,
+				LicenseDeclared:  noAssertion,
+				CopyrightText:    noAssertion,
+			},
+			{
+				Name:             "libfoo",
+				SPDXID:           "SPDXRef-libfoo",
+				VersionInfo:      "1.2.3",
+				DownloadLocation: "https://example.com/libfoo.git",
+				Homepage:         "https://example.com/libfoo",
+				LicenseConcluded: noAssertion,
+				LicenseDeclared:  "Apache-2.0 AND LicenseRef-legacy-5Fnotice",
+				CopyrightText:    noAssertion,
+				Comment:          "Package external/libfoo in external/libfoo",
+			},
+			{
+				Name:             "bar",
+				SPDXID:           "SPDXRef-bar",
+				DownloadLocation: noAssertion,
+				LicenseConcluded: noAssertion,
+				LicenseDeclared:  noAssertion,
+				CopyrightText:    noAssertion,
+				Comment:          "Built in vendor/bar, from a prebuilt",
+			},
+		},
+		Files: []spdxFile{
+			{
+				FileName: "./system/lib64/libfoo.so",
+				SPDXID:   "SPDXRef-File-0",
+				Checksums: []spdxChecksum{
+					{"SHA1", "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"},
+					{"SHA256", "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+				},
+				LicenseConcluded: noAssertion,
+				CopyrightText:    noAssertion,
+			},
+			{
+				FileName: "./system/bin/bar",
+				SPDXID:   "SPDXRef-File-1",
+				Checksums: []spdxChecksum{
+					{"SHA1", "62cdb7020ff920e5aa642c3d4066950dd1f01f4d"},
+					{"SHA256", "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"},
+				},
+				LicenseConcluded: noAssertion,
+				CopyrightText:    noAssertion,
+			},
+		},
+		Relationships: []spdxRelationship{
+			{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-system"},
+			{"SPDXRef-system", "CONTAINS", "SPDXRef-File-0"},
+			{"SPDXRef-File-0", "GENERATED_FROM", "SPDXRef-libfoo"},
+			{"SPDXRef-system", "CONTAINS", "SPDXRef-File-1"},
+			{"SPDXRef-File-1", "GENERATED_FROM", "SPDXRef-bar"},
+		},
+	}
+	if !reflect.DeepEqual(expected, doc) {
+		t.Errorf("incorrect SBOM\nexpected: %+v\n     got: %+v", expected, doc)
+	}
+}
+
+func TestGenSbomWithoutBuildDate(t *testing.T) {
+	dir := t.TempDir()
+	specFile := writeFile(t, dir, "spec.json", `{"name": "myapex", "namespace": "https://example.com/sbom/myapex"}`)
+	outFile := filepath.Join(dir, "sbom.spdx.json")
+
+	if err := genSbom(specFile, outFile, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc spdxDocument
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.CreationInfo.Created != "1970-01-01T00:00:00Z" {
+		t.Errorf("expected the epoch as the creation date of a build without a date, got %q", doc.CreationInfo.Created)
+	}
+}
+
+func TestLicenseExpression(t *testing.T) {
+	tests := []struct {
+		kinds    []string
+		expected string
+	}{
+		{nil, "NOASSERTION"},
+		{[]string{"SPDX-license-identifier-MIT"}, "MIT"},
+		{[]string{"SPDX-license-identifier-BSD", "legacy_by_exception_only"}, "BSD AND LicenseRef-legacy-5Fby-5Fexception-5Fonly"},
+	}
+	for _, test := range tests {
+		if got := licenseExpression(test.kinds); got != test.expected {
+			t.Errorf("licenseExpression(%q): expected %q, got %q", test.kinds, test.expected, got)
+		}
+	}
+}
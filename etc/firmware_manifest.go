@@ -0,0 +1,137 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etc
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// This file generates the firmware manifest, which lists the sha256 of each firmware file and
+// symlink installed by prebuilt_firmware modules with its path on the device, e.g.
+//
+//	<sha256>  /vendor/firmware/foo.bin
+//
+// `m firmware-manifest` writes it to out/soong/firmware_manifest.txt, and dists it for compliance.
+// It also checks that the firmware files are not installed twice into a partition.
+
+var (
+	firmwareSha256 = pctx.AndroidStaticRule("firmwareSha256",
+		blueprint.RuleParams{
+			Command: `sha256=$$(sha256sum ${in} | cut -d' ' -f1) && ` +
+				`(for p in ${paths}; do echo "$${sha256}  $${p}"; done) > ${out}`,
+			Description: "firmware sha256 $in",
+		},
+		"paths")
+
+	firmwareManifest = pctx.AndroidStaticRule("firmwareManifest",
+		blueprint.RuleParams{
+			Command:        `cat $$(cat ${out}.rsp) > ${out}`,
+			Rspfile:        "${out}.rsp",
+			RspfileContent: "${in}",
+			Description:    "firmware manifest",
+		})
+)
+
+func firmwareManifestSingletonFactory() android.Singleton {
+	return &firmwareManifestSingleton{}
+}
+
+type firmwareManifestSingleton struct {
+	manifest android.WritablePath
+}
+
+// devicePath returns the path of a file installed into the install directory on the device, e.g.
+// /vendor/firmware/foo.bin.
+func devicePath(dir android.InstallPath, file string) string {
+	rel, err := filepath.Rel(dir.PartitionDir(), dir.String())
+	if err != nil {
+		panic(err)
+	}
+	return "/" + filepath.Join(dir.Partition(), rel, file)
+}
+
+func (s *firmwareManifestSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	// The modules that install each device path, and each firmware file into each partition.
+	installedPaths := make(map[string]string)
+	installedSrcs := make(map[string]string)
+	hashes := make(map[string]android.Path)
+
+	ctx.VisitAllModules(func(module android.Module) {
+		p, ok := module.(*PrebuiltEtc)
+		if !ok || !p.firmware || !p.Enabled() || !p.Installable() || p.IsSkipInstall() {
+			return
+		}
+		name := ctx.ModuleName(module)
+		dir := p.installDirPath
+
+		srcKey := dir.Partition() + ":" + p.sourceFilePath.String()
+		if other, ok := installedSrcs[srcKey]; ok && other != name {
+			ctx.ModuleErrorf(module, "installs %s into %s like %q, use the symlinks property of %q instead",
+				p.sourceFilePath, dir.Partition(), other, other)
+			return
+		}
+		installedSrcs[srcKey] = name
+
+		paths := []string{devicePath(dir, p.outputFilePath.Base())}
+		for _, symlink := range p.properties.Symlinks {
+			paths = append(paths, devicePath(dir, symlink))
+		}
+		for _, path := range paths {
+			if other, ok := installedPaths[path]; ok && other != name {
+				ctx.ModuleErrorf(module, "installs %s, which is also installed by %q", path, other)
+				return
+			}
+			installedPaths[path] = name
+		}
+
+		hash := android.PathForOutput(ctx, "firmware_manifest", strings.TrimPrefix(paths[0], "/")+".sha256")
+		ctx.Build(pctx, android.BuildParams{
+			Rule:   firmwareSha256,
+			Input:  p.outputFilePath,
+			Output: hash,
+			Args: map[string]string{
+				"paths": strings.Join(paths, " "),
+			},
+		})
+		hashes[paths[0]] = hash
+	})
+	if len(hashes) == 0 {
+		return
+	}
+
+	var inputs android.Paths
+	for _, path := range android.SortedKeys(hashes) {
+		inputs = append(inputs, hashes[path])
+	}
+	s.manifest = android.PathForOutput(ctx, "firmware_manifest.txt")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:   firmwareManifest,
+		Inputs: inputs,
+		Output: s.manifest,
+	})
+
+	ctx.Phony("firmware-manifest", s.manifest)
+}
+
+func (s *firmwareManifestSingleton) MakeVars(ctx android.MakeVarsContext) {
+	if s.manifest != nil {
+		ctx.DistForGoal("firmware-manifest", s.manifest)
+	}
+}
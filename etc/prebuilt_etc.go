@@ -63,9 +63,14 @@ func RegisterPrebuiltEtcBuildComponents(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("prebuilt_firmware", PrebuiltFirmwareFactory)
 	ctx.RegisterModuleType("prebuilt_dsp", PrebuiltDSPFactory)
 	ctx.RegisterModuleType("prebuilt_rfsa", PrebuiltRFSAFactory)
+	ctx.RegisterModuleType("prebuilt_etc_dir", PrebuiltEtcDirFactory)
+	ctx.RegisterModuleType("prebuilt_usr_share_dir", PrebuiltUserShareDirFactory)
+	ctx.RegisterModuleType("prebuilt_firmware_dir", PrebuiltFirmwareDirFactory)
 
 	ctx.RegisterModuleType("prebuilt_defaults", defaultsFactory)
 
+	ctx.RegisterSingletonType("firmware_manifest", firmwareManifestSingletonFactory)
+
 }
 
 var PrepareForTestWithPrebuiltEtc = android.FixtureRegisterWithContext(RegisterPrebuiltEtcBuildComponents)
@@ -150,7 +155,9 @@ type PrebuiltEtc struct {
 	installDirBase string
 	// The base install location when soc_specific property is set to true, e.g. "firmware" for
 	// prebuilt_firmware.
-	socInstallDirBase      string
+	socInstallDirBase string
+	// Whether the module installs a firmware file that is listed in the firmware manifest.
+	firmware               bool
 	installDirPath         android.InstallPath
 	additionalDependencies *android.Paths
 }
@@ -524,11 +531,16 @@ func PrebuiltFontFactory() android.Module {
 
 // prebuilt_firmware installs a firmware file to <partition>/etc/firmware directory for system
 // image.
-// If soc_specific property is set to true, the firmware file is installed to the
+// If soc_specific or vendor property is set to true, the firmware file is installed to the
 // vendor <partition>/firmware directory for vendor image.
+// The firmware of each chipset can be selected with a soong_config_module_type for
+// prebuilt_firmware that sets src per chipset. The same firmware can be installed under several
+// names with the symlinks property, installing its src twice into a partition is an error. The
+// installed firmware and symlinks are listed with their sha256 in the firmware manifest.
 func PrebuiltFirmwareFactory() android.Module {
 	module := &PrebuiltEtc{}
 	module.socInstallDirBase = "firmware"
+	module.firmware = true
 	InitPrebuiltEtcModule(module, "etc/firmware")
 	// This module is device-only
 	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibFirst)
@@ -0,0 +1,249 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etc
+
+// This file implements module types that install a whole directory of prebuilt artifacts,
+// keeping the structure of the directory, instead of one prebuilt_etc module per file.
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+type prebuiltEtcDirInstallPathOverride struct {
+	// Path of the file relative to src_dir.
+	Src *string
+
+	// Path relative to the install directory to install the file to, instead of its path
+	// relative to src_dir.
+	Dest *string
+}
+
+type prebuiltEtcDirProperties struct {
+	// Directory relative to the module directory whose files are installed. The paths of the
+	// files relative to it are kept when they are installed. Default is the module directory.
+	Src_dir *string
+
+	// Glob patterns relative to src_dir of the files to install. Default is ["**/*"].
+	Include []string
+
+	// Glob patterns relative to src_dir of the files not to install.
+	Exclude []string
+
+	// Files that are installed to a different path than their path relative to src_dir.
+	Install_path_overrides []prebuiltEtcDirInstallPathOverride
+
+	// Whether this module is directly installable to one of the partitions. Default: true.
+	Installable *bool
+}
+
+type PrebuiltEtcDir struct {
+	android.ModuleBase
+	android.DefaultableModuleBase
+
+	properties       prebuiltEtcDirProperties
+	subdirProperties prebuiltSubdirProperties
+
+	// The base install location, e.g. "etc" for prebuilt_etc_dir.
+	installDirBase string
+	// The base install location when soc_specific property is set to true.
+	socInstallDirBase string
+
+	installDirPath  android.InstallPath
+	outputFilePaths android.Paths
+}
+
+func (p *PrebuiltEtcDir) SubDir() string {
+	if subDir := proptools.String(p.subdirProperties.Sub_dir); subDir != "" {
+		return subDir
+	}
+	return proptools.String(p.subdirProperties.Relative_install_path)
+}
+
+func (p *PrebuiltEtcDir) BaseDir() string {
+	return p.installDirBase
+}
+
+func (p *PrebuiltEtcDir) Installable() bool {
+	return p.properties.Installable == nil || proptools.Bool(p.properties.Installable)
+}
+
+var _ android.OutputFileProducer = (*PrebuiltEtcDir)(nil)
+
+func (p *PrebuiltEtcDir) OutputFiles(tag string) (android.Paths, error) {
+	switch tag {
+	case "":
+		return p.outputFilePaths, nil
+	default:
+		return nil, fmt.Errorf("unsupported module reference tag %q", tag)
+	}
+}
+
+func (p *PrebuiltEtcDir) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	srcDir := filepath.Clean(proptools.String(p.properties.Src_dir))
+	if srcDir == ".." || strings.HasPrefix(srcDir, "../") || filepath.IsAbs(srcDir) {
+		ctx.PropertyErrorf("src_dir", "must be a directory under the module directory, was %q", srcDir)
+		return
+	}
+
+	inSrcDir := func(patterns []string) []string {
+		ret := make([]string, 0, len(patterns))
+		for _, pattern := range patterns {
+			ret = append(ret, filepath.Join(srcDir, pattern))
+		}
+		return ret
+	}
+	include := p.properties.Include
+	if len(include) == 0 {
+		include = []string{"**/*"}
+	}
+	// Don't install the build files of the module directory.
+	exclude := append(inSrcDir(p.properties.Exclude), "Android.bp", "Android.mk")
+	srcs := android.PathsForModuleSrcExcludes(ctx, inSrcDir(include), exclude)
+
+	overrides := make(map[string]string)
+	for _, o := range p.properties.Install_path_overrides {
+		src := proptools.String(o.Src)
+		dest := proptools.String(o.Dest)
+		if src == "" || dest == "" {
+			ctx.PropertyErrorf("install_path_overrides", "src and dest must be set")
+			continue
+		}
+		if !isCleanRelativePath(src) || !isCleanRelativePath(dest) {
+			ctx.PropertyErrorf("install_path_overrides", "src and dest must be clean relative paths, were %q and %q", src, dest)
+			continue
+		}
+		if _, ok := overrides[src]; ok {
+			ctx.PropertyErrorf("install_path_overrides", "%q is overridden more than once", src)
+			continue
+		}
+		overrides[src] = dest
+	}
+
+	// Check that `sub_dir` and `relative_install_path` are not set at the same time.
+	if p.subdirProperties.Sub_dir != nil && p.subdirProperties.Relative_install_path != nil {
+		ctx.PropertyErrorf("sub_dir", "relative_install_path is set. Cannot set sub_dir")
+	}
+
+	installBaseDir := p.installDirBase
+	if p.SocSpecific() && p.socInstallDirBase != "" {
+		installBaseDir = p.socInstallDirBase
+	}
+	p.installDirPath = android.PathForModuleInstall(ctx, installBaseDir, p.SubDir())
+
+	if !p.Installable() {
+		p.SkipInstall()
+	}
+
+	installed := make(map[string]string)
+	for _, src := range srcs {
+		rel, err := filepath.Rel(srcDir, src.Rel())
+		if err != nil {
+			ctx.ModuleErrorf("%s is not under src_dir %q", src, srcDir)
+			continue
+		}
+		dest := rel
+		if override, ok := overrides[rel]; ok {
+			dest = override
+			delete(overrides, rel)
+		}
+		if other, ok := installed[dest]; ok {
+			ctx.PropertyErrorf("install_path_overrides", "%q and %q are both installed to %q", other, rel, dest)
+			continue
+		}
+		installed[dest] = rel
+
+		output := android.PathForModuleOut(ctx, "files", dest)
+		ctx.Build(pctx, android.BuildParams{
+			Rule:   android.Cp,
+			Output: output,
+			Input:  src,
+		})
+		p.outputFilePaths = append(p.outputFilePaths, output)
+
+		// Call InstallFile even when uninstallable to make the module included in the package
+		ctx.InstallFile(p.installDirPath.Join(ctx, filepath.Dir(dest)), filepath.Base(dest), output)
+	}
+
+	for _, src := range android.SortedKeys(overrides) {
+		ctx.PropertyErrorf("install_path_overrides", "%q is not one of the installed files", src)
+	}
+	if len(srcs) == 0 && !ctx.Config().AllowMissingDependencies() {
+		ctx.PropertyErrorf("include", "no files matched in src_dir %q", srcDir)
+	}
+}
+
+// isCleanRelativePath returns whether path is relative, doesn't go up with ".." and is already in
+// the form returned by filepath.Clean.
+func isCleanRelativePath(path string) bool {
+	return filepath.Clean(path) == path && !filepath.IsAbs(path) && path != ".." && !strings.HasPrefix(path, "../")
+}
+
+func (p *PrebuiltEtcDir) AndroidMkEntries() []android.AndroidMkEntries {
+	if len(p.outputFilePaths) == 0 {
+		return nil
+	}
+	return []android.AndroidMkEntries{android.AndroidMkEntries{
+		Class:      "ETC",
+		OutputFile: android.OptionalPathForPath(p.outputFilePaths[0]),
+		ExtraEntries: []android.AndroidMkExtraEntriesFunc{
+			func(ctx android.AndroidMkExtraEntriesContext, entries *android.AndroidMkEntries) {
+				entries.SetString("LOCAL_MODULE_TAGS", "optional")
+				entries.SetString("LOCAL_MODULE_PATH", p.installDirPath.String())
+				entries.SetBoolIfTrue("LOCAL_UNINSTALLABLE_MODULE", !p.Installable())
+			},
+		},
+	}}
+}
+
+func InitPrebuiltEtcDirModule(p *PrebuiltEtcDir, dirBase string) {
+	p.installDirBase = dirBase
+	p.AddProperties(&p.properties)
+	p.AddProperties(&p.subdirProperties)
+	// This module is device-only
+	android.InitAndroidArchModule(p, android.DeviceSupported, android.MultilibFirst)
+	android.InitDefaultableModule(p)
+}
+
+// prebuilt_etc_dir installs the files under a directory in <partition>/etc/<sub_dir>, keeping
+// their paths relative to the directory.
+func PrebuiltEtcDirFactory() android.Module {
+	module := &PrebuiltEtcDir{}
+	InitPrebuiltEtcDirModule(module, "etc")
+	return module
+}
+
+// prebuilt_usr_share_dir installs the files under a directory in <partition>/usr/share/<sub_dir>,
+// keeping their paths relative to the directory.
+func PrebuiltUserShareDirFactory() android.Module {
+	module := &PrebuiltEtcDir{}
+	InitPrebuiltEtcDirModule(module, "usr/share")
+	return module
+}
+
+// prebuilt_firmware_dir installs the files under a directory in <partition>/etc/firmware, or in
+// the vendor <partition>/firmware directory if soc_specific is set, keeping their paths relative
+// to the directory.
+func PrebuiltFirmwareDirFactory() android.Module {
+	module := &PrebuiltEtcDir{}
+	module.socInstallDirBase = "firmware"
+	InitPrebuiltEtcDirModule(module, "etc/firmware")
+	return module
+}
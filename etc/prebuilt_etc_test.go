@@ -320,6 +320,85 @@ func TestPrebuiltFirmwareDirPath(t *testing.T) {
 	}
 }
 
+func TestPrebuiltFirmwareManifest(t *testing.T) {
+	result := prepareForPrebuiltEtcTest.RunTestWithBp(t, `
+		prebuilt_firmware {
+			name: "foo.bin",
+			src: "foo.conf",
+			vendor: true,
+			symlinks: ["foo_alias.bin"],
+		}
+
+		prebuilt_firmware {
+			name: "bar.bin",
+			src: "bar.conf",
+		}
+
+		prebuilt_firmware {
+			name: "baz.bin",
+			src: "baz.conf",
+			installable: false,
+		}
+	`)
+
+	manifest := result.SingletonForTests("firmware_manifest")
+	foo := manifest.Output("firmware_manifest/vendor/firmware/foo.bin.sha256")
+	android.AssertStringEquals(t, "foo paths", "/vendor/firmware/foo.bin /vendor/firmware/foo_alias.bin", foo.Args["paths"])
+	bar := manifest.Output("firmware_manifest/system/etc/firmware/bar.bin.sha256")
+	android.AssertStringEquals(t, "bar paths", "/system/etc/firmware/bar.bin", bar.Args["paths"])
+
+	android.AssertPathsRelativeToTopEquals(t, "manifest inputs", []string{
+		"out/soong/firmware_manifest/system/etc/firmware/bar.bin.sha256",
+		"out/soong/firmware_manifest/vendor/firmware/foo.bin.sha256",
+	}, manifest.Output("firmware_manifest.txt").Inputs)
+}
+
+func TestPrebuiltFirmwareDuplicates(t *testing.T) {
+	tests := []struct {
+		description string
+		config      string
+		err         string
+	}{{
+		description: "same src",
+		config: `
+			prebuilt_firmware {
+				name: "foo.bin",
+				src: "foo.conf",
+				vendor: true,
+			}
+
+			prebuilt_firmware {
+				name: "foo_alias.bin",
+				src: "foo.conf",
+				vendor: true,
+			}`,
+		err: `installs foo.conf into vendor like "foo(_alias)?.bin", use the symlinks property of "foo(_alias)?.bin" instead`,
+	}, {
+		description: "same path",
+		config: `
+			prebuilt_firmware {
+				name: "foo.bin",
+				src: "foo.conf",
+				vendor: true,
+				symlinks: ["bar.bin"],
+			}
+
+			prebuilt_firmware {
+				name: "bar.bin",
+				src: "bar.conf",
+				vendor: true,
+			}`,
+		err: `installs /vendor/firmware/(foo|bar).bin, which is also installed by "(foo|bar).bin"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			prepareForPrebuiltEtcTest.
+				ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(tt.err)).
+				RunTestWithBp(t, tt.config)
+		})
+	}
+}
+
 func TestPrebuiltDSPDirPath(t *testing.T) {
 	targetPath := "out/soong/target/product/test_device"
 	tests := []struct {
@@ -388,6 +467,74 @@ func TestPrebuiltRFSADirPath(t *testing.T) {
 	}
 }
 
+func TestPrebuiltEtcDir(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForPrebuiltEtcTest,
+		android.FixtureMergeMockFs(android.MockFS{
+			"confs/a.conf":       nil,
+			"confs/sub/b.conf":   nil,
+			"confs/sub/c.conf":   nil,
+			"confs/sub/notes.md": nil,
+		}),
+	).RunTestWithBp(t, `
+		prebuilt_etc_dir {
+			name: "my_confs",
+			src_dir: "confs",
+			include: ["**/*.conf"],
+			exclude: ["sub/c.conf"],
+			install_path_overrides: [
+				{
+					src: "a.conf",
+					dest: "renamed/a.conf",
+				},
+			],
+			sub_dir: "my",
+		}
+	`)
+
+	p := result.Module("my_confs", "android_arm64_armv8-a").(*PrebuiltEtcDir)
+	android.AssertPathsRelativeToTopEquals(t, "installed files", []string{
+		"out/soong/target/product/test_device/system/etc/my/renamed/a.conf",
+		"out/soong/target/product/test_device/system/etc/my/sub/b.conf",
+	}, p.FilesToInstall().Paths())
+}
+
+func TestPrebuiltEtcDirInvalidOverride(t *testing.T) {
+	prepareForPrebuiltEtcTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`install_path_overrides: "missing.conf" is not one of the installed files`)).
+		RunTestWithBp(t, `
+			prebuilt_etc_dir {
+				name: "my_confs",
+				include: ["*.conf"],
+				install_path_overrides: [
+					{
+						src: "missing.conf",
+						dest: "foo.conf",
+					},
+				],
+			}
+		`)
+}
+
+func TestPrebuiltEtcDirOverrideOutsideInstallDir(t *testing.T) {
+	prepareForPrebuiltEtcTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`install_path_overrides: src and dest must be clean relative paths, were "a.conf" and "../../bin/a.conf"`)).
+		RunTestWithBp(t, `
+			prebuilt_etc_dir {
+				name: "my_confs",
+				include: ["*.conf"],
+				install_path_overrides: [
+					{
+						src: "a.conf",
+						dest: "../../bin/a.conf",
+					},
+				],
+			}
+		`)
+}
+
 func checkIfSnapshotTaken(t *testing.T, result *android.TestResult, image string, moduleName string) {
 	checkIfSnapshotExistAsExpected(t, result, image, moduleName, true)
 }
@@ -188,6 +188,10 @@ func (a *avbAddHashFooter) SignedOutputPath() android.Path {
 	return a.OutputPath() // always signed
 }
 
+func (a *avbAddHashFooter) AvbInfo() *AvbInfo {
+	return nil // chaining is not supported
+}
+
 // TODO(b/185115783): remove when not needed as input to a prebuilt_etc rule
 var _ android.SourceFileProducer = (*avbAddHashFooter)(nil)
 
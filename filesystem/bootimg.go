@@ -36,6 +36,9 @@ type bootimg struct {
 
 	output     android.OutputPath
 	installDir android.InstallPath
+
+	// The private key that the image is signed with by avbtool.
+	avbKey android.Path
 }
 
 type bootimgProperties struct {
@@ -48,6 +51,11 @@ type bootimgProperties struct {
 	// Filesystem module that is used as ramdisk
 	Ramdisk_module *string
 
+	// List of modules that are installed into the ramdisk. They are packaged into a compressed
+	// cpio android_filesystem module named <module_name>_ramdisk that is used as ramdisk. Can't
+	// be set together with `ramdisk_module`.
+	Ramdisk_deps []string
+
 	// Path to the device tree blob (DTB) prebuilt file to add to this boot image
 	Dtb_prebuilt *string `android:"arch_variant,path"`
 
@@ -79,6 +87,13 @@ type bootimgProperties struct {
 
 	// Hash and signing algorithm for avbtool. Default is SHA256_RSA4096.
 	Avb_algorithm *string
+
+	// Rollback index of the image, passed to avbtool. Default used by avbtool is 0.
+	Avb_rollback_index *int64
+
+	// Rollback index location of the image. Must be 1, 2, etc. to chain the partition from a
+	// vbmeta image. Default used by avbtool is 0.
+	Avb_rollback_index_location *int64
 }
 
 // bootimg is the image for the boot partition. It consists of header, kernel, ramdisk, and dtb.
@@ -86,6 +101,42 @@ func bootimgFactory() android.Module {
 	module := &bootimg{}
 	module.AddProperties(&module.properties)
 	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibFirst)
+	android.AddLoadHook(module, func(ctx android.LoadHookContext) { module.createRamdiskModule(ctx) })
+	return module
+}
+
+func (b *bootimg) ramdiskModuleName() string {
+	if len(b.properties.Ramdisk_deps) > 0 {
+		return b.BaseModuleName() + "_ramdisk"
+	}
+	return proptools.String(b.properties.Ramdisk_module)
+}
+
+// createRamdiskModule creates the android_filesystem module for the modules in ramdisk_deps.
+func (b *bootimg) createRamdiskModule(ctx android.LoadHookContext) {
+	if len(b.properties.Ramdisk_deps) == 0 {
+		return
+	}
+	if b.properties.Ramdisk_module != nil {
+		ctx.PropertyErrorf("ramdisk_deps", "can't be set together with ramdisk_module")
+		return
+	}
+	ctx.CreateModule(ramdiskFilesystemFactory, &struct {
+		Name *string
+		Type *string
+		Deps []string
+	}{
+		Name: proptools.StringPtr(b.ramdiskModuleName()),
+		Type: proptools.StringPtr("compressed_cpio"),
+		Deps: b.properties.Ramdisk_deps,
+	})
+}
+
+// ramdiskFilesystemFactory creates the android_filesystem of the ramdisk_deps of a bootimg, which
+// packages the ramdisk variants of the modules.
+func ramdiskFilesystemFactory() android.Module {
+	module := &filesystem{depsImageVariation: android.RamdiskVariation}
+	initFilesystemModule(module)
 	return module
 }
 
@@ -97,7 +148,7 @@ type bootimgDep struct {
 var bootimgRamdiskDep = bootimgDep{kind: "ramdisk"}
 
 func (b *bootimg) DepsMutator(ctx android.BottomUpMutatorContext) {
-	ramdisk := proptools.String(b.properties.Ramdisk_module)
+	ramdisk := b.ramdiskModuleName()
 	if ramdisk != "" {
 		ctx.AddDependency(ctx.Module(), bootimgRamdiskDep, ramdisk)
 	}
@@ -129,7 +180,11 @@ func (b *bootimg) buildBootImage(ctx android.ModuleContext, vendor bool) android
 	output := android.PathForModuleOut(ctx, "unsigned", b.installFileName()).OutputPath
 
 	builder := android.NewRuleBuilder(pctx, ctx)
-	cmd := builder.Command().BuiltTool("mkbootimg")
+	cmd := builder.Command()
+	if env := ctx.Config().SourceDateEpochEnv(); env != "" {
+		cmd.Text(strings.TrimSpace(env))
+	}
+	cmd.BuiltTool("mkbootimg")
 
 	kernel := proptools.String(b.properties.Kernel_prebuilt)
 	if vendor && kernel != "" {
@@ -175,7 +230,7 @@ func (b *bootimg) buildBootImage(ctx android.ModuleContext, vendor bool) android
 	}
 	cmd.FlagWithArg("--header_version ", headerVersion)
 
-	ramdiskName := proptools.String(b.properties.Ramdisk_module)
+	ramdiskName := b.ramdiskModuleName()
 	if ramdiskName != "" {
 		ramdisk := ctx.GetDirectDepWithTag(ramdiskName, bootimgRamdiskDep)
 		if filesystem, ok := ramdisk.(*filesystem); ok {
@@ -252,9 +307,10 @@ func (b *bootimg) buildPropFile(ctx android.ModuleContext) (propFile android.Out
 	addPath("avb_avbtool", ctx.Config().HostToolPath(ctx, "avbtool"))
 	algorithm := proptools.StringDefault(b.properties.Avb_algorithm, "SHA256_RSA4096")
 	addStr("avb_algorithm", algorithm)
-	key := android.PathForModuleSrc(ctx, proptools.String(b.properties.Avb_private_key))
-	addPath("avb_key_path", key)
-	addStr("avb_add_hash_footer_args", "") // TODO(jiyong): add --rollback_index
+	b.avbKey = android.PathForModuleSrc(ctx, proptools.String(b.properties.Avb_private_key))
+	addPath("avb_key_path", b.avbKey)
+	avbAddHashFooterArgs := avbRollbackIndexArgs(ctx, b.properties.Avb_rollback_index, b.properties.Avb_rollback_index_location)
+	addStr("avb_add_hash_footer_args", strings.Join(avbAddHashFooterArgs, " "))
 	partitionName := proptools.StringDefault(b.properties.Partition_name, b.Name())
 	addStr("partition_name", partitionName)
 	addStr("avb_salt", b.salt())
@@ -293,6 +349,17 @@ func (b *bootimg) SignedOutputPath() android.Path {
 	return nil
 }
 
+func (b *bootimg) AvbInfo() *AvbInfo {
+	if !proptools.Bool(b.properties.Use_avb) {
+		return nil
+	}
+	return &AvbInfo{
+		PartitionName:         b.partitionName(),
+		PrivateKey:            b.avbKey,
+		RollbackIndexLocation: proptools.Int(b.properties.Avb_rollback_index_location),
+	}
+}
+
 var _ android.OutputFileProducer = (*bootimg)(nil)
 
 // Implements android.OutputFileProducer
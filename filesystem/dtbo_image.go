@@ -0,0 +1,256 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+func init() {
+	android.RegisterModuleType("dtbo_image", dtboImageFactory)
+}
+
+type dtboImage struct {
+	android.ModuleBase
+
+	properties dtboImageProperties
+
+	output     android.OutputPath
+	installDir android.InstallPath
+
+	// The private key that the image is signed with by avbtool.
+	avbKey android.Path
+}
+
+type dtboEntryProperties struct {
+	// Device tree overlay of the entry. A .dts file is compiled with dtc, other files (e.g. a
+	// .dtbo file or the output of a genrule) are used as they are.
+	Src *string `android:"path"`
+
+	// Value of the id field of the entry, passed to mkdtimg as --id. Either a number or a path
+	// in the device tree, e.g. "/:board_id". Default is 0.
+	Id *string
+
+	// Value of the rev field of the entry, passed to mkdtimg as --rev. Either a number or a path
+	// in the device tree, e.g. "/:board_rev". Default is 0.
+	Rev *string
+
+	// Values of the custom fields of the entry, passed to mkdtimg as --custom0 to --custom3.
+	Custom []string
+}
+
+type dtboImageProperties struct {
+	// Set the name of the output. Defaults to <module_name>.img.
+	Stem *string
+
+	// The overlays in the image, in the order they are placed in the image.
+	Entries []dtboEntryProperties
+
+	// Flags passed to dtc when compiling the .dts sources of the entries.
+	Dtc_flags []string
+
+	// Page size of the image. Default is 2048.
+	Page_size *int64
+
+	// When set to true, sign the image with avbtool. Default is false.
+	Use_avb *bool
+
+	// Path to the private key that avbtool will use to sign this image.
+	Avb_private_key *string `android:"path"`
+
+	// Signing algorithm for avbtool. Default is SHA256_RSA4096.
+	Avb_algorithm *string
+
+	// Rollback index of the image, passed to avbtool. Default used by avbtool is 0.
+	Avb_rollback_index *int64
+
+	// Rollback index location of the image. Must be 1, 2, etc. to chain the partition from a
+	// vbmeta image. Default used by avbtool is 0.
+	Avb_rollback_index_location *int64
+
+	// Name of the partition stored in vbmeta desc. Defaults to "dtbo".
+	Partition_name *string
+
+	// Size of the partition. Defaults to dynamically calculating the size.
+	Partition_size *int64
+}
+
+// dtbo_image is a device tree blob overlay image, as flashed to the dtbo partition. The overlays
+// are compiled with dtc and packed with mkdtimg.
+func dtboImageFactory() android.Module {
+	module := &dtboImage{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibFirst)
+	return module
+}
+
+func (d *dtboImage) DepsMutator(ctx android.BottomUpMutatorContext) {
+	// do nothing
+}
+
+func (d *dtboImage) installFileName() string {
+	return proptools.StringDefault(d.properties.Stem, d.BaseModuleName()+".img")
+}
+
+func (d *dtboImage) partitionName() string {
+	return proptools.StringDefault(d.properties.Partition_name, "dtbo")
+}
+
+func (d *dtboImage) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	if len(d.properties.Entries) == 0 {
+		ctx.PropertyErrorf("entries", "must not be empty")
+		return
+	}
+
+	builder := android.NewRuleBuilder(pctx, ctx)
+	d.output = android.PathForModuleOut(ctx, d.installFileName()).OutputPath
+	cmd := builder.Command().BuiltTool("mkdtimg").Text("create").Output(d.output)
+	pageSize := proptools.IntDefault(d.properties.Page_size, 2048)
+	if pageSize < 1 {
+		ctx.PropertyErrorf("page_size", "must be at least 1, was %d", pageSize)
+	}
+	cmd.FlagWithArg("--page_size=", strconv.Itoa(pageSize))
+
+	for i, entry := range d.properties.Entries {
+		if entry.Src == nil {
+			ctx.PropertyErrorf("entries", "src of entry %d must be set", i)
+			continue
+		}
+		cmd.Input(d.compileOverlay(ctx, i, android.PathForModuleSrc(ctx, *entry.Src)))
+		if id := proptools.String(entry.Id); id != "" {
+			cmd.FlagWithArg("--id=", proptools.ShellEscape(id))
+		}
+		if rev := proptools.String(entry.Rev); rev != "" {
+			cmd.FlagWithArg("--rev=", proptools.ShellEscape(rev))
+		}
+		if len(entry.Custom) > 4 {
+			ctx.PropertyErrorf("entries", "custom of entry %d can have at most 4 values, has %d", i, len(entry.Custom))
+		}
+		for j, custom := range entry.Custom {
+			cmd.FlagWithArg(fmt.Sprintf("--custom%d=", j), proptools.ShellEscape(custom))
+		}
+	}
+
+	if proptools.Bool(d.properties.Use_avb) {
+		d.signImage(ctx, builder)
+	}
+
+	builder.Build("build_dtbo_image", fmt.Sprintf("Creating dtbo image %s", d.BaseModuleName()))
+
+	d.installDir = android.PathForModuleInstall(ctx, "etc")
+	ctx.InstallFile(d.installDir, d.installFileName(), d.output)
+}
+
+// compileOverlay compiles the .dts source of the i-th entry to a .dtbo file. Other sources are
+// returned as they are.
+func (d *dtboImage) compileOverlay(ctx android.ModuleContext, i int, src android.Path) android.Path {
+	if src.Ext() != ".dts" {
+		return src
+	}
+	dtbo := android.PathForModuleOut(ctx, "dtbo", strconv.Itoa(i), strings.TrimSuffix(src.Base(), ".dts")+".dtbo")
+	builder := android.NewRuleBuilder(pctx, ctx)
+	builder.Command().BuiltTool("dtc").
+		Flag("-@"). // keep the symbols that the overlays are applied with
+		FlagWithArg("-I ", "dts").
+		FlagWithArg("-O ", "dtb").
+		Flags(d.properties.Dtc_flags).
+		FlagWithOutput("-o ", dtbo).
+		Input(src)
+	builder.Build(fmt.Sprintf("dtc_%d", i), fmt.Sprintf("Compiling device tree overlay %s", src.Base()))
+	return dtbo
+}
+
+// signImage adds a command that adds the hash footer of avbtool to the image.
+func (d *dtboImage) signImage(ctx android.ModuleContext, builder *android.RuleBuilder) {
+	d.avbKey = android.PathForModuleSrc(ctx, proptools.String(d.properties.Avb_private_key))
+	cmd := builder.Command().BuiltTool("avbtool").Text("add_hash_footer").
+		FlagWithArg("--partition_name ", d.partitionName())
+	if d.properties.Partition_size == nil {
+		cmd.Flag("--dynamic_partition_size")
+	} else {
+		cmd.FlagWithArg("--partition_size ", strconv.FormatInt(*d.properties.Partition_size, 10))
+	}
+	cmd.FlagWithInput("--key ", d.avbKey).
+		FlagWithArg("--algorithm ", proptools.StringDefault(d.properties.Avb_algorithm, "SHA256_RSA4096")).
+		FlagWithArg("--salt ", d.salt())
+	cmd.Flags(avbRollbackIndexArgs(ctx, d.properties.Avb_rollback_index, d.properties.Avb_rollback_index_location))
+	cmd.FlagWithArg("--image ", d.output.String())
+}
+
+// Calculates avb_salt from some input for deterministic output.
+func (d *dtboImage) salt() string {
+	input := []string{d.partitionName()}
+	for _, entry := range d.properties.Entries {
+		input = append(input, proptools.String(entry.Src), proptools.String(entry.Id), proptools.String(entry.Rev))
+		input = append(input, entry.Custom...)
+	}
+	return sha1sum(input)
+}
+
+var _ android.AndroidMkEntriesProvider = (*dtboImage)(nil)
+
+// Implements android.AndroidMkEntriesProvider
+func (d *dtboImage) AndroidMkEntries() []android.AndroidMkEntries {
+	return []android.AndroidMkEntries{android.AndroidMkEntries{
+		Class:      "ETC",
+		OutputFile: android.OptionalPathForPath(d.output),
+		ExtraEntries: []android.AndroidMkExtraEntriesFunc{
+			func(ctx android.AndroidMkExtraEntriesContext, entries *android.AndroidMkEntries) {
+				entries.SetString("LOCAL_MODULE_PATH", d.installDir.String())
+				entries.SetString("LOCAL_INSTALLED_MODULE_STEM", d.installFileName())
+			},
+		},
+	}}
+}
+
+var _ Filesystem = (*dtboImage)(nil)
+
+func (d *dtboImage) OutputPath() android.Path {
+	return d.output
+}
+
+func (d *dtboImage) SignedOutputPath() android.Path {
+	if proptools.Bool(d.properties.Use_avb) {
+		return d.OutputPath()
+	}
+	return nil
+}
+
+func (d *dtboImage) AvbInfo() *AvbInfo {
+	if !proptools.Bool(d.properties.Use_avb) {
+		return nil
+	}
+	return &AvbInfo{
+		PartitionName:         d.partitionName(),
+		PrivateKey:            d.avbKey,
+		RollbackIndexLocation: proptools.Int(d.properties.Avb_rollback_index_location),
+	}
+}
+
+var _ android.OutputFileProducer = (*dtboImage)(nil)
+
+// Implements android.OutputFileProducer
+func (d *dtboImage) OutputFiles(tag string) (android.Paths, error) {
+	if tag == "" {
+		return []android.Path{d.output}, nil
+	}
+	return nil, fmt.Errorf("unsupported module reference tag %q", tag)
+}
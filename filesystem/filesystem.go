@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"android/soong/android"
@@ -51,13 +53,39 @@ type filesystem struct {
 	// Function that filters PackagingSpecs returned by PackagingBase.GatherPackagingSpecs()
 	filterPackagingSpecs func(specs map[string]android.PackagingSpec)
 
+	// The image variation of the modules in deps, e.g. android.RamdiskVariation. The core
+	// variations are used if empty.
+	depsImageVariation string
+
 	output     android.OutputPath
 	installDir android.InstallPath
 
+	// The private key that the image is signed with by avbtool.
+	avbKey android.Path
+
+	// Report of the bytes installed into the image per module. Only set when partition_size is set.
+	partitionSizeReport android.Path
+
+	// The config.fs and file_contexts fragments generated from the metadata of the installed files.
+	configFsFragment     android.OutputPath
+	fileContextsFragment android.OutputPath
+
+	// The partition of the files whose mode, owner or capabilities are declared, if any.
+	fsConfigPartition string
+
 	// For testing. Keeps the result of CopyDepsToZip()
 	entries []string
 }
 
+type erofsProperties struct {
+	// Compression algorithm and level passed to mkfs.erofs, e.g. "lz4hc,9". Default is "lz4hc".
+	Compressor *string
+
+	// Path to the file with the per-path compression settings passed to mkfs.erofs as
+	// --compress-hints. Each line is "<physical cluster size in bytes> <regular expression>".
+	Compress_hints *string `android:"path"`
+}
+
 type symlinkDefinition struct {
 	Target *string
 	Name   *string
@@ -81,10 +109,25 @@ type filesystemProperties struct {
 	// Name of the partition stored in vbmeta desc. Defaults to the name of this module.
 	Partition_name *string
 
-	// Type of the filesystem. Currently, ext4, cpio, and compressed_cpio are supported. Default
-	// is ext4.
+	// Rollback index of the image, passed to avbtool. Default used by avbtool is 0.
+	Avb_rollback_index *int64
+
+	// Rollback index location of the image. Must be 1, 2, etc. to chain the partition from a
+	// vbmeta image. Default used by avbtool is 0.
+	Avb_rollback_index_location *int64
+
+	// Type of the filesystem. Currently, ext4, erofs, cpio, and compressed_cpio are supported.
+	// Default is ext4.
 	Type *string
 
+	// Size of the partition in bytes. When set, the build fails with the list of the modules that
+	// install the most bytes if the files installed into the image don't fit into the partition.
+	// Otherwise, the image is sized to fit its contents.
+	Partition_size *int64
+
+	// Properties of erofs images. Only used when type is erofs.
+	Erofs erofsProperties
+
 	// file_contexts file to make image. Currently, only ext4 is supported.
 	File_contexts *string `android:"path"`
 
@@ -129,6 +172,10 @@ var dependencyTag = struct {
 }{}
 
 func (f *filesystem) DepsMutator(ctx android.BottomUpMutatorContext) {
+	if f.depsImageVariation != "" {
+		f.AddDepsWithImageVariation(ctx, dependencyTag, f.depsImageVariation)
+		return
+	}
 	f.AddDeps(ctx, dependencyTag)
 }
 
@@ -136,6 +183,7 @@ type fsType int
 
 const (
 	ext4Type fsType = iota
+	erofsType
 	compressedCpioType
 	cpioType // uncompressed
 	unknown
@@ -146,6 +194,8 @@ func (f *filesystem) fsType(ctx android.ModuleContext) fsType {
 	switch typeStr {
 	case "ext4":
 		return ext4Type
+	case "erofs":
+		return erofsType
 	case "compressed_cpio":
 		return compressedCpioType
 	case "cpio":
@@ -164,7 +214,7 @@ var pctx = android.NewPackageContext("android/soong/filesystem")
 
 func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	switch f.fsType(ctx) {
-	case ext4Type:
+	case ext4Type, erofsType:
 		f.output = f.buildImageUsingBuildImage(ctx)
 	case compressedCpioType:
 		f.output = f.buildCpioImage(ctx, true)
@@ -174,6 +224,11 @@ func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		return
 	}
 
+	if f.fsType(ctx) != erofsType &&
+		(f.properties.Erofs.Compressor != nil || f.properties.Erofs.Compress_hints != nil) {
+		ctx.PropertyErrorf("erofs", "only supported when type is erofs.")
+	}
+
 	f.installDir = android.PathForModuleInstall(ctx, "etc")
 	ctx.InstallFile(f.installDir, f.installFileName(), f.output)
 }
@@ -247,8 +302,11 @@ func (f *filesystem) buildRootZip(ctx android.ModuleContext) android.OutputPath
 }
 
 func (f *filesystem) buildImageUsingBuildImage(ctx android.ModuleContext) android.OutputPath {
+	specs := f.gatherFilteredPackagingSpecs(ctx)
 	depsZipFile := android.PathForModuleOut(ctx, "deps.zip").OutputPath
-	f.entries = f.CopyDepsToZip(ctx, f.gatherFilteredPackagingSpecs(ctx), depsZipFile)
+	f.entries = f.CopyDepsToZip(ctx, specs, depsZipFile)
+	f.partitionSizeReport = f.checkPartitionSize(ctx, specs)
+	f.buildInstalledFileMetadata(ctx, specs)
 
 	builder := android.NewRuleBuilder(pctx, ctx)
 	depsBase := proptools.StringDefault(f.properties.Base_dir, ".")
@@ -266,6 +324,7 @@ func (f *filesystem) buildImageUsingBuildImage(ctx android.ModuleContext) androi
 		FlagWithArg("-d ", rootDir.String()). // zipsync wipes this. No need to clear.
 		Input(rootZip).
 		Input(rebasedDepsZip)
+	f.buildFsConfigFiles(ctx, builder, rootDir)
 
 	// run host_init_verifier
 	// Ideally we should have a concept of pluggable linters that verify the generated image.
@@ -277,12 +336,16 @@ func (f *filesystem) buildImageUsingBuildImage(ctx android.ModuleContext) androi
 
 	propFile, toolDeps := f.buildPropFile(ctx)
 	output := android.PathForModuleOut(ctx, f.installFileName()).OutputPath
-	builder.Command().BuiltTool("build_image").
+	cmd := builder.Command().BuiltTool("build_image").
 		Text(rootDir.String()). // input directory
 		Input(propFile).
 		Implicits(toolDeps).
 		Output(output).
 		Text(rootDir.String()) // directory where to find fs_config_files|dirs
+	if f.partitionSizeReport != nil {
+		// Fail with the list of the largest modules before build_image fails.
+		cmd.Implicit(f.partitionSizeReport)
+	}
 
 	// rootDir is not deleted. Might be useful for quick inspection.
 	builder.Build("build_filesystem_image", fmt.Sprintf("Creating filesystem %s", f.BaseModuleName()))
@@ -292,14 +355,23 @@ func (f *filesystem) buildImageUsingBuildImage(ctx android.ModuleContext) androi
 
 func (f *filesystem) buildFileContexts(ctx android.ModuleContext) android.OutputPath {
 	builder := android.NewRuleBuilder(pctx, ctx)
+	// The labels of the installed files are appended so that they take precedence.
+	fc := android.PathForModuleOut(ctx, "file_contexts")
+	builder.Command().Text("cat").
+		Input(android.PathForModuleSrc(ctx, proptools.String(f.properties.File_contexts))).
+		Input(f.fileContextsFragment).
+		Text(">").Output(fc)
 	fcBin := android.PathForModuleOut(ctx, "file_contexts.bin")
 	builder.Command().BuiltTool("sefcontext_compile").
 		FlagWithOutput("-o ", fcBin).
-		Input(android.PathForModuleSrc(ctx, proptools.String(f.properties.File_contexts)))
+		Input(fc)
 	builder.Build("build_filesystem_file_contexts", fmt.Sprintf("Creating filesystem file contexts for %s", f.BaseModuleName()))
 	return fcBin.OutputPath
 }
 
+// The compressors supported by mkfs.erofs, optionally followed by a compression level.
+var erofsCompressorRegexp = regexp.MustCompile(`^(lz4|lz4hc|lzma|deflate)(,[0-9]+)?$`)
+
 // Calculates avb_salt from entry list (sorted) for deterministic output.
 func (f *filesystem) salt() string {
 	return sha1sum(f.entries)
@@ -324,20 +396,41 @@ func (f *filesystem) buildPropFile(ctx android.ModuleContext) (propFile android.
 	// Type string that build_image.py accepts.
 	fsTypeStr := func(t fsType) string {
 		switch t {
-		// TODO(jiyong): add more types like f2fs, erofs, etc.
+		// TODO(jiyong): add more types like f2fs, etc.
 		case ext4Type:
 			return "ext4"
+		case erofsType:
+			return "erofs"
 		}
 		panic(fmt.Errorf("unsupported fs type %v", t))
 	}
 
-	addStr("fs_type", fsTypeStr(f.fsType(ctx)))
+	fsType := f.fsType(ctx)
+	addStr("fs_type", fsTypeStr(fsType))
 	addStr("mount_point", "/")
-	addStr("use_dynamic_partition_size", "true")
-	addPath("ext_mkuserimg", ctx.Config().HostToolPath(ctx, "mkuserimg_mke2fs"))
-	// b/177813163 deps of the host tools have to be added. Remove this.
-	for _, t := range []string{"mke2fs", "e2fsdroid", "tune2fs"} {
-		deps = append(deps, ctx.Config().HostToolPath(ctx, t))
+	if partitionSize := f.properties.Partition_size; partitionSize != nil {
+		addStr("partition_size", strconv.FormatInt(*partitionSize, 10))
+	} else {
+		addStr("use_dynamic_partition_size", "true")
+	}
+	switch fsType {
+	case ext4Type:
+		addPath("ext_mkuserimg", ctx.Config().HostToolPath(ctx, "mkuserimg_mke2fs"))
+		// b/177813163 deps of the host tools have to be added. Remove this.
+		for _, t := range []string{"mke2fs", "e2fsdroid", "tune2fs"} {
+			deps = append(deps, ctx.Config().HostToolPath(ctx, t))
+		}
+	case erofsType:
+		// build_image.py runs mkfs.erofs from the PATH.
+		deps = append(deps, ctx.Config().HostToolPath(ctx, "mkfs.erofs"))
+		compressor := proptools.StringDefault(f.properties.Erofs.Compressor, "lz4hc")
+		if !erofsCompressorRegexp.MatchString(compressor) {
+			ctx.PropertyErrorf("erofs.compressor", "%q is not a supported compressor", compressor)
+		}
+		addStr("erofs_default_compressor", compressor)
+		if hints := proptools.String(f.properties.Erofs.Compress_hints); hints != "" {
+			addPath("erofs_compress_hints", android.PathForModuleSrc(ctx, hints))
+		}
 	}
 
 	if proptools.Bool(f.properties.Use_avb) {
@@ -345,15 +438,17 @@ func (f *filesystem) buildPropFile(ctx android.ModuleContext) (propFile android.
 		addPath("avb_avbtool", ctx.Config().HostToolPath(ctx, "avbtool"))
 		algorithm := proptools.StringDefault(f.properties.Avb_algorithm, "SHA256_RSA4096")
 		addStr("avb_algorithm", algorithm)
-		key := android.PathForModuleSrc(ctx, proptools.String(f.properties.Avb_private_key))
-		addPath("avb_key_path", key)
+		f.avbKey = android.PathForModuleSrc(ctx, proptools.String(f.properties.Avb_private_key))
+		addPath("avb_key_path", f.avbKey)
 		avb_add_hashtree_footer_args := "--do_not_generate_fec"
 		if hashAlgorithm := proptools.String(f.properties.Avb_hash_algorithm); hashAlgorithm != "" {
 			avb_add_hashtree_footer_args += " --hash_algorithm " + hashAlgorithm
 		}
+		for _, arg := range avbRollbackIndexArgs(ctx, f.properties.Avb_rollback_index, f.properties.Avb_rollback_index_location) {
+			avb_add_hashtree_footer_args += " " + arg
+		}
 		addStr("avb_add_hashtree_footer_args", avb_add_hashtree_footer_args)
-		partitionName := proptools.StringDefault(f.properties.Partition_name, f.Name())
-		addStr("partition_name", partitionName)
+		addStr("partition_name", f.partitionName())
 		addStr("avb_salt", f.salt())
 	}
 
@@ -382,7 +477,7 @@ func (f *filesystem) buildPropFile(ctx android.ModuleContext) (propFile android.
 
 func (f *filesystem) buildCpioImage(ctx android.ModuleContext, compressed bool) android.OutputPath {
 	if proptools.Bool(f.properties.Use_avb) {
-		ctx.PropertyErrorf("use_avb", "signing compresed cpio image using avbtool is not supported."+
+		ctx.PropertyErrorf("use_avb", "signing cpio image using avbtool is not supported. "+
 			"Consider adding this to bootimg module and signing the entire boot image.")
 	}
 
@@ -390,8 +485,11 @@ func (f *filesystem) buildCpioImage(ctx android.ModuleContext, compressed bool)
 		ctx.PropertyErrorf("file_contexts", "file_contexts is not supported for compressed cpio image.")
 	}
 
+	specs := f.gatherFilteredPackagingSpecs(ctx)
 	depsZipFile := android.PathForModuleOut(ctx, "deps.zip").OutputPath
-	f.entries = f.CopyDepsToZip(ctx, f.gatherFilteredPackagingSpecs(ctx), depsZipFile)
+	f.entries = f.CopyDepsToZip(ctx, specs, depsZipFile)
+	f.partitionSizeReport = f.checkPartitionSize(ctx, specs)
+	f.buildInstalledFileMetadata(ctx, specs)
 
 	builder := android.NewRuleBuilder(pctx, ctx)
 	depsBase := proptools.StringDefault(f.properties.Base_dir, ".")
@@ -414,6 +512,9 @@ func (f *filesystem) buildCpioImage(ctx android.ModuleContext, compressed bool)
 	cmd := builder.Command().
 		BuiltTool("mkbootfs").
 		Text(rootDir.String()) // input directory
+	if f.partitionSizeReport != nil {
+		cmd.Implicit(f.partitionSizeReport)
+	}
 	if compressed {
 		cmd.Text("|").
 			BuiltTool("lz4").
@@ -451,8 +552,16 @@ var _ android.OutputFileProducer = (*filesystem)(nil)
 
 // Implements android.OutputFileProducer
 func (f *filesystem) OutputFiles(tag string) (android.Paths, error) {
-	if tag == "" {
+	switch tag {
+	case "":
 		return []android.Path{f.output}, nil
+	case ".partition_size_report":
+		if f.partitionSizeReport == nil {
+			return nil, fmt.Errorf("partition_size is not set")
+		}
+		return []android.Path{f.partitionSizeReport}, nil
+	case ".config_fs":
+		return []android.Path{f.configFsFragment}, nil
 	}
 	return nil, fmt.Errorf("unsupported module reference tag %q", tag)
 }
@@ -466,6 +575,22 @@ type Filesystem interface {
 	// Returns the output file that is signed by avbtool. If this module is not signed, returns
 	// nil.
 	SignedOutputPath() android.Path
+
+	// Returns how the output file is signed by avbtool, for chaining it from a vbmeta image. If
+	// this module is not signed, returns nil.
+	AvbInfo() *AvbInfo
+}
+
+// AvbInfo describes how a filesystem image is signed by avbtool.
+type AvbInfo struct {
+	// Name of the partition stored in the vbmeta desc.
+	PartitionName string
+
+	// The private key that the image is signed with.
+	PrivateKey android.Path
+
+	// Rollback index location of the image.
+	RollbackIndexLocation int
 }
 
 var _ Filesystem = (*filesystem)(nil)
@@ -481,6 +606,40 @@ func (f *filesystem) SignedOutputPath() android.Path {
 	return nil
 }
 
+func (f *filesystem) AvbInfo() *AvbInfo {
+	if !proptools.Bool(f.properties.Use_avb) {
+		return nil
+	}
+	return &AvbInfo{
+		PartitionName:         f.partitionName(),
+		PrivateKey:            f.avbKey,
+		RollbackIndexLocation: proptools.Int(f.properties.Avb_rollback_index_location),
+	}
+}
+
+func (f *filesystem) partitionName() string {
+	return proptools.StringDefault(f.properties.Partition_name, f.Name())
+}
+
+// avbRollbackIndexArgs returns the avbtool arguments for the avb_rollback_index and
+// avb_rollback_index_location properties of an image, which must not be negative.
+func avbRollbackIndexArgs(ctx android.ModuleContext, rollbackIndex, rollbackIndexLocation *int64) []string {
+	var args []string
+	if rollbackIndex != nil {
+		if *rollbackIndex < 0 {
+			ctx.PropertyErrorf("avb_rollback_index", "must be 0, 1, 2, ...")
+		}
+		args = append(args, "--rollback_index "+strconv.FormatInt(*rollbackIndex, 10))
+	}
+	if rollbackIndexLocation != nil {
+		if *rollbackIndexLocation < 0 {
+			ctx.PropertyErrorf("avb_rollback_index_location", "must be 0, 1, 2, ...")
+		}
+		args = append(args, "--rollback_index_location "+strconv.FormatInt(*rollbackIndexLocation, 10))
+	}
+	return args
+}
+
 // Filter the result of GatherPackagingSpecs to discard items targeting outside "system" partition.
 // Note that "apex" module installs its contents to "apex"(fake partition) as well
 // for symbol lookup by imitating "activated" paths.
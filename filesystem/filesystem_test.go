@@ -22,6 +22,7 @@ import (
 	"android/soong/cc"
 	"android/soong/etc"
 
+	"github.com/google/blueprint"
 	"github.com/google/blueprint/proptools"
 )
 
@@ -282,3 +283,434 @@ func TestFileSystemWithCoverageVariants(t *testing.T) {
 		t.Error("prebuilt should use cov variant of filesystem")
 	}
 }
+
+func TestFileSystemErofs(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureAddFile("compress_hints.txt", nil),
+	).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			type: "erofs",
+			erofs: {
+				compressor: "lz4hc,9",
+				compress_hints: "compress_hints.txt",
+			},
+		}
+	`)
+
+	module := result.ModuleForTests("myfilesystem", "android_common")
+	module.Output("myfilesystem.img")
+
+	prop := module.Rule("build_filesystem_prop").RuleParams.Command
+	android.AssertStringDoesContain(t, "fs_type", prop, `"fs_type=erofs"`)
+	android.AssertStringDoesContain(t, "compressor", prop, `"erofs_default_compressor=lz4hc,9"`)
+	android.AssertStringDoesContain(t, "compress hints", prop, `"erofs_compress_hints=compress_hints.txt"`)
+	android.AssertStringDoesNotContain(t, "mkuserimg", prop, "ext_mkuserimg")
+}
+
+func TestFileSystemErofsInvalid(t *testing.T) {
+	fixture.ExtendWithErrorHandler(android.FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`module "myfilesystem".*erofs.compressor: "zstd" is not a supported compressor`,
+		`module "myext4".*erofs: only supported when type is erofs`,
+	})).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			type: "erofs",
+			erofs: {
+				compressor: "zstd",
+			},
+		}
+
+		android_filesystem {
+			name: "myext4",
+			erofs: {
+				compressor: "lz4",
+			},
+		}
+	`)
+}
+
+func TestVbmetaChainedFilesystems(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterModuleType("vbmeta", vbmetaFactory)
+		}),
+	).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			use_avb: true,
+			avb_private_key: "mykey.pem",
+			avb_rollback_index: 5,
+			avb_rollback_index_location: 2,
+			partition_name: "mypartition",
+		}
+
+		vbmeta {
+			name: "myvbmeta",
+			private_key: "vbmeta_key.pem",
+			chained_filesystems: ["myfilesystem"],
+		}
+	`)
+
+	prop := result.ModuleForTests("myfilesystem", "android_common").Rule("build_filesystem_prop").RuleParams.Command
+	android.AssertStringDoesContain(t, "avb_add_hashtree_footer_args", prop,
+		"--rollback_index 5 --rollback_index_location 2")
+
+	vbmeta := result.ModuleForTests("myvbmeta", "android_arm64_armv8-a")
+	extract := vbmeta.Rule("vbmeta_extract_public_key").RuleParams.Command
+	android.AssertStringDoesContain(t, "extract_public_key", extract, "--key mykey.pem")
+	cmd := vbmeta.Rule("vbmeta").RuleParams.Command
+	android.AssertStringDoesContain(t, "chain_partition", cmd,
+		"--chain_partition mypartition:2:out/soong/.intermediates/myvbmeta/android_arm64_armv8-a/mypartition.avbpubkey")
+}
+
+func TestCpioFileSystemWithAvb(t *testing.T) {
+	fixture.ExtendWithErrorHandler(android.FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`module "mycpio".*use_avb: signing cpio image using avbtool is not supported`,
+		`module "mycompressedcpio".*use_avb: signing cpio image using avbtool is not supported`,
+	})).RunTestWithBp(t, `
+		android_filesystem {
+			name: "mycpio",
+			type: "cpio",
+			use_avb: true,
+			avb_private_key: "mykey.pem",
+		}
+
+		android_filesystem {
+			name: "mycompressedcpio",
+			type: "compressed_cpio",
+			use_avb: true,
+			avb_private_key: "mykey.pem",
+		}
+	`)
+}
+
+func TestBootimgRamdiskDeps(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterModuleType("bootimg", bootimgFactory)
+		}),
+	).RunTestWithBp(t, `
+		bootimg {
+			name: "myboot",
+			kernel_prebuilt: "kernel",
+			header_version: "4",
+			ramdisk_deps: ["myetc"],
+			use_avb: true,
+			avb_private_key: "mykey.pem",
+			avb_rollback_index: 3,
+			avb_rollback_index_location: 1,
+		}
+
+		prebuilt_etc {
+			name: "myetc",
+			src: "myetc.txt",
+			ramdisk_available: true,
+		}
+	`)
+
+	ramdisk := result.ModuleForTests("myboot_ramdisk", "android_common")
+	ramdisk.Output("myboot_ramdisk.img")
+	android.AssertDeepEquals(t, "ramdisk entries", []string{"etc/myetc"},
+		ramdisk.Module().(*filesystem).entries)
+
+	etcRamdisk := result.ModuleForTests("myetc", "android_ramdisk_arm64_armv8-a").Module()
+	var deps []blueprint.Module
+	result.VisitDirectDeps(ramdisk.Module(), func(dep blueprint.Module) {
+		if result.ModuleName(dep) == "myetc" {
+			deps = append(deps, dep)
+		}
+	})
+	android.AssertDeepEquals(t, "myetc variants", []blueprint.Module{etcRamdisk}, deps)
+
+	boot := result.ModuleForTests("myboot", "android_arm64_armv8-a")
+	cmd := boot.Rule("build_bootimg").RuleParams.Command
+	android.AssertStringDoesContain(t, "ramdisk", cmd,
+		"--ramdisk out/soong/.intermediates/myboot_ramdisk/android_common/myboot_ramdisk.img")
+
+	prop := android.ContentFromFileRuleForTests(t, boot.Output("prop"))
+	android.AssertStringDoesContain(t, "avb_add_hash_footer_args", prop,
+		"avb_add_hash_footer_args=--rollback_index 3 --rollback_index_location 1\n")
+}
+
+func TestFileSystemPartitionSize(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureMergeMockFs(android.MockFS{
+			"foo.conf": nil,
+			"bar.conf": nil,
+		}),
+	).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			deps: ["foo", "bar"],
+			partition_size: 1048576,
+		}
+
+		prebuilt_etc {
+			name: "foo",
+			src: "foo.conf",
+		}
+
+		prebuilt_etc {
+			name: "bar",
+			src: "bar.conf",
+		}
+	`)
+
+	module := result.ModuleForTests("myfilesystem", "android_common")
+	ledger := android.ContentFromFileRuleForTests(t, module.Output("partition_size/ledger.txt"))
+	android.AssertStringDoesContain(t, "ledger", ledger, "bar\tetc/bar.conf\t")
+	android.AssertStringDoesContain(t, "ledger", ledger, "foo\tetc/foo.conf\t")
+
+	check := module.Rule("check_partition_size")
+	android.AssertStringDoesContain(t, "partition size", check.RuleParams.Command, "--partition-size 1048576")
+	report := module.Output("partition_size/report.txt").Output
+	android.AssertStringListContains(t, "image deps", android.PathsRelativeToTop(module.Output("myfilesystem.img").Implicits),
+		report.RelativeToTop().String())
+
+	prop := module.Rule("build_filesystem_prop").RuleParams.Command
+	android.AssertStringDoesContain(t, "partition_size", prop, `"partition_size=1048576"`)
+	android.AssertStringDoesNotContain(t, "dynamic size", prop, "use_dynamic_partition_size")
+}
+
+func TestLogicalPartitionMiscInfo(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureMergeMockFs(android.MockFS{
+			"system.img":  nil,
+			"vendor.img":  nil,
+			"product.img": nil,
+		}),
+	).RunTestWithBp(t, `
+		logical_partition {
+			name: "super",
+			size: "4294967296",
+			default_group: [
+				{
+					name: "product",
+					filesystem: "product.img",
+				},
+			],
+			groups: [
+				{
+					name: "group_a",
+					size: "2147483648",
+					partitions: [
+						{
+							name: "system",
+							filesystem: "system.img",
+						},
+						{
+							name: "vendor",
+							filesystem: "vendor.img",
+						},
+					],
+				},
+			],
+		}
+	`)
+
+	miscInfo := android.ContentFromFileRuleForTests(t,
+		result.ModuleForTests("super", "android_arm64_armv8-a").Output("super_misc_info.txt"))
+	for _, line := range []string{
+		"use_dynamic_partitions=true",
+		"super_partition_size=4294967296",
+		"dynamic_partition_list=product system vendor",
+		"super_partition_groups=group_a",
+		"super_group_a_group_size=2147483648",
+		"super_group_a_partition_list=system vendor",
+	} {
+		android.AssertStringDoesContain(t, "misc_info", miscInfo, line+"\n")
+	}
+}
+
+func TestDtboImage(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterModuleType("dtbo_image", dtboImageFactory)
+		}),
+		android.FixtureMergeMockFs(android.MockFS{
+			"board_a.dts":  nil,
+			"board_b.dtbo": nil,
+			"testkey.pem":  nil,
+		}),
+	).RunTestWithBp(t, `
+		dtbo_image {
+			name: "mydtbo",
+			entries: [
+				{
+					src: "board_a.dts",
+					id: "0x100",
+					rev: "1",
+				},
+				{
+					src: "board_b.dtbo",
+					id: "/:board_id",
+					custom: ["2"],
+				},
+			],
+			use_avb: true,
+			avb_private_key: "testkey.pem",
+			avb_rollback_index_location: 2,
+		}
+	`)
+
+	module := result.ModuleForTests("mydtbo", "android_arm64_armv8-a")
+	dtc := module.Rule("dtc_0")
+	android.AssertStringDoesContain(t, "dtc", dtc.RuleParams.Command, "-@ -I dts -O dtb")
+
+	cmd := module.Rule("build_dtbo_image").RuleParams.Command
+	android.AssertStringDoesContain(t, "mkdtimg", cmd, "mkdtimg create")
+	android.AssertStringDoesContain(t, "page size", cmd, "--page_size=2048")
+	android.AssertStringDoesContain(t, "entry a", cmd, "board_a.dtbo --id=0x100 --rev=1")
+	android.AssertStringDoesContain(t, "entry b", cmd, "board_b.dtbo --id=/:board_id --custom0=2")
+	android.AssertStringDoesContain(t, "avbtool", cmd, "add_hash_footer --partition_name dtbo --dynamic_partition_size")
+	android.AssertStringDoesContain(t, "rollback index location", cmd, "--rollback_index_location 2")
+
+	info := module.Module().(Filesystem).AvbInfo()
+	android.AssertStringEquals(t, "partition name", "dtbo", info.PartitionName)
+	android.AssertIntEquals(t, "rollback index location", 2, info.RollbackIndexLocation)
+}
+
+func TestFileSystemInstalledFileMetadata(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureMergeMockFs(android.MockFS{
+			"file_contexts": nil,
+			"foo.conf":      nil,
+			"bar.conf":      nil,
+			"system/core/libcutils/include/private/android_filesystem_config.h": nil,
+		}),
+	).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			deps: ["foo", "bar"],
+			file_contexts: "file_contexts",
+		}
+
+		prebuilt_etc {
+			name: "foo",
+			src: "foo.conf",
+			installed_file_attributes: [
+				{
+					mode: "640",
+					user: "AID_SYSTEM",
+					group: "AID_SHELL",
+				},
+				{
+					files: ["etc/foo.conf"],
+					capabilities: ["CAP_NET_RAW", "SYS_NICE"],
+					selinux_label: "u:object_r:foo_conf_file:s0",
+				},
+			],
+		}
+
+		prebuilt_etc {
+			name: "bar",
+			src: "bar.conf",
+		}
+	`)
+
+	module := result.ModuleForTests("myfilesystem", "android_common")
+	configFs := android.ContentFromFileRuleForTests(t, module.Output("installed_files/config.fs"))
+	android.AssertStringEquals(t, "config.fs", "[system/etc/foo.conf]\n"+
+		"mode: 0640\n"+
+		"user: AID_SYSTEM\n"+
+		"group: AID_SHELL\n"+
+		"caps: NET_RAW SYS_NICE\n\n", configFs)
+
+	fileContexts := module.Output("installed_files/file_contexts")
+	android.AssertStringEquals(t, "file_contexts", `/system/etc/foo\.conf u:object_r:foo_conf_file:s0`+"\n",
+		android.ContentFromFileRuleForTests(t, fileContexts))
+	android.AssertPathsRelativeToTopEquals(t, "file_contexts inputs",
+		[]string{"file_contexts", fileContexts.Output.RelativeToTop().String()},
+		module.Output("file_contexts").Inputs)
+
+	outputs, err := module.Module().(*filesystem).OutputFiles(".config_fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	android.AssertPathsRelativeToTopEquals(t, ".config_fs", []string{"out/soong/.intermediates/myfilesystem/android_common/installed_files/config.fs"}, outputs)
+
+	// The fs_config of the installed files is generated into the image.
+	cmd := module.Rule("build_filesystem_image").RuleParams.Command
+	android.AssertStringDoesContain(t, "fs_config_generator", cmd,
+		"fs_config_generator fsconfig --aid-header system/core/libcutils/include/private/android_filesystem_config.h "+
+			"--capability-header bionic/libc/kernel/uapi/linux/capability.h --partition system "+
+			"--all-partitions "+fsConfigOtherPartitions+" --files "+
+			"--out_file out/soong/.intermediates/myfilesystem/android_common/installed_files/fs_config_files "+
+			"out/soong/.intermediates/myfilesystem/android_common/installed_files/config.fs")
+	android.AssertStringDoesContain(t, "fs_config_files", cmd,
+		"cp -f out/soong/.intermediates/myfilesystem/android_common/installed_files/fs_config_files "+
+			"out/soong/.intermediates/myfilesystem/android_common/root/system/etc/fs_config_files")
+}
+
+func TestFileSystemInstalledFileLabelsWithoutFileContexts(t *testing.T) {
+	android.GroupFixturePreparers(
+		fixture,
+		android.FixtureMergeMockFs(android.MockFS{
+			"foo.conf": nil,
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsOneErrorPattern(
+		`file_contexts: must be set to label the installed files with their selinux_label, got labels for \["/system/etc/foo.conf"\]`,
+	)).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			deps: ["foo"],
+		}
+
+		prebuilt_etc {
+			name: "foo",
+			src: "foo.conf",
+			installed_file_attributes: [{
+				selinux_label: "u:object_r:foo_conf_file:s0",
+			}],
+		}
+	`)
+}
+
+func TestInstalledFileMetadataInvalid(t *testing.T) {
+	android.GroupFixturePreparers(
+		fixture,
+		android.FixtureMergeMockFs(android.MockFS{
+			"foo.conf": nil,
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`installed_file_attributes\[0\]\.mode: "0999" is not an octal mode`,
+		`installed_file_attributes\[0\]\.user: "system" is not an AID`,
+		`installed_file_attributes\[0\]\.selinux_label: "foo_conf_file" is not a file label`,
+	})).RunTestWithBp(t, `
+		prebuilt_etc {
+			name: "foo",
+			src: "foo.conf",
+			installed_file_attributes: [{
+				mode: "0999",
+				user: "system",
+				selinux_label: "foo_conf_file",
+			}],
+		}
+	`)
+
+	android.GroupFixturePreparers(
+		fixture,
+		android.FixtureMergeMockFs(android.MockFS{
+			"foo.conf": nil,
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsOneErrorPattern(
+		`installed_file_attributes\[0\]\.files: "etc/bar.conf" is not installed by this module`,
+	)).RunTestWithBp(t, `
+		prebuilt_etc {
+			name: "foo",
+			src: "foo.conf",
+			installed_file_attributes: [{
+				files: ["etc/bar.conf"],
+				mode: "0644",
+			}],
+		}
+	`)
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+// This file generates the fs_config and file_contexts fragments of the image from the metadata
+// that the modules declare for their installed files with the installed_file_attributes property.
+// The config.fs fragment is passed to fs_config_generator with the config.fs files of the product
+// to generate the fs_config_files of the image, and is available as the ".config_fs" output of the
+// module. The file_contexts fragment is appended to file_contexts.
+
+const (
+	fsConfigAidHeaderPath        = "system/core/libcutils/include/private/android_filesystem_config.h"
+	fsConfigCapabilityHeaderPath = "bionic/libc/kernel/uapi/linux/capability.h"
+
+	// The partitions whose files fs_config_generator leaves out of the fs_config of system.
+	fsConfigOtherPartitions = "vendor,oem,odm,vendor_dlkm,odm_dlkm,system_dlkm,product,system_ext"
+)
+
+// buildInstalledFileMetadata writes the config.fs and file_contexts fragments for the metadata of
+// the specs. The fragments are empty if no metadata is declared.
+func (f *filesystem) buildInstalledFileMetadata(ctx android.ModuleContext, specs map[string]android.PackagingSpec) {
+	var configFs, fileContexts strings.Builder
+	var labeled []string
+	f.fsConfigPartition = ""
+	for _, rel := range android.SortedKeys(specs) {
+		spec := specs[rel]
+		metadata := spec.Metadata()
+		if metadata.Empty() {
+			continue
+		}
+		// config.fs paths are relative to the root of the device, file_contexts paths absolute.
+		path := spec.Partition() + "/" + rel
+		if metadata.HasFsConfig() {
+			if f.fsConfigPartition == "" {
+				f.fsConfigPartition = spec.Partition()
+			} else if f.fsConfigPartition != spec.Partition() {
+				ctx.ModuleErrorf("installed_file_attributes of %q can't be combined with the ones of the "+
+					"files in the %s partition, the fs_config of an image is for one partition",
+					path, f.fsConfigPartition)
+			}
+			configFs.WriteString("[" + path + "]\n")
+			if metadata.Mode != "" {
+				configFs.WriteString("mode: " + metadata.Mode + "\n")
+			}
+			if metadata.User != "" {
+				configFs.WriteString("user: " + metadata.User + "\n")
+			}
+			if metadata.Group != "" {
+				configFs.WriteString("group: " + metadata.Group + "\n")
+			}
+			if len(metadata.Capabilities) > 0 {
+				configFs.WriteString("caps: " + strings.Join(metadata.Capabilities, " ") + "\n")
+			}
+			configFs.WriteString("\n")
+		}
+		if metadata.SelinuxLabel != "" {
+			fileContexts.WriteString(regexp.QuoteMeta("/"+path) + " " + metadata.SelinuxLabel + "\n")
+			labeled = append(labeled, "/"+path)
+		}
+	}
+	if len(labeled) > 0 && proptools.String(f.properties.File_contexts) == "" {
+		ctx.PropertyErrorf("file_contexts", "must be set to label the installed files with their "+
+			"selinux_label, got labels for %q", labeled)
+	}
+
+	f.configFsFragment = android.PathForModuleOut(ctx, "installed_files", "config.fs").OutputPath
+	android.WriteFileRuleVerbatim(ctx, f.configFsFragment, configFs.String())
+	f.fileContextsFragment = android.PathForModuleOut(ctx, "installed_files", "file_contexts").OutputPath
+	android.WriteFileRuleVerbatim(ctx, f.fileContextsFragment, fileContexts.String())
+}
+
+// buildFsConfigFiles adds the commands that generate the fs_config_files of the image from the
+// config.fs files of the product and the config.fs fragment of the installed files, and copy it to
+// <partition>/etc/fs_config_files in rootDir, from where build_image applies it to the files
+// of the partition. Nothing is added if no installed file declares its mode, owner or
+// capabilities, or if the tree doesn't define the AIDs.
+func (f *filesystem) buildFsConfigFiles(ctx android.ModuleContext, builder *android.RuleBuilder, rootDir android.OutputPath) {
+	if f.fsConfigPartition == "" {
+		return
+	}
+	aidHeader := android.ExistentPathForSource(ctx, fsConfigAidHeaderPath)
+	if !aidHeader.Valid() {
+		return
+	}
+
+	fsConfigFiles := android.PathForModuleOut(ctx, "installed_files", "fs_config_files")
+	cmd := builder.Command().BuiltTool("fs_config_generator").
+		Text("fsconfig").
+		FlagWithInput("--aid-header ", aidHeader.Path()).
+		FlagWithInput("--capability-header ", android.PathForSource(ctx, fsConfigCapabilityHeaderPath)).
+		FlagWithArg("--partition ", f.fsConfigPartition)
+	if f.fsConfigPartition == "system" {
+		cmd.FlagWithArg("--all-partitions ", fsConfigOtherPartitions)
+	}
+	cmd.Flag("--files").
+		FlagWithOutput("--out_file ", fsConfigFiles)
+	for _, configFs := range ctx.DeviceConfig().TargetFSConfigGen() {
+		cmd.Input(android.PathForSource(ctx, configFs))
+	}
+	cmd.Input(f.configFsFragment)
+
+	etcDir := rootDir.Join(ctx, f.fsConfigPartition, "etc")
+	builder.Command().Text("mkdir -p").Text(etcDir.String())
+	builder.Command().Text("cp -f").Input(fsConfigFiles).Text(etcDir.Join(ctx, "fs_config_files").String())
+}
@@ -17,6 +17,7 @@ package filesystem
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/google/blueprint/proptools"
 
@@ -34,6 +35,9 @@ type logicalPartition struct {
 
 	output     android.OutputPath
 	installDir android.InstallPath
+
+	// misc_info.txt compatible description of the dynamic partition groups, for the OTA tools.
+	miscInfo android.WritablePath
 }
 
 type logicalPartitionProperties struct {
@@ -133,6 +137,7 @@ func (l *logicalPartition) GenerateAndroidBuildActions(ctx android.ModuleContext
 
 	groupNames := make(map[string]bool)
 	partitionNames := make(map[string]bool)
+	var allPartitions []string
 
 	addPartitionsToGroup := func(partitions []partitionProperties, gName string) {
 		for _, part := range partitions {
@@ -144,6 +149,7 @@ func (l *logicalPartition) GenerateAndroidBuildActions(ctx android.ModuleContext
 				ctx.PropertyErrorf("groups.partitions.name", "already exists")
 			} else {
 				partitionNames[pName] = true
+				allPartitions = append(allPartitions, pName)
 			}
 			// Get size of the partition by reading the -size.txt file
 			pSize := fmt.Sprintf("$(cat %s)", sparseImageSizes[pName])
@@ -154,6 +160,9 @@ func (l *logicalPartition) GenerateAndroidBuildActions(ctx android.ModuleContext
 
 	addPartitionsToGroup(l.properties.Default_group, "default")
 
+	var groupList []string
+	var groupInfo []string
+
 	for _, group := range l.properties.Groups {
 		gName := proptools.String(group.Name)
 		if gName == "" {
@@ -176,6 +185,15 @@ func (l *logicalPartition) GenerateAndroidBuildActions(ctx android.ModuleContext
 		cmd.FlagWithArg("--group=", gName+":"+gSize)
 
 		addPartitionsToGroup(group.Partitions, gName)
+
+		var groupPartitions []string
+		for _, part := range group.Partitions {
+			groupPartitions = append(groupPartitions, proptools.String(part.Name))
+		}
+		groupList = append(groupList, gName)
+		groupInfo = append(groupInfo,
+			fmt.Sprintf("super_%s_group_size=%s", gName, gSize),
+			fmt.Sprintf("super_%s_partition_list=%s", gName, strings.Join(groupPartitions, " ")))
 	}
 
 	l.output = android.PathForModuleOut(ctx, l.installFileName()).OutputPath
@@ -183,10 +201,38 @@ func (l *logicalPartition) GenerateAndroidBuildActions(ctx android.ModuleContext
 
 	builder.Build("build_logical_partition", fmt.Sprintf("Creating %s", l.BaseModuleName()))
 
+	l.miscInfo = l.buildMiscInfo(ctx, size, allPartitions, groupList, groupInfo)
+
 	l.installDir = android.PathForModuleInstall(ctx, "etc")
 	ctx.InstallFile(l.installDir, l.installFileName(), l.output)
 }
 
+// buildMiscInfo writes the dynamic partition entries of misc_info.txt for the partitions and groups
+// of the logical partition, in the format that build_super_image.py and ota_from_target_files read.
+// groupInfo has the super_<group>_group_size and super_<group>_partition_list entries.
+func (l *logicalPartition) buildMiscInfo(ctx android.ModuleContext, size string, partitions, groups, groupInfo []string) android.WritablePath {
+	lines := []string{
+		"use_dynamic_partitions=true",
+		"build_super_partition=true",
+		"lpmake=lpmake",
+		"super_metadata_device_list=super",
+		"super_block_devices=super",
+	}
+	if size != "auto" {
+		lines = append(lines,
+			"super_super_device_size="+size,
+			"super_partition_size="+size)
+	}
+	lines = append(lines,
+		"dynamic_partition_list="+strings.Join(partitions, " "),
+		"super_partition_groups="+strings.Join(groups, " "))
+	lines = append(lines, groupInfo...)
+
+	miscInfo := android.PathForModuleOut(ctx, l.BaseModuleName()+"_misc_info.txt")
+	android.WriteFileRule(ctx, miscInfo, strings.Join(lines, "\n"))
+	return miscInfo
+}
+
 // Add a rule that converts the filesystem for the given partition to the given rule builder. The
 // path to the sparse file and the text file having the size of the partition are returned.
 func sparseFilesystem(ctx android.ModuleContext, p partitionProperties, builder *android.RuleBuilder) (sparseImg android.OutputPath, sizeTxt android.OutputPath) {
@@ -232,12 +278,19 @@ func (l *logicalPartition) SignedOutputPath() android.Path {
 	return nil // logical partition is not signed by itself
 }
 
+func (l *logicalPartition) AvbInfo() *AvbInfo {
+	return nil
+}
+
 var _ android.OutputFileProducer = (*logicalPartition)(nil)
 
 // Implements android.OutputFileProducer
 func (l *logicalPartition) OutputFiles(tag string) (android.Paths, error) {
-	if tag == "" {
+	switch tag {
+	case "":
 		return []android.Path{l.output}, nil
+	case ".misc_info":
+		return []android.Path{l.miscInfo}, nil
 	}
 	return nil, fmt.Errorf("unsupported module reference tag %q", tag)
 }
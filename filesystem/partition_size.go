@@ -0,0 +1,94 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"android/soong/android"
+)
+
+// This file keeps a ledger of the bytes that each module installs into a filesystem image, and
+// checks it against partition_size before the image is built. mkfs only reports that the image
+// doesn't fit, while the check lists the modules that take up the most space.
+
+// packagingSpecOwners returns the names of the direct deps that install each of the specs. When
+// several deps install the same path, the first one wins, like in GatherPackagingSpecs.
+func (f *filesystem) packagingSpecOwners(ctx android.ModuleContext, specs map[string]android.PackagingSpec) map[string]string {
+	owners := make(map[string]string)
+	ctx.VisitDirectDeps(func(child android.Module) {
+		if pi, ok := ctx.OtherModuleDependencyTag(child).(android.PackagingItem); !ok || !pi.IsPackagingItem() {
+			return
+		}
+		for _, ps := range child.TransitivePackagingSpecs() {
+			rel := ps.RelPathInPackage()
+			if _, ok := specs[rel]; !ok {
+				continue
+			}
+			if _, ok := owners[rel]; !ok {
+				owners[rel] = ctx.OtherModuleName(child)
+			}
+		}
+	})
+	return owners
+}
+
+// checkPartitionSize writes the ledger of the files installed into the image and returns a report
+// of the installed bytes per module that can only be built if they fit into partition_size. Returns
+// nil if partition_size is not set.
+func (f *filesystem) checkPartitionSize(ctx android.ModuleContext, specs map[string]android.PackagingSpec) android.Path {
+	if f.properties.Partition_size == nil {
+		return nil
+	}
+	partitionSize := *f.properties.Partition_size
+	if partitionSize < 1 {
+		ctx.PropertyErrorf("partition_size", "must be at least 1, was %d", partitionSize)
+		return nil
+	}
+
+	owners := f.packagingSpecOwners(ctx, specs)
+	var ledger strings.Builder
+	var srcs android.Paths
+	for _, rel := range android.SortedKeys(specs) {
+		ps := specs[rel]
+		src := ps.SrcPath()
+		if src == nil {
+			// Symlinks take up no space worth reporting.
+			continue
+		}
+		owner, ok := owners[rel]
+		if !ok {
+			owner = ctx.ModuleName()
+		}
+		fmt.Fprintf(&ledger, "%s\t%s\t%s\n", owner, rel, src.String())
+		srcs = append(srcs, src)
+	}
+	ledgerFile := android.PathForModuleOut(ctx, "partition_size", "ledger.txt")
+	android.WriteFileRule(ctx, ledgerFile, ledger.String())
+
+	report := android.PathForModuleOut(ctx, "partition_size", "report.txt")
+	builder := android.NewRuleBuilder(pctx, ctx)
+	builder.Command().
+		BuiltTool("check_partition_size").
+		FlagWithArg("--partition-name ", f.partitionName()).
+		FlagWithArg("--partition-size ", strconv.FormatInt(partitionSize, 10)).
+		FlagWithInput("--ledger ", ledgerFile).
+		FlagWithOutput("--report ", report).
+		Implicits(srcs)
+	builder.Build("check_partition_size", fmt.Sprintf("Checking partition size of %s", f.BaseModuleName()))
+	return report
+}
@@ -110,6 +110,10 @@ func (r *rawBinary) SignedOutputPath() android.Path {
 	return nil
 }
 
+func (r *rawBinary) AvbInfo() *AvbInfo {
+	return nil
+}
+
 var _ android.OutputFileProducer = (*rawBinary)(nil)
 
 // Implements android.OutputFileProducer
@@ -65,7 +65,7 @@ func (s *systemImage) buildLinkerConfigFile(ctx android.ModuleContext, root andr
 	})
 
 	builder := android.NewRuleBuilder(pctx, ctx)
-	linkerconfig.BuildLinkerConfig(ctx, builder, input, otherModules, output)
+	linkerconfig.BuildLinkerConfig(ctx, builder, android.Paths{input}, otherModules, output)
 	builder.Build("conv_linker_config", "Generate linker config protobuf "+output.String())
 	return output
 }
@@ -35,6 +35,9 @@ type vbmeta struct {
 
 	output     android.OutputPath
 	installDir android.InstallPath
+
+	// The private key that the image is signed with.
+	key android.Path
 }
 
 type vbmetaProperties struct {
@@ -63,6 +66,11 @@ type vbmetaProperties struct {
 
 	// List of chained partitions that this vbmeta deletages the verification.
 	Chained_partitions []chainedPartitionProperties
+
+	// List of filesystem modules that this vbmeta delegates the verification to. The filesystem
+	// modules have to be signed (use_avb: true). The partition name, the rollback index location
+	// and the public key of the chained partitions are taken from the filesystem modules.
+	Chained_filesystems []string
 }
 
 type chainedPartitionProperties struct {
@@ -97,9 +105,11 @@ type vbmetaDep struct {
 }
 
 var vbmetaPartitionDep = vbmetaDep{kind: "partition"}
+var vbmetaChainedFilesystemDep = vbmetaDep{kind: "chained_filesystem"}
 
 func (v *vbmeta) DepsMutator(ctx android.BottomUpMutatorContext) {
 	ctx.AddDependency(ctx.Module(), vbmetaPartitionDep, v.properties.Partitions...)
+	ctx.AddDependency(ctx.Module(), vbmetaChainedFilesystemDep, v.properties.Chained_filesystems...)
 }
 
 func (v *vbmeta) installFileName() string {
@@ -121,8 +131,8 @@ func (v *vbmeta) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	builder := android.NewRuleBuilder(pctx, ctx)
 	cmd := builder.Command().BuiltTool("avbtool").Text("make_vbmeta_image")
 
-	key := android.PathForModuleSrc(ctx, proptools.String(v.properties.Private_key))
-	cmd.FlagWithInput("--key ", key)
+	v.key = android.PathForModuleSrc(ctx, proptools.String(v.properties.Private_key))
+	cmd.FlagWithInput("--key ", v.key)
 
 	algorithm := proptools.StringDefault(v.properties.Algorithm, "SHA256_RSA4096")
 	cmd.FlagWithArg("--algorithm ", algorithm)
@@ -174,6 +184,35 @@ func (v *vbmeta) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		cmd.Implicit(publicKey)
 	}
 
+	for _, p := range ctx.GetDirectDepsWithTag(vbmetaChainedFilesystemDep) {
+		f, ok := p.(Filesystem)
+		if !ok {
+			ctx.PropertyErrorf("chained_filesystems", "%q(type: %s) is not supported",
+				p.Name(), ctx.OtherModuleType(p))
+			continue
+		}
+		info := f.AvbInfo()
+		if info == nil {
+			ctx.PropertyErrorf("chained_filesystems", "%q(type: %s) is not signed. Use `use_avb: true`",
+				p.Name(), ctx.OtherModuleType(p))
+			continue
+		}
+		if info.RollbackIndexLocation < 1 {
+			ctx.PropertyErrorf("chained_filesystems", "%q must have a rollback index location of 1 or higher",
+				p.Name())
+			continue
+		}
+		publicKey, ok := extractedPublicKeys[info.PartitionName]
+		if !ok {
+			ctx.PropertyErrorf("chained_filesystems", "%q has no public key for partition %q",
+				p.Name(), info.PartitionName)
+			continue
+		}
+		cmd.FlagWithArg("--chain_partition ",
+			fmt.Sprintf("%s:%d:%s", info.PartitionName, info.RollbackIndexLocation, publicKey.String()))
+		cmd.Implicit(publicKey)
+	}
+
 	cmd.FlagWithOutput("--output ", v.output)
 
 	// libavb expects to be able to read the maximum vbmeta size, so we must provide a partition
@@ -234,6 +273,29 @@ func (v *vbmeta) extractPublicKeys(ctx android.ModuleContext) map[string]android
 
 		result[name] = publicKeyFile
 	}
+
+	for _, p := range ctx.GetDirectDepsWithTag(vbmetaChainedFilesystemDep) {
+		f, ok := p.(Filesystem)
+		if !ok || f.AvbInfo() == nil {
+			// Reported by GenerateAndroidBuildActions.
+			continue
+		}
+		info := f.AvbInfo()
+		if _, ok := result[info.PartitionName]; ok {
+			ctx.PropertyErrorf("chained_filesystems", "partition name %q is duplicated", info.PartitionName)
+			continue
+		}
+
+		publicKeyFile := android.PathForModuleOut(ctx, info.PartitionName+".avbpubkey").OutputPath
+
+		builder.Command().
+			BuiltTool("avbtool").
+			Text("extract_public_key").
+			FlagWithInput("--key ", info.PrivateKey).
+			FlagWithOutput("--output ", publicKeyFile)
+
+		result[info.PartitionName] = publicKeyFile
+	}
 	builder.Build("vbmeta_extract_public_key", fmt.Sprintf("Extract public keys for %s", ctx.ModuleName()))
 	return result
 }
@@ -264,6 +326,14 @@ func (v *vbmeta) SignedOutputPath() android.Path {
 	return v.OutputPath() // vbmeta is always signed
 }
 
+func (v *vbmeta) AvbInfo() *AvbInfo {
+	return &AvbInfo{
+		PartitionName:         v.partitionName(),
+		PrivateKey:            v.key,
+		RollbackIndexLocation: proptools.IntDefault(v.properties.Rollback_index_location, 0),
+	}
+}
+
 var _ android.OutputFileProducer = (*vbmeta)(nil)
 
 // Implements android.OutputFileProducer
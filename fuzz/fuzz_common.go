@@ -352,6 +352,13 @@ type FuzzProperties struct {
 	// Optional list of seed files to be installed to the fuzz target's output
 	// directory.
 	Corpus []string `android:"path"`
+	// Optional list of modules that contribute seed files to the corpus, e.g. a
+	// filegroup or genrule of seeds shared by the fuzz targets of a library. The
+	// corpus of a fuzz target listed here is reused. Seeds listed more than once
+	// are only packaged once.
+	Fuzz_corpus_providers []string `android:"path"`
+	// Maximum size in bytes of the packaged seed corpus. Defaults to 256 MiB.
+	Corpus_max_size *int64
 	// Optional list of data files to be installed to the fuzz target's output
 	// directory. Directory structure relative to the module is preserved.
 	Data []string `android:"path"`
@@ -365,10 +372,17 @@ type FuzzProperties struct {
 	Fuzz_config *FuzzConfig
 }
 
+// The default maximum size of a packaged seed corpus, see Corpus_max_size.
+const defaultCorpusMaxSize = 256 << 20
+
 type FuzzPackagedModule struct {
-	FuzzProperties        FuzzProperties
-	Dictionary            android.Path
-	Corpus                android.Paths
+	FuzzProperties  FuzzProperties
+	Dictionary      android.Path
+	Corpus          android.Paths
+	CorpusProviders []string
+	Framework       Framework
+	// Whether the fuzz target is instrumented for coverage rather than fuzzing.
+	Coverage              bool
 	CorpusIntermediateDir android.Path
 	Config                android.Path
 	Data                  android.Paths
@@ -433,7 +447,48 @@ func IsValid(fuzzModule FuzzModule) bool {
 	return true
 }
 
+// FuzzMetadata describes the contents of the package of a fuzz target to the
+// fuzzing infrastructure. It is packaged as <fuzz target>_metadata.json.
+type FuzzMetadata struct {
+	Name         string          `json:"name"`
+	HostOrTarget string          `json:"host_or_target"`
+	Arch         string          `json:"arch"`
+	Framework    Framework       `json:"framework,omitempty"`
+	Coverage     bool            `json:"coverage,omitempty"`
+	Corpus       *CorpusMetadata `json:"corpus,omitempty"`
+	Dictionary   string          `json:"dictionary,omitempty"`
+	Data         string          `json:"data,omitempty"`
+	Config       string          `json:"config,omitempty"`
+}
+
+type CorpusMetadata struct {
+	Zip       string   `json:"zip"`
+	Entries   []string `json:"entries"`
+	Providers []string `json:"providers,omitempty"`
+	MaxSize   int      `json:"max_size"`
+}
+
+func (m *FuzzMetadata) String() string {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	return string(b) + "\n"
+}
+
 func (s *FuzzPackager) PackageArtifacts(ctx android.SingletonContext, module android.Module, fuzzModule FuzzPackagedModule, archDir android.OutputPath, builder *android.RuleBuilder) []FileToZip {
+	metadata := &FuzzMetadata{
+		Name:         module.Name(),
+		HostOrTarget: "target",
+		Arch:         module.Target().Arch.ArchType.String(),
+		Framework:    fuzzModule.Framework,
+		Coverage:     fuzzModule.Coverage,
+	}
+	if module.Target().Os.Class == android.Host {
+		metadata.HostOrTarget = "host"
+	}
+
 	// Package the corpora into a zipfile.
 	var files []FileToZip
 	if fuzzModule.Corpus != nil {
@@ -443,7 +498,26 @@ func (s *FuzzPackager) PackageArtifacts(ctx android.SingletonContext, module and
 			FlagWithOutput("-o ", corpusZip)
 		rspFile := corpusZip.ReplaceExtension(ctx, "rsp")
 		command.FlagWithRspFileInputList("-r ", rspFile, fuzzModule.Corpus)
+
+		// Fail the packaging rather than upload a corpus that is too large for
+		// the fuzzing infrastructure.
+		maxSize := proptools.IntDefault(fuzzModule.FuzzProperties.Corpus_max_size, defaultCorpusMaxSize)
+		builder.Command().
+			Textf(`if [ $(wc -c < %s) -gt %d ]; then`, corpusZip, maxSize).
+			Textf(`echo "%s: the seed corpus is larger than corpus_max_size (%d bytes)" >&2;`, module.Name(), maxSize).
+			Textf(`rm -f %s; exit 1; fi`, corpusZip)
 		files = append(files, FileToZip{corpusZip, ""})
+
+		entries := make([]string, 0, len(fuzzModule.Corpus))
+		for _, entry := range fuzzModule.Corpus {
+			entries = append(entries, entry.Base())
+		}
+		metadata.Corpus = &CorpusMetadata{
+			Zip:       corpusZip.Base(),
+			Entries:   entries,
+			Providers: fuzzModule.CorpusProviders,
+			MaxSize:   maxSize,
+		}
 	}
 
 	// Package the data into a zipfile.
@@ -457,18 +531,26 @@ func (s *FuzzPackager) PackageArtifacts(ctx android.SingletonContext, module and
 			command.FlagWithInput("-f ", f)
 		}
 		files = append(files, FileToZip{dataZip, ""})
+		metadata.Data = dataZip.Base()
 	}
 
 	// The dictionary.
 	if fuzzModule.Dictionary != nil {
 		files = append(files, FileToZip{fuzzModule.Dictionary, ""})
+		metadata.Dictionary = fuzzModule.Dictionary.Base()
 	}
 
 	// Additional fuzz config.
 	if fuzzModule.Config != nil && IsValidConfig(fuzzModule, module.Name()) {
 		files = append(files, FileToZip{fuzzModule.Config, ""})
+		metadata.Config = fuzzModule.Config.Base()
 	}
 
+	// The metadata of the package for the fuzzing infrastructure.
+	metadataFile := archDir.Join(ctx, module.Name()+"_metadata.json")
+	android.WriteFileRuleVerbatim(ctx, metadataFile, metadata.String())
+	files = append(files, FileToZip{metadataFile, ""})
+
 	return files
 }
 
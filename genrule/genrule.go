@@ -82,6 +82,19 @@ var (
 		Rspfile:        "${tmpZip}.rsp",
 		RspfileContent: "${zipArgs}",
 	}, "tmpZip", "genDir", "zipArgs")
+
+	// Used by genrule to extract the zip file of an out_dirs directory into the directory.
+	genruleOutDirExtract = pctx.AndroidStaticRule("genruleOutDirExtract", blueprint.RuleParams{
+		Command:     "${zipSync} -d ${outDir} -l ${out} ${in}",
+		CommandDeps: []string{"${zipSync}"},
+	}, "outDir")
+
+	// Used by genrule to check that a checked-in bootstrap source is a copy of the output.
+	genruleBootstrapSrcCheck = pctx.AndroidStaticRule("genruleBootstrapSrcCheck", blueprint.RuleParams{
+		Command: `if ! cmp -s ${in} ${bootstrapSrc}; then ` +
+			`echo "${bootstrapSrc} is out of date, update it with: cp ${in} ${bootstrapSrc}" >&2; exit 1; fi && ` +
+			`touch ${out}`,
+	}, "bootstrapSrc")
 )
 
 func init() {
@@ -151,6 +164,21 @@ type generatorProperties struct {
 
 	// input files to exclude
 	Exclude_srcs []string `android:"path,arch_variant"`
+
+	// If set, the command is only allowed to read its inputs, its tools, the system directories
+	// and these directories, relative to the module directory. The file accesses of the command are
+	// traced and the build fails if the command accesses any other file. Only supported on Linux
+	// hosts, elsewhere the command is not traced.
+	Allowed_read_dirs []string
+
+	// Checked-in copies of outputs, for a self-hosted tool that is built from the outputs of this
+	// module. Such a tool can't generate its own sources, so its two stages are separate modules:
+	// the first stage is built from bootstrap_srcs and listed in tools, and the second stage is
+	// built from the outputs and used by the other modules. Soong doesn't create the first stage,
+	// and a tool listed in tools that is built from the outputs is reported as a dependency cycle.
+	// Each of bootstrap_srcs must end with the path of an output relative to the genDir, and the
+	// build fails if it differs from the output, so that the first stage doesn't go stale.
+	Bootstrap_srcs []string `android:"path"`
 }
 
 type Module struct {
@@ -184,6 +212,12 @@ type Module struct {
 	outputFiles android.Paths
 	outputDeps  android.Paths
 
+	// The lists of the files extracted into the out_dirs directories.
+	outDirLists android.Paths
+
+	// The timestamps of the checks of the bootstrap_srcs.
+	bootstrapSrcChecks android.Paths
+
 	subName string
 	subDir  string
 
@@ -203,6 +237,11 @@ type generateTask struct {
 	genDir     android.WritablePath
 	extraTools android.Paths // dependencies on tools used by the generator
 
+	// Directories with unknown contents generated by the command, and the zip files that track
+	// their contents.
+	outDirs    android.WritablePaths
+	outDirZips android.WritablePaths
+
 	cmd string
 	// For gensrsc sharding.
 	shard  int
@@ -429,9 +468,19 @@ func (g *Module) generateCommonBuildActions(ctx android.ModuleContext) {
 		cmd = g.CmdModifier(ctx, cmd)
 	}
 
+	var allowedReadDirs []string
+	for _, dir := range g.properties.Allowed_read_dirs {
+		path := android.ExistentPathForSource(ctx, ctx.ModuleDir(), dir)
+		if !path.Valid() {
+			ctx.PropertyErrorf("allowed_read_dirs", "directory %q does not exist", dir)
+			continue
+		}
+		allowedReadDirs = append(allowedReadDirs, path.String())
+	}
+
 	// Generate tasks, either from genrule or gensrcs.
 	for _, task := range g.taskGenerator(ctx, cmd, srcFiles) {
-		if len(task.out) == 0 {
+		if len(task.out) == 0 && len(task.outDirs) == 0 {
 			ctx.ModuleErrorf("must have at least one output file")
 			return
 		}
@@ -453,6 +502,16 @@ func (g *Module) generateCommonBuildActions(ctx android.ModuleContext) {
 
 		// Use a RuleBuilder to create a rule that runs the command inside an sbox sandbox.
 		rule := android.NewRuleBuilder(pctx, ctx).Sbox(task.genDir, manifestPath).SandboxTools()
+		if g.properties.Allowed_read_dirs != nil && ctx.Config().BuildOS == android.Linux {
+			rule.SandboxFsProfile(allowedReadDirs)
+		}
+		if len(task.outDirs) > 0 {
+			// Create the out_dirs directories, the command may only write files into them.
+			mkdirCmd := rule.Command().Text("mkdir -p")
+			for _, dir := range task.outDirs {
+				mkdirCmd.Text(proptools.ShellEscape(mkdirCmd.PathForOutput(dir)))
+			}
+		}
 		cmd := rule.Command()
 
 		for _, out := range task.out {
@@ -553,6 +612,15 @@ func (g *Module) generateCommonBuildActions(ctx android.ModuleContext) {
 			cmd.ImplicitDepFile(task.depFile)
 		}
 
+		// Zip the contents of each out_dirs directory so that ninja can track them.
+		for i, dir := range task.outDirs {
+			zipCmd := rule.Command().
+				BuiltTool("soong_zip").
+				FlagWithOutput("-o ", task.outDirZips[i])
+			zipCmd.FlagWithArg("-C ", zipCmd.PathForOutput(dir)).
+				FlagWithArg("-D ", zipCmd.PathForOutput(dir))
+		}
+
 		// Create the rule to run the genrule command inside sbox.
 		rule.Build(name, desc)
 
@@ -570,6 +638,23 @@ func (g *Module) generateCommonBuildActions(ctx android.ModuleContext) {
 		} else {
 			outputFiles = append(outputFiles, task.out...)
 		}
+
+		// Extract the zip files of the out_dirs directories into the directories for the modules
+		// that use them, e.g. through export_include_dirs.
+		for i, dir := range task.outDirs {
+			list := dir.ReplaceExtension(ctx, "list")
+			ctx.Build(pctx, android.BuildParams{
+				Rule:        genruleOutDirExtract,
+				Description: "extract " + dir.Rel(),
+				Input:       task.outDirZips[i],
+				Output:      list,
+				Args: map[string]string{
+					"outDir": dir.String(),
+				},
+			})
+			outputFiles = append(outputFiles, task.outDirZips[i])
+			g.outDirLists = append(g.outDirLists, list)
+		}
 	}
 
 	if len(copyFrom) > 0 {
@@ -589,6 +674,45 @@ func (g *Module) generateCommonBuildActions(ctx android.ModuleContext) {
 	}
 
 	g.outputFiles = outputFiles.Paths()
+	g.bootstrapSrcChecks = g.checkBootstrapSrcs(ctx)
+}
+
+// checkBootstrapSrcs returns the timestamps of the rules that check that the bootstrap_srcs are
+// copies of the outputs.
+func (g *Module) checkBootstrapSrcs(ctx android.ModuleContext) android.Paths {
+	outputs := make(map[string]android.Path)
+	for _, out := range g.outputFiles {
+		outputs[out.Rel()] = out
+	}
+
+	var checks android.Paths
+	for _, src := range android.PathsForModuleSrc(ctx, g.properties.Bootstrap_srcs) {
+		var out android.Path
+		for _, rel := range android.SortedKeys(outputs) {
+			if src.Rel() == rel || strings.HasSuffix(src.Rel(), "/"+rel) {
+				out = outputs[rel]
+				break
+			}
+		}
+		if out == nil {
+			ctx.PropertyErrorf("bootstrap_srcs", "%q is not the copy of an output, the outputs are %q",
+				src.Rel(), android.SortedKeys(outputs))
+			continue
+		}
+		check := android.PathForModuleOut(ctx, "bootstrap_srcs", src.Rel()+".timestamp")
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        genruleBootstrapSrcCheck,
+			Description: "check bootstrap source " + src.Rel(),
+			Input:       out,
+			Implicit:    src,
+			Output:      check,
+			Args: map[string]string{
+				"bootstrapSrc": src.String(),
+			},
+		})
+		checks = append(checks, check)
+	}
+	return checks
 }
 
 func (g *Module) GenerateAndroidBuildActions(ctx android.ModuleContext) {
@@ -610,14 +734,17 @@ func (g *Module) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	// the genrules on AOSP. That will make things simpler to look at the graph in the common
 	// case. For larger sets of outputs, inject a phony target in between to limit ninja file
 	// growth.
-	if len(g.outputFiles) <= 6 {
-		g.outputDeps = g.outputFiles
+	outputDeps := append(android.Paths{}, g.outputFiles...)
+	outputDeps = append(outputDeps, g.outDirLists...)
+	outputDeps = append(outputDeps, g.bootstrapSrcChecks...)
+	if len(outputDeps) <= 6 {
+		g.outputDeps = outputDeps
 	} else {
 		phonyFile := android.PathForModuleGen(ctx, "genrule-phony")
 		ctx.Build(pctx, android.BuildParams{
 			Rule:   blueprint.Phony,
 			Output: phonyFile,
-			Inputs: g.outputFiles,
+			Inputs: outputDeps,
 		})
 		g.outputDeps = android.Paths{phonyFile}
 	}
@@ -712,6 +839,14 @@ func NewGenSrcs() *Module {
 		if s := properties.Shard_size; s != nil {
 			shardSize = int(*s)
 		}
+		shardJobs := 1
+		if j := properties.Shard_jobs; j != nil {
+			if *j < 1 {
+				ctx.PropertyErrorf("shard_jobs", "must be at least 1, was %d", *j)
+			} else {
+				shardJobs = int(*j)
+			}
+		}
 
 		// gensrcs rules can easily hit command line limits by repeating the command for
 		// every input file.  Shard the input files into groups.
@@ -779,7 +914,7 @@ func NewGenSrcs() *Module {
 				command = fmt.Sprintf("bash -c %v", proptools.ShellEscape(command))
 				commands = append(commands, command)
 			}
-			fullCommand := strings.Join(commands, " && ")
+			fullCommand := joinShardCommands(commands, shardJobs)
 
 			var outputDepfile android.WritablePath
 			var extraTools android.Paths
@@ -816,6 +951,38 @@ func NewGenSrcs() *Module {
 	return g
 }
 
+// joinShardCommands returns a command that runs the commands of a shard, at most jobs of them in
+// parallel.  The command fails if any of the commands fail.
+func joinShardCommands(commands []string, jobs int) string {
+	if jobs <= 1 {
+		return strings.Join(commands, " && ")
+	}
+
+	var batches []string
+	for start := 0; start < len(commands); start += jobs {
+		end := start + jobs
+		if end > len(commands) {
+			end = len(commands)
+		}
+		if end-start == 1 {
+			batches = append(batches, commands[start])
+			continue
+		}
+		// Start each command of the batch in the background and then wait for all of them,
+		// waiting on each pid separately to catch the failure of any of them.
+		var parts []string
+		for i, command := range commands[start:end] {
+			parts = append(parts, fmt.Sprintf("%s & p%d=$$!", command, i))
+		}
+		parts = append(parts, "s=0")
+		for i := range commands[start:end] {
+			parts = append(parts, fmt.Sprintf("{ wait $$p%d || s=1; }", i))
+		}
+		batches = append(batches, "{ "+strings.Join(parts, "; ")+"; [ $$s -eq 0 ]; }")
+	}
+	return strings.Join(batches, " && ")
+}
+
 func GenSrcsFactory() android.Module {
 	m := NewGenSrcs()
 	android.InitAndroidModule(m)
@@ -829,6 +996,10 @@ type genSrcsProperties struct {
 
 	// maximum number of files that will be passed on a single command line.
 	Shard_size *int64
+
+	// maximum number of files of a shard that the command is run on in parallel.  Defaults to 1,
+	// which runs the command on the files of a shard one after the other.
+	Shard_jobs *int64
 }
 
 type bazelGensrcsAttributes struct {
@@ -853,12 +1024,31 @@ func NewGenRule() *Module {
 			}
 			outs[i] = outPath
 		}
+		var outDirs, outDirZips android.WritablePaths
+		for _, dir := range properties.Out_dirs {
+			// The same rules as the paths of out, which are checked by PathForModuleGen, except that
+			// $(genDir) itself can't be used either.
+			if clean := filepath.Clean(dir); clean == "." || clean == ".." || strings.HasPrefix(clean, "../") ||
+				filepath.IsAbs(dir) || strings.Contains(dir, "$") {
+				ctx.PropertyErrorf("out_dirs", "%q must be a subdirectory of $(genDir)", dir)
+				continue
+			}
+			zip := android.PathForModuleGen(ctx, dir+".zip")
+			// Without out, $(depfile) is named after the zip of the first directory.
+			if depFile == nil && Bool(ctx.Module().(*Module).properties.Depfile) {
+				depFile = zip.ReplaceExtension(ctx, "d")
+			}
+			outDirs = append(outDirs, android.PathForModuleGen(ctx, dir))
+			outDirZips = append(outDirZips, zip)
+		}
 		return []generateTask{{
-			in:      srcFiles,
-			out:     outs,
-			depFile: depFile,
-			genDir:  android.PathForModuleGen(ctx),
-			cmd:     rawCommand,
+			in:         srcFiles,
+			out:        outs,
+			depFile:    depFile,
+			genDir:     android.PathForModuleGen(ctx),
+			outDirs:    outDirs,
+			outDirZips: outDirZips,
+			cmd:        rawCommand,
 		}}
 	}
 
@@ -876,6 +1066,13 @@ func GenRuleFactory() android.Module {
 type genRuleProperties struct {
 	// names of the output files that will be generated
 	Out []string
+
+	// names of the output directories, relative to $(genDir), into which the command generates files
+	// that are not known in advance. The contents of each directory are tracked by a zip file named
+	// after the directory, e.g. gen.zip for gen, which is one of the outputs of the module. The
+	// contents are also extracted into the directory for modules that use it, e.g. through
+	// export_include_dirs.
+	Out_dirs []string
 }
 
 type bazelGenruleAttributes struct {
@@ -594,6 +594,41 @@ func TestGenSrcs(t *testing.T) {
 				"out/soong/.intermediates/gen/gen/gensrcs/in3.h",
 			},
 		},
+		{
+			name: "shard jobs",
+			prop: `
+				tools: ["tool"],
+				srcs: ["in1.txt", "in2.txt", "in3.txt"],
+				cmd: "$(location) $(in) > $(out)",
+				shard_jobs: 2,
+			`,
+			cmds: []string{
+				"{ bash -c '__SBOX_SANDBOX_DIR__/tools/out/bin/tool in1.txt > __SBOX_SANDBOX_DIR__/out/in1.h' & p0=$!; " +
+					"bash -c '__SBOX_SANDBOX_DIR__/tools/out/bin/tool in2.txt > __SBOX_SANDBOX_DIR__/out/in2.h' & p1=$!; " +
+					"s=0; { wait $p0 || s=1; }; { wait $p1 || s=1; }; [ $s -eq 0 ]; } && " +
+					"bash -c '__SBOX_SANDBOX_DIR__/tools/out/bin/tool in3.txt > __SBOX_SANDBOX_DIR__/out/in3.h'",
+			},
+			deps: []string{
+				"out/soong/.intermediates/gen/gen/gensrcs/in1.h",
+				"out/soong/.intermediates/gen/gen/gensrcs/in2.h",
+				"out/soong/.intermediates/gen/gen/gensrcs/in3.h",
+			},
+			files: []string{
+				"out/soong/.intermediates/gen/gen/gensrcs/in1.h",
+				"out/soong/.intermediates/gen/gen/gensrcs/in2.h",
+				"out/soong/.intermediates/gen/gen/gensrcs/in3.h",
+			},
+		},
+		{
+			name: "invalid shard jobs",
+			prop: `
+				tools: ["tool"],
+				srcs: ["in1.txt"],
+				cmd: "$(location) $(in) > $(out)",
+				shard_jobs: 0,
+			`,
+			err: "must be at least 1, was 0",
+		},
 	}
 
 	for _, test := range testcases {
@@ -724,6 +759,179 @@ func TestGenruleDefaults(t *testing.T) {
 	android.AssertDeepEquals(t, "srcs", expectedSrcs, gen.properties.Srcs)
 }
 
+func TestGenruleAllowedReadDirs(t *testing.T) {
+	bp := `
+		genrule {
+			name: "gen",
+			tools: ["tool"],
+			srcs: ["in1"],
+			out: ["out"],
+			cmd: "$(location) $(in) data > $(out)",
+			allowed_read_dirs: ["data"],
+		}
+	`
+	result := android.GroupFixturePreparers(
+		prepareForGenRuleTest,
+		android.FixtureAddFile("data/a.txt", nil),
+	).RunTestWithBp(t, testGenruleBp()+bp)
+	if result.Config.BuildOS != android.Linux {
+		t.Skip("file accesses are only traced on Linux")
+	}
+
+	gen := result.ModuleForTests("gen", "")
+	cmd := gen.Output("out").RuleParams.Command
+	android.AssertStringDoesContain(t, "sbox command", cmd, "--fsatrace prebuilts/build-tools/linux-x86/bin/fsatrace")
+	android.AssertStringDoesContain(t, "sbox command", cmd, "--fs-profile out/soong/.intermediates/gen/genrule.sbox.fs_profile")
+
+	profile := android.ContentFromFileRuleForTests(t, gen.Output("genrule.sbox.fs_profile"))
+	android.AssertStringDoesContain(t, "fs profile", profile, "in1\n")
+	android.AssertStringDoesContain(t, "fs profile", profile, "data")
+}
+
+func TestGenruleAllowedReadDirsMissing(t *testing.T) {
+	bp := `
+		genrule {
+			name: "gen",
+			out: ["out"],
+			cmd: "ls data > $(out)",
+			allowed_read_dirs: ["data"],
+		}
+	`
+	prepareForGenRuleTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`allowed_read_dirs: directory "data" does not exist`)).
+		RunTestWithBp(t, bp)
+}
+
+func TestGenruleBootstrapSrcs(t *testing.T) {
+	bp := `
+		genrule {
+			name: "gen",
+			tools: ["tool"],
+			srcs: ["in1"],
+			out: ["out", "out2"],
+			cmd: "$(location) $(in) > $(out)",
+			bootstrap_srcs: ["bootstrap/out"],
+		}
+	`
+	result := android.GroupFixturePreparers(
+		prepareForGenRuleTest,
+		android.FixtureAddFile("bootstrap/out", nil),
+	).RunTestWithBp(t, testGenruleBp()+bp)
+
+	gen := result.ModuleForTests("gen", "")
+	check := gen.Output("bootstrap_srcs/bootstrap/out.timestamp")
+	android.AssertPathRelativeToTopEquals(t, "input", "out/soong/.intermediates/gen/gen/out", check.Input)
+	android.AssertStringEquals(t, "bootstrap src", "bootstrap/out", check.Args["bootstrapSrc"])
+
+	android.AssertPathsRelativeToTopEquals(t, "output deps", []string{
+		"out/soong/.intermediates/gen/gen/out",
+		"out/soong/.intermediates/gen/gen/out2",
+		"out/soong/.intermediates/gen/bootstrap_srcs/bootstrap/out.timestamp",
+	}, gen.Module().(*Module).outputDeps)
+}
+
+func TestGenruleBootstrapSrcsInvalid(t *testing.T) {
+	bp := `
+		genrule {
+			name: "gen",
+			tools: ["tool"],
+			srcs: ["in1"],
+			out: ["out"],
+			cmd: "$(location) $(in) > $(out)",
+			bootstrap_srcs: ["bootstrap/other"],
+		}
+	`
+	android.GroupFixturePreparers(
+		prepareForGenRuleTest,
+		android.FixtureAddFile("bootstrap/other", nil),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`bootstrap_srcs: "bootstrap/other" is not the copy of an output, the outputs are \["out"\]`)).
+		RunTestWithBp(t, testGenruleBp()+bp)
+}
+
+func TestGenruleOutDirs(t *testing.T) {
+	bp := `
+		genrule {
+			name: "gen",
+			tools: ["tool"],
+			srcs: ["in1"],
+			out: ["out"],
+			out_dirs: ["gen"],
+			cmd: "$(location) $(in) $(genDir)/gen > $(out)",
+		}
+	`
+	result := prepareForGenRuleTest.RunTestWithBp(t, testGenruleBp()+bp)
+
+	gen := result.ModuleForTests("gen", "")
+	module := gen.Module().(*Module)
+
+	android.AssertPathsRelativeToTopEquals(t, "output files", []string{
+		"out/soong/.intermediates/gen/gen/out",
+		"out/soong/.intermediates/gen/gen/gen.zip",
+	}, module.outputFiles)
+
+	manifest := android.RuleBuilderSboxProtoForTests(t, gen.Output("genrule.sbox.textproto"))
+	cmd := manifest.Commands[0].GetCommand()
+	android.AssertStringDoesContain(t, "mkdir", cmd, "mkdir -p __SBOX_SANDBOX_DIR__/out/gen &&")
+	android.AssertStringDoesContain(t, "zip", cmd,
+		"soong_zip -o __SBOX_SANDBOX_DIR__/out/gen.zip -C __SBOX_SANDBOX_DIR__/out/gen -D __SBOX_SANDBOX_DIR__/out/gen")
+	android.AssertStringEquals(t, "raw command", "__SBOX_SANDBOX_DIR__/tools/out/bin/tool in1 __SBOX_SANDBOX_DIR__/out/gen > __SBOX_SANDBOX_DIR__/out/out",
+		module.rawCommands[0])
+
+	// The contents of the zip file are extracted into the directory for the users of the module.
+	extract := gen.Output("gen/gen.list")
+	android.AssertPathRelativeToTopEquals(t, "extract input", "out/soong/.intermediates/gen/gen/gen.zip", extract.Input)
+	android.AssertStringEquals(t, "extract dir", "out/soong/.intermediates/gen/gen/gen", extract.Args["outDir"])
+	android.AssertPathsRelativeToTopEquals(t, "deps", []string{
+		"out/soong/.intermediates/gen/gen/out",
+		"out/soong/.intermediates/gen/gen/gen.zip",
+		"out/soong/.intermediates/gen/gen/gen.list",
+	}, module.GeneratedDeps())
+}
+
+func TestGenruleOutDirsDepfile(t *testing.T) {
+	bp := `
+		genrule {
+			name: "gen",
+			out_dirs: ["gen"],
+			depfile: true,
+			cmd: "touch $(genDir)/gen/a $(depfile)",
+		}
+
+		genrule {
+			name: "gen_no_depfile",
+			out_dirs: ["gen"],
+			cmd: "touch $(genDir)/gen/a",
+		}
+	`
+	result := prepareForGenRuleTest.RunTestWithBp(t, bp)
+
+	gen := result.ModuleForTests("gen", "").Output("genrule.sbox.textproto")
+	android.AssertPathRelativeToTopEquals(t, "depfile", "out/soong/.intermediates/gen/gen/gen.d", gen.Depfile)
+
+	noDepfile := result.ModuleForTests("gen_no_depfile", "").Output("genrule.sbox.textproto")
+	android.AssertBoolEquals(t, "no depfile", true, noDepfile.Depfile == nil)
+}
+
+func TestGenruleOutDirsInvalid(t *testing.T) {
+	for _, dir := range []string{"", ".", "..", "../gen", "gen/..", "gen/../..", "/gen", "$(out)"} {
+		t.Run(dir, func(t *testing.T) {
+			bp := fmt.Sprintf(`
+				genrule {
+					name: "gen",
+					out_dirs: [%q],
+					cmd: "touch $(genDir)/gen/a",
+				}
+			`, dir)
+			prepareForGenRuleTest.
+				ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+					regexp.QuoteMeta(fmt.Sprintf(`out_dirs: %q must be a subdirectory of $(genDir)`, dir)))).
+				RunTestWithBp(t, bp)
+		})
+	}
+}
+
 func TestGenruleAllowMissingDependencies(t *testing.T) {
 	bp := `
 		output {
@@ -0,0 +1,394 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+// This file implements the go_library, go_binary and go_test module types, which build host Go
+// tools in Soong rather than in the blueprint bootstrap. Unlike bootstrap_go_binary, their sources
+// may be generated by other Soong modules, they are built in a sandbox, and they are installed,
+// dist'd and tested like the other host modules.
+//
+// A go_library only provides its sources to the go_binary and go_test modules that depend on it,
+// which build them with `go build` or `go test -c` in a GOPATH laid out in the sandbox.
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/tradefed"
+)
+
+var pctx = android.NewPackageContext("android/soong/golang")
+
+func init() {
+	pctx.Import("android/soong/android")
+
+	RegisterGoBuildComponents(android.InitRegistrationContext)
+}
+
+func RegisterGoBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterModuleType("go_library", GoLibraryFactory)
+	ctx.RegisterModuleType("go_binary", GoBinaryFactory)
+	ctx.RegisterModuleType("go_test", GoTestFactory)
+}
+
+var PrepareForTestWithGoBuildComponents = android.FixtureRegisterWithContext(RegisterGoBuildComponents)
+
+// GoPackage is a Go package, by its import path and sources.
+type GoPackage struct {
+	PkgPath string
+	Srcs    android.Paths
+}
+
+// GoPackageInfo is provided by go_library modules.
+type GoPackageInfo struct {
+	GoPackage
+	// The packages that the package imports, directly or indirectly.
+	TransitiveDeps []GoPackage
+}
+
+var GoPackageInfoProvider = blueprint.NewProvider(GoPackageInfo{})
+
+type goProperties struct {
+	// The import path of the package, e.g. android/soong/cmd/foo. Defaults to the name of the
+	// module.
+	Pkg_path *string
+
+	// The .go files of the package, which may be generated by other modules. The files are built
+	// in a single directory, so their names must be unique.
+	Srcs []string `android:"path"`
+
+	// The go_library modules of the packages that the package imports.
+	Deps []string
+}
+
+type goDependencyTag struct {
+	blueprint.BaseDependencyTag
+	name string
+}
+
+var goPackageTag = goDependencyTag{name: "go_package"}
+
+type goModule struct {
+	android.ModuleBase
+
+	properties goProperties
+}
+
+func (g *goModule) pkgPath() string {
+	return proptools.StringDefault(g.properties.Pkg_path, g.Name())
+}
+
+func (g *goModule) DepsMutator(ctx android.BottomUpMutatorContext) {
+	ctx.AddVariationDependencies(nil, goPackageTag, g.properties.Deps...)
+}
+
+// packages returns the package of the module followed by the packages that it imports, directly or
+// indirectly.
+func (g *goModule) packages(ctx android.ModuleContext) (GoPackage, []GoPackage) {
+	pkg := GoPackage{PkgPath: g.pkgPath(), Srcs: android.PathsForModuleSrc(ctx, g.properties.Srcs)}
+	if len(pkg.Srcs) == 0 {
+		ctx.PropertyErrorf("srcs", "missing sources of package %q", pkg.PkgPath)
+	}
+	checkUniqueNames(ctx, pkg)
+
+	var deps []GoPackage
+	seen := map[string]bool{pkg.PkgPath: true}
+	add := func(dep GoPackage) {
+		if !seen[dep.PkgPath] {
+			seen[dep.PkgPath] = true
+			deps = append(deps, dep)
+		}
+	}
+	ctx.VisitDirectDepsWithTag(goPackageTag, func(dep android.Module) {
+		if !ctx.OtherModuleHasProvider(dep, GoPackageInfoProvider) {
+			ctx.PropertyErrorf("deps", "%q is not a go_library", ctx.OtherModuleName(dep))
+			return
+		}
+		info := ctx.OtherModuleProvider(dep, GoPackageInfoProvider).(GoPackageInfo)
+		add(info.GoPackage)
+		for _, d := range info.TransitiveDeps {
+			add(d)
+		}
+	})
+	return pkg, deps
+}
+
+func checkUniqueNames(ctx android.ModuleContext, pkg GoPackage) {
+	names := make(map[string]android.Path)
+	for _, src := range pkg.Srcs {
+		if other, ok := names[src.Base()]; ok {
+			ctx.PropertyErrorf("srcs", "%q and %q both have the name %q in package %q",
+				other, src, src.Base(), pkg.PkgPath)
+		}
+		names[src.Base()] = src
+	}
+}
+
+// goEnv returns the GOOS and GOARCH of a host target.
+func goEnv(target android.Target) (goos, goarch string, err error) {
+	switch target.Os {
+	case android.Linux, android.LinuxMusl, android.LinuxBionic:
+		goos = "linux"
+	case android.Darwin:
+		goos = "darwin"
+	default:
+		return "", "", fmt.Errorf("Go modules can't be built for %s", target.Os)
+	}
+	switch target.Arch.ArchType {
+	case android.X86_64:
+		goarch = "amd64"
+	case android.X86:
+		goarch = "386"
+	case android.Arm64:
+		goarch = "arm64"
+	case android.Arm:
+		goarch = "arm"
+	default:
+		return "", "", fmt.Errorf("Go modules can't be built for %s", target.Arch.ArchType)
+	}
+	return goos, goarch, nil
+}
+
+// goSandboxDir returns the sandbox directory of the Go build of the module, where its output must be.
+func goSandboxDir(ctx android.ModuleContext) android.ModuleOutPath {
+	return android.PathForModuleOut(ctx, "go")
+}
+
+// buildGoPackage adds a rule that builds the main package of a binary, or the tests of a package,
+// into out with the Go toolchain of prebuilts/go. The packages are copied into a GOPATH in the
+// sandbox, so that the build only depends on the sources of the packages. The build cache is shared
+// by the Go modules and persists across builds in the output directory.
+func buildGoPackage(ctx android.ModuleContext, pkg GoPackage, deps []GoPackage, out android.WritablePath, test bool) {
+	goos, goarch, err := goEnv(ctx.Target())
+	if err != nil {
+		ctx.ModuleErrorf("%s", err)
+		return
+	}
+
+	sandboxDir := goSandboxDir(ctx)
+	rule := android.NewRuleBuilder(pctx, ctx).Sbox(sandboxDir, android.PathForModuleOut(ctx, "go.sbox.textproto"))
+	goRoot := ctx.Config().GoRoot()
+	goCache := android.PathForOutput(ctx, "gocache")
+	if ctx.Config().BuildOS == android.Linux {
+		rule.SandboxFsProfile([]string{goRoot}).SandboxFsProfileWritableDirs([]string{goCache.String()})
+	}
+
+	goPath := sandboxDir.Join(ctx, "gopath")
+	rule.Command().Text("mkdir -p").Text(goCache.String())
+	for _, p := range append([]GoPackage{pkg}, deps...) {
+		dir := goPath.Join(ctx, "src", p.PkgPath)
+		mkdir := rule.Command().Text("mkdir -p")
+		mkdir.Text(mkdir.PathForOutput(dir))
+		cp := rule.Command().Text("cp").Inputs(p.Srcs)
+		cp.Text(cp.PathForOutput(dir))
+	}
+
+	cmd := rule.Command()
+	// The Go toolchain requires absolute paths.
+	cmd.Textf("GOROOT=$PWD/%s", goRoot).
+		Textf("GOPATH=$PWD/%s", cmd.PathForOutput(goPath)).
+		Textf("GOCACHE=$PWD/%s", goCache).
+		Text("GO111MODULE=off CGO_ENABLED=0").
+		Textf("GOOS=%s GOARCH=%s", goos, goarch).
+		Text(filepath.Join(goRoot, "bin", "go"))
+	if test {
+		cmd.Text("test -c")
+	} else {
+		cmd.Text("build")
+	}
+	cmd.Flag("-trimpath").FlagWithOutput("-o ", out).Text(pkg.PkgPath)
+
+	rule.Build("go", "go "+pkg.PkgPath)
+}
+
+// go_library is a Go package that go_binary and go_test modules can import.
+func GoLibraryFactory() android.Module {
+	module := &GoLibrary{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidArchModule(module, android.HostSupportedNoCross, android.MultilibFirst)
+	return module
+}
+
+type GoLibrary struct {
+	goModule
+}
+
+func (g *GoLibrary) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	pkg, deps := g.packages(ctx)
+	ctx.SetProvider(GoPackageInfoProvider, GoPackageInfo{
+		GoPackage:      pkg,
+		TransitiveDeps: deps,
+	})
+}
+
+func (g *GoLibrary) AndroidMk() android.AndroidMkData {
+	return android.AndroidMkData{Disabled: true}
+}
+
+// go_binary builds a host Go tool from its main package, and installs it into bin.
+func GoBinaryFactory() android.Module {
+	module := &GoBinary{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidArchModule(module, android.HostSupportedNoCross, android.MultilibFirst)
+	return module
+}
+
+type GoBinary struct {
+	goModule
+
+	outputFile    android.OutputPath
+	installedFile android.InstallPath
+}
+
+var _ android.HostToolProvider = (*GoBinary)(nil)
+var _ android.OutputFileProducer = (*GoBinary)(nil)
+
+func (g *GoBinary) build(ctx android.ModuleContext, test bool) {
+	pkg, deps := g.packages(ctx)
+	g.outputFile = goSandboxDir(ctx).Join(ctx, g.Name())
+	buildGoPackage(ctx, pkg, deps, g.outputFile, test)
+}
+
+func (g *GoBinary) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	g.build(ctx, false)
+	g.installedFile = ctx.InstallExecutable(android.PathForModuleInstall(ctx, "bin"), g.outputFile.Base(), g.outputFile)
+}
+
+func (g *GoBinary) HostToolPath() android.OptionalPath {
+	return android.OptionalPathForPath(g.installedFile)
+}
+
+// OutputFileProducer
+func (g *GoBinary) OutputFiles(tag string) (android.Paths, error) {
+	if tag != "" {
+		return nil, fmt.Errorf("unsupported tag %q", tag)
+	}
+	return android.Paths{g.outputFile}, nil
+}
+
+func (g *GoBinary) AndroidMkEntries() []android.AndroidMkEntries {
+	return []android.AndroidMkEntries{{
+		Class:      "EXECUTABLES",
+		OutputFile: android.OptionalPathForPath(g.outputFile),
+		Include:    "$(BUILD_SYSTEM)/soong_cc_rust_prebuilt.mk",
+		ExtraEntries: []android.AndroidMkExtraEntriesFunc{
+			func(ctx android.AndroidMkExtraEntriesContext, entries *android.AndroidMkEntries) {
+				entries.SetString("LOCAL_MODULE_SUFFIX", "")
+			},
+		},
+	}}
+}
+
+type goTestProperties struct {
+	// list of files or filegroup modules that provide data that should be installed alongside
+	// the test
+	Data []string `android:"path"`
+
+	// list of compatibility suites (for example "cts", "vts") that the module should be
+	// installed into.
+	Test_suites []string
+
+	// the name of the test configuration (for example "AndroidTest.xml") that should be
+	// installed with the module.
+	Test_config *string `android:"path"`
+
+	// the name of the test configuration template (for example "AndroidTestTemplate.xml") that
+	// should be installed with the module.
+	Test_config_template *string `android:"path"`
+
+	// Flag to indicate whether or not to create test config automatically. If AndroidTest.xml
+	// doesn't exist next to the Android.bp, this attribute doesn't need to be set to true
+	// explicitly.
+	Auto_gen_config *bool
+
+	// Test options.
+	Test_options android.CommonTestOptions
+}
+
+// go_test builds the tests of a Go package, i.e. its _test.go files, into a host test that is
+// installed into nativetest64 with a Tradefed config, and runs like an sh_test_host.
+func GoTestFactory() android.Module {
+	module := &GoTest{}
+	module.AddProperties(&module.properties, &module.testProperties)
+	android.InitAndroidArchModule(module, android.HostSupportedNoCross, android.MultilibFirst)
+	return module
+}
+
+type GoTest struct {
+	GoBinary
+
+	testProperties goTestProperties
+
+	installDir android.InstallPath
+	testConfig android.Path
+	data       android.Paths
+}
+
+func (g *GoTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	g.build(ctx, true)
+
+	if g.testProperties.Test_options.Unit_test == nil {
+		g.testProperties.Test_options.Unit_test = proptools.BoolPtr(true)
+	}
+	g.installDir = android.PathForModuleInstall(ctx, "nativetest64", ctx.ModuleName())
+	g.installedFile = ctx.InstallExecutable(g.installDir, g.outputFile.Base(), g.outputFile)
+
+	g.data = android.PathsForModuleSrc(ctx, g.testProperties.Data)
+	for _, d := range g.data {
+		ctx.InstallFile(g.installDir, d.Rel(), d)
+	}
+
+	g.testConfig = tradefed.AutoGenTestConfig(ctx, tradefed.AutoGenTestConfigOptions{
+		TestConfigProp:         g.testProperties.Test_config,
+		TestConfigTemplateProp: g.testProperties.Test_config_template,
+		TestSuites:             g.testProperties.Test_suites,
+		AutoGenConfig:          g.testProperties.Auto_gen_config,
+		OutputFileName:         g.outputFile.Base(),
+		DeviceTemplate:         "${ShellTestConfigTemplate}",
+		HostTemplate:           "${ShellTestConfigTemplate}",
+	})
+
+	files := []android.TestSuiteFile{{Src: g.outputFile, Rel: g.outputFile.Base()}}
+	for _, d := range g.data {
+		files = append(files, android.TestSuiteFile{Src: d, Rel: d.Rel()})
+	}
+	ctx.SetProvider(android.TestSuiteInfoProvider, android.TestSuiteInfo{
+		Files:  files,
+		Config: g.testConfig,
+	})
+}
+
+func (g *GoTest) AndroidMkEntries() []android.AndroidMkEntries {
+	return []android.AndroidMkEntries{{
+		Class:      "NATIVE_TESTS",
+		OutputFile: android.OptionalPathForPath(g.outputFile),
+		Include:    "$(BUILD_SYSTEM)/soong_cc_rust_prebuilt.mk",
+		ExtraEntries: []android.AndroidMkExtraEntriesFunc{
+			func(ctx android.AndroidMkExtraEntriesContext, entries *android.AndroidMkEntries) {
+				entries.SetString("LOCAL_MODULE_SUFFIX", "")
+				entries.SetPath("LOCAL_MODULE_PATH", g.installDir)
+				entries.AddCompatibilityTestSuites(g.testProperties.Test_suites...)
+				if g.testConfig != nil {
+					entries.SetPath("LOCAL_FULL_TEST_CONFIG", g.testConfig)
+				}
+				g.testProperties.Test_options.SetAndroidMkEntries(entries)
+			},
+		},
+	}}
+}
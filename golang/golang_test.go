@@ -0,0 +1,118 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+var prepareForGoTest = android.GroupFixturePreparers(
+	android.PrepareForTestWithArchMutator,
+	android.PrepareForTestWithDefaults,
+	PrepareForTestWithGoBuildComponents,
+	android.MockFS{
+		"foo/foo.go":       nil,
+		"foo/foo_test.go":  nil,
+		"foo/testdata/a":   nil,
+		"cmd/bar/main.go":  nil,
+		"lib/lib.go":       nil,
+		"lib/other/lib.go": nil,
+	}.AddToFixture(),
+)
+
+func TestGoBinary(t *testing.T) {
+	result := prepareForGoTest.RunTestWithBp(t, `
+		go_library {
+			name: "soong-lib",
+			pkg_path: "android/soong/lib",
+			srcs: ["lib/lib.go"],
+		}
+
+		go_library {
+			name: "soong-foo",
+			pkg_path: "android/soong/foo",
+			srcs: ["foo/foo.go"],
+			deps: ["soong-lib"],
+		}
+
+		go_binary {
+			name: "bar",
+			pkg_path: "android/soong/cmd/bar",
+			srcs: ["cmd/bar/main.go"],
+			deps: ["soong-foo"],
+		}
+	`)
+
+	buildOS := result.Config.BuildOS.String()
+	bar := result.ModuleForTests("bar", buildOS+"_x86_64")
+	manifest := android.RuleBuilderSboxProtoForTests(t, bar.Output("go.sbox.textproto"))
+	cmd := manifest.Commands[0].GetCommand()
+	android.AssertStringDoesContain(t, "lib", cmd,
+		"cp lib/lib.go __SBOX_SANDBOX_DIR__/out/gopath/src/android/soong/lib")
+	android.AssertStringDoesContain(t, "foo", cmd,
+		"cp foo/foo.go __SBOX_SANDBOX_DIR__/out/gopath/src/android/soong/foo")
+	android.AssertStringDoesContain(t, "build", cmd,
+		"bin/go build -trimpath -o __SBOX_SANDBOX_DIR__/out/bar android/soong/cmd/bar")
+	android.AssertStringDoesContain(t, "env", android.StringRelativeToTop(result.Config, cmd),
+		"GOROOT=$PWD/prebuilts/go/linux-x86 GOPATH=$PWD/__SBOX_SANDBOX_DIR__/out/gopath GOCACHE=$PWD/out/soong/gocache")
+
+	android.AssertPathRelativeToTopEquals(t, "host tool", "out/soong/host/linux-x86/bin/bar",
+		bar.Module().(*GoBinary).HostToolPath().Path())
+}
+
+func TestGoTest(t *testing.T) {
+	result := prepareForGoTest.RunTestWithBp(t, `
+		go_test {
+			name: "foo_test",
+			pkg_path: "android/soong/foo",
+			srcs: ["foo/foo.go", "foo/foo_test.go"],
+			data: ["foo/testdata/a"],
+		}
+	`)
+
+	buildOS := result.Config.BuildOS.String()
+	foo := result.ModuleForTests("foo_test", buildOS+"_x86_64")
+	manifest := android.RuleBuilderSboxProtoForTests(t, foo.Output("go.sbox.textproto"))
+	android.AssertStringDoesContain(t, "test", manifest.Commands[0].GetCommand(),
+		"bin/go test -c -trimpath -o __SBOX_SANDBOX_DIR__/out/foo_test android/soong/foo")
+
+	foo.Output("out/soong/host/linux-x86/nativetest64/foo_test/foo_test")
+	foo.Output("out/soong/host/linux-x86/nativetest64/foo_test/testdata/a")
+
+	info := result.ModuleProvider(foo.Module(), android.TestSuiteInfoProvider).(android.TestSuiteInfo)
+	android.AssertIntEquals(t, "test suite files", 2, len(info.Files))
+}
+
+func TestGoErrors(t *testing.T) {
+	prepareForGoTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAllErrorsToMatchAPattern([]string{
+			`"bar" is not a go_library`,
+			`"lib/lib.go" and "lib/other/lib.go" both have the name "lib.go"`,
+		})).
+		RunTestWithBp(t, `
+			go_binary {
+				name: "bar",
+				srcs: ["cmd/bar/main.go"],
+			}
+
+			go_binary {
+				name: "baz",
+				srcs: ["lib/lib.go", "lib/other/lib.go"],
+				deps: ["bar"],
+			}
+		`)
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"text/scanner"
 	"time"
@@ -35,6 +36,19 @@ const (
 
 var DefaultTime = time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC)
 
+// SourceDateTime returns the modification time of the entries of the jars and the zips written by
+// the tools in reproducible builds, which pass the SOURCE_DATE_EPOCH of the build to the tools, or
+// DefaultTime if sourceDateEpoch isn't a valid date of a zip entry.
+func SourceDateTime(sourceDateEpoch string) time.Time {
+	if epoch, err := strconv.ParseInt(sourceDateEpoch, 10, 64); err == nil {
+		// The modification times of the zip entries are MS-DOS dates, between 1980 and 2107.
+		if t := time.Unix(epoch, 0).UTC(); t.Year() >= 1980 && t.Year() <= 2107 {
+			return t
+		}
+	}
+	return DefaultTime
+}
+
 var MetaDirExtra = [2]byte{0xca, 0xfe}
 
 // EntryNamesLess tells whether <filepathA> should precede <filepathB> in
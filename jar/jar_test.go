@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"io"
 	"testing"
+	"time"
 )
 
 func TestGetJavaPackage(t *testing.T) {
@@ -77,6 +78,41 @@ func TestGetJavaPackage(t *testing.T) {
 	}
 }
 
+func TestSourceDateTime(t *testing.T) {
+	testCases := []struct {
+		name            string
+		sourceDateEpoch string
+		want            time.Time
+	}{
+		{
+			name: "unset",
+			want: time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "set",
+			sourceDateEpoch: "1672531200",
+			want:            time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "before 1980",
+			sourceDateEpoch: "0",
+			want:            time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "invalid",
+			sourceDateEpoch: "eng.user",
+			want:            time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SourceDateTime(tc.sourceDateEpoch); !got.Equal(tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
 func Test_javaIdentRune(t *testing.T) {
 	// runes that should be valid anywhere in an identifier
 	validAnywhere := []rune{
@@ -68,6 +68,17 @@ type rroDir struct {
 	overlayType overlayType
 }
 
+// filterRRODirs returns the paths of the RRO directories of an overlay type, in aapt2 order.
+func filterRRODirs(rroDirs []rroDir, t overlayType) android.Paths {
+	var paths android.Paths
+	for _, d := range rroDirs {
+		if d.overlayType == t {
+			paths = append(paths, d.path)
+		}
+	}
+	return paths
+}
+
 type overlayGlobResult struct {
 	dir         string
 	paths       android.DirectorySortedPaths
@@ -370,23 +370,29 @@ func (app *AndroidApp) AndroidMkEntries() []android.AndroidMkEntries {
 				}
 
 				filterRRO := func(filter overlayType) android.Paths {
-					var paths android.Paths
-					for _, d := range app.rroDirs {
-						if d.overlayType == filter {
-							paths = append(paths, d.path)
-						}
-					}
 					// Reverse the order, Soong stores rroDirs in aapt2 order (low to high priority), but Make
 					// expects it in LOCAL_RESOURCE_DIRS order (high to low priority).
-					return android.ReversePaths(paths)
+					return android.ReversePaths(filterRRODirs(app.rroDirs, filter))
 				}
 				deviceRRODirs := filterRRO(device)
+				productRRODirs := filterRRO(product)
+				// The RROs that Soong generates are installed with the app, the other ones are generated
+				// by Make. They target the package of the base app, so Make keeps generating the RROs of
+				// the override variants.
+				overridden := app.GetOverriddenBy() != ""
 				if len(deviceRRODirs) > 0 {
-					entries.AddStrings("LOCAL_SOONG_DEVICE_RRO_DIRS", deviceRRODirs.Strings()...)
+					if rro := app.appProperties.AutoGeneratedDeviceRRO; rro != "" && !overridden {
+						entries.AddStrings("LOCAL_REQUIRED_MODULES", rro)
+					} else {
+						entries.AddStrings("LOCAL_SOONG_DEVICE_RRO_DIRS", deviceRRODirs.Strings()...)
+					}
 				}
-				productRRODirs := filterRRO(product)
 				if len(productRRODirs) > 0 {
-					entries.AddStrings("LOCAL_SOONG_PRODUCT_RRO_DIRS", productRRODirs.Strings()...)
+					if rro := app.appProperties.AutoGeneratedProductRRO; rro != "" && !overridden {
+						entries.AddStrings("LOCAL_REQUIRED_MODULES", rro)
+					} else {
+						entries.AddStrings("LOCAL_SOONG_PRODUCT_RRO_DIRS", productRRODirs.Strings()...)
+					}
 				}
 
 				entries.SetBoolIfTrue("LOCAL_EXPORT_PACKAGE_RESOURCES", Bool(app.appProperties.Export_package_resources))
@@ -725,6 +731,23 @@ func (r *RuntimeResourceOverlay) AndroidMkEntries() []android.AndroidMkEntries {
 	}}
 }
 
+func (r *AutoGeneratedRuntimeResourceOverlay) AndroidMkEntries() []android.AndroidMkEntries {
+	if r.outputFile == nil {
+		return []android.AndroidMkEntries{android.AndroidMkEntries{Disabled: true}}
+	}
+	return []android.AndroidMkEntries{android.AndroidMkEntries{
+		Class:      "ETC",
+		OutputFile: android.OptionalPathForPath(r.outputFile),
+		Include:    "$(BUILD_SYSTEM)/soong_app_prebuilt.mk",
+		ExtraEntries: []android.AndroidMkExtraEntriesFunc{
+			func(ctx android.AndroidMkExtraEntriesContext, entries *android.AndroidMkEntries) {
+				entries.SetString("LOCAL_CERTIFICATE", r.certificate.AndroidMkString())
+				entries.SetPath("LOCAL_MODULE_PATH", r.installDir)
+			},
+		},
+	}}
+}
+
 func (apkSet *AndroidAppSet) AndroidMkEntries() []android.AndroidMkEntries {
 	return []android.AndroidMkEntries{
 		android.AndroidMkEntries{
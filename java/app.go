@@ -42,6 +42,7 @@ func RegisterAppBuildComponents(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("android_test", AndroidTestFactory)
 	ctx.RegisterModuleType("android_test_helper_app", AndroidTestHelperAppFactory)
 	ctx.RegisterModuleType("android_app_certificate", AndroidAppCertificateFactory)
+	ctx.RegisterModuleType("android_app_feature", AndroidAppFeatureFactory)
 	ctx.RegisterModuleType("override_android_app", OverrideAndroidAppModuleFactory)
 	ctx.RegisterModuleType("override_android_test", OverrideAndroidTestModuleFactory)
 }
@@ -96,7 +97,7 @@ type appProperties struct {
 	AlwaysPackageNativeLibs bool `blueprint:"mutated"`
 
 	// If set, find and merge all NOTICE files that this module and its dependencies have and store
-	// it in the APK as an asset.
+	// it in the APK as an asset. Defaults to true for the apps that embed their JNI libraries.
 	Embed_notices *bool
 
 	// cc.Coverage related properties
@@ -112,6 +113,26 @@ type appProperties struct {
 	// enforce_default_target_sdk_version: true in which case this defaults to true.
 	EnforceDefaultTargetSdkVersion bool `blueprint:"mutated"`
 
+	// The names of the RROs that Soong generates from the device and the product resource overlays
+	// of the app, if any.
+	AutoGeneratedDeviceRRO  string `blueprint:"mutated"`
+	AutoGeneratedProductRRO string `blueprint:"mutated"`
+
+	// Names of android_app_feature modules in the form ":module" to build as feature split APKs,
+	// which are signed with the certificate of the app and installed alongside its APK.
+	Features []string
+
+	App_bundle struct {
+		// If true, also build an Android App Bundle of the app with bundletool, in addition to its
+		// APK. The bundle is not signed, and is available with the ".aab" output tag, e.g. to dist
+		// it. Defaults to false.
+		Enabled *bool
+
+		// The dimensions of the configuration splits of the APKs that are generated from the
+		// bundle, from "abi", "density" and "language". Defaults to all of them.
+		Split_dimensions []string
+	}
+
 	// Whether this app is considered mainline updatable or not. When set to true, this will enforce
 	// additional rules to make sure an app can safely be updated. Default is false.
 	// Prefer using other specific properties if build behaviour must be changed; avoid using this
@@ -165,7 +186,10 @@ type AndroidApp struct {
 	embeddedJniLibs          bool
 	jniCoverageOutputs       android.Paths
 
-	bundleFile android.Path
+	bundleFile    android.Path
+	appBundleFile android.Path
+
+	featureSplitOutputFiles android.Paths
 
 	// the install APK name is normally the same as the module name, but can be overridden with PRODUCT_PACKAGE_NAME_OVERRIDES.
 	installApkName string
@@ -263,6 +287,8 @@ func (a *AndroidApp) DepsMutator(ctx android.BottomUpMutatorContext) {
 	}
 
 	a.usesLibrary.deps(ctx, sdkDep.hasFrameworkLibs())
+
+	a.appFeatureDeps(ctx)
 }
 
 func (a *AndroidApp) OverridablePropertiesDepsMutator(ctx android.BottomUpMutatorContext) {
@@ -381,6 +407,17 @@ func (a *AndroidApp) shouldEmbedJnis(ctx android.BaseModuleContext) bool {
 		!apexInfo.IsForPlatform() || a.appProperties.AlwaysPackageNativeLibs
 }
 
+// shouldEmbedNotices returns whether the NOTICE of the app is embedded in its assets, which is the
+// default for the apps that embed their JNI libraries, as the notices of the libraries on the
+// device don't cover them.
+func (a *AndroidApp) shouldEmbedNotices(ctx android.ModuleContext) bool {
+	if ctx.Config().IsEnvTrue("ALWAYS_EMBED_NOTICES") {
+		return true
+	}
+	embedsJnis := a.shouldEmbedJnis(ctx) && len(ctx.GetDirectDepsWithTag(jniLibTag)) > 0
+	return proptools.BoolDefault(a.appProperties.Embed_notices, embedsJnis)
+}
+
 func generateAaptRenamePackageFlags(packageName string, renameResourcesPackage bool) []string {
 	aaptFlags := []string{"--rename-manifest-package " + packageName}
 	if renameResourcesPackage {
@@ -398,6 +435,24 @@ func (a *AndroidApp) renameResourcesPackage() bool {
 	return proptools.BoolDefault(a.overridableAppProperties.Rename_resources_package, true)
 }
 
+// aaptConfigFlags returns the aapt2 link flags that select the configurations and the density of
+// the resources of the product, which the app and its feature splits are linked with.
+func (a *AndroidApp) aaptConfigFlags(ctx android.ModuleContext) []string {
+	var flags []string
+	if !Bool(a.aaptProperties.Aapt_include_all_resources) {
+		// Product AAPT config
+		for _, aaptConfig := range ctx.Config().ProductAAPTConfig() {
+			flags = append(flags, "-c", aaptConfig)
+		}
+
+		// Product AAPT preferred config
+		if len(ctx.Config().ProductAAPTPreferredConfig()) > 0 {
+			flags = append(flags, "--preferred-density", ctx.Config().ProductAAPTPreferredConfig())
+		}
+	}
+	return flags
+}
+
 func (a *AndroidApp) aaptBuildActions(ctx android.ModuleContext) {
 	usePlatformAPI := proptools.Bool(a.Module.deviceProperties.Platform_apis)
 	if ctx.Module().(android.SdkContext).SdkVersion(ctx).Kind == android.SdkModule {
@@ -416,17 +471,7 @@ func (a *AndroidApp) aaptBuildActions(ctx android.ModuleContext) {
 		aaptLinkFlags = append(aaptLinkFlags, "--product", ctx.Config().ProductAAPTCharacteristics())
 	}
 
-	if !Bool(a.aaptProperties.Aapt_include_all_resources) {
-		// Product AAPT config
-		for _, aaptConfig := range ctx.Config().ProductAAPTConfig() {
-			aaptLinkFlags = append(aaptLinkFlags, "-c", aaptConfig)
-		}
-
-		// Product AAPT preferred config
-		if len(ctx.Config().ProductAAPTPreferredConfig()) > 0 {
-			aaptLinkFlags = append(aaptLinkFlags, "--preferred-density", ctx.Config().ProductAAPTPreferredConfig())
-		}
-	}
+	aaptLinkFlags = append(aaptLinkFlags, a.aaptConfigFlags(ctx)...)
 
 	manifestPackageName, overridden := ctx.DeviceConfig().OverrideManifestPackageNameFor(ctx.ModuleName())
 	if overridden || a.overridableAppProperties.Package_name != nil {
@@ -629,7 +674,7 @@ func (a *AndroidApp) generateAndroidBuildActions(ctx android.ModuleContext) {
 	a.classLoaderContexts = a.usesLibrary.classLoaderContextForUsesLibDeps(ctx)
 
 	var noticeAssetPath android.WritablePath
-	if Bool(a.appProperties.Embed_notices) || ctx.Config().IsEnvTrue("ALWAYS_EMBED_NOTICES") {
+	if a.shouldEmbedNotices(ctx) {
 		// The rule to create the notice file can't be generated yet, as the final output path
 		// for the apk isn't known yet.  Add the path where the notice file will be generated to the
 		// aapt rules now before calling aaptBuildActions, the rule to create the notice file will
@@ -703,6 +748,13 @@ func (a *AndroidApp) generateAndroidBuildActions(ctx android.ModuleContext) {
 
 	if a.aapt.noticeFile.Valid() {
 		// Generating the notice file rule has to be here after a.outputFile is known.
+		// The notice covers the app and the JNI libraries embedded in it.
+		noticeModules := []android.Module{ctx.Module()}
+		if a.embeddedJniLibs {
+			for _, jni := range a.jniLibs {
+				noticeModules = append(noticeModules, jni.module)
+			}
+		}
 		noticeFile := android.PathForModuleOut(ctx, "NOTICE.html.gz")
 		android.BuildNoticeHtmlOutputFromLicenseMetadata(
 			ctx, noticeFile, "", "",
@@ -710,7 +762,7 @@ func (a *AndroidApp) generateAndroidBuildActions(ctx android.ModuleContext) {
 				a.installDir.String() + "/",
 				android.PathForModuleInstall(ctx).String() + "/",
 				a.outputFile.String(),
-			})
+			}, noticeModules...)
 		builder := android.NewRuleBuilder(pctx, ctx)
 		builder.Command().Text("cp").
 			Input(noticeFile).
@@ -731,11 +783,33 @@ func (a *AndroidApp) generateAndroidBuildActions(ctx android.ModuleContext) {
 		}
 	}
 
+	if features := a.appFeatures(ctx); len(features) > 0 {
+		_, _, _, _, libDeps, libFlags := aaptLibs(ctx, android.SdkContext(a), nil)
+		for i, feature := range features {
+			// Sign the feature split APKs
+			splitFile := a.linkAppFeature(ctx, feature, appFeatureFirstPackageId-i, libFlags, libDeps)
+			packageFile := android.PathForModuleOut(ctx, a.installApkName+"_"+feature.SplitName+".apk")
+			if v4SigningRequested {
+				v4SignatureFile = android.PathForModuleOut(ctx, a.installApkName+"_"+feature.SplitName+".apk.idsig")
+			}
+			CreateAndSignAppPackage(ctx, packageFile, splitFile, nil, nil, certificates, apkDeps, v4SignatureFile, lineageFile, rotationMinSdkVersion, false)
+			a.extraOutputFiles = append(a.extraOutputFiles, packageFile)
+			a.featureSplitOutputFiles = append(a.featureSplitOutputFiles, packageFile)
+			if v4SigningRequested {
+				a.extraOutputFiles = append(a.extraOutputFiles, v4SignatureFile)
+			}
+		}
+	}
+
 	// Build an app bundle.
 	bundleFile := android.PathForModuleOut(ctx, "base.zip")
 	BuildBundleModule(ctx, bundleFile, a.exportPackage, jniJarFile, dexJarFile)
 	a.bundleFile = bundleFile
 
+	if Bool(a.appProperties.App_bundle.Enabled) {
+		a.appBundleFile = a.buildAppBundle(ctx, bundleFile, a.outputFile)
+	}
+
 	apexInfo := ctx.Provider(android.ApexInfoProvider).(android.ApexInfo)
 
 	// Install the app package.
@@ -856,6 +930,7 @@ func collectAppDeps(ctx android.ModuleContext, app appDepsInterface,
 						coverageFile:   dep.CoverageOutputFile(),
 						unstrippedFile: dep.UnstrippedOutputFile(),
 						partition:      dep.Partition(),
+						module:         module,
 					})
 				} else if ctx.Config().AllowMissingDependencies() {
 					ctx.AddMissingDependencies([]string{otherName})
@@ -984,6 +1059,11 @@ func (a *AndroidApp) OutputFiles(tag string) (android.Paths, error) {
 		return []android.Path{a.aaptSrcJar}, nil
 	case ".export-package.apk":
 		return []android.Path{a.exportPackage}, nil
+	case ".aab":
+		if a.appBundleFile == nil {
+			return nil, fmt.Errorf("app_bundle.enabled is not set")
+		}
+		return []android.Path{a.appBundleFile}, nil
 	}
 	return a.Library.OutputFiles(tag)
 }
@@ -1033,6 +1113,10 @@ func AndroidAppFactory() android.Module {
 	android.InitApexModule(module)
 	android.InitBazelModule(module)
 
+	android.AddLoadHook(module, func(ctx android.LoadHookContext) {
+		module.createAutoGeneratedRROs(ctx)
+	})
+
 	return module
 }
 
@@ -1098,6 +1182,7 @@ func (a *AndroidTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	a.testConfig = a.FixTestConfig(ctx, testConfig)
 	a.extraTestConfigs = android.PathsForModuleSrc(ctx, a.testProperties.Test_options.Extra_test_configs)
 	a.data = android.PathsForModuleSrc(ctx, a.testProperties.Data)
+	setJacocoTestSuiteInfo(ctx, a.testProperties.Test_suites, a.jacocoReportClassesFile)
 }
 
 func (a *AndroidTest) FixTestConfig(ctx android.ModuleContext, testConfig android.Path) android.Path {
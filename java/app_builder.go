@@ -30,18 +30,30 @@ import (
 )
 
 var (
+	externalSignapk = pctx.AndroidStaticRule("external_signapk",
+		blueprint.RuleParams{
+			Command: `rm -f $out && $externalSigningCmd $dryRun --in $in --out $out --flags $flags $extraArgs`,
+		}, "externalSigningCmd", "dryRun", "flags", "extraArgs")
+
+	externalSignapkDryRun = pctx.AndroidStaticRule("external_signapk_dry_run",
+		blueprint.RuleParams{
+			Command: `rm -f $out && $externalSigningCmd $dryRun --in $in --out $out.signed --flags $flags $extraArgs && ` +
+				`rm -f $out.signed && touch $out`,
+		}, "externalSigningCmd", "dryRun", "flags", "extraArgs")
+
 	Signapk, SignapkRE = pctx.RemoteStaticRules("signapk",
 		blueprint.RuleParams{
-			Command: `rm -f $out && $reTemplate${config.JavaCmd} ${config.JavaVmFlags} -Djava.library.path=$$(dirname ${config.SignapkJniLibrary}) ` +
+			Command: `rm -f $out && ${android.SourceDateEpochEnv}$reTemplate${config.JavaCmd} ${config.JavaVmFlags} -Djava.library.path=$$(dirname ${config.SignapkJniLibrary}) ` +
 				`-jar ${config.SignapkCmd} $flags $certificates $in $out`,
 			CommandDeps: []string{"${config.SignapkCmd}", "${config.SignapkJniLibrary}"},
 		},
 		&remoteexec.REParams{Labels: map[string]string{"type": "tool", "name": "signapk"},
-			ExecStrategy:    "${config.RESignApkExecStrategy}",
-			Inputs:          []string{"${config.SignapkCmd}", "$in", "$$(dirname ${config.SignapkJniLibrary})", "$implicits"},
-			OutputFiles:     []string{"$outCommaList"},
-			ToolchainInputs: []string{"${config.JavaCmd}"},
-			Platform:        map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
+			ExecStrategy:         "${config.RESignApkExecStrategy}",
+			Inputs:               []string{"${config.SignapkCmd}", "$in", "$$(dirname ${config.SignapkJniLibrary})", "$implicits"},
+			OutputFiles:          []string{"$outCommaList"},
+			ToolchainInputs:      []string{"${config.JavaCmd}"},
+			EnvironmentVariables: []string{"SOURCE_DATE_EPOCH"},
+			Platform:             map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
 		}, []string{"flags", "certificates"}, []string{"implicits", "outCommaList"})
 )
 
@@ -104,26 +116,134 @@ func SignAppPackage(ctx android.ModuleContext, signedApk android.WritablePath, u
 		flags = append(flags, "--rotation-min-sdk-version", rotationMinSdkVersion)
 	}
 
+	BuildSignapk(ctx, SignapkParams{
+		Input:           unsignedApk,
+		Outputs:         outputFiles,
+		CertificateArgs: certificateArgs,
+		Certificates:    certificates,
+		Implicits:       deps,
+		Flags:           flags,
+	})
+}
+
+// SignapkParams describes a signing action for an APK or an APEX container.
+type SignapkParams struct {
+	Input android.Path
+	// The signed output followed optionally by the v4 signature file.
+	Outputs android.WritablePaths
+	// The certificate and private key arguments passed to signapk.
+	CertificateArgs []string
+	// The certificates that the arguments come from.
+	Certificates []Certificate
+	// Additional inputs of the action, including the certificates and keys.
+	Implicits   android.Paths
+	Flags       []string
+	Validations android.Paths
+	// The description of the signing action, defaults to "signapk".
+	Description string
+}
+
+// BuildSignapk creates the build rule that signs an APK or an APEX container. Signing is done
+// with signapk unless the product routes the signing of the module through an external command
+// with PRODUCT_EXTERNAL_SIGNING_COMMAND, e.g. to use keys stored in an HSM.
+//
+// The external command is invoked as:
+//
+//	<command> [--dry-run] --in <unsigned> --out <signed> [--v4-signature <file>]
+//	    --flags "<signapk flags>" --certificate <x509.pem> [--certificate <x509.pem> ...]
+//
+// It only receives the certificates, never the private keys, and must only read the listed
+// inputs and write the listed outputs. With PRODUCT_EXTERNAL_SIGNING_DRY_RUN the output is signed
+// with signapk as usual and the external command is run with --dry-run as a validation, so that
+// the signing requests can be checked in CI without access to the signing service.
+func BuildSignapk(ctx android.ModuleContext, params SignapkParams) {
+	description := params.Description
+	if description == "" {
+		description = "signapk"
+	}
+	validations := params.Validations
+	if ctx.Config().ExternalSigningForModule(ctx.ModuleName()) {
+		externalArgs, externalDeps := externalSigningArgs(ctx, params)
+		if !ctx.Config().ExternalSigningDryRun() {
+			ctx.Build(pctx, android.BuildParams{
+				Rule:        externalSignapk,
+				Description: "external " + description,
+				Outputs:     params.Outputs,
+				Input:       params.Input,
+				Implicits:   externalDeps,
+				Args:        externalArgs,
+				Validations: validations,
+			})
+			return
+		}
+
+		dryRun := android.PathForModuleOut(ctx, "external_signing", params.Outputs[0].Base()+".dry_run")
+		externalArgs["dryRun"] = "--dry-run"
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        externalSignapkDryRun,
+			Description: "external " + description + " dry run",
+			Output:      dryRun,
+			Input:       params.Input,
+			Implicits:   externalDeps,
+			Args:        externalArgs,
+		})
+		validations = append(validations, dryRun)
+	}
+
 	rule := Signapk
 	args := map[string]string{
-		"certificates": strings.Join(certificateArgs, " "),
-		"flags":        strings.Join(flags, " "),
+		"certificates": strings.Join(params.CertificateArgs, " "),
+		"flags":        strings.Join(params.Flags, " "),
 	}
 	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_SIGNAPK") {
 		rule = SignapkRE
-		args["implicits"] = strings.Join(deps.Strings(), ",")
-		args["outCommaList"] = strings.Join(outputFiles.Strings(), ",")
+		args["implicits"] = strings.Join(params.Implicits.Strings(), ",")
+		args["outCommaList"] = strings.Join(params.Outputs.Strings(), ",")
 	}
 	ctx.Build(pctx, android.BuildParams{
 		Rule:        rule,
-		Description: "signapk",
-		Outputs:     outputFiles,
-		Input:       unsignedApk,
-		Implicits:   deps,
+		Description: description,
+		Outputs:     params.Outputs,
+		Input:       params.Input,
+		Implicits:   params.Implicits,
 		Args:        args,
+		Validations: validations,
 	})
 }
 
+// externalSigningArgs returns the arguments and the inputs of the external signing command.
+func externalSigningArgs(ctx android.ModuleContext, params SignapkParams) (map[string]string, android.Paths) {
+	var deps android.Paths
+	cmd := ctx.Config().ExternalSigningCommand()
+	if !filepath.IsAbs(cmd) {
+		tool := android.PathForSource(ctx, cmd)
+		deps = append(deps, tool)
+		cmd = tool.String()
+	}
+
+	var extra []string
+	if len(params.Outputs) > 1 {
+		extra = append(extra, "--v4-signature", params.Outputs[1].String())
+	}
+	for _, c := range params.Certificates {
+		extra = append(extra, "--certificate", c.Pem.String())
+		deps = append(deps, c.Pem)
+	}
+	// Inputs referenced by the flags, e.g. the lineage file, are passed through as is.
+	for _, dep := range params.Implicits {
+		if android.InList(dep.String(), params.Flags) {
+			deps = append(deps, dep)
+		}
+	}
+
+	return map[string]string{
+		"externalSigningCmd": cmd,
+		"flags":              proptools.ShellEscape(strings.Join(params.Flags, " ")),
+		"extraArgs":          strings.Join(extra, " "),
+		"dryRun":             "",
+	}, deps
+}
+
 var buildAAR = pctx.AndroidStaticRule("buildAAR",
 	blueprint.RuleParams{
 		Command: `rm -rf ${outDir} && mkdir -p ${outDir} && ` +
@@ -0,0 +1,166 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"encoding/json"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// This file builds the Android App Bundles of the android_app modules with app_bundle.enabled.
+// bundletool builds the bundle from the bundle module of the app (base.zip), with a
+// BundleConfig.json that selects the dimensions of the configuration splits. The bundle is then
+// verified by generating its universal APK, which must have the same dex files and native
+// libraries as the APK of the app.
+
+var (
+	buildAppBundle = pctx.AndroidStaticRule("buildAppBundle",
+		blueprint.RuleParams{
+			Command: `rm -f $out && ` +
+				`${config.JavaCmd} ${config.JavaVmFlags} -jar ${config.BundletoolJar} build-bundle ` +
+				`--modules=$in --config=$bundleConfig --output=$out`,
+			CommandDeps: []string{
+				"${config.JavaCmd}",
+				"${config.BundletoolJar}",
+			},
+		}, "bundleConfig")
+
+	verifyAppBundle = pctx.AndroidStaticRule("verifyAppBundle",
+		blueprint.RuleParams{
+			Command: `rm -rf $tmpDir && mkdir -p $tmpDir && ` +
+				`${config.JavaCmd} ${config.JavaVmFlags} -jar ${config.BundletoolJar} build-apks ` +
+				`--bundle=$in --mode=universal --output=$tmpDir/universal.apks && ` +
+				`unzip -qo $tmpDir/universal.apks universal.apk -d $tmpDir && ` +
+				`unzip -Z1 $tmpDir/universal.apk | grep -E '^(classes[0-9]*\.dex|lib/.*\.so)$$' | sort > $tmpDir/universal.txt ; ` +
+				`unzip -Z1 $apk | grep -E '^(classes[0-9]*\.dex|lib/.*\.so)$$' | sort > $tmpDir/apk.txt ; ` +
+				`if ! diff $tmpDir/apk.txt $tmpDir/universal.txt ; then ` +
+				`echo "The universal APK of $in doesn't have the dex files and native libraries of $apk" >&2 ; ` +
+				`exit 1 ; fi && ` +
+				`rm -rf $tmpDir && touch $out`,
+			CommandDeps: []string{
+				"${config.JavaCmd}",
+				"${config.BundletoolJar}",
+			},
+		}, "apk", "tmpDir")
+)
+
+// The values of the app_bundle.split_dimensions property, and the corresponding split dimensions of
+// bundletool.
+var appBundleSplitDimensions = []struct {
+	name  string
+	value string
+}{
+	{"abi", "ABI"},
+	{"density", "SCREEN_DENSITY"},
+	{"language", "LANGUAGE"},
+}
+
+// appBundleConfig is the subset of the BundleConfig.json of bundletool that is generated for the
+// apps.
+type appBundleConfig struct {
+	Optimizations appBundleOptimizations `json:"optimizations"`
+}
+
+type appBundleOptimizations struct {
+	SplitsConfig              appBundleSplitsConfig `json:"splitsConfig"`
+	UncompressNativeLibraries appBundleEnabled      `json:"uncompressNativeLibraries"`
+	UncompressDexFiles        appBundleEnabled      `json:"uncompressDexFiles"`
+}
+
+type appBundleSplitsConfig struct {
+	SplitDimension []appBundleSplitDimension `json:"splitDimension"`
+}
+
+type appBundleSplitDimension struct {
+	Value  string `json:"value"`
+	Negate bool   `json:"negate"`
+}
+
+type appBundleEnabled struct {
+	Enabled bool `json:"enabled"`
+}
+
+// appBundleConfigJson returns the contents of the BundleConfig.json of the app.
+func (a *AndroidApp) appBundleConfigJson(ctx android.ModuleContext) string {
+	dimensions := a.appProperties.App_bundle.Split_dimensions
+	if dimensions == nil {
+		for _, d := range appBundleSplitDimensions {
+			dimensions = append(dimensions, d.name)
+		}
+	}
+	for _, d := range dimensions {
+		known := false
+		for _, s := range appBundleSplitDimensions {
+			known = known || d == s.name
+		}
+		if !known {
+			ctx.PropertyErrorf("app_bundle.split_dimensions", "unknown split dimension %q, expected abi, density or language", d)
+		}
+	}
+
+	var config appBundleConfig
+	for _, d := range appBundleSplitDimensions {
+		config.Optimizations.SplitsConfig.SplitDimension = append(config.Optimizations.SplitsConfig.SplitDimension,
+			appBundleSplitDimension{Value: d.value, Negate: !android.InList(d.name, dimensions)})
+	}
+	// Keep the native libraries and the dex files of the generated APKs stored like in the APK of
+	// the app.
+	config.Optimizations.UncompressNativeLibraries.Enabled = a.useEmbeddedNativeLibs(ctx)
+	config.Optimizations.UncompressDexFiles.Enabled = Bool(a.dexProperties.Uncompress_dex)
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		ctx.ModuleErrorf("failed to generate the bundle config: %s", err)
+		return ""
+	}
+	return string(data)
+}
+
+// buildAppBundle builds the Android App Bundle of the app from its bundle module, and verifies it
+// against the APK of the app.
+func (a *AndroidApp) buildAppBundle(ctx android.ModuleContext, bundleModule, apk android.Path) android.Path {
+	bundleConfig := android.PathForModuleOut(ctx, "app_bundle", "BundleConfig.json")
+	android.WriteFileRule(ctx, bundleConfig, a.appBundleConfigJson(ctx))
+
+	verified := android.PathForModuleOut(ctx, "app_bundle", "verified.timestamp")
+	appBundle := android.PathForModuleOut(ctx, a.installApkName+".aab")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        buildAppBundle,
+		Description: "app bundle",
+		Input:       bundleModule,
+		Implicit:    bundleConfig,
+		Output:      appBundle,
+		Validation:  verified,
+		Args: map[string]string{
+			"bundleConfig": bundleConfig.String(),
+		},
+	})
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        verifyAppBundle,
+		Description: "verify app bundle",
+		Input:       appBundle,
+		Implicit:    apk,
+		Output:      verified,
+		Args: map[string]string{
+			"apk":    apk.String(),
+			"tmpDir": android.PathForModuleOut(ctx, "app_bundle", "verify").String(),
+		},
+	})
+	return appBundle
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+// This file implements the feature splits of the android_app modules. An android_app_feature
+// module provides the manifest, the resources and the assets of a feature split, and the
+// android_app modules that list it in their features property build it as a split APK that is
+// linked against the resources of the base APK, signed with the certificate of the app and
+// installed alongside the base APK, including in the APEXes that contain the app.
+//
+// The manifest of a feature split must have the package of the app and the split name of the
+// android_app_feature module, which is checked when building the split APK.
+
+var (
+	appFeatureTag = dependencyTag{name: "app-feature"}
+
+	appFeatureLinkRule = pctx.AndroidStaticRule("appFeatureLink",
+		blueprint.RuleParams{
+			Command: `rm -f $out && ` +
+				`${config.Aapt2Cmd} link -o $out --manifest $manifest -I $baseApk $flags ` +
+				`--version-code $$(${config.Aapt2Cmd} dump badging $baseApk | sed -n "s/^package: .*versionCode='\([0-9]*\)'.*/\1/p") ` +
+				`@$out.rsp`,
+			CommandDeps:    []string{"${config.Aapt2Cmd}"},
+			Rspfile:        "$out.rsp",
+			RspfileContent: "$in",
+		}, "manifest", "baseApk", "flags")
+
+	appFeatureCheckRule = pctx.AndroidStaticRule("appFeatureCheck",
+		blueprint.RuleParams{
+			Command: `basePackage=$$(${config.Aapt2Cmd} dump packagename $baseApk) && ` +
+				`package=$$(${config.Aapt2Cmd} dump packagename $in) && ` +
+				`if [ "$$package" != "$$basePackage" ]; then ` +
+				`echo "$manifest: the package $$package of the feature split must be the package $$basePackage of the app" >&2 ; ` +
+				`exit 1 ; fi && ` +
+				`if ! ${config.Aapt2Cmd} dump badging $in | grep -q "split='$splitName'" ; then ` +
+				`echo "$manifest: the manifest of the feature split must have split=\"$splitName\"" >&2 ; ` +
+				`exit 1 ; fi && ` +
+				`touch $out`,
+			CommandDeps: []string{"${config.Aapt2Cmd}"},
+		}, "baseApk", "manifest", "splitName")
+)
+
+// The package id of the resources of the first feature split of an app, the following feature
+// splits use the previous package ids.
+const appFeatureFirstPackageId = 0x7e
+
+type appFeatureProperties struct {
+	// Name of the feature split, which must be the split attribute of its manifest. Defaults to the
+	// name of the module.
+	Split_name *string
+
+	// Path to the AndroidManifest.xml of the feature split. Defaults to AndroidManifest.xml.
+	Manifest *string `android:"path"`
+
+	// list of directories relative to the Blueprints file containing
+	// Android resources.  Defaults to ["res"] if a directory called res exists.
+	Resource_dirs []string
+
+	// list of directories relative to the Blueprints file containing assets.
+	// Defaults to ["assets"] if a directory called assets exists.
+	Asset_dirs []string
+}
+
+// AppFeatureInfo is the feature split that an android_app_feature module provides to the apps.
+type AppFeatureInfo struct {
+	// The split name of the feature split.
+	SplitName string
+
+	// The manifest of the feature split.
+	Manifest android.Path
+
+	// The compiled resources of the feature split.
+	CompiledResources android.Paths
+
+	// The asset directories of the feature split, and the files in them.
+	AssetDirs android.Paths
+	AssetDeps android.Paths
+}
+
+var AppFeatureInfoProvider = blueprint.NewProvider(AppFeatureInfo{})
+
+// AndroidAppFeature is the android_app_feature module.
+type AndroidAppFeature struct {
+	android.ModuleBase
+
+	properties appFeatureProperties
+}
+
+// android_app_feature provides the manifest, the resources and the assets of a feature split of
+// the android_app modules that list it in their features property.
+func AndroidAppFeatureFactory() android.Module {
+	module := &AndroidAppFeature{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidModule(module)
+	return module
+}
+
+func (f *AndroidAppFeature) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	manifest := android.PathForModuleSrc(ctx, proptools.StringDefault(f.properties.Manifest, "AndroidManifest.xml"))
+
+	var compiledResources android.Paths
+	for _, dir := range android.PathsWithOptionalDefaultForModuleSrc(ctx, f.properties.Resource_dirs, "res") {
+		compiledResources = append(compiledResources,
+			aapt2Compile(ctx, dir, androidResourceGlob(ctx, dir), []string{"--pseudo-localize"}).Paths()...)
+	}
+
+	assetDirs := android.PathsWithOptionalDefaultForModuleSrc(ctx, f.properties.Asset_dirs, "assets")
+	var assetDeps android.Paths
+	for _, dir := range assetDirs {
+		assetDeps = append(assetDeps, androidResourceGlob(ctx, dir)...)
+	}
+
+	ctx.SetProvider(AppFeatureInfoProvider, AppFeatureInfo{
+		SplitName:         proptools.StringDefault(f.properties.Split_name, ctx.ModuleName()),
+		Manifest:          manifest,
+		CompiledResources: compiledResources,
+		AssetDirs:         assetDirs,
+		AssetDeps:         assetDeps,
+	})
+}
+
+// appFeatureDeps adds the dependencies on the feature splits of the app.
+func (a *AndroidApp) appFeatureDeps(ctx android.BottomUpMutatorContext) {
+	for _, feature := range a.appProperties.Features {
+		feature = android.SrcIsModule(feature)
+		if feature != "" {
+			ctx.AddDependency(ctx.Module(), appFeatureTag, feature)
+		} else {
+			ctx.PropertyErrorf("features", `must be names of android_app_feature modules in the form ":module"`)
+		}
+	}
+}
+
+// appFeatures returns the feature splits of the app.
+func (a *AndroidApp) appFeatures(ctx android.ModuleContext) []AppFeatureInfo {
+	var features []AppFeatureInfo
+	ctx.VisitDirectDepsWithTag(appFeatureTag, func(m android.Module) {
+		if !ctx.OtherModuleHasProvider(m, AppFeatureInfoProvider) {
+			ctx.PropertyErrorf("features", "%q must be an android_app_feature module", ctx.OtherModuleName(m))
+			return
+		}
+		feature := ctx.OtherModuleProvider(m, AppFeatureInfoProvider).(AppFeatureInfo)
+		for _, other := range features {
+			if other.SplitName == feature.SplitName {
+				ctx.PropertyErrorf("features", "more than one feature split is named %q", feature.SplitName)
+				return
+			}
+		}
+		features = append(features, feature)
+	})
+	return features
+}
+
+// linkAppFeature links the resources and the assets of a feature split against the resources of
+// the app, with the aapt config flags of the app, and checks its manifest. It returns the unsigned
+// split APK.
+func (a *AndroidApp) linkAppFeature(ctx android.ModuleContext, feature AppFeatureInfo, packageId int,
+	libFlags []string, libDeps android.Paths) android.Path {
+
+	dir := android.PathForModuleOut(ctx, "feature_splits", feature.SplitName)
+	linked := dir.Join(ctx, "package-res.apk")
+	checked := dir.Join(ctx, "check.timestamp")
+
+	flags := append([]string(nil), libFlags...)
+	flags = append(flags,
+		"--package-id 0x"+strconv.FormatInt(int64(packageId), 16),
+		"--allow-reserved-package-id")
+	flags = append(flags, a.aaptConfigFlags(ctx)...)
+	if a.overriddenManifestPackageName != "" {
+		flags = append(flags, "--rename-manifest-package "+a.overriddenManifestPackageName)
+	}
+	flags = append(flags, android.JoinWithPrefix(feature.AssetDirs.Strings(), "-A "))
+
+	implicits := android.Paths{feature.Manifest, a.exportPackage}
+	implicits = append(implicits, libDeps...)
+	implicits = append(implicits, feature.AssetDeps...)
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        appFeatureLinkRule,
+		Description: "aapt2 link feature split " + feature.SplitName,
+		Inputs:      feature.CompiledResources,
+		Implicits:   implicits,
+		Output:      linked,
+		Validation:  checked,
+		Args: map[string]string{
+			"manifest": feature.Manifest.String(),
+			"baseApk":  a.exportPackage.String(),
+			"flags":    strings.Join(flags, " "),
+		},
+	})
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        appFeatureCheckRule,
+		Description: "check feature split " + feature.SplitName,
+		Input:       linked,
+		Implicit:    a.exportPackage,
+		Output:      checked,
+		Args: map[string]string{
+			"baseApk":   a.exportPackage.String(),
+			"manifest":  feature.Manifest.String(),
+			"splitName": feature.SplitName,
+		},
+	})
+	return linked
+}
+
+// FeatureSplitOutputFiles returns the signed feature split APKs of the app, which are installed
+// alongside its APK.
+func (a *AndroidApp) FeatureSplitOutputFiles() android.Paths {
+	return a.featureSplitOutputFiles
+}
@@ -17,7 +17,10 @@ package java
 // This file contains the module implementations for android_app_import and android_test_import.
 
 import (
+	"fmt"
+	"path"
 	"reflect"
+	"strings"
 
 	"github.com/google/blueprint"
 
@@ -54,6 +57,7 @@ var (
 func RegisterAppImportBuildComponents(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("android_app_import", AndroidAppImportFactory)
 	ctx.RegisterModuleType("android_test_import", AndroidTestImportFactory)
+	ctx.RegisterSingletonType("app_import_variant_report", appImportVariantReportSingletonFactory)
 }
 
 type AndroidAppImport struct {
@@ -62,9 +66,10 @@ type AndroidAppImport struct {
 	android.ApexModuleBase
 	prebuilt android.Prebuilt
 
-	properties   AndroidAppImportProperties
-	dpiVariants  interface{}
-	archVariants interface{}
+	properties       AndroidAppImportProperties
+	dpiVariants      interface{}
+	archVariants     interface{}
+	variantSelection appImportVariantSelectionProperties
 
 	outputFile  android.Path
 	certificate Certificate
@@ -86,6 +91,9 @@ type AndroidAppImportProperties struct {
 	// A prebuilt apk to import
 	Apk *string `android:"path"`
 
+	// The sha256 of the apk. If set, the build fails if the apk does not match.
+	Sha256 *string
+
 	// The name of a certificate in the default certificate directory or an android_app_certificate
 	// module name in the form ":module". Should be empty if presigned or default_dev_cert is set.
 	Certificate *string
@@ -130,6 +138,23 @@ type AndroidAppImportProperties struct {
 	Relative_install_path *string
 }
 
+// The dpi and arch variants that the apk of an android_app_import module was selected from, for the
+// variant selection report.
+type appImportVariantSelectionProperties struct {
+	// The dpi variants that were tried, in priority order.
+	Dpi_variant_candidates []string `blueprint:"mutated"`
+
+	// The highest priority dpi variant that sets the apk, if any.
+	Selected_dpi_variant *string `blueprint:"mutated"`
+
+	// The arch variants that were tried, in priority order.
+	Arch_variant_candidates []string `blueprint:"mutated"`
+
+	// The highest priority arch variant that sets the apk, if any, which takes precedence over the
+	// dpi variant.
+	Selected_arch_variant *string `blueprint:"mutated"`
+}
+
 func (a *AndroidAppImport) IsInstallable() bool {
 	return true
 }
@@ -138,20 +163,41 @@ func (a *AndroidAppImport) IsInstallable() bool {
 func (a *AndroidAppImport) processVariants(ctx android.LoadHookContext) {
 	config := ctx.Config()
 
+	// The dpi variants in priority order, the preferred config of the product first.
+	var dpis []string
+	if config.ProductAAPTPreferredConfig() != "" {
+		dpis = append(dpis, config.ProductAAPTPreferredConfig())
+	}
+	dpis = append(dpis, config.ProductAAPTPrebuiltDPI()...)
+	dpis, err := expandDpiPatterns(dpis)
+	if err != nil {
+		ctx.ModuleErrorf("invalid dpi in the product configuration: %s", err)
+		return
+	}
+
 	dpiProps := reflect.ValueOf(a.dpiVariants).Elem().FieldByName("Dpi_variants")
 	// Try DPI variant matches in the reverse-priority order so that the highest priority match
 	// overwrites everything else.
 	// TODO(jungjw): Can we optimize this by making it priority order?
-	for i := len(config.ProductAAPTPrebuiltDPI()) - 1; i >= 0; i-- {
-		MergePropertiesFromVariant(ctx, &a.properties, dpiProps, config.ProductAAPTPrebuiltDPI()[i])
+	for i := len(dpis) - 1; i >= 0; i-- {
+		MergePropertiesFromVariant(ctx, &a.properties, dpiProps, dpis[i])
 	}
-	if config.ProductAAPTPreferredConfig() != "" {
-		MergePropertiesFromVariant(ctx, &a.properties, dpiProps, config.ProductAAPTPreferredConfig())
+
+	archs, err := appImportArchVariants(config)
+	if err != nil {
+		ctx.ModuleErrorf("invalid preferred ABI in the product configuration: %s", err)
+		return
 	}
 
 	archProps := reflect.ValueOf(a.archVariants).Elem().FieldByName("Arch")
-	archType := ctx.Config().AndroidFirstDeviceTarget.Arch.ArchType
-	MergePropertiesFromVariant(ctx, &a.properties, archProps, archType.Name)
+	for i := len(archs) - 1; i >= 0; i-- {
+		MergePropertiesFromVariant(ctx, &a.properties, archProps, archs[i])
+	}
+
+	a.variantSelection.Dpi_variant_candidates = dpis
+	a.variantSelection.Selected_dpi_variant = proptools.StringPtr(firstVariantWithApk(dpiProps, dpis))
+	a.variantSelection.Arch_variant_candidates = archs
+	a.variantSelection.Selected_arch_variant = proptools.StringPtr(firstVariantWithApk(archProps, archs))
 
 	if String(a.properties.Apk) == "" {
 		// Disable this module since the apk property is still empty after processing all matching
@@ -161,6 +207,81 @@ func (a *AndroidAppImport) processVariants(ctx android.LoadHookContext) {
 	}
 }
 
+// expandDpiPatterns replaces the wildcard patterns in a list of dpis, like "x*hdpi", with the
+// supported dpis that match them from the densest to the least dense one, and removes the
+// duplicates.
+func expandDpiPatterns(dpis []string) ([]string, error) {
+	var expanded []string
+	for _, dpi := range dpis {
+		if !strings.ContainsAny(dpi, "*?[") {
+			expanded = append(expanded, dpi)
+			continue
+		}
+		for i := len(supportedDpis) - 1; i >= 0; i-- {
+			matched, err := path.Match(dpi, supportedDpis[i])
+			if err != nil {
+				return nil, fmt.Errorf("%q: %s", dpi, err)
+			}
+			if matched {
+				expanded = append(expanded, supportedDpis[i])
+			}
+		}
+	}
+	return android.FirstUniqueStrings(expanded), nil
+}
+
+// The arch variants of the app ABIs that the product can prefer.
+var appImportAbiArchs = map[string]string{
+	"armeabi":     "arm",
+	"armeabi-v7a": "arm",
+	"arm64-v8a":   "arm64",
+	"riscv64":     "riscv64",
+	"x86":         "x86",
+	"x86_64":      "x86_64",
+}
+
+// appImportArchVariants returns the arch variants in priority order, the ones of the preferred
+// ABIs of the product if it sets them, or else the arch of the first device target.
+func appImportArchVariants(config android.Config) ([]string, error) {
+	abis := config.ProductAppImportPreferredAbis()
+	if len(abis) == 0 {
+		return []string{config.AndroidFirstDeviceTarget.Arch.ArchType.Name}, nil
+	}
+
+	var archs []string
+	for _, abi := range abis {
+		arch, ok := appImportAbiArchs[abi]
+		if !ok {
+			// Allow the arch names as well.
+			for _, archType := range android.ArchTypeList() {
+				if archType.Name == abi {
+					arch, ok = abi, true
+				}
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown ABI %q", abi)
+		}
+		archs = append(archs, arch)
+	}
+	return android.FirstUniqueStrings(archs), nil
+}
+
+// firstVariantWithApk returns the first of the variants in a variant group that sets the apk, or
+// an empty string if none of them does.
+func firstVariantWithApk(variantGroup reflect.Value, variants []string) string {
+	for _, variant := range variants {
+		src := variantGroup.FieldByName(proptools.FieldNameForProperty(variant))
+		if !src.IsValid() || src.IsNil() {
+			continue
+		}
+		if String(src.Interface().(*AndroidAppImportProperties).Apk) != "" {
+			return variant
+		}
+	}
+	return ""
+}
+
 func MergePropertiesFromVariant(ctx android.EarlyModuleContext,
 	dst interface{}, variantGroup reflect.Value, variant string) {
 	src := variantGroup.FieldByName(proptools.FieldNameForProperty(variant))
@@ -268,7 +389,8 @@ func (a *AndroidAppImport) generateAndroidBuildActions(ctx android.ModuleContext
 	// TODO: LOCAL_EXTRACT_APK/LOCAL_EXTRACT_DPI_APK
 	// TODO: LOCAL_PACKAGE_SPLITS
 
-	srcApk := a.prebuilt.SingleSourcePath(ctx)
+	srcApk := android.CheckPrebuiltSha256(ctx, android.Paths{a.prebuilt.SingleSourcePath(ctx)},
+		a.properties.Sha256)[0]
 
 	// TODO: Install or embed JNI libraries
 
@@ -397,6 +519,8 @@ func (a *AndroidAppImport) populateAllVariantStructs() {
 
 	a.archVariants = reflect.New(archVariantGroupType).Interface()
 	a.AddProperties(a.archVariants)
+
+	a.AddProperties(&a.variantSelection)
 }
 
 func (a *AndroidAppImport) Privileged() bool {
@@ -467,6 +591,11 @@ func createVariantGroupType(variants []string, variantGroupName string) reflect.
 //	    },
 //	    presigned: true,
 //	}
+//
+// The dpi variant is selected from the preferred config and the prebuilt dpis of the product,
+// which can be wildcard patterns like "x*hdpi", and the arch variant from the preferred ABIs of the
+// product, or else the arch of its first device target. The selected variants are reported in
+// $OUT_DIR/soong/app_import_variants.txt.
 func AndroidAppImportFactory() android.Module {
 	module := &AndroidAppImport{}
 	module.AddProperties(&module.properties)
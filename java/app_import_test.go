@@ -59,6 +59,28 @@ func TestAndroidAppImport(t *testing.T) {
 	android.AssertStringEquals(t, "Invalid args", "/system/app/foo/foo.apk", rule.Args["install_path"])
 }
 
+func TestAndroidAppImport_Sha256(t *testing.T) {
+	ctx, _ := testJava(t, `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			sha256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			presigned: true,
+		}
+		`)
+
+	variant := ctx.ModuleForTests("foo", "android_common")
+	check := variant.Output("sha256/app.apk")
+	android.AssertStringEquals(t, "check input", "prebuilts/apk/app.apk", check.Input.String())
+
+	// The checked copy of the apk is imported.
+	jniRule := variant.Output("jnis-uncompressed/foo.apk")
+	android.AssertPathRelativeToTopEquals(t, "imported apk", check.Output.String(), jniRule.Input)
+
+	info := ctx.ModuleProvider(variant.Module(), android.PrebuiltSha256InfoProvider).(android.PrebuiltSha256Info)
+	android.AssertPathsRelativeToTopEquals(t, "prebuilt srcs", []string{"prebuilts/apk/app.apk"}, info.Srcs)
+}
+
 func TestAndroidAppImport_NoDexPreopt(t *testing.T) {
 	ctx, _ := testJava(t, `
 		android_app_import {
@@ -291,6 +313,20 @@ func TestAndroidAppImport_DpiVariants(t *testing.T) {
 			expected:                               "verify_uses_libraries/apk/app.apk",
 			expectedProvenanceMetaDataArtifactPath: "prebuilts/apk/app.apk",
 		},
+		{
+			name:                                   "wildcard AAPTPrebuiltDPI matches",
+			aaptPreferredConfig:                    nil,
+			aaptPrebuiltDPI:                        []string{"x*hdpi"},
+			expected:                               "verify_uses_libraries/apk/app_xxhdpi.apk",
+			expectedProvenanceMetaDataArtifactPath: "prebuilts/apk/app_xxhdpi.apk",
+		},
+		{
+			name:                                   "AAPTPreferredConfig before wildcard",
+			aaptPreferredConfig:                    proptools.StringPtr("xhdpi"),
+			aaptPrebuiltDPI:                        []string{"*"},
+			expected:                               "verify_uses_libraries/apk/app_xhdpi.apk",
+			expectedProvenanceMetaDataArtifactPath: "prebuilts/apk/app_xhdpi.apk",
+		},
 	}
 
 	for _, test := range testCases {
@@ -477,6 +513,153 @@ func TestAndroidAppImport_ArchVariants(t *testing.T) {
 	}
 }
 
+func TestAndroidAppImport_PreferredAbis(t *testing.T) {
+	bp := `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			arch: {
+				arm: {
+					apk: "prebuilts/apk/app_arm.apk",
+				},
+				arm64: {
+					apk: "prebuilts/apk/app_arm64.apk",
+				},
+			},
+			presigned: true,
+		}
+	`
+	testCases := []struct {
+		name          string
+		preferredAbis []string
+		artifactPath  string
+	}{
+		{
+			name:          "first device target",
+			preferredAbis: nil,
+			artifactPath:  "prebuilts/apk/app_arm64.apk",
+		},
+		{
+			name:          "preferred ABI",
+			preferredAbis: []string{"armeabi-v7a", "arm64-v8a"},
+			artifactPath:  "prebuilts/apk/app_arm.apk",
+		},
+		{
+			name:          "non-first preferred arch",
+			preferredAbis: []string{"x86_64", "arm64"},
+			artifactPath:  "prebuilts/apk/app_arm64.apk",
+		},
+		{
+			name:          "no matching preferred ABI",
+			preferredAbis: []string{"x86"},
+			artifactPath:  "prebuilts/apk/app.apk",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			result := android.GroupFixturePreparers(
+				PrepareForTestWithJavaDefaultModules,
+				android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+					variables.AppImportPreferredAbis = test.preferredAbis
+				}),
+			).RunTestWithBp(t, bp)
+
+			rule := result.ModuleForTests("foo", "android_common").Rule("genProvenanceMetaData")
+			android.AssertStringEquals(t, "selected apk", test.artifactPath, rule.Inputs[0].String())
+		})
+	}
+}
+
+func TestAndroidAppImport_InvalidVariantConfig(t *testing.T) {
+	bp := `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			presigned: true,
+		}
+	`
+	android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.AppImportPreferredAbis = []string{"mips"}
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`invalid preferred ABI in the product configuration: unknown ABI "mips"`)).
+		RunTestWithBp(t, bp)
+
+	android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.AAPTPrebuiltDPI = []string{"[xhdpi"}
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`invalid dpi in the product configuration: "\[xhdpi": syntax error in pattern`)).
+		RunTestWithBp(t, bp)
+}
+
+func TestAndroidAppImport_VariantReport(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.AAPTPreferredConfig = proptools.StringPtr("xhdpi")
+			variables.AAPTPrebuiltDPI = []string{"xx*"}
+		}),
+	).RunTestWithBp(t, `
+		android_app_import {
+			name: "dpi",
+			apk: "prebuilts/apk/app.apk",
+			dpi_variants: {
+				xxhdpi: {
+					apk: "prebuilts/apk/app_xxhdpi.apk",
+				},
+			},
+			presigned: true,
+		}
+
+		android_app_import {
+			name: "arch",
+			dpi_variants: {
+				xhdpi: {
+					apk: "prebuilts/apk/app_xhdpi.apk",
+				},
+			},
+			arch: {
+				arm64: {
+					apk: "prebuilts/apk/app_arm64.apk",
+				},
+			},
+			presigned: true,
+		}
+
+		android_app_import {
+			name: "default",
+			apk: "prebuilts/apk/app.apk",
+			presigned: true,
+		}
+
+		android_test_import {
+			name: "missing",
+			arch: {
+				arm: {
+					apk: "prebuilts/apk/app_arm.apk",
+				},
+			},
+			presigned: true,
+		}
+	`)
+
+	report := android.ContentFromFileRuleForTests(t,
+		result.SingletonForTests("app_import_variant_report").Output("app_import_variants.txt"))
+	tried := " (dpi variants tried: xhdpi, xxxhdpi, xxhdpi; arch variants tried: arm64)"
+	android.AssertStringEquals(t, "report", strings.Join([]string{
+		"arch: prebuilts/apk/app_arm64.apk, from the arm64 arch variant" + tried,
+		"default: prebuilts/apk/app.apk, the default apk" + tried,
+		"dpi: prebuilts/apk/app_xxhdpi.apk, from the xxhdpi dpi variant" + tried,
+		"missing: disabled, no variant sets the apk and there is no default apk" + tried,
+	}, "\n")+"\n", report)
+}
+
 func TestAndroidAppImport_overridesDisabledAndroidApp(t *testing.T) {
 	ctx, _ := testJava(t, `
 		android_app {
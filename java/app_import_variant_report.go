@@ -0,0 +1,116 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"android/soong/android"
+)
+
+// This singleton generates a report of the prebuilt apk that each android_app_import and
+// android_test_import module selected for the product, and of the dpi or arch variant it was
+// selected from, along with the variants that were tried in priority order, i.e. the preferred
+// config and the prebuilt dpis of the product and its preferred ABIs or the arch of its first
+// device target.
+//
+// The report is generated in $OUT_DIR/soong/app_import_variants.txt and is dist'ed as part of
+// droidcore.
+
+func appImportVariantReportSingletonFactory() android.Singleton {
+	return &appImportVariantReportSingleton{}
+}
+
+type appImportVariantReportSingleton struct {
+	report android.Path
+}
+
+var _ android.SingletonMakeVarsProvider = (*appImportVariantReportSingleton)(nil)
+
+// appImportVariantSelector is implemented by the android_app_import and android_test_import
+// modules.
+type appImportVariantSelector interface {
+	android.Module
+	variantSelectionReport() string
+}
+
+// variantSelectionReport returns the line of the variant selection report of the module.
+func (a *AndroidAppImport) variantSelectionReport() string {
+	selection := a.variantSelection
+
+	apk := String(a.properties.Apk)
+	var selected string
+	if apk == "" {
+		selected = "disabled, no variant sets the apk and there is no default apk"
+	} else if arch := String(selection.Selected_arch_variant); arch != "" {
+		selected = fmt.Sprintf("%s, from the %s arch variant", apk, arch)
+	} else if dpi := String(selection.Selected_dpi_variant); dpi != "" {
+		selected = fmt.Sprintf("%s, from the %s dpi variant", apk, dpi)
+	} else {
+		selected = fmt.Sprintf("%s, the default apk", apk)
+	}
+	if apk != "" && !a.Enabled() {
+		selected += ", disabled"
+	} else if apk != "" && !android.IsModulePreferred(a) {
+		selected += ", not installed as a source module is preferred"
+	}
+
+	return fmt.Sprintf("%s: %s (dpi variants tried: %s; arch variants tried: %s)",
+		android.RemoveOptionalPrebuiltPrefix(a.Name()), selected,
+		variantsOrNone(selection.Dpi_variant_candidates), variantsOrNone(selection.Arch_variant_candidates))
+}
+
+func variantsOrNone(variants []string) string {
+	if len(variants) == 0 {
+		return "none"
+	}
+	return strings.Join(variants, ", ")
+}
+
+func (s *appImportVariantReportSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	// The apex variants of a module select the same apk.
+	lines := make(map[string]string)
+	ctx.VisitAllModules(func(module android.Module) {
+		if m, ok := module.(appImportVariantSelector); ok {
+			lines[ctx.ModuleName(module)] = m.variantSelectionReport()
+		}
+	})
+
+	if len(lines) == 0 {
+		// There are no prebuilt apps.
+		return
+	}
+
+	var report []string
+	for _, line := range lines {
+		report = append(report, line)
+	}
+	// Sort the lines for determinism as modules are visited in no particular order.
+	sort.Strings(report)
+
+	output := android.PathForOutput(ctx, "app_import_variants.txt")
+	android.WriteFileRule(ctx, output, strings.Join(report, "\n"))
+
+	ctx.Phony("app-import-variant-report", output)
+	s.report = output
+}
+
+func (s *appImportVariantReportSingleton) MakeVars(ctx android.MakeVarsContext) {
+	if s.report != nil {
+		ctx.DistForGoal("droidcore", s.report)
+	}
+}
@@ -15,6 +15,7 @@
 package java
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"reflect"
@@ -22,6 +23,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/blueprint"
 	"github.com/google/blueprint/proptools"
 
 	"android/soong/android"
@@ -126,6 +128,198 @@ func TestAppSplits(t *testing.T) {
 	android.AssertPathsRelativeToTopEquals(t, `OutputFiles("")`, expectedOutputs, outputFiles)
 }
 
+func TestAppBundle(t *testing.T) {
+	ctx := testApp(t, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			app_bundle: {
+				enabled: true,
+				split_dimensions: ["abi", "density"],
+			},
+		}
+
+		android_app {
+			name: "bar",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+	`)
+
+	foo := ctx.ModuleForTests("foo", "android_common")
+	bundle := foo.Output("foo.aab")
+	android.AssertPathRelativeToTopEquals(t, "bundle input",
+		"out/soong/.intermediates/foo/android_common/base.zip", bundle.Input)
+	android.AssertPathRelativeToTopEquals(t, "bundle validation",
+		"out/soong/.intermediates/foo/android_common/app_bundle/verified.timestamp", bundle.Validation)
+
+	var config appBundleConfig
+	content := android.ContentFromFileRuleForTests(t, foo.Output("app_bundle/BundleConfig.json"))
+	if err := json.Unmarshal([]byte(content), &config); err != nil {
+		t.Fatalf("invalid bundle config %q: %s", content, err)
+	}
+	android.AssertDeepEquals(t, "split dimensions", []appBundleSplitDimension{
+		{Value: "ABI", Negate: false},
+		{Value: "SCREEN_DENSITY", Negate: false},
+		{Value: "LANGUAGE", Negate: true},
+	}, config.Optimizations.SplitsConfig.SplitDimension)
+
+	verify := foo.Output("app_bundle/verified.timestamp")
+	android.AssertStringEquals(t, "verified apk",
+		"out/soong/.intermediates/foo/android_common/foo.apk", verify.Args["apk"])
+
+	outputFiles, err := foo.Module().(*AndroidApp).OutputFiles(".aab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	android.AssertPathsRelativeToTopEquals(t, `OutputFiles(".aab")`,
+		[]string{"out/soong/.intermediates/foo/android_common/foo.aab"}, outputFiles)
+
+	bar := ctx.ModuleForTests("bar", "android_common")
+	if bar.MaybeOutput("bar.aab").Rule != nil {
+		t.Errorf("expected no app bundle without app_bundle.enabled")
+	}
+	if _, err := bar.Module().(*AndroidApp).OutputFiles(".aab"); err == nil {
+		t.Errorf("expected an error for the .aab output of an app without app_bundle.enabled")
+	}
+}
+
+func TestAppBundleInvalidSplitDimension(t *testing.T) {
+	testJavaError(t, `unknown split dimension "textures"`, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			app_bundle: {
+				enabled: true,
+				split_dimensions: ["abi", "textures"],
+			},
+		}
+	`)
+}
+
+func TestAppFeatures(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForJavaTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.AAPTConfig = []string{"normal", "xhdpi"}
+			variables.AAPTPreferredConfig = proptools.StringPtr("xhdpi")
+		}),
+		android.FixtureMergeMockFs(android.MockFS{
+			"camera/AndroidManifest.xml":       nil,
+			"camera/res/values/strings.xml":    nil,
+			"maps/AndroidManifest.xml":         nil,
+			"maps/assets/tiles.bin":            nil,
+			"maps/res/layout/layout.xml":       nil,
+			"maps/res/values-en/strings.xml":   nil,
+			"maps/res/values-fr/strings.xml":   nil,
+			"maps/res/values-de/strings.xml":   nil,
+			"maps/res/xml/maps_config.xml":     nil,
+			"maps/res/drawable/background.xml": nil,
+		}),
+	).RunTestWithBp(t, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			features: [":camera", ":foo_maps"],
+		}
+
+		android_app_feature {
+			name: "camera",
+			manifest: "camera/AndroidManifest.xml",
+			resource_dirs: ["camera/res"],
+		}
+
+		android_app_feature {
+			name: "foo_maps",
+			split_name: "maps",
+			manifest: "maps/AndroidManifest.xml",
+			resource_dirs: ["maps/res"],
+			asset_dirs: ["maps/assets"],
+		}
+	`)
+
+	foo := result.ModuleForTests("foo", "android_common")
+
+	expectedOutputs := []string{
+		"out/soong/.intermediates/foo/android_common/foo.apk",
+		"out/soong/.intermediates/foo/android_common/foo_camera.apk",
+		"out/soong/.intermediates/foo/android_common/foo_maps.apk",
+	}
+	for _, expectedOutput := range expectedOutputs {
+		foo.Output(expectedOutput)
+	}
+
+	outputFiles, err := foo.Module().(*AndroidApp).OutputFiles("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	android.AssertPathsRelativeToTopEquals(t, `OutputFiles("")`, expectedOutputs, outputFiles)
+	android.AssertPathsRelativeToTopEquals(t, "FeatureSplitOutputFiles()", expectedOutputs[1:],
+		foo.Module().(*AndroidApp).FeatureSplitOutputFiles())
+
+	camera := foo.Output("feature_splits/camera/package-res.apk")
+	android.AssertStringEquals(t, "camera base apk",
+		"out/soong/.intermediates/foo/android_common/package-res.apk", camera.Args["baseApk"])
+	android.AssertStringDoesContain(t, "camera flags", camera.Args["flags"], "--package-id 0x7e")
+	android.AssertStringDoesContain(t, "camera flags", camera.Args["flags"], "-c normal -c xhdpi --preferred-density xhdpi")
+	android.AssertPathRelativeToTopEquals(t, "camera validation",
+		"out/soong/.intermediates/foo/android_common/feature_splits/camera/check.timestamp", camera.Validation)
+
+	maps := foo.Output("feature_splits/maps/package-res.apk")
+	android.AssertStringDoesContain(t, "maps flags", maps.Args["flags"], "--package-id 0x7d")
+	android.AssertStringDoesContain(t, "maps flags", maps.Args["flags"], "-A maps/assets")
+	android.AssertStringEquals(t, "maps manifest", "maps/AndroidManifest.xml", maps.Args["manifest"])
+
+	check := foo.Output("feature_splits/maps/check.timestamp")
+	android.AssertStringEquals(t, "maps split name", "maps", check.Args["splitName"])
+}
+
+func TestAppFeaturesErrors(t *testing.T) {
+	testJavaError(t, `features: must be names of android_app_feature modules in the form ":module"`, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			features: ["camera"],
+		}
+	`)
+
+	testJavaError(t, `features: "bar" must be an android_app_feature module`, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			features: [":bar"],
+		}
+
+		java_library {
+			name: "bar",
+			srcs: ["b.java"],
+		}
+	`)
+
+	testJavaError(t, `features: more than one feature split is named "camera"`, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			features: [":camera", ":camera2"],
+		}
+
+		android_app_feature {
+			name: "camera",
+		}
+
+		android_app_feature {
+			name: "camera2",
+			split_name: "camera",
+		}
+	`)
+}
+
 func TestPlatformAPIs(t *testing.T) {
 	testJava(t, `
 		android_app {
@@ -1383,6 +1577,81 @@ func TestJNIPackaging(t *testing.T) {
 	}
 }
 
+func TestEmbedNotices(t *testing.T) {
+	ctx, _ := testJava(t, cc.GatherRequiredDepsForTest(android.Android)+`
+		cc_library {
+			name: "libjni",
+			system_shared_libs: [],
+			stl: "none",
+			sdk_version: "current",
+		}
+
+		android_app {
+			name: "app",
+			jni_libs: ["libjni"],
+			sdk_version: "current",
+		}
+
+		android_app {
+			name: "app_embed",
+			jni_libs: ["libjni"],
+			use_embedded_native_libs: true,
+			sdk_version: "current",
+		}
+
+		android_app {
+			name: "app_embed_no_notices",
+			jni_libs: ["libjni"],
+			use_embedded_native_libs: true,
+			embed_notices: false,
+			sdk_version: "current",
+		}
+
+		android_app {
+			name: "app_notices",
+			embed_notices: true,
+			sdk_version: "current",
+		}
+		`)
+
+	testCases := []struct {
+		name       string
+		notices    bool
+		jniNotices bool
+	}{
+		{"app", false, false},
+		{"app_embed", true, true},
+		{"app_embed_no_notices", false, false},
+		{"app_notices", true, false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			app := ctx.ModuleForTests(test.name, "android_common")
+			notice := app.MaybeOutput("NOTICE/NOTICE.html.gz")
+			if g, w := notice.Rule != nil, test.notices; g != w {
+				t.Errorf("expected notices embedded %v, got %v", w, g)
+			}
+			if notice.Rule == nil {
+				return
+			}
+
+			metadata := app.Output("NOTICE.html.gz").Implicits.Strings()
+			android.AssertStringListContains(t, "app license metadata", metadata,
+				"out/soong/.intermediates/"+test.name+"/android_common/meta_lic")
+			jniMetadata := false
+			for _, m := range metadata {
+				if strings.HasPrefix(m, "out/soong/.intermediates/libjni/") {
+					jniMetadata = true
+				}
+			}
+			if jniMetadata != test.jniNotices {
+				t.Errorf("expected JNI library notices %v in %q", test.jniNotices, metadata)
+			}
+		})
+	}
+}
+
 func TestJNISDK(t *testing.T) {
 	ctx, _ := testJava(t, cc.GatherRequiredDepsForTest(android.Android)+`
 		cc_library {
@@ -1732,6 +2001,81 @@ func TestRequestV4SigningFlag(t *testing.T) {
 	}
 }
 
+func TestExternalSigning(t *testing.T) {
+	bp := `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			v4_signature: true,
+		}
+
+		android_app {
+			name: "bar",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+	`
+
+	testCases := []struct {
+		name    string
+		modules []string
+		dryRun  bool
+		foo     blueprint.Rule
+		bar     blueprint.Rule
+	}{
+		{
+			name: "all modules",
+			foo:  externalSignapk,
+			bar:  externalSignapk,
+		},
+		{
+			name:    "listed modules",
+			modules: []string{"foo"},
+			foo:     externalSignapk,
+			bar:     Signapk,
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+			foo:    Signapk,
+			bar:    Signapk,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			result := android.GroupFixturePreparers(
+				PrepareForTestWithJavaDefaultModules,
+				android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+					variables.ExternalSigningCommand = proptools.StringPtr("vendor/hsm/sign.sh")
+					variables.ExternalSigningModules = test.modules
+					variables.ExternalSigningDryRun = proptools.BoolPtr(test.dryRun)
+				}),
+				android.FixtureAddFile("vendor/hsm/sign.sh", nil),
+			).RunTestWithBp(t, bp)
+
+			foo := result.ModuleForTests("foo", "android_common")
+			signapk := foo.Output("foo.apk")
+			android.AssertStringEquals(t, "foo signing rule", test.foo.String(), signapk.Rule.String())
+			bar := result.ModuleForTests("bar", "android_common").Output("bar.apk")
+			android.AssertStringEquals(t, "bar signing rule", test.bar.String(), bar.Rule.String())
+
+			if test.foo == externalSignapk {
+				android.AssertStringEquals(t, "command", "vendor/hsm/sign.sh", signapk.Args["externalSigningCmd"])
+				android.AssertStringDoesContain(t, "extra args", signapk.Args["extraArgs"], "foo.apk.idsig")
+				android.AssertStringDoesContain(t, "extra args", signapk.Args["extraArgs"], "--certificate build/make/target/product/security/testkey.x509.pem")
+				android.AssertStringDoesNotContain(t, "extra args", signapk.Args["extraArgs"], ".pk8")
+			}
+			if test.dryRun {
+				dryRun := foo.Output("external_signing/foo.apk.dry_run")
+				android.AssertStringEquals(t, "dry run flag", "--dry-run", dryRun.Args["dryRun"])
+				android.AssertPathsRelativeToTopEquals(t, "validations", []string{"out/soong/.intermediates/foo/android_common/external_signing/foo.apk.dry_run"}, signapk.Validations)
+			}
+		})
+	}
+}
+
 func TestPackageNameOverride(t *testing.T) {
 	testCases := []struct {
 		name                string
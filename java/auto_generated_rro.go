@@ -0,0 +1,289 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+// This file implements the runtime resource overlays that Soong generates for the android_app
+// modules with enforced RROs when the product sets AutoGeneratedRROs. Instead of passing the
+// resource overlay directories of the app to Make to be turned into RRO packages, the app creates
+// an overlay module for the device resource overlays and another one for the product resource
+// overlays, which are installed in the partitions and with the priorities of the product
+// configuration, and are required by the app. An overlay is only built if the app has resource
+// overlays of its type.
+
+import (
+	"fmt"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+var autoGeneratedRROBaseTag = dependencyTag{name: "auto-generated-rro-base"}
+
+var autoGeneratedRROManifest = pctx.AndroidStaticRule("autoGeneratedRROManifest",
+	blueprint.RuleParams{
+		Command: `package=$$(${config.Aapt2Cmd} dump packagename $in) && ` +
+			`sed -e "s/{package}/$$package/g" $template > $out`,
+		CommandDeps: []string{"${config.Aapt2Cmd}"},
+	}, "template")
+
+// The manifest of an auto-generated RRO, where {package} is replaced by the package of the app.
+const autoGeneratedRROManifestTemplate = `<?xml version="1.0" encoding="utf-8"?>
+<manifest xmlns:android="http://schemas.android.com/apk/res/android"
+    package="{package}.auto_generated_rro_%s__">
+    <application android:hasCode="false" />
+    <overlay android:targetPackage="{package}"
+        android:isStatic="true"
+        android:priority="%d" />
+</manifest>
+`
+
+type autoGeneratedRROProperties struct {
+	// The android_app module whose resource overlays are built into the overlay.
+	Base *string
+
+	// The type of the resource overlays of the app that are built into the overlay, "device" or
+	// "product".
+	Overlay_type *string
+
+	// The partition of the overlay, which is part of its package name.
+	Partition *string
+
+	// The priority of the overlay.
+	Priority *int64
+}
+
+// The properties that install an auto-generated RRO in its partition, overriding the ones that it
+// inherits from its app.
+type autoGeneratedRROPartitionProperties struct {
+	Vendor              *bool
+	Proprietary         *bool
+	Soc_specific        *bool
+	Device_specific     *bool
+	Product_specific    *bool
+	System_ext_specific *bool
+}
+
+type AutoGeneratedRuntimeResourceOverlay struct {
+	android.ModuleBase
+
+	properties autoGeneratedRROProperties
+
+	certificate Certificate
+
+	outputFile android.Path
+	installDir android.InstallPath
+}
+
+func autoGeneratedRROFactory() android.Module {
+	module := &AutoGeneratedRuntimeResourceOverlay{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidMultiTargetsArchModule(module, android.DeviceSupported, android.MultilibCommon)
+	return module
+}
+
+// autoGeneratedRROModuleName returns the name of the overlay that is generated for an app in a
+// partition.
+func autoGeneratedRROModuleName(app, partition string) string {
+	return app + "__auto_generated_rro_" + partition
+}
+
+// autoGeneratedRROPartition returns the properties that install an overlay in a partition.
+func autoGeneratedRROPartition(partition string) (*autoGeneratedRROPartitionProperties, error) {
+	props := &autoGeneratedRROPartitionProperties{
+		Vendor:              proptools.BoolPtr(false),
+		Proprietary:         proptools.BoolPtr(false),
+		Soc_specific:        proptools.BoolPtr(false),
+		Device_specific:     proptools.BoolPtr(false),
+		Product_specific:    proptools.BoolPtr(false),
+		System_ext_specific: proptools.BoolPtr(false),
+	}
+	switch partition {
+	case "vendor":
+		props.Soc_specific = proptools.BoolPtr(true)
+	case "odm":
+		props.Device_specific = proptools.BoolPtr(true)
+	case "product":
+		props.Product_specific = proptools.BoolPtr(true)
+	case "system_ext":
+		props.System_ext_specific = proptools.BoolPtr(true)
+	default:
+		return nil, fmt.Errorf("unknown partition %q, expected vendor, odm, product or system_ext", partition)
+	}
+	return props, nil
+}
+
+// createAutoGeneratedRROs creates the overlays of the device and the product resource overlays of
+// the app if the product generates the RROs of the modules with enforced RROs in Soong.
+func (a *AndroidApp) createAutoGeneratedRROs(ctx android.LoadHookContext) {
+	if !ctx.Config().AutoGeneratedRROs() || !ctx.Config().EnforceRROForModule(ctx.ModuleName()) {
+		return
+	}
+
+	devicePartition, devicePriority := ctx.Config().DeviceRRO()
+	productPartition, productPriority := ctx.Config().ProductRRO()
+	if devicePartition == productPartition {
+		ctx.ModuleErrorf("the auto-generated RROs of the device and the product resource overlays "+
+			"must be in different partitions, got %q for both", devicePartition)
+		return
+	}
+
+	overlays := []struct {
+		overlayType string
+		overlayDirs []string
+		partition   string
+		priority    int
+		name        *string
+	}{
+		{"device", ctx.Config().DeviceResourceOverlays(), devicePartition, devicePriority,
+			&a.appProperties.AutoGeneratedDeviceRRO},
+		{"product", ctx.Config().ProductResourceOverlays(), productPartition, productPriority,
+			&a.appProperties.AutoGeneratedProductRRO},
+	}
+	for _, overlay := range overlays {
+		if len(overlay.overlayDirs) == 0 {
+			continue
+		}
+		partitionProps, err := autoGeneratedRROPartition(overlay.partition)
+		if err != nil {
+			ctx.ModuleErrorf("invalid partition of the auto-generated RROs of the %s resource overlays: %s",
+				overlay.overlayType, err)
+			continue
+		}
+		name := autoGeneratedRROModuleName(ctx.ModuleName(), overlay.partition)
+		props := struct {
+			Name *string
+			autoGeneratedRROProperties
+		}{
+			Name: proptools.StringPtr(name),
+			autoGeneratedRROProperties: autoGeneratedRROProperties{
+				Base:         proptools.StringPtr(ctx.ModuleName()),
+				Overlay_type: proptools.StringPtr(overlay.overlayType),
+				Partition:    proptools.StringPtr(overlay.partition),
+				Priority:     proptools.Int64Ptr(int64(overlay.priority)),
+			},
+		}
+		ctx.CreateModule(autoGeneratedRROFactory, &props, partitionProps)
+		*overlay.name = name
+	}
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) DepsMutator(ctx android.BottomUpMutatorContext) {
+	sdkDep := decodeSdkDep(ctx, android.SdkContext(r))
+	if sdkDep.hasFrameworkLibs() {
+		ctx.AddVariationDependencies(nil, frameworkResTag, sdkDep.frameworkResModule)
+	}
+	ctx.AddVariationDependencies(nil, autoGeneratedRROBaseTag, String(r.properties.Base))
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	t := device
+	if String(r.properties.Overlay_type) == "product" {
+		t = product
+	}
+
+	var basePackage android.Path
+	var rroDirs android.Paths
+	ctx.VisitDirectDepsWithTag(autoGeneratedRROBaseTag, func(m android.Module) {
+		if app, ok := m.(AndroidLibraryDependency); ok {
+			basePackage = app.ExportPackage()
+			rroDirs = filterRRODirs(app.ExportedRRODirs(), t)
+		}
+	})
+	if basePackage == nil || len(rroDirs) == 0 {
+		// The app has no resource overlays of this type, there is nothing to overlay.
+		return
+	}
+
+	template := android.PathForModuleOut(ctx, "AndroidManifest.xml.template")
+	android.WriteFileRule(ctx, template, fmt.Sprintf(autoGeneratedRROManifestTemplate,
+		String(r.properties.Partition), proptools.Int(r.properties.Priority)))
+	manifest := android.PathForModuleOut(ctx, "AndroidManifest.xml")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        autoGeneratedRROManifest,
+		Description: "auto-generated RRO manifest",
+		Input:       basePackage,
+		Implicit:    template,
+		Output:      manifest,
+		Args: map[string]string{
+			"template": template.String(),
+		},
+	})
+
+	// The resource overlays are all overlays of the overlay package, in aapt2 order.
+	var compiledOverlay android.Paths
+	for _, dir := range rroDirs {
+		compiledOverlay = append(compiledOverlay,
+			aapt2Compile(ctx, dir, androidResourceGlob(ctx, dir), []string{"--pseudo-localize"}).Paths()...)
+	}
+
+	_, _, _, _, libDeps, libFlags := aaptLibs(ctx, android.SdkContext(r), nil)
+	linkFlags := []string{
+		"--manifest " + manifest.String(),
+		"--auto-add-overlay",
+		"--no-resource-deduping",
+		"--no-resource-removal",
+		"-I " + basePackage.String(),
+	}
+	linkFlags = append(linkFlags, libFlags...)
+	linkDeps := append(android.Paths{manifest, basePackage}, libDeps...)
+
+	packageRes := android.PathForModuleOut(ctx, "package-res.apk")
+	aapt2Link(ctx, packageRes,
+		android.PathForModuleGen(ctx, "android", "R.srcjar"),
+		android.PathForModuleGen(ctx, "proguard.options"),
+		android.PathForModuleOut(ctx, "R.txt"),
+		android.PathForModuleOut(ctx, "extra_packages"),
+		linkFlags, linkDeps, nil, compiledOverlay, nil, nil)
+
+	// Sign the overlay with the platform certificate, like the RROs that Make generates.
+	var certificates []Certificate
+	r.certificate, certificates = processMainCert(r.ModuleBase, "platform", nil, ctx)
+	signed := android.PathForModuleOut(ctx, "signed", r.Name()+".apk")
+	SignAppPackage(ctx, signed, packageRes, certificates, nil, nil, "")
+
+	r.outputFile = signed
+	r.installDir = android.PathForModuleInPartitionInstall(ctx, rroPartition(ctx), "overlay")
+	ctx.InstallFile(r.installDir, r.outputFile.Base(), r.outputFile)
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) SdkVersion(ctx android.EarlyModuleContext) android.SdkSpec {
+	return android.SdkSpecFrom(ctx, "current")
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) SystemModules() string {
+	return ""
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) MinSdkVersion(ctx android.EarlyModuleContext) android.ApiLevel {
+	return r.SdkVersion(ctx).ApiLevel
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) ReplaceMaxSdkVersionPlaceholder(ctx android.EarlyModuleContext) android.ApiLevel {
+	return android.SdkSpecPrivate.ApiLevel
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) TargetSdkVersion(ctx android.EarlyModuleContext) android.ApiLevel {
+	return r.SdkVersion(ctx).ApiLevel
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) Certificate() Certificate {
+	return r.certificate
+}
+
+func (r *AutoGeneratedRuntimeResourceOverlay) OutputFile() android.Path {
+	return r.outputFile
+}
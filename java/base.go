@@ -147,6 +147,15 @@ type CommonProperties struct {
 		// If preceded by '.' it matches all classes in the package and subpackages, otherwise
 		// it matches classes in the package that have the class name as a prefix.
 		Exclude_filter []string
+
+		// List of annotations, by fully qualified name, of the classes to exclude from
+		// instrumentation with jacoco along with their nested classes. The annotations must have a
+		// class or a runtime retention.
+		Exclude_annotations []string
+
+		// Whether to exclude the classes generated by the build, i.e. the R, the Manifest and the
+		// BuildConfig classes, from instrumentation with jacoco. Defaults to true.
+		Exclude_generated_code *bool
 	}
 
 	Errorprone struct {
@@ -162,6 +171,20 @@ type CommonProperties struct {
 		// environment variable is true. Setting this to false will improve build
 		// performance more than adding -XepDisableAllChecks in javacflags.
 		Enabled *bool
+
+		// List of errorprone checks that are reported as warnings, e.g. ["MissingOverride"].
+		Enabled_checks []string
+
+		// List of errorprone checks that are reported as errors and fail the build, unless they are
+		// listed in the baseline.
+		Fatal_checks []string
+
+		// Errorprone baseline file of the module, listing the checks of fatal_checks that are known to
+		// fail and that are only reported as warnings until they are fixed, with one check per line.
+		// Lines starting with # are comments. Building with UPDATE_ERRORPRONE_BASELINE=true and the
+		// update-errorprone-baseline goal generates the up to date baseline of each module in
+		// $OUT_DIR/soong/.intermediates/<module>/<variant>/errorprone/errorprone-baseline.txt.
+		Baseline *string `android:"path"`
 	}
 
 	Proto struct {
@@ -270,6 +293,10 @@ type DeviceProperties struct {
 	// public stubs library.
 	SyspropPublicStub string `blueprint:"mutated"`
 
+	// Only for libraries created by a sysprop_library module, the vendor and odm modules that may
+	// link against the internal properties of the library.
+	SyspropInternalAccessAllowlist []string `blueprint:"mutated"`
+
 	HiddenAPIPackageProperties
 	HiddenAPIFlagFileProperties
 }
@@ -493,6 +520,9 @@ type Module struct {
 	maxSdkVersion android.ApiLevel
 
 	sourceExtensions []string
+
+	// True if only the header jar of the module is built, see libraryProperties.Api_only.
+	apiOnly bool
 }
 
 func (j *Module) CheckStableSdkVersion(ctx android.BaseModuleContext) error {
@@ -714,6 +744,7 @@ func (j *Module) deps(ctx android.BottomUpMutatorContext) {
 		j.linter.deps(ctx)
 
 		sdkDeps(ctx, android.SdkContext(j), j.dexer)
+		coreLibraryDesugaringDeps(ctx, j.dexer)
 
 		if j.deviceProperties.SyspropPublicStub != "" {
 			// This is a sysprop implementation library that has a corresponding sysprop public
@@ -899,7 +930,7 @@ func (j *Module) collectBuilderFlags(ctx android.ModuleContext, deps deps) javaB
 	flags.javaVersion = getJavaVersion(ctx, String(j.properties.Java_version), android.SdkContext(j))
 
 	epEnabled := j.properties.Errorprone.Enabled
-	if (ctx.Config().RunErrorProne() && epEnabled == nil) || Bool(epEnabled) {
+	if (ctx.Config().RunErrorProne() && epEnabled == nil) || Bool(epEnabled) || j.updateErrorproneBaseline(ctx) {
 		if config.ErrorProneClasspath == nil && !ctx.Config().RunningInsideUnitTest() {
 			ctx.ModuleErrorf("cannot build with Error Prone, missing external/error_prone?")
 		}
@@ -908,6 +939,7 @@ func (j *Module) collectBuilderFlags(ctx android.ModuleContext, deps deps) javaB
 			"-Xplugin:ErrorProne",
 			"${config.ErrorProneChecks}",
 		}
+		errorProneFlags = append(errorProneFlags, j.errorproneCheckFlags(ctx)...)
 		errorProneFlags = append(errorProneFlags, j.properties.Errorprone.Javacflags...)
 
 		flags.errorProneExtraJavacFlags = "${config.ErrorProneHeapFlags} ${config.ErrorProneFlags} " +
@@ -1118,6 +1150,23 @@ func (j *Module) compile(ctx android.ModuleContext, aaptSrcJar android.Path) {
 	var kotlinJars android.Paths
 	var kotlinHeaderJars android.Paths
 
+	var isolatedAptResJars android.Paths
+	if srcFiles.HasExt(".kt") {
+		// Kapt runs all the annotation processors together as it needs the kotlin sources, which
+		// turbine does not support.
+		for _, plugin := range deps.isolatedPlugins {
+			flags.processorPath = append(flags.processorPath, plugin.processorPath...)
+			flags.processors = append(flags.processors, plugin.processorClass)
+		}
+	} else if len(deps.isolatedPlugins) > 0 {
+		// Run the isolating annotation processors before compiling the header jar so that the
+		// generated sources are compiled by both turbine and javac.
+		var isolatedAptSrcJars android.Paths
+		isolatedAptSrcJars, isolatedAptResJars = j.runIsolatedAnnotationProcessors(ctx,
+			uniqueJavaFiles, srcJars, deps.isolatedPlugins, flags)
+		srcJars = append(srcJars, isolatedAptSrcJars...)
+	}
+
 	if srcFiles.HasExt(".kt") {
 		// When using kotlin sources turbine is used to generate annotation processor sources,
 		// including for annotation processors that generate API, so we can use turbine for
@@ -1197,6 +1246,7 @@ func (j *Module) compile(ctx android.ModuleContext, aaptSrcJar android.Path) {
 	}
 
 	jars := append(android.Paths(nil), kotlinJars...)
+	jars = append(jars, isolatedAptResJars...)
 
 	j.compiledSrcJars = srcJars
 
@@ -1217,6 +1267,10 @@ func (j *Module) compile(ctx android.ModuleContext, aaptSrcJar android.Path) {
 			return
 		}
 	}
+	if j.apiOnly {
+		j.compileApiOnly(ctx)
+		return
+	}
 	if len(uniqueJavaFiles) > 0 || len(srcJars) > 0 {
 		hasErrorproneableFiles := false
 		for _, ext := range j.sourceExtensions {
@@ -1227,6 +1281,10 @@ func (j *Module) compile(ctx android.ModuleContext, aaptSrcJar android.Path) {
 				break
 			}
 		}
+		if hasErrorproneableFiles && j.updateErrorproneBaseline(ctx) {
+			j.buildErrorproneBaseline(ctx, uniqueJavaFiles, srcJars, enableErrorproneFlags(flags))
+		}
+
 		var extraJarDeps android.Paths
 		if Bool(j.properties.Errorprone.Enabled) {
 			// If error-prone is enabled, enable errorprone flags on the regular
@@ -1618,6 +1676,55 @@ func (j *Module) compile(ctx android.ModuleContext, aaptSrcJar android.Path) {
 	j.outputFile = outputFile.WithoutRel()
 }
 
+// compileApiOnly provides the header jar built by turbine as the only output of a module that is
+// only used at compile time, skipping the compilation of its implementation jar.
+func (j *Module) compileApiOnly(ctx android.ModuleContext) {
+	if android.InList(".kt", j.sourceExtensions) {
+		ctx.PropertyErrorf("api_only", "is not supported for modules with kotlin sources")
+		return
+	}
+	if j.headerJarFile == nil {
+		ctx.PropertyErrorf("api_only", "requires the header jar to be built by turbine, which is "+
+			"only possible for device modules without annotation processors that generate API")
+		return
+	}
+	if Bool(j.properties.Installable) {
+		ctx.PropertyErrorf("api_only", "cannot be used with installable: true as the module has no implementation jar")
+		return
+	}
+
+	ctx.CheckbuildFile(j.headerJarFile)
+
+	ctx.SetProvider(JavaInfoProvider, JavaInfo{
+		HeaderJars:                     android.PathsIfNonNil(j.headerJarFile),
+		TransitiveLibsHeaderJars:       j.transitiveLibsHeaderJars,
+		TransitiveStaticLibsHeaderJars: j.transitiveStaticLibsHeaderJars,
+		AidlIncludeDirs:                j.exportAidlIncludeDirs,
+		ExportedPlugins:                j.exportedPluginJars,
+		ExportedPluginClasses:          j.exportedPluginClasses,
+		ExportedPluginDisableTurbine:   j.exportedDisableTurbine,
+		ApiOnly:                        true,
+	})
+
+	j.outputFile = j.headerJarFile
+}
+
+// runIsolatedAnnotationProcessors runs each of the isolating annotation processors in its own
+// turbine action and returns the jars of the sources and of the resources that they generated.
+func (j *Module) runIsolatedAnnotationProcessors(ctx android.ModuleContext, srcFiles, srcJars android.Paths,
+	plugins []isolatedPlugin, flags javaBuilderFlags) (android.Paths, android.Paths) {
+
+	var genSrcJars, resJars android.Paths
+	for _, plugin := range plugins {
+		genSrcJar := android.PathForModuleOut(ctx, "isolated_apt", plugin.name, "gen.srcjar")
+		resJar := android.PathForModuleOut(ctx, "isolated_apt", plugin.name, "res.jar")
+		TurbineIsolatedApt(ctx, genSrcJar, resJar, srcFiles, srcJars, flags, plugin.processorPath, plugin.processorClass)
+		genSrcJars = append(genSrcJars, genSrcJar)
+		resJars = append(resJars, resJar)
+	}
+	return genSrcJars, resJars
+}
+
 func (j *Module) useCompose() bool {
 	return android.InList("androidx.compose.runtime_runtime", j.properties.Static_libs)
 }
@@ -1731,7 +1838,8 @@ func (j *Module) instrument(ctx android.ModuleContext, flags javaBuilderFlags,
 	jacocoReportClassesFile := android.PathForModuleOut(ctx, "jacoco-report-classes", jarName)
 	instrumentedJar := android.PathForModuleOut(ctx, "jacoco", jarName).OutputPath
 
-	jacocoInstrumentJar(ctx, instrumentedJar, jacocoReportClassesFile, classesJar, specs)
+	excludedClasses := j.jacocoExcludedClassesForAnnotations(ctx, classesJar)
+	jacocoInstrumentJar(ctx, instrumentedJar, jacocoReportClassesFile, classesJar, specs, excludedClasses)
 
 	j.jacocoReportClassesFile = jacocoReportClassesFile
 
@@ -2046,6 +2154,16 @@ func (j *Module) collectDeps(ctx android.ModuleContext) deps {
 				// dep with the JavaInfo from the SyspropPublicStubInfoProvider.
 				syspropDep := ctx.OtherModuleProvider(module, SyspropPublicStubInfoProvider).(SyspropPublicStubInfo)
 				dep = syspropDep.JavaInfo
+			} else if ctx.OtherModuleHasProvider(module, SyspropPublicStubInfoProvider) &&
+				j.partitionGroup(ctx) == partitionGroupVendor {
+				// dep is the implementation library of a platform sysprop_library, which reads and
+				// writes the internal properties of the platform.
+				syspropDep := ctx.OtherModuleProvider(module, SyspropPublicStubInfoProvider).(SyspropPublicStubInfo)
+				if !inList(ctx.ModuleName(), syspropDep.InternalAccessAllowlist) {
+					ctx.ModuleErrorf("links against the internal properties of the platform sysprop_library %q from the %s partition group. "+
+						"Use an sdk_version to link against its public properties, or add %q to its internal_access_allowlist.",
+						otherName, j.partitionGroup(ctx), ctx.ModuleName())
+				}
 			}
 			switch tag {
 			case bootClasspathTag:
@@ -2065,6 +2183,9 @@ func (j *Module) collectDeps(ctx android.ModuleContext) deps {
 				if _, ok := module.(*Plugin); ok {
 					ctx.ModuleErrorf("a java_plugin (%s) cannot be used as a static_libs dependency", otherName)
 				}
+				if dep.ApiOnly {
+					ctx.ModuleErrorf("an api_only library (%s) has no implementation jar and cannot be used as a static_libs dependency, use libs instead", otherName)
+				}
 				deps.classpath = append(deps.classpath, dep.HeaderJars...)
 				deps.staticJars = append(deps.staticJars, dep.ImplementationJars...)
 				deps.staticHeaderJars = append(deps.staticHeaderJars, dep.HeaderJars...)
@@ -2077,6 +2198,20 @@ func (j *Module) collectDeps(ctx android.ModuleContext) deps {
 				deps.disableTurbine = deps.disableTurbine || dep.ExportedPluginDisableTurbine
 			case pluginTag:
 				if plugin, ok := module.(*Plugin); ok {
+					if Bool(plugin.pluginProperties.Isolating) {
+						if plugin.pluginProperties.Processor_class == nil {
+							ctx.PropertyErrorf("plugins", "%q is isolating but does not set processor_class", otherName)
+						} else {
+							deps.isolatedPlugins = append(deps.isolatedPlugins, isolatedPlugin{
+								name:           otherName,
+								processorPath:  classpath(dep.ImplementationAndResourcesJars),
+								processorClass: *plugin.pluginProperties.Processor_class,
+							})
+						}
+						// The sources generated by isolating annotation processors are available to
+						// turbine, so they do not need to disable the turbine optimization.
+						break
+					}
 					if plugin.pluginProperties.Processor_class != nil {
 						addPlugins(&deps, dep.ImplementationAndResourcesJars, *plugin.pluginProperties.Processor_class)
 					} else {
@@ -2118,7 +2253,8 @@ func (j *Module) collectDeps(ctx android.ModuleContext) deps {
 				// This is a sysprop implementation library, forward the JavaInfoProvider from
 				// the corresponding sysprop public stub library as SyspropPublicStubInfoProvider.
 				ctx.SetProvider(SyspropPublicStubInfoProvider, SyspropPublicStubInfo{
-					JavaInfo: dep,
+					JavaInfo:                dep,
+					InternalAccessAllowlist: j.deviceProperties.SyspropInternalAccessAllowlist,
 				})
 			}
 		} else if dep, ok := module.(android.SourceFileProducer); ok {
@@ -0,0 +1,160 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"android/soong/android"
+	"android/soong/dexpreopt"
+
+	"github.com/google/blueprint"
+)
+
+// Contains support for checking that the classes of a bootclasspath_fragment do not collide with
+// the classes of the rest of the bootclasspath.
+//
+// At runtime a class that is in more than one jar on the bootclasspath is only loaded from the
+// first jar that contains it, so a duplicate class in a fragment is either silently ignored or
+// silently shadows the class of another part of the bootclasspath, depending on the order of the
+// jars on the device. The check is done when building the fragment, comparing the classes of its
+// contents with those of all the other jars of the bootclasspath of the product, i.e. the
+// platform jars and the contents of the other fragments.
+
+// bootclasspathClassCollisionsDependencyTag is the tag used for the dependencies of a
+// bootclasspath_fragment onto the other jars of the bootclasspath, whose classes must not
+// collide with the classes of the fragment.
+type bootclasspathClassCollisionsDependencyTag struct {
+	bootclasspathDependencyTag
+}
+
+// The other jars of the bootclasspath are not part of the apex of the fragment.
+func (t bootclasspathClassCollisionsDependencyTag) ExcludeFromApexContents() {
+}
+
+// The jars of the bootclasspath can be disabled in some builds, e.g. when a prebuilt is used
+// instead of a source module. Those are ignored, as is done by gatherApexModulePairDepsWithTag.
+func (t bootclasspathClassCollisionsDependencyTag) AllowDisabledModuleDependency(target android.Module) bool {
+	return true
+}
+
+var _ android.ExcludeFromApexContentsTag = bootclasspathClassCollisionsDependencyTag{}
+var _ android.AllowDisabledModuleDependency = bootclasspathClassCollisionsDependencyTag{}
+
+var bootclasspathClassCollisionsDepTag = bootclasspathClassCollisionsDependencyTag{
+	bootclasspathDependencyTag{name: "class-collisions"},
+}
+
+// shouldCheckClassCollisions returns true if the classes of the fragment are checked, i.e. if it is
+// the active source module of an APEX variant of a bootclasspath_fragment.
+func shouldCheckClassCollisions(ctx android.BaseModuleContext) bool {
+	module := ctx.Module()
+	if android.IsModulePrebuilt(module) || !isActiveModule(module) {
+		return false
+	}
+	apexInfo := ctx.Provider(android.ApexInfoProvider).(android.ApexInfo)
+	return !apexInfo.IsForPlatform()
+}
+
+// otherBootclasspathJars returns the jars of the bootclasspath of the product that are not part
+// of the contents of the fragment.
+func (b *BootclasspathFragmentModule) otherBootclasspathJars(ctx android.BaseModuleContext) android.ConfiguredJarList {
+	global := dexpreopt.GetGlobalConfig(ctx)
+	jars := global.BootJars.AppendList(&global.ApexBootJars)
+
+	var others android.ConfiguredJarList
+	for i := 0; i < jars.Len(); i++ {
+		if !android.InList(jars.Jar(i), b.properties.Contents) {
+			others = others.Append(jars.Apex(i), jars.Jar(i))
+		}
+	}
+	return others
+}
+
+// addClassCollisionsDependencies adds dependencies onto all the jars of the bootclasspath that are
+// not part of the contents of the fragment.
+//
+// Unlike addDependencyOntoApexModulePair this does not report the jars that do not exist as that
+// is the responsibility of the platform_bootclasspath.
+func (b *BootclasspathFragmentModule) addClassCollisionsDependencies(ctx android.BottomUpMutatorContext) {
+	if !shouldCheckClassCollisions(ctx) {
+		return
+	}
+
+	jars := b.otherBootclasspathJars(ctx)
+	target := ctx.Module().Target()
+	for i := 0; i < jars.Len(); i++ {
+		apex, name := jars.Apex(i), jars.Jar(i)
+
+		var variations []blueprint.Variation
+		if apex != "platform" && apex != "system_ext" {
+			variations = []blueprint.Variation{
+				{Mutator: "apex", Variation: apex},
+			}
+		}
+		variations = append(variations, target.Variations()...)
+
+		for _, dep := range []string{name, android.PrebuiltNameFromSource(name)} {
+			if ctx.OtherModuleDependencyVariantExists(variations, dep) {
+				ctx.AddFarVariationDependencies(variations, bootclasspathClassCollisionsDepTag, dep)
+			}
+		}
+	}
+}
+
+// buildRuleCheckClassCollisions creates a rule that checks that the classes of the contents of the
+// fragment do not collide with each other or with the other jars of the bootclasspath, and returns
+// the stamp file that it creates, or nil if the classes are not checked.
+//
+// The stamp file is intended to be used as a validation of the outputs of the fragment so that the
+// check is run whenever the fragment is built.
+func (b *BootclasspathFragmentModule) buildRuleCheckClassCollisions(ctx android.ModuleContext, contents []android.Module) android.Path {
+	if !shouldCheckClassCollisions(ctx) {
+		return nil
+	}
+
+	stamp := android.PathForModuleOut(ctx, "class_collisions", "check.stamp")
+
+	rule := android.NewRuleBuilder(pctx, ctx)
+	command := rule.Command().
+		BuiltTool("check_boot_class_collisions").
+		FlagWithArg("--fragment ", ctx.ModuleName())
+
+	for _, module := range contents {
+		name := android.RemoveOptionalPrebuiltPrefix(ctx.OtherModuleName(module))
+		for _, jar := range retrieveClassesJarsFromModule(module) {
+			command.FlagWithInput("--jar "+name+"=", jar)
+		}
+	}
+
+	// Identify the other jars by their apex:jar pairs in the diagnostics.
+	otherJars := b.otherBootclasspathJars(ctx)
+	for _, module := range gatherApexModulePairDepsWithTag(ctx, bootclasspathClassCollisionsDepTag) {
+		name := android.RemoveOptionalPrebuiltPrefix(ctx.OtherModuleName(module))
+		if index := otherJars.IndexOfJar(name); index != -1 {
+			name = otherJars.Apex(index) + ":" + name
+		}
+		for _, jar := range retrieveClassesJarsFromModule(module) {
+			command.FlagWithInput("--other-jar "+name+"=", jar)
+		}
+	}
+
+	if allowlist := android.OptionalPathForModuleSrc(ctx, b.properties.Class_collisions_allowlist); allowlist.Valid() {
+		command.FlagWithInput("--allowlist ", allowlist.Path())
+	}
+
+	command.FlagWithOutput("--output ", stamp)
+
+	rule.Build("check_class_collisions", "check class collisions of "+ctx.ModuleName())
+	return stamp
+}
@@ -143,6 +143,15 @@ type bootclasspathFragmentProperties struct {
 	// processing as it needs access to all the classes used by a fragment including those provided
 	// by other fragments.
 	BootclasspathFragmentsDepsProperties
+
+	// A file listing the classes of this fragment's contents that are allowed to also be in other
+	// jars of the bootclasspath, one per line, either a class name, e.g. android.foo.Bar, or a
+	// package wildcard, e.g. android.foo.*. Lines starting with # are comments.
+	//
+	// The build fails if any other class of the contents is also in another jar of the
+	// bootclasspath, i.e. a platform jar or a jar of another fragment, or in more than one jar of
+	// the contents.
+	Class_collisions_allowlist *string `android:"path"`
 }
 
 type HiddenAPIPackageProperties struct {
@@ -442,6 +451,10 @@ func (b *BootclasspathFragmentModule) DepIsInSameApex(ctx android.BaseModuleCont
 		// Cross-cutting metadata dependencies are metadata.
 		return false
 	}
+	if tag == bootclasspathClassCollisionsDepTag {
+		// The other jars of the bootclasspath are only used to check the classes of the contents.
+		return false
+	}
 	panic(fmt.Errorf("boot_image module %q should not have a dependency on %q via tag %s", b, dep, android.PrettyPrintTag(tag)))
 }
 
@@ -498,6 +511,9 @@ func (b *BootclasspathFragmentModule) DepsMutator(ctx android.BottomUpMutatorCon
 func (b *BootclasspathFragmentModule) BootclasspathDepsMutator(ctx android.BottomUpMutatorContext) {
 	// Add dependencies on all the fragments.
 	b.properties.BootclasspathFragmentsDepsProperties.addDependenciesOntoFragments(ctx)
+
+	// Add dependencies on the other jars of the bootclasspath.
+	b.addClassCollisionsDependencies(ctx)
 }
 
 func (b *BootclasspathFragmentModule) GenerateAndroidBuildActions(ctx android.ModuleContext) {
@@ -508,12 +524,6 @@ func (b *BootclasspathFragmentModule) GenerateAndroidBuildActions(ctx android.Mo
 		b.bootclasspathImageNameContentsConsistencyCheck(ctx)
 	}
 
-	// Generate classpaths.proto config
-	b.generateClasspathProtoBuildActions(ctx)
-
-	// Collect the module directory for IDE info in java/jdeps.go.
-	b.modulePaths = append(b.modulePaths, ctx.ModuleDir())
-
 	// Gather the bootclasspath fragment's contents.
 	var contents []android.Module
 	ctx.VisitDirectDeps(func(module android.Module) {
@@ -523,6 +533,19 @@ func (b *BootclasspathFragmentModule) GenerateAndroidBuildActions(ctx android.Mo
 		}
 	})
 
+	// Check the classes of the contents against the rest of the bootclasspath whenever the
+	// classpaths.proto config is built.
+	var validations android.Paths
+	if stamp := b.buildRuleCheckClassCollisions(ctx, contents); stamp != nil {
+		validations = append(validations, stamp)
+	}
+
+	// Generate classpaths.proto config
+	b.generateClasspathProtoBuildActions(ctx, validations)
+
+	// Collect the module directory for IDE info in java/jdeps.go.
+	b.modulePaths = append(b.modulePaths, ctx.ModuleDir())
+
 	fragments := gatherApexModulePairDepsWithTag(ctx, bootclasspathFragmentDepTag)
 
 	// Verify that the image_name specified on a bootclasspath_fragment is valid even if this is a
@@ -546,6 +569,11 @@ func (b *BootclasspathFragmentModule) GenerateAndroidBuildActions(ctx android.Mo
 
 			// Copy the dex jars of this fragment's content modules to their predefined locations.
 			copyBootJarsToPredefinedLocations(ctx, hiddenAPIOutput.EncodedBootDexFilesByModule, imageConfig.dexPathsByModule)
+
+			// Make the boot image files available to the dex_bootjars singleton.
+			ctx.SetProvider(BootImageInfoProvider, BootImageInfo{
+				Variants: bootImageVariantInfos(ctx, bootImageFiles.variants),
+			})
 		}
 	}
 
@@ -612,7 +640,7 @@ func (b *BootclasspathFragmentModule) provideApexContentInfo(ctx android.ModuleC
 }
 
 // generateClasspathProtoBuildActions generates all required build actions for classpath.proto config
-func (b *BootclasspathFragmentModule) generateClasspathProtoBuildActions(ctx android.ModuleContext) {
+func (b *BootclasspathFragmentModule) generateClasspathProtoBuildActions(ctx android.ModuleContext, validations android.Paths) {
 	var classpathJars []classpathJar
 	configuredJars := b.configuredJars(ctx)
 	if "art" == proptools.String(b.properties.Image_name) {
@@ -621,7 +649,7 @@ func (b *BootclasspathFragmentModule) generateClasspathProtoBuildActions(ctx and
 	} else {
 		classpathJars = configuredJarListToClasspathJars(ctx, configuredJars, b.classpathType)
 	}
-	b.classpathFragmentBase().generateClasspathProtoBuildActions(ctx, configuredJars, classpathJars)
+	b.classpathFragmentBase().generateClasspathProtoBuildActions(ctx, configuredJars, classpathJars, validations)
 }
 
 func (b *BootclasspathFragmentModule) configuredJars(ctx android.ModuleContext) android.ConfiguredJarList {
@@ -891,10 +919,11 @@ func (b *BootclasspathFragmentModule) generateBootImageBuildActions(ctx android.
 	}
 
 	// Build boot image files for the host variants.
-	buildBootImageVariantsForBuildOs(ctx, imageConfig, profile)
+	hostBootImageFiles := buildBootImageVariantsForBuildOs(ctx, imageConfig, profile)
 
 	// Build boot image files for the android variants.
 	bootImageFiles := buildBootImageVariantsForAndroidOs(ctx, imageConfig, profile)
+	bootImageFiles.variants = append(hostBootImageFiles.variants, bootImageFiles.variants...)
 
 	// Return the boot image files for the android variants for inclusion in an APEX and to be zipped
 	// up for the dist.
@@ -1214,7 +1243,7 @@ func (module *PrebuiltBootclasspathFragmentModule) produceBootImageFiles(ctx and
 	// provided by the contents of this module as prebuilt versions of the host boot image files are
 	// not available, i.e. there is no host specific prebuilt apex containing them. This has to be
 	// built without a profile as the prebuilt modules do not provide a profile.
-	buildBootImageVariantsForBuildOs(ctx, imageConfig, profile)
+	hostBootImageFiles := buildBootImageVariantsForBuildOs(ctx, imageConfig, profile)
 
 	if profile == nil && imageConfig.isProfileGuided() {
 		ctx.ModuleErrorf("Unable to produce boot image files: profiles not found in the prebuilt apex")
@@ -1222,7 +1251,9 @@ func (module *PrebuiltBootclasspathFragmentModule) produceBootImageFiles(ctx and
 	}
 	// Build boot image files for the android variants from the dex files provided by the contents
 	// of this module.
-	return buildBootImageVariantsForAndroidOs(ctx, imageConfig, profile)
+	bootImageFiles := buildBootImageVariantsForAndroidOs(ctx, imageConfig, profile)
+	bootImageFiles.variants = append(hostBootImageFiles.variants, bootImageFiles.variants...)
+	return bootImageFiles
 }
 
 func (b *PrebuiltBootclasspathFragmentModule) getImageName() *string {
@@ -139,6 +139,29 @@ var (
 		},
 		"abis", "allow-prereleased", "screen-densities", "sdk-version", "skip-sdk-check", "stem", "apkcerts", "partition", "zip")
 
+	// Compiles the sources with errorprone, which must report the fatal checks as warnings, and
+	// writes the fatal checks that have warnings to an errorprone baseline file.
+	errorproneBaseline = pctx.AndroidStaticRule("errorproneBaseline",
+		blueprint.RuleParams{
+			Command: `rm -rf "$outDir" "$annoDir" "$srcJarDir" "$out" && mkdir -p "$outDir" "$annoDir" "$srcJarDir" && ` +
+				`${config.ZipSyncCmd} -d $srcJarDir -l $srcJarDir/list -f "*.java" $srcJars && ` +
+				`(${config.JavacCmd} ${config.JavacHeapFlags} ${config.JavacVmFlags} ${config.CommonJdkFlags} ` +
+				`$processorpath $processor $javacFlags $bootClasspath $classpath ` +
+				`-source $javaVersion -target $javaVersion -Xmaxwarns 1000000 ` +
+				`-d $outDir -s $annoDir @$out.rsp @$srcJarDir/list 2> $out.log || (cat $out.log; exit 1)) && ` +
+				`${config.ErrorproneBaselineCmd} --checks $checks --log $out.log --output $out && ` +
+				`rm -rf "$outDir" "$annoDir" "$srcJarDir"`,
+			CommandDeps: []string{
+				"${config.JavacCmd}",
+				"${config.ZipSyncCmd}",
+				"${config.ErrorproneBaselineCmd}",
+			},
+			Rspfile:        "$out.rsp",
+			RspfileContent: "$in",
+		},
+		"javacFlags", "bootClasspath", "classpath", "processorpath", "processor", "srcJars", "srcJarDir",
+		"outDir", "annoDir", "javaVersion", "checks")
+
 	turbine, turbineRE = pctx.RemoteStaticRules("turbine",
 		blueprint.RuleParams{
 			Command: `$reTemplate${config.JavaCmd} ${config.JavaVmFlags} -jar ${config.TurbineJar} $outputFlags ` +
@@ -166,7 +189,7 @@ var (
 
 	jar, jarRE = pctx.RemoteStaticRules("jar",
 		blueprint.RuleParams{
-			Command:        `$reTemplate${config.SoongZipCmd} -jar -o $out @$out.rsp`,
+			Command:        `$reTemplate${config.SoongZipCmd} ${android.SourceDateEpochFlag} -jar -o $out @$out.rsp`,
 			CommandDeps:    []string{"${config.SoongZipCmd}"},
 			Rspfile:        "$out.rsp",
 			RspfileContent: "$jarArgs",
@@ -181,7 +204,7 @@ var (
 
 	zip, zipRE = pctx.RemoteStaticRules("zip",
 		blueprint.RuleParams{
-			Command:        `${config.SoongZipCmd} -o $out @$out.rsp`,
+			Command:        `${config.SoongZipCmd} ${android.SourceDateEpochFlag} -o $out @$out.rsp`,
 			CommandDeps:    []string{"${config.SoongZipCmd}"},
 			Rspfile:        "$out.rsp",
 			RspfileContent: "$jarArgs",
@@ -196,7 +219,7 @@ var (
 
 	combineJar = pctx.AndroidStaticRule("combineJar",
 		blueprint.RuleParams{
-			Command:     `${config.MergeZipsCmd} --ignore-duplicates -j $jarArgs $out $in`,
+			Command:     `${config.MergeZipsCmd} ${android.SourceDateEpochFlag} --ignore-duplicates -j $jarArgs $out $in`,
 			CommandDeps: []string{"${config.MergeZipsCmd}"},
 		},
 		"jarArgs")
@@ -471,6 +494,19 @@ func TurbineApt(ctx android.ModuleContext, outputSrcJar, outputResJar android.Wr
 	})
 }
 
+// TurbineIsolatedApt produces a rule to run a single isolating annotation processor using turbine.
+//
+// Unlike TurbineApt the action only depends on the sources and classpath of the module and on the
+// jars of the annotation processor itself, so it is not rerun when the other annotation processors
+// of the module change, and can be cached independently.
+func TurbineIsolatedApt(ctx android.ModuleContext, outputSrcJar, outputResJar android.WritablePath,
+	srcFiles, srcJars android.Paths, flags javaBuilderFlags, processorPath classpath, processor string) {
+
+	flags.processorPath = processorPath
+	flags.processors = []string{processor}
+	TurbineApt(ctx, outputSrcJar, outputResJar, srcFiles, srcJars, flags)
+}
+
 // transformJavaToClasses takes source files and converts them to a jar containing .class files.
 // srcFiles is a list of paths to sources, srcJars is a list of paths to jar files that contain
 // sources.  flags contains various command line flags to be passed to the compiler.
@@ -548,6 +584,62 @@ func transformJavaToClasses(ctx android.ModuleContext, outputFile android.Writab
 	})
 }
 
+// transformJavaToErrorproneBaseline generates the errorprone baseline of the fatal checks from the
+// warnings of compiling the sources with the errorprone flags.
+func transformJavaToErrorproneBaseline(ctx android.ModuleContext, outputFile android.WritablePath,
+	srcFiles, srcJars android.Paths, flags javaBuilderFlags, checks []string) {
+
+	deps := append(android.Paths(nil), srcJars...)
+	classpath := flags.classpath
+
+	var bootClasspath string
+	if flags.javaVersion.usesJavaModules() {
+		var systemModuleDeps android.Paths
+		bootClasspath, systemModuleDeps = flags.systemModules.FormJavaSystemModulesPath(ctx.Device())
+		deps = append(deps, systemModuleDeps...)
+		classpath = append(flags.java9Classpath, classpath...)
+	} else {
+		deps = append(deps, flags.bootClasspath...)
+		if len(flags.bootClasspath) == 0 && ctx.Device() {
+			// explicitly specify -bootclasspath "" if the bootclasspath is empty to
+			// ensure java does not fall back to the default bootclasspath.
+			bootClasspath = `-bootclasspath ""`
+		} else {
+			bootClasspath = flags.bootClasspath.FormJavaClassPath("-bootclasspath")
+		}
+	}
+
+	deps = append(deps, classpath...)
+	deps = append(deps, flags.processorPath...)
+
+	processor := "-proc:none"
+	if len(flags.processors) > 0 {
+		processor = "-processor " + strings.Join(flags.processors, ",")
+	}
+
+	intermediatesDir := "errorprone_baseline"
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        errorproneBaseline,
+		Description: "errorprone baseline",
+		Output:      outputFile,
+		Inputs:      srcFiles,
+		Implicits:   deps,
+		Args: map[string]string{
+			"javacFlags":    flags.javacFlags,
+			"bootClasspath": bootClasspath,
+			"classpath":     classpath.FormJavaClassPath("-classpath"),
+			"processorpath": flags.processorPath.FormJavaClassPath("-processorpath"),
+			"processor":     processor,
+			"srcJars":       strings.Join(srcJars.Strings(), " "),
+			"srcJarDir":     android.PathForModuleOut(ctx, intermediatesDir, "srcjars").String(),
+			"outDir":        android.PathForModuleOut(ctx, intermediatesDir, "classes").String(),
+			"annoDir":       android.PathForModuleOut(ctx, intermediatesDir, "anno").String(),
+			"javaVersion":   flags.javaVersion.String(),
+			"checks":        strings.Join(checks, ","),
+		},
+	})
+}
+
 func TransformResourcesToJar(ctx android.ModuleContext, outputFile android.WritablePath,
 	jarArgs []string, deps android.Paths) {
 
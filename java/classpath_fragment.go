@@ -151,7 +151,9 @@ func configuredJarListToClasspathJars(ctx android.ModuleContext, configuredJars
 	return jars
 }
 
-func (c *ClasspathFragmentBase) generateClasspathProtoBuildActions(ctx android.ModuleContext, configuredJars android.ConfiguredJarList, jars []classpathJar) {
+// generateClasspathProtoBuildActions generates the classpaths.proto config of the jars. The
+// validations are checked whenever the config is built.
+func (c *ClasspathFragmentBase) generateClasspathProtoBuildActions(ctx android.ModuleContext, configuredJars android.ConfiguredJarList, jars []classpathJar, validations android.Paths) {
 	generateProto := proptools.BoolDefault(c.properties.Generate_classpaths_proto, true)
 	if generateProto {
 		outputFilename := strings.ToLower(c.classpathType.String()) + ".pb"
@@ -167,7 +169,8 @@ func (c *ClasspathFragmentBase) generateClasspathProtoBuildActions(ctx android.M
 			Flag("encode").
 			Flag("--format=textproto").
 			FlagWithInput("--input=", generatedTextproto).
-			FlagWithOutput("--output=", c.outputFilepath)
+			FlagWithOutput("--output=", c.outputFilepath).
+			Validations(validations)
 
 		rule.Build("classpath_fragment", "Compiling "+c.outputFilepath.String())
 	}
@@ -37,8 +37,20 @@ var (
 	DefaultLambdaStubsLibrary                = "core-lambda-stubs"
 	SdkLambdaStubsPath                       = "prebuilts/sdk/tools/core-lambda-stubs.jar"
 
+	// The defaults of the core library desugaring of the modules with desugaring.core_library.
+	DefaultCoreLibraryDesugaringJar    = "desugar_jdk_libs"
+	DefaultCoreLibraryDesugaringConfig = "prebuilts/r8/desugar_jdk_libs.json"
+	// The highest min_sdk_version that requires core library desugaring, the core libraries of the
+	// later devices provide all the desugared APIs.
+	CoreLibraryDesugaringMaxMinSdkVersion = 33
+
 	DefaultMakeJacocoExcludeFilter = []string{"org.junit.*", "org.jacoco.*", "org.mockito.*"}
 	DefaultJacocoExcludeFilter     = []string{"org.junit.**", "org.jacoco.**", "org.mockito.**"}
+	// The zip2zip specs of the classes generated by the build that are excluded from instrumentation
+	// with jacoco unless jacoco.exclude_generated_code is false.
+	DefaultJacocoGeneratedCodeExcludeSpecs = []string{
+		"**/R.class", "**/R$*.class", "**/Manifest.class", "**/Manifest$*.class", "**/BuildConfig.class",
+	}
 
 	InstrumentFrameworkModules = []string{
 		"framework",
@@ -136,6 +148,10 @@ func init() {
 		return "17"
 	})
 
+	android.RegisterBuildProvenanceToolchain("jdk", func(ctx android.PathContext) string {
+		return javaHome(ctx).String()
+	})
+
 	pctx.SourcePathVariable("JavaToolchain", "${JavaHome}/bin")
 	pctx.SourcePathVariableWithEnvOverride("JavacCmd",
 		"${JavaToolchain}/javac", "ALTERNATE_JAVAC")
@@ -163,6 +179,8 @@ func init() {
 	pctx.HostBinToolVariable("ResourceShrinkerCmd", "resourceshrinker")
 	pctx.HostBinToolVariable("HiddenAPICmd", "hiddenapi")
 	pctx.HostBinToolVariable("ExtractApksCmd", "extract_apks")
+	pctx.HostBinToolVariable("ErrorproneBaselineCmd", "errorprone_baseline")
+	pctx.HostBinToolVariable("JacocoExcludedClassesCmd", "jacoco_excluded_classes")
 	pctx.VariableFunc("TurbineJar", func(ctx android.PackageVarContext) string {
 		turbine := "turbine.jar"
 		if ctx.Config().AlwaysUsePrebuiltSdks() {
@@ -180,6 +198,7 @@ func init() {
 	pctx.HostJavaToolVariable("JetifierJar", "jetifier.jar")
 	pctx.HostJavaToolVariable("R8Jar", "r8.jar")
 	pctx.HostJavaToolVariable("D8Jar", "d8.jar")
+	pctx.HostJavaToolVariable("BundletoolJar", "bundletool.jar")
 
 	pctx.HostBinToolVariable("SoongJavacWrapper", "soong_javac_wrapper")
 	pctx.HostBinToolVariable("DexpreoptGen", "dexpreopt_gen")
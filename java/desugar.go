@@ -0,0 +1,190 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/java/config"
+)
+
+// This file implements the core library desugaring of the modules with desugaring.core_library.
+// d8 or r8 rewrite the uses of the java.* APIs that are missing from the devices of
+// min_sdk_version to use the desugared core libraries, and write the keep rules of the classes of
+// the desugared core libraries that the module uses. l8 then dexes and shrinks the desugared core
+// libraries with these keep rules, and its dex files are added to the dex jar of the module after
+// the dex files of the module itself.
+
+var coreLibraryDesugaringTag = dependencyTag{name: "core-library-desugaring"}
+
+var l8 = pctx.AndroidStaticRule("l8",
+	blueprint.RuleParams{
+		Command: `rm -rf "$outDir" && mkdir -p "$outDir/l8" "$outDir/dex" && ` +
+			`${config.JavaCmd} ${config.JavaVmFlags} -cp ${config.R8Jar} com.android.tools.r8.L8 ` +
+			`--output $outDir/l8 $l8Flags $desugaredLibJar && ` +
+			// Number the dex files of the desugared core libraries after the ones of the module.
+			`n=$$(unzip -Z1 $in 'classes*.dex' | wc -l) && ` +
+			`m=$$(ls $outDir/l8/classes*.dex | wc -l) && ` +
+			`for i in $$(seq 1 $$m); do ` +
+			`if [ $$i -eq 1 ]; then src=classes.dex; else src=classes$$i.dex; fi && ` +
+			`mv $outDir/l8/$$src $outDir/dex/classes$$((n + i)).dex; done && ` +
+			`${config.SoongZipCmd} $zipFlags -o $outDir/classes.dex.jar -C $outDir/dex -f "$outDir/dex/classes*.dex" && ` +
+			`${config.MergeZipsCmd} $out $in $outDir/classes.dex.jar`,
+		CommandDeps: []string{
+			"${config.JavaCmd}",
+			"${config.R8Jar}",
+			"${config.SoongZipCmd}",
+			"${config.MergeZipsCmd}",
+		},
+	}, "outDir", "l8Flags", "desugaredLibJar", "zipFlags")
+
+// coreLibraryDesugaringDeps adds the dependency on the desugared core libraries of a module with
+// desugaring.core_library.
+func coreLibraryDesugaringDeps(ctx android.BottomUpMutatorContext, d dexer) {
+	if !proptools.Bool(d.dexProperties.Desugaring.Core_library) {
+		return
+	}
+	jar := proptools.StringDefault(d.dexProperties.Desugaring.Core_library_jar, config.DefaultCoreLibraryDesugaringJar)
+	ctx.AddVariationDependencies(nil, coreLibraryDesugaringTag, jar)
+}
+
+// coreLibraryDesugaring is the core library desugaring of a module.
+type coreLibraryDesugaring struct {
+	// The desugared library configuration.
+	config android.Path
+
+	// The implementation jar of the desugared core libraries.
+	jar android.Path
+
+	// The keep rules of the desugared core libraries that are written by d8 or r8.
+	keepRules android.WritablePath
+}
+
+// coreLibraryDesugaring returns the core library desugaring of the module, or nil if it doesn't
+// enable desugaring.core_library or if its configuration is invalid.
+func (d *dexer) coreLibraryDesugaring(ctx android.ModuleContext, dexParams *compileDexParams) *coreLibraryDesugaring {
+	props := d.dexProperties.Desugaring
+	if !proptools.Bool(props.Core_library) {
+		if props.Core_library_jar != nil {
+			ctx.PropertyErrorf("desugaring.core_library_jar", "requires desugaring.core_library: true")
+		}
+		if props.Core_library_config != nil {
+			ctx.PropertyErrorf("desugaring.core_library_config", "requires desugaring.core_library: true")
+		}
+		if len(props.Keep_rules) > 0 {
+			ctx.PropertyErrorf("desugaring.keep_rules", "requires desugaring.core_library: true")
+		}
+		return nil
+	}
+
+	// Supplying the platform build flag to d8 and r8 disables desugaring.
+	if !dexParams.sdkVersion.Stable() {
+		ctx.PropertyErrorf("desugaring.core_library",
+			"requires a stable sdk_version, d8 and r8 don't desugar the modules built against the platform APIs")
+		return nil
+	}
+
+	effectiveVersion, err := dexParams.minSdkVersion.EffectiveVersion(ctx)
+	if err != nil {
+		// Reported by dexCommonFlags.
+		return nil
+	}
+	if effectiveVersion.FinalOrFutureInt() > config.CoreLibraryDesugaringMaxMinSdkVersion {
+		ctx.PropertyErrorf("desugaring.core_library",
+			"min_sdk_version %s does not require core library desugaring, the devices from API level %d "+
+				"provide all the desugared APIs", effectiveVersion, config.CoreLibraryDesugaringMaxMinSdkVersion+1)
+		return nil
+	}
+
+	desugaring := &coreLibraryDesugaring{
+		keepRules: android.PathForModuleOut(ctx, "dex", "desugared_lib_keep_rules.txt"),
+	}
+	if props.Core_library_config != nil {
+		desugaring.config = android.PathForModuleSrc(ctx, *props.Core_library_config)
+	} else {
+		desugaring.config = android.PathForSource(ctx, config.DefaultCoreLibraryDesugaringConfig)
+	}
+
+	ctx.VisitDirectDepsWithTag(coreLibraryDesugaringTag, func(m android.Module) {
+		if !ctx.OtherModuleHasProvider(m, JavaInfoProvider) {
+			ctx.PropertyErrorf("desugaring.core_library_jar", "%q is not a java library", ctx.OtherModuleName(m))
+			return
+		}
+		dep := ctx.OtherModuleProvider(m, JavaInfoProvider).(JavaInfo)
+		if len(dep.ImplementationJars) != 1 {
+			ctx.PropertyErrorf("desugaring.core_library_jar", "%q must have a single implementation jar, got %q",
+				ctx.OtherModuleName(m), dep.ImplementationJars.Strings())
+			return
+		}
+		desugaring.jar = dep.ImplementationJars[0]
+	})
+	if desugaring.jar == nil {
+		// The dependency is missing and allowed to be, or its error is reported above.
+		return nil
+	}
+	return desugaring
+}
+
+// dexFlags returns the flags of d8 and r8 that desugar the uses of the core libraries and write the
+// keep rules of the desugared core libraries.
+func (c *coreLibraryDesugaring) dexFlags() (flags []string, deps android.Paths) {
+	flags = append(flags,
+		"--desugared-lib "+c.config.String(),
+		"--desugared-lib-pg-conf-output "+c.keepRules.String())
+	deps = append(deps, c.config)
+	return flags, deps
+}
+
+// buildDexJar adds the dex files of the desugared core libraries, shrunk to the classes used by the
+// module and by the keep rules of desugaring.keep_rules, to the dex jar of the module.
+func (c *coreLibraryDesugaring) buildDexJar(ctx android.ModuleContext, d *dexer, dexParams *compileDexParams,
+	dexJar android.Path, zipFlags string) android.OutputPath {
+
+	effectiveVersion, _ := dexParams.minSdkVersion.EffectiveVersion(ctx)
+	keepRules := append(android.Paths{c.keepRules},
+		android.PathsForModuleSrc(ctx, d.dexProperties.Desugaring.Keep_rules)...)
+
+	l8Flags := []string{
+		"--min-api " + strconv.Itoa(effectiveVersion.FinalOrFutureInt()),
+		"--desugared-lib " + c.config.String(),
+	}
+	l8Flags = append(l8Flags, dexParams.flags.bootClasspath.FormRepeatedClassPath("--lib ")...)
+	l8Flags = append(l8Flags, android.JoinWithPrefix(keepRules.Strings(), "--pg-conf "))
+
+	implicits := android.Paths{c.config, c.jar}
+	implicits = append(implicits, dexParams.flags.bootClasspath...)
+	implicits = append(implicits, keepRules...)
+
+	output := android.PathForModuleOut(ctx, "desugared_lib", dexParams.jarName).OutputPath
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        l8,
+		Description: "l8",
+		Output:      output,
+		Input:       dexJar,
+		Implicits:   implicits,
+		Args: map[string]string{
+			"outDir":          android.PathForModuleOut(ctx, "desugared_lib", "tmp").String(),
+			"l8Flags":         strings.Join(l8Flags, " "),
+			"desugaredLibJar": c.jar.String(),
+			"zipFlags":        zipFlags,
+		},
+	})
+	return output
+}
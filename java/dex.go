@@ -81,6 +81,27 @@ type DexProperties struct {
 
 	// Exclude kotlinc generate files: *.kotlin_module, *.kotlin_builtins. Defaults to false.
 	Exclude_kotlinc_generated_files *bool
+
+	Desugaring struct {
+		// If true, the uses of the java.* APIs that are missing from the devices of min_sdk_version
+		// are rewritten to use the desugared core libraries, which are dexed into extra dex files of
+		// the module. Requires a min_sdk_version that is missing some of the desugared APIs.
+		// Defaults to false.
+		Core_library *bool
+
+		// Name of the java_library module that provides the implementation of the desugared core
+		// libraries. Defaults to "desugar_jdk_libs".
+		Core_library_jar *string
+
+		// The desugared library configuration that is passed to d8, r8 and l8. Defaults to
+		// prebuilts/r8/desugar_jdk_libs.json.
+		Core_library_config *string `android:"path"`
+
+		// Specifies the locations of files containing proguard flags that keep the classes of the
+		// desugared core libraries that are only used by reflection. The classes used by the module
+		// are kept automatically.
+		Keep_rules []string `android:"path"`
+	}
 }
 
 type dexer struct {
@@ -117,6 +138,7 @@ var d8, d8RE = pctx.MultiCommandRemoteStaticRules("d8",
 		"$d8Template": &remoteexec.REParams{
 			Labels:          map[string]string{"type": "compile", "compiler": "d8"},
 			Inputs:          []string{"${config.D8Jar}"},
+			OutputFiles:     []string{"$outCommaList"},
 			ExecStrategy:    "${config.RED8ExecStrategy}",
 			ToolchainInputs: []string{"${config.JavaCmd}"},
 			Platform:        map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
@@ -128,7 +150,7 @@ var d8, d8RE = pctx.MultiCommandRemoteStaticRules("d8",
 			ExecStrategy: "${config.RED8ExecStrategy}",
 			Platform:     map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
 		},
-	}, []string{"outDir", "d8Flags", "zipFlags", "tmpJar", "mergeZipsFlags"}, nil)
+	}, []string{"outDir", "d8Flags", "zipFlags", "tmpJar", "mergeZipsFlags"}, []string{"outCommaList"})
 
 var r8, r8RE = pctx.MultiCommandRemoteStaticRules("r8",
 	blueprint.RuleParams{
@@ -161,7 +183,7 @@ var r8, r8RE = pctx.MultiCommandRemoteStaticRules("r8",
 		"$r8Template": &remoteexec.REParams{
 			Labels:          map[string]string{"type": "compile", "compiler": "r8"},
 			Inputs:          []string{"$implicits", "${config.R8Jar}"},
-			OutputFiles:     []string{"${outUsage}"},
+			OutputFiles:     []string{"$outCommaList"},
 			ExecStrategy:    "${config.RER8ExecStrategy}",
 			ToolchainInputs: []string{"${config.JavaCmd}"},
 			Platform:        map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
@@ -181,7 +203,7 @@ var r8, r8RE = pctx.MultiCommandRemoteStaticRules("r8",
 			Platform:     map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
 		},
 	}, []string{"outDir", "outDict", "outConfig", "outUsage", "outUsageZip", "outUsageDir",
-		"r8Flags", "zipFlags", "tmpJar", "mergeZipsFlags"}, []string{"implicits"})
+		"r8Flags", "zipFlags", "tmpJar", "mergeZipsFlags"}, []string{"implicits", "outCommaList"})
 
 func (d *dexer) dexCommonFlags(ctx android.ModuleContext,
 	dexParams *compileDexParams) (flags []string, deps android.Paths) {
@@ -362,6 +384,15 @@ func (d *dexer) compileDex(ctx android.ModuleContext, dexParams *compileDexParam
 
 	commonFlags, commonDeps := d.dexCommonFlags(ctx, dexParams)
 
+	var implicitOutputs android.WritablePaths
+	desugaring := d.coreLibraryDesugaring(ctx, dexParams)
+	if desugaring != nil {
+		desugaringFlags, desugaringDeps := desugaring.dexFlags()
+		commonFlags = append(commonFlags, desugaringFlags...)
+		commonDeps = append(commonDeps, desugaringDeps...)
+		implicitOutputs = append(implicitOutputs, desugaring.keepRules)
+	}
+
 	// Exclude kotlinc generated files when "exclude_kotlinc_generated_files" is set to true.
 	mergeZipsFlags := ""
 	if proptools.BoolDefault(d.dexProperties.Exclude_kotlinc_generated_files, false) {
@@ -397,12 +428,14 @@ func (d *dexer) compileDex(ctx android.ModuleContext, dexParams *compileDexParam
 		if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_R8") {
 			rule = r8RE
 			args["implicits"] = strings.Join(r8Deps.Strings(), ",")
+			// The outputs of r8 other than the dex files, which are zipped by another command.
+			args["outCommaList"] = strings.Join(append([]string{proguardUsage.String()}, implicitOutputs.Strings()...), ",")
 		}
 		ctx.Build(pctx, android.BuildParams{
 			Rule:            rule,
 			Description:     "r8",
 			Output:          javalibJar,
-			ImplicitOutputs: android.WritablePaths{proguardDictionary, proguardUsageZip},
+			ImplicitOutputs: append(android.WritablePaths{proguardDictionary, proguardUsageZip}, implicitOutputs...),
 			Input:           dexParams.classesJar,
 			Implicits:       r8Deps,
 			Args:            args,
@@ -411,24 +444,31 @@ func (d *dexer) compileDex(ctx android.ModuleContext, dexParams *compileDexParam
 		d8Flags, d8Deps := d8Flags(dexParams.flags)
 		d8Deps = append(d8Deps, commonDeps...)
 		rule := d8
+		args := map[string]string{
+			"d8Flags":        strings.Join(append(commonFlags, d8Flags...), " "),
+			"zipFlags":       zipFlags,
+			"outDir":         outDir.String(),
+			"tmpJar":         tmpJar.String(),
+			"mergeZipsFlags": mergeZipsFlags,
+		}
 		if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_D8") {
 			rule = d8RE
+			// The outputs of d8 other than the dex files, which are zipped by another command.
+			args["outCommaList"] = strings.Join(implicitOutputs.Strings(), ",")
 		}
 		ctx.Build(pctx, android.BuildParams{
-			Rule:        rule,
-			Description: "d8",
-			Output:      javalibJar,
-			Input:       dexParams.classesJar,
-			Implicits:   d8Deps,
-			Args: map[string]string{
-				"d8Flags":        strings.Join(append(commonFlags, d8Flags...), " "),
-				"zipFlags":       zipFlags,
-				"outDir":         outDir.String(),
-				"tmpJar":         tmpJar.String(),
-				"mergeZipsFlags": mergeZipsFlags,
-			},
+			Rule:            rule,
+			Description:     "d8",
+			Output:          javalibJar,
+			ImplicitOutputs: implicitOutputs,
+			Input:           dexParams.classesJar,
+			Implicits:       d8Deps,
+			Args:            args,
 		})
 	}
+	if desugaring != nil {
+		javalibJar = desugaring.buildDexJar(ctx, d, dexParams, javalibJar, zipFlags)
+	}
 	if proptools.Bool(d.dexProperties.Uncompress_dex) {
 		alignedJavalibJar := android.PathForModuleOut(ctx, "aligned", dexParams.jarName).OutputPath
 		TransformZipAlign(ctx, alignedJavalibJar, javalibJar)
@@ -15,6 +15,7 @@
 package java
 
 import (
+	"strings"
 	"testing"
 
 	"android/soong/android"
@@ -327,6 +328,168 @@ func TestD8(t *testing.T) {
 		fooD8.Args["d8Flags"], staticLibHeader.String())
 }
 
+func TestCoreLibraryDesugaring(t *testing.T) {
+	result := PrepareForTestWithJavaDefaultModules.RunTestWithBp(t, `
+		android_app {
+			name: "app",
+			srcs: ["foo.java"],
+			sdk_version: "current",
+			min_sdk_version: "24",
+			desugaring: {
+				core_library: true,
+				keep_rules: ["reflection.flags"],
+			},
+		}
+
+		java_library {
+			name: "foo",
+			srcs: ["foo.java"],
+			sdk_version: "current",
+			min_sdk_version: "24",
+			installable: true,
+			desugaring: {
+				core_library: true,
+				core_library_jar: "my_desugar_jdk_libs",
+				core_library_config: "my_desugar_jdk_libs.json",
+			},
+		}
+
+		java_library {
+			name: "desugar_jdk_libs",
+			srcs: ["foo.java"],
+			sdk_version: "current",
+		}
+
+		java_library {
+			name: "my_desugar_jdk_libs",
+			srcs: ["foo.java"],
+			sdk_version: "current",
+		}
+	`)
+
+	app := result.ModuleForTests("app", "android_common")
+	appR8 := app.Rule("r8")
+	android.AssertStringDoesContain(t, "app r8 flags", appR8.Args["r8Flags"],
+		"--desugared-lib prebuilts/r8/desugar_jdk_libs.json --desugared-lib-pg-conf-output "+
+			"out/soong/.intermediates/app/android_common/dex/desugared_lib_keep_rules.txt")
+
+	appL8 := app.Rule("l8")
+	android.AssertPathRelativeToTopEquals(t, "app l8 input",
+		"out/soong/.intermediates/app/android_common/dex/app.jar", appL8.Input)
+	android.AssertPathRelativeToTopEquals(t, "app l8 output",
+		"out/soong/.intermediates/app/android_common/desugared_lib/app.jar", appL8.Output)
+	android.AssertStringDoesContain(t, "app l8 desugared lib jar", appL8.Args["desugaredLibJar"],
+		"out/soong/.intermediates/desugar_jdk_libs/android_common/")
+	android.AssertStringDoesContain(t, "app l8 flags", appL8.Args["l8Flags"],
+		"--min-api 24 --desugared-lib prebuilts/r8/desugar_jdk_libs.json")
+	android.AssertStringDoesContain(t, "app l8 flags", appL8.Args["l8Flags"],
+		"--pg-conf out/soong/.intermediates/app/android_common/dex/desugared_lib_keep_rules.txt "+
+			"--pg-conf reflection.flags")
+
+	foo := result.ModuleForTests("foo", "android_common")
+	fooD8 := foo.Rule("d8")
+	android.AssertStringDoesContain(t, "foo d8 flags", fooD8.Args["d8Flags"],
+		"--desugared-lib my_desugar_jdk_libs.json")
+	fooL8 := foo.Rule("l8")
+	android.AssertStringDoesContain(t, "foo l8 desugared lib jar", fooL8.Args["desugaredLibJar"],
+		"out/soong/.intermediates/my_desugar_jdk_libs/android_common/")
+}
+
+func TestCoreLibraryDesugaringRBE(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.UseRBE = proptools.BoolPtr(true)
+		}),
+		android.FixtureMergeEnv(map[string]string{
+			"RBE_D8": "true",
+			"RBE_R8": "true",
+		}),
+	).RunTestWithBp(t, `
+		android_app {
+			name: "app",
+			srcs: ["foo.java"],
+			sdk_version: "current",
+			min_sdk_version: "24",
+			desugaring: {
+				core_library: true,
+			},
+		}
+
+		java_library {
+			name: "foo",
+			srcs: ["foo.java"],
+			sdk_version: "current",
+			min_sdk_version: "24",
+			installable: true,
+			desugaring: {
+				core_library: true,
+			},
+		}
+
+		java_library {
+			name: "desugar_jdk_libs",
+			srcs: ["foo.java"],
+			sdk_version: "current",
+		}
+	`)
+
+	// The keep rules are downloaded for the l8 rule.
+	appR8 := result.ModuleForTests("app", "android_common").Rule("r8RE")
+	android.AssertStringDoesContain(t, "app r8 command", appR8.RuleParams.Command, "--output_files=$outCommaList")
+	android.AssertStringListContains(t, "app r8 output files", strings.Split(appR8.Args["outCommaList"], ","),
+		"out/soong/.intermediates/app/android_common/dex/desugared_lib_keep_rules.txt")
+
+	fooD8 := result.ModuleForTests("foo", "android_common").Rule("d8RE")
+	android.AssertStringDoesContain(t, "foo d8 command", fooD8.RuleParams.Command, "--output_files=$outCommaList")
+	android.AssertStringEquals(t, "foo d8 output files",
+		"out/soong/.intermediates/foo/android_common/dex/desugared_lib_keep_rules.txt", fooD8.Args["outCommaList"])
+}
+
+func TestCoreLibraryDesugaringErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		properties    string
+		expectedError string
+	}{
+		{
+			name:          "min_sdk_version",
+			properties:    `sdk_version: "current", min_sdk_version: "34", desugaring: { core_library: true }`,
+			expectedError: `min_sdk_version 34 does not require core library desugaring`,
+		},
+		{
+			name:          "platform_apis",
+			properties:    `platform_apis: true, min_sdk_version: "24", desugaring: { core_library: true }`,
+			expectedError: `requires a stable sdk_version`,
+		},
+		{
+			name:          "keep_rules",
+			properties:    `sdk_version: "current", desugaring: { keep_rules: ["reflection.flags"] }`,
+			expectedError: `desugaring.keep_rules: requires desugaring.core_library: true`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			PrepareForTestWithJavaDefaultModules.
+				ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(tc.expectedError)).
+				RunTestWithBp(t, `
+					android_app {
+						name: "app",
+						srcs: ["foo.java"],
+						`+tc.properties+`
+					}
+
+					java_library {
+						name: "desugar_jdk_libs",
+						srcs: ["foo.java"],
+						sdk_version: "current",
+					}
+				`)
+		})
+	}
+}
+
 func TestProguardFlagsInheritance(t *testing.T) {
 	result := PrepareForTestWithJavaDefaultModules.RunTestWithBp(t, `
 		android_app {
@@ -20,8 +20,19 @@ import (
 
 	"android/soong/android"
 	"android/soong/dexpreopt"
+
+	"github.com/google/blueprint"
 )
 
+// DexpreoptInfo contains information about the dexpreopt outputs of a module, i.e. the .odex, .vdex
+// and .art files and the profile, that are installed on the device.
+type DexpreoptInfo struct {
+	// The dexpreopt outputs of the module and their install paths on the device.
+	Installs android.RuleBuilderInstalls
+}
+
+var DexpreoptInfoProvider = blueprint.NewProvider(DexpreoptInfo{})
+
 type DexpreopterInterface interface {
 	// True if the java module is to be dexed and installed on devices.
 	// Structs that embed dexpreopter must implement this.
@@ -126,6 +137,11 @@ type dexpreopter struct {
 	// The path to the profile that dexpreopter accepts. It must be in the binary format. If this is
 	// set, it overrides the profile settings in `dexpreoptProperties`.
 	inputProfilePathOnHost android.Path
+
+	// The path, relative to the root of the source tree, to the text listing of the profile of the
+	// systemserverclasspath_fragment that contains the module, or empty if it has none. It is used
+	// if `dexpreoptProperties` does not specify a profile.
+	classpathFragmentProfile string
 }
 
 type DexpreoptProperties struct {
@@ -337,6 +353,10 @@ func (d *dexpreopter) dexpreopt(ctx android.ModuleContext, dexJarFile android.Wr
 			profileBootListing = android.ExistentPathForSource(ctx,
 				ctx.ModuleDir(), String(d.dexpreoptProperties.Dex_preopt.Profile)+"-boot")
 			profileIsTextListing = true
+		} else if d.classpathFragmentProfile != "" {
+			profileClassListing = android.OptionalPathForPath(
+				android.PathForSource(ctx, d.classpathFragmentProfile))
+			profileIsTextListing = true
 		} else if global.ProfileDir != "" {
 			profileClassListing = android.ExistentPathForSource(ctx,
 				global.ProfileDir, moduleName(ctx)+".prof")
@@ -400,6 +420,9 @@ func (d *dexpreopter) dexpreopt(ctx android.ModuleContext, dexJarFile android.Wr
 
 	isApexSystemServerJar := global.AllApexSystemServerJars(ctx).ContainsJar(moduleName(ctx))
 
+	// The dexpreopt outputs that are installed on the device, either by Soong or by Make.
+	var installed android.RuleBuilderInstalls
+
 	for _, install := range dexpreoptRule.Installs() {
 		// Remove the "/" prefix because the path should be relative to $ANDROID_PRODUCT_OUT.
 		installDir := strings.TrimPrefix(filepath.Dir(install.To), "/")
@@ -428,15 +451,21 @@ func (d *dexpreopter) dexpreopt(ctx android.ModuleContext, dexJarFile android.Wr
 					installDirOnDevice:  installPath,
 					installFileOnDevice: installBase,
 				})
+				installed = append(installed, install)
 			}
 		} else if !d.preventInstall {
 			ctx.InstallFile(installPath, installBase, install.From)
+			installed = append(installed, install)
 		}
 	}
 
 	if !isApexSystemServerJar {
 		d.builtInstalled = dexpreoptRule.Installs().String()
 	}
+
+	ctx.SetProvider(DexpreoptInfoProvider, DexpreoptInfo{
+		Installs: installed,
+	})
 }
 
 func (d *dexpreopter) DexpreoptBuiltInstalledForApex() []dexpreopterInstall {
@@ -454,3 +483,7 @@ func (d *dexpreopter) AndroidMkEntriesForApex() []android.AndroidMkEntries {
 func (d *dexpreopter) OutputProfilePathOnHost() android.Path {
 	return d.outputProfilePathOnHost
 }
+
+func (d *dexpreopter) setClasspathFragmentProfile(profile string) {
+	d.classpathFragmentProfile = profile
+}
@@ -22,6 +22,7 @@ import (
 	"android/soong/android"
 	"android/soong/dexpreopt"
 
+	"github.com/google/blueprint"
 	"github.com/google/blueprint/proptools"
 )
 
@@ -491,6 +492,21 @@ type dexpreoptBootJars struct {
 	// Build path to a config file that Soong writes for Make (to be used in makefiles that install
 	// the default boot image).
 	dexpreoptConfigForMake android.WritablePath
+
+	// The boot image variants built by the modules, keyed by bootImageVariantKey.
+	bootImageVariantInfos map[string]BootImageVariantInfo
+
+	// Rules which should be used in make to install the profiles of the default boot image.
+	profileInstalls android.RuleBuilderInstalls
+
+	// Path to the license metadata file for the module that built the profiles.
+	profileLicenseMetadataFile android.OptionalPath
+}
+
+// bootImageVariantKey returns the key of a variant of a boot image in
+// dexpreoptBootJars.bootImageVariantInfos.
+func bootImageVariantKey(imageName string, target android.Target) string {
+	return imageName + "_" + target.String()
 }
 
 // Provide paths to boot images for use by modules that depend upon them.
@@ -524,6 +540,22 @@ func (d *dexpreoptBootJars) GenerateSingletonBuildActions(ctx android.SingletonC
 			d.otherImages = append(d.otherImages, config)
 		}
 	}
+
+	// Collect the boot image files from the modules that built them.
+	d.bootImageVariantInfos = make(map[string]BootImageVariantInfo)
+	ctx.VisitAllModules(func(module android.Module) {
+		if !ctx.ModuleHasProvider(module, BootImageInfoProvider) {
+			return
+		}
+		info := ctx.ModuleProvider(module, BootImageInfoProvider).(BootImageInfo)
+		for _, variant := range info.Variants {
+			d.bootImageVariantInfos[bootImageVariantKey(variant.ImageName, variant.Target)] = variant
+		}
+		d.profileInstalls = append(d.profileInstalls, info.ProfileInstalls...)
+		if info.ProfileLicenseMetadataFile.Valid() {
+			d.profileLicenseMetadataFile = info.ProfileLicenseMetadataFile
+		}
+	})
 }
 
 // shouldBuildBootImages determines whether boot images should be built.
@@ -592,10 +624,10 @@ func buildBootImageVariantsForAndroidOs(ctx android.ModuleContext, image *bootIm
 //
 // The files need to be generated into their predefined location because they are used from there
 // both within Soong and outside, e.g. for ART based host side testing and also for use by some
-// cloud based tools. However, they are not needed by callers of this function and so the paths do
-// not need to be returned from this func, unlike the buildBootImageVariantsForAndroidOs func.
-func buildBootImageVariantsForBuildOs(ctx android.ModuleContext, image *bootImageConfig, profile android.WritablePath) {
-	buildBootImageForOsType(ctx, image, profile, ctx.Config().BuildOS)
+// cloud based tools. However, the paths are not needed by callers of this function, which only use
+// the returned outputs to provide the install rules of the variants.
+func buildBootImageVariantsForBuildOs(ctx android.ModuleContext, image *bootImageConfig, profile android.WritablePath) bootImageOutputs {
+	return buildBootImageForOsType(ctx, image, profile, ctx.Config().BuildOS)
 }
 
 // bootImageFilesByArch is a map from android.ArchType to the paths to the boot image files.
@@ -610,6 +642,7 @@ type bootImageOutputs struct {
 	// Map from arch to the paths to the boot image files created/obtained for that arch.
 	byArch bootImageFilesByArch
 
+	// The outputs of the variants that were built, including those for the build OS.
 	variants []bootImageVariantOutputs
 
 	// The path to the profile file created/obtained for the boot image.
@@ -665,6 +698,76 @@ func buildBootImageZipInPredefinedLocation(ctx android.ModuleContext, image *boo
 
 type bootImageVariantOutputs struct {
 	config *bootImageVariant
+
+	// Rules which should be used in make to install the outputs on host.
+	installs android.RuleBuilderInstalls
+
+	// Rules which should be used in make to install the vdex outputs on host.
+	vdexInstalls android.RuleBuilderInstalls
+
+	// Rules which should be used in make to install the unstripped outputs on host.
+	unstrippedInstalls android.RuleBuilderInstalls
+}
+
+// BootImageVariantInfo contains information about the files of a variant of a boot image that were
+// built by a module.
+type BootImageVariantInfo struct {
+	// The name of the boot image config, e.g. "art" or "boot".
+	ImageName string
+
+	// The target for which the variant was built.
+	Target android.Target
+
+	// Rules which should be used in make to install the outputs on host.
+	Installs android.RuleBuilderInstalls
+
+	// Rules which should be used in make to install the vdex outputs on host.
+	VdexInstalls android.RuleBuilderInstalls
+
+	// Rules which should be used in make to install the unstripped outputs on host.
+	UnstrippedInstalls android.RuleBuilderInstalls
+
+	// Path to the license metadata file for the module that built the variant.
+	LicenseMetadataFile android.OptionalPath
+}
+
+// BootImageInfo contains information about the boot image files built by a module.
+//
+// It is only provided by the module that is responsible for building the files of a boot image,
+// i.e. the active module that copies its files to the predefined locations. It replaces the
+// deprecated fields of bootImageConfig and bootImageVariant, which are overwritten by every module
+// that builds the image.
+type BootImageInfo struct {
+	// The variants of the boot images built by the module.
+	Variants []BootImageVariantInfo
+
+	// Rules which should be used in make to install the profiles of the default boot image.
+	ProfileInstalls android.RuleBuilderInstalls
+
+	// Path to the license metadata file for the module that built the profiles.
+	ProfileLicenseMetadataFile android.OptionalPath
+}
+
+var BootImageInfoProvider = blueprint.NewProvider(BootImageInfo{})
+
+// bootImageVariantInfos returns the BootImageVariantInfo of the supplied variant outputs.
+func bootImageVariantInfos(ctx android.ModuleContext, outputs []bootImageVariantOutputs) []BootImageVariantInfo {
+	var infos []BootImageVariantInfo
+	for _, output := range outputs {
+		// The variant was not built because of an error.
+		if output.config == nil {
+			continue
+		}
+		infos = append(infos, BootImageVariantInfo{
+			ImageName:           output.config.name,
+			Target:              output.config.target,
+			Installs:            output.installs,
+			VdexInstalls:        output.vdexInstalls,
+			UnstrippedInstalls:  output.unstrippedInstalls,
+			LicenseMetadataFile: android.OptionalPathForPath(ctx.LicenseMetadataFile()),
+		})
+	}
+	return infos
 }
 
 // Generate boot image build rules for a specific target.
@@ -874,7 +977,10 @@ func buildBootImageVariant(ctx android.ModuleContext, image *bootImageVariant, p
 	}
 
 	return bootImageVariantOutputs{
-		image,
+		config:             image,
+		installs:           rule.Installs(),
+		vdexInstalls:       vdexInstalls,
+		unstrippedInstalls: unstrippedInstalls,
 	}
 }
 
@@ -1031,9 +1137,9 @@ func (d *dexpreoptBootJars) MakeVars(ctx android.MakeVarsContext) {
 
 	image := d.defaultBootImage
 	if image != nil {
-		ctx.Strict("DEXPREOPT_IMAGE_PROFILE_BUILT_INSTALLED", image.profileInstalls.String())
-		if image.profileLicenseMetadataFile.Valid() {
-			ctx.Strict("DEXPREOPT_IMAGE_PROFILE_LICENSE_METADATA", image.profileLicenseMetadataFile.String())
+		ctx.Strict("DEXPREOPT_IMAGE_PROFILE_BUILT_INSTALLED", d.profileInstalls.String())
+		if d.profileLicenseMetadataFile.Valid() {
+			ctx.Strict("DEXPREOPT_IMAGE_PROFILE_LICENSE_METADATA", d.profileLicenseMetadataFile.String())
 		}
 
 		if SkipDexpreoptBootJars(ctx) {
@@ -1056,13 +1162,14 @@ func (d *dexpreoptBootJars) MakeVars(ctx android.MakeVarsContext) {
 					suffix = "_host"
 				}
 				sfx := variant.name + suffix + "_" + variant.target.Arch.ArchType.String()
-				ctx.Strict("DEXPREOPT_IMAGE_VDEX_BUILT_INSTALLED_"+sfx, variant.vdexInstalls.String())
+				info := d.bootImageVariantInfos[bootImageVariantKey(variant.name, variant.target)]
+				ctx.Strict("DEXPREOPT_IMAGE_VDEX_BUILT_INSTALLED_"+sfx, info.VdexInstalls.String())
 				ctx.Strict("DEXPREOPT_IMAGE_"+sfx, variant.imagePathOnHost.String())
 				ctx.Strict("DEXPREOPT_IMAGE_DEPS_"+sfx, strings.Join(variant.imagesDeps.Strings(), " "))
-				ctx.Strict("DEXPREOPT_IMAGE_BUILT_INSTALLED_"+sfx, variant.installs.String())
-				ctx.Strict("DEXPREOPT_IMAGE_UNSTRIPPED_BUILT_INSTALLED_"+sfx, variant.unstrippedInstalls.String())
-				if variant.licenseMetadataFile.Valid() {
-					ctx.Strict("DEXPREOPT_IMAGE_LICENSE_METADATA_"+sfx, variant.licenseMetadataFile.String())
+				ctx.Strict("DEXPREOPT_IMAGE_BUILT_INSTALLED_"+sfx, info.Installs.String())
+				ctx.Strict("DEXPREOPT_IMAGE_UNSTRIPPED_BUILT_INSTALLED_"+sfx, info.UnstrippedInstalls.String())
+				if info.LicenseMetadataFile.Valid() {
+					ctx.Strict("DEXPREOPT_IMAGE_LICENSE_METADATA_"+sfx, info.LicenseMetadataFile.String())
 				}
 			}
 			imageLocationsOnHost, imageLocationsOnDevice := current.getAnyAndroidVariant().imageLocations()
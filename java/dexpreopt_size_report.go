@@ -0,0 +1,123 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"sort"
+	"strings"
+
+	"android/soong/android"
+)
+
+// This singleton generates a report of the size of the dexpreopt outputs that are installed on the
+// device, i.e. the .odex, .vdex, .art and .oat files and profiles, grouped by the module that built
+// them. The boot images are reported under the modules that built them, i.e. the
+// platform_bootclasspath and the bootclasspath_fragment modules.
+//
+// The report is generated in $OUT_DIR/soong/dexpreopt_size_report.json and is dist'ed as part of
+// droidcore.
+
+func init() {
+	registerDexpreoptSizeReportBuildComponents(android.InitRegistrationContext)
+}
+
+func registerDexpreoptSizeReportBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterSingletonType("dexpreopt_size_report", dexpreoptSizeReportSingletonFactory)
+}
+
+var PrepareForTestWithDexpreoptSizeReport = android.FixtureRegisterWithContext(registerDexpreoptSizeReportBuildComponents)
+
+func dexpreoptSizeReportSingletonFactory() android.Singleton {
+	return &dexpreoptSizeReportSingleton{}
+}
+
+type dexpreoptSizeReportSingleton struct {
+	report android.Path
+}
+
+var _ android.SingletonMakeVarsProvider = (*dexpreoptSizeReportSingleton)(nil)
+
+// dexpreoptSizeReportInstalls returns the dexpreopt outputs of the module that are installed on
+// the device.
+func dexpreoptSizeReportInstalls(ctx android.SingletonContext, module android.Module) android.RuleBuilderInstalls {
+	var installs android.RuleBuilderInstalls
+	if ctx.ModuleHasProvider(module, DexpreoptInfoProvider) {
+		info := ctx.ModuleProvider(module, DexpreoptInfoProvider).(DexpreoptInfo)
+		installs = append(installs, info.Installs...)
+	}
+	if ctx.ModuleHasProvider(module, BootImageInfoProvider) {
+		info := ctx.ModuleProvider(module, BootImageInfoProvider).(BootImageInfo)
+		for _, variant := range info.Variants {
+			// Only the boot images for the device take space on the device.
+			if variant.Target.Os.Class != android.Device {
+				continue
+			}
+			installs = append(installs, variant.Installs...)
+			installs = append(installs, variant.VdexInstalls...)
+		}
+		installs = append(installs, info.ProfileInstalls...)
+	}
+	return installs
+}
+
+func (s *dexpreoptSizeReportSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	var lines []string
+	var files android.Paths
+	ctx.VisitAllModules(func(module android.Module) {
+		if !module.Enabled() {
+			return
+		}
+
+		// Prevent reporting both prebuilts and matching source modules when one replaces the other.
+		if !android.IsModulePreferred(module) {
+			return
+		}
+
+		name := android.RemoveOptionalPrebuiltPrefix(ctx.ModuleName(module))
+		for _, install := range dexpreoptSizeReportInstalls(ctx, module) {
+			lines = append(lines, strings.Join([]string{name, install.To, install.From.String()}, "\t"))
+			files = append(files, install.From)
+		}
+	})
+
+	if len(lines) == 0 {
+		// Nothing is dexpreopted.
+		return
+	}
+
+	// Sort the lines for determinism as modules are visited in no particular order.
+	sort.Strings(lines)
+
+	list := android.PathForOutput(ctx, "dexpreopt_size_report", "installs.txt")
+	android.WriteFileRule(ctx, list, strings.Join(lines, "\n"))
+
+	report := android.PathForOutput(ctx, "dexpreopt_size_report.json")
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		BuiltTool("dexpreopt_size_report").
+		FlagWithInput("--installs ", list).
+		Implicits(android.SortedUniquePaths(files)).
+		FlagWithOutput("--output ", report)
+	rule.Build("dexpreopt_size_report", "dexpreopt size report")
+
+	ctx.Phony("dexpreopt-size-report", report)
+	s.report = report
+}
+
+func (s *dexpreoptSizeReportSingleton) MakeVars(ctx android.MakeVarsContext) {
+	if s.report != nil {
+		ctx.DistForGoal("droidcore", s.report)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestDexpreoptSizeReport(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithDexpreopt,
+		PrepareForTestWithDexpreoptSizeReport,
+	).RunTestWithBp(t, `
+		java_library {
+			name: "foo",
+			installable: true,
+			srcs: ["a.java"],
+		}
+
+		java_library {
+			name: "bar",
+			installable: true,
+			srcs: ["a.java"],
+			dex_preopt: {
+				enabled: false,
+			},
+		}
+	`)
+
+	foo := result.ModuleForTests("foo", "android_common")
+	info := result.ModuleProvider(foo.Module(), DexpreoptInfoProvider).(DexpreoptInfo)
+	android.AssertStringDoesContain(t, "foo installs", info.Installs.String(),
+		":/system/framework/oat/arm64/foo.odex")
+
+	report := result.SingletonForTests("dexpreopt_size_report")
+	installs := android.ContentFromFileRuleForTests(t, report.Output("dexpreopt_size_report/installs.txt"))
+	android.AssertStringDoesContain(t, "installs", installs, "foo\t/system/framework/oat/arm64/foo.odex\t")
+	android.AssertStringDoesContain(t, "installs", installs, "foo\t/system/framework/oat/arm64/foo.vdex\t")
+	android.AssertStringDoesNotContain(t, "installs", installs, "bar\t")
+
+	rule := report.Output("dexpreopt_size_report.json")
+	android.AssertStringDoesContain(t, "command", rule.RuleParams.Command,
+		"dexpreopt_size_report --installs out/soong/dexpreopt_size_report/installs.txt")
+	android.AssertStringListContains(t, "implicits", rule.Implicits.Strings(),
+		"out/soong/.intermediates/foo/android_common/dexpreopt/oat/arm64/foo.odex")
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+// This file implements the severities of the errorprone checks of a module. The checks of
+// errorprone.enabled_checks are reported as warnings and the checks of errorprone.fatal_checks are
+// reported as errors, except the ones that are listed in the errorprone baseline of the module,
+// which are reported as warnings until they are fixed.
+//
+// When building with UPDATE_ERRORPRONE_BASELINE=true, the fatal checks are reported as warnings and
+// the update-errorprone-baseline goal generates the baseline of each module with fatal checks from
+// the warnings of its errorprone run, so that a team can enable a check as fatal and ratchet the
+// existing failures down.
+
+// parseErrorproneBaseline returns the checks of an errorprone baseline file, with the line that
+// lists each of them.
+func parseErrorproneBaseline(data []byte) (map[string]int, error) {
+	checks := make(map[string]int)
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 1 {
+			return nil, fmt.Errorf("line %d: expected a single check, got %q", i+1, strings.TrimSpace(line))
+		}
+		checks[fields[0]] = i + 1
+	}
+	return checks, nil
+}
+
+// updateErrorproneBaseline returns true if the errorprone baseline of the module must be
+// regenerated.
+func (j *Module) updateErrorproneBaseline(ctx android.BaseModuleContext) bool {
+	return ctx.Config().UpdateErrorproneBaseline() && len(j.properties.Errorprone.Fatal_checks) > 0 &&
+		proptools.BoolDefault(j.properties.Errorprone.Enabled, true)
+}
+
+// errorproneCheckFlags returns the errorprone flags that set the severities of the checks of
+// errorprone.enabled_checks and errorprone.fatal_checks.
+func (j *Module) errorproneCheckFlags(ctx android.ModuleContext) []string {
+	props := j.properties.Errorprone
+
+	for _, check := range props.Fatal_checks {
+		if android.InList(check, props.Enabled_checks) {
+			ctx.PropertyErrorf("errorprone.fatal_checks", "%q is also in errorprone.enabled_checks", check)
+		}
+	}
+
+	var baseline map[string]int
+	if props.Baseline != nil {
+		data, err := ctx.Config().ErrorproneBaseline(ctx, android.PathForModuleSrc(ctx, *props.Baseline))
+		if err != nil {
+			ctx.PropertyErrorf("errorprone.baseline", "failed to read the baseline: %s", err)
+			return nil
+		}
+		baseline, err = parseErrorproneBaseline(data)
+		if err != nil {
+			ctx.PropertyErrorf("errorprone.baseline", "invalid baseline: %s", err)
+			return nil
+		}
+		for _, check := range android.SortedKeys(baseline) {
+			if !android.InList(check, props.Fatal_checks) {
+				ctx.PropertyErrorf("errorprone.baseline",
+					"line %d: %q is not in errorprone.fatal_checks, remove it from the baseline",
+					baseline[check], check)
+			}
+		}
+	}
+
+	var flags []string
+	for _, check := range android.FirstUniqueStrings(props.Enabled_checks) {
+		flags = append(flags, "-Xep:"+check+":WARN")
+	}
+	update := j.updateErrorproneBaseline(ctx)
+	for _, check := range android.FirstUniqueStrings(props.Fatal_checks) {
+		if _, exempt := baseline[check]; exempt || update {
+			flags = append(flags, "-Xep:"+check+":WARN")
+		} else {
+			flags = append(flags, "-Xep:"+check+":ERROR")
+		}
+	}
+	return flags
+}
+
+// buildErrorproneBaseline generates the errorprone baseline of the module from the warnings of the
+// fatal checks when compiling its sources with errorprone, and adds it to the
+// update-errorprone-baseline goal.
+func (j *Module) buildErrorproneBaseline(ctx android.ModuleContext, srcFiles, srcJars android.Paths,
+	flags javaBuilderFlags) {
+
+	baseline := android.PathForModuleOut(ctx, "errorprone", "errorprone-baseline.txt")
+	transformJavaToErrorproneBaseline(ctx, baseline, srcFiles, srcJars, flags,
+		android.FirstUniqueStrings(j.properties.Errorprone.Fatal_checks))
+	ctx.Phony("update-errorprone-baseline", baseline)
+}
@@ -30,8 +30,14 @@ import (
 const (
 	hostString   = "host"
 	targetString = "target"
+
+	// The Jazzer agent that instruments the classes of the fuzz target at runtime. It is
+	// packaged with each host fuzz target.
+	jazzerAgentModule = "jazzer_agent"
 )
 
+var jazzerAgentTag = dependencyTag{name: "jazzer-agent"}
+
 func init() {
 	RegisterJavaFuzzBuildComponents(android.InitRegistrationContext)
 }
@@ -45,11 +51,18 @@ type JavaFuzzTest struct {
 	Test
 	fuzzPackagedModule fuzz.FuzzPackagedModule
 	jniFilePaths       android.Paths
+	jazzerAgentJar     android.Path
 }
 
 // java_fuzz builds and links sources into a `.jar` file for the device.
 // This generates .class files in a jar which can then be instrumented before
 // fuzzing in Android Runtime (ART: Android OS on emulator or device)
+//
+// The fuzz target is fuzzed with Jazzer: the host variant is built against the
+// host jars and packaged with the Jazzer agent, and the device variant is built
+// against the sdk_version of the module. Each is packaged with its JNI
+// libraries and the metadata of the fuzzing infrastructure into
+// fuzz-java-<host|target>-<arch>.zip.
 func JavaFuzzFactory() android.Module {
 	module := &JavaFuzzTest{}
 
@@ -71,6 +84,12 @@ func JavaFuzzFactory() android.Module {
 		}{}
 		disableLinuxBionic.Target.Linux_bionic.Enabled = proptools.BoolPtr(false)
 		ctx.AppendProperties(&disableLinuxBionic)
+
+		targetFramework := fuzz.GetFramework(ctx, fuzz.Java)
+		if !fuzz.IsValidFrameworkForModule(targetFramework, fuzz.Java, module.fuzzPackagedModule.FuzzProperties.Fuzzing_frameworks) {
+			ctx.Module().Disable()
+			return
+		}
 	})
 
 	InitJavaModuleMultiTargets(module, android.HostAndDeviceSupported)
@@ -84,13 +103,23 @@ func (j *JavaFuzzTest) DepsMutator(ctx android.BottomUpMutatorContext) {
 			ctx.AddFarVariationDependencies(sharedLibVariations, jniLibTag, j.testProperties.Jni_libs...)
 		}
 	}
+	if ctx.Host() {
+		ctx.AddVariationDependencies(nil, jazzerAgentTag, jazzerAgentModule)
+	}
 
 	j.deps(ctx)
 }
 
 func (j *JavaFuzzTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
-	if j.fuzzPackagedModule.FuzzProperties.Corpus != nil {
-		j.fuzzPackagedModule.Corpus = android.PathsForModuleSrc(ctx, j.fuzzPackagedModule.FuzzProperties.Corpus)
+	fuzzProperties := j.fuzzPackagedModule.FuzzProperties
+	if fuzzProperties.Corpus != nil || fuzzProperties.Fuzz_corpus_providers != nil {
+		j.fuzzPackagedModule.Corpus = android.FirstUniquePaths(append(
+			android.PathsForModuleSrc(ctx, fuzzProperties.Corpus),
+			android.PathsForModuleSrc(ctx, fuzzProperties.Fuzz_corpus_providers)...))
+		for _, provider := range fuzzProperties.Fuzz_corpus_providers {
+			m, _ := android.SrcIsModuleWithTag(provider)
+			j.fuzzPackagedModule.CorpusProviders = append(j.fuzzPackagedModule.CorpusProviders, m)
+		}
 	}
 	if j.fuzzPackagedModule.FuzzProperties.Data != nil {
 		j.fuzzPackagedModule.Data = android.PathsForModuleSrc(ctx, j.fuzzPackagedModule.FuzzProperties.Data)
@@ -99,11 +128,18 @@ func (j *JavaFuzzTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		j.fuzzPackagedModule.Dictionary = android.PathForModuleSrc(ctx, *j.fuzzPackagedModule.FuzzProperties.Dictionary)
 	}
 	if j.fuzzPackagedModule.FuzzProperties.Fuzz_config != nil {
+		config := *j.fuzzPackagedModule.FuzzProperties.Fuzz_config
+		// A fuzz target with JNI needs the version of Jazzer that supports it.
+		if config.IsJni == nil && len(j.testProperties.Jni_libs) > 0 {
+			config.IsJni = proptools.BoolPtr(true)
+		}
 		configPath := android.PathForModuleOut(ctx, "config").Join(ctx, "config.json")
-		android.WriteFileRule(ctx, configPath, j.fuzzPackagedModule.FuzzProperties.Fuzz_config.String())
+		android.WriteFileRule(ctx, configPath, config.String())
 		j.fuzzPackagedModule.Config = configPath
 	}
 
+	j.fuzzPackagedModule.Framework = fuzz.Jazzer
+
 	_, sharedDeps := cc.CollectAllSharedDependencies(ctx)
 	for _, dep := range sharedDeps {
 		sharedLibInfo := ctx.OtherModuleProvider(dep, cc.SharedLibraryInfoProvider).(cc.SharedLibraryInfo)
@@ -126,6 +162,14 @@ func (j *JavaFuzzTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 
 	}
 
+	ctx.VisitDirectDepsWithTag(jazzerAgentTag, func(dep android.Module) {
+		if info, ok := ctx.OtherModuleProvider(dep, JavaInfoProvider).(JavaInfo); ok && len(info.ImplementationAndResourcesJars) > 0 {
+			j.jazzerAgentJar = info.ImplementationAndResourcesJars[0]
+		} else {
+			ctx.ModuleErrorf("%q is not a java library", ctx.OtherModuleName(dep))
+		}
+	})
+
 	j.Test.GenerateAndroidBuildActions(ctx)
 }
 
@@ -177,6 +221,11 @@ func (s *javaFuzzPackager) GenerateBuildActions(ctx android.SingletonContext) {
 		// Add .jar
 		files = append(files, fuzz.FileToZip{javaFuzzModule.implementationJarFile, ""})
 
+		// Add the Jazzer agent
+		if javaFuzzModule.jazzerAgentJar != nil {
+			files = append(files, fuzz.FileToZip{javaFuzzModule.jazzerAgentJar, ""})
+		}
+
 		// Add jni .so files
 		for _, fPath := range javaFuzzModule.jniFilePaths {
 			files = append(files, fuzz.FileToZip{fPath, ""})
@@ -53,6 +53,11 @@ func TestJavaFuzz(t *testing.T) {
 			srcs: ["c.java"],
 		}
 
+		java_library_host {
+			name: "jazzer_agent",
+			srcs: ["d.java"],
+		}
+
 		cc_library_shared {
 			name: "libjni",
 			host_supported: true,
@@ -94,4 +99,45 @@ func TestJavaFuzz(t *testing.T) {
 		t.Errorf(`expected foo test data relative path [%q], got %q`,
 			expected, fooJniFilePaths.Strings())
 	}
+
+	agent := ctx.ModuleForTests("jazzer_agent", osCommonTarget).Module().(*Library)
+	android.AssertPathRelativeToTopEquals(t, "jazzer agent",
+		android.PathRelativeToTop(agent.ImplementationAndResourcesJars()[0]), foo.jazzerAgentJar)
+
+	packaging := ctx.SingletonForTests("java_fuzz_packaging")
+	fooZip := packaging.Output("out/soong/.intermediates/fuzz/host/common/foo.zip")
+	android.AssertStringListContains(t, "foo.zip inputs", fooZip.Implicits.Strings(),
+		android.PathRelativeToTop(foo.jazzerAgentJar))
+
+	metadata := packaging.Output("out/soong/.intermediates/fuzz/host/common/foo_metadata.json")
+	android.AssertStringDoesContain(t, "foo metadata", android.ContentFromFileRuleForTests(t, metadata),
+		`"framework": "jazzer"`)
+}
+
+func TestJavaFuzzDevice(t *testing.T) {
+	result := prepForJavaFuzzTest.RunTestWithBp(t, `
+		java_fuzz {
+			name: "foo",
+			srcs: ["a.java"],
+			jni_libs: ["libjni"],
+			fuzz_config: {
+				cc: ["foo@example.com"],
+			},
+		}
+
+		cc_library_shared {
+			name: "libjni",
+			stl: "none",
+			system_shared_libs: [],
+		}
+		`)
+
+	// The Jazzer agent is only packaged with the host fuzz targets.
+	foo := result.ModuleForTests("foo", "android_common")
+	if agent := foo.Module().(*JavaFuzzTest).jazzerAgentJar; agent != nil {
+		t.Errorf("expected no Jazzer agent for the device variant, got %q", agent)
+	}
+
+	config := android.ContentFromFileRuleForTests(t, foo.Output("config/config.json"))
+	android.AssertStringDoesContain(t, "config.json", config, `"is_jni":true`)
 }
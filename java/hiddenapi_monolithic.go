@@ -46,6 +46,18 @@ type MonolithicHiddenAPIInfo struct {
 	// bootclasspath_fragment modules.
 	FlagSubsets SignatureCsvSubsets
 
+	// The stub-flags.csv files of the bootclasspath_fragment modules, which are the shards of the
+	// monolithic hiddenapi-stub-flags.txt file when it is sharded.
+	StubFlagsPaths android.Paths
+
+	// The all-flags.csv files of the bootclasspath_fragment modules, which are the shards of the
+	// monolithic hiddenapi-flags.csv file when it is sharded.
+	AllFlagsPaths android.Paths
+
+	// The stub dex jars for each of the *HiddenAPIScope in hiddenAPIScopes provided by the
+	// bootclasspath_fragment modules.
+	StubDexJarsByScope StubDexJarsByModule
+
 	// The classes jars from the libraries on the platform bootclasspath.
 	ClassesJars android.Paths
 }
@@ -53,7 +65,9 @@ type MonolithicHiddenAPIInfo struct {
 // newMonolithicHiddenAPIInfo creates a new MonolithicHiddenAPIInfo from the flagFilesByCategory
 // plus information provided by each of the fragments.
 func newMonolithicHiddenAPIInfo(ctx android.ModuleContext, flagFilesByCategory FlagFilesByCategory, classpathElements ClasspathElements) MonolithicHiddenAPIInfo {
-	monolithicInfo := MonolithicHiddenAPIInfo{}
+	monolithicInfo := MonolithicHiddenAPIInfo{
+		StubDexJarsByScope: StubDexJarsByModule{},
+	}
 
 	monolithicInfo.FlagsFilesByCategory = flagFilesByCategory
 
@@ -88,6 +102,22 @@ func (i *MonolithicHiddenAPIInfo) append(other *HiddenAPIInfo) {
 
 	i.StubFlagSubsets = append(i.StubFlagSubsets, other.StubFlagSubset())
 	i.FlagSubsets = append(i.FlagSubsets, other.FlagSubset())
+
+	// The snapshots of the prebuilt fragments since T only provide the filtered flags, which lack the
+	// signatures that are not part of any API.
+	i.StubFlagsPaths = append(i.StubFlagsPaths, firstNonNilPath(other.StubFlagsPath, other.FilteredStubFlagsPath))
+	i.AllFlagsPaths = append(i.AllFlagsPaths, firstNonNilPath(other.AllFlagsPath, other.FilteredFlagsPath))
+	i.StubDexJarsByScope.addStubDexJarsByModule(other.TransitiveStubDexJarsByScope)
+}
+
+// firstNonNilPath returns the first of the paths that is not nil, or nil if they are all nil.
+func firstNonNilPath(paths ...android.Path) android.Path {
+	for _, path := range paths {
+		if path != nil {
+			return path
+		}
+	}
+	return nil
 }
 
 var MonolithicHiddenAPIInfoProvider = blueprint.NewProvider(MonolithicHiddenAPIInfo{})
@@ -19,6 +19,7 @@ package java
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/google/blueprint"
@@ -45,6 +46,13 @@ var (
 		},
 	},
 		"strippedJar", "stripSpec", "tmpDir", "tmpJar")
+
+	jacocoExcludedClasses = pctx.AndroidStaticRule("jacocoExcludedClasses", blueprint.RuleParams{
+		Command:     `${config.JacocoExcludedClassesCmd} $annotations --output $out $in`,
+		CommandDeps: []string{"${config.JacocoExcludedClassesCmd}"},
+	}, "annotations")
+
+	jacocoAnnotationRegexp = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)+$`)
 )
 
 func jacocoDepsMutator(ctx android.BottomUpMutatorContext) {
@@ -78,19 +86,28 @@ func jacocoDepsMutator(ctx android.BottomUpMutatorContext) {
 // Instruments a jar using the Jacoco command line interface.  Uses stripSpec to extract a subset
 // of the classes in inputJar into strippedJar, instruments strippedJar into tmpJar, and then
 // combines the classes in tmpJar with inputJar (preferring the instrumented classes in tmpJar)
-// to produce instrumentedJar.
+// to produce instrumentedJar. The classes listed in excludedClasses, if set, are also left out of
+// strippedJar.
 func jacocoInstrumentJar(ctx android.ModuleContext, instrumentedJar, strippedJar android.WritablePath,
-	inputJar android.Path, stripSpec string) {
+	inputJar android.Path, stripSpec string, excludedClasses android.Path) {
 
 	// The basename of tmpJar has to be the same as the basename of strippedJar
 	tmpJar := android.PathForModuleOut(ctx, "jacoco", "tmp", strippedJar.Base())
 
+	var implicits android.Paths
+	if excludedClasses != nil {
+		// The excluded classes are flags of zip2zip, which must come before its specs.
+		stripSpec = "$$(sed -e 's/^/-x /' " + excludedClasses.String() + ") " + stripSpec
+		implicits = append(implicits, excludedClasses)
+	}
+
 	ctx.Build(pctx, android.BuildParams{
 		Rule:           jacoco,
 		Description:    "jacoco",
 		Output:         instrumentedJar,
 		ImplicitOutput: strippedJar,
 		Input:          inputJar,
+		Implicits:      implicits,
 		Args: map[string]string{
 			"strippedJar": strippedJar.String(),
 			"stripSpec":   stripSpec,
@@ -100,6 +117,28 @@ func jacocoInstrumentJar(ctx android.ModuleContext, instrumentedJar, strippedJar
 	})
 }
 
+// jacocoExcludedClassesForAnnotations lists the classes of inputJar that are annotated with the
+// excluded annotations of the module, and their nested classes, or returns nil if the module
+// doesn't exclude any annotation.
+func (j *Module) jacocoExcludedClassesForAnnotations(ctx android.ModuleContext, inputJar android.Path) android.Path {
+	annotations := j.properties.Jacoco.Exclude_annotations
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	excludedClasses := android.PathForModuleOut(ctx, "jacoco", "excluded-classes.txt")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        jacocoExcludedClasses,
+		Description: "jacoco excluded classes",
+		Output:      excludedClasses,
+		Input:       inputJar,
+		Args: map[string]string{
+			"annotations": android.JoinWithPrefix(proptools.NinjaAndShellEscapeList(annotations), "--annotation "),
+		},
+	})
+	return excludedClasses
+}
+
 func (j *Module) jacocoModuleToZipCommand(ctx android.ModuleContext) string {
 	includes, err := jacocoFiltersToSpecs(j.properties.Jacoco.Include_filter)
 	if err != nil {
@@ -111,6 +150,18 @@ func (j *Module) jacocoModuleToZipCommand(ctx android.ModuleContext) string {
 		ctx.PropertyErrorf("jacoco.exclude_filter", "%s", err.Error())
 	}
 
+	// Exclude the classes generated by the build unless the module wants them instrumented.
+	if proptools.BoolDefault(j.properties.Jacoco.Exclude_generated_code, true) {
+		excludes = append(excludes, proptools.NinjaAndShellEscapeList(config.DefaultJacocoGeneratedCodeExcludeSpecs)...)
+	}
+
+	for _, annotation := range j.properties.Jacoco.Exclude_annotations {
+		if !jacocoAnnotationRegexp.MatchString(annotation) {
+			ctx.PropertyErrorf("jacoco.exclude_annotations",
+				"%q must be the fully qualified name of an annotation", annotation)
+		}
+	}
+
 	return jacocoFiltersToZipCommand(includes, excludes)
 }
 
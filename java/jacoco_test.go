@@ -14,7 +14,12 @@
 
 package java
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"android/soong/android"
+)
 
 func TestJacocoFilterToSpecs(t *testing.T) {
 	testCases := []struct {
@@ -103,3 +108,121 @@ func TestJacocoFiltersToZipCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestJacocoExcludes(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		PrepareForTestWithJacocoInstrumentation,
+	).RunTestWithBp(t, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			jacoco: {
+				exclude_annotations: ["com.android.Excluded"],
+			},
+		}
+
+		android_app {
+			name: "bar",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			jacoco: {
+				exclude_generated_code: false,
+			},
+		}
+	`)
+
+	foo := result.ModuleForTests("foo", "android_common")
+	excludedClasses := foo.Output("jacoco/excluded-classes.txt")
+	android.AssertStringEquals(t, "annotations", "--annotation com.android.Excluded",
+		excludedClasses.Args["annotations"])
+
+	instrumented := foo.Output("jacoco/foo.jar")
+	android.AssertPathRelativeToTopEquals(t, "input", instrumented.Input.String(), excludedClasses.Input)
+	android.AssertStringDoesContain(t, "strip spec", instrumented.Args["stripSpec"],
+		"$$(sed -e 's/^/-x /' out/soong/.intermediates/foo/android_common/jacoco/excluded-classes.txt) ")
+	android.AssertStringDoesContain(t, "strip spec", instrumented.Args["stripSpec"], "-x '**/R$$*.class'")
+	android.AssertPathsRelativeToTopEquals(t, "implicits",
+		[]string{"out/soong/.intermediates/foo/android_common/jacoco/excluded-classes.txt"}, instrumented.Implicits)
+
+	bar := result.ModuleForTests("bar", "android_common")
+	if bar.MaybeOutput("jacoco/excluded-classes.txt").Rule != nil {
+		t.Errorf("expected no excluded classes for bar")
+	}
+	android.AssertStringDoesNotContain(t, "strip spec", bar.Output("jacoco/bar.jar").Args["stripSpec"], "R.class")
+}
+
+func TestJacocoExcludeAnnotationsErrors(t *testing.T) {
+	android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`jacoco.exclude_annotations: "Excluded" must be the fully qualified name of an annotation`)).
+		RunTestWithBp(t, `
+			android_app {
+				name: "foo",
+				srcs: ["a.java"],
+				sdk_version: "current",
+				jacoco: {
+					exclude_annotations: ["Excluded"],
+				},
+			}
+		`)
+}
+
+func TestJacocoTestSuites(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		PrepareForTestWithJacocoInstrumentation,
+	).RunTestWithBp(t, `
+		android_app {
+			name: "app",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+
+		android_test {
+			name: "test",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			instrumentation_for: "app",
+			test_suites: ["device-tests", "general-tests"],
+		}
+
+		android_test {
+			name: "other_test",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			instrumentation_for: "app",
+			test_suites: ["general-tests"],
+		}
+
+		android_test {
+			name: "no_suite_test",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			instrumentation_for: "app",
+		}
+	`)
+
+	reportClasses := func(module string) string {
+		return "out/soong/.intermediates/" + module + "/android_common/jacoco-report-classes/" + module + ".jar"
+	}
+
+	singleton := result.SingletonForTests("jacoco_test_suites")
+	android.AssertStringEquals(t, "general-tests", strings.Join([]string{
+		"app " + reportClasses("app"),
+		"other_test " + reportClasses("other_test"),
+		"test " + reportClasses("test"),
+	}, "\n")+"\n",
+		android.ContentFromFileRuleForTests(t, singleton.Output("jacoco/general-tests/jacoco-report-classes.txt")))
+	android.AssertStringEquals(t, "device-tests", strings.Join([]string{
+		"app " + reportClasses("app"),
+		"test " + reportClasses("test"),
+	}, "\n")+"\n",
+		android.ContentFromFileRuleForTests(t, singleton.Output("jacoco/device-tests/jacoco-report-classes.txt")))
+
+	merged := singleton.Output("jacoco/general-tests/jacoco-report-classes.jar")
+	android.AssertPathsRelativeToTopEquals(t, "merged jars",
+		[]string{reportClasses("app"), reportClasses("other_test"), reportClasses("test")}, merged.Inputs)
+}
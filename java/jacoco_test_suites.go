@@ -0,0 +1,155 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// This singleton merges the coverage metadata of the instrumented tests of each test suite, so
+// that the coverage data collected by all the tests of the suite can be reported at once. For each
+// test suite that has android_test modules built with coverage, it generates in
+// $OUT_DIR/soong/jacoco/<suite>:
+//   - jacoco-report-classes.jar, the uninstrumented classes of the tests and of the apps that they
+//     instrument, against which the merged .ec files of the suite are reported.
+//   - jacoco-report-classes.txt, the module that each jacoco-report-classes jar merged in the jar
+//     above comes from, one "<module> <jar>" pair per line.
+//
+// Both are built by the jacoco-report-classes-<suite> phony target and dist'ed as part of the
+// goal of the suite.
+
+// JacocoReportClassesJar is the jacoco-report-classes jar of a module.
+type JacocoReportClassesJar struct {
+	Module string
+	Jar    android.Path
+}
+
+// JacocoTestSuiteInfo is provided by the test modules that are built with coverage and are in
+// test suites.
+type JacocoTestSuiteInfo struct {
+	// The test suites of the test.
+	TestSuites []string
+
+	// The jacoco-report-classes jars of the test and of the modules that it instruments.
+	ReportClassesJars []JacocoReportClassesJar
+}
+
+var JacocoTestSuiteInfoProvider = blueprint.NewProvider(JacocoTestSuiteInfo{})
+
+// setJacocoTestSuiteInfo provides the jacoco-report-classes jars of a test and of the modules that
+// it instruments for its test suites.
+func setJacocoTestSuiteInfo(ctx android.ModuleContext, testSuites []string, reportClassesFile android.Path) {
+	if len(testSuites) == 0 {
+		return
+	}
+
+	var jars []JacocoReportClassesJar
+	if reportClassesFile != nil {
+		jars = append(jars, JacocoReportClassesJar{ctx.ModuleName(), reportClassesFile})
+	}
+	ctx.VisitDirectDepsWithTag(instrumentationForTag, func(m android.Module) {
+		if !ctx.OtherModuleHasProvider(m, JavaInfoProvider) {
+			return
+		}
+		info := ctx.OtherModuleProvider(m, JavaInfoProvider).(JavaInfo)
+		if info.JacocoReportClassesFile != nil {
+			jars = append(jars, JacocoReportClassesJar{ctx.OtherModuleName(m), info.JacocoReportClassesFile})
+		}
+	})
+	if len(jars) == 0 {
+		return
+	}
+
+	ctx.SetProvider(JacocoTestSuiteInfoProvider, JacocoTestSuiteInfo{
+		TestSuites:        testSuites,
+		ReportClassesJars: jars,
+	})
+}
+
+func jacocoTestSuitesSingletonFactory() android.Singleton {
+	return &jacocoTestSuitesSingleton{}
+}
+
+type jacocoTestSuitesSingleton struct {
+	// The merged jacoco-report-classes jar and its description of each test suite.
+	jars         map[string]android.Path
+	descriptions map[string]android.Path
+}
+
+var _ android.SingletonMakeVarsProvider = (*jacocoTestSuitesSingleton)(nil)
+
+func (s *jacocoTestSuitesSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	// The jacoco-report-classes jars of each test suite, by path, as the tests of a suite can
+	// instrument the same app.
+	suites := make(map[string]map[string]JacocoReportClassesJar)
+	ctx.VisitAllModules(func(module android.Module) {
+		if !module.Enabled() || !ctx.ModuleHasProvider(module, JacocoTestSuiteInfoProvider) {
+			return
+		}
+		info := ctx.ModuleProvider(module, JacocoTestSuiteInfoProvider).(JacocoTestSuiteInfo)
+		for _, suite := range info.TestSuites {
+			if suites[suite] == nil {
+				suites[suite] = make(map[string]JacocoReportClassesJar)
+			}
+			for _, jar := range info.ReportClassesJars {
+				suites[suite][jar.Jar.String()] = jar
+			}
+		}
+	})
+
+	s.jars = make(map[string]android.Path)
+	s.descriptions = make(map[string]android.Path)
+	for _, suite := range android.SortedKeys(suites) {
+		var jars android.Paths
+		var lines []string
+		for _, path := range android.SortedKeys(suites[suite]) {
+			jar := suites[suite][path]
+			jars = append(jars, jar.Jar)
+			lines = append(lines, jar.Module+" "+path)
+		}
+		// Sort the lines by module for readability.
+		sort.Strings(lines)
+
+		dir := android.PathForOutput(ctx, "jacoco", suite)
+		description := dir.Join(ctx, "jacoco-report-classes.txt")
+		android.WriteFileRule(ctx, description, strings.Join(lines, "\n"))
+
+		merged := dir.Join(ctx, "jacoco-report-classes.jar")
+		rule := android.NewRuleBuilder(pctx, ctx)
+		rule.Command().
+			BuiltTool("merge_zips").
+			Flag("--ignore-duplicates").
+			Flag("-j").
+			Output(merged).
+			Inputs(jars)
+		rule.Build("jacoco_report_classes_"+suite, "jacoco report classes of "+suite)
+
+		ctx.Phony("jacoco-report-classes-"+suite, merged, description)
+		s.jars[suite] = merged
+		s.descriptions[suite] = description
+	}
+}
+
+func (s *jacocoTestSuitesSingleton) MakeVars(ctx android.MakeVarsContext) {
+	for _, suite := range android.SortedKeys(s.jars) {
+		ctx.DistForGoalWithFilename(suite, s.jars[suite], "jacoco/"+suite+"-jacoco-report-classes.jar")
+		ctx.DistForGoalWithFilename(suite, s.descriptions[suite], "jacoco/"+suite+"-jacoco-report-classes.txt")
+	}
+}
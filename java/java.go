@@ -75,6 +75,7 @@ func registerJavaBuildComponents(ctx android.RegistrationContext) {
 
 	ctx.RegisterSingletonType("logtags", LogtagsSingleton)
 	ctx.RegisterSingletonType("kythe_java_extract", kytheExtractJavaFactory)
+	ctx.RegisterSingletonType("jacoco_test_suites", jacocoTestSuitesSingletonFactory)
 }
 
 func RegisterJavaSdkMemberTypes() {
@@ -273,6 +274,10 @@ type JavaInfo struct {
 	// JacocoReportClassesFile is the path to a jar containing uninstrumented classes that will be
 	// instrumented by jacoco.
 	JacocoReportClassesFile android.Path
+
+	// ApiOnly is true if the module only provides header jars, i.e. it has no implementation jars
+	// and can only be used at compile time.
+	ApiOnly bool
 }
 
 var JavaInfoProvider = blueprint.NewProvider(JavaInfo{})
@@ -283,6 +288,10 @@ type SyspropPublicStubInfo struct {
 	// JavaInfo is the JavaInfoProvider of the sysprop public stub library that corresponds to
 	// the sysprop implementation library.
 	JavaInfo JavaInfo
+
+	// InternalAccessAllowlist is the list of vendor and odm modules that may link against the
+	// sysprop implementation library, and so against the internal properties of the platform.
+	InternalAccessAllowlist []string
 }
 
 var SyspropPublicStubInfoProvider = blueprint.NewProvider(SyspropPublicStubInfo{})
@@ -344,6 +353,13 @@ func (d dependencyTag) LicenseAnnotations() []android.LicenseAnnotation {
 
 var _ android.LicenseAnnotationsDependencyTag = dependencyTag{}
 
+// LicenseStaticLink returns true for the static libraries, which are merged into the module.
+func (d dependencyTag) LicenseStaticLink() bool {
+	return d == staticLibTag
+}
+
+var _ android.LicenseStaticLinkDependencyTag = dependencyTag{}
+
 type usesLibraryDependencyTag struct {
 	dependencyTag
 	sdkVersion int  // SDK version in which the library appared as a standalone library.
@@ -447,6 +463,7 @@ type jniLib struct {
 	coverageFile   android.OptionalPath
 	unstrippedFile android.Path
 	partition      string
+	module         android.Module
 }
 
 func sdkDeps(ctx android.BottomUpMutatorContext, sdkContext android.SdkContext, d dexer) {
@@ -502,9 +519,25 @@ type deps struct {
 	kotlinAnnotations       android.Paths
 	kotlinPlugins           android.Paths
 
+	// isolatedPlugins is the list of the isolating annotation processors of the module, which are
+	// not in processorPath and processorClasses as each of them is run separately.
+	isolatedPlugins []isolatedPlugin
+
 	disableTurbine bool
 }
 
+// isolatedPlugin is an isolating annotation processor, see PluginProperties.Isolating.
+type isolatedPlugin struct {
+	// The name of the java_plugin module.
+	name string
+
+	// The jars of the annotation processor.
+	processorPath classpath
+
+	// The name of the class of the annotation processor.
+	processorClass string
+}
+
 func checkProducesJars(ctx android.ModuleContext, dep android.SourceFileProducer) {
 	for _, f := range dep.Srcs() {
 		if f.Ext() != ".jar" {
@@ -606,12 +639,29 @@ func normalizeJavaVersion(ctx android.BaseModuleContext, javaVersion string) jav
 // Java libraries (.jar file)
 //
 
+type libraryProperties struct {
+	// If set to true then only the header jar of the library is built with turbine, skipping the
+	// compilation of its implementation jar with javac and its dexing. Defaults to false.
+	//
+	// This is intended for libraries that are only used at compile time, e.g. annotations or stubs,
+	// so they can only be used in the libs of other modules and cannot be installed. It is only
+	// supported for device libraries whose sources can be compiled by turbine, i.e. java sources
+	// without annotation processors that generate API.
+	Api_only *bool
+}
+
 type Library struct {
 	Module
 
+	libraryProperties libraryProperties
+
 	exportedProguardFlagFiles android.Paths
 
 	InstallMixin func(ctx android.ModuleContext, installPath android.Path) (extraInstallDeps android.Paths)
+
+	// OutputMixin replaces the output jar of the library before it is installed, e.g. with a copy
+	// that is validated by a run of the test during the build.
+	OutputMixin func(ctx android.ModuleContext, outputFile android.Path) android.Path
 }
 
 var _ LibraryDependency = (*Library)(nil)
@@ -686,13 +736,27 @@ func (j *Library) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		j.dexpreopter.uncompressedDex = *j.dexProperties.Uncompress_dex
 		j.classLoaderContexts = j.usesLibrary.classLoaderContextForUsesLibDeps(ctx)
 	}
+	j.apiOnly = Bool(j.libraryProperties.Api_only)
+	if j.apiOnly {
+		if ctx.Host() {
+			ctx.PropertyErrorf("api_only", "is only supported for device libraries")
+			return
+		}
+		// Make cannot tell the compile time uses of the library from the others so it is not
+		// exported to Make.
+		j.HideFromMake()
+	}
 	j.compile(ctx, nil)
+	if j.OutputMixin != nil && j.outputFile != nil {
+		j.outputFile = j.OutputMixin(ctx, j.outputFile)
+	}
 
 	// Collect the module directory for IDE info in java/jdeps.go.
 	j.modulePaths = append(j.modulePaths, ctx.ModuleDir())
 
 	exclusivelyForApex := !apexInfo.IsForPlatform()
-	if (Bool(j.properties.Installable) || ctx.Host()) && !exclusivelyForApex {
+	// An api_only library only has a header jar, so there is nothing to install.
+	if (Bool(j.properties.Installable) || ctx.Host()) && !exclusivelyForApex && !j.apiOnly {
 		var extraInstallDeps android.Paths
 		if j.InstallMixin != nil {
 			extraInstallDeps = j.InstallMixin(ctx, j.outputFile)
@@ -886,6 +950,7 @@ func LibraryFactory() android.Module {
 	module := &Library{}
 
 	module.addHostAndDeviceProperties()
+	module.AddProperties(&module.libraryProperties)
 
 	module.initModuleAndImport(module)
 
@@ -993,6 +1058,8 @@ type hostTestProperties struct {
 	// list of device binary modules that should be installed alongside the test
 	// This property only adds 32bit variants of the dependency
 	Data_device_bins_32 []string `android:"arch_variant"`
+
+	android.RunAtBuildProperties
 }
 
 type testHelperLibraryProperties struct {
@@ -1194,9 +1261,24 @@ func (j *TestHost) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		})
 	}
 
+	j.OutputMixin = j.runAtBuild
 	j.Test.generateAndroidBuildActionsWithConfig(ctx, configs)
 }
 
+// runAtBuild runs the JUnit tests of the test jar during the build if run_at_build is set. The test
+// classes are the classes of the jar whose names end with Test, and JUnit must be in the jar, e.g.
+// through static_libs.
+func (j *TestHost) runAtBuild(ctx android.ModuleContext, outputFile android.Path) android.Path {
+	javaCmd := config.JavaCmd(ctx)
+	return android.RunTestAtBuild(ctx, j.testHostProperties.RunAtBuildProperties, outputFile,
+		func(cmd *android.RuleBuilderCommand) {
+			cmd.Input(javaCmd).
+				FlagWithInput("-cp ", outputFile).
+				Text("org.junit.runner.JUnitCore").
+				Textf(`$(unzip -Z1 %s '*Test.class' | grep -v '\$' | sed -e 's/\.class$//' -e 's|/|.|g')`, outputFile)
+		}, j.data, []string{filepath.Dir(filepath.Dir(javaCmd.String()))})
+}
+
 func (j *Test) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	j.generateAndroidBuildActionsWithConfig(ctx, nil)
 }
@@ -1256,6 +1338,17 @@ func (j *Test) generateAndroidBuildActionsWithConfig(ctx android.ModuleContext,
 	})
 
 	j.Library.GenerateAndroidBuildActions(ctx)
+
+	if j.outputFile != nil {
+		files := []android.TestSuiteFile{{Src: j.outputFile, Rel: ctx.ModuleName() + ".jar"}}
+		for _, d := range j.data {
+			files = append(files, android.TestSuiteFile{Src: d, Rel: d.Rel()})
+		}
+		ctx.SetProvider(android.TestSuiteInfoProvider, android.TestSuiteInfo{
+			Files:  files,
+			Config: j.testConfig,
+		})
+	}
 }
 
 func (j *TestHelperLibrary) GenerateAndroidBuildActions(ctx android.ModuleContext) {
@@ -1939,6 +2032,10 @@ var _ UsesLibraryDependency = (*ApiLibrary)(nil)
 type ImportProperties struct {
 	Jars []string `android:"path,arch_variant"`
 
+	// The sha256 of the jar. If set, jars must contain a single jar and the build fails if it does
+	// not match.
+	Sha256 *string
+
 	// The version of the SDK that the source prebuilt file was built against. Defaults to the
 	// current version if not specified.
 	Sdk_version *string
@@ -2077,6 +2174,7 @@ func (j *Import) DepsMutator(ctx android.BottomUpMutatorContext) {
 
 	if ctx.Device() && Bool(j.dexProperties.Compile_dex) {
 		sdkDeps(ctx, android.SdkContext(j), j.dexer)
+		coreLibraryDesugaringDeps(ctx, j.dexer)
 	}
 }
 
@@ -2097,7 +2195,8 @@ func (j *Import) commonBuildActions(ctx android.ModuleContext) {
 func (j *Import) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	j.commonBuildActions(ctx)
 
-	jars := android.PathsForModuleSrc(ctx, j.properties.Jars)
+	jars := android.CheckPrebuiltSha256(ctx, android.PathsForModuleSrc(ctx, j.properties.Jars),
+		j.properties.Sha256)
 
 	jarName := j.Stem() + ".jar"
 	outputFile := android.PathForModuleOut(ctx, "combined", jarName)
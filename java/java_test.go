@@ -491,6 +491,33 @@ func TestTest(t *testing.T) {
 	}
 }
 
+func TestTestHostRunAtBuild(t *testing.T) {
+	ctx, _ := testJava(t, `
+		java_test_host {
+			name: "foo",
+			srcs: ["a.java"],
+			run_at_build: true,
+		}
+	`)
+
+	buildOS := ctx.Config().BuildOS.String()
+	foo := ctx.ModuleForTests("foo", buildOS+"_common")
+
+	validated := foo.Output("out/soong/.intermediates/foo/" + buildOS + "_common/run_at_build_validated/foo.jar")
+	android.AssertPathRelativeToTopEquals(t, "validation",
+		"out/soong/.intermediates/foo/"+buildOS+"_common/run_at_build/run_at_build.timestamp",
+		validated.Validation)
+	android.AssertPathRelativeToTopEquals(t, "output",
+		"out/soong/.intermediates/foo/"+buildOS+"_common/run_at_build_validated/foo.jar",
+		foo.Module().(*TestHost).outputFile)
+
+	manifest := android.RuleBuilderSboxProtoForTests(t, foo.Output("run_at_build.sbox.textproto"))
+	cmd := manifest.Commands[0].GetCommand()
+	android.AssertStringDoesContain(t, "timeout", cmd, "timeout 600s ")
+	android.AssertStringDoesContain(t, "runner", cmd,
+		"-cp "+validated.Input.String()+" org.junit.runner.JUnitCore ")
+}
+
 func TestHostBinaryNoJavaDebugInfoOverride(t *testing.T) {
 	bp := `
 		java_library {
@@ -983,6 +1010,62 @@ func TestTurbine(t *testing.T) {
 	android.AssertStringDoesContain(t, "baz javac classpath", bazJavac.Args["classpath"], "prebuilts/sdk/14/public/android.jar")
 }
 
+func TestApiOnly(t *testing.T) {
+	result := prepareForJavaTest.RunTestWithBp(t, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java"],
+			api_only: true,
+		}
+
+		java_library {
+			name: "bar",
+			srcs: ["b.java"],
+			libs: ["foo"],
+		}
+		`)
+
+	foo := result.ModuleForTests("foo", "android_common")
+	foo.Rule("turbine")
+	android.AssertBoolEquals(t, "foo has javac rule", false, foo.MaybeRule("javac").Rule != nil)
+	android.AssertBoolEquals(t, "foo has d8 rule", false, foo.MaybeRule("d8").Rule != nil)
+
+	fooInfo := result.ModuleProvider(foo.Module(), JavaInfoProvider).(JavaInfo)
+	android.AssertBoolEquals(t, "foo api only", true, fooInfo.ApiOnly)
+	android.AssertDeepEquals(t, "foo implementation jars", android.Paths(nil), fooInfo.ImplementationJars)
+
+	fooHeaderJar := filepath.Join("out", "soong", ".intermediates", "foo", "android_common", "turbine-combined", "foo.jar")
+	barJavac := result.ModuleForTests("bar", "android_common").Rule("javac")
+	android.AssertStringDoesContain(t, "bar javac classpath", barJavac.Args["classpath"], fooHeaderJar)
+
+	t.Run("static_libs", func(t *testing.T) {
+		testJavaError(t, `an api_only library \(foo\) has no implementation jar and cannot be used as a static_libs dependency`, `
+			java_library {
+				name: "foo",
+				srcs: ["a.java"],
+				api_only: true,
+			}
+
+			java_library {
+				name: "bar",
+				srcs: ["b.java"],
+				static_libs: ["foo"],
+			}
+		`)
+	})
+
+	t.Run("installable", func(t *testing.T) {
+		testJavaError(t, `api_only: cannot be used with installable: true`, `
+			java_library {
+				name: "foo",
+				srcs: ["a.java"],
+				api_only: true,
+				installable: true,
+			}
+		`)
+	})
+}
+
 func TestSharding(t *testing.T) {
 	ctx, _ := testJava(t, `
 		java_library {
@@ -1573,6 +1656,115 @@ func TestErrorproneEnabledOnlyByEnvironmentVariable(t *testing.T) {
 	}
 }
 
+func TestErrorproneCheckSeverities(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureAddTextFile("errorprone-baseline.txt", "# comment\n\nReferenceEquality\n"),
+	).RunTestWithBp(t, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java"],
+			errorprone: {
+				enabled: true,
+				enabled_checks: ["UnusedVariable"],
+				fatal_checks: ["MissingOverride", "ReferenceEquality"],
+				baseline: "errorprone-baseline.txt",
+			},
+		}
+	`)
+
+	javac := result.ModuleForTests("foo", "android_common").Description("javac")
+	android.AssertStringDoesContain(t, "javacFlags", javac.Args["javacFlags"],
+		"-Xep:UnusedVariable:WARN -Xep:MissingOverride:ERROR -Xep:ReferenceEquality:WARN")
+
+	baseline := result.ModuleForTests("foo", "android_common").MaybeRule("errorproneBaseline")
+	if baseline.Rule != nil {
+		t.Errorf("expected no errorprone baseline rule without UPDATE_ERRORPRONE_BASELINE")
+	}
+}
+
+func TestErrorproneCheckErrors(t *testing.T) {
+	testJavaError(t, `"MissingOverride" is also in errorprone.enabled_checks`, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java"],
+			errorprone: {
+				enabled: true,
+				enabled_checks: ["MissingOverride"],
+				fatal_checks: ["MissingOverride"],
+			},
+		}
+	`)
+
+	android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureAddTextFile("errorprone-baseline.txt", "MissingOverride\nReferenceEquality\n"),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`line 2: "ReferenceEquality" is not in errorprone.fatal_checks, remove it from the baseline`)).
+		RunTestWithBp(t, `
+			java_library {
+				name: "foo",
+				srcs: ["a.java"],
+				errorprone: {
+					enabled: true,
+					fatal_checks: ["MissingOverride"],
+					baseline: "errorprone-baseline.txt",
+				},
+			}
+		`)
+}
+
+func TestErrorproneUpdateBaseline(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureMergeEnv(map[string]string{
+			"UPDATE_ERRORPRONE_BASELINE": "true",
+		}),
+	).RunTestWithBp(t, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java"],
+			errorprone: {
+				fatal_checks: ["MissingOverride"],
+			},
+		}
+
+		java_library {
+			name: "bar",
+			srcs: ["a.java"],
+		}
+	`)
+
+	foo := result.ModuleForTests("foo", "android_common")
+
+	// The baseline is generated by a separate compilation, so the regular build of the module
+	// doesn't depend on UPDATE_ERRORPRONE_BASELINE.
+	javac := foo.Description("javac")
+	android.AssertStringDoesNotContain(t, "javacFlags", javac.Args["javacFlags"], "-Xplugin:ErrorProne")
+
+	baseline := foo.Rule("errorproneBaseline")
+	android.AssertPathRelativeToTopEquals(t, "output",
+		"out/soong/.intermediates/foo/android_common/errorprone/errorprone-baseline.txt", baseline.Output)
+	android.AssertStringDoesContain(t, "javacFlags", baseline.Args["javacFlags"], "-Xep:MissingOverride:WARN")
+	android.AssertStringEquals(t, "checks", "MissingOverride", baseline.Args["checks"])
+
+	if result.ModuleForTests("bar", "android_common").MaybeRule("errorproneBaseline").Rule != nil {
+		t.Errorf("expected no errorprone baseline rule for a module without fatal checks")
+	}
+}
+
+func TestParseErrorproneBaseline(t *testing.T) {
+	checks, err := parseErrorproneBaseline([]byte("# comment\n\nMissingOverride\n  ReferenceEquality\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	android.AssertDeepEquals(t, "checks", map[string]int{"MissingOverride": 3, "ReferenceEquality": 4}, checks)
+
+	_, err = parseErrorproneBaseline([]byte("MissingOverride ReferenceEquality\n"))
+	android.AssertStringEquals(t, "several checks",
+		`line 1: expected a single check, got "MissingOverride ReferenceEquality"`, err.Error())
+}
+
 func TestDataDeviceBinsBuildsDeviceBinary(t *testing.T) {
 	testCases := []struct {
 		dataDeviceBinType  string
@@ -195,7 +195,7 @@ func (b *platformBootclasspathModule) generateClasspathProtoBuildActions(ctx and
 	configuredJars := b.configuredJars(ctx)
 	// ART and platform boot jars must have a corresponding entry in DEX2OATBOOTCLASSPATH
 	classpathJars := configuredJarListToClasspathJars(ctx, configuredJars, BOOTCLASSPATH, DEX2OATBOOTCLASSPATH)
-	b.classpathFragmentBase().generateClasspathProtoBuildActions(ctx, configuredJars, classpathJars)
+	b.classpathFragmentBase().generateClasspathProtoBuildActions(ctx, configuredJars, classpathJars, nil)
 }
 
 func (b *platformBootclasspathModule) configuredJars(ctx android.ModuleContext) android.ConfiguredJarList {
@@ -305,30 +305,38 @@ func (b *platformBootclasspathModule) generateHiddenAPIBuildActions(ctx android.
 	// hiddenAPIComputeMonolithicStubLibModules.
 	input.gatherStubLibInfo(ctx, nil)
 
-	// Use the flag files from this module and all the fragments.
-	input.FlagFilesByCategory = monolithicInfo.FlagsFilesByCategory
-
-	// Generate the monolithic stub-flags.csv file.
-	stubFlags := hiddenAPISingletonPaths(ctx).stubFlags
-	buildRuleToGenerateHiddenAPIStubFlagsFile(ctx, "platform-bootclasspath-monolithic-hiddenapi-stub-flags", "monolithic hidden API stub flags", stubFlags, bootDexJarByModule.bootDexJars(), input, monolithicInfo.StubFlagSubsets)
-
-	// Generate the annotation-flags.csv file from all the module annotations.
-	annotationFlags := android.PathForModuleOut(ctx, "hiddenapi-monolithic", "annotation-flags-from-classes.csv")
-	buildRuleToGenerateAnnotationFlags(ctx, "intermediate hidden API flags", classesJars, stubFlags, annotationFlags)
-
-	// Generate the monolithic hiddenapi-flags.csv file.
-	//
-	// Use annotation flags generated directly from the classes jars as well as annotation flag files
-	// provided by prebuilts.
-	allAnnotationFlagFiles := android.Paths{annotationFlags}
-	allAnnotationFlagFiles = append(allAnnotationFlagFiles, monolithicInfo.AnnotationFlagsPaths...)
-	allFlags := hiddenAPISingletonPaths(ctx).flags
-	buildRuleToGenerateHiddenApiFlags(ctx, "hiddenAPIFlagsFile", "monolithic hidden API flags", allFlags, stubFlags, allAnnotationFlagFiles, monolithicInfo.FlagsFilesByCategory, monolithicInfo.FlagSubsets, android.OptionalPath{})
+	// The stub flags against which the flags of the libraries without fragments are generated.
+	var classesStubFlags android.Path
+	if ctx.Config().ShardedHiddenApiFlags() {
+		classesStubFlags = b.generateShardedHiddenAPIFlags(ctx, input, classpathElements, bootDexJarByModule, monolithicInfo)
+	} else {
+		stubFlags := hiddenAPISingletonPaths(ctx).stubFlags
+		allFlags := hiddenAPISingletonPaths(ctx).flags
+
+		// Use the flag files from this module and all the fragments.
+		input.FlagFilesByCategory = monolithicInfo.FlagsFilesByCategory
+
+		// Generate the monolithic stub-flags.csv file.
+		buildRuleToGenerateHiddenAPIStubFlagsFile(ctx, "platform-bootclasspath-monolithic-hiddenapi-stub-flags", "monolithic hidden API stub flags", stubFlags, bootDexJarByModule.bootDexJars(), input, monolithicInfo.StubFlagSubsets)
+
+		// Generate the annotation-flags.csv file from all the module annotations.
+		annotationFlags := android.PathForModuleOut(ctx, "hiddenapi-monolithic", "annotation-flags-from-classes.csv")
+		buildRuleToGenerateAnnotationFlags(ctx, "intermediate hidden API flags", classesJars, stubFlags, annotationFlags)
+
+		// Generate the monolithic hiddenapi-flags.csv file.
+		//
+		// Use annotation flags generated directly from the classes jars as well as annotation flag files
+		// provided by prebuilts.
+		allAnnotationFlagFiles := android.Paths{annotationFlags}
+		allAnnotationFlagFiles = append(allAnnotationFlagFiles, monolithicInfo.AnnotationFlagsPaths...)
+		buildRuleToGenerateHiddenApiFlags(ctx, "hiddenAPIFlagsFile", "monolithic hidden API flags", allFlags, stubFlags, allAnnotationFlagFiles, monolithicInfo.FlagsFilesByCategory, monolithicInfo.FlagSubsets, android.OptionalPath{})
+		classesStubFlags = stubFlags
+	}
 
 	// Generate an intermediate monolithic hiddenapi-metadata.csv file directly from the annotations
 	// in the source code.
 	intermediateMetadataCSV := android.PathForModuleOut(ctx, "hiddenapi-monolithic", "metadata-from-classes.csv")
-	buildRuleToGenerateMetadata(ctx, "intermediate hidden API metadata", classesJars, stubFlags, intermediateMetadataCSV)
+	buildRuleToGenerateMetadata(ctx, "intermediate hidden API metadata", classesJars, classesStubFlags, intermediateMetadataCSV)
 
 	// Generate the monolithic hiddenapi-metadata.csv file.
 	//
@@ -359,6 +367,72 @@ func (b *platformBootclasspathModule) generateHiddenAPIBuildActions(ctx android.
 	return bootDexJarByModule
 }
 
+// generateShardedHiddenAPIFlags generates the monolithic hiddenapi-stub-flags.txt and
+// hiddenapi-flags.csv files by merging the flags of each shard of the bootclasspath, i.e. of each
+// bootclasspath_fragment, and of the libraries that are not in a fragment. The latter are
+// processed like a fragment that depends on the APIs of all the fragments, with the flag files
+// specified on this module, so a change to the dex jars of a fragment only regenerates the flags
+// of that fragment and the merged files.
+//
+// The rules only update their outputs when their contents change, so they do not cause the rules
+// that use the merged files to rerun when a change to a fragment does not change its flags.
+//
+// It returns the stub flags of the libraries that are not in a fragment.
+func (b *platformBootclasspathModule) generateShardedHiddenAPIFlags(ctx android.ModuleContext, input HiddenAPIFlagInput, classpathElements ClasspathElements, bootDexJars bootDexJarByModule, monolithicInfo MonolithicHiddenAPIInfo) android.Path {
+	// Collect the boot dex jars of the libraries that are not in a fragment.
+	libraryBootDexJars := bootDexJarByModule{}
+	for _, element := range classpathElements {
+		if e, ok := element.(*ClasspathLibraryElement); ok {
+			name := android.RemoveOptionalPrebuiltPrefix(e.Module().Name())
+			if path, ok := bootDexJars[name]; ok {
+				libraryBootDexJars[name] = path
+			}
+		}
+	}
+
+	// The classes of the libraries can only depend on the APIs of the fragments.
+	input.DependencyStubDexJarsByScope.addStubDexJarsByModule(monolithicInfo.StubDexJarsByScope)
+
+	// Use the flag files from this module only, the fragments apply their own flag files.
+	input.extractFlagFilesFromProperties(ctx, &b.properties.HiddenAPIFlagFileProperties)
+
+	stubFlags := android.PathForModuleOut(ctx, "hiddenapi-platform", "stub-flags.csv")
+	buildRuleToGenerateHiddenAPIStubFlagsFile(ctx, "platform-bootclasspath-platform-hiddenapi-stub-flags", "platform hidden API stub flags", stubFlags, libraryBootDexJars.bootDexJars(), input, nil)
+
+	annotationFlags := android.PathForModuleOut(ctx, "hiddenapi-platform", "annotation-flags-from-classes.csv")
+	buildRuleToGenerateAnnotationFlags(ctx, "platform hidden API annotation flags", monolithicInfo.ClassesJars, stubFlags, annotationFlags)
+
+	allFlags := android.PathForModuleOut(ctx, "hiddenapi-platform", "all-flags.csv")
+	buildRuleToGenerateHiddenApiFlags(ctx, "platformHiddenAPIFlagsFile", "platform hidden API flags", allFlags, stubFlags, android.Paths{annotationFlags}, input.FlagFilesByCategory, nil, android.OptionalPath{})
+
+	// Merge the flags of the platform with those of the fragments.
+	allStubFlagsPaths := append(android.Paths{stubFlags}, monolithicInfo.StubFlagsPaths...)
+	allFlagsPaths := append(android.Paths{allFlags}, monolithicInfo.AllFlagsPaths...)
+	for i := range allStubFlagsPaths {
+		if allStubFlagsPaths[i] == nil || allFlagsPaths[i] == nil {
+			ctx.ModuleErrorf("the sharded hidden API flags require the flags of all the bootclasspath_fragment modules")
+			return stubFlags
+		}
+	}
+	buildRuleMergeHiddenAPIFlags(ctx, "sharded hidden API stub flags", allStubFlagsPaths, hiddenAPISingletonPaths(ctx).stubFlags)
+	buildRuleMergeHiddenAPIFlags(ctx, "sharded hidden API flags", allFlagsPaths, hiddenAPISingletonPaths(ctx).flags)
+
+	return stubFlags
+}
+
+// buildRuleMergeHiddenAPIFlags creates a rule to merge the flags of the shards of the
+// bootclasspath, which only updates the output when its contents change.
+func buildRuleMergeHiddenAPIFlags(ctx android.ModuleContext, desc string, inputPaths android.Paths, outputPath android.WritablePath) {
+	tempPath := tempPathForRestat(ctx, outputPath)
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		BuiltTool("merge_flags").
+		FlagWithOutput("--output=", tempPath).
+		Inputs(inputPaths)
+	commitChangeForRestat(rule, tempPath, outputPath)
+	rule.Build(desc, desc)
+}
+
 // createAndProvideMonolithicHiddenAPIInfo creates a MonolithicHiddenAPIInfo and provides it for
 // testing.
 func (b *platformBootclasspathModule) createAndProvideMonolithicHiddenAPIInfo(ctx android.ModuleContext, classpathElements ClasspathElements) MonolithicHiddenAPIInfo {
@@ -412,12 +486,22 @@ func (b *platformBootclasspathModule) generateBootImageBuildActions(ctx android.
 
 	frameworkBootImageConfig := defaultBootImageConfig(ctx)
 	bootFrameworkProfileRule(ctx, frameworkBootImageConfig)
-	b.generateBootImage(ctx, frameworkBootImageName)
-	b.generateBootImage(ctx, mainlineBootImageName)
+	frameworkBootImageFiles := b.generateBootImage(ctx, frameworkBootImageName)
+	mainlineBootImageFiles := b.generateBootImage(ctx, mainlineBootImageName)
 	dumpOatRules(ctx, frameworkBootImageConfig)
+
+	// Make the boot image files available to the dex_bootjars singleton. The profile install rules
+	// were added to the config by the profile rules above so are only ever set by this module.
+	ctx.SetProvider(BootImageInfoProvider, BootImageInfo{
+		Variants:                   bootImageVariantInfos(ctx, append(frameworkBootImageFiles.variants, mainlineBootImageFiles.variants...)),
+		ProfileInstalls:            frameworkBootImageConfig.profileInstalls,
+		ProfileLicenseMetadataFile: frameworkBootImageConfig.profileLicenseMetadataFile,
+	})
 }
 
-func (b *platformBootclasspathModule) generateBootImage(ctx android.ModuleContext, imageName string) {
+// generateBootImage generates the rules to build the named boot image and returns the outputs of
+// its variants.
+func (b *platformBootclasspathModule) generateBootImage(ctx android.ModuleContext, imageName string) bootImageOutputs {
 	imageConfig := genBootImageConfigs(ctx)[imageName]
 
 	modules := b.getModulesForImage(ctx, imageConfig)
@@ -432,7 +516,7 @@ func (b *platformBootclasspathModule) generateBootImage(ctx android.ModuleContex
 	// If dexpreopt of boot image jars should be skipped, generate only a profile.
 	global := dexpreopt.GetGlobalConfig(ctx)
 	if global.DisablePreoptBootImages {
-		return
+		return bootImageOutputs{profile: profile}
 	}
 
 	// Build boot image files for the android variants.
@@ -442,7 +526,10 @@ func (b *platformBootclasspathModule) generateBootImage(ctx android.ModuleContex
 	buildBootImageZipInPredefinedLocation(ctx, imageConfig, androidBootImageFiles.byArch)
 
 	// Build boot image files for the host variants. There are use directly by ART host side tests.
-	buildBootImageVariantsForBuildOs(ctx, imageConfig, profile)
+	hostBootImageFiles := buildBootImageVariantsForBuildOs(ctx, imageConfig, profile)
+
+	androidBootImageFiles.variants = append(androidBootImageFiles.variants, hostBootImageFiles.variants...)
+	return androidBootImageFiles
 }
 
 // Copy apex module dex jars to their predefined locations. They will be used for dexpreopt for apps.
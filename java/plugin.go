@@ -55,6 +55,16 @@ type PluginProperties struct {
 	// This necessitates disabling the turbine optimization on modules that use this plugin, which will reduce
 	// parallelism and cause more recompilation for modules that depend on modules that use this plugin.
 	Generates_api *bool
+
+	// If true, the annotation processor is isolating, i.e. each file that it generates only depends on
+	// the sources of the module and not on the files generated by other annotation processors. An
+	// isolating annotation processor is run by turbine in its own action, separately from the javac
+	// compilation and the other annotation processors of the module, so that its outputs can be
+	// cached independently. Requires processor_class to be set.
+	//
+	// As the generated sources are available to turbine when building the header jar of the module,
+	// an isolating annotation processor that generates API does not disable the turbine optimization.
+	Isolating *bool
 }
 
 type pluginAttributes struct {
@@ -15,7 +15,10 @@
 package java
 
 import (
+	"strings"
 	"testing"
+
+	"android/soong/android"
 )
 
 func TestNoPlugin(t *testing.T) {
@@ -120,3 +123,71 @@ func TestPluginGeneratesApi(t *testing.T) {
 		t.Errorf("foo processor %q != '-processor com.bar'", javac.Args["processor"])
 	}
 }
+
+func TestPluginIsolating(t *testing.T) {
+	ctx, _ := testJava(t, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java"],
+			plugins: ["bar", "baz"],
+		}
+
+		java_plugin {
+			name: "bar",
+			processor_class: "com.bar",
+			generates_api: true,
+			isolating: true,
+			srcs: ["b.java"],
+		}
+
+		java_plugin {
+			name: "baz",
+			processor_class: "com.baz",
+			srcs: ["c.java"],
+		}
+	`)
+
+	buildOS := ctx.Config().BuildOS.String()
+
+	foo := ctx.ModuleForTests("foo", "android_common")
+	javac := foo.Rule("javac")
+	apt := foo.Output("isolated_apt/bar/gen.srcjar")
+
+	// The isolating plugin that generates API does not disable turbine as its generated sources are
+	// compiled into the header jar.
+	headerTurbine := foo.Output("turbine/foo.jar")
+	genSrcJar := apt.Output.String()
+	android.AssertStringDoesContain(t, "turbine srcJars", headerTurbine.Args["srcJars"], genSrcJar)
+	android.AssertStringDoesContain(t, "javac srcJars", javac.Args["srcJars"], genSrcJar)
+
+	bar := ctx.ModuleForTests("bar", buildOS+"_common").Rule("javac").Output.String()
+	baz := ctx.ModuleForTests("baz", buildOS+"_common").Rule("javac").Output.String()
+
+	// The isolating plugin is only run by its own turbine action.
+	android.AssertStringDoesContain(t, "apt turbineFlags", apt.Args["turbineFlags"], "--processors com.bar")
+	android.AssertStringDoesNotContain(t, "apt turbineFlags", apt.Args["turbineFlags"], baz)
+	android.AssertStringEquals(t, "javac processorpath", "-processorpath "+baz, javac.Args["processorpath"])
+	android.AssertStringEquals(t, "javac processor", "-processor com.baz", javac.Args["processor"])
+	android.AssertBoolEquals(t, "javac runs isolating plugin", false, strings.Contains(javac.Args["processorpath"], bar))
+
+	// The resources generated by the isolating plugin are combined into the implementation jar.
+	combined := foo.Output("combined/foo.jar")
+	android.AssertStringListContains(t, "combined inputs", combined.Inputs.Strings(),
+		"out/soong/.intermediates/foo/android_common/isolated_apt/bar/res.jar")
+}
+
+func TestPluginIsolatingRequiresProcessorClass(t *testing.T) {
+	testJavaError(t, `"bar" is isolating but does not set processor_class`, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java"],
+			plugins: ["bar"],
+		}
+
+		java_plugin {
+			name: "bar",
+			isolating: true,
+			srcs: ["b.java"],
+		}
+	`)
+}
@@ -26,6 +26,7 @@ func init() {
 func RegisterRuntimeResourceOverlayBuildComponents(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("runtime_resource_overlay", RuntimeResourceOverlayFactory)
 	ctx.RegisterModuleType("override_runtime_resource_overlay", OverrideRuntimeResourceOverlayModuleFactory)
+	ctx.RegisterSingletonType("rro_conflicts", rroConflictsSingletonFactory)
 }
 
 type RuntimeResourceOverlay struct {
@@ -0,0 +1,99 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"android/soong/android"
+)
+
+func rroConflictsSingletonFactory() android.Singleton {
+	return &rroConflictsSingleton{}
+}
+
+// rroConflictsSingleton checks the runtime resource overlays installed by the product for
+// resources that more than one of them overlays in the same package, in the check-rro-conflicts
+// goal. The overlays are the PRODUCT_PACKAGES and the modules they require. The conflicts are
+// written to rro_conflicts/rro-conflicts.txt with the order in which the overlays are applied, and
+// the static overlays with the same priority that overlay the same resource are reported as
+// warnings. The overlays built by Make aren't visible to Soong and aren't checked.
+type rroConflictsSingleton struct{}
+
+func (s *rroConflictsSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	if len(ctx.Config().ProductPackages()) == 0 {
+		return
+	}
+	byName := make(map[string][]android.Module)
+	ctx.VisitAllModules(func(module android.Module) {
+		byName[ctx.ModuleName(module)] = append(byName[ctx.ModuleName(module)], module)
+	})
+	installed := make(map[string]bool)
+	queue := append([]string(nil), ctx.Config().ProductPackages()...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if installed[name] {
+			continue
+		}
+		installed[name] = true
+		for _, module := range byName[name] {
+			queue = append(queue, module.RequiredModuleNames()...)
+			queue = append(queue, module.HostRequiredModuleNames()...)
+			queue = append(queue, module.TargetRequiredModuleNames()...)
+		}
+	}
+
+	type overlay struct {
+		name  string
+		theme string
+		apk   android.Path
+	}
+	var overlays []overlay
+	ctx.VisitAllModules(func(module android.Module) {
+		if !module.Enabled() || module.IsSkipInstall() {
+			return
+		}
+		switch m := module.(type) {
+		case *RuntimeResourceOverlay:
+			if installed[m.Name()] && m.outputFile != nil {
+				overlays = append(overlays, overlay{m.Name(), m.Theme(), m.outputFile})
+			}
+		case *AutoGeneratedRuntimeResourceOverlay:
+			// The auto-generated RROs are installed with their app.
+			if installed[String(m.properties.Base)] && m.outputFile != nil {
+				overlays = append(overlays, overlay{m.Name(), "", m.outputFile})
+			}
+		}
+	})
+	if len(overlays) < 2 {
+		return
+	}
+
+	report := android.PathForOutput(ctx, "rro_conflicts", "rro-conflicts.txt")
+	stamp := android.PathForOutput(ctx, "rro_conflicts", "rro-conflicts.stamp")
+	builder := android.NewRuleBuilder(pctx, ctx)
+	cmd := builder.Command().BuiltTool("check_rro_conflicts").
+		FlagWithInput("--aapt ", ctx.Config().HostToolPath(ctx, "aapt2")).
+		FlagWithOutput("--report ", report).
+		FlagWithOutput("--output ", stamp)
+	for _, o := range overlays {
+		cmd.FlagWithInput("--overlay "+o.name+"=", o.apk)
+		if o.theme != "" {
+			cmd.Flag("--theme " + o.name + "=" + o.theme)
+		}
+	}
+	builder.Build("rro_conflicts", "Checking the runtime resource overlays for conflicts")
+
+	ctx.Phony("check-rro-conflicts", stamp)
+}
@@ -19,6 +19,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/blueprint/proptools"
+
 	"android/soong/android"
 	"android/soong/shared"
 )
@@ -407,3 +409,172 @@ func TestRuntimeResourceOverlayPartition(t *testing.T) {
 		android.AssertPathRelativeToTopEquals(t, "Install dir is not correct for "+testCase.name, testCase.expectedPath, mod.installDir)
 	}
 }
+
+func TestAutoGeneratedRROs(t *testing.T) {
+	fs := android.MockFS{
+		"foo/res/values/strings.xml":                            nil,
+		"bar/res/values/strings.xml":                            nil,
+		"device/vendor/blah/overlay/foo/res/values/strings.xml": nil,
+		"device/vendor/blah/overlay/bar/res/values/strings.xml": nil,
+	}
+	bp := `
+		android_app {
+			name: "foo",
+			sdk_version: "current",
+			resource_dirs: ["foo/res"],
+		}
+
+		android_app {
+			name: "bar",
+			sdk_version: "current",
+			resource_dirs: ["bar/res"],
+		}
+
+		override_android_app {
+			name: "foo_override",
+			base: "foo",
+			package_name: "com.android.foo.override",
+		}
+	`
+
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		PrepareForTestWithOverlayBuildComponents,
+		android.FixtureModifyConfig(android.SetKatiEnabledForTests),
+		fs.AddToFixture(),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.DeviceResourceOverlays = []string{"device/vendor/blah/overlay"}
+			variables.ProductResourceOverlays = []string{"product/vendor/blah/overlay"}
+			variables.EnforceRROTargets = []string{"foo"}
+			variables.AutoGeneratedRROs = proptools.BoolPtr(true)
+			variables.DeviceRROPartition = proptools.StringPtr("odm")
+			priority := 3
+			variables.DeviceRROPriority = &priority
+		}),
+	).RunTestWithBp(t, bp)
+
+	rro := result.ModuleForTests("foo__auto_generated_rro_odm", "android_common")
+
+	manifest := android.ContentFromFileRuleForTests(t, rro.Output("AndroidManifest.xml.template"))
+	android.AssertStringDoesContain(t, "manifest package", manifest,
+		`package="{package}.auto_generated_rro_odm__"`)
+	android.AssertStringDoesContain(t, "manifest priority", manifest, `android:priority="3"`)
+	android.AssertStringEquals(t, "target package",
+		"out/soong/.intermediates/foo/android_common/package-res.apk",
+		rro.Output("AndroidManifest.xml").Input.String())
+
+	overlayList := android.PathsRelativeToTop(rro.Output("aapt2/overlay.list").Inputs)
+	android.AssertStringListContains(t, "overlays", overlayList,
+		"out/soong/.intermediates/foo__auto_generated_rro_odm/android_common/aapt2/device/vendor/blah/overlay/foo/res/values_strings.arsc.flat")
+
+	aapt2Flags := rro.Output("package-res.apk").Args["flags"]
+	android.AssertStringDoesContain(t, "aapt2 link flags", aapt2Flags,
+		"-I out/soong/.intermediates/foo/android_common/package-res.apk")
+
+	androidMkEntries := android.AndroidMkEntriesForTest(t, result.TestContext, rro.Module())[0]
+	android.AssertStringPathsRelativeToTopEquals(t, "LOCAL_MODULE_PATH", result.Config,
+		[]string{"out/target/product/test_device/odm/overlay"}, androidMkEntries.EntryMap["LOCAL_MODULE_PATH"])
+
+	// foo has no product resource overlays, its product RRO is not built.
+	productRRO := result.ModuleForTests("foo__auto_generated_rro_product", "android_common")
+	if productRRO.MaybeOutput("package-res.apk").Rule != nil {
+		t.Errorf("expected no product RRO without product resource overlays")
+	}
+	if entries := android.AndroidMkEntriesForTest(t, result.TestContext, productRRO.Module()); len(entries) > 0 && !entries[0].Disabled {
+		t.Errorf("expected the product RRO to be disabled in Make")
+	}
+
+	// The app requires its RROs instead of exporting its RRO directories to Make.
+	foo := result.ModuleForTests("foo", "android_common")
+	fooEntries := android.AndroidMkEntriesForTest(t, result.TestContext, foo.Module())[0]
+	android.AssertDeepEquals(t, "LOCAL_REQUIRED_MODULES", []string{"foo__auto_generated_rro_odm"},
+		fooEntries.EntryMap["LOCAL_REQUIRED_MODULES"])
+	android.AssertDeepEquals(t, "LOCAL_SOONG_DEVICE_RRO_DIRS", []string(nil),
+		fooEntries.EntryMap["LOCAL_SOONG_DEVICE_RRO_DIRS"])
+
+	// The RRO of foo targets its package, Make generates the RROs of the override variant.
+	fooOverride := result.ModuleForTests("foo", "android_common_foo_override")
+	fooOverrideEntries := android.AndroidMkEntriesForTest(t, result.TestContext, fooOverride.Module())[0]
+	android.AssertStringListDoesNotContain(t, "LOCAL_REQUIRED_MODULES",
+		fooOverrideEntries.EntryMap["LOCAL_REQUIRED_MODULES"], "foo__auto_generated_rro_odm")
+	android.AssertStringPathsRelativeToTopEquals(t, "LOCAL_SOONG_DEVICE_RRO_DIRS", result.Config,
+		[]string{"device/vendor/blah/overlay/foo/res"}, fooOverrideEntries.EntryMap["LOCAL_SOONG_DEVICE_RRO_DIRS"])
+
+	// RRO is not enforced for bar, its overlays are static.
+	if variants := result.ModuleVariantsForTests("bar__auto_generated_rro_odm"); len(variants) > 0 {
+		t.Errorf("expected no RRO for bar, got %q", variants)
+	}
+}
+
+func TestAutoGeneratedRROsPartitions(t *testing.T) {
+	android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		PrepareForTestWithOverlayBuildComponents,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.DeviceResourceOverlays = []string{"device/vendor/blah/overlay"}
+			variables.EnforceRROTargets = []string{"*"}
+			variables.AutoGeneratedRROs = proptools.BoolPtr(true)
+			variables.DeviceRROPartition = proptools.StringPtr("product")
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`must be in different partitions, got "product" for both`)).
+		RunTestWithBp(t, `
+			android_app {
+				name: "foo",
+				sdk_version: "current",
+			}
+		`)
+}
+
+func TestRROConflictsCheck(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.ProductPackages = []string{"foo", "foo_themed", "bar", "baz"}
+		}),
+	).RunTestWithBp(t, `
+		runtime_resource_overlay {
+			name: "foo",
+			product_specific: true,
+		}
+
+		runtime_resource_overlay {
+			name: "foo_themed",
+			product_specific: true,
+			theme: "faza",
+		}
+
+		runtime_resource_overlay {
+			name: "bar",
+			vendor: true,
+		}
+
+		runtime_resource_overlay {
+			name: "not_installed",
+			product_specific: true,
+		}
+
+		android_app {
+			name: "baz",
+			sdk_version: "current",
+			required: ["baz_overlay"],
+		}
+
+		runtime_resource_overlay {
+			name: "baz_overlay",
+			product_specific: true,
+		}
+	`)
+
+	check := result.SingletonForTests("rro_conflicts").Output("rro_conflicts/rro-conflicts.stamp")
+	android.AssertStringDoesContain(t, "command", check.RuleParams.Command,
+		"--overlay foo=out/soong/.intermediates/foo/android_common/signed/foo.apk")
+	android.AssertStringDoesContain(t, "command", check.RuleParams.Command,
+		"--overlay bar=out/soong/.intermediates/bar/android_common/signed/bar.apk")
+	android.AssertStringDoesContain(t, "command", check.RuleParams.Command,
+		"--overlay baz_overlay=out/soong/.intermediates/baz_overlay/android_common/signed/baz_overlay.apk")
+	android.AssertStringDoesContain(t, "command", check.RuleParams.Command, "--theme foo_themed=faza")
+	android.AssertStringDoesNotContain(t, "command", check.RuleParams.Command, "not_installed")
+	android.AssertStringDoesContain(t, "command", check.RuleParams.Command,
+		"--report out/soong/rro_conflicts/rro-conflicts.txt")
+}
@@ -15,6 +15,8 @@
 package java
 
 import (
+	"path/filepath"
+
 	"android/soong/android"
 	"android/soong/dexpreopt"
 
@@ -31,6 +33,10 @@ func registerSystemserverClasspathBuildComponents(ctx android.RegistrationContex
 	ctx.RegisterModuleType("platform_systemserverclasspath", platformSystemServerClasspathFactory)
 	ctx.RegisterModuleType("systemserverclasspath_fragment", systemServerClasspathFactory)
 	ctx.RegisterModuleType("prebuilt_systemserverclasspath_fragment", prebuiltSystemServerClasspathModuleFactory)
+
+	ctx.PostDepsMutators(func(ctx android.RegisterMutatorsContext) {
+		ctx.TopDown("systemserverclasspath_fragment_profile", systemServerClasspathFragmentProfileMutator).Parallel()
+	})
 }
 
 var SystemServerClasspathFragmentSdkMemberType = &systemServerClasspathFragmentMemberType{
@@ -68,7 +74,7 @@ func (p *platformSystemServerClasspathModule) GenerateAndroidBuildActions(ctx an
 	standaloneClasspathJars := configuredJarListToClasspathJars(ctx, standaloneConfiguredJars, STANDALONE_SYSTEMSERVER_JARS)
 	configuredJars = configuredJars.AppendList(&standaloneConfiguredJars)
 	classpathJars = append(classpathJars, standaloneClasspathJars...)
-	p.classpathFragmentBase().generateClasspathProtoBuildActions(ctx, configuredJars, classpathJars)
+	p.classpathFragmentBase().generateClasspathProtoBuildActions(ctx, configuredJars, classpathJars, nil)
 }
 
 func (p *platformSystemServerClasspathModule) configuredJars(ctx android.ModuleContext) android.ConfiguredJarList {
@@ -106,6 +112,19 @@ type systemServerClasspathFragmentProperties struct {
 	//
 	// The order does not matter.
 	Standalone_contents []string
+
+	Dex_preopt struct {
+		// If set, provides the path, relative to the Android.bp file, to a text listing of the
+		// classes and methods of the contents that are used to guide the optimization, in the same
+		// format as the boot image profile.
+		//
+		// The listing is converted by profman into the binary profile of each of the contents, which
+		// is installed into the APEX next to the jar and makes dexpreopt use the "speed-profile"
+		// compiler filter. A content module that specifies its own dex_preopt.profile uses that
+		// instead. Ignored by prebuilt_systemserverclasspath_fragment, whose contents use the
+		// profiles from the prebuilt APEX.
+		Profile *string
+	}
 }
 
 func systemServerClasspathFactory() android.Module {
@@ -128,7 +147,7 @@ func (s *SystemServerClasspathModule) GenerateAndroidBuildActions(ctx android.Mo
 	standaloneClasspathJars := configuredJarListToClasspathJars(ctx, standaloneConfiguredJars, STANDALONE_SYSTEMSERVER_JARS)
 	configuredJars = configuredJars.AppendList(&standaloneConfiguredJars)
 	classpathJars = append(classpathJars, standaloneClasspathJars...)
-	s.classpathFragmentBase().generateClasspathProtoBuildActions(ctx, configuredJars, classpathJars)
+	s.classpathFragmentBase().generateClasspathProtoBuildActions(ctx, configuredJars, classpathJars, nil)
 
 	// Collect the module directory for IDE info in java/jdeps.go.
 	s.modulePaths = append(s.modulePaths, ctx.ModuleDir())
@@ -221,6 +240,30 @@ func IsSystemServerClasspathFragmentContentDepTag(tag blueprint.DependencyTag) b
 	return tag == systemServerClasspathFragmentContentDepTag
 }
 
+// classpathFragmentProfileUser is implemented by the modules that can be dexpreopted with the
+// profile of the systemserverclasspath_fragment that contains them.
+type classpathFragmentProfileUser interface {
+	setClasspathFragmentProfile(profile string)
+}
+
+var _ classpathFragmentProfileUser = (*dexpreopter)(nil)
+
+// systemServerClasspathFragmentProfileMutator passes the dex_preopt.profile of a
+// systemserverclasspath_fragment to its contents, before they are dexpreopted.
+func systemServerClasspathFragmentProfileMutator(ctx android.TopDownMutatorContext) {
+	s, ok := ctx.Module().(*SystemServerClasspathModule)
+	if !ok || s.properties.Dex_preopt.Profile == nil {
+		return
+	}
+
+	profile := filepath.Join(ctx.ModuleDir(), *s.properties.Dex_preopt.Profile)
+	ctx.VisitDirectDepsWithTag(systemServerClasspathFragmentContentDepTag, func(m android.Module) {
+		if u, ok := m.(classpathFragmentProfileUser); ok {
+			u.setClasspathFragmentProfile(profile)
+		}
+	})
+}
+
 func (s *SystemServerClasspathModule) ComponentDepsMutator(ctx android.BottomUpMutatorContext) {
 	module := ctx.Module()
 	_, isSourceModule := module.(*SystemServerClasspathModule)
@@ -19,6 +19,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/google/blueprint"
 	"github.com/google/blueprint/proptools"
 
 	"android/soong/android"
@@ -49,6 +50,29 @@ type linkerConfigProperties struct {
 	// Installable should be marked as false for APEX configuration to avoid
 	// conflicts of configuration on /system/etc directory.
 	Installable *bool
+
+	// If set to true, the provideLibs and requireLibs that Soong computes from the modules of the
+	// partition the configuration is installed to are added to the configuration, so that they
+	// don't need to be maintained by hand. Only an installable configuration can include them.
+	Include_generated_libs *bool
+}
+
+// LinkerConfigInfo is provided by linker_config modules, so that their hand-maintained entries
+// can be checked against the libraries that the modules of the partition provide and require.
+type LinkerConfigInfo struct {
+	// The source linker configuration .json file.
+	Src android.Path
+
+	// The partition the configuration is installed to, or empty if it is not installable.
+	Partition string
+}
+
+var LinkerConfigInfoProvider = blueprint.NewProvider(LinkerConfigInfo{})
+
+// GeneratedLinkerConfigPath returns the path of the linker configuration .json file with the
+// provideLibs and requireLibs that Soong computes for a partition.
+func GeneratedLinkerConfigPath(ctx android.PathContext, partition string) android.OutputPath {
+	return android.PathForOutput(ctx, "linker_config", partition+".json")
 }
 
 type linkerConfig struct {
@@ -90,16 +114,32 @@ func (l *linkerConfig) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	input := android.PathForModuleSrc(ctx, android.String(l.properties.Src))
 	output := android.PathForModuleOut(ctx, "linker.config.pb").OutputPath
 
-	builder := android.NewRuleBuilder(pctx, ctx)
-	BuildLinkerConfig(ctx, builder, input, nil, output)
-	builder.Build("conv_linker_config", "Generate linker config protobuf "+output.String())
-
 	l.outputFilePath = output
 	l.installDirPath = android.PathForModuleInstall(ctx, "etc")
 	if !proptools.BoolDefault(l.properties.Installable, true) {
 		l.SkipInstall()
 	}
+
+	info := LinkerConfigInfo{Src: input}
+	if !l.IsSkipInstall() && ctx.Device() {
+		info.Partition = l.installDirPath.Partition()
+	}
+
+	inputs := android.Paths{input}
+	if proptools.Bool(l.properties.Include_generated_libs) {
+		if info.Partition == "" {
+			ctx.PropertyErrorf("include_generated_libs", "can only be set for an installable device configuration")
+		} else {
+			inputs = append(inputs, GeneratedLinkerConfigPath(ctx, info.Partition))
+		}
+	}
+
+	builder := android.NewRuleBuilder(pctx, ctx)
+	BuildLinkerConfig(ctx, builder, inputs, nil, output)
+	builder.Build("conv_linker_config", "Generate linker config protobuf "+output.String())
+
 	ctx.InstallFile(l.installDirPath, l.outputFilePath.Base(), l.outputFilePath)
+	ctx.SetProvider(LinkerConfigInfoProvider, info)
 }
 
 type linkerConfigAttributes struct {
@@ -125,14 +165,14 @@ func (l *linkerConfig) ConvertWithBp2build(ctx android.TopDownMutatorContext) {
 }
 
 func BuildLinkerConfig(ctx android.ModuleContext, builder *android.RuleBuilder,
-	input android.Path, otherModules []android.Module, output android.OutputPath) {
+	inputs android.Paths, otherModules []android.Module, output android.OutputPath) {
 
-	// First, convert the input json to protobuf format
+	// First, convert the input json files to protobuf format
 	interimOutput := android.PathForModuleOut(ctx, "temp.pb")
 	builder.Command().
 		BuiltTool("conv_linker_config").
 		Flag("proto").
-		FlagWithInput("-s ", input).
+		FlagWithInputList("-s ", inputs, ":").
 		FlagWithOutput("-o ", interimOutput)
 
 	// Secondly, if there's provideLibs gathered from otherModules, append them
@@ -89,3 +89,17 @@ func TestUninstallableLinkerConfig(t *testing.T) {
 		t.Errorf("LOCAL_UNINSTALLABLE_MODULE is not defined")
 	}
 }
+
+func TestUninstallableLinkerConfigWithGeneratedLibs(t *testing.T) {
+	prepareForLinkerConfigTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`include_generated_libs: can only be set for an installable device configuration`)).
+		RunTestWithBp(t, `
+		linker_config {
+			name: "linker-config-base",
+			src: "linker.config.json",
+			installable: false,
+			include_generated_libs: true,
+		}
+	`)
+}
@@ -0,0 +1,325 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// gen_build_provenance generates the provenance of a dist artifact from the parameters written by
+// Soong: an in-toto statement with a SLSA v1 provenance predicate, signed into a DSSE envelope.
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// The parameters of the provenance of an artifact, as written by Soong.
+type params struct {
+	Name        string            `json:"name"`
+	Subject     string            `json:"subject"`
+	Goals       []string          `json:"goals"`
+	Product     map[string]string `json:"product"`
+	Environment map[string]string `json:"environment"`
+	Rule        string            `json:"rule"`
+	Args        map[string]string `json:"args"`
+	Inputs      []string          `json:"inputs"`
+	Toolchains  map[string]string `json:"toolchains"`
+}
+
+const (
+	statementType = "https://in-toto.io/Statement/v1"
+	predicateType = "https://slsa.dev/provenance/v1"
+	buildType     = "https://source.android.com/docs/setup/build/provenance/v1"
+	builderID     = "https://source.android.com/docs/setup/build/soong"
+	payloadType   = "application/vnd.in-toto+json"
+	digestSha256  = "sha256"
+	pemPrivateKey = "PRIVATE KEY"
+	dssePaePrefix = "DSSEv1"
+)
+
+// The subset of the in-toto statement and of the SLSA provenance written by gen_build_provenance.
+type statement struct {
+	Type          string               `json:"_type"`
+	Subject       []resourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     provenance           `json:"predicate"`
+}
+
+type resourceDescriptor struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+type provenance struct {
+	BuildDefinition buildDefinition `json:"buildDefinition"`
+	RunDetails      runDetails      `json:"runDetails"`
+}
+
+type buildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   externalParameters   `json:"externalParameters"`
+	InternalParameters   internalParameters   `json:"internalParameters"`
+	ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies"`
+}
+
+type externalParameters struct {
+	Product map[string]string `json:"product"`
+	Goals   []string          `json:"goals"`
+}
+
+type internalParameters struct {
+	Environment map[string]string `json:"environment"`
+	Rule        string            `json:"rule"`
+	Args        map[string]string `json:"args,omitempty"`
+}
+
+type runDetails struct {
+	Builder  builder  `json:"builder"`
+	Metadata metadata `json:"metadata"`
+}
+
+type builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type metadata struct {
+	InvocationID string `json:"invocationId,omitempty"`
+}
+
+// The DSSE envelope of the signed statement.
+type envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []signature `json:"signatures"`
+}
+
+type signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+var (
+	outFile         = flag.String("o", "", "output file")
+	keyFile         = flag.String("key", "", "PKCS#8 private key, in PEM or DER, that signs the provenance")
+	buildNumberFile = flag.String("build_number_file", "", "file containing the build number")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: gen_build_provenance -key <key> [-build_number_file <file>] -o <output> <params>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || *outFile == "" || *keyFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := genBuildProvenance(flag.Arg(0), *keyFile, *buildNumberFile, *outFile); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		os.Exit(2)
+	}
+}
+
+func genBuildProvenance(paramsFile, keyFile, buildNumberFile, outFile string) error {
+	buf, err := ioutil.ReadFile(paramsFile)
+	if err != nil {
+		return fmt.Errorf("error reading parameters %q: %w", paramsFile, err)
+	}
+	var p params
+	if err := json.Unmarshal(buf, &p); err != nil {
+		return fmt.Errorf("error parsing parameters %q: %w", paramsFile, err)
+	}
+
+	s, err := statementFor(p, buildNumberFile)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("error marshalling provenance: %w", err)
+	}
+
+	key, err := readKey(keyFile)
+	if err != nil {
+		return err
+	}
+	env, err := sign(payload, key)
+	if err != nil {
+		return fmt.Errorf("error signing provenance with %q: %w", keyFile, err)
+	}
+
+	out, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("error marshalling envelope: %w", err)
+	}
+	if err := ioutil.WriteFile(outFile, append(out, '\n'), 0666); err != nil {
+		return fmt.Errorf("error writing provenance %q: %w", outFile, err)
+	}
+	return nil
+}
+
+func statementFor(p params, buildNumberFile string) (statement, error) {
+	subject, err := digest(p.Subject)
+	if err != nil {
+		return statement{}, err
+	}
+
+	dependencies := []resourceDescriptor{}
+	for _, input := range p.Inputs {
+		d, err := digest(input)
+		if err != nil {
+			return statement{}, err
+		}
+		dependencies = append(dependencies, resourceDescriptor{Name: input, Digest: d})
+	}
+
+	var invocationID string
+	if buildNumberFile != "" {
+		buf, err := ioutil.ReadFile(buildNumberFile)
+		if err != nil {
+			return statement{}, fmt.Errorf("error reading build number %q: %w", buildNumberFile, err)
+		}
+		invocationID = strings.TrimSpace(string(buf))
+	}
+
+	return statement{
+		Type:          statementType,
+		Subject:       []resourceDescriptor{{Name: p.Name, Digest: subject}},
+		PredicateType: predicateType,
+		Predicate: provenance{
+			BuildDefinition: buildDefinition{
+				BuildType: buildType,
+				ExternalParameters: externalParameters{
+					Product: p.Product,
+					Goals:   p.Goals,
+				},
+				InternalParameters: internalParameters{
+					Environment: p.Environment,
+					Rule:        p.Rule,
+					Args:        p.Args,
+				},
+				ResolvedDependencies: dependencies,
+			},
+			RunDetails: runDetails{
+				Builder: builder{
+					ID:      builderID,
+					Version: p.Toolchains,
+				},
+				Metadata: metadata{
+					InvocationID: invocationID,
+				},
+			},
+		},
+	}, nil
+}
+
+// digest returns the sha256 digest of a file, or no digest for a directory.
+func digest(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %q: %w", path, err)
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err != nil {
+		return nil, fmt.Errorf("error reading %q: %w", path, err)
+	} else if info.IsDir() {
+		return nil, nil
+	}
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, file); err != nil {
+		return nil, fmt.Errorf("error reading %q: %w", path, err)
+	}
+	return map[string]string{digestSha256: hex.EncodeToString(sum.Sum(nil))}, nil
+}
+
+// readKey reads a PKCS#8 private key, either PEM encoded or DER encoded like the .pk8 keys of the
+// certificates of the apps.
+func readKey(keyFile string) (crypto.Signer, error) {
+	buf, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key %q: %w", keyFile, err)
+	}
+	if block, _ := pem.Decode(buf); block != nil {
+		if block.Type != pemPrivateKey {
+			return nil, fmt.Errorf("error parsing key %q: unexpected PEM block %q", keyFile, block.Type)
+		}
+		buf = block.Bytes
+	}
+	key, err := x509.ParsePKCS8PrivateKey(buf)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing key %q: %w", keyFile, err)
+	}
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey, ed25519.PrivateKey, *rsa.PrivateKey:
+		return key.(crypto.Signer), nil
+	default:
+		return nil, fmt.Errorf("error parsing key %q: unsupported key type %T", keyFile, key)
+	}
+}
+
+// pae returns the DSSE pre-authentication encoding of a payload, which is what is signed.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("%s %d %s %d %s", dssePaePrefix, len(payloadType), payloadType, len(payload), payload))
+}
+
+// sign signs a payload into a DSSE envelope, with the sha256 digest of the public key as the key id.
+func sign(payload []byte, key crypto.Signer) (envelope, error) {
+	message := pae(payloadType, payload)
+
+	var sig []byte
+	var err error
+	switch key.(type) {
+	case ed25519.PrivateKey:
+		sig, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+	default:
+		// ECDSA and RSA PKCS #1 v1.5 signatures of the sha256 digest.
+		digest := sha256.Sum256(message)
+		sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return envelope{}, err
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return envelope{}, err
+	}
+	keyID := sha256.Sum256(publicKey)
+
+	return envelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []signature{{
+			KeyID: hex.EncodeToString(keyID[:]),
+			Sig:   base64.StdEncoding.EncodeToString(sig),
+		}},
+	}, nil
+}
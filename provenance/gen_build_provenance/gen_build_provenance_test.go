@@ -0,0 +1,178 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenBuildProvenance(t *testing.T) {
+	testCases := []struct {
+		name      string
+		key       func() crypto.Signer
+		pemEncode bool
+	}{
+		{
+			name: "ed25519 pem",
+			key: func() crypto.Signer {
+				_, key, _ := ed25519.GenerateKey(rand.Reader)
+				return key
+			},
+			pemEncode: true,
+		},
+		{
+			name: "ecdsa der",
+			key: func() crypto.Signer {
+				key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				return key
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			subject := filepath.Join(dir, "foo.zip")
+			input := filepath.Join(dir, "foo.txt")
+			writeFile(t, subject, []byte("zip"))
+			writeFile(t, input, []byte("foo"))
+			writeFile(t, filepath.Join(dir, "build_number.txt"), []byte("1234\n"))
+
+			key := tc.key()
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.pemEncode {
+				der = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+			}
+			writeFile(t, filepath.Join(dir, "key.pk8"), der)
+
+			p := params{
+				Name:        "foo.zip",
+				Subject:     subject,
+				Goals:       []string{"droidcore"},
+				Product:     map[string]string{"product": "test_product"},
+				Environment: map[string]string{"TARGET_BUILD_VARIANT": "userdebug"},
+				Rule:        "android/soong/android.Cp",
+				Inputs:      []string{input},
+				Toolchains:  map[string]string{"clang": "clang-r498229b"},
+			}
+			buf, _ := json.Marshal(p)
+			writeFile(t, filepath.Join(dir, "params.json"), buf)
+
+			out := filepath.Join(dir, "foo.zip.intoto.jsonl")
+			err = genBuildProvenance(filepath.Join(dir, "params.json"), filepath.Join(dir, "key.pk8"),
+				filepath.Join(dir, "build_number.txt"), out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf, err = ioutil.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var env envelope
+			if err := json.Unmarshal(buf, &env); err != nil {
+				t.Fatal(err)
+			}
+			if env.PayloadType != payloadType {
+				t.Errorf("expected payload type %q, got %q", payloadType, env.PayloadType)
+			}
+			payload, err := base64.StdEncoding.DecodeString(env.Payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(env.Signatures) != 1 {
+				t.Fatalf("expected 1 signature, got %d", len(env.Signatures))
+			}
+			sig, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			message := pae(payloadType, payload)
+			switch public := key.Public().(type) {
+			case ed25519.PublicKey:
+				if !ed25519.Verify(public, message, sig) {
+					t.Errorf("invalid signature")
+				}
+			case *ecdsa.PublicKey:
+				digest := sha256.Sum256(message)
+				if !ecdsa.VerifyASN1(public, digest[:], sig) {
+					t.Errorf("invalid signature")
+				}
+			}
+
+			var s statement
+			if err := json.Unmarshal(payload, &s); err != nil {
+				t.Fatal(err)
+			}
+			expected := []resourceDescriptor{{
+				Name:   "foo.zip",
+				Digest: map[string]string{"sha256": "4a70fe9aa6436e02c2dea340fbd1e352e4ef2d8ce6ca52ad25d4b95471fc8bf2"},
+			}}
+			if !reflect.DeepEqual(s.Subject, expected) {
+				t.Errorf("expected subject %v, got %v", expected, s.Subject)
+			}
+			expected = []resourceDescriptor{{
+				Name:   input,
+				Digest: map[string]string{"sha256": "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+			}}
+			if !reflect.DeepEqual(s.Predicate.BuildDefinition.ResolvedDependencies, expected) {
+				t.Errorf("expected dependencies %v, got %v", expected, s.Predicate.BuildDefinition.ResolvedDependencies)
+			}
+			if s.Predicate.RunDetails.Metadata.InvocationID != "1234" {
+				t.Errorf("expected invocation id 1234, got %q", s.Predicate.RunDetails.Metadata.InvocationID)
+			}
+			if g, w := s.Predicate.RunDetails.Builder.Version, p.Toolchains; !reflect.DeepEqual(g, w) {
+				t.Errorf("expected builder version %v, got %v", w, g)
+			}
+		})
+	}
+}
+
+func TestGenBuildProvenanceMissingInput(t *testing.T) {
+	dir := t.TempDir()
+	subject := filepath.Join(dir, "foo.zip")
+	writeFile(t, subject, nil)
+
+	_, err := statementFor(params{Name: "foo.zip", Subject: subject, Inputs: []string{filepath.Join(dir, "missing")}}, "")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing input error, got %v", err)
+	}
+}
@@ -30,6 +30,7 @@ func init() {
 
 func registerPythonBinaryComponents(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("python_binary_host", PythonBinaryHostFactory)
+	ctx.RegisterModuleType("python_binary", PythonBinaryFactory)
 }
 
 type BinaryProperties struct {
@@ -90,6 +91,13 @@ func PythonBinaryHostFactory() android.Module {
 	return NewBinary(android.HostSupported).init()
 }
 
+// python_binary builds a Python binary for the device, and for the host if host_supported is set.
+// There is no Python interpreter on the device, so the device variants are always built with the
+// embedded launcher and the Python standard library packaged into the binary.
+func PythonBinaryFactory() android.Module {
+	return NewBinary(android.HostAndDeviceSupported).init()
+}
+
 func (p *PythonBinaryModule) init() android.Module {
 	p.AddProperties(&p.properties, &p.protoProperties)
 	p.AddProperties(&p.binaryProperties)
@@ -183,8 +191,9 @@ func (p *PythonBinaryModule) DepsMutator(ctx android.BottomUpMutatorContext) {
 // HostToolPath returns a path if appropriate such that this module can be used as a host tool,
 // fulfilling the android.HostToolProvider interface.
 func (p *PythonBinaryModule) HostToolPath() android.OptionalPath {
-	// TODO: This should only be set when building host binaries -- tests built for device would be
-	// setting this incorrectly.
+	if p.Os().Class != android.Host {
+		return android.OptionalPath{}
+	}
 	return android.OptionalPathForPath(p.installedDest)
 }
 
@@ -199,7 +208,8 @@ func (p *PythonBinaryModule) OutputFiles(tag string) (android.Paths, error) {
 }
 
 func (p *PythonBinaryModule) isEmbeddedLauncherEnabled() bool {
-	return Bool(p.properties.Embedded_launcher)
+	// The device has no Python interpreter to run the binary with.
+	return Bool(p.properties.Embedded_launcher) || p.Os().Class == android.Device
 }
 
 func (b *PythonBinaryModule) autorun() bool {
@@ -81,6 +81,13 @@ var (
 			"build/soong/python/scripts/precompile_python.py",
 		},
 	}, "stdlibZip", "launcher", "ldLibraryPath")
+
+	typecheck = pctx.AndroidStaticRule("typecheckPython", blueprint.RuleParams{
+		Command:        `build/soong/python/scripts/typecheck_python.py --mypy-zip $mypyZip $flags --out $out @$out.rsp`,
+		CommandDeps:    []string{"build/soong/python/scripts/typecheck_python.py", "$mypyZip"},
+		Rspfile:        "$out.rsp",
+		RspfileContent: "$srcs",
+	}, "flags", "srcs")
 )
 
 func init() {
@@ -89,6 +96,8 @@ func init() {
 
 	pctx.HostBinToolVariable("parCmd", "soong_zip")
 	pctx.HostBinToolVariable("mergeParCmd", "merge_zips")
+	// The prebuilt mypy used by typecheck, rather than one installed on the host.
+	pctx.SourcePathVariable("mypyZip", "prebuilts/build-tools/common/py3-mypy/mypy.zip")
 }
 
 func registerBuildActionForParFile(ctx android.ModuleContext, embeddedLauncher bool,
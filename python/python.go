@@ -96,6 +96,15 @@ type BaseProperties struct {
 	// list of the Python libraries compatible both with Python2 and Python3.
 	Libs []string `android:"arch_variant"`
 
+	// whether to check the types of the sources with mypy as a validation of the module. The
+	// sources of the Python libraries the module depends on, including .pyi stub files in their
+	// data, are used to resolve imports.
+	Typecheck *bool
+
+	// whether type errors found by typecheck fail the build, defaults to true. If false, they are
+	// only reported as warnings.
+	Typecheck_blocking *bool
+
 	Version struct {
 		// Python2-specific properties, including whether Python2 is supported for this module
 		// and version-specific sources, exclusions and dependencies.
@@ -434,8 +443,13 @@ func (p *PythonLibraryModule) GenerateAndroidBuildActions(ctx android.ModuleCont
 	// generate src:destination path mappings for this module
 	p.genModulePathMappings(ctx, pkgPath, expandedSrcs, expandedData)
 
+	var validations android.Paths
+	if Bool(p.properties.Typecheck) {
+		validations = append(validations, p.typecheckSrcs(ctx))
+	}
+
 	// generate the zipfile of all source and data files
-	p.srcsZip = p.createSrcsZip(ctx, pkgPath)
+	p.srcsZip = p.createSrcsZip(ctx, pkgPath, validations)
 	p.precompiledSrcsZip = p.precompileSrcs(ctx)
 }
 
@@ -489,8 +503,52 @@ func (p *PythonLibraryModule) genModulePathMappings(ctx android.ModuleContext, p
 	}
 }
 
+// typecheckSrcs registers a build action to check the types of the current module's sources and
+// returns the path of its stamp file.
+func (p *PythonLibraryModule) typecheckSrcs(ctx android.ModuleContext) android.Path {
+	var depZips android.Paths
+	ctx.WalkDeps(func(child, parent android.Module) bool {
+		if ctx.OtherModuleDependencyTag(child) != pythonLibTag {
+			return false
+		}
+		if dep, ok := child.(pythonDependency); ok && dep.getSrcsZip() != nil {
+			depZips = append(depZips, dep.getSrcsZip())
+		}
+		return true
+	})
+	depZips = android.FirstUniquePaths(depZips)
+
+	var srcs []string
+	var inputs android.Paths
+	for _, path := range p.srcsPathMappings {
+		if path.src.Ext() == pyExt {
+			srcs = append(srcs, path.dest+"="+path.src.String())
+			inputs = append(inputs, path.src)
+		}
+	}
+
+	flags := android.JoinWithPrefix(depZips.Strings(), "--dep-zip ")
+	if !BoolDefault(p.properties.Typecheck_blocking, true) {
+		flags += " --warn-only"
+	}
+
+	stamp := android.PathForModuleOut(ctx, "typecheck.stamp")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        typecheck,
+		Description: "typecheck " + ctx.ModuleName(),
+		Output:      stamp,
+		Inputs:      inputs,
+		Implicits:   depZips,
+		Args: map[string]string{
+			"flags": flags,
+			"srcs":  strings.Join(srcs, " "),
+		},
+	})
+	return stamp
+}
+
 // createSrcsZip registers build actions to zip current module's sources and data.
-func (p *PythonLibraryModule) createSrcsZip(ctx android.ModuleContext, pkgPath string) android.Path {
+func (p *PythonLibraryModule) createSrcsZip(ctx android.ModuleContext, pkgPath string, validations android.Paths) android.Path {
 	relativeRootMap := make(map[string]android.Paths)
 	var protoSrcs android.Paths
 	addPathMapping := func(path pathMapping) {
@@ -563,7 +621,8 @@ func (p *PythonLibraryModule) createSrcsZip(ctx android.ModuleContext, pkgPath s
 			Description: "python library archive",
 			Output:      origSrcsZip,
 			// as zip rule does not use $in, there is no real need to distinguish between Inputs and Implicits
-			Implicits: paths,
+			Implicits:   paths,
+			Validations: validations,
 			Args: map[string]string{
 				"args": strings.Join(parArgs, " "),
 			},
@@ -580,6 +639,7 @@ func (p *PythonLibraryModule) createSrcsZip(ctx android.ModuleContext, pkgPath s
 			Description: "combine python library archive",
 			Output:      combinedSrcsZip,
 			Inputs:      zips,
+			Validations: validations,
 		})
 		return combinedSrcsZip
 	}
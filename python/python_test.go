@@ -360,6 +360,88 @@ cc_binary {
 	}
 }
 
+func TestPythonBinaryDevice(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		android.PrepareForTestWithDefaults,
+		android.PrepareForTestWithArchMutator,
+		android.PrepareForTestWithAllowMissingDependencies,
+		cc.PrepareForTestWithCcDefaultModules,
+		PrepareForTestWithPythonBuildComponents,
+		android.FixtureAddFile("dir/bin.py", nil),
+	).RunTestWithBp(t, `
+		python_library {
+			name: "py3-stdlib",
+			host_supported: true,
+		}
+		cc_binary {
+			name: "py3-launcher-autorun",
+			host_supported: true,
+		}
+		python_binary {
+			name: "bin",
+			host_supported: true,
+			srcs: ["dir/bin.py"],
+			main: "dir/bin.py",
+		}
+	`)
+
+	device := result.ModuleForTests("bin", "android_arm64_armv8-a_PY3")
+	device.Rule("embeddedPar")
+	deviceBin := device.Module().(*PythonBinaryModule)
+	android.AssertPathRelativeToTopEquals(t, "device install path",
+		"out/soong/target/product/test_device/system/bin/bin", deviceBin.installedDest)
+	android.AssertBoolEquals(t, "device host tool path", false, deviceBin.HostToolPath().Valid())
+
+	host := result.ModuleForTests("bin", result.Config.BuildOSTarget.String()+"_PY3")
+	host.Rule("hostPar")
+	android.AssertBoolEquals(t, "host host tool path", true,
+		host.Module().(*PythonBinaryModule).HostToolPath().Valid())
+}
+
+func TestPythonTypecheck(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		android.PrepareForTestWithDefaults,
+		android.PrepareForTestWithArchMutator,
+		android.PrepareForTestWithAllowMissingDependencies,
+		cc.PrepareForTestWithCcDefaultModules,
+		PrepareForTestWithPythonBuildComponents,
+		android.FixtureAddFile("dir/lib.py", nil),
+		android.FixtureAddFile("dir/lib.pyi", nil),
+		android.FixtureAddFile("dir/bin.py", nil),
+	).RunTestWithBp(t, `
+		python_library_host {
+			name: "lib",
+			srcs: ["dir/lib.py"],
+			data: ["dir/lib.pyi"],
+		}
+		python_binary_host {
+			name: "bin",
+			srcs: ["dir/bin.py"],
+			main: "dir/bin.py",
+			libs: ["lib"],
+			typecheck: true,
+			typecheck_blocking: false,
+		}
+	`)
+
+	bin := result.ModuleForTests("bin", result.Config.BuildOSTarget.String()+"_PY3")
+	typecheck := bin.Rule("typecheckPython")
+	android.AssertStringEquals(t, "srcs", "dir/bin.py=dir/bin.py", typecheck.Args["srcs"])
+	android.AssertStringDoesContain(t, "flags", typecheck.Args["flags"],
+		"--dep-zip out/soong/.intermediates/lib/"+result.Config.BuildOSTarget.String()+"_PY3/lib.py.srcszip")
+	android.AssertStringDoesContain(t, "flags", typecheck.Args["flags"], "--warn-only")
+
+	srcsZip := bin.Output("bin.py.srcszip")
+	android.AssertPathsRelativeToTopEquals(t, "validations",
+		[]string{"out/soong/.intermediates/bin/" + result.Config.BuildOSTarget.String() + "_PY3/typecheck.stamp"},
+		srcsZip.Validations)
+
+	lib := result.ModuleForTests("lib", result.Config.BuildOSTarget.String()+"_PY3")
+	if rule := lib.MaybeRule("typecheckPython"); rule.Rule != nil {
+		t.Errorf("expected no typecheck for lib")
+	}
+}
+
 func expectModule(t *testing.T, ctx *android.TestContext, name, variant, expectedSrcsZip string, expectedPyRunfiles []string) {
 	module := ctx.ModuleForTests(name, variant)
 
@@ -0,0 +1,104 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Checks the types of the sources of a python module with mypy.
+
+The sources are staged at their paths within the python archive, and the
+source zips of the python_library dependencies, which may contain .pyi stub
+files, are extracted next to them so that the imports can be resolved. mypy is
+imported from the prebuilt zip given with --mypy-zip rather than from the host.
+"""
+
+import argparse
+import os
+import shutil
+import sys
+import tempfile
+import zipfile
+
+
+def parse_args():
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument('--mypy-zip', required=True,
+                        help='Path of the prebuilt zip of mypy.')
+    parser.add_argument('--out', required=True,
+                        help='Path of the stamp file to write on success.')
+    parser.add_argument('--dep-zip', action='append', default=[],
+                        help='Source zip of a python_library dependency.')
+    parser.add_argument('--warn-only', action='store_true',
+                        help='Report type errors without failing.')
+    parser.add_argument('srcs', nargs='*', metavar='DEST=SRC',
+                        help='A source of the module and its path in the archive.')
+    return parser.parse_args()
+
+
+def import_mypy(mypy_zip):
+    """Imports the mypy API from the prebuilt zip."""
+    sys.path.insert(0, mypy_zip)
+    try:
+        from mypy import api  # pylint: disable=import-outside-toplevel
+    except ImportError as e:
+        sys.exit('error: mypy unavailable, could not import it from %s: %s' %
+                 (mypy_zip, e))
+    # Don't fall back to a mypy installed on the host.
+    if not os.path.abspath(api.__file__).startswith(
+            os.path.abspath(mypy_zip) + os.sep):
+        sys.exit('error: mypy unavailable, %s does not contain it' % mypy_zip)
+    return api
+
+
+def main():
+    args = parse_args()
+    api = import_mypy(args.mypy_zip)
+
+    root = tempfile.mkdtemp(prefix='Soong_typecheck_')
+    try:
+        for dep_zip in args.dep_zip:
+            with zipfile.ZipFile(dep_zip) as z:
+                z.extractall(root)
+
+        srcs = []
+        for src in args.srcs:
+            dest, path = src.split('=', 1)
+            staged = os.path.join(root, dest)
+            os.makedirs(os.path.dirname(staged), exist_ok=True)
+            shutil.copyfile(path, staged)
+            srcs.append(dest)
+
+        cwd = os.getcwd()
+        os.environ['MYPYPATH'] = root
+        os.chdir(root)
+        try:
+            stdout, stderr, status = api.run(
+                ['--no-error-summary', '--namespace-packages',
+                 '--explicit-package-bases', '--cache-dir=' + os.devnull] +
+                srcs)
+        finally:
+            os.chdir(cwd)
+    finally:
+        shutil.rmtree(root, ignore_errors=True)
+
+    if status != 0:
+        sys.stderr.write(stdout + stderr)
+        if not args.warn_only:
+            sys.exit('error: type errors found, see above')
+        print('warning: type errors found, see above', file=sys.stderr)
+
+    with open(args.out, 'w'):
+        pass
+
+
+if __name__ == '__main__':
+    main()
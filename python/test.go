@@ -66,6 +66,8 @@ type TestProperties struct {
 
 	// Test options.
 	Test_options TestOptions
+
+	android.RunAtBuildProperties
 }
 
 type TestOptions struct {
@@ -147,8 +149,6 @@ func (p *PythonTestModule) GenerateAndroidBuildActions(ctx android.ModuleContext
 		panic(fmt.Errorf("unknown python test runner '%s', should be 'tradefed' or 'mobly'", runner))
 	}
 
-	p.installedDest = ctx.InstallFile(installDir(ctx, "nativetest", "nativetest64", ctx.ModuleName()), p.installSource.Base(), p.installSource)
-
 	for _, dataSrcPath := range android.PathsForModuleSrc(ctx, p.testProperties.Data) {
 		p.data = append(p.data, android.DataPath{SrcPath: dataSrcPath})
 	}
@@ -159,6 +159,24 @@ func (p *PythonTestModule) GenerateAndroidBuildActions(ctx android.ModuleContext
 			p.data = append(p.data, android.DataPath{SrcPath: javaDataSrcPath})
 		}
 	}
+
+	var dataSrcPaths android.Paths
+	for _, d := range p.data {
+		dataSrcPaths = append(dataSrcPaths, d.SrcPath)
+	}
+	test := p.installSource
+	p.installSource = android.RunTestAtBuild(ctx, p.testProperties.RunAtBuildProperties, test,
+		func(cmd *android.RuleBuilderCommand) {
+			cmd.Input(test)
+		}, dataSrcPaths, nil)
+
+	p.installedDest = ctx.InstallFile(installDir(ctx, "nativetest", "nativetest64", ctx.ModuleName()), p.installSource.Base(), p.installSource)
+
+	ctx.SetProvider(android.TestSuiteInfoProvider, android.TestSuiteInfo{
+		Files: append([]android.TestSuiteFile{{Src: p.installSource, Rel: p.installSource.Base()}},
+			android.TestSuiteFilesForData(p.data)...),
+		Config: p.testConfig,
+	})
 }
 
 func (p *PythonTestModule) AndroidMkEntries() []android.AndroidMkEntries {
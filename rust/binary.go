@@ -112,6 +112,8 @@ func (binary *binaryDecorator) compilerDeps(ctx DepsContext, deps Deps) Deps {
 			deps.CrtBegin = []string{"libc_musl_crtbegin_dynamic"}
 		}
 		deps.CrtEnd = []string{"libc_musl_crtend"}
+	} else if ctx.Os() == android.Windows {
+		deps = windowsDeps(deps)
 	}
 
 	return deps
@@ -415,6 +415,13 @@ func muslDeps(ctx DepsContext, deps Deps, static bool) Deps {
 	return deps
 }
 
+func windowsDeps(deps Deps) Deps {
+	// Like cc, link libwinpthread statically so the binaries don't need libwinpthread-1.dll at
+	// runtime.
+	deps.StaticLibs = append(deps.StaticLibs, "libwinpthread")
+	return deps
+}
+
 func (compiler *baseCompiler) crateName() string {
 	return compiler.Properties.Crate_name
 }
@@ -98,6 +98,7 @@ func init() {
 	})
 
 	pctx.VariableFunc("RustVersion", getRustVersionPctx)
+	android.RegisterBuildProvenanceToolchain("rustc", GetRustVersion)
 
 	pctx.StaticVariable("RustPath", "${RustBase}/${HostPrebuiltTag}/${RustVersion}")
 	pctx.StaticVariable("RustBin", "${RustPath}/bin")
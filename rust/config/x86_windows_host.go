@@ -0,0 +1,133 @@
+// Copyright 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+
+	"android/soong/android"
+)
+
+var (
+	WindowsRustFlags     = []string{}
+	WindowsRustLinkFlags = []string{
+		"-B${cc_config.ClangBin}",
+		"-fuse-ld=lld",
+		"--sysroot ${cc_config.WindowsGccRoot}/${cc_config.WindowsGccTriple}",
+	}
+	windowsX86Rustflags   = []string{}
+	windowsX86Linkflags   = []string{"-target i686-windows-gnu"}
+	windowsX8664Rustflags = []string{}
+	windowsX8664Linkflags = []string{"-target x86_64-pc-windows-gnu"}
+)
+
+func init() {
+	registerToolchainFactory(android.Windows, android.X86_64, windowsX8664ToolchainFactory)
+	registerToolchainFactory(android.Windows, android.X86, windowsX86ToolchainFactory)
+
+	pctx.StaticVariable("WindowsToolchainRustFlags", strings.Join(WindowsRustFlags, " "))
+	pctx.StaticVariable("WindowsToolchainLinkFlags", strings.Join(WindowsRustLinkFlags, " "))
+	pctx.StaticVariable("WindowsToolchainX86RustFlags", strings.Join(windowsX86Rustflags, " "))
+	pctx.StaticVariable("WindowsToolchainX86LinkFlags", strings.Join(windowsX86Linkflags, " "))
+	pctx.StaticVariable("WindowsToolchainX8664RustFlags", strings.Join(windowsX8664Rustflags, " "))
+	pctx.StaticVariable("WindowsToolchainX8664LinkFlags", strings.Join(windowsX8664Linkflags, " "))
+}
+
+type toolchainWindows struct{}
+
+type toolchainWindowsX86 struct {
+	toolchain32Bit
+	toolchainWindows
+}
+
+type toolchainWindowsX8664 struct {
+	toolchain64Bit
+	toolchainWindows
+}
+
+func (toolchainWindowsX86) Supported() bool {
+	return true
+}
+
+func (toolchainWindowsX8664) Supported() bool {
+	return true
+}
+
+func (toolchainWindowsX86) Bionic() bool {
+	return false
+}
+
+func (toolchainWindowsX8664) Bionic() bool {
+	return false
+}
+
+func (t *toolchainWindowsX86) Name() string {
+	return "x86"
+}
+
+func (t *toolchainWindowsX8664) Name() string {
+	return "x86_64"
+}
+
+func (t *toolchainWindowsX86) RustTriple() string {
+	return "i686-pc-windows-gnu"
+}
+
+func (t *toolchainWindowsX8664) RustTriple() string {
+	return "x86_64-pc-windows-gnu"
+}
+
+func (toolchainWindows) ExecutableSuffix() string {
+	return ".exe"
+}
+
+func (toolchainWindows) SharedLibSuffix() string {
+	return ".dll"
+}
+
+func (toolchainWindows) DylibSuffix() string {
+	return ".rustlib.dll"
+}
+
+func (t *toolchainWindowsX86) ToolchainLinkFlags() string {
+	// Prepend the lld flags from cc_config so we stay in sync with cc
+	return "${cc_config.WindowsLldflags} ${cc_config.WindowsX86Lldflags} " +
+		"${config.WindowsToolchainLinkFlags} ${config.WindowsToolchainX86LinkFlags}"
+}
+
+func (t *toolchainWindowsX86) ToolchainRustFlags() string {
+	return "${config.WindowsToolchainRustFlags} ${config.WindowsToolchainX86RustFlags}"
+}
+
+func (t *toolchainWindowsX8664) ToolchainLinkFlags() string {
+	// Prepend the lld flags from cc_config so we stay in sync with cc
+	return "${cc_config.WindowsLldflags} ${cc_config.WindowsX8664Lldflags} " +
+		"${config.WindowsToolchainLinkFlags} ${config.WindowsToolchainX8664LinkFlags}"
+}
+
+func (t *toolchainWindowsX8664) ToolchainRustFlags() string {
+	return "${config.WindowsToolchainRustFlags} ${config.WindowsToolchainX8664RustFlags}"
+}
+
+func windowsX86ToolchainFactory(arch android.Arch) Toolchain {
+	return toolchainWindowsX86Singleton
+}
+
+func windowsX8664ToolchainFactory(arch android.Arch) Toolchain {
+	return toolchainWindowsX8664Singleton
+}
+
+var toolchainWindowsX86Singleton Toolchain = &toolchainWindowsX86{}
+var toolchainWindowsX8664Singleton Toolchain = &toolchainWindowsX8664{}
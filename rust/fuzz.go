@@ -45,17 +45,25 @@ func RustFuzzFactory() android.Module {
 
 func NewRustFuzz(hod android.HostOrDeviceSupported) (*Module, *fuzzDecorator) {
 	module, binary := NewRustBinary(hod)
-	fuzz := &fuzzDecorator{
+	fuzzer := &fuzzDecorator{
 		binaryDecorator: binary,
 	}
 
 	// Change the defaults for the binaryDecorator's baseCompiler
-	fuzz.binaryDecorator.baseCompiler.dir = "fuzz"
-	fuzz.binaryDecorator.baseCompiler.dir64 = "fuzz"
-	fuzz.binaryDecorator.baseCompiler.location = InstallInData
+	fuzzer.binaryDecorator.baseCompiler.dir = "fuzz"
+	fuzzer.binaryDecorator.baseCompiler.dir64 = "fuzz"
+	fuzzer.binaryDecorator.baseCompiler.location = InstallInData
 	module.sanitize.SetSanitizer(cc.Fuzzer, true)
-	module.compiler = fuzz
-	return module, fuzz
+	module.compiler = fuzzer
+
+	android.AddLoadHook(module, func(ctx android.LoadHookContext) {
+		targetFramework := fuzz.GetFramework(ctx, fuzz.Rust)
+		if !fuzz.IsValidFrameworkForModule(targetFramework, fuzz.Rust, fuzzer.fuzzPackagedModule.FuzzProperties.Fuzzing_frameworks) {
+			ctx.Module().Disable()
+		}
+	})
+
+	return module, fuzzer
 }
 
 func (fuzzer *fuzzDecorator) compilerFlags(ctx ModuleContext, flags Flags) Flags {
@@ -105,28 +113,30 @@ func (fuzzer *fuzzDecorator) autoDep(ctx android.BottomUpMutatorContext) autoDep
 	return rlibAutoDep
 }
 
-func (fuzz *fuzzDecorator) install(ctx ModuleContext) {
-	fuzz.binaryDecorator.baseCompiler.dir = filepath.Join(
+func (fuzzer *fuzzDecorator) install(ctx ModuleContext) {
+	fuzzer.binaryDecorator.baseCompiler.dir = filepath.Join(
 		"fuzz", ctx.Target().Arch.ArchType.String(), ctx.ModuleName())
-	fuzz.binaryDecorator.baseCompiler.dir64 = filepath.Join(
+	fuzzer.binaryDecorator.baseCompiler.dir64 = filepath.Join(
 		"fuzz", ctx.Target().Arch.ArchType.String(), ctx.ModuleName())
-	fuzz.binaryDecorator.baseCompiler.install(ctx)
+	fuzzer.binaryDecorator.baseCompiler.install(ctx)
 
-	fuzz.fuzzPackagedModule = cc.PackageFuzzModule(ctx, fuzz.fuzzPackagedModule, pctx)
+	fuzzer.fuzzPackagedModule = cc.PackageFuzzModule(ctx, fuzzer.fuzzPackagedModule, pctx)
+	fuzzer.fuzzPackagedModule.Framework = fuzz.LibFuzzer
+	fuzzer.fuzzPackagedModule.Coverage = ctx.RustModule().coverage.Properties.CoverageEnabled
 
 	installBase := "fuzz"
 
 	// Grab the list of required shared libraries.
-	fuzz.sharedLibraries, _ = cc.CollectAllSharedDependencies(ctx)
+	fuzzer.sharedLibraries, _ = cc.CollectAllSharedDependencies(ctx)
 
-	for _, lib := range fuzz.sharedLibraries {
-		fuzz.installedSharedDeps = append(fuzz.installedSharedDeps,
+	for _, lib := range fuzzer.sharedLibraries {
+		fuzzer.installedSharedDeps = append(fuzzer.installedSharedDeps,
 			cc.SharedLibraryInstallLocation(
 				lib, ctx.Host(), installBase, ctx.Arch().ArchType.String()))
 
 		// Also add the dependency on the shared library symbols dir.
 		if !ctx.Host() {
-			fuzz.installedSharedDeps = append(fuzz.installedSharedDeps,
+			fuzzer.installedSharedDeps = append(fuzzer.installedSharedDeps,
 				cc.SharedLibrarySymbolsInstallLocation(lib, installBase, ctx.Arch().ArchType.String()))
 		}
 	}
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"android/soong/android"
+	"android/soong/cc"
 )
 
 func TestRustFuzz(t *testing.T) {
@@ -61,3 +62,72 @@ func TestRustFuzz(t *testing.T) {
 		t.Errorf("rust_fuzz dependent library does not contain the expected flags (sancov-module, cfg fuzzing, hwaddress sanitizer).")
 	}
 }
+
+func TestRustFuzzFrameworks(t *testing.T) {
+	ctx := testRust(t, `
+			rust_fuzz {
+				name: "fuzz_afl_only",
+				srcs: ["foo.rs"],
+				fuzzing_frameworks: {
+					libfuzzer: false,
+				},
+			}
+	`)
+
+	if ctx.ModuleForTests("fuzz_afl_only", "android_arm64_armv8-a_fuzzer").Module().Enabled() {
+		t.Errorf("rust_fuzz module not built for libfuzzer is enabled")
+	}
+}
+
+func TestRustFuzzPackaging(t *testing.T) {
+	skipTestIfOsNotSupported(t)
+	result := android.GroupFixturePreparers(
+		prepareForRustTest,
+		cc.PrepareForTestWithFuzzPackaging,
+		rustMockedFiles.AddToFixture(),
+		android.FixtureAddFile("seed.bin", nil),
+	).RunTestWithBp(t, `
+			rust_fuzz {
+				name: "fuzz_libtest",
+				srcs: ["foo.rs"],
+				corpus: ["seed.bin"],
+			}
+	`)
+
+	// The rust fuzz targets are packaged with the cc fuzz targets.
+	packaging := result.SingletonForTests("cc_fuzz_packaging")
+	fuzzZip := packaging.Output("out/soong/.intermediates/fuzz/target/arm64/fuzz_libtest.zip")
+	android.AssertStringListContains(t, "fuzz_libtest.zip inputs", fuzzZip.Implicits.Strings(),
+		"out/soong/.intermediates/fuzz/target/arm64/fuzz_libtest_seed_corpus.zip")
+	packaging.Output("fuzz-target-arm64.zip")
+
+	metadata := packaging.Output("out/soong/.intermediates/fuzz/target/arm64/fuzz_libtest_metadata.json")
+	android.AssertStringDoesContain(t, "fuzz_libtest metadata", android.ContentFromFileRuleForTests(t, metadata),
+		`"framework": "libfuzzer"`)
+}
+
+func TestRustFuzzCoverage(t *testing.T) {
+	ctx := testRustCov(t, `
+			rust_fuzz {
+				name: "fuzz_libtest",
+				srcs: ["foo.rs"],
+			}
+	`)
+
+	var variant string
+	for _, v := range ctx.ModuleVariantsForTests("fuzz_libtest") {
+		if strings.HasPrefix(v, "android_arm64_armv8-a") && strings.Contains(v, "fuzzer") && strings.Contains(v, "cov") {
+			variant = v
+		}
+	}
+	if variant == "" {
+		t.Fatalf("no coverage variant of fuzz_libtest in %q", ctx.ModuleVariantsForTests("fuzz_libtest"))
+	}
+
+	fuzzCov := ctx.ModuleForTests("fuzz_libtest", variant)
+	rustcFlags := fuzzCov.Rule("rustc").Args["rustcFlags"]
+	android.AssertStringDoesContain(t, "rustcFlags", rustcFlags, "-C instrument-coverage")
+	android.AssertStringDoesContain(t, "rustcFlags", rustcFlags, "--cfg fuzzing")
+	android.AssertBoolEquals(t, "coverage", true,
+		fuzzCov.Module().(*Module).FuzzPackagedModule().Coverage)
+}
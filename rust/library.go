@@ -441,6 +441,8 @@ func (library *libraryDecorator) compilerDeps(ctx DepsContext, deps Deps) Deps {
 			deps = muslDeps(ctx, deps, false)
 			deps.CrtBegin = []string{"libc_musl_crtbegin_so"}
 			deps.CrtEnd = []string{"libc_musl_crtend_so"}
+		} else if ctx.Os() == android.Windows {
+			deps = windowsDeps(deps)
 		}
 	}
 
@@ -473,7 +475,7 @@ func (library *libraryDecorator) compilerFlags(ctx ModuleContext, flags Flags) F
 	if library.shared() || library.static() {
 		library.includeDirs = append(library.includeDirs, android.PathsForModuleSrc(ctx, library.Properties.Include_dirs)...)
 	}
-	if library.shared() {
+	if library.shared() && !ctx.Windows() {
 		flags.LinkFlags = append(flags.LinkFlags, "-Wl,-soname="+library.sharedLibFilename(ctx))
 	}
 
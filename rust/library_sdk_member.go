@@ -0,0 +1,148 @@
+// Copyright 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"path/filepath"
+
+	"android/soong/android"
+
+	"github.com/google/blueprint"
+)
+
+func init() {
+	android.RegisterSdkMemberType(rustRlibSdkMemberType)
+}
+
+// rustRlibSdkMemberType snapshots the rlib variants of rust libraries as rust_prebuilt_rlib
+// modules.
+var rustRlibSdkMemberType = &rlibSdkMemberType{
+	SdkMemberTypeBase: android.SdkMemberTypeBase{
+		PropertyName:    "rust_rlibs",
+		SupportsSdk:     true,
+		HostOsDependent: true,
+	},
+}
+
+type rlibSdkMemberType struct {
+	android.SdkMemberTypeBase
+}
+
+func (mt *rlibSdkMemberType) AddDependencies(ctx android.SdkDependencyContext, dependencyTag blueprint.DependencyTag, names []string) {
+	for _, lib := range names {
+		for _, target := range ctx.MultiTargets() {
+			variations := target.Variations()
+			if ctx.Device() {
+				variations = append(variations,
+					blueprint.Variation{Mutator: "image", Variation: android.CoreVariation})
+			}
+			variations = append(variations,
+				blueprint.Variation{Mutator: "rust_libraries", Variation: rlibVariation},
+				blueprint.Variation{Mutator: "rust_stdlinkage", Variation: "rlib-std"})
+			ctx.AddFarVariationDependencies(variations, dependencyTag, lib)
+		}
+	}
+}
+
+func (mt *rlibSdkMemberType) IsInstance(module android.Module) bool {
+	if m, ok := module.(*Module); ok && m.compiler != nil {
+		if library, ok := m.compiler.(libraryInterface); ok {
+			return library.rlib()
+		}
+	}
+	return false
+}
+
+func (mt *rlibSdkMemberType) AddPrebuiltModule(ctx android.SdkMemberContext, member android.SdkMember) android.BpModule {
+	return ctx.SnapshotBuilder().AddPrebuiltModule(member, "rust_prebuilt_rlib")
+}
+
+func (mt *rlibSdkMemberType) CreateVariantPropertiesStruct() android.SdkMemberProperties {
+	return &rlibSdkMemberProperties{}
+}
+
+const (
+	rustRlibDir = "rlib"
+)
+
+// rustRlibPathFor returns the path to the rlib relative to <sdk_root>/<api_dir>.
+func rustRlibPathFor(p rlibSdkMemberProperties) string {
+	return filepath.Join(p.OsPrefix(), p.archType, rustRlibDir, p.outputFile.Base())
+}
+
+// rlibSdkMemberProperties represents the properties of a rust rlib.
+//
+// The exported (capitalized) fields will be examined and may be changed during common value extraction.
+// The unexported fields will be left untouched.
+type rlibSdkMemberProperties struct {
+	android.SdkMemberPropertiesBase
+
+	// archType is not exported as if set (to a non default value) it is always arch specific.
+	// This is "" for common properties.
+	archType string
+
+	// outputFile is not exported as it is always arch specific.
+	outputFile android.Path
+
+	// The name of the crate, which must match the name of the crate in the prebuilt rlib.
+	Crate_name string
+
+	// The rust libraries that the rlib depends upon.
+	Rlibs    []string
+	Rustlibs []string
+}
+
+func (p *rlibSdkMemberProperties) PopulateFromVariant(ctx android.SdkMemberContext, variant android.Module) {
+	rustModule := variant.(*Module)
+
+	p.archType = rustModule.Target().Arch.ArchType.String()
+	if outputFile := rustModule.OutputFile(); outputFile.Valid() {
+		p.outputFile = outputFile.Path()
+	} else {
+		ctx.SdkModuleContext().ModuleErrorf("member variant %s does not have a valid output file", variant)
+	}
+	p.Crate_name = rustModule.CrateName()
+
+	var compiler *baseCompiler
+	switch c := rustModule.compiler.(type) {
+	case *libraryDecorator:
+		compiler = c.baseCompiler
+	case *prebuiltLibraryDecorator:
+		compiler = c.baseCompiler
+	}
+	if compiler != nil {
+		p.Rlibs = compiler.Properties.Rlibs
+		p.Rustlibs = compiler.Properties.Rustlibs
+	}
+}
+
+func (p *rlibSdkMemberProperties) AddToPropertySet(ctx android.SdkMemberContext, propertySet android.BpPropertySet) {
+	builder := ctx.SnapshotBuilder()
+	if p.outputFile != nil {
+		path := rustRlibPathFor(*p)
+		propertySet.AddProperty("srcs", []string{path})
+		builder.CopyToSnapshot(p.outputFile, path)
+	}
+
+	if p.Crate_name != "" {
+		propertySet.AddProperty("crate_name", p.Crate_name)
+	}
+	if len(p.Rlibs) > 0 {
+		propertySet.AddPropertyWithTag("rlibs", p.Rlibs, builder.SdkMemberReferencePropertyTag(false))
+	}
+	if len(p.Rustlibs) > 0 {
+		propertySet.AddPropertyWithTag("rustlibs", p.Rustlibs, builder.SdkMemberReferencePropertyTag(false))
+	}
+}
@@ -0,0 +1,152 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Check that the classes of a bootclasspath_fragment are unique on the bootclasspath.
+
+A class that is in more than one jar on the bootclasspath is only loaded from
+the first of them at runtime, so the classes of the jars of a
+bootclasspath_fragment must not be in any other jar of the bootclasspath, nor
+in more than one of the jars of the fragment.
+
+The allowlist contains the classes that are known to collide, one per line,
+either a class name, e.g. android.foo.Bar, or a package wildcard, e.g.
+android.foo.*, that matches the classes of the package but not of its
+subpackages. Lines starting with # are comments.
+"""
+
+import argparse
+import sys
+import zipfile
+
+
+def class_name(entry):
+    """Returns the class name of a jar entry, or None if it isn't a class."""
+    if not entry.endswith('.class') or entry.startswith('META-INF/'):
+        return None
+    name = entry[:-len('.class')].replace('/', '.')
+    if name.endswith('module-info') or name.endswith('package-info'):
+        return None
+    return name
+
+
+def classes_of_jar(path):
+    with zipfile.ZipFile(path) as jar:
+        return {
+            name for name in map(class_name, jar.namelist()) if name
+        }
+
+
+def parse_allowlist(lines):
+    classes = set()
+    packages = set()
+    for line in lines:
+        line = line.strip()
+        if not line or line.startswith('#'):
+            continue
+        if line.endswith('.*'):
+            packages.add(line[:-len('.*')])
+        else:
+            classes.add(line)
+    return classes, packages
+
+
+def is_allowed(name, allowlist):
+    classes, packages = allowlist
+    return name in classes or name.rpartition('.')[0] in packages
+
+
+def find_collisions(fragment_jars, other_jars, allowlist):
+    """Find the classes of the fragment jars that are in more than one jar.
+
+    :param fragment_jars: a list of (name, classes) pairs of the jars of the
+    fragment.
+    :param other_jars: a list of (name, classes) pairs of the other jars of the
+    bootclasspath.
+    :param allowlist: the allowlist returned by parse_allowlist.
+    :return: a sorted list of (class, jar, other jars) tuples.
+    """
+    jars_by_class = {}
+    for name, classes in fragment_jars + other_jars:
+        for cls in classes:
+            jars_by_class.setdefault(cls, []).append(name)
+
+    collisions = []
+    for fragment_jar, classes in fragment_jars:
+        for cls in classes:
+            others = [j for j in jars_by_class[cls] if j != fragment_jar]
+            if others and not is_allowed(cls, allowlist):
+                collisions.append((cls, fragment_jar, others))
+    return sorted(collisions)
+
+
+def parse_jar_arg(arg):
+    name, sep, path = arg.partition('=')
+    if not sep:
+        raise argparse.ArgumentTypeError(
+            f'expected <name>=<path>, got {arg!r}')
+    return name, path
+
+
+def main(argv):
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument(
+        '--fragment', required=True, help='The name of the fragment.')
+    parser.add_argument(
+        '--jar',
+        action='append',
+        default=[],
+        type=parse_jar_arg,
+        help='<name>=<path> of a jar of the fragment, repeatable.')
+    parser.add_argument(
+        '--other-jar',
+        action='append',
+        default=[],
+        type=parse_jar_arg,
+        help='<name>=<path> of another jar of the bootclasspath, repeatable.')
+    parser.add_argument('--allowlist', help='The allowlist of the collisions.')
+    parser.add_argument(
+        '--output', required=True, help='The stamp file to create.')
+    args = parser.parse_args(argv)
+
+    allowlist = (set(), set())
+    if args.allowlist:
+        with open(args.allowlist, 'r', encoding='utf8') as f:
+            allowlist = parse_allowlist(f)
+
+    fragment_jars = [(name, classes_of_jar(path)) for name, path in args.jar]
+    other_jars = [
+        (name, classes_of_jar(path)) for name, path in args.other_jar
+    ]
+
+    collisions = find_collisions(fragment_jars, other_jars, allowlist)
+    if collisions:
+        for cls, jar, others in collisions:
+            print(
+                f'error: bootclasspath_fragment {args.fragment}: class {cls} '
+                f'in {jar} is also in {", ".join(others)}',
+                file=sys.stderr)
+        print(
+            f'error: {len(collisions)} classes of {args.fragment} collide '
+            'with other classes of the bootclasspath, remove the duplicates '
+            'or add them to the class_collisions_allowlist of the fragment',
+            file=sys.stderr)
+        sys.exit(1)
+
+    with open(args.output, 'w', encoding='utf8'):
+        pass
+
+
+if __name__ == '__main__':
+    main(sys.argv[1:])
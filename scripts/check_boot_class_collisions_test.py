@@ -0,0 +1,73 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Unit tests for check_boot_class_collisions.py."""
+
+import unittest
+
+import check_boot_class_collisions as check
+
+
+class CheckBootClassCollisionsTest(unittest.TestCase):
+
+    def test_class_name(self):
+        self.assertEqual('android.foo.Bar',
+                         check.class_name('android/foo/Bar.class'))
+        self.assertEqual('android.foo.Bar$Baz',
+                         check.class_name('android/foo/Bar$Baz.class'))
+        self.assertIsNone(check.class_name('android/foo/'))
+        self.assertIsNone(check.class_name('android/foo/res.txt'))
+        self.assertIsNone(check.class_name('module-info.class'))
+        self.assertIsNone(check.class_name('android/foo/package-info.class'))
+        self.assertIsNone(
+            check.class_name('META-INF/versions/9/android/foo/Bar.class'))
+
+    def test_no_collisions(self):
+        self.assertEqual([],
+                         check.find_collisions(
+                             [('bar', {'bar.A'})],
+                             [('platform:framework', {'android.A'})],
+                             (set(), set())))
+
+    def test_collisions(self):
+        collisions = check.find_collisions(
+            [('bar', {'bar.A', 'shared.B'}), ('baz', {'shared.B'})],
+            [('platform:framework', {'bar.A'})],
+            (set(), set()))
+        self.assertEqual([
+            ('bar.A', 'bar', ['platform:framework']),
+            ('shared.B', 'bar', ['baz']),
+            ('shared.B', 'baz', ['bar']),
+        ], collisions)
+
+    def test_allowlist(self):
+        allowlist = check.parse_allowlist([
+            '# Known duplicates.',
+            '',
+            'bar.A',
+            'shared.*',
+        ])
+        self.assertEqual(({'bar.A'}, {'shared'}), allowlist)
+        collisions = check.find_collisions(
+            [('bar', {'bar.A', 'shared.B', 'shared.sub.C'})],
+            [('platform:framework', {'bar.A', 'shared.B', 'shared.sub.C'})],
+            allowlist)
+        self.assertEqual([
+            ('shared.sub.C', 'bar', ['platform:framework']),
+        ], collisions)
+
+
+if __name__ == '__main__':
+    unittest.main(verbosity=2)
@@ -0,0 +1,120 @@
+#!/bin/bash -eu
+
+# Copyright 2023 Google Inc. All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Script to check the security features and the alignment of a linked ELF file
+# Inputs:
+#  Environment:
+#   CLANG_BIN: path to the clang bin directory
+#  Arguments:
+#   -i ${file}: input file (required)
+#   -o ${file}: output stamp file (required)
+#   -m ${module}: name of the module of the input file, for the errors (required)
+#   --bti-pac: require the BTI and PAC properties of arm64
+#   --execute-only: forbid executable LOAD segments that are also readable
+#   --no-text-relocations: forbid text relocations
+#   --max-page-size=${size}: require the LOAD segments to be aligned to size
+
+OPTSTRING=i:o:m:-:
+
+usage() {
+    cat <<EOF
+Usage: check_elf.sh [options] -i in-file -o out-file -m module
+Options:
+  --bti-pac                 require the BTI and PAC properties of arm64
+  --execute-only            forbid executable LOAD segments that are also readable
+  --no-text-relocations     forbid text relocations
+  --max-page-size=SIZE      require the LOAD segments to be aligned to SIZE
+EOF
+    exit 1
+}
+
+error() {
+    echo "error: ${module}: ${infile}: $*" >&2
+    failed=true
+}
+
+infile=
+outfile=
+module=
+bti_pac=
+execute_only=
+no_text_relocations=
+max_page_size=
+failed=
+
+while getopts $OPTSTRING opt; do
+    case "$opt" in
+        i) infile="${OPTARG}" ;;
+        o) outfile="${OPTARG}" ;;
+        m) module="${OPTARG}" ;;
+        -)
+            case "${OPTARG}" in
+                bti-pac) bti_pac=true ;;
+                execute-only) execute_only=true ;;
+                no-text-relocations) no_text_relocations=true ;;
+                max-page-size=*) max_page_size="${OPTARG#max-page-size=}" ;;
+                *) echo "Unknown option --${OPTARG}"; usage ;;
+            esac;;
+        ?) usage ;;
+        *) echo "'${opt}' '${OPTARG}'"
+    esac
+done
+
+if [ -z "${infile}" ] || [ -z "${outfile}" ] || [ -z "${module}" ]; then
+    usage
+fi
+
+if [ -n "${bti_pac}" ]; then
+    features=$("${CLANG_BIN}/llvm-readelf" --notes "${infile}" | grep -i "aarch64 feature:" || true)
+    if ! grep -q "BTI" <<< "${features}"; then
+        error "missing the BTI property, build with -mbranch-protection=standard"
+    fi
+    if ! grep -q "PAC" <<< "${features}"; then
+        error "missing the PAC property, build with -mbranch-protection=standard"
+    fi
+fi
+
+if [ -n "${execute_only}" ]; then
+    # The flags of a LOAD segment are the fields between its memory size and its alignment.
+    for flags in $("${CLANG_BIN}/llvm-readelf" --program-headers --wide "${infile}" | \
+            awk '$1 == "LOAD" { flags = ""; for (i = 7; i < NF; i++) flags = flags $i; print flags }'); do
+        if [[ "${flags}" == *E* && "${flags}" == *R* ]]; then
+            error "has a readable executable LOAD segment, link with -Wl,--execute-only"
+            break
+        fi
+    done
+fi
+
+if [ -n "${no_text_relocations}" ]; then
+    if "${CLANG_BIN}/llvm-readelf" --dynamic "${infile}" | grep -q "TEXTREL"; then
+        error "has text relocations"
+    fi
+fi
+
+if [ -n "${max_page_size}" ]; then
+    for align in $("${CLANG_BIN}/llvm-readelf" --program-headers --wide "${infile}" | awk '$1 == "LOAD" { print $NF }'); do
+        if (( align < max_page_size || align % max_page_size != 0 )); then
+            error "has a LOAD segment aligned to ${align}, expected a multiple of ${max_page_size}, link with -Wl,-z,max-page-size=${max_page_size}"
+            break
+        fi
+    done
+fi
+
+if [ -n "${failed}" ]; then
+    exit 1
+fi
+
+touch "${outfile}"
@@ -0,0 +1,83 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Checks that the files installed into a partition fit into its size.
+
+The ledger lists the files installed into the partition, one per line, as
+"<module>\t<path in partition>\t<source file>". The report lists the bytes
+installed by each module, largest first. If the total exceeds the partition
+size, the largest modules are printed and the check fails.
+"""
+
+import argparse
+import collections
+import os
+import sys
+
+
+def read_ledger(path):
+  """Returns a map from the modules in the ledger to their installed bytes."""
+  sizes = collections.Counter()
+  with open(path) as f:
+    for line in f:
+      line = line.rstrip('\n')
+      if not line:
+        continue
+      module, _, src = line.split('\t')
+      sizes[module] += os.path.getsize(src)
+  return sizes
+
+
+def parse_args():
+  parser = argparse.ArgumentParser(description=__doc__)
+  parser.add_argument('--partition-name', required=True,
+                      help='Name of the partition, for the error message.')
+  parser.add_argument('--partition-size', required=True, type=int,
+                      help='Size of the partition in bytes.')
+  parser.add_argument('--ledger', required=True,
+                      help='Path of the file listing the installed files.')
+  parser.add_argument('--report', required=True,
+                      help='Path of the report to write.')
+  parser.add_argument('--top', type=int, default=10,
+                      help='Number of modules to list when the check fails.')
+  return parser.parse_args()
+
+
+def main():
+  args = parse_args()
+
+  sizes = read_ledger(args.ledger)
+  # Sort by size, then by name for a stable report.
+  modules = sorted(sizes.items(), key=lambda item: (-item[1], item[0]))
+  total = sum(sizes.values())
+
+  lines = ['%d\t%s' % (size, module) for module, size in modules]
+  lines.append('%d\ttotal (partition size %d)' % (total, args.partition_size))
+  with open(args.report, 'w') as f:
+    f.write('\n'.join(lines) + '\n')
+
+  if total > args.partition_size:
+    print('error: the files installed into %s are %d bytes, which exceeds its '
+          'partition_size of %d bytes by %d bytes. The largest modules are:' %
+          (args.partition_name, total, args.partition_size,
+           total - args.partition_size), file=sys.stderr)
+    for module, size in modules[:args.top]:
+      print('  %12d  %s' % (size, module), file=sys.stderr)
+    sys.exit(1)
+
+
+if __name__ == '__main__':
+  main()
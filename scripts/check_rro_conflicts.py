@@ -0,0 +1,233 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Check the runtime resource overlays that target the same package for conflicts.
+
+A resource of a package that is overlaid by more than one overlay only gets the
+value of one of them at runtime. The static overlays with the highest
+android:priority win over the ones with lower priorities, and are themselves
+overridden by the overlays that are not static, whose order is set at runtime.
+The conflicts are written to the report with the order in which the overlays
+are applied, from the overlay whose value is used to the overridden ones.
+
+Two static overlays with the same priority that overlay the same resource are
+reported as warnings, as which of them wins depends on their partitions and
+paths rather than on their priorities. The overlays of different themes are
+never enabled together, so they don't conflict with each other.
+"""
+
+import argparse
+import re
+import subprocess
+import sys
+
+ATTRIBUTE_RE = re.compile(
+    r'^\s*A: http://schemas.android.com/apk/res/android:'
+    r'(targetPackage|priority|isStatic)\(0x[0-9a-f]+\)=(.*)$')
+RESOURCE_RE = re.compile(r'^\s*resource 0x[0-9a-f]+ (\S+)')
+VALUE_RE = re.compile(r'^\s*\(([^)]*)\)')
+
+
+class Overlay:
+    """A runtime resource overlay."""
+
+    def __init__(self, name, theme, target_package, priority, is_static,
+                 resources):
+        self.name = name
+        self.theme = theme
+        self.target_package = target_package
+        self.priority = priority
+        self.is_static = is_static
+        # The (resource, config) pairs of the overlay.
+        self.resources = resources
+
+    def description(self):
+        if not self.is_static:
+            return f'{self.name} (not static)'
+        return f'{self.name} (priority {self.priority})'
+
+
+def attribute_value(value):
+    """Returns the value of an attribute in the xmltree dump of aapt2."""
+    value = value.strip()
+    if value.startswith('"'):
+        return value[1:value.index('"', 1)]
+    if value.startswith('(type'):
+        value = value[value.index(')') + 1:]
+    return value
+
+
+def parse_overlay_manifest(lines):
+    """Returns the target package, the priority and whether the overlay is
+    static, from the xmltree dump of the manifest of an overlay."""
+    target_package, priority, is_static = None, 0, False
+    in_overlay = False
+    for line in lines:
+        stripped = line.strip()
+        if stripped.startswith('E: '):
+            in_overlay = stripped.split()[1] == 'overlay'
+            continue
+        match = ATTRIBUTE_RE.match(line)
+        if not in_overlay or not match:
+            continue
+        name, value = match.group(1), attribute_value(match.group(2))
+        if name == 'targetPackage':
+            target_package = value
+        elif name == 'priority':
+            priority = int(value, 0)
+            if priority >= 1 << 31:
+                priority -= 1 << 32
+        elif name == 'isStatic':
+            is_static = value in ('true', '0xffffffff')
+    return target_package, priority, is_static
+
+
+def parse_resources(lines):
+    """Returns the (resource, config) pairs of the resources dump of aapt2."""
+    resources = set()
+    resource = None
+    for line in lines:
+        match = RESOURCE_RE.match(line)
+        if match:
+            resource = match.group(1)
+            continue
+        match = VALUE_RE.match(line)
+        if resource and match:
+            resources.add((resource, match.group(1) or 'default'))
+    return resources
+
+
+def overlay_of_apk(aapt, name, theme, apk):
+    manifest = subprocess.check_output(
+        [aapt, 'dump', 'xmltree', '--file', 'AndroidManifest.xml', apk],
+        text=True)
+    target_package, priority, is_static = parse_overlay_manifest(
+        manifest.splitlines())
+    if not target_package:
+        raise ValueError(f'{name}: {apk} has no overlay target package')
+    resources = subprocess.check_output([aapt, 'dump', 'resources', apk],
+                                        text=True)
+    return Overlay(name, theme, target_package, priority, is_static,
+                   parse_resources(resources.splitlines()))
+
+
+def resolution_order(overlays):
+    """Sorts the overlays from the one whose values are used to the overridden
+    ones."""
+    return sorted(overlays,
+                  key=lambda o: (not o.is_static, o.priority, o.name),
+                  reverse=True)
+
+
+def find_conflicts(overlays):
+    """Finds the resources that are overlaid by more than one overlay.
+
+    :param overlays: a list of Overlay.
+    :return: a sorted list of (target package, resource, config, overlays)
+    tuples, with the overlays in resolution order, and the list of the warnings.
+    """
+    overlays_by_resource = {}
+    for overlay in overlays:
+        for resource, config in overlay.resources:
+            overlays_by_resource.setdefault(
+                (overlay.target_package, resource, config), []).append(overlay)
+
+    conflicts = []
+    warnings = set()
+    for key in sorted(overlays_by_resource):
+        target_package, resource, config = key
+        candidates = overlays_by_resource[key]
+        themes = {o.theme for o in candidates if o.theme}
+        # The overlays of each theme conflict with the ones without a theme.
+        groups = [[o for o in candidates if o.theme in ('', theme)]
+                  for theme in sorted(themes)] or [candidates]
+        for group in groups:
+            if len(group) < 2:
+                continue
+            ordered = resolution_order(group)
+            conflicts.append((target_package, resource, config, ordered))
+            priorities = [o.priority for o in ordered if o.is_static]
+            for priority in sorted(set(priorities)):
+                if priorities.count(priority) < 2:
+                    continue
+                names = ', '.join(
+                    sorted(o.name for o in ordered
+                           if o.is_static and o.priority == priority))
+                warnings.add(
+                    f'{target_package}: {resource} ({config}) is overlaid by '
+                    f'the static overlays {names} with the same priority '
+                    f'{priority}, set different priorities to order them')
+    return conflicts, sorted(warnings)
+
+
+def format_report(conflicts):
+    lines = []
+    for target_package, resource, config, ordered in conflicts:
+        lines.append(f'{target_package}: {resource} ({config}): ' +
+                     ' overrides '.join(o.description() for o in ordered))
+    return ''.join(line + '\n' for line in lines)
+
+
+def parse_overlay_arg(arg):
+    name, sep, path = arg.partition('=')
+    if not sep:
+        raise argparse.ArgumentTypeError(
+            f'expected <name>=<path>, got {arg!r}')
+    return name, path
+
+
+def main(argv):
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument('--aapt', required=True, help='The path to aapt2.')
+    parser.add_argument(
+        '--overlay',
+        action='append',
+        default=[],
+        type=parse_overlay_arg,
+        help='<name>=<path> of the APK of an overlay, repeatable.')
+    parser.add_argument(
+        '--theme',
+        action='append',
+        default=[],
+        type=parse_overlay_arg,
+        help='<name>=<theme> of a themed overlay, repeatable.')
+    parser.add_argument(
+        '--report', required=True, help='The conflicts report to write.')
+    parser.add_argument(
+        '--output', required=True, help='The stamp file to create.')
+    args = parser.parse_args(argv)
+
+    themes = dict(args.theme)
+    overlays = [
+        overlay_of_apk(args.aapt, name, themes.get(name, ''), apk)
+        for name, apk in args.overlay
+    ]
+
+    conflicts, warnings = find_conflicts(overlays)
+    with open(args.report, 'w', encoding='utf8') as f:
+        f.write(format_report(conflicts))
+
+    if warnings:
+        for warning in warnings:
+            print(f'warning: {warning}', file=sys.stderr)
+        print(f'warning: see {args.report} for all the overlay conflicts',
+              file=sys.stderr)
+
+    with open(args.output, 'w', encoding='utf8'):
+        pass
+
+
+if __name__ == '__main__':
+    main(sys.argv[1:])
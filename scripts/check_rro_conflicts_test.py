@@ -0,0 +1,117 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Unit tests for check_rro_conflicts.py."""
+
+import unittest
+
+import check_rro_conflicts as check
+
+
+def overlay(name, priority=0, is_static=True, theme='', resources=None):
+    return check.Overlay(name, theme, 'com.android.foo', priority, is_static,
+                         resources or {('string/app_name', 'default')})
+
+
+class CheckRroConflictsTest(unittest.TestCase):
+
+    def test_parse_overlay_manifest(self):
+        manifest = [
+            'N: android=http://schemas.android.com/apk/res/android (line=2)',
+            '  E: manifest (line=2)',
+            '    A: package="com.android.foo.rro" (Raw: "com.android.foo.rro")',
+            '    E: application (line=4)',
+            '      A: http://schemas.android.com/apk/res/android:hasCode(0x0101000c)=false',
+            '    E: overlay (line=5)',
+            '      A: http://schemas.android.com/apk/res/android:targetPackage(0x01010021)="com.android.foo" (Raw: "com.android.foo")',
+            '      A: http://schemas.android.com/apk/res/android:priority(0x0101051c)=(type 0x10)0x3',
+            '      A: http://schemas.android.com/apk/res/android:isStatic(0x0101052b)=(type 0x12)0xffffffff',
+        ]
+        self.assertEqual(('com.android.foo', 3, True),
+                         check.parse_overlay_manifest(manifest))
+
+    def test_parse_overlay_manifest_defaults(self):
+        manifest = [
+            '  E: manifest (line=2)',
+            '    E: overlay (line=5)',
+            '      A: http://schemas.android.com/apk/res/android:targetPackage(0x01010021)="com.android.foo" (Raw: "com.android.foo")',
+        ]
+        self.assertEqual(('com.android.foo', 0, False),
+                         check.parse_overlay_manifest(manifest))
+
+    def test_parse_resources(self):
+        resources = [
+            'Binary APK',
+            'Package name=com.android.foo.rro id=7f',
+            '  type string id=01 entryCount=2',
+            '    resource 0x7f010000 string/app_name',
+            '      () "Foo"',
+            '      (fr) "Foo FR"',
+            '    resource 0x7f010001 string/title',
+            '      () "Title"',
+        ]
+        self.assertEqual(
+            {
+                ('string/app_name', 'default'),
+                ('string/app_name', 'fr'),
+                ('string/title', 'default'),
+            }, check.parse_resources(resources))
+
+    def test_no_conflicts(self):
+        conflicts, warnings = check.find_conflicts([
+            overlay('a', resources={('string/a', 'default')}),
+            overlay('b', resources={('string/b', 'default')}),
+            overlay('c', resources={('string/a', 'fr')}),
+        ])
+        self.assertEqual([], conflicts)
+        self.assertEqual([], warnings)
+
+    def test_resolution_order(self):
+        low, high, dynamic = overlay('low', 1), overlay('high', 5), overlay(
+            'dynamic', is_static=False)
+        conflicts, warnings = check.find_conflicts([low, dynamic, high])
+        self.assertEqual([('com.android.foo', 'string/app_name', 'default',
+                           [dynamic, high, low])], conflicts)
+        self.assertEqual([], warnings)
+        self.assertEqual(
+            'com.android.foo: string/app_name (default): dynamic (not static)'
+            ' overrides high (priority 5) overrides low (priority 1)\n',
+            check.format_report(conflicts))
+
+    def test_same_priority(self):
+        _, warnings = check.find_conflicts(
+            [overlay('a'), overlay('b'),
+             overlay('c', 1)])
+        self.assertEqual([
+            'com.android.foo: string/app_name (default) is overlaid by the '
+            'static overlays a, b with the same priority 0, set different '
+            'priorities to order them'
+        ], warnings)
+
+    def test_themes(self):
+        plain, red, blue = overlay('plain', 1), overlay(
+            'red', theme='red'), overlay('blue', theme='blue')
+        conflicts, warnings = check.find_conflicts([plain, red, blue])
+        self.assertEqual([
+            ('com.android.foo', 'string/app_name', 'default', [plain, blue]),
+            ('com.android.foo', 'string/app_name', 'default', [plain, red]),
+        ], conflicts)
+        # The red and the blue overlays have the same priority, but are never
+        # enabled together.
+        self.assertEqual([], warnings)
+
+
+if __name__ == '__main__':
+    unittest.main(verbosity=2)
@@ -62,6 +62,12 @@ def Proto(args):
     if args.source:
         for input in args.source.split(':'):
             pb.MergeFrom(LoadJsonMessage(input))
+    # The library lists of the inputs may overlap, e.g. when a hand-maintained configuration is
+    # merged with the one generated by Soong.
+    for key in ['provideLibs', 'requireLibs']:
+        libs = list(dict.fromkeys(getattr(pb, key)))
+        pb.ClearField(key)
+        getattr(pb, key).extend(libs)
     with open(args.output, 'wb') as f:
         f.write(pb.SerializeToString())
 
@@ -120,6 +126,32 @@ def Merge(args):
         f.write(pb.SerializeToString())
 
 
+def Check(args):
+    """
+    Checks that the libraries listed in provideLibs and requireLibs of the linker configuration
+    (--source) are provided and required by the modules of the partition, as listed in the
+    configuration generated by Soong (--generated), and touches --output. The libraries that Make
+    installs to the partition (--make-installed) can't be checked and are accepted.
+    """
+    pb = LoadJsonMessage(args.source)
+    generated = LoadJsonMessage(args.generated)
+    make_installed = args.make_installed.split()
+
+    errors = []
+    for key, verb in [('provideLibs', 'provided'), ('requireLibs', 'required')]:
+        stale = [lib for lib in getattr(pb, key)
+                 if lib not in getattr(generated, key) and lib not in make_installed]
+        if stale:
+            errors.append(f'{args.source}: {key} lists {" ".join(stale)}, which are not {verb} '
+                          f'by the modules of the partition.')
+    if errors:
+        sys.stderr.write('\n'.join(errors) + '\n')
+        sys.exit(1)
+
+    with open(args.output, 'w'):
+        pass
+
+
 def GetArgParser():
     parser = argparse.ArgumentParser()
     subparsers = parser.add_subparsers()
@@ -227,6 +259,34 @@ def GetArgParser():
         help='Linker configuration files to merge.')
     append.set_defaults(func=Merge)
 
+    check = subparsers.add_parser(
+        'check',
+        help='Check the entries of a configuration against the configuration generated by Soong.')
+    check.add_argument(
+        '-s',
+        '--source',
+        required=True,
+        type=str,
+        help='Linker configuration file in JSON.')
+    check.add_argument(
+        '--generated',
+        required=True,
+        type=str,
+        help='Linker configuration file in JSON generated by Soong for the partition.')
+    check.add_argument(
+        '--make-installed',
+        default='',
+        type=str,
+        help='Libraries that Make installs to the partition. If there are more than one '
+        'it should be separated by empty space')
+    check.add_argument(
+        '-o',
+        '--output',
+        required=True,
+        type=str,
+        help='File to touch if the configuration is valid.')
+    check.set_defaults(func=Check)
+
     return parser
 
 
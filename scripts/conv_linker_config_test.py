@@ -94,6 +94,15 @@ class ConvLinkerConfigTest(TempDirTest):
     self.assertSetEqual(set(pb.provideLibs), set(['libfoo.so', 'libbar.so']))
 
 
+  def test_Proto_with_overlapping_input(self):
+    self.write('foo.json', b'{ "provideLibs": ["libfoo.so", "libbar.so"]}')
+    self.write('generated.json', b'{ "provideLibs": ["libbar.so", "libbaz.so"]}')
+    self.command(['proto', '-s', FileArgs([FileArg('foo.json'), FileArg('generated.json')]), '-o', FileArg('out.pb')])
+    pb = LinkerConfig()
+    pb.ParseFromString(self.read('out.pb'))
+    self.assertSequenceEqual(pb.provideLibs, ['libfoo.so', 'libbar.so', 'libbaz.so'])
+
+
   def test_Proto_with_existing_output(self):
     self.write('out.pb', LinkerConfig(provideLibs=['libfoo.so']).SerializeToString())
     buf = io.StringIO()
@@ -122,6 +131,35 @@ class ConvLinkerConfigTest(TempDirTest):
     self.assertSetEqual(set(pb.provideLibs), set(['libbar.so']))
 
 
+  def test_Check(self):
+    self.write('foo.json', b'{ "provideLibs": ["libfoo.so"], "requireLibs": ["libbar.so"]}')
+    self.write('generated.json', b'{ "provideLibs": ["libfoo.so", "libbaz.so"], "requireLibs": ["libbar.so"]}')
+    self.command(['check', '-s', FileArg('foo.json'), '--generated', FileArg('generated.json'),
+                  '-o', FileArg('check.stamp')])
+    self.assertEqual(self.read('check.stamp'), b'')
+
+
+  def test_Check_with_stale_entries(self):
+    self.write('foo.json', b'{ "provideLibs": ["libfoo.so", "libold.so"], "requireLibs": ["libbar.so"]}')
+    self.write('generated.json', b'{ "provideLibs": ["libfoo.so"]}')
+    buf = io.StringIO()
+    with self.assertRaises(SystemExit) as err:
+      with redirect_stderr(buf):
+        self.command(['check', '-s', FileArg('foo.json'), '--generated', FileArg('generated.json'),
+                      '-o', FileArg('check.stamp')])
+    self.assertEqual(err.exception.code, 1)
+    self.assertRegex(buf.getvalue(), r'provideLibs lists libold\.so, which are not provided')
+    self.assertRegex(buf.getvalue(), r'requireLibs lists libbar\.so, which are not required')
+
+
+  def test_Check_with_make_installed_libraries(self):
+    self.write('foo.json', b'{ "provideLibs": ["libfoo.so", "libmake.so"]}')
+    self.write('generated.json', b'{ "provideLibs": ["libfoo.so"]}')
+    self.command(['check', '-s', FileArg('foo.json'), '--generated', FileArg('generated.json'),
+                  '--make-installed', 'libmake.so', '-o', FileArg('check.stamp')])
+    self.assertEqual(self.read('check.stamp'), b'')
+
+
   def command(self, args):
     parser = conv_linker_config.GetArgParser()
     parsed_args = parser.parse_args(self.resolve_paths(args))
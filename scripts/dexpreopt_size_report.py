@@ -0,0 +1,111 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Generate a report of the size of the dexpreopt outputs of each module.
+
+The input is a file with one line per dexpreopt output that is installed on the
+device, each line containing the name of the module, the install path on the
+device and the path of the file on the host, separated by tabs.
+
+The output is a JSON file containing a list with an entry per module, sorted by
+the decreasing total size of its outputs, e.g.:
+
+[
+  {
+    "module": "Foo",
+    "total": 1234,
+    "sizes": {"odex": 1000, "vdex": 234},
+    "files": [{"path": "/system/app/Foo/oat/arm64/Foo.odex", "size": 1000},
+              ...]
+  },
+  ...
+]
+"""
+
+import argparse
+import json
+import os
+import sys
+
+
+def parse_installs(lines):
+    """Parse the installs, returning a sorted list of (module, install, host)."""
+    installs = set()
+    for line in lines:
+        line = line.rstrip('\n')
+        if not line:
+            continue
+        fields = line.split('\t')
+        if len(fields) != 3:
+            raise ValueError(
+                f'expected <module>\\t<install>\\t<host>, got {line!r}')
+        installs.add(tuple(fields))
+    return sorted(installs)
+
+
+def kind_of(path):
+    """Returns the kind of a dexpreopt output from its extension, e.g. odex."""
+    _, ext = os.path.splitext(path)
+    return ext[1:] if ext else 'other'
+
+
+def build_report(installs, size_of):
+    """Build the report from the installs.
+
+    :param installs: a list of (module, install, host) tuples as returned by
+    parse_installs.
+    :param size_of: a function returning the size of a host file.
+    :return: the list of the entries of the report.
+    """
+    modules = {}
+    for module, install, host in installs:
+        entry = modules.setdefault(module, {
+            'module': module,
+            'total': 0,
+            'sizes': {},
+            'files': [],
+        })
+        # The same file can be provided by several variants of a module.
+        if any(f['path'] == install for f in entry['files']):
+            continue
+        size = size_of(host)
+        kind = kind_of(install)
+        entry['total'] += size
+        entry['sizes'][kind] = entry['sizes'].get(kind, 0) + size
+        entry['files'].append({'path': install, 'size': size})
+
+    return sorted(modules.values(), key=lambda e: (-e['total'], e['module']))
+
+
+def main(argv):
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument(
+        '--installs', required=True, help='The list of the installs.')
+    parser.add_argument(
+        '--output', required=True, help='The JSON report to create.')
+    args = parser.parse_args(argv)
+
+    with open(args.installs, 'r', encoding='utf8') as f:
+        installs = parse_installs(f)
+
+    report = build_report(installs, os.path.getsize)
+
+    with open(args.output, 'w', encoding='utf8') as f:
+        json.dump(report, f, indent=2, sort_keys=True)
+        f.write('\n')
+
+
+if __name__ == '__main__':
+    main(sys.argv[1:])
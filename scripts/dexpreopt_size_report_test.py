@@ -0,0 +1,88 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Unit tests for dexpreopt_size_report.py."""
+
+import unittest
+
+import dexpreopt_size_report as report
+
+
+class DexpreoptSizeReportTest(unittest.TestCase):
+
+    def test_parse_installs(self):
+        installs = report.parse_installs([
+            'Foo\t/system/app/Foo/oat/arm64/Foo.vdex\tout/Foo.vdex\n',
+            '\n',
+            'Foo\t/system/app/Foo/oat/arm64/Foo.odex\tout/Foo.odex\n',
+            'Foo\t/system/app/Foo/oat/arm64/Foo.odex\tout/Foo.odex\n',
+        ])
+        self.assertEqual([
+            ('Foo', '/system/app/Foo/oat/arm64/Foo.odex', 'out/Foo.odex'),
+            ('Foo', '/system/app/Foo/oat/arm64/Foo.vdex', 'out/Foo.vdex'),
+        ], installs)
+
+    def test_parse_installs_invalid(self):
+        with self.assertRaises(ValueError):
+            report.parse_installs(['Foo\t/system/app/Foo/oat/arm64/Foo.odex'])
+
+    def test_kind_of(self):
+        self.assertEqual('odex',
+                         report.kind_of('/system/framework/oat/arm64/foo.odex'))
+        self.assertEqual('art',
+                         report.kind_of('/system/framework/arm64/boot.art'))
+        self.assertEqual('prof',
+                         report.kind_of('/system/etc/boot-image.prof'))
+        self.assertEqual('other', report.kind_of('/system/etc/foo'))
+
+    def test_build_report(self):
+        sizes = {
+            'out/Foo.odex': 100,
+            'out/Foo.vdex': 20,
+            'out/Foo.art': 3,
+            'out/Foo.arm.odex': 50,
+            'out/bar.odex': 500,
+        }
+        entries = report.build_report([
+            ('Foo', '/system/app/Foo/oat/arm/Foo.odex', 'out/Foo.arm.odex'),
+            ('Foo', '/system/app/Foo/oat/arm64/Foo.art', 'out/Foo.art'),
+            ('Foo', '/system/app/Foo/oat/arm64/Foo.odex', 'out/Foo.odex'),
+            ('Foo', '/system/app/Foo/oat/arm64/Foo.vdex', 'out/Foo.vdex'),
+            ('bar', '/system/framework/oat/arm64/bar.odex', 'out/bar.odex'),
+            # Another variant of bar that installs the same file.
+            ('bar', '/system/framework/oat/arm64/bar.odex', 'out/other/bar.odex'),
+        ], sizes.get)
+        self.assertEqual([{
+            'module': 'bar',
+            'total': 500,
+            'sizes': {'odex': 500},
+            'files': [
+                {'path': '/system/framework/oat/arm64/bar.odex', 'size': 500},
+            ],
+        }, {
+            'module': 'Foo',
+            'total': 173,
+            'sizes': {'art': 3, 'odex': 150, 'vdex': 20},
+            'files': [
+                {'path': '/system/app/Foo/oat/arm/Foo.odex', 'size': 50},
+                {'path': '/system/app/Foo/oat/arm64/Foo.art', 'size': 3},
+                {'path': '/system/app/Foo/oat/arm64/Foo.odex', 'size': 100},
+                {'path': '/system/app/Foo/oat/arm64/Foo.vdex', 'size': 20},
+            ],
+        }], entries)
+
+
+if __name__ == '__main__':
+    unittest.main(verbosity=2)
@@ -0,0 +1,149 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Compares a generated sdk snapshot with a checked-in prebuilt snapshot.
+
+Writes a human readable report of the differences in the members, the stub
+API files and the license data of the snapshots.
+"""
+
+import argparse
+import difflib
+import os
+import re
+import sys
+import zipfile
+
+# Matches the start of a module definition in a snapshot Android.bp file along
+# with its name, which is always the first property.
+MODULE_RE = re.compile(r'^(\w+) \{\n\s+name: "([^"]+)"', re.MULTILINE)
+
+
+def parse_args():
+  parser = argparse.ArgumentParser(description=__doc__)
+  parser.add_argument('--new', required=True,
+                      help='the zip file of the generated snapshot')
+  parser.add_argument('--old', required=True,
+                      help='the directory of the checked-in snapshot')
+  parser.add_argument('--out', required=True, help='the report to write')
+  return parser.parse_args()
+
+
+def read_zip(path):
+  """Returns a map from path to contents of the files in a snapshot zip."""
+  with zipfile.ZipFile(path) as z:
+    return {
+        name: z.read(name) for name in z.namelist() if not name.endswith('/')
+    }
+
+
+def read_dir(path):
+  """Returns a map from path to contents of the files in a snapshot dir."""
+  files = {}
+  if not os.path.isdir(path):
+    return files
+  for root, _, names in os.walk(path):
+    for name in names:
+      full = os.path.join(root, name)
+      with open(full, 'rb') as f:
+        files[os.path.relpath(full, path)] = f.read()
+  return files
+
+
+def members(files):
+  """Returns the set of (module type, name) pairs of a snapshot."""
+  contents = files.get('Android.bp', b'').decode('utf-8', 'replace')
+  return set(MODULE_RE.findall(contents))
+
+
+def is_api_file(path):
+  return path.startswith('sdk_library/') and path.endswith('.txt')
+
+
+def is_license_file(path):
+  return path.startswith('licenses/')
+
+
+def diff_files(old, new, select):
+  """Returns the added, removed and changed paths selected from the files."""
+  old_paths = set(p for p in old if select(p))
+  new_paths = set(p for p in new if select(p))
+  added = sorted(new_paths - old_paths)
+  removed = sorted(old_paths - new_paths)
+  changed = sorted(
+      p for p in old_paths & new_paths if old[p] != new[p])
+  return added, removed, changed
+
+
+def unified_diff(path, old, new):
+  return difflib.unified_diff(
+      old.decode('utf-8', 'replace').splitlines(),
+      new.decode('utf-8', 'replace').splitlines(),
+      fromfile='old/' + path, tofile='new/' + path, lineterm='')
+
+
+def generate_report(old, new):
+  """Returns the lines of the report comparing the old and new snapshots."""
+  lines = []
+
+  old_members = members(old)
+  new_members = members(new)
+  lines.append('Members:')
+  added = sorted(new_members - old_members)
+  removed = sorted(old_members - new_members)
+  for module_type, name in added:
+    lines.append('  + %s %s' % (module_type, name))
+  for module_type, name in removed:
+    lines.append('  - %s %s' % (module_type, name))
+  if not added and not removed:
+    lines.append('  no changes')
+
+  for title, select in (('Stub API files', is_api_file),
+                        ('License files', is_license_file)):
+    lines.append('')
+    lines.append(title + ':')
+    added, removed, changed = diff_files(old, new, select)
+    for path in added:
+      lines.append('  + ' + path)
+    for path in removed:
+      lines.append('  - ' + path)
+    for path in changed:
+      lines.append('  ~ ' + path)
+    if not added and not removed and not changed:
+      lines.append('  no changes')
+    for path in changed:
+      lines.append('')
+      lines.extend(unified_diff(path, old[path], new[path]))
+
+  others = [
+      p for p in diff_files(old, new, lambda p: True)[2]
+      if not is_api_file(p) and not is_license_file(p)
+  ]
+  if others:
+    lines.append('')
+    lines.append('Other changed files: %d' % len(others))
+
+  return lines
+
+
+def main():
+  args = parse_args()
+  report = generate_report(read_dir(args.old), read_zip(args.new))
+  with open(args.out, 'w') as f:
+    f.write('\n'.join(report) + '\n')
+
+
+if __name__ == '__main__':
+  sys.exit(main())
@@ -0,0 +1,99 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Tests for diff_sdk_snapshot."""
+
+import diff_sdk_snapshot
+import unittest
+
+OLD_BP = b'''
+java_import {
+    name: "mysdk_foo",
+    prefer: false,
+}
+
+java_sdk_library_import {
+    name: "mysdk_bar",
+    prefer: false,
+}
+'''
+
+NEW_BP = b'''
+java_import {
+    name: "mysdk_foo",
+    prefer: false,
+}
+
+license {
+    name: "mysdk_license",
+}
+'''
+
+
+class DiffSdkSnapshotTest(unittest.TestCase):
+
+  def test_no_changes(self):
+    files = {'Android.bp': OLD_BP, 'sdk_library/public/bar.txt': b'api'}
+    report = diff_sdk_snapshot.generate_report(files, dict(files))
+    self.assertEqual(report, [
+        'Members:',
+        '  no changes',
+        '',
+        'Stub API files:',
+        '  no changes',
+        '',
+        'License files:',
+        '  no changes',
+    ])
+
+  def test_changes(self):
+    old = {
+        'Android.bp': OLD_BP,
+        'sdk_library/public/bar.txt': b'method a\n',
+        'sdk_library/public/baz.txt': b'',
+        'java/foo.jar': b'old',
+    }
+    new = {
+        'Android.bp': NEW_BP,
+        'sdk_library/public/bar.txt': b'method a\nmethod b\n',
+        'licenses/NOTICE': b'notice',
+        'java/foo.jar': b'new',
+    }
+    report = diff_sdk_snapshot.generate_report(old, new)
+    self.assertEqual(report, [
+        'Members:',
+        '  + license mysdk_license',
+        '  - java_sdk_library_import mysdk_bar',
+        '',
+        'Stub API files:',
+        '  - sdk_library/public/baz.txt',
+        '  ~ sdk_library/public/bar.txt',
+        '',
+        '--- old/sdk_library/public/bar.txt',
+        '+++ new/sdk_library/public/bar.txt',
+        '@@ -1 +1,2 @@',
+        ' method a',
+        '+method b',
+        '',
+        'License files:',
+        '  + licenses/NOTICE',
+        '',
+        'Other changed files: 2',
+    ])
+
+
+if __name__ == '__main__':
+  unittest.main(verbosity=2)
@@ -0,0 +1,74 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Generate the errorprone baseline of a module.
+
+The input is the output of compiling the module with errorprone, with its fatal
+checks reported as warnings. The baseline lists the fatal checks that have
+warnings, one per line, e.g.:
+
+# Errorprone checks of errorprone.fatal_checks that are reported as warnings
+# until they are fixed. Generated with UPDATE_ERRORPRONE_BASELINE=true.
+MissingOverride
+"""
+
+import argparse
+import re
+import sys
+
+HEADER = [
+    '# Errorprone checks of errorprone.fatal_checks that are reported as warnings',
+    '# until they are fixed. Generated with UPDATE_ERRORPRONE_BASELINE=true.',
+]
+
+WARNING_RE = re.compile(r': warning: \[(\w+)\]')
+
+
+def failing_checks(lines, checks):
+    """Returns the sorted checks that have warnings in the errorprone output."""
+    failing = set()
+    for line in lines:
+        match = WARNING_RE.search(line)
+        if match and match.group(1) in checks:
+            failing.add(match.group(1))
+    return sorted(failing)
+
+
+def build_baseline(failing):
+    """Returns the contents of the baseline of the failing checks."""
+    return '\n'.join(HEADER + failing) + '\n'
+
+
+def main(argv):
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument(
+        '--checks',
+        required=True,
+        help='The comma separated fatal checks of the module.')
+    parser.add_argument(
+        '--log', required=True, help='The output of the errorprone run.')
+    parser.add_argument(
+        '--output', required=True, help='The baseline to create.')
+    args = parser.parse_args(argv)
+
+    with open(args.log, 'r', encoding='utf8') as f:
+        failing = failing_checks(f, set(args.checks.split(',')))
+
+    with open(args.output, 'w', encoding='utf8') as f:
+        f.write(build_baseline(failing))
+
+
+if __name__ == '__main__':
+    main(sys.argv[1:])
@@ -0,0 +1,52 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Unit tests for errorprone_baseline.py."""
+
+import unittest
+
+import errorprone_baseline as baseline
+
+
+class ErrorproneBaselineTest(unittest.TestCase):
+
+    def test_failing_checks(self):
+        failing = baseline.failing_checks([
+            'a/Foo.java:12: warning: [MissingOverride] bar implements method\n',
+            '    public void bar() {}\n',
+            'a/Foo.java:20: warning: [ReferenceEquality] Comparison using\n',
+            'a/Bar.java:3: warning: [MissingOverride] baz implements method\n',
+            'a/Bar.java:5: error: [DeadException] Exception created\n',
+            'Note: Some input files use unchecked or unsafe operations.\n',
+        ], {'MissingOverride', 'DeadException', 'UnusedVariable'})
+        self.assertEqual(['MissingOverride'], failing)
+
+    def test_build_baseline(self):
+        self.assertEqual(
+            '# Errorprone checks of errorprone.fatal_checks that are reported as warnings\n'
+            '# until they are fixed. Generated with UPDATE_ERRORPRONE_BASELINE=true.\n'
+            'MissingOverride\n'
+            'ReferenceEquality\n',
+            baseline.build_baseline(['MissingOverride', 'ReferenceEquality']))
+
+    def test_build_baseline_empty(self):
+        self.assertEqual(
+            '# Errorprone checks of errorprone.fatal_checks that are reported as warnings\n'
+            '# until they are fixed. Generated with UPDATE_ERRORPRONE_BASELINE=true.\n',
+            baseline.build_baseline([]))
+
+
+if __name__ == '__main__':
+    unittest.main(verbosity=2)
@@ -0,0 +1,93 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Merge the hidden API flags of the shards of the bootclasspath.
+
+Each shard, i.e. a bootclasspath_fragment or the platform libraries, provides
+the flags of the signatures of its own classes, one signature per line followed
+by its flags. The merged file contains the lines of all the shards, sorted in
+the same way as the flags generated by generate_hiddenapi_lists.py.
+
+A signature must only be provided by one shard, unless all the shards that
+provide it agree on its flags.
+"""
+
+import argparse
+import sys
+
+
+def merge_flags_from_streams(streams):
+    """Merge the flags of the shards.
+
+    :param streams: a list of (name, stream) pairs, one per shard.
+    :return: a pair of the merged lines and the errors.
+    """
+    lines_by_signature = {}
+    shard_by_signature = {}
+    errors = []
+    for name, stream in streams:
+        for line in stream:
+            line = line.rstrip('\n')
+            if not line:
+                continue
+            signature = line.split(',', 1)[0]
+            if signature not in lines_by_signature:
+                lines_by_signature[signature] = line
+                shard_by_signature[signature] = name
+            elif lines_by_signature[signature] != line:
+                errors.append(
+                    f'{signature} has different flags in '
+                    f'{shard_by_signature[signature]} and {name}:\n'
+                    f'    {lines_by_signature[signature]}\n'
+                    f'    {line}')
+    return sorted(lines_by_signature.values()), errors
+
+
+def merge_flags_from_files(files):
+    streams = []
+    try:
+        for file in files:
+            # pylint: disable=consider-using-with
+            streams.append((file, open(file, 'r', encoding='utf8')))
+        return merge_flags_from_streams(streams)
+    finally:
+        for _, stream in streams:
+            stream.close()
+
+
+def main(argv):
+    args_parser = argparse.ArgumentParser(
+        description='Merge the hidden API flags of the shards of the '
+        'bootclasspath into a single file.')
+    args_parser.add_argument(
+        '--output', help='The file to which the merged flags are written.')
+    args_parser.add_argument(
+        'files', nargs='*', help='The flags of each shard.')
+    args = args_parser.parse_args(argv)
+
+    lines, errors = merge_flags_from_files(args.files)
+    if errors:
+        for error in errors:
+            print(error, file=sys.stderr)
+        sys.exit(1)
+
+    with open(args.output, 'w', encoding='utf8') as output_file:
+        for line in lines:
+            output_file.write(line)
+            output_file.write('\n')
+
+
+if __name__ == '__main__':
+    main(sys.argv[1:])
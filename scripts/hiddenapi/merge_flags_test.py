@@ -0,0 +1,65 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the 'License');
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an 'AS IS' BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Unit tests for merge_flags.py."""
+import io
+import unittest
+
+import merge_flags
+
+
+class TestMergeFlags(unittest.TestCase):
+
+    @staticmethod
+    def merge(*shards):
+        return merge_flags.merge_flags_from_streams([
+            (name, io.StringIO(text)) for name, text in shards
+        ])
+
+    def test_merge(self):
+        lines, errors = self.merge(
+            ('platform', 'Lb/B;->b()V,blocked\n'
+             'La/A;->a()V,public-api,sdk\n'),
+            ('art', '\nLjava/lang/Object;->hashCode()I,public-api,sdk\n'),
+        )
+        self.assertEqual([], errors)
+        self.assertEqual([
+            'La/A;->a()V,public-api,sdk',
+            'Lb/B;->b()V,blocked',
+            'Ljava/lang/Object;->hashCode()I,public-api,sdk',
+        ], lines)
+
+    def test_merge_same_flags(self):
+        lines, errors = self.merge(
+            ('platform', 'La/A;->a()V,blocked\n'),
+            ('art', 'La/A;->a()V,blocked\n'),
+        )
+        self.assertEqual([], errors)
+        self.assertEqual(['La/A;->a()V,blocked'], lines)
+
+    def test_merge_different_flags(self):
+        _, errors = self.merge(
+            ('platform', 'La/A;->a()V,blocked\n'),
+            ('art', 'La/A;->a()V,public-api,sdk\n'),
+        )
+        self.assertEqual([
+            'La/A;->a()V has different flags in platform and art:\n'
+            '    La/A;->a()V,blocked\n'
+            '    La/A;->a()V,public-api,sdk'
+        ], errors)
+
+
+if __name__ == '__main__':
+    unittest.main(verbosity=2)
@@ -0,0 +1,198 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""List the classes of a jar that are excluded from jacoco instrumentation.
+
+The classes annotated with one of the annotations, with either a runtime or a
+class retention, are excluded along with their nested classes. The output
+contains the jar entries of the excluded classes, one per line.
+"""
+
+import argparse
+import struct
+import sys
+import zipfile
+
+# The sizes of the constant pool entries that aren't UTF-8 strings, by tag.
+CONSTANT_SIZES = {
+    3: 4,  # Integer
+    4: 4,  # Float
+    5: 8,  # Long
+    6: 8,  # Double
+    7: 2,  # Class
+    8: 2,  # String
+    9: 4,  # Fieldref
+    10: 4,  # Methodref
+    11: 4,  # InterfaceMethodref
+    12: 4,  # NameAndType
+    15: 3,  # MethodHandle
+    16: 2,  # MethodType
+    17: 4,  # Dynamic
+    18: 4,  # InvokeDynamic
+    19: 2,  # Module
+    20: 2,  # Package
+}
+CONSTANT_UTF8 = 1
+
+ANNOTATION_ATTRIBUTES = ('RuntimeVisibleAnnotations',
+                         'RuntimeInvisibleAnnotations')
+
+
+class ClassReader:
+    """Reads the big-endian values of a class file."""
+
+    def __init__(self, data):
+        self.data = data
+        self.offset = 0
+
+    def read(self, fmt):
+        values = struct.unpack_from('>' + fmt, self.data, self.offset)
+        self.offset += struct.calcsize('>' + fmt)
+        return values[0] if len(values) == 1 else values
+
+    def skip(self, size):
+        self.offset += size
+
+
+def skip_element_value(reader):
+    tag = chr(reader.read('B'))
+    if tag == 'e':
+        reader.skip(4)
+    elif tag == '@':
+        skip_annotation(reader)
+    elif tag == '[':
+        for _ in range(reader.read('H')):
+            skip_element_value(reader)
+    else:
+        reader.skip(2)
+
+
+def skip_annotation(reader):
+    reader.skip(2)
+    for _ in range(reader.read('H')):
+        reader.skip(2)
+        skip_element_value(reader)
+
+
+def skip_members(reader):
+    """Skips the fields or the methods of a class file."""
+    for _ in range(reader.read('H')):
+        reader.skip(6)
+        for _ in range(reader.read('H')):
+            reader.skip(2)
+            reader.skip(reader.read('I'))
+
+
+def class_annotations(data):
+    """Returns the annotations of a class file, by fully qualified name."""
+    reader = ClassReader(data)
+    if reader.read('I') != 0xCAFEBABE:
+        raise ValueError('not a class file')
+    reader.skip(4)
+
+    strings = {}
+    count = reader.read('H')
+    index = 1
+    while index < count:
+        tag = reader.read('B')
+        if tag == CONSTANT_UTF8:
+            length = reader.read('H')
+            strings[index] = data[reader.offset:reader.offset +
+                                  length].decode('utf-8', 'replace')
+            reader.skip(length)
+        else:
+            reader.skip(CONSTANT_SIZES[tag])
+        # The long and the double constants take two entries.
+        index += 2 if tag in (5, 6) else 1
+
+    reader.skip(6)
+    reader.skip(2 * reader.read('H'))
+    skip_members(reader)
+    skip_members(reader)
+
+    annotations = set()
+    for _ in range(reader.read('H')):
+        name = strings.get(reader.read('H'))
+        length = reader.read('I')
+        end = reader.offset + length
+        if name in ANNOTATION_ATTRIBUTES:
+            for _ in range(reader.read('H')):
+                descriptor = strings.get(reader.read('H'), '')
+                # The descriptors of the annotations are like Lfoo/Bar;.
+                annotations.add(descriptor[1:-1].replace('/', '.'))
+                for _ in range(reader.read('H')):
+                    reader.skip(2)
+                    skip_element_value(reader)
+        reader.offset = end
+    return annotations
+
+
+def excluded_classes(classes, annotations):
+    """Finds the classes that are excluded from instrumentation.
+
+    :param classes: a dict of the jar entries of the classes to their
+    annotations.
+    :param annotations: the annotations of the excluded classes.
+    :return: the sorted jar entries of the classes that are annotated with one
+    of the annotations, and of their nested classes.
+    """
+    annotated = {
+        entry[:-len('.class')]
+        for entry, class_annotations in classes.items()
+        if class_annotations & annotations
+    }
+
+    def is_excluded(entry):
+        name = entry[:-len('.class')]
+        while True:
+            if name in annotated:
+                return True
+            if '$' not in name:
+                return False
+            name = name.rpartition('$')[0]
+
+    return sorted(entry for entry in classes if is_excluded(entry))
+
+
+def classes_of_jar(path):
+    """Returns a dict of the jar entries of the classes to their annotations."""
+    classes = {}
+    with zipfile.ZipFile(path) as jar:
+        for entry in jar.namelist():
+            if entry.endswith('.class') and not entry.startswith('META-INF/'):
+                classes[entry] = class_annotations(jar.read(entry))
+    return classes
+
+
+def main(argv):
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument(
+        '--annotation',
+        action='append',
+        default=[],
+        help='The fully qualified name of an annotation of the excluded '
+        'classes, repeatable.')
+    parser.add_argument(
+        '--output', required=True, help='The list of classes to write.')
+    parser.add_argument('jar', help='The jar of the classes.')
+    args = parser.parse_args(argv)
+
+    excluded = excluded_classes(classes_of_jar(args.jar), set(args.annotation))
+    with open(args.output, 'w', encoding='utf8') as f:
+        f.write(''.join(entry + '\n' for entry in excluded))
+
+
+if __name__ == '__main__':
+    main(sys.argv[1:])
@@ -0,0 +1,125 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Unit tests for jacoco_excluded_classes.py."""
+
+import struct
+import unittest
+
+import jacoco_excluded_classes as excluded
+
+
+def class_file(name, visible=(), invisible=()):
+    """Builds a minimal class file with the runtime visible and invisible class
+    annotations."""
+    pool = []
+
+    def add(data, slots=1):
+        pool.append((data, slots))
+        return sum(s for _, s in pool[:-1]) + 1
+
+    def utf8(s):
+        encoded = s.encode('utf-8')
+        return add(struct.pack('>BH', 1, len(encoded)) + encoded)
+
+    this_class = add(struct.pack('>BH', 7, utf8(name)))
+    super_class = add(struct.pack('>BH', 7, utf8('java/lang/Object')))
+    # A long constant takes two entries of the constant pool.
+    add(struct.pack('>Bq', 5, 42), slots=2)
+    code = utf8('Code')
+    value = utf8('value')
+    text = utf8('text')
+
+    def annotations_attribute(attribute, descriptors):
+        body = struct.pack('>H', len(descriptors))
+        for descriptor in descriptors:
+            # Each annotation has a string value, an array value and a
+            # nested annotation value to skip.
+            body += struct.pack('>HH', utf8(descriptor), 3)
+            body += struct.pack('>HBH', value, ord('s'), text)
+            body += struct.pack('>HBHBHBH', value, ord('['), 2, ord('I'),
+                                text, ord('e'), text) + struct.pack(
+                                    '>H', text)
+            body += struct.pack('>HB', value, ord('@')) + struct.pack(
+                '>HH', utf8('Lfoo/Nested;'), 0)
+        return struct.pack('>HI', utf8(attribute), len(body)) + body
+
+    attributes = []
+    if visible:
+        attributes.append(
+            annotations_attribute('RuntimeVisibleAnnotations', visible))
+    if invisible:
+        attributes.append(
+            annotations_attribute('RuntimeInvisibleAnnotations',
+                                  invisible))
+
+    data = struct.pack('>IHH', 0xCAFEBABE, 0, 52)
+    data += struct.pack('>H', sum(s for _, s in pool) + 1)
+    data += b''.join(d for d, _ in pool)
+    data += struct.pack('>HHH', 0x21, this_class, super_class)
+    # No interfaces, no fields and a method with a code attribute.
+    data += struct.pack('>HH', 0, 0)
+    data += struct.pack('>HHHHH', 1, 0x1, value, text, 1)
+    data += struct.pack('>HI', code, 3) + b'\x00\x01\x02'
+    data += struct.pack('>H', len(attributes)) + b''.join(attributes)
+    return data
+
+
+class JacocoExcludedClassesTest(unittest.TestCase):
+
+    def test_class_annotations(self):
+        self.assertEqual(
+            {'foo.Visible', 'foo.Invisible'},
+            excluded.class_annotations(
+                class_file(
+                    'foo/Bar',
+                    visible=['Lfoo/Visible;'],
+                    invisible=['Lfoo/Invisible;'])))
+
+    def test_class_annotations_none(self):
+        self.assertEqual(set(),
+                         excluded.class_annotations(class_file('foo/Bar')))
+
+    def test_not_a_class_file(self):
+        with self.assertRaises(ValueError):
+            excluded.class_annotations(b'\x00' * 16)
+
+    def test_excluded_classes(self):
+        classes = {
+            'foo/Annotated.class': {'foo.Exclude'},
+            'foo/Annotated$Nested.class': set(),
+            'foo/Annotated$Nested$Deeper.class': set(),
+            'foo/AnnotatedNot.class': set(),
+            'foo/Outer.class': {'foo.Other'},
+            'foo/Outer$Annotated.class': {'foo.Exclude'},
+            'foo/Outer$Annotated$Nested.class': set(),
+            'foo/Outer$Other.class': set(),
+        }
+        self.assertEqual([
+            'foo/Annotated$Nested$Deeper.class',
+            'foo/Annotated$Nested.class',
+            'foo/Annotated.class',
+            'foo/Outer$Annotated$Nested.class',
+            'foo/Outer$Annotated.class',
+        ], excluded.excluded_classes(classes, {'foo.Exclude'}))
+
+    def test_excluded_classes_no_annotations(self):
+        self.assertEqual([],
+                         excluded.excluded_classes(
+                             {'foo/Bar.class': {'foo.Exclude'}}, set()))
+
+
+if __name__ == '__main__':
+    unittest.main(verbosity=2)
@@ -0,0 +1,90 @@
+#!/bin/bash -eu
+
+# Copyright 2023 Google Inc. All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Script to record or restore the outputs of a build rule for artifact promotion.
+#
+# The digest of a rule is the sha256 of its key (a hash of the rule and its arguments computed
+# by Soong) followed by the sha256 of each of its inputs.
+#
+# Usage:
+#   promote_artifact.sh digest <key> <inputs rsp> <digest file>
+#     Writes the digest of the rule to <digest file>.
+#   promote_artifact.sh restore <key> <inputs rsp> <archive dir> <soong out dir> <outputs...>
+#     Copies <outputs...> from <archive dir> if the digest recorded in the archive matches.
+
+usage() {
+    sed -n '/^# Usage:/,/^$/p' "$0" >&2
+    exit 1
+}
+
+compute_digest() {
+    local key="$1"
+    local rsp="$2"
+    {
+        echo "${key}"
+        tr ' ' '\n' < "${rsp}" | sed '/^$/d' | sort | while read -r f; do
+            sha256sum "${f}"
+        done
+    } | sha256sum | cut -d' ' -f1
+}
+
+if [[ $# -lt 1 ]]; then
+    usage
+fi
+
+mode="$1"
+shift
+
+case "${mode}" in
+    digest)
+        [[ $# -eq 3 ]] || usage
+        compute_digest "$1" "$2" > "$3"
+        ;;
+    restore)
+        [[ $# -ge 5 ]] || usage
+        key="$1"
+        rsp="$2"
+        archive="$3"
+        soong_out="$4"
+        shift 4
+
+        digest="$(compute_digest "${key}" "${rsp}")"
+        for out in "$@"; do
+            rel="${out#${soong_out}/}"
+            archived_digest="${archive}/.promotion/${rel}.digest"
+            if [[ ! -f "${archive}/${rel}" || ! -f "${archived_digest}" ]]; then
+                echo "error: ${out} is not present in promotion archive ${archive}" >&2
+                echo "Remove its module from SOONG_PROMOTE_MODULES or regenerate the archive." >&2
+                exit 1
+            fi
+            if [[ "${digest}" != "$(cat "${archived_digest}")" ]]; then
+                echo "error: inputs of ${out} changed since the archived build in ${archive}" >&2
+                echo "Remove its module from SOONG_PROMOTE_MODULES or regenerate the archive." >&2
+                exit 1
+            fi
+        done
+
+        for out in "$@"; do
+            rel="${out#${soong_out}/}"
+            rm -f "${out}"
+            mkdir -p "$(dirname "${out}")"
+            cp "${archive}/${rel}" "${out}"
+        done
+        ;;
+    *)
+        usage
+        ;;
+esac
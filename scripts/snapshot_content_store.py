@@ -0,0 +1,93 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Stores the prebuilts of a snapshot by their content.
+
+Identical files, e.g. the same library built for several architecture
+variants, are stored once as objects/<sha256> under the snapshot directory.
+Each architecture directory gets a manifest.json that maps the path of the
+prebuilt within the architecture directory to its object.
+"""
+
+import argparse
+import collections
+import hashlib
+import json
+import os
+import shutil
+
+
+def sha256(path):
+  h = hashlib.sha256()
+  with open(path, 'rb') as f:
+    for chunk in iter(lambda: f.read(1 << 20), b''):
+      h.update(chunk)
+  return h.hexdigest()
+
+
+def store(snapshot_dir, entries):
+  """Stores the files and returns the manifests and the stored objects.
+
+  entries is a list of (arch directory, path within it, source file) tuples.
+  """
+  manifests = collections.defaultdict(dict)
+  objects = {}
+  for arch, rel, src in entries:
+    digest = sha256(src)
+    obj = os.path.join(snapshot_dir, 'objects', digest)
+    if digest not in objects:
+      objects[digest] = obj
+      shutil.copyfile(src, obj)
+      shutil.copymode(src, obj)
+    manifests[arch][rel] = os.path.join('objects', digest)
+  return manifests, sorted(objects.values())
+
+
+def parse_args():
+  parser = argparse.ArgumentParser(
+      description=__doc__, fromfile_prefix_chars='@')
+  parser.add_argument('--snapshot-dir', required=True,
+                      help='Directory of the snapshot for a device arch.')
+  parser.add_argument('--list', required=True,
+                      help='Path of the file listing the stored objects.')
+  parser.add_argument('entries', nargs='*', metavar='ARCH:PATH:SRC',
+                      help='A prebuilt for the snapshot. @FILE reads the '
+                      'prebuilts from FILE, one per line.')
+  return parser.parse_args()
+
+
+def main():
+  args = parse_args()
+
+  objects_dir = os.path.join(args.snapshot_dir, 'objects')
+  shutil.rmtree(objects_dir, ignore_errors=True)
+  os.makedirs(objects_dir)
+
+  entries = [entry.split(':', 2) for entry in args.entries]
+  manifests, objects = store(args.snapshot_dir, entries)
+
+  for arch, manifest in manifests.items():
+    with open(os.path.join(args.snapshot_dir, arch, 'manifest.json'), 'w') as f:
+      json.dump(manifest, f, indent=2, sort_keys=True)
+      f.write('\n')
+
+  with open(args.list, 'w') as f:
+    for obj in objects:
+      f.write(obj + '\n')
+
+
+if __name__ == '__main__':
+  main()
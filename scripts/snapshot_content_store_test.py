@@ -0,0 +1,133 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Unit tests for snapshot_content_store.py."""
+
+import hashlib
+import json
+import os
+import sys
+import tempfile
+import unittest
+from unittest import mock
+
+import snapshot_content_store as content_store
+
+
+class SnapshotContentStoreTest(unittest.TestCase):
+
+  def setUp(self):
+    self.tmp = tempfile.TemporaryDirectory()
+    self.addCleanup(self.tmp.cleanup)
+    self.snapshot_dir = os.path.join(self.tmp.name, 'snapshot')
+    for arch in ('arch-arm64-armv8-a', 'arch-arm-armv7-a-neon'):
+      os.makedirs(os.path.join(self.snapshot_dir, arch))
+    os.makedirs(os.path.join(self.snapshot_dir, 'objects'))
+
+  def write(self, name, content):
+    path = os.path.join(self.tmp.name, name)
+    with open(path, 'w') as f:
+      f.write(content)
+    return path
+
+  def digest(self, content):
+    return hashlib.sha256(content.encode()).hexdigest()
+
+  def test_store_deduplicates_identical_files(self):
+    libfoo64 = self.write('libfoo64.so', 'foo')
+    libfoo32 = self.write('libfoo32.so', 'foo')
+    libbar = self.write('libbar.so', 'bar')
+
+    manifests, objects = content_store.store(self.snapshot_dir, [
+        ('arch-arm64-armv8-a', 'shared/libfoo.so', libfoo64),
+        ('arch-arm64-armv8-a', 'shared/libbar.so', libbar),
+        ('arch-arm-armv7-a-neon', 'shared/libfoo.so', libfoo32),
+    ])
+
+    foo = os.path.join('objects', self.digest('foo'))
+    bar = os.path.join('objects', self.digest('bar'))
+    self.assertEqual(
+        {
+            'arch-arm64-armv8-a': {
+                'shared/libfoo.so': foo,
+                'shared/libbar.so': bar,
+            },
+            'arch-arm-armv7-a-neon': {
+                'shared/libfoo.so': foo,
+            },
+        }, manifests)
+    self.assertEqual(
+        sorted([
+            os.path.join(self.snapshot_dir, foo),
+            os.path.join(self.snapshot_dir, bar)
+        ]), objects)
+    with open(os.path.join(self.snapshot_dir, foo)) as f:
+      self.assertEqual('foo', f.read())
+
+  def test_store_keeps_the_mode(self):
+    tool = self.write('tool', 'tool')
+    os.chmod(tool, 0o755)
+
+    _, objects = content_store.store(self.snapshot_dir,
+                                     [('arch-arm64-armv8-a', 'bin/tool', tool)])
+
+    self.assertEqual(0o755, os.stat(objects[0]).st_mode & 0o777)
+
+  def test_main_writes_the_manifests_and_the_list(self):
+    libfoo = self.write('libfoo.so', 'foo')
+    stale = os.path.join(self.snapshot_dir, 'objects', 'stale')
+    self.write(stale, 'stale')
+    object_list = os.path.join(self.tmp.name, 'objects.txt')
+
+    with mock.patch.object(sys, 'argv', [
+        'snapshot_content_store', '--snapshot-dir', self.snapshot_dir,
+        '--list', object_list,
+        'arch-arm64-armv8-a:shared/libfoo.so:' + libfoo
+    ]):
+      content_store.main()
+
+    foo = os.path.join('objects', self.digest('foo'))
+    with open(os.path.join(self.snapshot_dir, 'arch-arm64-armv8-a',
+                           'manifest.json')) as f:
+      self.assertEqual({'shared/libfoo.so': foo}, json.load(f))
+    with open(object_list) as f:
+      self.assertEqual(os.path.join(self.snapshot_dir, foo) + '\n', f.read())
+    self.assertFalse(os.path.exists(stale))
+
+  def test_main_reads_the_prebuilts_from_a_rsp_file(self):
+    libfoo = self.write('libfoo.so', 'foo')
+    libbar = self.write('libbar.so', 'bar')
+    rsp = self.write(
+        'objects.rsp', 'arch-arm64-armv8-a:shared/libfoo.so:' + libfoo + '\n'
+        'arch-arm64-armv8-a:shared/libbar.so:' + libbar + '\n')
+    object_list = os.path.join(self.tmp.name, 'objects.txt')
+
+    with mock.patch.object(sys, 'argv', [
+        'snapshot_content_store', '--snapshot-dir', self.snapshot_dir,
+        '--list', object_list, '@' + rsp
+    ]):
+      content_store.main()
+
+    with open(os.path.join(self.snapshot_dir, 'arch-arm64-armv8-a',
+                           'manifest.json')) as f:
+      self.assertEqual(
+          {
+              'shared/libfoo.so': os.path.join('objects', self.digest('foo')),
+              'shared/libbar.so': os.path.join('objects', self.digest('bar')),
+          }, json.load(f))
+
+
+if __name__ == '__main__':
+  unittest.main(verbosity=2)
@@ -0,0 +1,68 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"android/soong/android"
+	"android/soong/rust"
+)
+
+var prepareForSdkTestWithRust = android.GroupFixturePreparers(
+	rust.PrepareForTestWithRustDefaultModules,
+	PrepareForTestWithSdkBuildComponents,
+	android.FixtureMergeMockFs(android.MockFS{
+		"foo.rs": nil,
+		"bar.rs": nil,
+	}),
+)
+
+func TestSnapshotWithRustRlib(t *testing.T) {
+	result := prepareForSdkTestWithRust.RunTestWithBp(t, `
+		module_exports {
+			name: "myexports",
+			rust_rlibs: ["librustfoo"],
+		}
+
+		rust_library_rlib {
+			name: "librustfoo",
+			crate_name: "rustfoo",
+			srcs: ["foo.rs"],
+			rustlibs: ["librustbar"],
+		}
+
+		rust_library_rlib {
+			name: "librustbar",
+			crate_name: "rustbar",
+			srcs: ["bar.rs"],
+		}
+	`)
+
+	CheckSnapshot(t, result, "myexports", "",
+		func(info *snapshotBuildInfo) {
+			info.t.Helper()
+			bp := info.androidBpContents
+			android.AssertStringDoesContain(t, "module type", bp, "rust_prebuilt_rlib {\n    name: \"librustfoo\",")
+			android.AssertStringDoesContain(t, "crate name", bp, `crate_name: "rustfoo",`)
+			android.AssertStringDoesContain(t, "rustlibs", bp, `rustlibs: ["librustbar"],`)
+			android.AssertStringDoesContain(t, "arm64 srcs", bp, `srcs: ["arm64/rlib/librustfoo.rlib"],`)
+		},
+		checkAllCopyRules(`
+.intermediates/librustfoo/android_arm64_armv8-a_rlib_rlib-std/librustfoo.rlib -> arm64/rlib/librustfoo.rlib
+.intermediates/librustfoo/android_arm_armv7-a-neon_rlib_rlib-std/librustfoo.rlib -> arm/rlib/librustfoo.rlib
+`),
+	)
+}
@@ -75,6 +75,9 @@ type sdk struct {
 
 	infoFile android.OptionalPath
 
+	// The report comparing the snapshot with the checked-in snapshot.
+	snapshotDiffFile android.OptionalPath
+
 	// The builder, preserved for testing.
 	builderForTests *snapshotBuilder
 }
@@ -98,6 +101,14 @@ type sdkProperties struct {
 	//   dropped. Adding a rule to members that have //visibility:private will
 	//   cause the //visibility:private to be discarded.
 	Prebuilt_visibility []string
+
+	// The directory containing the checked-in prebuilt snapshot of this sdk, relative to the root
+	// of the source tree, e.g. prebuilts/module_sdk/art/current/sdk.
+	//
+	// If specified then building <name>.snapshot_diff generates a report of the differences in the
+	// members, stub API files and license data between the generated snapshot and the checked-in
+	// one.
+	Checked_in_snapshot *string
 }
 
 // sdk defines an SDK which is a logical group of modules (e.g. native libs, headers, java libs, etc.)
@@ -217,6 +228,13 @@ func (s *sdk) AndroidMkEntries() []android.AndroidMkEntries {
 				infoTarget := s.Name() + ".info"
 				fmt.Fprintln(w, ".PHONY:", infoTarget)
 				fmt.Fprintln(w, infoTarget+":", s.infoFile.String())
+
+				// Allow the snapshot diff to be built by passing its name on the command line.
+				if s.snapshotDiffFile.Valid() {
+					diffTarget := s.Name() + ".snapshot_diff"
+					fmt.Fprintln(w, ".PHONY:", diffTarget)
+					fmt.Fprintln(w, diffTarget+":", s.snapshotDiffFile.String())
+				}
 			},
 		},
 	}}
@@ -15,6 +15,7 @@
 package sdk
 
 import (
+	"bytes"
 	"log"
 	"os"
 	"runtime"
@@ -212,6 +213,35 @@ func TestSdkInstall(t *testing.T) {
 `))
 }
 
+func TestSdkSnapshotDiff(t *testing.T) {
+	sdk := `
+		sdk {
+			name: "mysdk",
+			checked_in_snapshot: "prebuilts/module_sdk/mysdk",
+		}
+	`
+	result := testSdkWithFs(t, sdk, android.MockFS{
+		"prebuilts/module_sdk/mysdk/Android.bp":          nil,
+		"prebuilts/module_sdk/mysdk/licenses/NOTICE.txt": nil,
+	})
+
+	mysdk := result.ModuleForTests("mysdk", android.CommonOS.Name)
+	rule := mysdk.Output("mysdk-current.diff.txt")
+	android.AssertStringDoesContain(t, "command", rule.RuleParams.Command,
+		"--new out/soong/.intermediates/mysdk/common_os/mysdk-current.zip --old prebuilts/module_sdk/mysdk")
+	inputs := rule.Implicits.Strings()
+	android.AssertStringListContains(t, "inputs", inputs, "prebuilts/module_sdk/mysdk/Android.bp")
+	android.AssertStringListContains(t, "inputs", inputs, "prebuilts/module_sdk/mysdk/licenses/NOTICE.txt")
+
+	entries := android.AndroidMkEntriesForTest(t, result.TestContext, mysdk.Module())[0]
+	footer := &bytes.Buffer{}
+	for _, f := range entries.ExtraFooters {
+		f(footer, "mysdk", "", "")
+	}
+	android.AssertStringDoesContain(t, "footer", footer.String(),
+		"mysdk.snapshot_diff: out/soong/.intermediates/mysdk/common_os/mysdk-current.diff.txt")
+}
+
 type EmbeddedPropertiesStruct struct {
 	S_Embedded_Common    string `android:"arch_variant"`
 	S_Embedded_Different string `android:"arch_variant"`
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -540,6 +541,27 @@ be unnecessary as every module in the sdk already has its own licenses property.
 	// Install the zip, making sure that the info file has been installed as well.
 	installedZip := ctx.InstallFile(android.PathForMainlineSdksInstall(ctx), outputZipFile.Base(), outputZipFile, installedInfo)
 	s.snapshotFile = android.OptionalPathForPath(installedZip)
+
+	if dir := proptools.String(s.properties.Checked_in_snapshot); dir != "" {
+		s.snapshotDiffFile = android.OptionalPathForPath(s.buildSnapshotDiff(ctx, outputZipFile, dir))
+	}
+}
+
+// buildSnapshotDiff creates a rule that compares the generated snapshot with the checked-in
+// snapshot in dir and returns the path to the generated report.
+func (s *sdk) buildSnapshotDiff(ctx android.ModuleContext, snapshotZip android.Path, dir string) android.Path {
+	report := android.PathForModuleOut(ctx, ctx.ModuleName()+snapshotFileSuffix+".diff.txt")
+	checkedInFiles := ctx.GlobFiles(filepath.Join(dir, "**/*"), nil)
+
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		BuiltTool("diff_sdk_snapshot").
+		FlagWithInput("--new ", snapshotZip).
+		FlagWithArg("--old ", dir).
+		Implicits(checkedInFiles).
+		FlagWithOutput("--out ", report)
+	rule.Build("snapshot_diff", "Comparing snapshot of "+ctx.ModuleName()+" with "+dir)
+	return report
 }
 
 type moduleInfo struct {
@@ -136,18 +136,28 @@ type TestProperties struct {
 	Data_libs []string `android:"path,arch_variant"`
 
 	// list of device binary modules that should be installed alongside the test.
-	// Only available for host sh_test modules.
+	// Only available for host sh_test modules, or the host variant of sh_test modules with
+	// host_supported: true.
 	Data_device_bins []string `android:"path,arch_variant"`
 
 	// list of device library modules that should be installed alongside the test.
-	// Only available for host sh_test modules.
+	// Only available for host sh_test modules, or the host variant of sh_test modules with
+	// host_supported: true.
 	Data_device_libs []string `android:"path,arch_variant"`
 
 	// Install the test into a folder named for the module in all test suites.
 	Per_testcase_directory *bool
 
 	// Test options.
-	Test_options android.CommonTestOptions
+	Test_options TestOptions
+}
+
+type TestOptions struct {
+	android.CommonTestOptions
+
+	// Extra <option> tags to add to the auto generated test xml file. The "key"
+	// is optional in each of these.
+	Tradefed_options []tradefed.Option
 }
 
 type ShBinary struct {
@@ -322,7 +332,7 @@ func (s *ShTest) DepsMutator(ctx android.BottomUpMutatorContext) {
 		ctx.AddFarVariationDependencies(deviceVariations, shTestDataDeviceBinsTag, s.testProperties.Data_device_bins...)
 		ctx.AddFarVariationDependencies(append(deviceVariations, sharedLibVariations...),
 			shTestDataDeviceLibsTag, s.testProperties.Data_device_libs...)
-	} else if ctx.Target().Os.Class != android.Host {
+	} else if ctx.Target().Os.Class != android.Host && !s.HostSupported() {
 		if len(s.testProperties.Data_device_bins) > 0 {
 			ctx.PropertyErrorf("data_device_bins", "only available for host modules")
 		}
@@ -370,7 +380,7 @@ func (s *ShTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		options := []tradefed.Option{{Name: "force-root", Value: "false"}}
 		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.RootTargetPreparer", options})
 	}
-	if len(s.testProperties.Data_device_bins) > 0 {
+	if ctx.Host() && len(s.testProperties.Data_device_bins) > 0 {
 		moduleName := s.Name()
 		remoteDir := "/data/local/tests/unrestricted/" + moduleName + "/"
 		options := []tradefed.Option{{Name: "cleanup", Value: "true"}}
@@ -380,14 +390,15 @@ func (s *ShTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.PushFilePreparer", options})
 	}
 	s.testConfig = tradefed.AutoGenTestConfig(ctx, tradefed.AutoGenTestConfigOptions{
-		TestConfigProp:         s.testProperties.Test_config,
-		TestConfigTemplateProp: s.testProperties.Test_config_template,
-		TestSuites:             s.testProperties.Test_suites,
-		Config:                 configs,
-		AutoGenConfig:          s.testProperties.Auto_gen_config,
-		OutputFileName:         s.outputFilePath.Base(),
-		DeviceTemplate:         "${ShellTestConfigTemplate}",
-		HostTemplate:           "${ShellTestConfigTemplate}",
+		TestConfigProp:          s.testProperties.Test_config,
+		TestConfigTemplateProp:  s.testProperties.Test_config_template,
+		TestSuites:              s.testProperties.Test_suites,
+		Config:                  configs,
+		OptionsForAutogenerated: s.testProperties.Test_options.Tradefed_options,
+		AutoGenConfig:           s.testProperties.Auto_gen_config,
+		OutputFileName:          s.outputFilePath.Base(),
+		DeviceTemplate:          "${ShellTestConfigTemplate}",
+		HostTemplate:            "${ShellTestConfigTemplate}",
 	})
 
 	s.dataModules = make(map[string]android.Path)
@@ -426,6 +437,18 @@ func (s *ShTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 			ctx.PropertyErrorf(property, "%q of type %q is not supported", dep.Name(), ctx.OtherModuleType(dep))
 		}
 	})
+
+	files := []android.TestSuiteFile{{Src: s.outputFilePath, Rel: s.outputFilePath.Base()}}
+	for _, d := range s.data {
+		files = append(files, android.TestSuiteFile{Src: d, Rel: d.Rel()})
+	}
+	for _, relPath := range android.SortedKeys(s.dataModules) {
+		files = append(files, android.TestSuiteFile{Src: s.dataModules[relPath], Rel: relPath})
+	}
+	ctx.SetProvider(android.TestSuiteInfoProvider, android.TestSuiteInfo{
+		Files:  files,
+		Config: s.testConfig,
+	})
 }
 
 func (s *ShTest) InstallInData() bool {
@@ -255,3 +255,56 @@ func TestShTestHost_dataDeviceModulesAutogenTradefedConfig(t *testing.T) {
 		t.Errorf("foo extraConfings %v does not contain %q", autogen.Args["extraConfigs"], expectedBinAutogenConfig)
 	}
 }
+
+func TestShTest_hostSupported(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForShTest,
+		android.FixtureModifyConfig(android.SetKatiEnabledForTests),
+	).RunTestWithBp(t, `
+		sh_test {
+			name: "foo",
+			src: "test.sh",
+			host_supported: true,
+			data_device_bins: ["bar"],
+			test_options: {
+				tradefed_options: [
+					{
+						name: "test-timeout",
+						value: "5m",
+					},
+				],
+			},
+		}
+
+		cc_binary {
+			name: "bar",
+			no_libcrt: true,
+			nocrt: true,
+			system_shared_libs: [],
+			stl: "none",
+		}
+	`+cc.GatherRequiredDepsForTest(android.Android))
+
+	expectedPushFile := `<option name="push-file" key="bar" value="/data/local/tests/unrestricted/foo/bar" />`
+	expectedOption := `<option name="test-timeout" value="5m" />`
+
+	device := result.ModuleForTests("foo", "android_arm64_armv8-a")
+	deviceConfigs := device.Rule("autogen").Args["extraConfigs"]
+	android.AssertStringDoesContain(t, "device extraConfigs", deviceConfigs, expectedOption)
+	android.AssertStringDoesNotContain(t, "device extraConfigs", deviceConfigs, expectedPushFile)
+	deviceEntries := android.AndroidMkEntriesForTest(t, result.TestContext, device.Module())[0]
+	android.AssertStringPathRelativeToTopEquals(t, "device LOCAL_MODULE_PATH", result.Config,
+		"out/target/product/test_device/data/nativetest64/foo", deviceEntries.EntryMap["LOCAL_MODULE_PATH"][0])
+	android.AssertDeepEquals(t, "device LOCAL_TEST_DATA", []string(nil), deviceEntries.EntryMap["LOCAL_TEST_DATA"])
+
+	buildOS := result.Config.BuildOS.String()
+	host := result.ModuleForTests("foo", buildOS+"_x86_64")
+	hostConfigs := host.Rule("autogen").Args["extraConfigs"]
+	android.AssertStringDoesContain(t, "host extraConfigs", hostConfigs, expectedOption)
+	android.AssertStringDoesContain(t, "host extraConfigs", hostConfigs, expectedPushFile)
+	hostEntries := android.AndroidMkEntriesForTest(t, result.TestContext, host.Module())[0]
+	android.AssertStringPathRelativeToTopEquals(t, "host LOCAL_MODULE_PATH", result.Config,
+		"out/host/linux-x86/nativetest64/foo", hostEntries.EntryMap["LOCAL_MODULE_PATH"][0])
+	android.AssertStringPathsRelativeToTopEquals(t, "host LOCAL_TEST_DATA", result.Config,
+		[]string{"out/soong/.intermediates/bar/android_arm64_armv8-a/:bar"}, hostEntries.EntryMap["LOCAL_TEST_DATA"])
+}
@@ -59,6 +59,10 @@ type SnapshotPaths struct {
 
 	// Notice files of the snapshot output files
 	NoticeFiles android.Paths
+
+	// Files listing additional files to be included in the snapshot, whose names are only known
+	// when the snapshot is built.
+	ListFiles android.Paths
 }
 
 // Interface of function to capture snapshot from each module
@@ -78,6 +82,7 @@ func (c *SnapshotSingleton) GenerateBuildActions(ctx android.SingletonContext) {
 	}
 
 	var snapshotOutputs android.Paths
+	var snapshotListFiles android.Paths
 
 	// Snapshot zipped artifacts will be captured under {SNAPSHOT_ARCH} directory
 
@@ -94,6 +99,7 @@ func (c *SnapshotSingleton) GenerateBuildActions(ctx android.SingletonContext) {
 	for _, f := range snapshotActionList {
 		snapshotPaths := f(*c, ctx, snapshotArchDir)
 		snapshotOutputs = append(snapshotOutputs, snapshotPaths.OutputFiles...)
+		snapshotListFiles = append(snapshotListFiles, snapshotPaths.ListFiles...)
 		for _, notice := range snapshotPaths.NoticeFiles {
 			if _, ok := installedNotices[notice.String()]; !ok {
 				installedNotices[notice.String()] = true
@@ -128,11 +134,14 @@ func (c *SnapshotSingleton) GenerateBuildActions(ctx android.SingletonContext) {
 
 	zipRule.Temporary(snapshotOutputList)
 
-	zipRule.Command().
+	zipCmd := zipRule.Command().
 		BuiltTool("soong_zip").
 		FlagWithOutput("-o ", zipPath).
 		FlagWithArg("-C ", android.PathForOutput(ctx, snapshotDir).String()).
 		FlagWithInput("-l ", snapshotOutputList)
+	for _, listFile := range snapshotListFiles {
+		zipCmd.FlagWithInput("-l ", listFile)
+	}
 
 	zipRule.Build(zipPath.String(), c.name+" snapshot "+zipPath.String())
 	zipRule.DeleteTemporaryFiles()
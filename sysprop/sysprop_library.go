@@ -144,6 +144,11 @@ type syspropLibraryProperties struct {
 	// list of package names that will be documented and publicized as API
 	Api_packages []string
 
+	// List of vendor and odm modules that may link against the internal properties of this
+	// library, which is owned by Platform. Other vendor and odm modules can only link against its
+	// public properties.
+	Internal_access_allowlist []string
+
 	// If set to true, allow this module to be dexed and installed on devices.
 	Installable *bool
 
@@ -322,6 +327,23 @@ func (m *syspropLibrary) GenerateAndroidBuildActions(ctx android.ModuleContext)
 		Text("; exit 38) )").
 		Implicits(apiFileList)
 
+	// 3. checks that the .sysprop files are owned by the owner of the library, as the access to
+	// the properties from each partition is derived from property_owner.
+	for _, syspropFile := range android.PathsForModuleSrc(ctx, m.properties.Srcs) {
+		msg = fmt.Sprintf(`\n******************************\n`+
+			`%s of sysprop_library %s is not owned by %s\n`+
+			`Please fix the owner of the file or the property_owner of the library.\n`+
+			`******************************\n`, syspropFile.String(), baseModuleName, m.Owner())
+
+		rule.Command().
+			Text("( grep -qE").
+			Flag(fmt.Sprintf(`'^\s*owner\s*:\s*%s\s*$'`, m.Owner())).
+			Input(syspropFile).
+			Text("|| ( echo").Flag("-e").
+			Flag(`"` + msg + `"`).
+			Text("; exit 38) )")
+	}
+
 	m.checkApiFileTimeStamp = android.PathForModuleOut(ctx, "check_api.timestamp")
 
 	rule.Command().
@@ -428,6 +450,8 @@ type javaLibraryProperties struct {
 	SyspropPublicStub string
 	Apex_available    []string
 	Min_sdk_version   *string
+
+	SyspropInternalAccessAllowlist []string
 }
 
 func syspropLibraryHook(ctx android.LoadHookContext, m *syspropLibrary) {
@@ -472,6 +496,10 @@ func syspropLibraryHook(ctx android.LoadHookContext, m *syspropLibrary) {
 		ctx.PropertyErrorf("property_owner",
 			"Unknown value %s: must be one of Platform, Vendor or Odm", m.Owner())
 	}
+	if len(m.properties.Internal_access_allowlist) > 0 && !(isOwnerPlatform && installedInSystem) {
+		ctx.PropertyErrorf("internal_access_allowlist",
+			"only sysprop_library owned by Platform and installed in system can allow access to its internal properties")
+	}
 
 	// Generate a C++ implementation library.
 	// cc_library can receive *.sysprop files as their srcs, generating sources itself.
@@ -541,6 +569,8 @@ func syspropLibraryHook(ctx android.LoadHookContext, m *syspropLibrary) {
 		SyspropPublicStub: publicStub,
 		Apex_available:    m.ApexProperties.Apex_available,
 		Min_sdk_version:   m.properties.Java.Min_sdk_version,
+
+		SyspropInternalAccessAllowlist: m.properties.Internal_access_allowlist,
 	})
 
 	if publicStub != "" {
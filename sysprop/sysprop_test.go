@@ -32,7 +32,10 @@ func TestMain(m *testing.M) {
 
 func test(t *testing.T, bp string) *android.TestResult {
 	t.Helper()
+	return prepareForSyspropTest(bp).RunTest(t)
+}
 
+func prepareForSyspropTest(bp string) android.FixturePreparer {
 	bp += `
 		cc_library {
 			name: "libbase",
@@ -116,7 +119,7 @@ func test(t *testing.T, bp string) *android.TestResult {
 		"com/android2/OdmProperties.sysprop":         nil,
 	}
 
-	result := android.GroupFixturePreparers(
+	return android.GroupFixturePreparers(
 		cc.PrepareForTestWithCcDefaultModules,
 		java.PrepareForTestWithJavaDefaultModules,
 		PrepareForTestWithSyspropBuildComponents,
@@ -127,9 +130,7 @@ func test(t *testing.T, bp string) *android.TestResult {
 		}),
 		mockFS.AddToFixture(),
 		android.FixtureWithRootAndroidBp(bp),
-	).RunTest(t)
-
-	return result
+	)
 }
 
 func TestSyspropLibrary(t *testing.T) {
@@ -368,3 +369,74 @@ func TestMinSdkVersionIsForwarded(t *testing.T) {
 	propFromJava := javaModule.MinSdkVersionString()
 	android.AssertStringEquals(t, "min_sdk_version forwarding to java module", "30", propFromJava)
 }
+
+func TestSyspropLibraryOwnerCheck(t *testing.T) {
+	result := test(t, `
+		sysprop_library {
+			name: "sysprop-vendor",
+			srcs: ["com/android/VendorProperties.sysprop"],
+			api_packages: ["com.android"],
+			property_owner: "Vendor",
+			vendor: true,
+		}
+	`)
+
+	checkApi := result.ModuleForTests("sysprop-vendor_sysprop_library", "").Rule("sysprop-vendor_check_api")
+	android.AssertStringDoesContain(t, "check api", checkApi.RuleParams.Command,
+		`( grep -qE '^\s*owner\s*:\s*Vendor\s*$' com/android/VendorProperties.sysprop || ( echo -e`)
+}
+
+func TestSyspropLibraryInternalAccess(t *testing.T) {
+	bp := `
+		sysprop_library {
+			name: "sysprop-platform",
+			srcs: ["android/sysprop/PlatformProperties.sysprop"],
+			api_packages: ["android.sysprop"],
+			property_owner: "Platform",
+			internal_access_allowlist: ["java-vendor-allowed"],
+		}
+
+		java_library {
+			name: "java-vendor-system-api",
+			srcs: ["c.java"],
+			sdk_version: "system_current",
+			soc_specific: true,
+			libs: ["sysprop-platform"],
+		}
+
+		java_library {
+			name: "java-vendor-allowed",
+			srcs: ["c.java"],
+			platform_apis: true,
+			soc_specific: true,
+			libs: ["sysprop-platform"],
+		}
+
+		java_library {
+			name: "java-vendor-internal",
+			srcs: ["c.java"],
+			platform_apis: true,
+			soc_specific: true,
+			libs: ["sysprop-platform"],
+		}
+	`
+
+	prepareForSyspropTest(bp).
+		ExtendWithErrorHandler(android.FixtureExpectsOneErrorPattern(
+			`module "java-vendor-internal" variant "android_common": links against the internal properties of the platform sysprop_library "sysprop-platform" from the vendor partition group`)).
+		RunTest(t)
+
+	prepareForSyspropTest(`
+		sysprop_library {
+			name: "sysprop-vendor",
+			srcs: ["com/android/VendorProperties.sysprop"],
+			api_packages: ["com.android"],
+			property_owner: "Vendor",
+			vendor: true,
+			internal_access_allowlist: ["java-vendor"],
+		}
+	`).
+		ExtendWithErrorHandler(android.FixtureExpectsOneErrorPattern(
+			`module "sysprop-vendor_sysprop_library": internal_access_allowlist: only sysprop_library owned by Platform and installed in system can allow access to its internal properties`)).
+		RunTest(t)
+}
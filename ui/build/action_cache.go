@@ -0,0 +1,216 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	smpb "android/soong/ui/metrics/metrics_proto"
+)
+
+// This file maintains the opt-in local action cache that sbox fills with the outputs of the
+// sandboxed rules when SOONG_ACTION_CACHE_DIR is set. After each build it reports the hit rate
+// of the lookups sbox recorded and evicts the least recently used entries once the cache grows
+// over SOONG_ACTION_CACHE_MAX_SIZE_GB.
+
+const (
+	// The file sbox appends a line to for each lookup, see cmd/sbox/action_cache.go.
+	actionCacheStatsFileName = "stats.log"
+
+	defaultActionCacheMaxSizeGB = 20
+)
+
+// actionCacheEntry is a directory of the action cache holding the outputs of one sbox manifest.
+type actionCacheEntry struct {
+	dir string
+	// The total size of the outputs.
+	size int64
+	// The last time the entry was stored or used.
+	lastUsed time.Time
+}
+
+// readActionCacheStats counts the hits and misses recorded by sbox.
+func readActionCacheStats(file string) (hits, misses uint64, err error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		switch strings.TrimSpace(scanner.Text()) {
+		case "hit":
+			hits++
+		case "miss":
+			misses++
+		}
+	}
+	return hits, misses, scanner.Err()
+}
+
+// readActionCacheEntries returns the entries of the action cache. Leftover temporary directories
+// of sbox invocations that were killed are removed.
+func readActionCacheEntries(cacheDir string) ([]actionCacheEntry, error) {
+	shards, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []actionCacheEntry
+	for _, shard := range shards {
+		if !shard.IsDir() {
+			continue
+		}
+		shardDir := filepath.Join(cacheDir, shard.Name())
+		dirs, err := os.ReadDir(shardDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			entryDir := filepath.Join(shardDir, dir.Name())
+			if strings.Contains(dir.Name(), ".tmp") {
+				os.RemoveAll(entryDir)
+				continue
+			}
+			info, err := dir.Info()
+			if err != nil {
+				return nil, err
+			}
+			entry := actionCacheEntry{dir: entryDir, lastUsed: info.ModTime()}
+			err = filepath.WalkDir(entryDir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() {
+					info, err := d.Info()
+					if err != nil {
+						return err
+					}
+					entry.size += info.Size()
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// actionCacheEntriesToEvict returns the least recently used entries that have to be removed for
+// the cache to fit in maxSize bytes.
+func actionCacheEntriesToEvict(entries []actionCacheEntry, maxSize int64) []actionCacheEntry {
+	var size int64
+	for _, e := range entries {
+		size += e.size
+	}
+	if size <= maxSize {
+		return nil
+	}
+
+	sorted := append([]actionCacheEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].lastUsed.Before(sorted[j].lastUsed)
+	})
+	var evict []actionCacheEntry
+	for _, e := range sorted {
+		if size <= maxSize {
+			break
+		}
+		evict = append(evict, e)
+		size -= e.size
+	}
+	return evict
+}
+
+// actionCacheMaxSize returns the size in bytes that the action cache is trimmed to.
+func actionCacheMaxSize(ctx Context, config Config) int64 {
+	maxSizeGB := int64(defaultActionCacheMaxSizeGB)
+	if v, ok := config.Environment().Get("SOONG_ACTION_CACHE_MAX_SIZE_GB"); ok && v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			ctx.Fatalf("SOONG_ACTION_CACHE_MAX_SIZE_GB must be a number of gigabytes, got %q", v)
+		}
+		maxSizeGB = n
+	}
+	return maxSizeGB << 30
+}
+
+// collectActionCacheGarbage reports the hit rate of the action cache during the build and evicts
+// the least recently used entries. It must run while no sbox invocation is using the cache.
+func collectActionCacheGarbage(ctx Context, config Config) {
+	cacheDir, _ := config.Environment().Get("SOONG_ACTION_CACHE_DIR")
+	if cacheDir == "" {
+		return
+	}
+	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
+		return
+	}
+
+	ctx.BeginTrace("action_cache", "gc")
+	defer ctx.EndTrace()
+
+	statsFile := filepath.Join(cacheDir, actionCacheStatsFileName)
+	hits, misses, err := readActionCacheStats(statsFile)
+	if err != nil {
+		ctx.Verbosef("Failed to read the action cache stats: %s", err)
+	}
+	os.Remove(statsFile)
+
+	entries, err := readActionCacheEntries(cacheDir)
+	if err != nil {
+		ctx.Verbosef("Failed to read the action cache: %s", err)
+		return
+	}
+	evict := actionCacheEntriesToEvict(entries, actionCacheMaxSize(ctx, config))
+	var size, evictedSize int64
+	for _, e := range entries {
+		size += e.size
+	}
+	for _, e := range evict {
+		if err := os.RemoveAll(e.dir); err != nil {
+			ctx.Verbosef("Failed to evict %s from the action cache: %s", e.dir, err)
+		}
+		evictedSize += e.size
+	}
+
+	if hits+misses > 0 {
+		ctx.Verbosef("Action cache: %d hits, %d misses (%d%% hit rate)", hits, misses, hits*100/(hits+misses))
+	}
+	ctx.Verbosef("Action cache: evicted %d of %d entries, %d bytes in use", len(evict), len(entries), size-evictedSize)
+	if ctx.Metrics != nil {
+		ctx.Metrics.SetActionCacheInfo(&smpb.ActionCacheInfo{
+			Hits:           proto.Uint64(hits),
+			Misses:         proto.Uint64(misses),
+			NumEntries:     proto.Uint32(uint32(len(entries) - len(evict))),
+			SizeBytes:      proto.Uint64(uint64(size - evictedSize)),
+			EvictedEntries: proto.Uint32(uint32(len(evict))),
+		})
+	}
+}
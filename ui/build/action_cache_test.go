@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestActionCacheGarbageCollection(t *testing.T) {
+	cacheDir := t.TempDir()
+	now := time.Now()
+	write := func(entry string, size int, lastUsed time.Duration) {
+		dir := filepath.Join(cacheDir, entry[:2], entry)
+		os.MkdirAll(filepath.Join(dir, "0"), 0777)
+		if err := os.WriteFile(filepath.Join(dir, "0", "0"), make([]byte, size), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(dir, now.Add(-lastUsed), now.Add(-lastUsed)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("aa01", 100, 3*time.Hour)
+	write("aa02", 200, 1*time.Hour)
+	write("bb01", 300, 2*time.Hour)
+	write("bb02", 400, 0)
+	os.MkdirAll(filepath.Join(cacheDir, "cc", "cc01.tmp123"), 0777)
+	os.WriteFile(filepath.Join(cacheDir, actionCacheStatsFileName), []byte("hit\nmiss\nhit\nhit\n"), 0666)
+
+	hits, misses, err := readActionCacheStats(filepath.Join(cacheDir, actionCacheStatsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits != 3 || misses != 1 {
+		t.Errorf("expected 3 hits and 1 miss, got %d hits and %d misses", hits, misses)
+	}
+
+	entries, err := readActionCacheEntries(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "cc", "cc01.tmp123")); !os.IsNotExist(err) {
+		t.Errorf("expected the leftover temporary directory to be removed")
+	}
+
+	// The two least recently used entries have to go for the cache to fit in 700 bytes.
+	var evicted []string
+	for _, e := range actionCacheEntriesToEvict(entries, 700) {
+		evicted = append(evicted, filepath.Base(e.dir))
+	}
+	assertDeepEqual(t, []string{"aa01", "bb01"}, evicted)
+
+	if evict := actionCacheEntriesToEvict(entries, 1000); evict != nil {
+		t.Errorf("expected no entries to be evicted from a cache under the limit, got %v", evict)
+	}
+}
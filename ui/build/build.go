@@ -295,6 +295,9 @@ func Build(ctx Context, config Config) {
 
 	if what&RunSoong != 0 {
 		runSoong(ctx, config)
+		if config.ReportUnusedModules() {
+			reportUnusedModules(ctx, config)
+		}
 	}
 
 	if what&RunKati != 0 {
@@ -327,6 +330,7 @@ func Build(ctx Context, config Config) {
 			installCleanIfNecessary(ctx, config)
 		}
 		runNinjaForBuild(ctx, config)
+		collectActionCacheGarbage(ctx, config)
 	}
 
 	if what&RunDistActions != 0 {
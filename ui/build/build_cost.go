@@ -0,0 +1,273 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// This file attributes the durations of the actions of the primary ninja run to the modules that
+// own their outputs, and the modules to the teams listed in the nearest OWNERS file of their
+// directory. It writes the per-module and per-team build cost to build_cost.json and
+// build_cost.txt in the logs directory after each build.
+
+const (
+	buildCostJsonFileName = "build_cost.json"
+	buildCostTextFileName = "build_cost.txt"
+
+	unknownModule = "unknown"
+	unknownTeam   = "unknown"
+)
+
+// buildCostModule is a module that is attributed actions.
+type buildCostModule struct {
+	name string
+	// The directory of the module relative to the top of the tree, empty if unknown.
+	dir string
+}
+
+// buildCostAttributor maps the actions to their modules and the modules to their teams.
+type buildCostAttributor struct {
+	// The directories with an Android.bp file.
+	blueprintDirs map[string]bool
+	// The directories with an OWNERS file.
+	ownersDirs map[string]bool
+	// The module that installs each file, and the directory of each Make module, from
+	// module-info.json.
+	installedFiles map[string]buildCostModule
+	moduleDirs     map[string]string
+}
+
+// newBuildCostAttributor returns an attributor from the lists of Android.bp and OWNERS files
+// found in the tree, and from the contents of the module-info.json written by Make.
+func newBuildCostAttributor(blueprintFiles, ownersFiles []string, moduleInfo []byte) (*buildCostAttributor, error) {
+	a := &buildCostAttributor{
+		blueprintDirs:  make(map[string]bool),
+		ownersDirs:     make(map[string]bool),
+		installedFiles: make(map[string]buildCostModule),
+		moduleDirs:     make(map[string]string),
+	}
+	for _, file := range blueprintFiles {
+		a.blueprintDirs[filepath.Dir(file)] = true
+	}
+	for _, file := range ownersFiles {
+		a.ownersDirs[filepath.Dir(file)] = true
+	}
+
+	if len(moduleInfo) > 0 {
+		var modules map[string]struct {
+			Path      []string `json:"path"`
+			Installed []string `json:"installed"`
+		}
+		if err := json.Unmarshal(moduleInfo, &modules); err != nil {
+			return nil, fmt.Errorf("failed to parse module-info.json: %w", err)
+		}
+		for name, info := range modules {
+			m := buildCostModule{name: name}
+			if len(info.Path) > 0 {
+				m.dir = info.Path[0]
+				a.moduleDirs[name] = m.dir
+			}
+			for _, installed := range info.Installed {
+				a.installedFiles[installed] = m
+			}
+		}
+	}
+	return a, nil
+}
+
+// module returns the module of the action. Soong writes descriptions as "//<dir>:<module> ...",
+// else the module is found from the installed files of module-info.json, from the Soong
+// intermediates directory "<out>/soong/.intermediates/<dir>/<module>/..." or from the Make
+// intermediates directory "<module>_intermediates" of the outputs.
+func (a *buildCostAttributor) module(action *ninjaLogAction) buildCostModule {
+	if strings.HasPrefix(action.description, "//") {
+		label := strings.TrimPrefix(strings.Fields(action.description)[0], "//")
+		if dir, name, ok := strings.Cut(label, ":"); ok {
+			return buildCostModule{name: name, dir: dir}
+		}
+	}
+
+	for _, output := range action.outputs {
+		if m, ok := a.installedFiles[output]; ok {
+			return m
+		}
+	}
+
+	output := action.outputs[0]
+	if _, rest, ok := strings.Cut(output, "/soong/.intermediates/"); ok {
+		// The module directory is the longest prefix of the path that has an Android.bp file.
+		parts := strings.Split(rest, "/")
+		for i := len(parts) - 1; i > 0; i-- {
+			dir := strings.Join(parts[:i], "/")
+			if a.blueprintDirs[dir] {
+				return buildCostModule{name: parts[i], dir: dir}
+			}
+		}
+	}
+	for _, dir := range strings.Split(filepath.Dir(output), "/") {
+		if strings.HasSuffix(dir, "_intermediates") {
+			name := strings.TrimSuffix(dir, "_intermediates")
+			return buildCostModule{name: name, dir: a.moduleDirs[name]}
+		}
+	}
+	return buildCostModule{name: unknownModule}
+}
+
+// team returns the OWNERS file closest to the directory of the module, which identifies the team
+// that owns it.
+func (a *buildCostAttributor) team(m buildCostModule) string {
+	if m.dir == "" {
+		return unknownTeam
+	}
+	for dir := filepath.Clean(m.dir); ; dir = filepath.Dir(dir) {
+		if a.ownersDirs[dir] {
+			return filepath.Join(dir, "OWNERS")
+		}
+		if dir == "." || dir == "/" {
+			return unknownTeam
+		}
+	}
+}
+
+type buildCostEntry struct {
+	Name            string `json:"name"`
+	Dir             string `json:"dir,omitempty"`
+	Team            string `json:"team,omitempty"`
+	NumModules      int    `json:"num_modules,omitempty"`
+	NumActions      int    `json:"num_actions"`
+	TotalTimeMillis int64  `json:"total_time_millis"`
+}
+
+type buildCostReport struct {
+	Modules []buildCostEntry `json:"modules"`
+	Teams   []buildCostEntry `json:"teams"`
+}
+
+// attributeBuildCost returns the cost of the actions per module and per team, from the most
+// expensive.
+func attributeBuildCost(actions []*ninjaLogAction, a *buildCostAttributor) buildCostReport {
+	type cost struct {
+		entry buildCostEntry
+		total time.Duration
+	}
+	modules := make(map[buildCostModule]*cost)
+	teams := make(map[string]*cost)
+	for _, action := range actions {
+		m := a.module(action)
+		mc := modules[m]
+		if mc == nil {
+			mc = &cost{entry: buildCostEntry{Name: m.name, Dir: m.dir, Team: a.team(m)}}
+			modules[m] = mc
+			tc := teams[mc.entry.Team]
+			if tc == nil {
+				tc = &cost{entry: buildCostEntry{Name: mc.entry.Team}}
+				teams[mc.entry.Team] = tc
+			}
+			tc.entry.NumModules++
+		}
+		tc := teams[mc.entry.Team]
+		for _, c := range []*cost{mc, tc} {
+			c.entry.NumActions++
+			c.total += action.duration()
+		}
+	}
+
+	sorted := func(costs []*cost) []buildCostEntry {
+		sort.Slice(costs, func(i, j int) bool {
+			if costs[i].total != costs[j].total {
+				return costs[i].total > costs[j].total
+			}
+			if costs[i].entry.Name != costs[j].entry.Name {
+				return costs[i].entry.Name < costs[j].entry.Name
+			}
+			return costs[i].entry.Dir < costs[j].entry.Dir
+		})
+		ret := make([]buildCostEntry, len(costs))
+		for i, c := range costs {
+			ret[i] = c.entry
+			ret[i].TotalTimeMillis = c.total.Milliseconds()
+		}
+		return ret
+	}
+	var moduleCosts, teamCosts []*cost
+	for _, c := range modules {
+		moduleCosts = append(moduleCosts, c)
+	}
+	for _, c := range teams {
+		teamCosts = append(teamCosts, c)
+	}
+	return buildCostReport{Modules: sorted(moduleCosts), Teams: sorted(teamCosts)}
+}
+
+// formatBuildCost returns the human readable report of the topN most expensive modules and teams.
+func formatBuildCost(report buildCostReport, topN int) string {
+	var sb strings.Builder
+	for _, section := range []struct {
+		title   string
+		entries []buildCostEntry
+	}{{"teams", report.Teams}, {"modules", report.Modules}} {
+		fmt.Fprintf(&sb, "Most expensive %s:\n", section.title)
+		for _, e := range firstN(section.entries, topN) {
+			name := e.Name
+			if e.Dir != "" {
+				name = "//" + e.Dir + ":" + e.Name
+			}
+			fmt.Fprintf(&sb, "  %s %6d actions  %s\n",
+				formatNinjaLogDuration(time.Duration(e.TotalTimeMillis)*time.Millisecond), e.NumActions, name)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// writeBuildCostReport writes the cost of the actions per module and per team to the logs
+// directory.
+func writeBuildCostReport(ctx Context, config Config, actions []*ninjaLogAction) {
+	readList := func(name string) []string {
+		data, err := os.ReadFile(filepath.Join(config.FileListDir(), name))
+		if err != nil {
+			ctx.Verbosef("Failed to read %s, the build cost will be attributed to unknown modules: %s", name, err)
+		}
+		return strings.Fields(string(data))
+	}
+	// module-info.json doesn't exist in Soong-only builds.
+	moduleInfo, _ := os.ReadFile(filepath.Join(config.ProductOut(), "module-info.json"))
+	a, err := newBuildCostAttributor(readList("Android.bp.list"), readList("OWNERS.list"), moduleInfo)
+	if err != nil {
+		ctx.Verbosef("Not writing the build cost: %s", err)
+		return
+	}
+
+	report := attributeBuildCost(actions, a)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Fatalf("failed to marshal the build cost: %s", err)
+	}
+	for file, content := range map[string][]byte{
+		buildCostJsonFileName: data,
+		buildCostTextFileName: []byte(formatBuildCost(report, ninjaLogTopN)),
+	} {
+		if err := os.WriteFile(filepath.Join(config.LogsDir(), config.GetLogsPrefix()+file), content, 0666); err != nil {
+			ctx.Verbosef("Failed to write the build cost: %s", err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAttributeBuildCost(t *testing.T) {
+	a, err := newBuildCostAttributor(
+		[]string{"foo/Android.bp", "foo/bar/Android.bp"},
+		[]string{"foo/OWNERS", "vendor/OWNERS"},
+		[]byte(`{
+			"libbaz": {"path": ["vendor/baz"], "installed": ["out/target/product/test/vendor/lib64/libbaz.so"]},
+			"qux": {"path": ["qux"]}
+		}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	action := func(ms int, description string, outputs ...string) *ninjaLogAction {
+		return &ninjaLogAction{
+			outputs:     outputs,
+			end:         time.Duration(ms) * time.Millisecond,
+			description: description,
+		}
+	}
+	actions := []*ninjaLogAction{
+		action(100, "//foo:libfoo clang a.c", "out/soong/.intermediates/foo/libfoo/android_arm64/obj/a.o"),
+		action(200, "", "out/soong/.intermediates/foo/bar/libbar/android_arm64/libbar.so"),
+		action(300, "", "out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so"),
+		action(350, "", "out/target/product/test/vendor/lib64/libbaz.so"),
+		action(500, "", "out/target/product/test/obj/EXECUTABLES/qux_intermediates/qux"),
+		action(50, "", "out/build_date.txt"),
+	}
+
+	report := attributeBuildCost(actions, a)
+
+	var modules, teams []string
+	for _, e := range report.Modules {
+		modules = append(modules, e.Name+" "+e.Dir+" "+e.Team)
+	}
+	for _, e := range report.Teams {
+		teams = append(teams, e.Name)
+	}
+	assertDeepEqual(t, []string{
+		"qux qux unknown",
+		"libfoo foo foo/OWNERS",
+		"libbaz vendor/baz vendor/OWNERS",
+		"libbar foo/bar foo/OWNERS",
+		"unknown  unknown",
+	}, modules)
+	assertDeepEqual(t, []string{"foo/OWNERS", "unknown", "vendor/OWNERS"}, teams)
+
+	foo := report.Teams[0]
+	if foo.NumModules != 2 || foo.NumActions != 3 || foo.TotalTimeMillis != 600 {
+		t.Errorf("expected foo/OWNERS to own 2 modules with 3 actions taking 600ms, got %+v", foo)
+	}
+
+	text := formatBuildCost(report, 2)
+	for _, line := range []string{
+		"Most expensive teams:\n    0:01      3 actions  foo/OWNERS\n    0:01      2 actions  unknown\n",
+		"Most expensive modules:\n    0:01      1 actions  //qux:qux\n    0:00      2 actions  //foo:libfoo\n",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("expected the report to contain %q, got:\n%s", line, text)
+		}
+	}
+}
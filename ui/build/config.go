@@ -78,6 +78,7 @@ type configImpl struct {
 	queryview         bool
 	reportMkMetrics   bool // Collect and report mk2bp migration progress metrics.
 	soongDocs         bool
+	productValidate   bool // Only validate the product config
 	multitreeBuild    bool // This is a multitree build.
 	skipConfig        bool
 	skipKati          bool
@@ -89,6 +90,10 @@ type configImpl struct {
 	skipMetricsUpload bool
 	buildStartedTime  int64 // For metrics-upload-only - manually specify a build-started time
 	buildFromTextStub bool
+	verifyDeterminism bool // Build the targets twice and compare the outputs
+
+	reportUnusedModules bool   // Report the modules that nothing in the build uses
+	soongIdeModules     string // The modules of the project generated by soong_ide. Comma-delimited
 
 	// From the product config
 	katiArgs        []string
@@ -869,12 +874,18 @@ func (c *configImpl) parseArgs(ctx Context, args []string) {
 			}
 		} else if arg == "--build-from-text-stub" {
 			c.buildFromTextStub = true
+		} else if arg == "--verify-determinism" {
+			c.verifyDeterminism = true
+		} else if arg == "--report-unused-modules" {
+			c.reportUnusedModules = true
 		} else if strings.HasPrefix(arg, "--build-command=") {
 			buildCmd := strings.TrimPrefix(arg, "--build-command=")
 			// remove quotations
 			buildCmd = strings.TrimPrefix(buildCmd, "\"")
 			buildCmd = strings.TrimSuffix(buildCmd, "\"")
 			ctx.Metrics.SetBuildCommand([]string{buildCmd})
+		} else if strings.HasPrefix(arg, "--modules=") {
+			c.soongIdeModules = strings.TrimPrefix(arg, "--modules=")
 		} else if strings.HasPrefix(arg, "--bazel-force-enabled-modules=") {
 			c.bazelForceEnabledModules = strings.TrimPrefix(arg, "--bazel-force-enabled-modules=")
 		} else if strings.HasPrefix(arg, "--build-started-time-unix-millis=") {
@@ -927,6 +938,8 @@ func (c *configImpl) parseArgs(ctx Context, args []string) {
 			c.queryview = true
 		} else if arg == "soong_docs" {
 			c.soongDocs = true
+		} else if arg == "product-validate" {
+			c.productValidate = true
 		} else {
 			if arg == "checkbuild" {
 				c.checkbuild = true
@@ -1022,7 +1035,7 @@ func (c *configImpl) SoongBuildInvocationNeeded() bool {
 		return true
 	}
 
-	if !c.JsonModuleGraph() && !c.Bp2Build() && !c.Queryview() && !c.SoongDocs() && !c.ApiBp2build() {
+	if !c.JsonModuleGraph() && !c.Bp2Build() && !c.Queryview() && !c.SoongDocs() && !c.ApiBp2build() && !c.ProductValidate() {
 		// Command line was empty, the default Ninja target is built
 		return true
 	}
@@ -1113,6 +1126,12 @@ func (c *configImpl) SoongDocsHtml() string {
 	return shared.JoinPath(c.SoongOutDir(), "docs/soong_build.html")
 }
 
+// ProductValidateFile returns the path of the report written by soong_build with
+// --product_validate.
+func (c *configImpl) ProductValidateFile() string {
+	return shared.JoinPath(c.SoongOutDir(), "product_validate-"+c.TargetProduct()+".json")
+}
+
 func (c *configImpl) QueryviewMarkerFile() string {
 	return shared.JoinPath(c.SoongOutDir(), "queryview.marker")
 }
@@ -1125,6 +1144,24 @@ func (c *configImpl) ModuleGraphFile() string {
 	return shared.JoinPath(c.SoongOutDir(), "module-graph.json")
 }
 
+// UnusedModulesFile returns the path of the report written by soong_build with
+// --report-unused-modules. It must be kept in sync with android/unused_modules.go.
+func (c *configImpl) UnusedModulesFile() string {
+	return shared.JoinPath(c.SoongOutDir(), "unused_modules-"+c.TargetProduct()+".json")
+}
+
+// SoongTargetsListFile returns the path of the list of targets written by soong_build. It must be
+// kept in sync with android.SoongTargetsListFileName.
+func (c *configImpl) SoongTargetsListFile() string {
+	return shared.JoinPath(c.SoongOutDir(), "soong_targets.txt")
+}
+
+// ModuleGraphV2File returns the module graph in the version 2 format documented in
+// docs/module_graph.md, which soong_build writes next to the module graph.
+func (c *configImpl) ModuleGraphV2File() string {
+	return shared.JoinPath(c.SoongOutDir(), "module-graph-v2.json")
+}
+
 func (c *configImpl) ModuleActionsFile() string {
 	return shared.JoinPath(c.SoongOutDir(), "module-actions.json")
 }
@@ -1174,6 +1211,10 @@ func (c *configImpl) SoongDocs() bool {
 	return c.soongDocs
 }
 
+func (c *configImpl) ProductValidate() bool {
+	return c.productValidate
+}
+
 func (c *configImpl) IsVerbose() bool {
 	return c.verbose
 }
@@ -1214,6 +1255,14 @@ func (c *configImpl) BuildFromTextStub() bool {
 	return c.buildFromTextStub
 }
 
+func (c *configImpl) VerifyDeterminism() bool {
+	return c.verifyDeterminism
+}
+
+func (c *configImpl) ReportUnusedModules() bool {
+	return c.reportUnusedModules
+}
+
 func (c *configImpl) TargetProduct() string {
 	if v, ok := c.environ.Get("TARGET_PRODUCT"); ok {
 		return v
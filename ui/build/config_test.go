@@ -186,6 +186,25 @@ func TestConfigParseArgsVars(t *testing.T) {
 	}
 }
 
+func TestConfigParseArgsSoongIdeModules(t *testing.T) {
+	ctx := testContext()
+	defer logger.Recover(func(err error) {
+		t.Fatal(err)
+	})
+
+	c := &configImpl{
+		environ: &Environment{},
+	}
+	c.parseArgs(ctx, []string{"soong_ide", "--modules=libfoo,libbar"})
+
+	if want, got := "libfoo,libbar", c.soongIdeModules; want != got {
+		t.Errorf("soong_ide modules:\nwant: %q\n got: %q\n", want, got)
+	}
+	if want, got := []string{"soong_ide"}, c.arguments; !reflect.DeepEqual(want, got) {
+		t.Errorf("remaining arguments:\nwant: %q\n got: %q\n", want, got)
+	}
+}
+
 func TestConfigCheckTopDir(t *testing.T) {
 	ctx := testContext()
 	buildRootDir := filepath.Dir(srcDirFileCheck)
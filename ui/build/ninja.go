@@ -81,6 +81,9 @@ func useNinjaBuildLog(ctx Context, config Config, cmd *Cmd) {
 // environment variables. It's important to restrict the environment Ninja runs
 // for hermeticity reasons, and to avoid spurious rebuilds.
 func runNinjaForBuild(ctx Context, config Config) {
+	// Analyze the ninja log once the ninja reader below has read the status of all the actions.
+	defer analyzeNinjaLog(ctx, config, time.Now())
+
 	ctx.BeginTrace(metrics.PrimaryNinja, "ninja")
 	defer ctx.EndTrace()
 
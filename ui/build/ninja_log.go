@@ -0,0 +1,365 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	smpb "android/soong/ui/metrics/metrics_proto"
+	"android/soong/ui/status"
+)
+
+// This file analyzes the durations of the actions of the primary ninja run recorded in the
+// .ninja_log, so that build speed regressions can be triaged without external scripts. It writes
+// the critical path and the slowest actions, rules and modules to ninja_log_summary.txt in the
+// logs directory and to the soong_metrics.
+
+const (
+	ninjaLogSummaryFileName = "ninja_log_summary.txt"
+
+	// The number of slowest actions, rules and modules that are reported.
+	ninjaLogTopN = 20
+)
+
+// ninjaLogAction is an action of the last build recorded in the .ninja_log.
+type ninjaLogAction struct {
+	outputs []string
+	// Start and end times since ninja started.
+	start, end time.Duration
+	cmdHash    string
+
+	// The description and the inputs of the action, if the status of the build has them.
+	description string
+	inputs      []string
+}
+
+func (a *ninjaLogAction) duration() time.Duration {
+	return a.end - a.start
+}
+
+func (a *ninjaLogAction) String() string {
+	if a.description != "" {
+		return a.description
+	}
+	return strings.Join(a.outputs, " ")
+}
+
+// readNinjaLog returns the actions of the last build recorded in a .ninja_log, in the order they
+// finished.
+func readNinjaLog(r io.Reader) ([]*ninjaLogAction, error) {
+	var actions []*ninjaLogAction
+	var prev *ninjaLogAction
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if lineNum == 1 && !strings.HasPrefix(line, "# ninja log v") {
+			return nil, fmt.Errorf("not a ninja log, the first line is %q", line)
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		// ninja log: <start>	<end>	<restat>	<name>	<cmdhash>
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected 5 fields, found %d", lineNum, len(fields))
+		}
+		start, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start time: %s", lineNum, err)
+		}
+		end, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end time: %s", lineNum, err)
+		}
+		action := &ninjaLogAction{
+			outputs: []string{fields[3]},
+			start:   time.Duration(start) * time.Millisecond,
+			end:     time.Duration(end) * time.Millisecond,
+			cmdHash: fields[4],
+		}
+
+		switch {
+		case prev != nil && action.end < prev.end:
+			// Ninja appends the actions of a build to the log as they finish, with times since
+			// it started, so an action that finished earlier than the previous one starts a
+			// new build.
+			actions = nil
+		case prev != nil && action.start == prev.start && action.end == prev.end && action.cmdHash == prev.cmdHash:
+			// The outputs of an action are logged on consecutive lines.
+			prev.outputs = append(prev.outputs, action.outputs...)
+			continue
+		}
+		actions = append(actions, action)
+		prev = action
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// describeNinjaLogActions sets the descriptions and the inputs of the actions from the actions
+// that lookup returns for their outputs.
+func describeNinjaLogActions(actions []*ninjaLogAction, lookup func(output string) *status.Action) {
+	for _, a := range actions {
+		if s := lookup(a.outputs[0]); s != nil {
+			a.description = s.Description
+			a.inputs = s.Inputs
+		}
+	}
+}
+
+// ninjaLogActionModule returns the name of the module of the action. Soong writes descriptions
+// as "//<dir>:<module> <rule> ...", else the module is guessed from the Make intermediates
+// directory of the outputs.
+func ninjaLogActionModule(a *ninjaLogAction) string {
+	if strings.HasPrefix(a.description, "//") {
+		return strings.Fields(a.description)[0]
+	}
+	for _, dir := range strings.Split(filepath.Dir(a.outputs[0]), "/") {
+		if strings.HasSuffix(dir, "_intermediates") {
+			return strings.TrimSuffix(dir, "_intermediates")
+		}
+	}
+	return "unknown"
+}
+
+// ninjaLogActionRule returns the kind of the action, which is the first word after the module for
+// Soong descriptions and the prefix before the colon for Make descriptions, e.g.
+// "target C++: libfoo <= foo.cpp".
+func ninjaLogActionRule(a *ninjaLogAction) string {
+	if strings.HasPrefix(a.description, "//") {
+		if fields := strings.Fields(a.description); len(fields) > 1 {
+			return fields[1]
+		}
+	} else if i := strings.Index(a.description, ": "); i > 0 {
+		return a.description[:i]
+	}
+	return "unknown"
+}
+
+// ninjaLogCriticalPath returns the path of dependent actions with the longest sum of durations,
+// from the last action to the first, and that sum. Actions without known inputs don't depend on
+// any other action.
+func ninjaLogCriticalPath(actions []*ninjaLogAction) ([]*ninjaLogAction, time.Duration) {
+	type node struct {
+		action             *ninjaLogAction
+		cumulativeDuration time.Duration
+		input              *node
+	}
+	nodes := make(map[string]*node)
+	var max *node
+	// The actions are in the order they finished, so the actions that produced the inputs of an
+	// action are always visited before it.
+	for _, a := range actions {
+		n := &node{action: a, cumulativeDuration: a.duration()}
+		for _, input := range a.inputs {
+			if x := nodes[input]; x != nil && (n.input == nil || x.cumulativeDuration > n.input.cumulativeDuration) {
+				n.input = x
+			}
+		}
+		if n.input != nil {
+			n.cumulativeDuration += n.input.cumulativeDuration
+		}
+		for _, output := range a.outputs {
+			nodes[output] = n
+		}
+		if max == nil || n.cumulativeDuration > max.cumulativeDuration {
+			max = n
+		}
+	}
+	if max == nil {
+		return nil, 0
+	}
+	var path []*ninjaLogAction
+	for n := max; n != nil; n = n.input {
+		path = append(path, n.action)
+	}
+	return path, max.cumulativeDuration
+}
+
+type ninjaLogGroup struct {
+	name       string
+	numActions int
+	total      time.Duration
+}
+
+// groupNinjaLogActions returns the sums of the durations of the actions grouped by key, from the
+// slowest group.
+func groupNinjaLogActions(actions []*ninjaLogAction, key func(*ninjaLogAction) string) []ninjaLogGroup {
+	groups := make(map[string]*ninjaLogGroup)
+	for _, a := range actions {
+		name := key(a)
+		g := groups[name]
+		if g == nil {
+			g = &ninjaLogGroup{name: name}
+			groups[name] = g
+		}
+		g.numActions++
+		g.total += a.duration()
+	}
+	ret := make([]ninjaLogGroup, 0, len(groups))
+	for _, g := range groups {
+		ret = append(ret, *g)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].total != ret[j].total {
+			return ret[i].total > ret[j].total
+		}
+		return ret[i].name < ret[j].name
+	})
+	return ret
+}
+
+func firstN[T any](s []T, n int) []T {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func formatNinjaLogDuration(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%3d:%02d", seconds/60, seconds%60)
+}
+
+// summarizeNinjaLog returns the metrics and the human readable summary of the actions.
+func summarizeNinjaLog(actions []*ninjaLogAction, topN int) (*smpb.NinjaLogInfo, string) {
+	var total time.Duration
+	var wallStart, wallEnd time.Duration
+	for i, a := range actions {
+		total += a.duration()
+		if i == 0 || a.start < wallStart {
+			wallStart = a.start
+		}
+		if a.end > wallEnd {
+			wallEnd = a.end
+		}
+	}
+	criticalPath, criticalTime := ninjaLogCriticalPath(actions)
+
+	slowest := append([]*ninjaLogAction(nil), actions...)
+	sort.SliceStable(slowest, func(i, j int) bool {
+		return slowest[i].duration() > slowest[j].duration()
+	})
+	slowest = firstN(slowest, topN)
+	rules := firstN(groupNinjaLogActions(actions, ninjaLogActionRule), topN)
+	modules := firstN(groupNinjaLogActions(actions, ninjaLogActionModule), topN)
+
+	jobInfos := func(actions []*ninjaLogAction) []*smpb.JobInfo {
+		var ret []*smpb.JobInfo
+		for _, a := range actions {
+			ret = append(ret, &smpb.JobInfo{
+				ElapsedTimeMicros: proto.Uint64(uint64(a.duration().Microseconds())),
+				JobDescription:    proto.String(a.String()),
+			})
+		}
+		return ret
+	}
+	groupInfos := func(groups []ninjaLogGroup) []*smpb.ActionGroupInfo {
+		var ret []*smpb.ActionGroupInfo
+		for _, g := range groups {
+			ret = append(ret, &smpb.ActionGroupInfo{
+				Name:            proto.String(g.name),
+				NumActions:      proto.Uint32(uint32(g.numActions)),
+				TotalTimeMicros: proto.Uint64(uint64(g.total.Microseconds())),
+			})
+		}
+		return ret
+	}
+	info := &smpb.NinjaLogInfo{
+		NumActions:             proto.Uint32(uint32(len(actions))),
+		TotalActionTimeMicros:  proto.Uint64(uint64(total.Microseconds())),
+		CriticalPathTimeMicros: proto.Uint64(uint64(criticalTime.Microseconds())),
+		CriticalPath:           jobInfos(criticalPath),
+		SlowestActions:         jobInfos(slowest),
+		SlowestRules:           groupInfos(rules),
+		SlowestModules:         groupInfos(modules),
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d actions ran in %s, taking %s in total.\n",
+		len(actions), strings.TrimSpace(formatNinjaLogDuration(wallEnd-wallStart)),
+		strings.TrimSpace(formatNinjaLogDuration(total)))
+
+	fmt.Fprintf(&sb, "\nCritical path (%s):\n", strings.TrimSpace(formatNinjaLogDuration(criticalTime)))
+	for i := len(criticalPath) - 1; i >= 0; i-- {
+		fmt.Fprintf(&sb, "  %s %s\n", formatNinjaLogDuration(criticalPath[i].duration()), criticalPath[i])
+	}
+	fmt.Fprintf(&sb, "\nSlowest actions:\n")
+	for _, a := range slowest {
+		fmt.Fprintf(&sb, "  %s %s\n", formatNinjaLogDuration(a.duration()), a)
+	}
+	for _, groups := range []struct {
+		title  string
+		groups []ninjaLogGroup
+	}{{"rules", rules}, {"modules", modules}} {
+		fmt.Fprintf(&sb, "\nSlowest %s:\n", groups.title)
+		for _, g := range groups.groups {
+			fmt.Fprintf(&sb, "  %s %6d actions  %s\n", formatNinjaLogDuration(g.total), g.numActions, g.name)
+		}
+	}
+
+	return info, sb.String()
+}
+
+// analyzeNinjaLog writes the analysis of the actions of the ninja run that started at
+// ninjaStarted to the logs directory and to the metrics.
+func analyzeNinjaLog(ctx Context, config Config, ninjaStarted time.Time) {
+	ninjaLogFile := filepath.Join(config.OutDir(), ninjaLogFileName)
+	// Ninja doesn't write to the log when no action runs, in which case the actions in the log
+	// are the ones of a previous build.
+	if fi, err := os.Stat(ninjaLogFile); err != nil || fi.ModTime().Before(ninjaStarted) {
+		return
+	}
+	f, err := os.Open(ninjaLogFile)
+	if err != nil {
+		ctx.Verbosef("Failed to open the ninja log: %s", err)
+		return
+	}
+	defer f.Close()
+	actions, err := readNinjaLog(f)
+	if err != nil {
+		ctx.Verbosef("Failed to read the ninja log: %s", err)
+		return
+	}
+
+	if ctx.CriticalPath != nil {
+		describeNinjaLogActions(actions, ctx.CriticalPath.Action)
+	}
+	info, summary := summarizeNinjaLog(actions, ninjaLogTopN)
+
+	summaryFile := filepath.Join(config.LogsDir(), config.GetLogsPrefix()+ninjaLogSummaryFileName)
+	if err := os.WriteFile(summaryFile, []byte(summary), 0666); err != nil {
+		ctx.Verbosef("Failed to write the ninja log summary: %s", err)
+	}
+	if ctx.Metrics != nil {
+		ctx.Metrics.SetNinjaLogInfo(info)
+	}
+
+	writeBuildCostReport(ctx, config, actions)
+}
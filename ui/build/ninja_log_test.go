@@ -0,0 +1,157 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"strings"
+	"testing"
+
+	"android/soong/ui/status"
+)
+
+const testNinjaLog = `# ninja log v5
+0	100	0	out/old	1111
+0	50	0	out/soong/.intermediates/foo/libfoo/android_arm64/obj/a.o	aaaa
+0	70	0	out/soong/.intermediates/foo/libfoo/android_arm64/obj/b.o	bbbb
+70	270	0	out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so	cccc
+70	270	0	out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so.toc	cccc
+270	280	0	out/target/product/test/system/lib64/libfoo.so	eeee
+10	310	0	out/target/product/test/obj/JAVA_LIBRARIES/bar_intermediates/classes.jar	dddd
+`
+
+func TestReadNinjaLog(t *testing.T) {
+	actions, err := readNinjaLog(strings.NewReader(testNinjaLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, a := range actions {
+		got = append(got, strings.Join(a.outputs, " ")+" "+a.duration().String())
+	}
+	// The action of the previous build is dropped, and the outputs of an action are merged.
+	assertDeepEqual(t, []string{
+		"out/soong/.intermediates/foo/libfoo/android_arm64/obj/a.o 50ms",
+		"out/soong/.intermediates/foo/libfoo/android_arm64/obj/b.o 70ms",
+		"out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so.toc 200ms",
+		"out/target/product/test/system/lib64/libfoo.so 10ms",
+		"out/target/product/test/obj/JAVA_LIBRARIES/bar_intermediates/classes.jar 300ms",
+	}, got)
+
+	if _, err := readNinjaLog(strings.NewReader("0\t1\t0\tout/foo\n")); err == nil {
+		t.Errorf("expected an error for a file that is not a ninja log")
+	}
+}
+
+func TestSummarizeNinjaLog(t *testing.T) {
+	actions, err := readNinjaLog(strings.NewReader(testNinjaLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusActions := map[string]*status.Action{
+		"out/soong/.intermediates/foo/libfoo/android_arm64/obj/a.o": {
+			Description: "//foo:libfoo clang a.c",
+		},
+		"out/soong/.intermediates/foo/libfoo/android_arm64/obj/b.o": {
+			Description: "//foo:libfoo clang b.c",
+		},
+		"out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so": {
+			Description: "//foo:libfoo ld libfoo.so",
+			Inputs: []string{
+				"out/soong/.intermediates/foo/libfoo/android_arm64/obj/a.o",
+				"out/soong/.intermediates/foo/libfoo/android_arm64/obj/b.o",
+			},
+		},
+		"out/target/product/test/system/lib64/libfoo.so": {
+			Description: "//foo:libfoo install libfoo.so",
+			Inputs:      []string{"out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so"},
+		},
+	}
+	describeNinjaLogActions(actions, func(output string) *status.Action {
+		return statusActions[output]
+	})
+
+	info, summary := summarizeNinjaLog(actions, 2)
+
+	if g, w := info.GetNumActions(), uint32(5); g != w {
+		t.Errorf("expected %d actions, got %d", w, g)
+	}
+	if g, w := info.GetTotalActionTimeMicros(), uint64(630000); g != w {
+		t.Errorf("expected total action time %d, got %d", w, g)
+	}
+	// The Make action takes longer than any chain of the Soong actions.
+	if g, w := info.GetCriticalPathTimeMicros(), uint64(300000); g != w {
+		t.Errorf("expected critical path time %d, got %d", w, g)
+	}
+
+	var slowest []string
+	for _, j := range info.GetSlowestActions() {
+		slowest = append(slowest, j.GetJobDescription())
+	}
+	assertDeepEqual(t, []string{
+		"out/target/product/test/obj/JAVA_LIBRARIES/bar_intermediates/classes.jar",
+		"//foo:libfoo ld libfoo.so",
+	}, slowest)
+
+	var rules, modules []string
+	for _, g := range info.GetSlowestRules() {
+		rules = append(rules, g.GetName())
+	}
+	for _, g := range info.GetSlowestModules() {
+		modules = append(modules, g.GetName())
+	}
+	assertDeepEqual(t, []string{"unknown", "ld"}, rules)
+	assertDeepEqual(t, []string{"//foo:libfoo", "bar"}, modules)
+
+	for _, line := range []string{
+		"5 actions ran in 0:00, taking 0:01 in total.",
+		"Critical path (0:00):",
+		"    0:00 out/target/product/test/obj/JAVA_LIBRARIES/bar_intermediates/classes.jar\n",
+		"Slowest modules:\n    0:00      4 actions  //foo:libfoo\n",
+	} {
+		if !strings.Contains(summary, line) {
+			t.Errorf("expected the summary to contain %q, got:\n%s", line, summary)
+		}
+	}
+}
+
+func TestNinjaLogCriticalPath(t *testing.T) {
+	actions, err := readNinjaLog(strings.NewReader(testNinjaLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without the Make action, the critical path goes through the Soong actions.
+	actions = actions[:4]
+	actions[2].inputs = []string{
+		"out/soong/.intermediates/foo/libfoo/android_arm64/obj/a.o",
+		"out/soong/.intermediates/foo/libfoo/android_arm64/obj/b.o",
+	}
+	actions[3].inputs = []string{"out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so.toc"}
+
+	path, criticalTime := ninjaLogCriticalPath(actions)
+	var got []string
+	for _, a := range path {
+		got = append(got, a.outputs[0])
+	}
+	assertDeepEqual(t, []string{
+		"out/target/product/test/system/lib64/libfoo.so",
+		"out/soong/.intermediates/foo/libfoo/android_arm64/libfoo.so",
+		"out/soong/.intermediates/foo/libfoo/android_arm64/obj/b.o",
+	}, got)
+	if g, w := criticalTime.Milliseconds(), int64(280); g != w {
+		t.Errorf("expected critical path time %dms, got %dms", w, g)
+	}
+}
@@ -15,6 +15,7 @@
 package build
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -43,6 +44,7 @@ const (
 	queryviewTag         = "queryview"
 	apiBp2buildTag       = "api_bp2build"
 	soongDocsTag         = "soong_docs"
+	productValidateTag   = "product_validate"
 
 	// bootstrapEpoch is used to determine if an incremental build is incompatible with the current
 	// version of bootstrap and needs cleaning before continuing the build.  Increment this for
@@ -51,6 +53,25 @@ const (
 	bootstrapEpoch = 1
 )
 
+// The goals of the reports and checks of Soong singletons that visit all the modules, and the
+// environment variables that enable the singletons. soong_build only runs the singletons when their
+// goals are built, and reruns when they are built after a build without them, or the other way
+// around.
+var soongReportGoals = map[string]string{
+	"check-sdk-versions":     "SOONG_CHECK_SDK_VERSIONS",
+	"hidl-migration-report":  "SOONG_HIDL_MIGRATION_REPORT",
+	"visibility-owners-lint": "SOONG_VISIBILITY_OWNERS_LINT",
+}
+
+// setSoongReportGoalsEnv enables the singletons of the report goals that are built.
+func setSoongReportGoalsEnv(env *Environment, goals []string) {
+	for _, goal := range goals {
+		if name, ok := soongReportGoals[goal]; ok {
+			env.Set(name, "true")
+		}
+	}
+}
+
 func writeEnvironmentFile(_ Context, envFile string, envDeps map[string]string) error {
 	data, err := shared.EnvFileContents(envDeps)
 	if err != nil {
@@ -253,6 +274,7 @@ func bootstrapGlobFileList(config Config) []string {
 		config.NamedGlobFile(queryviewTag),
 		config.NamedGlobFile(apiBp2buildTag),
 		config.NamedGlobFile(soongDocsTag),
+		config.NamedGlobFile(productValidateTag),
 	}
 }
 
@@ -288,6 +310,12 @@ func bootstrapBlueprint(ctx Context, config Config) {
 	if config.buildFromTextStub {
 		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--build-from-text-stub")
 	}
+	if config.reportUnusedModules {
+		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--report-unused-modules")
+	}
+	if len(config.soongIdeModules) > 0 {
+		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--soong-ide-modules="+config.soongIdeModules)
+	}
 
 	queryviewDir := filepath.Join(config.SoongOutDir(), "queryview")
 	// The BUILD files will be generated in out/soong/.api_bp2build (no symlinks to src files)
@@ -347,6 +375,13 @@ func bootstrapBlueprint(ctx Context, config Config) {
 			output:       config.SoongDocsHtml(),
 			specificArgs: []string{"--soong_docs", config.SoongDocsHtml()},
 		},
+		{
+			name:         productValidateTag,
+			description:  fmt.Sprintf("validating the product config of %s", config.TargetProduct()),
+			config:       config,
+			output:       config.ProductValidateFile(),
+			specificArgs: []string{"--product_validate", config.ProductValidateFile()},
+		},
 	}
 
 	// Figure out which invocations will be run under the debugger:
@@ -473,6 +508,8 @@ func runSoong(ctx Context, config Config) {
 	soongBuildEnv.Set("LOG_DIR", config.LogsDir())
 	soongBuildEnv.Set("BAZEL_DEPS_FILE", absPath(ctx, filepath.Join(config.BazelOutDir(), "bazel.list")))
 
+	setSoongReportGoalsEnv(soongBuildEnv, config.Arguments())
+
 	// For Soong bootstrapping tests
 	if os.Getenv("ALLOW_MISSING_DEPENDENCIES") == "true" {
 		soongBuildEnv.Set("ALLOW_MISSING_DEPENDENCIES", "true")
@@ -508,6 +545,10 @@ func runSoong(ctx Context, config Config) {
 		if config.SoongDocs() {
 			checkEnvironmentFile(soongBuildEnv, config.UsedEnvFile(soongDocsTag))
 		}
+
+		if config.ProductValidate() {
+			checkEnvironmentFile(soongBuildEnv, config.UsedEnvFile(productValidateTag))
+		}
 	}()
 
 	runMicrofactory(ctx, config, "bpglob", "github.com/google/blueprint/bootstrap/bpglob",
@@ -593,6 +634,10 @@ func runSoong(ctx Context, config Config) {
 		targets = append(targets, config.SoongDocsHtml())
 	}
 
+	if config.ProductValidate() {
+		targets = append(targets, config.ProductValidateFile())
+	}
+
 	if config.SoongBuildInvocationNeeded() {
 		// This build generates <builddir>/build.ninja, which is used later by build/soong/ui/build/build.go#Build().
 		targets = append(targets, config.SoongNinjaFile())
@@ -610,7 +655,32 @@ func runSoong(ctx Context, config Config) {
 
 	if config.JsonModuleGraph() {
 		distGzipFile(ctx, config, config.ModuleGraphFile(), "soong")
+		distGzipFile(ctx, config, config.ModuleGraphV2File(), "soong")
+	}
+}
+
+// reportUnusedModules prints a summary of the report written by soong_build with
+// --report-unused-modules.
+func reportUnusedModules(ctx Context, config Config) {
+	data, err := os.ReadFile(config.UnusedModulesFile())
+	if err != nil {
+		ctx.Fatalf("failed to read the unused modules: %s", err)
+	}
+	var report struct {
+		ProductPackagesKnown bool `json:"product_packages_known"`
+		UnusedModules        []struct {
+			Name string `json:"name"`
+			Dir  string `json:"dir"`
+		} `json:"unused_modules"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		ctx.Fatalf("failed to parse %s: %s", config.UnusedModulesFile(), err)
+	}
+	if !report.ProductPackagesKnown {
+		ctx.Println("PRODUCT_PACKAGES were not provided to Soong, only the modules that are not installed are reported.")
 	}
+	ctx.Printf("%d modules are not reachable from the product, the tests or the dist targets, see %s",
+		len(report.UnusedModules), config.UnusedModulesFile())
 }
 
 func runMicrofactory(ctx Context, config Config, name string, pkg string, mapping map[string]string) {
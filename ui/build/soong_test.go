@@ -0,0 +1,35 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetSoongReportGoalsEnv(t *testing.T) {
+	env := &Environment{"TEST=1"}
+	setSoongReportGoalsEnv(env, []string{"droid", "hidl-migration-report"})
+	want := []string{"TEST=1", "SOONG_HIDL_MIGRATION_REPORT=true"}
+	if got := env.Environ(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	env = &Environment{"TEST=1"}
+	setSoongReportGoalsEnv(env, []string{"droid"})
+	if got := env.Environ(); !reflect.DeepEqual(got, []string{"TEST=1"}) {
+		t.Errorf("Expected the environment to be unchanged, got %q", got)
+	}
+}
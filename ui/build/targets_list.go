@@ -0,0 +1,76 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+// This file contains the functionality to list the targets known to the last
+// Soong analysis, used by `soong_ui --list-targets` and shell completion.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Target is a buildable target as recorded by soong_build in soong_targets.txt.
+type Target struct {
+	// Kind is one of "module", "test" or "phony".
+	Kind string
+	Name string
+}
+
+// ReadTargetsList reads the list of targets written by the last Soong analysis.
+func ReadTargetsList(config Config) ([]Target, error) {
+	f, err := os.Open(config.SoongTargetsListFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s does not exist, run a build (e.g. `m nothing`) first",
+				config.SoongTargetsListFile())
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return parseTargetsList(f)
+}
+
+func parseTargetsList(r io.Reader) ([]Target, error) {
+	var targets []Target
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed targets list at line %d: %q", line, scanner.Text())
+		}
+		targets = append(targets, Target{Kind: fields[0], Name: fields[1]})
+	}
+	return targets, scanner.Err()
+}
+
+// FilterTargets returns the targets whose name starts with prefix and, if kinds is not empty,
+// whose kind is one of kinds.
+func FilterTargets(targets []Target, prefix string, kinds []string) []Target {
+	var ret []Target
+	for _, t := range targets {
+		if !strings.HasPrefix(t.Name, prefix) {
+			continue
+		}
+		if len(kinds) > 0 && !inList(t.Kind, kinds) {
+			continue
+		}
+		ret = append(ret, t)
+	}
+	return ret
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAndFilterTargetsList(t *testing.T) {
+	targets, err := parseTargetsList(strings.NewReader(
+		"module\tlibfoo\n" +
+			"test\tlibfoo_test\n" +
+			"phony\tMODULES-IN-foo\n" +
+			"module\tlibbar\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		prefix string
+		kinds  []string
+		want   []Target
+	}{
+		{
+			name:   "prefix",
+			prefix: "libf",
+			want:   []Target{{"module", "libfoo"}, {"test", "libfoo_test"}},
+		},
+		{
+			name:   "prefix and kind",
+			prefix: "libf",
+			kinds:  []string{"test"},
+			want:   []Target{{"test", "libfoo_test"}},
+		},
+		{
+			name:  "kind only",
+			kinds: []string{"phony"},
+			want:  []Target{{"phony", "MODULES-IN-foo"}},
+		},
+		{
+			name:   "no match",
+			prefix: "libbaz",
+			want:   nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FilterTargets(targets, tc.prefix, tc.kinds); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("FilterTargets(%q, %q) = %v, want %v", tc.prefix, tc.kinds, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMalformedTargetsList(t *testing.T) {
+	if _, err := parseTargetsList(strings.NewReader("libfoo\n")); err == nil {
+		t.Error("expected an error for a line without a kind")
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// This file implements --verify-determinism, which builds the requested targets twice from
+// scratch in output directories with differently sized random names, and reports the actions
+// whose outputs differ between the two builds.
+
+const (
+	verifyDeterminismDirName  = "verify_determinism"
+	determinismReportFileName = "determinism_report.json"
+)
+
+// The ways in which an output can differ between the two builds.
+const (
+	// The output of the first build contains the path of its output directory.
+	determinismEmbedsOutDir = "embeds_out_dir"
+	// The contents of the output differ for another reason, e.g. an embedded timestamp.
+	determinismDiffers = "differs"
+	// The output was only created by the first build.
+	determinismMissing = "missing"
+)
+
+// determinismReport is the machine-readable result of --verify-determinism.
+type determinismReport struct {
+	Targets                 []string                 `json:"targets"`
+	OutDirs                 []string                 `json:"out_dirs"`
+	NumActions              int                      `json:"num_actions"`
+	NondeterministicActions []nondeterministicAction `json:"nondeterministic_actions"`
+}
+
+// nondeterministicAction is an action of the first build that had outputs different from the
+// ones of the second build.
+type nondeterministicAction struct {
+	// The outputs of the action relative to the output directory.
+	Outputs                 []string                 `json:"outputs"`
+	NondeterministicOutputs []nondeterministicOutput `json:"nondeterministic_outputs"`
+}
+
+type nondeterministicOutput struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+// compareDeterminismOutputs compares the outputs of the actions of the first build with the
+// same files of the second build. The outputs of the actions are relative to outDirs[0].
+func compareDeterminismOutputs(actions []*ninjaLogAction, outDirs [2]string) []nondeterministicAction {
+	var ret []nondeterministicAction
+	for _, action := range actions {
+		var rels []string
+		var outputs []nondeterministicOutput
+		for _, output := range action.outputs {
+			rel, err := filepath.Rel(outDirs[0], output)
+			if err != nil || strings.HasPrefix(rel, "../") {
+				// Outputs outside the output directory are the same file for both builds.
+				continue
+			}
+			rels = append(rels, rel)
+			if reason := compareDeterminismOutput(rel, outDirs); reason != "" {
+				outputs = append(outputs, nondeterministicOutput{File: rel, Reason: reason})
+			}
+		}
+		if len(outputs) > 0 {
+			ret = append(ret, nondeterministicAction{
+				Outputs:                 rels,
+				NondeterministicOutputs: outputs,
+			})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Outputs[0] < ret[j].Outputs[0]
+	})
+	return ret
+}
+
+// compareDeterminismOutput returns why the output differs between the two builds, or an empty
+// string if it is the same.
+func compareDeterminismOutput(rel string, outDirs [2]string) string {
+	var contents [2][]byte
+	for i, outDir := range outDirs {
+		file := filepath.Join(outDir, rel)
+		info, err := os.Lstat(file)
+		if err != nil {
+			if i == 0 {
+				// Outputs that the action removed, e.g. temporary files, can't be compared.
+				return ""
+			}
+			return determinismMissing
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(file)
+			if err != nil {
+				return determinismMissing
+			}
+			contents[i] = []byte(target)
+		case info.IsDir():
+			return ""
+		default:
+			contents[i], err = os.ReadFile(file)
+			if err != nil {
+				return determinismMissing
+			}
+		}
+	}
+
+	if bytes.Equal(contents[0], contents[1]) {
+		return ""
+	}
+	// The random names of the output directories only appear in outputs that embed their path.
+	if bytes.Contains(contents[0], []byte(filepath.Base(outDirs[0]))) {
+		return determinismEmbedsOutDir
+	}
+	return determinismDiffers
+}
+
+// VerifyDeterminism builds the targets of the command line twice in new output directories under
+// the output directory, and writes the actions whose outputs differ to determinism_report.json.
+func VerifyDeterminism(ctx Context, config Config) {
+	ctx.BeginTrace("soong_ui", "verify_determinism")
+	defer ctx.EndTrace()
+
+	targets := config.Arguments()
+	if len(targets) == 0 {
+		ctx.Fatalln("--verify-determinism requires the targets to build")
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		ctx.Fatalf("failed to find soong_ui: %s", err)
+	}
+
+	baseDir, err := filepath.Abs(filepath.Join(config.OutDir(), verifyDeterminismDirName))
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if err := os.RemoveAll(baseDir); err != nil {
+		ctx.Fatalf("failed to remove the output of the last verification: %s", err)
+	}
+	if err := os.MkdirAll(baseDir, 0777); err != nil {
+		ctx.Fatal(err)
+	}
+
+	// Name the output directories differently, and with different lengths, so that outputs that
+	// embed their path or its length differ.
+	var outDirs [2]string
+	for i, pattern := range []string{"out-*", "scrambled-out-dir-*"} {
+		outDirs[i], err = os.MkdirTemp(baseDir, pattern)
+		if err != nil {
+			ctx.Fatal(err)
+		}
+		ctx.Printf("Building %s in %s", strings.Join(targets, " "), outDirs[i])
+		cmd := Command(ctx, config, "soong_ui", executable,
+			append([]string{"--make-mode", "--skip-soong-tests"}, targets...)...)
+		cmd.Environment.Set("OUT_DIR", outDirs[i])
+		cmd.RunAndStreamOrFatal()
+	}
+
+	f, err := os.Open(filepath.Join(outDirs[0], ninjaLogFileName))
+	if err != nil {
+		ctx.Fatalf("failed to read the ninja log of the first build: %s", err)
+	}
+	actions, err := readNinjaLog(f)
+	f.Close()
+	if err != nil {
+		ctx.Fatalf("failed to read the ninja log of the first build: %s", err)
+	}
+
+	report := determinismReport{
+		Targets:                 targets,
+		OutDirs:                 outDirs[:],
+		NumActions:              len(actions),
+		NondeterministicActions: compareDeterminismOutputs(actions, outDirs),
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	reportFile := filepath.Join(baseDir, determinismReportFileName)
+	if err := os.WriteFile(reportFile, data, 0666); err != nil {
+		ctx.Fatalf("failed to write %s: %s", reportFile, err)
+	}
+
+	if len(report.NondeterministicActions) == 0 {
+		ctx.Printf("All %d actions are deterministic", len(actions))
+		for _, outDir := range outDirs {
+			os.RemoveAll(outDir)
+		}
+		return
+	}
+	for _, action := range report.NondeterministicActions {
+		for _, output := range action.NondeterministicOutputs {
+			ctx.Printf("  %s: %s", output.Reason, output.File)
+		}
+	}
+	ctx.Fatalf("%d of %d actions are nondeterministic, see %s", len(report.NondeterministicActions),
+		len(actions), reportFile)
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareDeterminismOutputs(t *testing.T) {
+	temp := t.TempDir()
+	outDirs := [2]string{filepath.Join(temp, "out-1234"), filepath.Join(temp, "scrambled-out-dir-5678")}
+	write := func(i int, file, content string) {
+		path := filepath.Join(outDirs[i], file)
+		os.MkdirAll(filepath.Dir(path), 0777)
+		if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeBoth := func(file, content0, content1 string) {
+		write(0, file, content0)
+		write(1, file, content1)
+	}
+
+	writeBoth("soong/same.txt", "same", "same")
+	writeBoth("soong/path.txt", "built in "+outDirs[0], "built in "+outDirs[1])
+	writeBoth("soong/date.txt", "built at 10:00", "built at 10:05")
+	writeBoth("soong/multi.a", "same", "same")
+	writeBoth("soong/multi.b", "1", "2")
+	write(0, "soong/missing.txt", "only in the first build")
+
+	actions := []*ninjaLogAction{
+		{outputs: []string{filepath.Join(outDirs[0], "soong/same.txt")}},
+		{outputs: []string{filepath.Join(outDirs[0], "soong/path.txt")}},
+		{outputs: []string{filepath.Join(outDirs[0], "soong/date.txt")}},
+		{outputs: []string{filepath.Join(outDirs[0], "soong/multi.a"), filepath.Join(outDirs[0], "soong/multi.b")}},
+		{outputs: []string{filepath.Join(outDirs[0], "soong/missing.txt")}},
+		// Outputs removed by the build and outputs outside the output directory are ignored.
+		{outputs: []string{filepath.Join(outDirs[0], "soong/removed.txt")}},
+		{outputs: []string{filepath.Join(temp, "outside.txt")}},
+	}
+
+	assertDeepEqual(t, []nondeterministicAction{
+		{
+			Outputs:                 []string{"soong/date.txt"},
+			NondeterministicOutputs: []nondeterministicOutput{{"soong/date.txt", determinismDiffers}},
+		},
+		{
+			Outputs:                 []string{"soong/missing.txt"},
+			NondeterministicOutputs: []nondeterministicOutput{{"soong/missing.txt", determinismMissing}},
+		},
+		{
+			Outputs:                 []string{"soong/multi.a", "soong/multi.b"},
+			NondeterministicOutputs: []nondeterministicOutput{{"soong/multi.b", determinismDiffers}},
+		},
+		{
+			Outputs:                 []string{"soong/path.txt"},
+			NondeterministicOutputs: []nondeterministicOutput{{"soong/path.txt", determinismEmbedsOutDir}},
+		},
+	}, compareDeterminismOutputs(actions, outDirs))
+}
@@ -130,6 +130,16 @@ func (m *Metrics) SetCriticalPathInfo(criticalPathInfo soong_metrics_proto.Criti
 	m.metrics.CriticalPathInfo = &criticalPathInfo
 }
 
+// SetNinjaLogInfo stores the action durations of the primary ninja run read from the .ninja_log.
+func (m *Metrics) SetNinjaLogInfo(ninjaLogInfo *soong_metrics_proto.NinjaLogInfo) {
+	m.metrics.NinjaLogInfo = ninjaLogInfo
+}
+
+// SetActionCacheInfo stores the statistics of the local action cache after the build.
+func (m *Metrics) SetActionCacheInfo(actionCacheInfo *soong_metrics_proto.ActionCacheInfo) {
+	m.metrics.ActionCacheInfo = actionCacheInfo
+}
+
 // SetFatalOrPanicMessage stores a non-zero exit and the relevant message in the latest event if
 // available or the metrics base.
 func (m *Metrics) SetFatalOrPanicMessage(errMsg string) {
@@ -102,6 +102,15 @@ func (cp *CriticalPath) FinishAction(action *Action) {
 	}
 }
 
+// Action returns the action that built the output during this build, or nil if the output wasn't
+// built.
+func (cp *CriticalPath) Action(output string) *Action {
+	if node := cp.nodes[output]; node != nil {
+		return node.action
+	}
+	return nil
+}
+
 func (cp *CriticalPath) criticalPath() (path []*node, elapsedTime time.Duration, criticalTime time.Duration) {
 	var max *node
 
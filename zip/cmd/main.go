@@ -164,6 +164,7 @@ func main() {
 	cpuProfile := flags.String("cpuprofile", "", "write cpu profile to file")
 	traceFile := flags.String("trace", "", "write trace to file")
 	sha256Checksum := flags.Bool("sha256", false, "add a zip header to each file containing its SHA256 digest")
+	sourceDateEpoch := flags.String("source_date_epoch", "", "the modification time of the entries, in seconds since the epoch")
 
 	flags.Var(&rootPrefix{}, "P", "path prefix within the zip at which to place files")
 	flags.Var(&listFiles{}, "l", "file containing list of files to zip")
@@ -226,6 +227,7 @@ func main() {
 		StoreSymlinks:            *symlinks,
 		IgnoreMissingFiles:       *ignoreMissingFiles,
 		Sha256Checksum:           *sha256Checksum,
+		SourceDateEpoch:          *sourceDateEpoch,
 	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err.Error())
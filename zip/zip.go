@@ -271,6 +271,7 @@ type ZipArgs struct {
 	StoreSymlinks            bool
 	IgnoreMissingFiles       bool
 	Sha256Checksum           bool
+	SourceDateEpoch          string
 
 	Stderr     io.Writer
 	Filesystem pathtools.FileSystem
@@ -285,7 +286,7 @@ func zipTo(args ZipArgs, w io.Writer) error {
 	followSymlinks := pathtools.ShouldFollowSymlinks(!args.StoreSymlinks)
 
 	z := &ZipWriter{
-		time:               jar.DefaultTime,
+		time:               jar.SourceDateTime(args.SourceDateEpoch),
 		createdDirs:        make(map[string]string),
 		createdFiles:       make(map[string]string),
 		directories:        args.AddDirectoryEntriesToZip,